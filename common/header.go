@@ -5,6 +5,7 @@ package common
 import (
 	"encoding/binary"
 	"errors"
+	"math/rand"
 	"sync/atomic"
 
 	"github.com/contiv/libOpenflow/util"
@@ -12,10 +13,72 @@ import (
 
 var messageXid uint32 = 1
 
+// ErrHeaderTooShort is returned when the data is shorter than an OpenFlow header.
+var ErrHeaderTooShort = errors.New("the []byte is too short to unmarshal a full OpenFlow header")
+
+// XidGenerator returns the xid of a new message. It is called concurrently, and should never return 0 as some
+// switches treat xid 0 specially.
+type XidGenerator func() uint32
+
+type xidGeneratorHolder struct {
+	generator XidGenerator
+}
+
+var xidGenerator atomic.Value
+
+// NewSequentialXidGenerator returns an XidGenerator which returns start, start+1, ... and skips 0 when it wraps
+// around. It could be used by tests which need deterministic xids.
+func NewSequentialXidGenerator(start uint32) XidGenerator {
+	next := start - 1
+	return func() uint32 {
+		for {
+			if xid := atomic.AddUint32(&next, 1); xid != 0 {
+				return xid
+			}
+		}
+	}
+}
+
+// NewRandomXidGenerator returns an XidGenerator which returns random non-zero xids.
+func NewRandomXidGenerator() XidGenerator {
+	return func() uint32 {
+		for {
+			if xid := rand.Uint32(); xid != 0 {
+				return xid
+			}
+		}
+	}
+}
+
+// SetXidGenerator sets the XidGenerator used by the header generators which are created by
+// NewHeaderGenerator. A nil generator restores the default behavior, i.e., a sequential xid shared by all the
+// header generators in the process, starting from 2.
+func SetXidGenerator(generator XidGenerator) {
+	xidGenerator.Store(xidGeneratorHolder{generator: generator})
+}
+
+// NextXid returns the xid of a new message from the XidGenerator set by SetXidGenerator.
+func NextXid() uint32 {
+	if holder, ok := xidGenerator.Load().(xidGeneratorHolder); ok && holder.generator != nil {
+		return holder.generator()
+	}
+	return atomic.AddUint32(&messageXid, 1)
+}
+
+// NewHeaderGenerator returns a function to create the headers of the given version, the xid of each header is
+// returned by NextXid.
 func NewHeaderGenerator(ver int) func() Header {
 	return func() Header {
-		xid := atomic.AddUint32(&messageXid, 1)
-		p := Header{uint8(ver), 0, 8, xid}
+		p := Header{uint8(ver), 0, 8, NextXid()}
+		return p
+	}
+}
+
+// NewHeaderGeneratorWithXid returns a function to create the headers of the given version, the xid of each
+// header is returned by the given XidGenerator instead of the package-level one.
+func NewHeaderGeneratorWithXid(ver int, generator XidGenerator) func() Header {
+	return func() Header {
+		p := Header{uint8(ver), 0, 8, generator()}
 		return p
 	}
 }
@@ -53,8 +116,8 @@ func (h *Header) MarshalBinary() (data []byte, err error) {
 }
 
 func (h *Header) UnmarshalBinary(data []byte) error {
-	if len(data) < 4 {
-		return errors.New("The []byte is too short to unmarshel a full HelloElemHeader.")
+	if len(data) < 8 {
+		return ErrHeaderTooShort
 	}
 	h.Version = data[0]
 	h.Type = data[1]
@@ -159,7 +222,7 @@ func (h *HelloElemVersionBitmap) UnmarshalBinary(data []byte) error {
 	read += int(h.HelloElemHeader.Len())
 
 	h.Bitmaps = make([]uint32, 0)
-	for read < length {
+	for read+4 <= length {
 		h.Bitmaps = append(h.Bitmaps, binary.BigEndian.Uint32(data[read:read+4]))
 		read += 4
 	}
@@ -195,7 +258,8 @@ func NewHello(ver int) (h *Hello, err error) {
 func (h *Hello) Len() (n uint16) {
 	n = h.Header.Len()
 	for _, e := range h.Elements {
-		n += e.Len()
+		// The elements are padded to a multiple of 8 bytes, the padding is not counted in their lengths.
+		n += (e.Len() + 7) / 8 * 8
 	}
 	return
 }
@@ -213,7 +277,7 @@ func (h *Hello) MarshalBinary() (data []byte, err error) {
 	for _, e := range h.Elements {
 		bytes, err = e.MarshalBinary()
 		copy(data[next:], bytes)
-		next += len(bytes)
+		next += (len(bytes) + 7) / 8 * 8
 	}
 	return
 }
@@ -221,20 +285,29 @@ func (h *Hello) MarshalBinary() (data []byte, err error) {
 func (h *Hello) UnmarshalBinary(data []byte) error {
 	next := 0
 	err := h.Header.UnmarshalBinary(data[next:])
+	if err != nil {
+		return err
+	}
 	next += int(h.Header.Len())
 
 	h.Elements = make([]HelloElem, 0)
 	for next < len(data) {
 		e := NewHelloElemHeader()
-		e.UnmarshalBinary(data[next:])
+		if err = e.UnmarshalBinary(data[next:]); err != nil {
+			return err
+		}
+		if e.Length < e.Len() || next+int(e.Length) > len(data) {
+			return errors.New("the []byte is too short to unmarshal a full HelloElem")
+		}
 
 		switch e.Type {
 		case HelloElemType_VersionBitmap:
 			v := NewHelloElemVersionBitmap()
-			err = v.UnmarshalBinary(data[next:])
-			next += int(v.Len())
+			err = v.UnmarshalBinary(data[next : next+int(e.Length)])
 			h.Elements = append(h.Elements, v)
 		}
+		// Skip the elements which are not supported. The elements are padded to a multiple of 8 bytes.
+		next += (int(e.Length) + 7) / 8 * 8
 	}
 	return err
 }
@@ -0,0 +1,36 @@
+package common
+
+import (
+	"testing"
+)
+
+func TestXidGenerator(t *testing.T) {
+	defer SetXidGenerator(nil)
+
+	SetXidGenerator(NewSequentialXidGenerator(0xfffffffe))
+	newHeader := NewHeaderGenerator(4)
+	for _, expected := range []uint32{0xfffffffe, 0xffffffff, 1, 2} {
+		if h := newHeader(); h.Xid != expected {
+			t.Errorf("Expect xid %d, actual: %d", expected, h.Xid)
+		}
+	}
+
+	SetXidGenerator(NewRandomXidGenerator())
+	for i := 0; i < 100; i++ {
+		if h := newHeader(); h.Xid == 0 {
+			t.Errorf("Random xid should not be 0")
+		}
+	}
+
+	SetXidGenerator(nil)
+	h1 := newHeader()
+	h2 := newHeader()
+	if h2.Xid != h1.Xid+1 {
+		t.Errorf("Expect sequential xids by default, actual: %d, %d", h1.Xid, h2.Xid)
+	}
+
+	newHeader = NewHeaderGeneratorWithXid(4, NewSequentialXidGenerator(100))
+	if h := newHeader(); h.Xid != 100 {
+		t.Errorf("Expect xid 100, actual: %d", h.Xid)
+	}
+}
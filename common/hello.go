@@ -0,0 +1,192 @@
+package common
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ofp_hello_failed_code, the codes of the OFPET_HELLO_FAILED error.
+const (
+	HelloFailedCode_Incompatible = iota /* No compatible version. */
+	HelloFailedCode_EPerm               /* Permissions error. */
+)
+
+const (
+	typeError            = 1 /* OFPT_ERROR, the same in all the versions. */
+	errorTypeHelloFailed = 0 /* OFPET_HELLO_FAILED. */
+)
+
+// NewHelloElemVersionBitmapOf returns the version bitmap element of the versions, e.g., 0x01 and 0x04 for
+// OpenFlow 1.0 and 1.3.
+func NewHelloElemVersionBitmapOf(versions ...uint8) *HelloElemVersionBitmap {
+	h := new(HelloElemVersionBitmap)
+	h.HelloElemHeader = *NewHelloElemHeader()
+	for _, v := range versions {
+		for int(v/32) >= len(h.Bitmaps) {
+			h.Bitmaps = append(h.Bitmaps, 0)
+		}
+		h.Bitmaps[v/32] |= 1 << (v % 32)
+	}
+	h.Length = h.Len()
+	return h
+}
+
+// Supports returns true if the version is set in the bitmaps.
+func (h *HelloElemVersionBitmap) Supports(version uint8) bool {
+	i := int(version / 32)
+	return i < len(h.Bitmaps) && h.Bitmaps[i]&(1<<(version%32)) != 0
+}
+
+// Versions returns the versions set in the bitmaps in the ascending order.
+func (h *HelloElemVersionBitmap) Versions() []uint8 {
+	var versions []uint8
+	for i, bitmap := range h.Bitmaps {
+		for bit := 0; bit < 32 && i*32+bit <= 0xff; bit++ {
+			if bitmap&(1<<bit) != 0 {
+				versions = append(versions, uint8(i*32+bit))
+			}
+		}
+	}
+	return versions
+}
+
+// NewHelloWithVersions returns the Hello of the versions, the version in the header is the highest one, and the
+// versions are in the version bitmap element.
+func NewHelloWithVersions(versions ...uint8) (*Hello, error) {
+	if len(versions) == 0 {
+		return nil, errors.New("no OpenFlow version for Hello")
+	}
+	h := new(Hello)
+	h.Header = NewHeaderGenerator(int(highestVersion(versions)))()
+	h.Elements = append(h.Elements, NewHelloElemVersionBitmapOf(versions...))
+	return h, nil
+}
+
+// VersionBitmap returns the version bitmap element of the Hello, or nil if there is none, e.g., the Hello of an
+// OpenFlow 1.0 switch.
+func (h *Hello) VersionBitmap() *HelloElemVersionBitmap {
+	for _, e := range h.Elements {
+		if v, ok := e.(*HelloElemVersionBitmap); ok {
+			return v
+		}
+	}
+	return nil
+}
+
+func highestVersion(versions []uint8) uint8 {
+	var highest uint8
+	for _, v := range versions {
+		if v > highest {
+			highest = v
+		}
+	}
+	return highest
+}
+
+// NegotiateVersion returns the version agreed with the peer which sent the hello. If both sides have the version
+// bitmap, it is the highest version in both bitmaps, otherwise it is the smaller one of our highest version and
+// the version in the header of the hello, which must be one of ours. If there is no such version, the
+// HelloFailed to send to the peer is returned instead.
+func NegotiateVersion(ourVersions []uint8, hello *Hello) (uint8, *HelloFailed) {
+	ours := NewHelloElemVersionBitmapOf(ourVersions...)
+	ourHighest := highestVersion(ourVersions)
+	var peerVersions string
+	if bitmap := hello.VersionBitmap(); bitmap != nil {
+		var agreed uint8
+		for _, v := range bitmap.Versions() {
+			if ours.Supports(v) {
+				agreed = v
+			}
+		}
+		if agreed != 0 {
+			return agreed, nil
+		}
+		peerVersions = fmt.Sprintf("peer supports versions %s", formatVersions(bitmap.Versions()))
+	} else {
+		agreed := hello.Version
+		if ourHighest < agreed {
+			agreed = ourHighest
+		}
+		if ours.Supports(agreed) {
+			return agreed, nil
+		}
+		peerVersions = fmt.Sprintf("peer supports version 0x%02x", hello.Version)
+	}
+
+	version := hello.Version
+	if ourHighest < version {
+		version = ourHighest
+	}
+	failed := NewHelloFailed(version, HelloFailedCode_Incompatible,
+		fmt.Sprintf("We support versions %s, %s", formatVersions(ours.Versions()), peerVersions))
+	failed.Xid = hello.Xid
+	return 0, failed
+}
+
+func formatVersions(versions []uint8) string {
+	s := make([]string, len(versions))
+	for i, v := range versions {
+		s[i] = fmt.Sprintf("0x%02x", v)
+	}
+	return strings.Join(s, ", ")
+}
+
+// HelloFailed is the OFPT_ERROR message of OFPET_HELLO_FAILED, Data is the ASCII text of the failure. It is
+// defined here as the version is not negotiated yet, and it is the same as ErrorMsg of the OpenFlow packages on
+// the wire.
+type HelloFailed struct {
+	Header
+	Code uint16
+	Data []byte
+}
+
+func NewHelloFailed(version uint8, code uint16, text string) *HelloFailed {
+	e := new(HelloFailed)
+	e.Header = NewHeaderGenerator(int(version))()
+	e.Header.Type = typeError
+	e.Code = code
+	e.Data = []byte(text)
+	e.Length = e.Len()
+	return e
+}
+
+func (e *HelloFailed) Len() (n uint16) {
+	return e.Header.Len() + 4 + uint16(len(e.Data))
+}
+
+func (e *HelloFailed) MarshalBinary() (data []byte, err error) {
+	e.Header.Length = e.Len()
+	data = make([]byte, int(e.Len()))
+	b, err := e.Header.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	binary.BigEndian.PutUint16(data[n:], errorTypeHelloFailed)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], e.Code)
+	n += 2
+	copy(data[n:], e.Data)
+	return
+}
+
+func (e *HelloFailed) UnmarshalBinary(data []byte) error {
+	if err := e.Header.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	n := int(e.Header.Len())
+	if int(e.Header.Length) < n+4 || int(e.Header.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full HelloFailed message")
+	}
+	if e.Header.Type != typeError || binary.BigEndian.Uint16(data[n:]) != errorTypeHelloFailed {
+		return fmt.Errorf("not a hello failed error message, type %d", e.Header.Type)
+	}
+	n += 2
+	e.Code = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	e.Data = make([]byte, int(e.Header.Length)-n)
+	copy(e.Data, data[n:e.Header.Length])
+	return nil
+}
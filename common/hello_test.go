@@ -0,0 +1,58 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNegotiateVersion(t *testing.T) {
+	hello, err := NewHelloWithVersions(0x01, 0x04, 0x06)
+	if err != nil {
+		t.Fatalf("Failed to create Hello: %v", err)
+	}
+	data, err := hello.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal Hello: %v", err)
+	}
+	if len(data) != 16 || data[0] != 0x06 {
+		t.Errorf("Unexpected Hello: %x", data)
+	}
+	peer := new(Hello)
+	if err = peer.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal Hello: %v", err)
+	}
+	if versions := peer.VersionBitmap().Versions(); len(versions) != 3 || versions[1] != 0x04 {
+		t.Errorf("Unexpected versions %v", versions)
+	}
+
+	if version, failed := NegotiateVersion([]uint8{0x04, 0x05}, peer); failed != nil || version != 0x04 {
+		t.Errorf("Expect version 0x04, actual: 0x%02x, %v", version, failed)
+	}
+	// The version in the header is used without the version bitmap.
+	peer.Elements = nil
+	if version, failed := NegotiateVersion([]uint8{0x04, 0x05}, peer); failed != nil || version != 0x05 {
+		t.Errorf("Expect version 0x05, actual: 0x%02x, %v", version, failed)
+	}
+	peer.Version = 0x02
+	if _, failed := NegotiateVersion([]uint8{0x04, 0x05}, peer); failed == nil {
+		t.Errorf("Expect HelloFailed without the common version")
+	}
+
+	peer, _ = NewHelloWithVersions(0x01, 0x02)
+	version, failed := NegotiateVersion([]uint8{0x04, 0x06}, peer)
+	if version != 0 || failed == nil || failed.Code != HelloFailedCode_Incompatible || failed.Xid != peer.Xid {
+		t.Fatalf("Unexpected HelloFailed: %+v", failed)
+	}
+	data, err = failed.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal HelloFailed: %v", err)
+	}
+	failed2 := new(HelloFailed)
+	if err = failed2.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal HelloFailed: %v", err)
+	}
+	if failed2.Type != 1 || failed2.Version != 0x02 ||
+		!strings.HasPrefix(string(failed2.Data), "We support versions 0x04, 0x06, peer supports versions 0x01, 0x02") {
+		t.Errorf("Unexpected HelloFailed: %+v, %s", failed2, failed2.Data)
+	}
+}
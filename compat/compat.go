@@ -0,0 +1,25 @@
+// Package compat has the constructors of libOpenflow with the signatures expected by the projects migrating
+// from contiv/ofnet, as thin wrappers of package openflow13, so that such projects could upgrade libOpenflow
+// without renaming the calls across their code base at once. The calls could be moved to openflow13 one at a
+// time after the upgrade. The constructors whose signatures are unchanged since contiv/ofnet, e.g.,
+// openflow13.NewNXActionRegLoad and openflow13.NewNXActionResubmitTableAction, are not duplicated here, and the
+// tests of the package make sure they keep their signatures.
+package compat
+
+import (
+	"github.com/contiv/libOpenflow/openflow13"
+)
+
+// NewRegMatchField returns the match field of NXM_NX_REG<idx> with the full 32 bits, same as
+// openflow13.NewRegMatchField(idx, data, nil).
+func NewRegMatchField(idx int, data uint32) *openflow13.MatchField {
+	return openflow13.NewRegMatchField(idx, data, nil)
+}
+
+// NewRegMatchFieldWithMask returns the match field of NXM_NX_REG<idx> with an arbitrary mask. The mask of
+// openflow13.NewRegMatchField is a contiguous range of bits, so the mask is set on the field instead.
+func NewRegMatchFieldWithMask(idx int, data uint32, mask uint32) *openflow13.MatchField {
+	field := openflow13.NewRegMatchField(idx, data, openflow13.NewNXRange(0, 31))
+	field.Mask = &openflow13.Uint32Message{Data: mask}
+	return field
+}
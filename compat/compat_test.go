@@ -0,0 +1,71 @@
+package compat
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/contiv/libOpenflow/openflow13"
+)
+
+// The constructors used by contiv/ofnet which are not wrapped, their signatures must stay the same in
+// openflow13.
+var (
+	_ func(uint16, *openflow13.MatchField, uint64) *openflow13.NXActionRegLoad                                 = openflow13.NewNXActionRegLoad
+	_ func(uint16, uint16, uint16, *openflow13.MatchField, *openflow13.MatchField) *openflow13.NXActionRegMove = openflow13.NewNXActionRegMove
+	_ func(uint16, uint8) *openflow13.NXActionResubmitTable                                                    = openflow13.NewNXActionResubmitTableAction
+	_ func(uint8, uint8, uint32) *openflow13.NXActionConjunction                                               = openflow13.NewNXActionConjunction
+	_ func() *openflow13.NXActionConnTrack                                                                     = openflow13.NewNXActionConnTrack
+	_ func() *openflow13.NXActionCTNAT                                                                         = openflow13.NewNXActionCTNAT
+	_ func(*openflow13.MatchField, uint16) *openflow13.NXActionOutputReg                                       = openflow13.NewOutputFromField
+	_ func(string, bool) (*openflow13.MatchField, error)                                                       = openflow13.FindFieldHeaderByName
+	_ func(*openflow13.CTStates) *openflow13.MatchField                                                        = openflow13.NewCTStateMatchField
+	_ func(uint32, *uint32) *openflow13.MatchField                                                             = openflow13.NewCTMarkMatchField
+	_ func([16]byte, *[16]byte) *openflow13.MatchField                                                         = openflow13.NewCTLabelMatchField
+	_ func(uint32) *openflow13.MatchField                                                                      = openflow13.NewConjIDMatchField
+	_ func(int, []byte, []byte) *openflow13.MatchField                                                         = openflow13.NewTunMetadataField
+	_ func(uint64, *uint64) *openflow13.MatchField                                                             = openflow13.NewMetadataField
+	_ func(net.IP, *net.IP) *openflow13.MatchField                                                             = openflow13.NewIpv4SrcField
+)
+
+func expectSameField(t *testing.T, expected, actual *openflow13.MatchField) {
+	t.Helper()
+	expectedData, err := expected.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal the expected field: %v", err)
+	}
+	actualData, err := actual.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal the field: %v", err)
+	}
+	if !bytes.Equal(actualData, expectedData) {
+		t.Errorf("Expect field %v, actual: %v", expectedData, actualData)
+	}
+}
+
+func TestNewRegMatchField(t *testing.T) {
+	for idx := 0; idx < 16; idx++ {
+		expectSameField(t, openflow13.NewRegMatchField(idx, 0x1234, nil), NewRegMatchField(idx, 0x1234))
+	}
+}
+
+func TestNewRegMatchFieldWithMask(t *testing.T) {
+	// A contiguous mask is the same as the range of openflow13.NewRegMatchField.
+	for idx := 0; idx < 16; idx++ {
+		expectSameField(t, openflow13.NewRegMatchField(idx, 0x120, openflow13.NewNXRange(4, 11)),
+			NewRegMatchFieldWithMask(idx, 0x120, 0xff0))
+	}
+
+	field := NewRegMatchFieldWithMask(3, 0x1001, 0xf00f)
+	data, err := field.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal reg3: %v", err)
+	}
+	header, _ := openflow13.FindFieldHeaderByName("NXM_NX_REG3", true)
+	header.Value = &openflow13.Uint32Message{Data: 0x1001}
+	header.Mask = &openflow13.Uint32Message{Data: 0xf00f}
+	expectSameField(t, header, field)
+	if len(data) != 12 {
+		t.Errorf("Expect reg3 with the mask in 12 bytes, actual: %d", len(data))
+	}
+}
@@ -0,0 +1,11 @@
+// Package integration is a suite of examples which install OpenFlow messages built with libOpenflow on a live
+// OVS bridge, and verify the result by reading it back with multipart requests. The tests act as the living
+// documentation of the library and as an interoperability check with OVS.
+//
+// The tests are skipped unless LIBOPENFLOW_OVS_TARGET is set to the OpenFlow connection of an OVS bridge, e.g.,
+// "unix:/var/run/openvswitch/br-test.mgmt", or "tcp:127.0.0.1:6653" after "ovs-vsctl set-controller br-test
+// ptcp:6653". The bridge must enable OpenFlow13, and the tests modify its flows, groups and meters:
+//
+//	ovs-vsctl add-br br-test -- set bridge br-test protocols=OpenFlow13
+//	LIBOPENFLOW_OVS_TARGET=unix:/var/run/openvswitch/br-test.mgmt go test ./examples/integration/
+package integration
@@ -0,0 +1,380 @@
+package integration
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/contiv/libOpenflow/common"
+	"github.com/contiv/libOpenflow/openflow13"
+	"github.com/contiv/libOpenflow/protocol"
+	"github.com/contiv/libOpenflow/util"
+)
+
+// targetEnv is the environment variable of the OpenFlow connection of the OVS bridge under test.
+const targetEnv = "LIBOPENFLOW_OVS_TARGET"
+
+const (
+	testCookie  = 0x1b0f10000000001
+	testGroupID = 1000
+	testMeterID = 1000
+)
+
+// ovsConn is a synchronous OpenFlow connection to OVS, every request is followed by a barrier so that the
+// replies and errors of the request are collected before the request returns.
+type ovsConn struct {
+	t       *testing.T
+	conn    net.Conn
+	version uint8
+}
+
+// connect connects to the bridge in LIBOPENFLOW_OVS_TARGET and negotiates OpenFlow 1.3, or skips the test if
+// the variable is not set.
+func connect(t *testing.T) *ovsConn {
+	return connectVersion(t, openflow13.VERSION)
+}
+
+// connectVersion is connect negotiating the OpenFlow version, the requests of the test must carry the version.
+func connectVersion(t *testing.T, version uint8) *ovsConn {
+	target := os.Getenv(targetEnv)
+	if target == "" {
+		t.Skipf("%s is not set, skip the test with live OVS", targetEnv)
+	}
+	parts := strings.SplitN(target, ":", 2)
+	if len(parts) != 2 {
+		t.Fatalf("Invalid %s %q, expect unix:<path> or tcp:<ip>:<port>", targetEnv, target)
+	}
+	conn, err := net.DialTimeout(parts[0], parts[1], 5*time.Second)
+	if err != nil {
+		t.Fatalf("Failed to connect to %s: %v", target, err)
+	}
+	c := &ovsConn{t: t, conn: conn, version: version}
+	t.Cleanup(func() { conn.Close() })
+
+	hello, _ := common.NewHello(int(version))
+	c.send(hello)
+	if msg := c.recv(); msg == nil {
+		t.Fatalf("Failed to receive Hello from %s", target)
+	} else if _, ok := msg.(*common.Hello); !ok {
+		t.Fatalf("Expect Hello from %s, actual: %T", target, msg)
+	}
+	return c
+}
+
+func (c *ovsConn) send(msgs ...util.Message) {
+	c.t.Helper()
+	for _, msg := range msgs {
+		data, err := msg.MarshalBinary()
+		if err != nil {
+			c.t.Fatalf("Failed to marshal %T: %v", msg, err)
+		}
+		c.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+		if _, err = c.conn.Write(data); err != nil {
+			c.t.Fatalf("Failed to send %T: %v", msg, err)
+		}
+	}
+}
+
+func (c *ovsConn) recv() util.Message {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(c.conn, header); err != nil {
+		c.t.Fatalf("Failed to read message header: %v", err)
+	}
+	data := make([]byte, binary.BigEndian.Uint16(header[2:]))
+	copy(data, header)
+	if _, err := io.ReadFull(c.conn, data[8:]); err != nil {
+		c.t.Fatalf("Failed to read message body: %v", err)
+	}
+	if data[1] == openflow13.Type_Hello {
+		hello := new(common.Hello)
+		if err := hello.UnmarshalBinary(data); err != nil {
+			c.t.Fatalf("Failed to parse Hello: %v", err)
+		}
+		return hello
+	}
+	msg, err := openflow13.Parse(data)
+	if err != nil {
+		c.t.Fatalf("Failed to parse message type %d: %v", data[1], err)
+	}
+	return msg
+}
+
+// transact sends msgs followed by a barrier, and returns the replies received before the barrier reply. The test
+// fails if OVS replies an error.
+func (c *ovsConn) transact(msgs ...util.Message) []util.Message {
+	c.t.Helper()
+	barrier := openflow13.NewOfp13Header()
+	barrier.Version = c.version
+	barrier.Type = openflow13.Type_BarrierRequest
+	c.send(append(msgs, &barrier)...)
+	var replies []util.Message
+	for {
+		msg := c.recv()
+		switch m := msg.(type) {
+		case *openflow13.ErrorMsg:
+			c.t.Fatalf("OVS replied error type %d code %d", m.Type, m.Code)
+		case *openflow13.VendorError:
+			c.t.Fatalf("OVS replied experimenter error 0x%x type %d code %d", m.ExperimenterID, m.Type, m.Code)
+		case *common.Header:
+			if m.Type == openflow13.Type_BarrierReply && m.Xid == barrier.Xid {
+				return replies
+			}
+		}
+		replies = append(replies, msg)
+	}
+}
+
+// multipart sends the multipart request, and returns the bodies of all the replies.
+func (c *ovsConn) multipart(mpType uint16, body util.Message) []util.Message {
+	c.t.Helper()
+	req := &openflow13.MultipartRequest{Header: openflow13.NewOfp13Header(), Type: mpType, Body: body}
+	req.Header.Version = c.version
+	req.Header.Type = openflow13.Type_MultiPartRequest
+	var bodies []util.Message
+	for _, msg := range c.transact(req) {
+		if reply, ok := msg.(*openflow13.MultipartReply); ok && reply.Xid == req.Xid {
+			bodies = append(bodies, reply.Body...)
+		}
+	}
+	return bodies
+}
+
+// dumpTestFlows returns the flows with testCookie in all the tables.
+func (c *ovsConn) dumpTestFlows() []*openflow13.FlowStats {
+	c.t.Helper()
+	req := openflow13.NewFlowStatsRequest()
+	req.TableId = openflow13.OFPTT_ALL
+	req.Cookie = testCookie
+	req.CookieMask = 0xffffffffffffffff
+	var flows []*openflow13.FlowStats
+	for _, body := range c.multipart(openflow13.MultipartType_Flow, req) {
+		flows = append(flows, body.(*openflow13.FlowStats))
+	}
+	return flows
+}
+
+// deleteTestFlows deletes the flows with testCookie when the test finishes.
+func (c *ovsConn) deleteTestFlows() {
+	c.t.Cleanup(func() {
+		flow := openflow13.NewFlowMod()
+		flow.Header.Version = c.version
+		flow.Command = openflow13.FC_DELETE
+		flow.TableId = openflow13.OFPTT_ALL
+		flow.Cookie = testCookie
+		flow.CookieMask = 0xffffffffffffffff
+		c.transact(flow)
+	})
+}
+
+func newTestFlow(priority uint16, fields ...*openflow13.MatchField) *openflow13.FlowMod {
+	flow := openflow13.NewFlowMod()
+	flow.Cookie = testCookie
+	flow.Priority = priority
+	for _, f := range fields {
+		flow.Match.AddField(*f)
+	}
+	return flow
+}
+
+func TestFlowMod(t *testing.T) {
+	c := connect(t)
+	c.deleteTestFlows()
+
+	flow := newTestFlow(100,
+		openflow13.NewEthTypeField(protocol.IPv4_MSG),
+		openflow13.NewIpProtoField(protocol.Type_TCP),
+		openflow13.NewTcpDstField(80))
+	instr := openflow13.NewInstrApplyActions()
+	instr.AddAction(openflow13.NewActionOutput(openflow13.P_NORMAL), false)
+	flow.AddInstruction(instr)
+	c.transact(flow)
+
+	flows := c.dumpTestFlows()
+	if len(flows) != 1 {
+		t.Fatalf("Expect 1 flow, actual: %d", len(flows))
+	}
+	if flows[0].Priority != 100 || len(flows[0].Match.Fields) != 3 || len(flows[0].Instructions) != 1 {
+		t.Errorf("Unexpected flow: %+v", flows[0])
+	}
+}
+
+func TestGroupMod(t *testing.T) {
+	c := connect(t)
+
+	group := openflow13.NewGroupMod()
+	group.GroupId = testGroupID
+	group.Type = openflow13.OFPGT_ALL
+	bucket := openflow13.NewBucket()
+	bucket.AddAction(openflow13.NewActionOutput(openflow13.P_LOCAL))
+	group.AddBucket(*bucket)
+	c.transact(group)
+	t.Cleanup(func() {
+		group := openflow13.NewGroupMod()
+		group.Command = openflow13.OFPGC_DELETE
+		group.GroupId = testGroupID
+		c.transact(group)
+	})
+
+	// The group desc reply is not decoded by the library, check the group_id of each ofp_group_desc.
+	found := false
+	for _, body := range c.multipart(openflow13.MultipartType_GroupDesc, util.NewBuffer(nil)) {
+		data := body.(*util.Buffer).Bytes()
+		for len(data) >= 8 {
+			length := binary.BigEndian.Uint16(data)
+			if binary.BigEndian.Uint32(data[4:]) == testGroupID {
+				found = data[2] == openflow13.OFPGT_ALL
+			}
+			if length < 8 || int(length) > len(data) {
+				break
+			}
+			data = data[length:]
+		}
+	}
+	if !found {
+		t.Errorf("Group %d is not found in the group desc reply", testGroupID)
+	}
+}
+
+func TestMeterMod(t *testing.T) {
+	c := connect(t)
+
+	meter := openflow13.NewMeterMod()
+	meter.MeterId = testMeterID
+	meter.Flags = openflow13.OFPMF13_KBPS
+	band := &openflow13.MeterBandDrop{MeterBandHeader: *openflow13.NewMeterBandHeader()}
+	band.Type = openflow13.OFPMBT13_DROP
+	band.Rate = 1000
+	meter.AddMeterBand(band)
+	c.transact(meter)
+	t.Cleanup(func() {
+		meter := openflow13.NewMeterMod()
+		meter.Command = openflow13.OFPMC_DELETE
+		meter.MeterId = testMeterID
+		c.transact(meter)
+	})
+
+	found := false
+	for _, body := range c.multipart(openflow13.MultipartType_MeterConfig, openflow13.NewMeterMultipartRequest(testMeterID)) {
+		desc := body.(*openflow13.MeterDesc)
+		if desc.MeterId == testMeterID {
+			found = desc.Flags&openflow13.OFPMF13_KBPS != 0
+			t.Logf("dump-meters:\n%s", openflow13.FormatMeterDesc(desc))
+		}
+	}
+	if !found {
+		t.Errorf("Meter %d is not found in the meter config reply", testMeterID)
+	}
+}
+
+func TestBundle(t *testing.T) {
+	c := connect(t)
+	c.deleteTestFlows()
+
+	msgs := []util.Message{
+		newTestFlow(100, openflow13.NewEthTypeField(protocol.IPv4_MSG)),
+		newTestFlow(100, openflow13.NewEthTypeField(protocol.IPv6_MSG)),
+	}
+	err := openflow13.CommitBundleWithRetry(msgs, openflow13.OFPBCT_ATOMIC, func(bundleID uint32, bundleMsgs []util.Message) error {
+		c.transact(bundleMsgs...)
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to commit bundle: %v", err)
+	}
+
+	if flows := c.dumpTestFlows(); len(flows) != 2 {
+		t.Errorf("Expect 2 flows installed by the bundle, actual: %d", len(flows))
+	}
+}
+
+func TestPacketOut(t *testing.T) {
+	c := connect(t)
+	c.deleteTestFlows()
+
+	// The flow counts the ARP packets from the local port.
+	c.transact(newTestFlow(200,
+		openflow13.NewInPortField(openflow13.P_LOCAL),
+		openflow13.NewEthTypeField(protocol.ARP_MSG)))
+
+	arp, _ := protocol.NewARP(protocol.Type_Request)
+	eth := protocol.NewEthernet()
+	eth.HWDst, _ = net.ParseMAC("ff:ff:ff:ff:ff:ff")
+	eth.HWSrc, _ = net.ParseMAC("00:00:00:00:00:01")
+	eth.Ethertype = protocol.ARP_MSG
+	eth.Data = arp
+	packetOut, err := openflow13.NewPacketOutToTable(openflow13.P_LOCAL, eth)
+	if err != nil {
+		t.Fatalf("Failed to build PacketOut: %v", err)
+	}
+	if err = packetOut.Validate(); err != nil {
+		t.Fatalf("Invalid PacketOut: %v", err)
+	}
+	c.transact(packetOut)
+
+	flows := c.dumpTestFlows()
+	if len(flows) != 1 || flows[0].PacketCount != 1 {
+		t.Errorf("Expect the PacketOut to hit the flow once, actual flows: %+v", flows)
+	}
+}
+
+// flowUpdates returns the flow updates in the flow monitor replies of msgs.
+func flowUpdates(msgs []util.Message) []*openflow13.FlowUpdateFull {
+	var updates []*openflow13.FlowUpdateFull
+	for _, msg := range msgs {
+		if reply, ok := msg.(*openflow13.MultipartReply); ok && reply.Type == openflow13.MultipartType_FlowMonitor {
+			for _, body := range reply.Body {
+				if update, ok := body.(*openflow13.FlowUpdateFull); ok {
+					updates = append(updates, update)
+				}
+			}
+		}
+	}
+	return updates
+}
+
+func TestFlowMonitor(t *testing.T) {
+	// The flow monitor is a multipart request of OpenFlow 1.4 and later.
+	c := connectVersion(t, openflow13.OFP15_VERSION)
+	c.deleteTestFlows()
+
+	flow := newTestFlow(100, openflow13.NewEthTypeField(protocol.IPv4_MSG))
+	flow.Header.Version = c.version
+	c.transact(flow)
+
+	req := openflow13.NewFlowMonitorRequest(1, openflow13.OFPFMF_INITIAL|openflow13.OFPFMF_ADD|openflow13.OFPFMF_NO_ABBREV)
+	var initial []*openflow13.FlowUpdateFull
+	for _, body := range c.multipart(openflow13.MultipartType_FlowMonitor, req) {
+		if update, ok := body.(*openflow13.FlowUpdateFull); ok && update.Cookie == testCookie {
+			initial = append(initial, update)
+		}
+	}
+	if len(initial) != 1 || initial[0].Event != openflow13.OFPFME_INITIAL || initial[0].Priority != 100 {
+		t.Fatalf("Expect the initial update of the test flow, actual: %+v", initial)
+	}
+
+	flow = newTestFlow(200, openflow13.NewEthTypeField(protocol.ARP_MSG))
+	flow.Header.Version = c.version
+	updates := flowUpdates(c.transact(flow))
+	if len(updates) != 1 || updates[0].Event != openflow13.OFPFME_ADDED || updates[0].Priority != 200 {
+		t.Errorf("Expect the added update of the test flow, actual: %+v", updates)
+	}
+
+	cancel := openflow13.NewFlowMonitorRequest(1, 0)
+	cancel.Command = openflow13.OFPFMC_DELETE
+	mpReq := &openflow13.MultipartRequest{Header: openflow13.NewOfp13Header(), Type: openflow13.MultipartType_FlowMonitor, Body: cancel}
+	mpReq.Header.Version = c.version
+	mpReq.Header.Type = openflow13.Type_MultiPartRequest
+	c.transact(mpReq)
+
+	flow = newTestFlow(300, openflow13.NewEthTypeField(protocol.IPv6_MSG))
+	flow.Header.Version = c.version
+	if updates = flowUpdates(c.transact(flow)); len(updates) != 0 {
+		t.Errorf("Expect no update after the monitor is cancelled, actual: %+v", updates)
+	}
+}
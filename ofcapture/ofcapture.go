@@ -0,0 +1,493 @@
+// Package ofcapture decodes the OpenFlow messages in a pcap or pcapng capture, e.g., one taken by tcpdump on a
+// production node, without relying on the partial OpenFlow 1.5 support of wireshark. The TCP streams of the
+// OpenFlow ports are reassembled per direction and the messages are parsed with the Parse functions of
+// libOpenflow.
+package ofcapture
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/contiv/libOpenflow/ofmsg"
+	"github.com/contiv/libOpenflow/util"
+)
+
+// The well-known TCP ports of OpenFlow, DefaultPorts are used if Options.Ports is empty.
+const (
+	PortOpenFlowLegacy = 6633
+	PortOpenFlow       = 6653
+)
+
+// DefaultPorts are the TCP ports of the OpenFlow connections decoded by default.
+var DefaultPorts = []uint16{PortOpenFlowLegacy, PortOpenFlow}
+
+// ofpHeaderLen is the length of the OpenFlow header, which is the same in all the OpenFlow versions.
+const ofpHeaderLen = 8
+
+// maxPendingSegments is the default number of out-of-order segments buffered per direction before the missing
+// bytes are given up.
+const maxPendingSegments = 256
+
+// ErrStreamGap is set to Message.Err when bytes of a stream are missing from the capture, e.g., dropped by the
+// kernel, so that the messages around the gap are lost. The decoding resumes at the next segment which starts
+// with a message.
+var ErrStreamGap = errors.New("bytes missing from the captured TCP stream")
+
+// ErrStreamDesync is set to Message.Err when the stream doesn't hold a valid OpenFlow header where a message
+// should start, e.g., when the capture starts in the middle of a message.
+var ErrStreamDesync = errors.New("invalid OpenFlow header in the captured TCP stream")
+
+// Direction is the direction of a message, which is told by the side of the connection on the OpenFlow port.
+type Direction int
+
+const (
+	// ToController is a message sent by the switch to the OpenFlow port of the controller.
+	ToController Direction = iota
+	// ToSwitch is a message sent by the controller from its OpenFlow port.
+	ToSwitch
+)
+
+func (d Direction) String() string {
+	if d == ToSwitch {
+		return "to-switch"
+	}
+	return "to-controller"
+}
+
+// Options are the options of a Reader.
+type Options struct {
+	// Ports are the TCP ports of the controller, the streams to or from these ports are decoded. DefaultPorts are
+	// used if it is empty.
+	Ports []uint16
+	// Parser parses the messages, ofmsg.Parser is used if it is nil. A message consumed by a handler of the parser
+	// is kept as the *util.HandledMessage.
+	Parser util.Parser
+	// MaxPendingSegments is the number of out-of-order segments buffered per direction before the missing bytes
+	// are given up, 256 is used if it is 0.
+	MaxPendingSegments int
+}
+
+// Message is an OpenFlow message decoded from a capture.
+type Message struct {
+	// Time is the capture time of the packet which completes the message.
+	Time time.Time
+	// Src and Dst are the endpoints of the TCP connection in the direction of the message.
+	Src, Dst  *net.TCPAddr
+	Direction Direction
+	// Version is the OpenFlow version in the header of the message.
+	Version uint8
+	// Raw is the bytes of the message, it is nil for ErrStreamGap and ErrStreamDesync.
+	Raw []byte
+	// Msg is the parsed message, it is nil if Err is not nil.
+	Msg util.Message
+	// Err is the error of the parser, or ErrStreamGap or ErrStreamDesync.
+	Err error
+}
+
+// Reader reads the OpenFlow messages from a capture in the order they are completed in the capture. A Reader is
+// not safe for concurrent use.
+type Reader struct {
+	source  packetSource
+	ports   map[uint16]bool
+	parser  util.Parser
+	pending int
+	streams map[streamKey]*stream
+	ready   []*Message
+}
+
+// NewReader returns a Reader of a pcap or pcapng file.
+func NewReader(r io.Reader, opts Options) (*Reader, error) {
+	source, err := newPacketSource(r)
+	if err != nil {
+		return nil, err
+	}
+	ports := opts.Ports
+	if len(ports) == 0 {
+		ports = DefaultPorts
+	}
+	reader := &Reader{
+		source:  source,
+		ports:   make(map[uint16]bool, len(ports)),
+		parser:  opts.Parser,
+		pending: opts.MaxPendingSegments,
+		streams: make(map[streamKey]*stream),
+	}
+	for _, port := range ports {
+		reader.ports[port] = true
+	}
+	if reader.parser == nil {
+		reader.parser = ofmsg.Parser{}
+	}
+	if reader.pending <= 0 {
+		reader.pending = maxPendingSegments
+	}
+	return reader, nil
+}
+
+// Next returns the next message, or io.EOF at the end of the capture. The error of a message is returned in
+// Message.Err, an error is only returned by Next if the capture could not be read.
+func (r *Reader) Next() (*Message, error) {
+	for len(r.ready) == 0 {
+		pkt, err := r.source.next()
+		if err != nil {
+			return nil, err
+		}
+		r.handlePacket(pkt)
+	}
+	msg := r.ready[0]
+	r.ready[0] = nil
+	r.ready = r.ready[1:]
+	return msg, nil
+}
+
+// ReadAll returns all the messages of a capture.
+func ReadAll(r io.Reader, opts Options) ([]*Message, error) {
+	reader, err := NewReader(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	var msgs []*Message
+	for {
+		msg, err := reader.Next()
+		if err == io.EOF {
+			return msgs, nil
+		} else if err != nil {
+			return msgs, err
+		}
+		msgs = append(msgs, msg)
+	}
+}
+
+// streamKey identifies a direction of a TCP connection.
+type streamKey struct {
+	src, dst         [16]byte
+	srcPort, dstPort uint16
+}
+
+// stream is the reassembly state of a direction of a TCP connection.
+type stream struct {
+	src, dst  *net.TCPAddr
+	direction Direction
+	// synced is false until the first segment of the direction, and nextSeq is not valid then.
+	synced  bool
+	nextSeq uint32
+	// segments are the out-of-order segments by their sequence numbers.
+	segments map[uint32][]byte
+	buf      []byte
+	// resync is set after a gap or an invalid header, the bytes are dropped until a segment starts with a header.
+	resync bool
+	// closed is set after a FIN or RST, the segments retransmitted after it are ignored until a SYN reuses the
+	// ports.
+	closed bool
+}
+
+// tcpSegment is the part of a TCP segment used by the reassembly.
+type tcpSegment struct {
+	src, dst         net.IP
+	srcPort, dstPort uint16
+	seq              uint32
+	syn, fin, rst    bool
+	payload          []byte
+}
+
+func (r *Reader) handlePacket(pkt *packet) {
+	seg, ok := decodeSegment(pkt.linkType, pkt.data)
+	if !ok {
+		return
+	}
+	var direction Direction
+	switch {
+	case r.ports[seg.dstPort]:
+		direction = ToController
+	case r.ports[seg.srcPort]:
+		direction = ToSwitch
+	default:
+		return
+	}
+	key := streamKey{srcPort: seg.srcPort, dstPort: seg.dstPort}
+	copy(key.src[:], seg.src.To16())
+	copy(key.dst[:], seg.dst.To16())
+	s := r.streams[key]
+	if s == nil {
+		s = &stream{
+			src:       &net.TCPAddr{IP: seg.src, Port: int(seg.srcPort)},
+			dst:       &net.TCPAddr{IP: seg.dst, Port: int(seg.dstPort)},
+			direction: direction,
+			segments:  make(map[uint32][]byte),
+		}
+		r.streams[key] = s
+	}
+	if s.closed && !seg.syn {
+		return
+	}
+	if seg.syn {
+		// The SYN takes a sequence number, and starts a new connection if the ports are reused.
+		s.synced, s.nextSeq, s.buf, s.resync, s.closed = true, seg.seq+1, nil, false, false
+		s.segments = make(map[uint32][]byte)
+		seg.seq++
+	}
+	if seg.rst {
+		r.close(s, pkt.time)
+		return
+	}
+	if len(seg.payload) > 0 {
+		r.addSegment(s, pkt.time, seg.seq, seg.payload)
+	}
+	if seg.fin {
+		r.close(s, pkt.time)
+	}
+}
+
+// close ends the stream at a FIN or RST. The out-of-order segments buffered after a gap are emitted after an
+// ErrStreamGap, and a partial message left in the buffer is reported as an ErrStreamGap, as the rest of it is
+// never sent. The stream is kept closed so that the segments retransmitted after it don't start a new stream.
+func (r *Reader) close(s *stream, ts time.Time) {
+	for len(s.segments) > 0 {
+		r.skipGap(s, ts)
+		s.appendBuffered()
+		r.emit(s, ts)
+	}
+	if len(s.buf) > 0 {
+		r.ready = append(r.ready, s.newMessage(ts, ErrStreamGap))
+	}
+	s.closed, s.buf, s.segments = true, nil, nil
+}
+
+// addSegment adds the payload of a segment to the stream, and emits the messages completed by it.
+func (r *Reader) addSegment(s *stream, ts time.Time, seq uint32, payload []byte) {
+	if !s.synced {
+		s.synced, s.nextSeq = true, seq
+	}
+	if offset := int32(seq - s.nextSeq); offset > 0 {
+		s.segments[seq] = append([]byte(nil), payload...)
+		if len(s.segments) <= r.pending {
+			return
+		}
+		r.skipGap(s, ts)
+	} else {
+		s.append(seq, payload)
+	}
+	s.appendBuffered()
+	r.emit(s, ts)
+}
+
+// appendBuffered appends the buffered segments which follow the bytes of the stream.
+func (s *stream) appendBuffered() {
+	for progress := true; progress && len(s.segments) > 0; {
+		progress = false
+		for segSeq, data := range s.segments {
+			if int32(segSeq-s.nextSeq) <= 0 {
+				delete(s.segments, segSeq)
+				s.append(segSeq, data)
+				progress = true
+			}
+		}
+	}
+}
+
+// append appends the bytes of a segment after nextSeq, the retransmitted bytes are ignored. The segments are
+// skipped after a gap until one starts with an OpenFlow header.
+func (s *stream) append(seq uint32, payload []byte) {
+	if overlap := int32(s.nextSeq - seq); overlap > 0 {
+		if int(overlap) >= len(payload) {
+			return
+		}
+		payload = payload[overlap:]
+	}
+	if s.resync {
+		if !validHeader(payload) {
+			s.nextSeq += uint32(len(payload))
+			return
+		}
+		s.resync = false
+	}
+	s.buf = append(s.buf, payload...)
+	s.nextSeq += uint32(len(payload))
+}
+
+// skipGap gives up the missing bytes before the first buffered segment, the partial message in the buffer is
+// dropped and the stream continues at the first buffered segment.
+func (r *Reader) skipGap(s *stream, ts time.Time) {
+	first, found := uint32(0), false
+	for seq := range s.segments {
+		if !found || int32(seq-first) < 0 {
+			first, found = seq, true
+		}
+	}
+	s.buf, s.resync = nil, true
+	s.nextSeq = first
+	r.ready = append(r.ready, s.newMessage(ts, ErrStreamGap))
+}
+
+// emit parses the complete messages in the buffer of the stream.
+func (r *Reader) emit(s *stream, ts time.Time) {
+	for len(s.buf) >= ofpHeaderLen {
+		if !validHeader(s.buf) {
+			s.buf, s.resync = nil, true
+			r.ready = append(r.ready, s.newMessage(ts, ErrStreamDesync))
+			return
+		}
+		length := int(binary.BigEndian.Uint16(s.buf[2:]))
+		if len(s.buf) < length {
+			break
+		}
+		raw := make([]byte, length)
+		copy(raw, s.buf)
+		s.buf = s.buf[length:]
+		msg := s.newMessage(ts, nil)
+		msg.Version, msg.Raw = raw[0], raw
+		msg.Msg, msg.Err = r.parser.Parse(raw)
+		if msg.Err != nil {
+			msg.Msg = nil
+		}
+		r.ready = append(r.ready, msg)
+	}
+	if len(s.buf) == 0 {
+		s.buf = nil
+	}
+}
+
+// validHeader checks whether data starts with a plausible OpenFlow header, i.e., a version from 1.0 to 1.5 and a
+// length of at least a header.
+func validHeader(data []byte) bool {
+	if len(data) < ofpHeaderLen {
+		return false
+	}
+	return data[0] >= 0x01 && data[0] <= 0x06 && binary.BigEndian.Uint16(data[2:]) >= ofpHeaderLen
+}
+
+func (s *stream) newMessage(ts time.Time, err error) *Message {
+	return &Message{Time: ts, Src: s.src, Dst: s.dst, Direction: s.direction, Err: err}
+}
+
+// decodeSegment returns the TCP segment in a captured frame, the other frames and the IP fragments are ignored.
+func decodeSegment(linkType uint32, data []byte) (*tcpSegment, bool) {
+	var etherType uint16
+	switch linkType {
+	case LinkTypeEthernet:
+		if len(data) < 14 {
+			return nil, false
+		}
+		etherType, data = binary.BigEndian.Uint16(data[12:]), data[14:]
+		// Skip the 802.1Q and 802.1ad tags.
+		for (etherType == 0x8100 || etherType == 0x88a8) && len(data) >= 4 {
+			etherType, data = binary.BigEndian.Uint16(data[2:]), data[4:]
+		}
+	case LinkTypeLinuxSLL:
+		if len(data) < 16 {
+			return nil, false
+		}
+		etherType, data = binary.BigEndian.Uint16(data[14:]), data[16:]
+	case LinkTypeSLL2:
+		if len(data) < 20 {
+			return nil, false
+		}
+		etherType, data = binary.BigEndian.Uint16(data[0:]), data[20:]
+	case LinkTypeNull:
+		if len(data) < 4 {
+			return nil, false
+		}
+		// The address family is in the byte order of the capturing host.
+		family := binary.LittleEndian.Uint32(data)
+		if family > 0xffff {
+			family = binary.BigEndian.Uint32(data)
+		}
+		data = data[4:]
+		switch family {
+		case 2:
+			etherType = 0x0800
+		case 10, 24, 28, 30:
+			etherType = 0x86dd
+		default:
+			return nil, false
+		}
+	case LinkTypeRaw, linkTypeRawOpenBSD:
+		if len(data) == 0 {
+			return nil, false
+		}
+		switch data[0] >> 4 {
+		case 4:
+			etherType = 0x0800
+		case 6:
+			etherType = 0x86dd
+		default:
+			return nil, false
+		}
+	case LinkTypeIPv4:
+		etherType = 0x0800
+	case LinkTypeIPv6:
+		etherType = 0x86dd
+	default:
+		return nil, false
+	}
+	seg := new(tcpSegment)
+	switch etherType {
+	case 0x0800:
+		if len(data) < 20 || data[0]>>4 != 4 {
+			return nil, false
+		}
+		ihl := int(data[0]&0x0f) * 4
+		total := int(binary.BigEndian.Uint16(data[2:]))
+		if ihl < 20 || total < ihl || total > len(data) || data[9] != 6 {
+			return nil, false
+		}
+		if flagsOffset := binary.BigEndian.Uint16(data[6:]); flagsOffset&0x3fff != 0 {
+			return nil, false
+		}
+		seg.src, seg.dst = net.IP(data[12:16]), net.IP(data[16:20])
+		data = data[ihl:total]
+	case 0x86dd:
+		if len(data) < 40 || data[0]>>4 != 6 {
+			return nil, false
+		}
+		total := 40 + int(binary.BigEndian.Uint16(data[4:]))
+		if total > len(data) {
+			return nil, false
+		}
+		nextHeader := data[6]
+		seg.src, seg.dst = net.IP(data[8:24]), net.IP(data[24:40])
+		data = data[40:total]
+		// Skip the hop-by-hop, routing and destination options headers, the fragments are not reassembled.
+		for nextHeader == 0 || nextHeader == 43 || nextHeader == 60 {
+			if len(data) < 8 {
+				return nil, false
+			}
+			extLen := (int(data[1]) + 1) * 8
+			if extLen > len(data) {
+				return nil, false
+			}
+			nextHeader, data = data[0], data[extLen:]
+		}
+		if nextHeader != 6 {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+	if len(data) < 20 {
+		return nil, false
+	}
+	dataOffset := int(data[12]>>4) * 4
+	if dataOffset < 20 || dataOffset > len(data) {
+		return nil, false
+	}
+	seg.srcPort = binary.BigEndian.Uint16(data[0:])
+	seg.dstPort = binary.BigEndian.Uint16(data[2:])
+	seg.seq = binary.BigEndian.Uint32(data[4:])
+	flags := data[13]
+	seg.fin, seg.syn, seg.rst = flags&0x01 != 0, flags&0x02 != 0, flags&0x04 != 0
+	seg.payload = data[dataOffset:]
+	return seg, true
+}
+
+func (m *Message) String() string {
+	if m.Err != nil {
+		return fmt.Sprintf("%s %s > %s %s: %v", m.Time.Format(time.RFC3339Nano), m.Src, m.Dst, m.Direction, m.Err)
+	}
+	return fmt.Sprintf("%s %s > %s %s: version 0x%x %T", m.Time.Format(time.RFC3339Nano), m.Src, m.Dst,
+		m.Direction, m.Version, m.Msg)
+}
@@ -0,0 +1,262 @@
+package ofcapture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/contiv/libOpenflow/common"
+	"github.com/contiv/libOpenflow/openflow13"
+)
+
+var (
+	switchAddr     = &net.TCPAddr{IP: net.IPv4(192, 168, 1, 2).To4(), Port: 40000}
+	controllerAddr = &net.TCPAddr{IP: net.IPv4(192, 168, 1, 1).To4(), Port: PortOpenFlow}
+)
+
+// segment is a TCP segment to be written to a test capture.
+type segment struct {
+	src, dst *net.TCPAddr
+	seq      uint32
+	flags    uint8
+	payload  []byte
+}
+
+// ethernetFrame returns an Ethernet frame of an IPv4 TCP segment.
+func ethernetFrame(seg segment) []byte {
+	frame := make([]byte, 14+20+20+len(seg.payload))
+	binary.BigEndian.PutUint16(frame[12:], 0x0800)
+	ip := frame[14:]
+	ip[0] = 0x45
+	binary.BigEndian.PutUint16(ip[2:], uint16(40+len(seg.payload)))
+	ip[8] = 64
+	ip[9] = 6
+	copy(ip[12:], seg.src.IP.To4())
+	copy(ip[16:], seg.dst.IP.To4())
+	tcp := ip[20:]
+	binary.BigEndian.PutUint16(tcp[0:], uint16(seg.src.Port))
+	binary.BigEndian.PutUint16(tcp[2:], uint16(seg.dst.Port))
+	binary.BigEndian.PutUint32(tcp[4:], seg.seq)
+	tcp[12] = 5 << 4
+	tcp[13] = seg.flags
+	copy(tcp[20:], seg.payload)
+	return frame
+}
+
+func writePcap(start time.Time, segs []segment) []byte {
+	buf := new(bytes.Buffer)
+	header := make([]byte, pcapFileHeaderLen)
+	binary.LittleEndian.PutUint32(header[0:], pcapMagicMicro)
+	binary.LittleEndian.PutUint16(header[4:], 2)
+	binary.LittleEndian.PutUint16(header[6:], 4)
+	binary.LittleEndian.PutUint32(header[16:], 65535)
+	binary.LittleEndian.PutUint32(header[20:], LinkTypeEthernet)
+	buf.Write(header)
+	for i, seg := range segs {
+		frame := ethernetFrame(seg)
+		ts := start.Add(time.Duration(i) * time.Millisecond)
+		record := make([]byte, pcapRecordHeaderLen)
+		binary.LittleEndian.PutUint32(record[0:], uint32(ts.Unix()))
+		binary.LittleEndian.PutUint32(record[4:], uint32(ts.Nanosecond()/1000))
+		binary.LittleEndian.PutUint32(record[8:], uint32(len(frame)))
+		binary.LittleEndian.PutUint32(record[12:], uint32(len(frame)))
+		buf.Write(record)
+		buf.Write(frame)
+	}
+	return buf.Bytes()
+}
+
+func pcapngBlock(blockType uint32, body []byte) []byte {
+	for len(body)%4 != 0 {
+		body = append(body, 0)
+	}
+	block := make([]byte, 12+len(body))
+	binary.BigEndian.PutUint32(block[0:], blockType)
+	binary.BigEndian.PutUint32(block[4:], uint32(len(block)))
+	copy(block[8:], body)
+	binary.BigEndian.PutUint32(block[len(block)-4:], uint32(len(block)))
+	return block
+}
+
+func writePcapng(start time.Time, segs []segment) []byte {
+	buf := new(bytes.Buffer)
+	shb := make([]byte, 16)
+	binary.BigEndian.PutUint32(shb[0:], pcapngByteOrderMagic)
+	binary.BigEndian.PutUint16(shb[4:], 1)
+	binary.BigEndian.PutUint64(shb[8:], 0xffffffffffffffff)
+	buf.Write(pcapngBlock(pcapngBlockSHB, shb))
+	// The interface has a timestamp resolution of nanoseconds.
+	idb := []byte{0, LinkTypeEthernet, 0, 0, 0, 0, 0xff, 0xff, 0, pcapngOptionTsresol, 0, 1, 9, 0, 0, 0, 0, 0, 0, 0}
+	buf.Write(pcapngBlock(pcapngBlockIDB, idb))
+	for i, seg := range segs {
+		frame := ethernetFrame(seg)
+		ts := uint64(start.Add(time.Duration(i) * time.Millisecond).UnixNano())
+		epb := make([]byte, 20+len(frame))
+		binary.BigEndian.PutUint32(epb[4:], uint32(ts>>32))
+		binary.BigEndian.PutUint32(epb[8:], uint32(ts))
+		binary.BigEndian.PutUint32(epb[12:], uint32(len(frame)))
+		binary.BigEndian.PutUint32(epb[16:], uint32(len(frame)))
+		copy(epb[20:], frame)
+		buf.Write(pcapngBlock(pcapngBlockEPB, epb))
+	}
+	return buf.Bytes()
+}
+
+// testSegments returns a connection in which the switch sends a hello split across two segments, with the
+// second segment captured first and the first one retransmitted, and the controller replies with a hello and a
+// flow mod in a single segment.
+func testSegments(t *testing.T) []segment {
+	hello, _ := common.NewHello(4)
+	helloData, _ := hello.MarshalBinary()
+	flow := openflow13.NewFlowMod()
+	flow.Priority = 100
+	flowData, err := flow.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal flow mod: %v", err)
+	}
+	const switchISN, controllerISN = 1000, 0xfffffff0
+	return []segment{
+		{src: switchAddr, dst: controllerAddr, seq: switchISN, flags: 0x02},
+		{src: controllerAddr, dst: switchAddr, seq: controllerISN, flags: 0x12},
+		{src: switchAddr, dst: controllerAddr, seq: switchISN + 1 + 3, flags: 0x18, payload: helloData[3:]},
+		{src: switchAddr, dst: controllerAddr, seq: switchISN + 1, flags: 0x18, payload: helloData[:3]},
+		{src: switchAddr, dst: controllerAddr, seq: switchISN + 1, flags: 0x18, payload: helloData[:3]},
+		// The sequence numbers of the controller wrap around.
+		{src: controllerAddr, dst: switchAddr, seq: controllerISN + 1, flags: 0x18, payload: append(helloData, flowData...)},
+		{src: switchAddr, dst: controllerAddr, seq: switchISN + 1 + uint32(len(helloData)), flags: 0x11},
+	}
+}
+
+func checkMessages(t *testing.T, msgs []*Message, start time.Time) {
+	if len(msgs) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(msgs))
+	}
+	for _, msg := range msgs {
+		if msg.Err != nil {
+			t.Fatalf("Unexpected error of message %s", msg)
+		}
+	}
+	if msgs[0].Direction != ToController || msgs[0].Src.String() != switchAddr.String() {
+		t.Errorf("Unexpected first message %s", msgs[0])
+	}
+	if _, ok := msgs[0].Msg.(*common.Hello); !ok {
+		t.Errorf("Expected a hello, got %T", msgs[0].Msg)
+	}
+	// The hello of the switch is completed by the fourth packet.
+	if !msgs[0].Time.Equal(start.Add(3 * time.Millisecond)) {
+		t.Errorf("Unexpected time of the first message %s", msgs[0].Time)
+	}
+	if msgs[1].Direction != ToSwitch || msgs[2].Direction != ToSwitch {
+		t.Errorf("Unexpected directions of the replies %s, %s", msgs[1].Direction, msgs[2].Direction)
+	}
+	if flow, ok := msgs[2].Msg.(*openflow13.FlowMod); !ok || flow.Priority != 100 || msgs[2].Version != openflow13.VERSION {
+		t.Errorf("Unexpected flow mod %s", msgs[2])
+	}
+}
+
+func TestReadPcap(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	msgs, err := ReadAll(bytes.NewReader(writePcap(start, testSegments(t))), Options{})
+	if err != nil {
+		t.Fatalf("Failed to read the capture: %v", err)
+	}
+	checkMessages(t, msgs, start)
+}
+
+func TestReadPcapng(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	msgs, err := ReadAll(bytes.NewReader(writePcapng(start, testSegments(t))), Options{})
+	if err != nil {
+		t.Fatalf("Failed to read the capture: %v", err)
+	}
+	checkMessages(t, msgs, start)
+}
+
+func TestReadGap(t *testing.T) {
+	echo := openflow13.NewEchoRequest()
+	echoData, _ := echo.MarshalBinary()
+	segs := []segment{
+		// The capture starts in the middle of a message, the bytes up to the next segment starting with a header
+		// are dropped.
+		{src: switchAddr, dst: controllerAddr, seq: 100, flags: 0x18, payload: echoData[2:]},
+		{src: switchAddr, dst: controllerAddr, seq: 106, flags: 0x18, payload: echoData},
+		{src: switchAddr, dst: controllerAddr, seq: 114, flags: 0x18, payload: echoData},
+		// The bytes from 122 to 130 are missing, the following segment is buffered until it is given up.
+		{src: switchAddr, dst: controllerAddr, seq: 130, flags: 0x18, payload: echoData},
+		{src: switchAddr, dst: controllerAddr, seq: 138, flags: 0x18, payload: echoData},
+	}
+	msgs, err := ReadAll(bytes.NewReader(writePcap(time.Unix(0, 0), segs)), Options{MaxPendingSegments: 1})
+	if err != nil {
+		t.Fatalf("Failed to read the capture: %v", err)
+	}
+	var errs []error
+	var echos int
+	for _, msg := range msgs {
+		if msg.Err != nil {
+			errs = append(errs, msg.Err)
+		} else {
+			echos++
+		}
+	}
+	if len(errs) != 2 || errs[0] != ErrStreamDesync || errs[1] != ErrStreamGap || echos != 3 {
+		t.Errorf("Unexpected messages %v", msgs)
+	}
+}
+
+func TestReadClose(t *testing.T) {
+	echo := openflow13.NewEchoRequest()
+	echoData, _ := echo.MarshalBinary()
+	otherSwitch := &net.TCPAddr{IP: switchAddr.IP, Port: switchAddr.Port + 1}
+	segs := []segment{
+		{src: switchAddr, dst: controllerAddr, seq: 100, flags: 0x18, payload: echoData},
+		// The bytes from 108 to 116 are missing when the connection is closed, the buffered segment after the
+		// gap is emitted at the FIN.
+		{src: switchAddr, dst: controllerAddr, seq: 116, flags: 0x18, payload: echoData},
+		{src: switchAddr, dst: controllerAddr, seq: 124, flags: 0x11},
+		// The segments retransmitted after the FIN don't start a new stream.
+		{src: switchAddr, dst: controllerAddr, seq: 100, flags: 0x18, payload: echoData},
+		{src: switchAddr, dst: controllerAddr, seq: 124, flags: 0x11},
+		// The partial message is reported at the RST.
+		{src: otherSwitch, dst: controllerAddr, seq: 200, flags: 0x18, payload: echoData[:4]},
+		{src: otherSwitch, dst: controllerAddr, seq: 204, flags: 0x14},
+		// The ports are reused by a new connection.
+		{src: switchAddr, dst: controllerAddr, seq: 500, flags: 0x02},
+		{src: switchAddr, dst: controllerAddr, seq: 501, flags: 0x18, payload: echoData},
+	}
+	msgs, err := ReadAll(bytes.NewReader(writePcap(time.Unix(0, 0), segs)), Options{})
+	if err != nil {
+		t.Fatalf("Failed to read the capture: %v", err)
+	}
+	expected := []struct {
+		src *net.TCPAddr
+		err error
+	}{{switchAddr, nil}, {switchAddr, ErrStreamGap}, {switchAddr, nil}, {otherSwitch, ErrStreamGap}, {switchAddr, nil}}
+	if len(msgs) != len(expected) {
+		t.Fatalf("Expected %d messages, got %v", len(expected), msgs)
+	}
+	for i, msg := range msgs {
+		if msg.Src.String() != expected[i].src.String() || msg.Err != expected[i].err {
+			t.Errorf("Unexpected message %d: %s", i, msg)
+		}
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	if _, err := NewReader(bytes.NewReader([]byte("not a capture")), Options{}); err != ErrUnknownFormat {
+		t.Errorf("Expected ErrUnknownFormat, got %v", err)
+	}
+	truncated := writePcap(time.Unix(0, 0), testSegments(t))
+	reader, err := NewReader(bytes.NewReader(truncated[:len(truncated)-10]), Options{})
+	if err != nil {
+		t.Fatalf("Failed to create reader: %v", err)
+	}
+	for err == nil {
+		_, err = reader.Next()
+	}
+	if err == io.EOF {
+		t.Errorf("Expected an error of the truncated capture")
+	}
+}
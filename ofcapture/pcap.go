@@ -0,0 +1,284 @@
+package ofcapture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// The link types of the captures, see https://www.tcpdump.org/linktypes.html.
+const (
+	LinkTypeNull     = 0
+	LinkTypeEthernet = 1
+	LinkTypeRaw      = 101
+	LinkTypeLinuxSLL = 113
+	LinkTypeIPv4     = 228
+	LinkTypeIPv6     = 229
+	LinkTypeSLL2     = 276
+
+	// linkTypeRawOpenBSD is the value of DLT_RAW used by OpenBSD in the old captures.
+	linkTypeRawOpenBSD = 12
+)
+
+const (
+	pcapMagicMicro        = 0xa1b2c3d4
+	pcapMagicNano         = 0xa1b23c4d
+	pcapFileHeaderLen     = 24
+	pcapRecordHeaderLen   = 16
+	pcapngBlockSHB        = 0x0a0d0d0a
+	pcapngBlockIDB        = 0x00000001
+	pcapngBlockSPB        = 0x00000003
+	pcapngBlockEPB        = 0x00000006
+	pcapngByteOrderMagic  = 0x1a2b3c4d
+	pcapngOptionTsresol   = 9
+	pcapngBlockHeaderLen  = 12
+	pcapngDefaultTsresol  = 6
+	maxCaptureRecordBytes = 256 * 1024 * 1024
+)
+
+// ErrUnknownFormat is returned by NewReader if the file is neither a pcap nor a pcapng file.
+var ErrUnknownFormat = errors.New("not a pcap or pcapng file")
+
+// packet is a captured frame with the link type of the interface it was captured on.
+type packet struct {
+	time     time.Time
+	linkType uint32
+	data     []byte
+}
+
+// packetSource returns the captured packets of a file in the order of the file.
+type packetSource interface {
+	next() (*packet, error)
+}
+
+// newPacketSource detects the format of the file by its magic number.
+func newPacketSource(r io.Reader) (packetSource, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	switch {
+	case binary.BigEndian.Uint32(magic) == pcapngBlockSHB:
+		return newPcapngSource(br)
+	case binary.BigEndian.Uint32(magic) == pcapMagicMicro, binary.BigEndian.Uint32(magic) == pcapMagicNano,
+		binary.LittleEndian.Uint32(magic) == pcapMagicMicro, binary.LittleEndian.Uint32(magic) == pcapMagicNano:
+		return newPcapSource(br)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// pcapSource reads the classic libpcap format.
+type pcapSource struct {
+	r        io.Reader
+	order    binary.ByteOrder
+	nano     bool
+	linkType uint32
+	header   [pcapRecordHeaderLen]byte
+}
+
+func newPcapSource(r io.Reader) (*pcapSource, error) {
+	var header [pcapFileHeaderLen]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	s := &pcapSource{r: r}
+	switch {
+	case binary.LittleEndian.Uint32(header[0:]) == pcapMagicMicro:
+		s.order = binary.LittleEndian
+	case binary.LittleEndian.Uint32(header[0:]) == pcapMagicNano:
+		s.order, s.nano = binary.LittleEndian, true
+	case binary.BigEndian.Uint32(header[0:]) == pcapMagicMicro:
+		s.order = binary.BigEndian
+	default:
+		s.order, s.nano = binary.BigEndian, true
+	}
+	// The upper bits of the link type field may hold the FCS length, only the lower 16 bits are the link type.
+	s.linkType = s.order.Uint32(header[20:]) & 0xffff
+	return s, nil
+}
+
+func (s *pcapSource) next() (*packet, error) {
+	if _, err := io.ReadFull(s.r, s.header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("truncated pcap record header: %w", err)
+		}
+		return nil, err
+	}
+	sec := s.order.Uint32(s.header[0:])
+	frac := s.order.Uint32(s.header[4:])
+	capLen := s.order.Uint32(s.header[8:])
+	if capLen > maxCaptureRecordBytes {
+		return nil, fmt.Errorf("invalid pcap record length %d", capLen)
+	}
+	data := make([]byte, capLen)
+	if _, err := io.ReadFull(s.r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("truncated pcap record: %w", err)
+	}
+	nsec := int64(frac) * 1000
+	if s.nano {
+		nsec = int64(frac)
+	}
+	return &packet{time: time.Unix(int64(sec), nsec), linkType: s.linkType, data: data}, nil
+}
+
+// pcapngInterface is the link type and the timestamp resolution of an interface described by an IDB.
+type pcapngInterface struct {
+	linkType uint32
+	tsresol  uint8
+}
+
+// pcapngSource reads the pcapng format. The blocks other than the section headers, the interface descriptions
+// and the enhanced and simple packets are skipped.
+type pcapngSource struct {
+	r          io.Reader
+	order      binary.ByteOrder
+	interfaces []pcapngInterface
+}
+
+func newPcapngSource(r io.Reader) (*pcapngSource, error) {
+	return &pcapngSource{r: r, order: binary.BigEndian}, nil
+}
+
+// readBlock returns the type and the body of the next block.
+func (s *pcapngSource) readBlock() (uint32, []byte, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(s.r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return 0, nil, fmt.Errorf("truncated pcapng block header: %w", err)
+		}
+		return 0, nil, err
+	}
+	blockType := binary.BigEndian.Uint32(header[0:])
+	if blockType == pcapngBlockSHB {
+		// The byte order of a section is given by the byte order magic following the block length.
+		var bom [4]byte
+		if _, err := io.ReadFull(s.r, bom[:]); err != nil {
+			return 0, nil, fmt.Errorf("truncated pcapng section header: %w", err)
+		}
+		if binary.BigEndian.Uint32(bom[:]) == pcapngByteOrderMagic {
+			s.order = binary.BigEndian
+		} else if binary.LittleEndian.Uint32(bom[:]) == pcapngByteOrderMagic {
+			s.order = binary.LittleEndian
+		} else {
+			return 0, nil, ErrUnknownFormat
+		}
+		s.interfaces = s.interfaces[:0]
+		total := s.order.Uint32(header[4:])
+		if total < pcapngBlockHeaderLen+4 || total > maxCaptureRecordBytes || total%4 != 0 {
+			return 0, nil, fmt.Errorf("invalid pcapng block length %d", total)
+		}
+		// The body is followed by the trailing copy of the block length.
+		body := make([]byte, total-8)
+		copy(body, bom[:])
+		if _, err := io.ReadFull(s.r, body[4:]); err != nil {
+			return 0, nil, fmt.Errorf("truncated pcapng section header: %w", err)
+		}
+		return blockType, body[:len(body)-4], nil
+	}
+	blockType = s.order.Uint32(header[0:])
+	total := s.order.Uint32(header[4:])
+	if total < pcapngBlockHeaderLen || total > maxCaptureRecordBytes || total%4 != 0 {
+		return 0, nil, fmt.Errorf("invalid pcapng block length %d", total)
+	}
+	rest := make([]byte, total-8)
+	if _, err := io.ReadFull(s.r, rest); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, nil, fmt.Errorf("truncated pcapng block: %w", err)
+	}
+	// The trailing copy of the block length is not part of the body.
+	return blockType, rest[:len(rest)-4], nil
+}
+
+func (s *pcapngSource) next() (*packet, error) {
+	for {
+		blockType, body, err := s.readBlock()
+		if err != nil {
+			return nil, err
+		}
+		switch blockType {
+		case pcapngBlockIDB:
+			if len(body) < 8 {
+				return nil, fmt.Errorf("invalid pcapng interface description length %d", len(body))
+			}
+			intf := pcapngInterface{linkType: uint32(s.order.Uint16(body[0:])), tsresol: pcapngDefaultTsresol}
+			s.parseInterfaceOptions(&intf, body[8:])
+			s.interfaces = append(s.interfaces, intf)
+		case pcapngBlockEPB:
+			if len(body) < 20 {
+				return nil, fmt.Errorf("invalid pcapng enhanced packet length %d", len(body))
+			}
+			id := s.order.Uint32(body[0:])
+			if int(id) >= len(s.interfaces) {
+				return nil, fmt.Errorf("pcapng packet of unknown interface %d", id)
+			}
+			intf := s.interfaces[id]
+			ts := uint64(s.order.Uint32(body[4:]))<<32 | uint64(s.order.Uint32(body[8:]))
+			capLen := s.order.Uint32(body[12:])
+			if uint64(capLen) > uint64(len(body)-20) {
+				return nil, fmt.Errorf("invalid pcapng captured length %d", capLen)
+			}
+			return &packet{time: pcapngTime(ts, intf.tsresol), linkType: intf.linkType, data: body[20 : 20+capLen]}, nil
+		case pcapngBlockSPB:
+			if len(body) < 4 || len(s.interfaces) == 0 {
+				return nil, errors.New("invalid pcapng simple packet")
+			}
+			origLen := s.order.Uint32(body[0:])
+			data := body[4:]
+			if uint64(origLen) < uint64(len(data)) {
+				data = data[:origLen]
+			}
+			return &packet{linkType: s.interfaces[0].linkType, data: data}, nil
+		}
+	}
+}
+
+// parseInterfaceOptions reads if_tsresol from the options of an IDB.
+func (s *pcapngSource) parseInterfaceOptions(intf *pcapngInterface, options []byte) {
+	for len(options) >= 4 {
+		code := s.order.Uint16(options[0:])
+		length := int(s.order.Uint16(options[2:]))
+		if code == 0 || 4+length > len(options) {
+			return
+		}
+		if code == pcapngOptionTsresol && length >= 1 {
+			intf.tsresol = options[4]
+		}
+		options = options[4+(length+3)&^3:]
+	}
+}
+
+// pcapngTime converts a timestamp in the units given by if_tsresol, i.e., 10^-n seconds, or 2^-n seconds if the
+// most significant bit is set.
+func pcapngTime(ts uint64, tsresol uint8) time.Time {
+	var unitsPerSecond uint64
+	if tsresol&0x80 != 0 {
+		shift := tsresol & 0x7f
+		if shift > 63 {
+			return time.Time{}
+		}
+		unitsPerSecond = 1 << shift
+	} else {
+		if tsresol > 19 {
+			return time.Time{}
+		}
+		unitsPerSecond = uint64(math.Pow10(int(tsresol)))
+	}
+	sec := ts / unitsPerSecond
+	rem := ts % unitsPerSecond
+	nsec := uint64(float64(rem) * 1e9 / float64(unitsPerSecond))
+	return time.Unix(int64(sec), int64(nsec))
+}
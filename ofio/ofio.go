@@ -0,0 +1,156 @@
+// Package ofio reads and writes the framed OpenFlow messages on a net.Conn, for the controllers which manage the
+// connection in their own goroutines rather than with util.MessageStream.
+package ofio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+// ofpHeaderLen is the length of the OpenFlow header, which is the same in all the OpenFlow versions.
+const ofpHeaderLen = 8
+
+// ErrMessageTooLarge is returned by MessageReader if the length of a message exceeds ReaderOptions.MaxMessageSize.
+// The message is discarded, and the next message can be read.
+var ErrMessageTooLarge = errors.New("OpenFlow message exceeds the maximum message size")
+
+// ReaderOptions are the options of a MessageReader.
+type ReaderOptions struct {
+	// MaxMessageSize is the maximum length of a message, all the messages up to 65535 bytes are read if it is 0.
+	MaxMessageSize int
+	// ReadTimeout sets the read deadline of the connection before reading each message if it is not 0, e.g., to
+	// detect a dead peer together with the echo requests.
+	ReadTimeout time.Duration
+}
+
+// MessageReader reads the OpenFlow messages from a net.Conn. A message is read according to the length in its
+// header, across as many reads of the connection as needed. A MessageReader is not safe for concurrent use.
+type MessageReader struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	parser util.Parser
+	opts   ReaderOptions
+	header [ofpHeaderLen]byte
+}
+
+// NewMessageReader returns a MessageReader which parses the messages with parser, e.g., ofmsg.Parser.
+func NewMessageReader(conn net.Conn, parser util.Parser, opts ReaderOptions) *MessageReader {
+	return &MessageReader{conn: conn, reader: bufio.NewReader(conn), parser: parser, opts: opts}
+}
+
+// ReadRaw returns the bytes of the next message. The error of the connection is returned as is, e.g., io.EOF if
+// the connection is closed between the messages, and io.ErrUnexpectedEOF if it is closed in a message.
+func (r *MessageReader) ReadRaw() ([]byte, error) {
+	if r.opts.ReadTimeout > 0 {
+		if err := r.conn.SetReadDeadline(time.Now().Add(r.opts.ReadTimeout)); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := io.ReadFull(r.reader, r.header[:]); err != nil {
+		return nil, err
+	}
+	length := int(binary.BigEndian.Uint16(r.header[2:]))
+	if length < ofpHeaderLen {
+		return nil, fmt.Errorf("invalid OpenFlow message length %d", length)
+	}
+	if r.opts.MaxMessageSize > 0 && length > r.opts.MaxMessageSize {
+		// Discard the body so that the reader stays at the start of the next message.
+		if _, err := r.reader.Discard(length - ofpHeaderLen); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		return nil, ErrMessageTooLarge
+	}
+	data := make([]byte, length)
+	copy(data, r.header[:])
+	if _, err := io.ReadFull(r.reader, data[ofpHeaderLen:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// ReadMessage returns the next message parsed by the parser. The messages consumed by a handler of the parser,
+// i.e., parsed as a *util.HandledMessage, are skipped.
+func (r *MessageReader) ReadMessage() (util.Message, error) {
+	for {
+		data, err := r.ReadRaw()
+		if err != nil {
+			return nil, err
+		}
+		msg, err := r.parser.Parse(data)
+		if _, handled := msg.(*util.HandledMessage); handled && err == nil {
+			continue
+		}
+		return msg, err
+	}
+}
+
+// MessageWriter writes the OpenFlow messages to a net.Conn through a buffer, so that a batch of messages, e.g.,
+// the flow mods of a bundle, is sent with few writes. The messages are written as a whole, so they are never
+// interleaved by the concurrent writers. A MessageWriter is safe for concurrent use.
+type MessageWriter struct {
+	lock   sync.Mutex
+	writer *bufio.Writer
+}
+
+// NewMessageWriter returns a MessageWriter with a buffer of bufferSize bytes, or of the default size of bufio if
+// bufferSize is not positive.
+func NewMessageWriter(conn net.Conn, bufferSize int) *MessageWriter {
+	if bufferSize <= 0 {
+		return &MessageWriter{writer: bufio.NewWriter(conn)}
+	}
+	return &MessageWriter{writer: bufio.NewWriterSize(conn, bufferSize)}
+}
+
+// WriteMessage writes the message to the buffer, the buffered messages are sent when the buffer is full or
+// Flush is called.
+func (w *MessageWriter) WriteMessage(msg util.Message) error {
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	_, err = w.writer.Write(data)
+	return err
+}
+
+// WriteMessages writes the messages as a batch and sends them.
+func (w *MessageWriter) WriteMessages(msgs ...util.Message) error {
+	batch := make([][]byte, 0, len(msgs))
+	for _, msg := range msgs {
+		data, err := msg.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		batch = append(batch, data)
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	for _, data := range batch {
+		if _, err := w.writer.Write(data); err != nil {
+			return err
+		}
+	}
+	return w.writer.Flush()
+}
+
+// Flush sends the buffered messages.
+func (w *MessageWriter) Flush() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.writer.Flush()
+}
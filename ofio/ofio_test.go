@@ -0,0 +1,135 @@
+package ofio
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/contiv/libOpenflow/common"
+	"github.com/contiv/libOpenflow/openflow13"
+	"github.com/contiv/libOpenflow/util"
+)
+
+// parser13 parses the messages with openflow13.Parse.
+type parser13 struct{}
+
+func (p parser13) Parse(b []byte) (util.Message, error) {
+	return openflow13.Parse(b)
+}
+
+func TestMessageReaderWriter(t *testing.T) {
+	local, remote := net.Pipe()
+	defer local.Close()
+	defer remote.Close()
+	reader := NewMessageReader(remote, parser13{}, ReaderOptions{ReadTimeout: 5 * time.Second})
+	writer := NewMessageWriter(local, 0)
+
+	flow := openflow13.NewFlowMod()
+	flow.Priority = 100
+	errCh := make(chan error, 1)
+	go func() {
+		if err := writer.WriteMessage(openflow13.NewEchoRequest()); err != nil {
+			errCh <- err
+			return
+		}
+		errCh <- writer.WriteMessages(flow, openflow13.NewEchoReply())
+	}()
+
+	for _, check := range []func(msg util.Message) bool{
+		func(msg util.Message) bool {
+			h, ok := msg.(*common.Header)
+			return ok && h.Type == openflow13.Type_EchoRequest
+		},
+		func(msg util.Message) bool {
+			f, ok := msg.(*openflow13.FlowMod)
+			return ok && f.Priority == 100
+		},
+		func(msg util.Message) bool {
+			h, ok := msg.(*common.Header)
+			return ok && h.Type == openflow13.Type_EchoReply
+		},
+	} {
+		msg, err := reader.ReadMessage()
+		if err != nil {
+			t.Fatalf("Failed to read message: %v", err)
+		}
+		if !check(msg) {
+			t.Errorf("Unexpected message %+v", msg)
+		}
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("Failed to write messages: %v", err)
+	}
+}
+
+func TestMessageReaderSkipsHandledMessages(t *testing.T) {
+	local, remote := net.Pipe()
+	defer local.Close()
+	defer remote.Close()
+	const vendor, expType = 0x00abcdef, 7
+	parser := openflow13.NewParser(openflow13.DecodeOptions{})
+	parser.RegisterExperimenterHandler(vendor, expType, openflow13.DiscardExperimenter)
+	reader := NewMessageReader(remote, parser, ReaderOptions{ReadTimeout: 5 * time.Second})
+
+	keepalive := &openflow13.VendorHeader{Header: openflow13.NewOfp13Header(), Vendor: vendor, ExperimenterType: expType}
+	keepalive.Header.Type = openflow13.Type_Experimenter
+	go NewMessageWriter(local, 0).WriteMessages(keepalive, openflow13.NewEchoRequest())
+
+	msg, err := reader.ReadMessage()
+	if err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	if h, ok := msg.(*common.Header); !ok || h.Type != openflow13.Type_EchoRequest {
+		t.Errorf("Expect the echo request after the handled message, actual: %+v", msg)
+	}
+}
+
+func TestMessageReaderPartialReads(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close()
+	reader := NewMessageReader(remote, parser13{}, ReaderOptions{MaxMessageSize: 64, ReadTimeout: 5 * time.Second})
+
+	echo := []byte{4, openflow13.Type_EchoRequest, 0, 12, 0, 0, 0, 1, 1, 2, 3, 4}
+	go func() {
+		// One byte per write, then a message over the maximum size, then the echo again and a message cut by
+		// closing.
+		for i := range echo {
+			local.Write(echo[i : i+1])
+		}
+		local.Write([]byte{4, openflow13.Type_EchoRequest, 0, 65, 0, 0, 0, 2})
+		local.Write(make([]byte, 65-8))
+		local.Write(echo)
+		local.Write(echo[:10])
+		local.Close()
+	}()
+
+	data, err := reader.ReadRaw()
+	if err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	if string(data) != string(echo) {
+		t.Errorf("Expect message %v, actual: %v", echo, data)
+	}
+	if _, err = reader.ReadRaw(); err != ErrMessageTooLarge {
+		t.Errorf("Expect ErrMessageTooLarge, actual: %v", err)
+	}
+	// The message over the maximum size is discarded, and the reader continues at the next message.
+	if data, err = reader.ReadRaw(); err != nil || string(data) != string(echo) {
+		t.Errorf("Expect message %v after ErrMessageTooLarge, actual: %v, %v", echo, data, err)
+	}
+	if _, err = reader.ReadRaw(); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expect io.ErrUnexpectedEOF, actual: %v", err)
+	}
+}
+
+func TestMessageReaderTimeout(t *testing.T) {
+	local, remote := net.Pipe()
+	defer local.Close()
+	defer remote.Close()
+	reader := NewMessageReader(remote, parser13{}, ReaderOptions{ReadTimeout: 10 * time.Millisecond})
+	_, err := reader.ReadRaw()
+	if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+		t.Errorf("Expect timeout error, actual: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+// Package ofmsg parses the OpenFlow messages of all the versions implemented by libOpenflow, by the version in
+// the OpenFlow header, so that a controller of the switches speaking different versions doesn't need its own
+// dispatching.
+package ofmsg
+
+import (
+	"fmt"
+
+	"github.com/contiv/libOpenflow/common"
+	"github.com/contiv/libOpenflow/openflow13"
+	"github.com/contiv/libOpenflow/openflow14"
+	"github.com/contiv/libOpenflow/util"
+)
+
+// Parse parses the message with the parser of its version, and returns the message and the version. OpenFlow
+// 1.5 messages are parsed by openflow13.Parse, as the OpenFlow 1.5 messages used with OVS are implemented in
+// package openflow13. An error is returned for the versions not implemented, e.g., OpenFlow 1.0.
+func Parse(data []byte) (util.Message, uint8, error) {
+	if len(data) < 8 {
+		return nil, 0, common.ErrHeaderTooShort
+	}
+	version := data[0]
+	var msg util.Message
+	var err error
+	switch version {
+	case openflow13.VERSION, openflow13.OFP15_VERSION:
+		msg, err = openflow13.Parse(data)
+	case openflow14.VERSION:
+		msg, err = openflow14.Parse(data)
+	default:
+		return nil, version, fmt.Errorf("unsupported OpenFlow version 0x%x", version)
+	}
+	return msg, version, err
+}
+
+// Parser is a util.Parser of the messages of all the implemented versions, e.g., for util.NewMessageStream.
+type Parser struct{}
+
+func (p Parser) Parse(b []byte) (message util.Message, err error) {
+	message, _, err = Parse(b)
+	return
+}
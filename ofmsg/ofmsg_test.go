@@ -0,0 +1,56 @@
+package ofmsg
+
+import (
+	"testing"
+
+	"github.com/contiv/libOpenflow/common"
+	"github.com/contiv/libOpenflow/openflow13"
+	"github.com/contiv/libOpenflow/openflow14"
+	"github.com/contiv/libOpenflow/util"
+)
+
+func TestParse(t *testing.T) {
+	hello, _ := common.NewHello(openflow14.VERSION)
+	controllerStatus := openflow13.NewControllerStatusMsg(openflow13.NewControllerStatus(1, openflow13.OFPCR_ROLE_MASTER, 0, 0))
+	for _, tc := range []struct {
+		msg     util.Message
+		version uint8
+		check   func(msg util.Message) bool
+	}{
+		{openflow13.NewFlowMod(), openflow13.VERSION, func(msg util.Message) bool {
+			_, ok := msg.(*openflow13.FlowMod)
+			return ok
+		}},
+		{openflow14.NewFlowMod(), openflow14.VERSION, func(msg util.Message) bool {
+			_, ok := msg.(*openflow14.FlowMod)
+			return ok
+		}},
+		{controllerStatus, openflow13.OFP15_VERSION, func(msg util.Message) bool {
+			_, ok := msg.(*openflow13.ControllerStatusMsg)
+			return ok
+		}},
+		{hello, openflow14.VERSION, func(msg util.Message) bool {
+			_, ok := msg.(*common.Hello)
+			return ok
+		}},
+	} {
+		data, err := tc.msg.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to marshal %T: %v", tc.msg, err)
+		}
+		msg, version, err := Parse(data)
+		if err != nil {
+			t.Fatalf("Failed to parse %T: %v", tc.msg, err)
+		}
+		if version != tc.version || !tc.check(msg) {
+			t.Errorf("Unexpected message %T of version %d, expect %T of version %d", msg, version, tc.msg, tc.version)
+		}
+	}
+
+	if _, _, err := Parse([]byte{1, openflow13.Type_EchoRequest, 0, 8, 0, 0, 0, 1}); err == nil {
+		t.Errorf("Expect error when parsing OpenFlow 1.0 message")
+	}
+	if _, _, err := Parse([]byte{4, 0}); err != common.ErrHeaderTooShort {
+		t.Errorf("Expect ErrHeaderTooShort, actual: %v", err)
+	}
+}
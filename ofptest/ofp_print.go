@@ -0,0 +1,145 @@
+// Package ofptest provides helpers to verify the encoding of OpenFlow messages with the OVS tools. The checks
+// run in the conformance mode, which is enabled by setting LIBOPENFLOW_OFP_PRINT, e.g.,
+// "LIBOPENFLOW_OFP_PRINT=1 go test ./...", and requires ovs-ofctl. Otherwise the checks are skipped.
+package ofptest
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+// ConformanceEnv is the environment variable which enables the conformance mode.
+const ConformanceEnv = "LIBOPENFLOW_OFP_PRINT"
+
+// OfctlBinary is the name or the path of the ovs-ofctl binary used to print OpenFlow messages.
+var OfctlBinary = "ovs-ofctl"
+
+// ofpPrintHeaderRegex matches the message description printed by ovs-ofctl at the beginning of the output,
+// e.g., "OFPT_FLOW_MOD (OF1.3) (xid=0x1):".
+var ofpPrintHeaderRegex = regexp.MustCompile(`^\S+ \(OF[0-9.x]+\) \(xid=0x[0-9a-f]+\):`)
+
+// ofctlProtocols are the names of the OpenFlow versions in the -O option of ovs-ofctl.
+var ofctlProtocols = map[uint8]string{
+	0x04: "OpenFlow13",
+	0x05: "OpenFlow14",
+	0x06: "OpenFlow15",
+}
+
+// ConformanceEnabled returns true if the conformance mode is enabled by ConformanceEnv.
+func ConformanceEnabled() bool {
+	return os.Getenv(ConformanceEnv) != ""
+}
+
+// OfctlAvailable returns true if the ovs-ofctl binary is found.
+func OfctlAvailable() bool {
+	_, err := exec.LookPath(OfctlBinary)
+	return err == nil
+}
+
+// OfpPrint marshals the message and returns the text decoded from it by "ovs-ofctl ofp-print".
+func OfpPrint(msg util.Message) (string, error) {
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	out, err := exec.Command(OfctlBinary, "ofp-print", hex.EncodeToString(data)).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to run %s ofp-print: %v, output: %s", OfctlBinary, err, string(out))
+	}
+	return string(out), nil
+}
+
+// ParseFlow returns the text of the flow mod encoded by "ovs-ofctl parse-flow" from the flow in the syntax of
+// ovs-ofctl add-flow, with the OpenFlow version. The lines of the usable and chosen protocols are removed.
+func ParseFlow(version uint8, flow string) (string, error) {
+	protocol, ok := ofctlProtocols[version]
+	if !ok {
+		return "", fmt.Errorf("unsupported OpenFlow version 0x%x", version)
+	}
+	out, err := exec.Command(OfctlBinary, "-O", protocol, "parse-flow", flow).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to run %s parse-flow: %v, output: %s", OfctlBinary, err, string(out))
+	}
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if !strings.HasPrefix(line, "usable protocols:") && !strings.HasPrefix(line, "chosen protocol:") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// NormalizeOfpPrint removes the message description in the output of ovs-ofctl ofp-print, and collapses all
+// whitespaces, so that the output could be compared with the String() output of the message.
+func NormalizeOfpPrint(text string) string {
+	text = strings.TrimSpace(text)
+	text = ofpPrintHeaderRegex.ReplaceAllString(text, "")
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// requireOfctl skips the test if the conformance mode is not enabled, and fails it if ovs-ofctl is not
+// installed in the conformance mode.
+func requireOfctl(t testing.TB) {
+	t.Helper()
+	if !ConformanceEnabled() {
+		t.Skipf("%s is not set, skip ofp-print conformance check", ConformanceEnv)
+	}
+	if !OfctlAvailable() {
+		t.Fatalf("%s is not found, it is required by %s", OfctlBinary, ConformanceEnv)
+	}
+}
+
+// CheckOfpPrint compares the output of "ovs-ofctl ofp-print" for the message with the String() output of the
+// message, and reports an error on t if they are different. It is used for the messages whose String() is in
+// the syntax of ofp-print.
+func CheckOfpPrint(t testing.TB, msg interface {
+	util.Message
+	fmt.Stringer
+}) {
+	t.Helper()
+	requireOfctl(t)
+	out, err := OfpPrint(msg)
+	if err != nil {
+		t.Fatalf("Failed to print message with ovs-ofctl: %v", err)
+	}
+	expect := NormalizeOfpPrint(out)
+	actual := NormalizeOfpPrint(msg.String())
+	if expect != actual {
+		t.Errorf("Message text is not equal to ovs-ofctl ofp-print, expect: %q, actual: %q", expect, actual)
+	}
+}
+
+// CheckParseFlow checks the flow mod whose String() is in the syntax of ovs-ofctl add-flow. The flow mod encoded
+// by the message and the one encoded by "ovs-ofctl parse-flow" from its String() must be printed the same by
+// ovs-ofctl, otherwise an error is reported on t.
+func CheckParseFlow(t testing.TB, msg interface {
+	util.Message
+	fmt.Stringer
+}) {
+	t.Helper()
+	requireOfctl(t)
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+	out, err := OfpPrint(msg)
+	if err != nil {
+		t.Fatalf("Failed to print message with ovs-ofctl: %v", err)
+	}
+	parsed, err := ParseFlow(data[0], msg.String())
+	if err != nil {
+		t.Fatalf("Failed to parse flow %q with ovs-ofctl: %v", msg.String(), err)
+	}
+	expect := NormalizeOfpPrint(parsed)
+	actual := NormalizeOfpPrint(out)
+	if expect != actual {
+		t.Errorf("Flow mod is not equal to ovs-ofctl parse-flow of %q, expect: %q, actual: %q", msg.String(), expect, actual)
+	}
+}
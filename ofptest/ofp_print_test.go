@@ -0,0 +1,88 @@
+package ofptest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeOfctl prints the flow mod of ofp-print, and the flow of parse-flow after the protocol lines.
+const fakeOfctl = `#!/bin/sh
+case "$1" in
+ofp-print)
+	echo "OFPT_FLOW_MOD (OF1.3) (xid=0x1): ADD priority=100 actions=drop"
+	;;
+-O)
+	[ "$2" = OpenFlow13 ] && [ "$3" = parse-flow ] || exit 1
+	printf 'usable protocols: OXM\nchosen protocol: OpenFlow13\nOFPT_FLOW_MOD (OF1.3) (xid=0x2):  ADD %s\n' "$4"
+	;;
+esac
+`
+
+// textMessage is a message of fixed data and text.
+type textMessage struct {
+	data []byte
+	text string
+}
+
+func (m *textMessage) Len() uint16                       { return uint16(len(m.data)) }
+func (m *textMessage) MarshalBinary() ([]byte, error)    { return m.data, nil }
+func (m *textMessage) UnmarshalBinary(data []byte) error { m.data = data; return nil }
+func (m *textMessage) String() string                    { return m.text }
+
+// errorRecorder records the errors reported by the checks.
+type errorRecorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *errorRecorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func useFakeOfctl(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ovs-ofctl")
+	if err := os.WriteFile(path, []byte(fakeOfctl), 0755); err != nil {
+		t.Fatalf("Failed to write fake ovs-ofctl: %v", err)
+	}
+	binary := OfctlBinary
+	OfctlBinary = path
+	t.Cleanup(func() { OfctlBinary = binary })
+}
+
+func TestNormalizeOfpPrint(t *testing.T) {
+	text := "OFPT_FLOW_MOD (OF1.3) (xid=0x1a): ADD  table:1\n priority=100 actions=drop\n"
+	if normalized := NormalizeOfpPrint(text); normalized != "ADD table:1 priority=100 actions=drop" {
+		t.Errorf("Unexpected normalized text: %q", normalized)
+	}
+}
+
+func TestConformanceMode(t *testing.T) {
+	useFakeOfctl(t)
+	msg := &textMessage{data: []byte{4, 14, 0, 8, 0, 0, 0, 1}, text: "ADD priority=100 actions=drop"}
+
+	t.Setenv(ConformanceEnv, "")
+	if !t.Run("disabled", func(t *testing.T) {
+		CheckOfpPrint(t, msg)
+		t.Errorf("Expect the check skipped without %s", ConformanceEnv)
+	}) {
+		t.Errorf("Expect the check skipped without %s", ConformanceEnv)
+	}
+
+	t.Setenv(ConformanceEnv, "1")
+	recorder := &errorRecorder{TB: t}
+	CheckOfpPrint(recorder, msg)
+	msg.text = "priority=100 actions=drop"
+	CheckParseFlow(recorder, msg)
+	if len(recorder.errors) != 0 {
+		t.Errorf("Unexpected errors of the checks: %v", recorder.errors)
+	}
+
+	msg.text = "priority=200 actions=drop"
+	CheckOfpPrint(recorder, msg)
+	CheckParseFlow(recorder, msg)
+	if len(recorder.errors) != 2 {
+		t.Errorf("Expect 2 errors of the different text, actual: %v", recorder.errors)
+	}
+}
@@ -3,6 +3,8 @@ package openflow13
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
+	"sort"
 
 	"github.com/contiv/libOpenflow/util"
 )
@@ -25,10 +27,54 @@ const (
 	ActionType_SetField   = 25
 	ActionType_PushPbb    = 26
 	ActionType_PopPbb     = 27
+	ActionType_CopyField  = 28 /* OpenFlow 1.5 */
 
 	ActionType_Experimenter = 0xffff
 )
 
+// actionSetOrder returns the position of the action in the execution order of the action set, per section 5.10
+// of the OpenFlow 1.3 specification. The experimenter actions are kept with the set actions.
+func actionSetOrder(act Action) int {
+	switch act.Header().Type {
+	case ActionType_CopyTtlIn:
+		return 0
+	case ActionType_PopVlan, ActionType_PopMpls, ActionType_PopPbb:
+		return 1
+	case ActionType_PushMpls:
+		return 2
+	case ActionType_PushPbb:
+		return 3
+	case ActionType_PushVlan:
+		return 4
+	case ActionType_CopyTtlOut:
+		return 5
+	case ActionType_DecMplsTtl, ActionType_DecNwTtl:
+		return 6
+	case ActionType_SetQueue:
+		return 8
+	case ActionType_Group:
+		return 9
+	case ActionType_Output:
+		return 10
+	default:
+		// ActionType_SetField, ActionType_CopyField, ActionType_SetMplsTtl, ActionType_SetNwTtl and experimenter
+		// actions.
+		return 7
+	}
+}
+
+// SortActionSet returns the actions in the order they are executed in the action set, e.g., pop before push,
+// set-field before output. The actions in the same step keep their relative order. The given slice is not
+// modified.
+func SortActionSet(actions []Action) []Action {
+	sorted := make([]Action, len(actions))
+	copy(sorted, actions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return actionSetOrder(sorted[i]) < actionSetOrder(sorted[j])
+	})
+	return sorted
+}
+
 type Action interface {
 	Header() *ActionHeader
 	util.Message
@@ -66,7 +112,16 @@ func (a *ActionHeader) UnmarshalBinary(data []byte) error {
 
 // Decode Action types.
 func DecodeAction(data []byte) (Action, error) {
+	if len(data) < 4 {
+		return nil, errors.New("the []byte is too short to decode an action")
+	}
 	t := binary.BigEndian.Uint16(data[:2])
+	// The action is decoded within its length, so that a malformed length can't make it read the next one.
+	length := int(binary.BigEndian.Uint16(data[2:4]))
+	if length < 4 || length > len(data) {
+		return nil, fmt.Errorf("invalid length %d of action type %d in %d bytes", length, t, len(data))
+	}
+	data = data[:length]
 	var a Action
 	switch t {
 	case ActionType_Output:
@@ -82,7 +137,7 @@ func DecodeAction(data []byte) (Action, error) {
 	case ActionType_PushVlan:
 		a = new(ActionPush)
 	case ActionType_PopVlan:
-		a = new(ActionHeader)
+		a = new(ActionPopVlan)
 	case ActionType_PushMpls:
 		a = new(ActionPush)
 	case ActionType_PopMpls:
@@ -101,6 +156,8 @@ func DecodeAction(data []byte) (Action, error) {
 		a = new(ActionPush)
 	case ActionType_PopPbb:
 		a = new(ActionHeader)
+	case ActionType_CopyField:
+		a = new(ActionCopyField)
 	case ActionType_Experimenter:
 		// For Experimenter message, the length of action should be at least 10 bytes,
 		// including type(2 byte), length(2 byte), vendor(4 byte), and subtype(2 byte)
@@ -112,6 +169,9 @@ func DecodeAction(data []byte) (Action, error) {
 			a = DecodeNxAction(data)
 		}
 	}
+	if a == nil {
+		return nil, fmt.Errorf("unsupported action type %d", t)
+	}
 	err := a.UnmarshalBinary(data)
 	if err != nil {
 		return a, err
@@ -127,7 +187,7 @@ type ActionOutput struct {
 	ActionHeader
 	Port   uint32
 	MaxLen uint16
-	pad    []byte // 6 bytes to make it 64bit aligned
+	pad    [6]byte // 6 bytes to make it 64bit aligned
 }
 
 // ofp_controller_max_len 1.3
@@ -144,7 +204,6 @@ func NewActionOutput(portNum uint32) *ActionOutput {
 	act.Length = act.Len()
 	act.Port = portNum
 	act.MaxLen = 256
-	act.pad = make([]byte, 6)
 	return act
 }
 
@@ -164,7 +223,7 @@ func (a *ActionOutput) MarshalBinary() (data []byte, err error) {
 	n += 4
 	binary.BigEndian.PutUint16(data[n:], a.MaxLen)
 	n += 2
-	copy(data[n:], a.pad)
+	copy(data[n:], a.pad[:])
 	n += len(a.pad)
 
 	return
@@ -182,7 +241,7 @@ func (a *ActionOutput) UnmarshalBinary(data []byte) error {
 	n += 4
 	a.MaxLen = binary.BigEndian.Uint16(data[n:])
 	n += 2
-	copy(a.pad, data[n:n+6])
+	copy(a.pad[:], data[n:n+6])
 	n += 6
 	return err
 }
@@ -272,19 +331,18 @@ func (a *ActionGroup) UnmarshalBinary(data []byte) error {
 type ActionMplsTtl struct {
 	ActionHeader
 	MplsTtl uint8
-	pad     []byte // 3bytes
+	pad     [3]byte // 3bytes
 }
 
 type ActionDecNwTtl struct {
 	ActionHeader
-	pad []byte // 4bytes
+	pad [4]byte // 4bytes
 }
 
 func NewActionDecNwTtl() *ActionDecNwTtl {
 	act := new(ActionDecNwTtl)
 	act.Type = ActionType_DecNwTtl
 	act.Length = act.Len()
-	act.pad = make([]byte, 4)
 	return act
 }
 
@@ -305,19 +363,19 @@ func (a *ActionDecNwTtl) MarshalBinary() (data []byte, err error) {
 }
 
 func (a *ActionDecNwTtl) UnmarshalBinary(data []byte) error {
-	return a.ActionHeader.UnmarshalBinary(data[:4])
+	return a.ActionHeader.UnmarshalBinary(data)
 }
 
 type ActionNwTtl struct {
 	ActionHeader
 	NwTtl uint8
-	pad   []byte // 3bytes
+	pad   [3]byte // 3bytes
 }
 
 type ActionPush struct {
 	ActionHeader
 	EtherType uint16
-	pad       []byte // 2bytes
+	pad       [2]byte // 2bytes
 }
 
 func NewActionPushVlan(etherType uint16) *ActionPush {
@@ -351,6 +409,9 @@ func (a *ActionPush) MarshalBinary() (data []byte, err error) {
 }
 
 func (a *ActionPush) UnmarshalBinary(data []byte) error {
+	if len(data) < int(a.Len()) {
+		return errors.New("the []byte is too short to unmarshal an ActionPush message")
+	}
 	a.ActionHeader.UnmarshalBinary(data[:4])
 	a.EtherType = binary.BigEndian.Uint16(data[4:])
 	return nil
@@ -358,7 +419,7 @@ func (a *ActionPush) UnmarshalBinary(data []byte) error {
 
 type ActionPopVlan struct {
 	ActionHeader
-	pad []byte // 4bytes
+	pad [4]byte // 4bytes
 }
 
 func NewActionPopVlan() *ActionPopVlan {
@@ -384,14 +445,13 @@ func (a *ActionPopVlan) MarshalBinary() (data []byte, err error) {
 }
 
 func (a *ActionPopVlan) UnmarshalBinary(data []byte) error {
-	a.ActionHeader.UnmarshalBinary(data[:4])
-	return nil
+	return a.ActionHeader.UnmarshalBinary(data)
 }
 
 type ActionPopMpls struct {
 	ActionHeader
 	EtherType uint16
-	pad       []byte // 2bytes
+	pad       [2]byte // 2bytes
 }
 
 func NewActionPopMpls(etherType uint16) *ActionPopMpls {
@@ -419,6 +479,9 @@ func (a *ActionPopMpls) MarshalBinary() (data []byte, err error) {
 }
 
 func (a *ActionPopMpls) UnmarshalBinary(data []byte) error {
+	if len(data) < int(a.Len()) {
+		return errors.New("the []byte is too short to unmarshal an ActionPopMpls message")
+	}
 	a.ActionHeader.UnmarshalBinary(data[:4])
 	a.EtherType = binary.BigEndian.Uint16(data[4:])
 	return nil
@@ -457,12 +520,145 @@ func (a *ActionSetField) MarshalBinary() (data []byte, err error) {
 
 	return
 }
+
+// Validate checks the field to set. OXM_OF_METADATA can't be the field of a set-field action, use the
+// write-metadata instruction to modify the metadata.
+func (a *ActionSetField) Validate() error {
+	if a.Field.Class == OXM_CLASS_OPENFLOW_BASIC && a.Field.Field == OXM_FIELD_METADATA {
+		return errors.New("the metadata can't be modified with set-field action, use write-metadata instruction instead")
+	}
+	return nil
+}
+
 func (a *ActionSetField) UnmarshalBinary(data []byte) error {
 	n := 0
-	err := a.ActionHeader.UnmarshalBinary(data[n:])
+	if err := a.ActionHeader.UnmarshalBinary(data[n:]); err != nil {
+		return err
+	}
 	n += int(a.ActionHeader.Len())
-	err = a.Field.UnmarshalBinary(data[n:])
-	n += int(a.Field.Len())
+	return a.Field.UnmarshalBinary(data[n:])
+}
 
-	return err
+// ActionCopyField is the OFPAT_COPY_FIELD action of OpenFlow 1.5, which copies NBits bits from SrcOffset of
+// SrcField to DstOffset of DstField. Only the headers of the fields are used.
+type ActionCopyField struct {
+	ActionHeader
+	NBits     uint16
+	SrcOffset uint16
+	DstOffset uint16
+	pad       [2]byte
+	SrcField  MatchField
+	DstField  MatchField
+}
+
+func NewActionCopyField(nBits uint16, srcOffset uint16, dstOffset uint16, srcField MatchField, dstField MatchField) *ActionCopyField {
+	a := new(ActionCopyField)
+	a.Type = ActionType_CopyField
+	a.NBits = nBits
+	a.SrcOffset = srcOffset
+	a.DstOffset = dstOffset
+	a.SrcField = srcField
+	a.DstField = dstField
+	a.Length = a.Len()
+	return a
+}
+
+// oxmIDLen returns the length of the field in oxm_ids, the experimenter ID follows the header of an experimenter
+// field.
+func oxmIDLen(field *MatchField) uint16 {
+	if field.Class == OXM_CLASS_EXPERIMENTER {
+		return 8
+	}
+	return 4
+}
+
+func (a *ActionCopyField) Len() (n uint16) {
+	n = a.ActionHeader.Len() + 8 + oxmIDLen(&a.SrcField) + oxmIDLen(&a.DstField)
+	// Round it to closest multiple of 8
+	n = ((n + 7) / 8) * 8
+	return
+}
+
+func (a *ActionCopyField) MarshalBinary() (data []byte, err error) {
+	a.Length = a.Len()
+	data = make([]byte, int(a.Len()))
+	n := 0
+	b, err := a.ActionHeader.MarshalBinary()
+	copy(data, b)
+	n += int(a.ActionHeader.Len())
+	binary.BigEndian.PutUint16(data[n:], a.NBits)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], a.SrcOffset)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], a.DstOffset)
+	n += 2
+	n += 2 // for pad
+	for _, field := range []*MatchField{&a.SrcField, &a.DstField} {
+		binary.BigEndian.PutUint32(data[n:], field.MarshalHeader())
+		n += 4
+		if field.Class == OXM_CLASS_EXPERIMENTER {
+			binary.BigEndian.PutUint32(data[n:], field.ExperimenterID)
+			n += 4
+		}
+	}
+	return
+}
+
+func (a *ActionCopyField) UnmarshalBinary(data []byte) error {
+	n := 0
+	if err := a.ActionHeader.UnmarshalBinary(data[n:]); err != nil {
+		return err
+	}
+	if int(a.Length) > len(data) || a.Length < 20 {
+		return errors.New("the []byte is too short to unmarshal a full ActionCopyField message")
+	}
+	n += int(a.ActionHeader.Len())
+	a.NBits = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	a.SrcOffset = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	a.DstOffset = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	n += 2 // for pad
+	for _, field := range []*MatchField{&a.SrcField, &a.DstField} {
+		*field = MatchField{}
+		if n+4 > int(a.Length) {
+			return errors.New("the []byte is too short to unmarshal a full ActionCopyField message")
+		}
+		if err := field.UnmarshalHeader(data[n:]); err != nil {
+			return err
+		}
+		n += 4
+		if field.Class == OXM_CLASS_EXPERIMENTER {
+			if n+4 > int(a.Length) {
+				return errors.New("the []byte is too short to unmarshal a full ActionCopyField message")
+			}
+			field.ExperimenterID = binary.BigEndian.Uint32(data[n:])
+			n += 4
+		}
+	}
+	return nil
+}
+
+// Validate checks that both the source and the destination have NBits bits from their offsets, and the fields
+// are not masked.
+func (a *ActionCopyField) Validate() error {
+	if a.NBits == 0 {
+		return errors.New("copy-field action copies no bits")
+	}
+	ranges := []struct {
+		name   string
+		field  *MatchField
+		offset uint16
+	}{{"source", &a.SrcField, a.SrcOffset}, {"destination", &a.DstField, a.DstOffset}}
+	for _, r := range ranges {
+		if r.field.HasMask {
+			return fmt.Errorf("the %s field of copy-field action is masked", r.name)
+		}
+		if bits := oxmFieldBits(r.field); int(r.offset)+int(a.NBits) > bits {
+			return fmt.Errorf("the %s field of copy-field action has %d bits, less than offset %d plus %d bits",
+				r.name, bits, r.offset, a.NBits)
+		}
+	}
+	return nil
 }
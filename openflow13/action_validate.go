@@ -0,0 +1,317 @@
+package openflow13
+
+import (
+	"fmt"
+
+	"github.com/contiv/libOpenflow/protocol"
+)
+
+// MaxActionsLen is the maximum length of the actions of an instruction, so that the FlowMod with the smallest
+// match and the instruction header fits in the 16-bit length of the message.
+const MaxActionsLen = 0xffff - 48 - 8 - 8
+
+// ActionError is returned by ValidateActions for the actions which the switch would reject, Code is the BAC_*
+// code of the error which the switch would reply with, and Index is the index of the invalid action, or -1 if
+// the error is about the whole list.
+type ActionError struct {
+	Code   uint16
+	Index  int
+	Reason string
+}
+
+func (e *ActionError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("invalid actions, code %d: %s", e.Code, e.Reason)
+	}
+	return fmt.Sprintf("invalid action %d, code %d: %s", e.Index, e.Code, e.Reason)
+}
+
+func newActionError(code uint16, index int, format string, args ...interface{}) *ActionError {
+	return &ActionError{Code: code, Index: index, Reason: fmt.Sprintf(format, args...)}
+}
+
+// fieldPrerequisite is the eth_type and the ip_proto which the packet must have to set a field, ipProto 0 means
+// any protocol.
+type fieldPrerequisite struct {
+	ethTypes []uint16
+	ipProto  uint8
+}
+
+var (
+	ipEthTypes   = []uint16{protocol.IPv4_MSG, protocol.IPv6_MSG}
+	ipv4EthTypes = []uint16{protocol.IPv4_MSG}
+	ipv6EthTypes = []uint16{protocol.IPv6_MSG}
+	arpEthTypes  = []uint16{protocol.ARP_MSG}
+	mplsEthTypes = []uint16{0x8847, 0x8848}
+)
+
+// fieldPrerequisites maps the OXM and NXM names of the fields to the prerequisites to set them, as the
+// prerequisites to match them.
+var fieldPrerequisites = map[string]fieldPrerequisite{
+	"OXM_OF_IP_DSCP":     {ethTypes: ipEthTypes},
+	"OXM_OF_IP_ECN":      {ethTypes: ipEthTypes},
+	"OXM_OF_IP_PROTO":    {ethTypes: ipEthTypes},
+	"NXM_OF_IP_TOS":      {ethTypes: ipEthTypes},
+	"NXM_NX_IP_ECN":      {ethTypes: ipEthTypes},
+	"NXM_NX_IP_TTL":      {ethTypes: ipEthTypes},
+	"OXM_OF_IPV4_SRC":    {ethTypes: ipv4EthTypes},
+	"OXM_OF_IPV4_DST":    {ethTypes: ipv4EthTypes},
+	"NXM_OF_IP_SRC":      {ethTypes: ipv4EthTypes},
+	"NXM_OF_IP_DST":      {ethTypes: ipv4EthTypes},
+	"OXM_OF_IPV6_SRC":    {ethTypes: ipv6EthTypes},
+	"OXM_OF_IPV6_DST":    {ethTypes: ipv6EthTypes},
+	"OXM_OF_IPV6_FLABEL": {ethTypes: ipv6EthTypes},
+	"NXM_NX_IPV6_SRC":    {ethTypes: ipv6EthTypes},
+	"NXM_NX_IPV6_DST":    {ethTypes: ipv6EthTypes},
+	"NXM_NX_IPV6_LABEL":  {ethTypes: ipv6EthTypes},
+	"OXM_OF_TCP_SRC":     {ethTypes: ipEthTypes, ipProto: protocol.Type_TCP},
+	"OXM_OF_TCP_DST":     {ethTypes: ipEthTypes, ipProto: protocol.Type_TCP},
+	"NXM_OF_TCP_SRC":     {ethTypes: ipEthTypes, ipProto: protocol.Type_TCP},
+	"NXM_OF_TCP_DST":     {ethTypes: ipEthTypes, ipProto: protocol.Type_TCP},
+	"OXM_OF_UDP_SRC":     {ethTypes: ipEthTypes, ipProto: protocol.Type_UDP},
+	"OXM_OF_UDP_DST":     {ethTypes: ipEthTypes, ipProto: protocol.Type_UDP},
+	"NXM_OF_UDP_SRC":     {ethTypes: ipEthTypes, ipProto: protocol.Type_UDP},
+	"NXM_OF_UDP_DST":     {ethTypes: ipEthTypes, ipProto: protocol.Type_UDP},
+	"OXM_OF_SCTP_SRC":    {ethTypes: ipEthTypes, ipProto: protocol.Type_SCTP},
+	"OXM_OF_SCTP_DST":    {ethTypes: ipEthTypes, ipProto: protocol.Type_SCTP},
+	"OXM_OF_ICMPV4_TYPE": {ethTypes: ipv4EthTypes, ipProto: protocol.Type_ICMP},
+	"OXM_OF_ICMPV4_CODE": {ethTypes: ipv4EthTypes, ipProto: protocol.Type_ICMP},
+	"NXM_OF_ICMP_TYPE":   {ethTypes: ipv4EthTypes, ipProto: protocol.Type_ICMP},
+	"NXM_OF_ICMP_CODE":   {ethTypes: ipv4EthTypes, ipProto: protocol.Type_ICMP},
+	"OXM_OF_ICMPV6_TYPE": {ethTypes: ipv6EthTypes, ipProto: protocol.Type_IPv6ICMP},
+	"OXM_OF_ICMPV6_CODE": {ethTypes: ipv6EthTypes, ipProto: protocol.Type_IPv6ICMP},
+	"NXM_NX_ICMPV6_TYPE": {ethTypes: ipv6EthTypes, ipProto: protocol.Type_IPv6ICMP},
+	"NXM_NX_ICMPV6_CODE": {ethTypes: ipv6EthTypes, ipProto: protocol.Type_IPv6ICMP},
+	"OXM_OF_ARP_OP":      {ethTypes: arpEthTypes},
+	"OXM_OF_ARP_SPA":     {ethTypes: arpEthTypes},
+	"OXM_OF_ARP_TPA":     {ethTypes: arpEthTypes},
+	"OXM_OF_ARP_SHA":     {ethTypes: arpEthTypes},
+	"OXM_OF_ARP_THA":     {ethTypes: arpEthTypes},
+	"NXM_OF_ARP_OP":      {ethTypes: arpEthTypes},
+	"NXM_OF_ARP_SPA":     {ethTypes: arpEthTypes},
+	"NXM_OF_ARP_TPA":     {ethTypes: arpEthTypes},
+	"NXM_NX_ARP_SHA":     {ethTypes: arpEthTypes},
+	"NXM_NX_ARP_THA":     {ethTypes: arpEthTypes},
+	"OXM_OF_MPLS_LABEL":  {ethTypes: mplsEthTypes},
+	"OXM_OF_MPLS_TC":     {ethTypes: mplsEthTypes},
+	"OXM_OF_MPLS_BOS":    {ethTypes: mplsEthTypes},
+}
+
+// packetState is the eth_type and the ip_proto of the packet known from the match and the actions, nil if
+// unknown.
+type packetState struct {
+	ethType *uint16
+	ipProto *uint8
+}
+
+func newPacketState(match *Match) *packetState {
+	s := new(packetState)
+	if match == nil {
+		return s
+	}
+	for i := range match.Fields {
+		f := &match.Fields[i]
+		if f.HasMask {
+			continue
+		}
+		switch v := f.Value.(type) {
+		case *EthTypeField:
+			ethType := v.EthType
+			s.ethType = &ethType
+		case *IpProtoField:
+			ipProto := v.protocol
+			s.ipProto = &ipProto
+		}
+	}
+	return s
+}
+
+// check returns the reason if the packet could not have the field to set, which is valid only if the match
+// or the previous actions make sure of the prerequisites of the field.
+func (s *packetState) check(field *MatchField) (string, bool) {
+	name := oxxFieldName(field)
+	prereq, ok := fieldPrerequisites[name]
+	if !ok {
+		return "", true
+	}
+	if s.ethType == nil {
+		return fmt.Sprintf("%s requires eth_type in the match", name), false
+	}
+	found := false
+	for _, ethType := range prereq.ethTypes {
+		found = found || ethType == *s.ethType
+	}
+	if !found {
+		return fmt.Sprintf("%s requires eth_type 0x%04x, but the packet has 0x%04x", name, prereq.ethTypes[0], *s.ethType), false
+	}
+	if prereq.ipProto != 0 && (s.ipProto == nil || *s.ipProto != prereq.ipProto) {
+		return fmt.Sprintf("%s requires ip_proto %d in the match", name, prereq.ipProto), false
+	}
+	return "", true
+}
+
+// ValidateActions checks the action list of an apply-actions instruction or a PacketOut the same way as OVS,
+// which would otherwise reject the FlowMod with the BAC_* error, see ValidateActionsWithMatch to check the
+// prerequisites of the fields to set too. The returned error is an *ActionError:
+//   - conjunction actions must be the only actions of the list;
+//   - nat must be in the actions of ct, and ct may only execute the set-field and load actions of ct_mark
+//     and ct_label;
+//   - the output ports must be valid, and the groups must be usable groups;
+//   - the set-field actions must not set the metadata;
+//   - the actions must have a multiple of 8 bytes and the list must fit in MaxActionsLen.
+func ValidateActions(actions []Action) error {
+	return validateActions(nil, actions)
+}
+
+// ValidateActionsWithMatch checks the actions as ValidateActions, and checks the fields set by the set-field,
+// load and move actions against the eth_type and ip_proto of the match, e.g., set_field of ipv4_src requires
+// eth_type=0x0800 in the match, otherwise the switch replies with BAC_MATCH_INCONSISTENT. The push_mpls and
+// pop_mpls actions change the eth_type of the following actions.
+func ValidateActionsWithMatch(match *Match, actions []Action) error {
+	if match == nil {
+		match = NewMatch()
+	}
+	return validateActions(newPacketState(match), actions)
+}
+
+// ValidateActionSet checks the actions of a write-actions instruction as ValidateActionsWithMatch, and checks
+// that the action set has at most one action of each type, and not both output and group, as the output would
+// be ignored by the switch.
+func ValidateActionSet(match *Match, actions []Action) error {
+	if err := ValidateActionsWithMatch(match, actions); err != nil {
+		return err
+	}
+	var output, group bool
+	setFields := make(map[string]bool)
+	types := make(map[uint16]bool)
+	for i, act := range actions {
+		header := act.Header()
+		switch a := act.(type) {
+		case *ActionOutput:
+			output = true
+		case *ActionGroup:
+			group = true
+		case *ActionSetField:
+			name := oxxFieldName(&a.Field)
+			if setFields[name] {
+				return newActionError(BAC_UNSUPPORTED_ORDER, i, "%s is set twice in the action set", name)
+			}
+			setFields[name] = true
+			continue
+		}
+		if header.Type != ActionType_Experimenter && types[header.Type] {
+			return newActionError(BAC_UNSUPPORTED_ORDER, i, "action type %d is twice in the action set", header.Type)
+		}
+		types[header.Type] = true
+	}
+	if output && group {
+		return newActionError(BAC_UNSUPPORTED_ORDER, -1, "the output is ignored with the group in the action set")
+	}
+	return nil
+}
+
+func validateActions(state *packetState, actions []Action) error {
+	length := 0
+	conjunctions := 0
+	for i, act := range actions {
+		if act.Len()%8 != 0 {
+			return newActionError(BAC_BAD_LEN, i, "length %d is not a multiple of 8", act.Len())
+		}
+		length += int(act.Len())
+		if err := validateAction(state, i, act); err != nil {
+			return err
+		}
+		if _, ok := act.(*NXActionConjunction); ok {
+			conjunctions++
+		}
+	}
+	if conjunctions > 0 && conjunctions != len(actions) {
+		return newActionError(BAC_UNSUPPORTED_ORDER, -1, "conjunction actions may not be mixed with other actions")
+	}
+	if length > MaxActionsLen {
+		return newActionError(BAC_BAD_LEN, -1, "the actions of %d bytes exceed %d bytes", length, MaxActionsLen)
+	}
+	return nil
+}
+
+func validateAction(state *packetState, i int, act Action) error {
+	checkField := func(field *MatchField) error {
+		if state == nil || field == nil {
+			return nil
+		}
+		if reason, ok := state.check(field); !ok {
+			return newActionError(BAC_MATCH_INCONSISTENT, i, reason)
+		}
+		return nil
+	}
+	switch a := act.(type) {
+	case *ActionOutput:
+		if a.Port == 0 || a.Port == P_ANY || (a.Port > P_MAX && a.Port < P_IN_PORT) {
+			return newActionError(BAC_BAD_OUT_PORT, i, "output to port 0x%x", a.Port)
+		}
+	case *ActionGroup:
+		if a.GroupId > OFPG_MAX {
+			return newActionError(BAC_BAD_OUT_GROUP, i, "group 0x%x", a.GroupId)
+		}
+	case *ActionSetField:
+		if err := a.Validate(); err != nil {
+			return newActionError(BAC_BAD_SET_TYPE, i, err.Error())
+		}
+		return checkField(&a.Field)
+	case *ActionCopyField:
+		if err := a.Validate(); err != nil {
+			return newActionError(BAC_BAD_ARGUMENT, i, err.Error())
+		}
+		if err := checkField(&a.SrcField); err != nil {
+			return err
+		}
+		return checkField(&a.DstField)
+	case *NXActionRegLoad:
+		return checkField(a.DstReg)
+	case *NXActionRegLoad2:
+		return checkField(a.DstField)
+	case *NXActionRegMove:
+		if err := checkField(a.SrcField); err != nil {
+			return err
+		}
+		return checkField(a.DstField)
+	case *ActionPush:
+		if a.Type == ActionType_PushMpls && state != nil {
+			ethType := a.EtherType
+			state.ethType, state.ipProto = &ethType, nil
+		}
+	case *ActionPopMpls:
+		if state != nil {
+			ethType := a.EtherType
+			state.ethType, state.ipProto = &ethType, nil
+		}
+	case *NXActionCTNAT:
+		return newActionError(BAC_BAD_ARGUMENT, i, "nat must be in the actions of ct")
+	case *NXActionConnTrack:
+		for _, nested := range a.actions {
+			switch n := nested.(type) {
+			case *NXActionCTNAT:
+			case *ActionSetField:
+				if !isCTExecField(&n.Field) {
+					return newActionError(BAC_BAD_ARGUMENT, i, "ct may not set %s", oxxFieldName(&n.Field))
+				}
+			case *NXActionRegLoad:
+				if !isCTExecField(n.DstReg) {
+					return newActionError(BAC_BAD_ARGUMENT, i, "ct may not load %s", oxxFieldName(n.DstReg))
+				}
+			case *NXActionRegLoad2:
+				if !isCTExecField(n.DstField) {
+					return newActionError(BAC_BAD_ARGUMENT, i, "ct may not load %s", oxxFieldName(n.DstField))
+				}
+			default:
+				return newActionError(BAC_BAD_ARGUMENT, i, "ct may not execute action %T", nested)
+			}
+		}
+	}
+	return nil
+}
+
+// isCTExecField returns whether the field could be set in the actions of ct, i.e., ct_mark and ct_label.
+func isCTExecField(field *MatchField) bool {
+	return field.Class == OXM_CLASS_NXM_1 && (field.Field == NXM_NX_CT_MARK || field.Field == NXM_NX_CT_LABEL)
+}
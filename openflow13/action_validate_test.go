@@ -0,0 +1,117 @@
+package openflow13
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/contiv/libOpenflow/protocol"
+)
+
+func TestFieldPrerequisiteNames(t *testing.T) {
+	for name := range fieldPrerequisites {
+		if _, ok := oxxFieldHeaderMap[name]; !ok {
+			t.Errorf("Unknown field %s in the prerequisites", name)
+		}
+	}
+}
+
+func TestValidateActions(t *testing.T) {
+	ctMark := NewCTMarkMatchField(1, nil)
+	nat := NewNXActionCTNAT()
+	for _, tc := range []struct {
+		name    string
+		actions []Action
+		code    uint16
+		index   int
+	}{
+		{"conjunction with output", []Action{NewNXActionConjunction(0, 2, 1), NewActionOutput(1)}, BAC_UNSUPPORTED_ORDER, -1},
+		{"nat outside ct", []Action{nat}, BAC_BAD_ARGUMENT, 0},
+		{"ct exec output", []Action{NewNXActionConnTrack().Commit().AddAction(NewActionOutput(1))}, BAC_BAD_ARGUMENT, 0},
+		{"output to port 0", []Action{NewActionOutput(2), NewActionOutput(0)}, BAC_BAD_OUT_PORT, 1},
+		{"reserved group", []Action{NewActionGroup(OFPG_ALL)}, BAC_BAD_OUT_GROUP, 0},
+		{"set metadata", []Action{NewActionSetField(*NewMetadataField(1, nil))}, BAC_BAD_SET_TYPE, 0},
+	} {
+		err := ValidateActions(tc.actions)
+		var actionErr *ActionError
+		if !errors.As(err, &actionErr) || actionErr.Code != tc.code || actionErr.Index != tc.index {
+			t.Errorf("%s: expect error code %d of action %d, actual: %v", tc.name, tc.code, tc.index, err)
+		}
+	}
+
+	for _, actions := range [][]Action{
+		{NewNXActionConjunction(0, 2, 1), NewNXActionConjunction(1, 2, 2)},
+		{NewNXActionConnTrack().Commit().AddAction(NewNXActionRegLoad2(ctMark), nat), NewActionOutput(P_IN_PORT)},
+		{NewActionGroup(1), NewActionOutput(P_CONTROLLER)},
+	} {
+		if err := ValidateActions(actions); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+}
+
+func TestValidateActionsWithMatch(t *testing.T) {
+	setIPv4Src := NewActionSetField(*NewIpv4SrcField(net.ParseIP("10.0.0.1"), nil))
+	setTCPDst, _ := NewPortMaskField(OXM_FIELD_TCP_DST, PortMask{Port: 80, Mask: 0xffff})
+	ipMatch, _ := NewMatchBuilder().SetIPProto(protocol.Type_TCP).Build()
+
+	if err := ValidateActionsWithMatch(ipMatch, []Action{setIPv4Src, NewActionSetField(*setTCPDst)}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	var actionErr *ActionError
+	if err := ValidateActionsWithMatch(NewMatch(), []Action{setIPv4Src}); !errors.As(err, &actionErr) || actionErr.Code != BAC_MATCH_INCONSISTENT {
+		t.Errorf("Expect BAC_MATCH_INCONSISTENT without eth_type, actual: %v", err)
+	}
+	udpMatch, _ := NewMatchBuilder().SetIPProto(protocol.Type_UDP).Build()
+	if err := ValidateActionsWithMatch(udpMatch, []Action{NewActionSetField(*setTCPDst)}); !errors.As(err, &actionErr) || actionErr.Code != BAC_MATCH_INCONSISTENT {
+		t.Errorf("Expect BAC_MATCH_INCONSISTENT for tcp_dst with udp, actual: %v", err)
+	}
+	if err := ValidateActionsWithMatch(ipMatch, []Action{NewActionPushMpls(0x8847), setIPv4Src}); !errors.As(err, &actionErr) || actionErr.Index != 1 {
+		t.Errorf("Expect error after push_mpls, actual: %v", err)
+	}
+
+	if err := ValidateActionSet(ipMatch, []Action{NewActionOutput(1), NewActionGroup(1)}); !errors.As(err, &actionErr) || actionErr.Code != BAC_UNSUPPORTED_ORDER {
+		t.Errorf("Expect BAC_UNSUPPORTED_ORDER for output and group, actual: %v", err)
+	}
+	if err := ValidateActionSet(ipMatch, []Action{setIPv4Src, setIPv4Src}); !errors.As(err, &actionErr) || actionErr.Index != 1 {
+		t.Errorf("Expect error for the field set twice, actual: %v", err)
+	}
+}
+
+func TestActionCopyField(t *testing.T) {
+	reg0, _ := FindFieldHeaderByName("NXM_NX_REG0", false)
+	erspanIdx, _ := FindFieldHeaderByName("NXOXM_ET_ERSPAN_IDX", false)
+	act := NewActionCopyField(16, 16, 0, *reg0, *erspanIdx)
+	if act.Len() != 24 {
+		t.Errorf("Expect 24 bytes, actual: %d", act.Len())
+	}
+	data, err := act.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal ActionCopyField: %v", err)
+	}
+	decoded, err := DecodeAction(data)
+	if err != nil {
+		t.Fatalf("Failed to decode ActionCopyField: %v", err)
+	}
+	copyField, ok := decoded.(*ActionCopyField)
+	if !ok || copyField.NBits != 16 || copyField.SrcOffset != 16 || copyField.DstOffset != 0 ||
+		copyField.SrcField.Field != NXM_NX_REG0 || copyField.DstField.ExperimenterID != NxExperimenterID {
+		t.Fatalf("Unexpected action %+v", decoded)
+	}
+	if err := ValidateActions([]Action{copyField}); err != nil {
+		t.Errorf("Unexpected error of valid copy-field: %v", err)
+	}
+	if s := copyField.String(); s != "move:NXM_NX_REG0[16..31]->NXOXM_ET_ERSPAN_IDX[0..15]" {
+		t.Errorf("Unexpected string %s", s)
+	}
+
+	act.SrcOffset = 17
+	err = ValidateActions([]Action{act})
+	var actionErr *ActionError
+	if !errors.As(err, &actionErr) || actionErr.Code != BAC_BAD_ARGUMENT {
+		t.Errorf("Expect error of the source out of the field, actual: %v", err)
+	}
+	if err := NewActionCopyField(0, 0, 0, *reg0, *reg0).Validate(); err == nil {
+		t.Errorf("Expect error of copying no bits")
+	}
+}
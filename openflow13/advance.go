@@ -0,0 +1,25 @@
+package openflow13
+
+import (
+	"fmt"
+)
+
+// minElemLen is the minimum length of an element in a list of properties, stats, actions, instructions or
+// match fields, i.e., a 2-byte type and a 2-byte length, or a 4-byte OXM header.
+const minElemLen = 4
+
+// safeAdvance returns the offset after the element of elemLen bytes at offset n in a list ending at end. It
+// guarantees the progress of the parse loops on malformed data: a DecodeError of the length of the element at
+// offset n is returned if elemLen is shorter than minElemLen, which could stall the loop, or if the element overruns
+// the list.
+func safeAdvance(n int, elemLen uint16, end int, name string) (int, error) {
+	if elemLen < minElemLen {
+		return n, &DecodeError{Message: name, Offset: n, Field: "length",
+			Err: fmt.Errorf("invalid length %d, the minimum is %d", elemLen, minElemLen)}
+	}
+	if n+int(elemLen) > end {
+		return n, &DecodeError{Message: name, Offset: n, Field: "length",
+			Err: fmt.Errorf("%w: the length %d overruns the end %d", ErrTruncated, elemLen, end)}
+	}
+	return n + int(elemLen), nil
+}
@@ -0,0 +1,79 @@
+package openflow13
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+// zeroLengthNXAction returns an NX dec_ttl action whose length is 0, it used to stall the action loops.
+func zeroLengthNXAction() []byte {
+	data := make([]byte, 16)
+	binary.BigEndian.PutUint16(data[0:], ActionType_Experimenter)
+	binary.BigEndian.PutUint32(data[4:], NxExperimenterID)
+	binary.BigEndian.PutUint16(data[8:], NXAST_DEC_TTL)
+	return data
+}
+
+func TestSafeAdvanceMalformedLength(t *testing.T) {
+	instrData := make([]byte, 8)
+	binary.BigEndian.PutUint16(instrData[0:], InstrType_APPLY_ACTIONS)
+	binary.BigEndian.PutUint16(instrData[2:], 24)
+	instrData = append(instrData, zeroLengthNXAction()...)
+
+	bucketData := make([]byte, 16)
+	binary.BigEndian.PutUint16(bucketData[0:], 32)
+	bucketData = append(bucketData, zeroLengthNXAction()...)
+
+	// The in_port field overruns the match of 10 bytes.
+	matchData := []byte{0, 1, 0, 10, 0x80, 0, 0, 4, 0, 0, 0, 1, 0, 0, 0, 0}
+
+	meterData := make([]byte, 32)
+	meterData[0] = VERSION
+	meterData[1] = Type_MeterMod
+	binary.BigEndian.PutUint16(meterData[2:], 32)
+	binary.BigEndian.PutUint16(meterData[16:], OFPMBT13_DROP)
+
+	packetOutData := make([]byte, 24)
+	packetOutData[0] = VERSION
+	packetOutData[1] = Type_PacketOut
+	binary.BigEndian.PutUint16(packetOutData[2:], 40)
+	binary.BigEndian.PutUint16(packetOutData[16:], 16)
+	packetOutData = append(packetOutData, zeroLengthNXAction()...)
+
+	// The packet count field overruns the stats of 12 bytes.
+	oxsData := []byte{0, 0, 0, 12, 0x80, 0x02, OFPXST_OFB_PACKET_COUNT << 1, 8, 0, 0, 0, 0, 0, 0, 0, 1}
+
+	for name, tc := range map[string]struct {
+		msg  util.Message
+		data []byte
+	}{
+		"InstrActions": {new(InstrActions), instrData},
+		"Bucket":       {new(Bucket), bucketData},
+		"Match":        {new(Match), matchData},
+		"MeterMod":     {new(MeterMod), meterData},
+		"PacketOut":    {NewPacketOut(), packetOutData},
+		"Stats":        {new(Stats), oxsData},
+	} {
+		done := make(chan error, 1)
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%s: panic on malformed data: %v", name, r)
+					done <- nil
+				}
+			}()
+			done <- tc.msg.UnmarshalBinary(tc.data)
+		}()
+		select {
+		case err := <-done:
+			if err == nil {
+				t.Errorf("%s: expect error on malformed data", name)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s: parse loop doesn't make progress on malformed data", name)
+		}
+	}
+}
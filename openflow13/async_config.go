@@ -0,0 +1,244 @@
+package openflow13
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/contiv/libOpenflow/common"
+)
+
+// The bits of the masks of the asynchronous message configuration, one bit for each reason of the message.
+const (
+	// Reasons of PacketIn, ofp_packet_in_reason.
+	AsyncPacketInNoMatch    = 1 << R_NO_MATCH
+	AsyncPacketInAction     = 1 << R_ACTION
+	AsyncPacketInInvalidTTL = 1 << R_INVALID_TTL
+
+	// Reasons of PortStatus, ofp_port_reason.
+	AsyncPortStatusAdd    = 1 << PR_ADD
+	AsyncPortStatusDelete = 1 << PR_DELETE
+	AsyncPortStatusModify = 1 << PR_MODIFY
+
+	// Reasons of FlowRemoved, ofp_flow_removed_reason.
+	AsyncFlowRemovedIdleTimeout = 1 << RR_IDLE_TIMEOUT
+	AsyncFlowRemovedHardTimeout = 1 << RR_HARD_TIMEOUT
+	AsyncFlowRemovedDelete      = 1 << RR_DELETE
+	AsyncFlowRemovedGroupDelete = 1 << RR_GROUP_DELETE
+)
+
+// ofp_async_config_prop_type 1.4, the reasons properties which replace the masks of ofp_async_config 1.3.
+const (
+	OFPACPT_PACKET_IN_SLAVE       = 0      /* Packet-in mask for slave. */
+	OFPACPT_PACKET_IN_MASTER      = 1      /* Packet-in mask for master. */
+	OFPACPT_PORT_STATUS_SLAVE     = 2      /* Port-status mask for slave. */
+	OFPACPT_PORT_STATUS_MASTER    = 3      /* Port-status mask for master. */
+	OFPACPT_FLOW_REMOVED_SLAVE    = 4      /* Flow removed mask for slave. */
+	OFPACPT_FLOW_REMOVED_MASTER   = 5      /* Flow removed mask for master. */
+	OFPACPT_ROLE_STATUS_SLAVE     = 6      /* Role status mask for slave. */
+	OFPACPT_ROLE_STATUS_MASTER    = 7      /* Role status mask for master. */
+	OFPACPT_TABLE_STATUS_SLAVE    = 8      /* Table status mask for slave. */
+	OFPACPT_TABLE_STATUS_MASTER   = 9      /* Table status mask for master. */
+	OFPACPT_REQUESTFORWARD_SLAVE  = 10     /* RequestForward mask for slave. */
+	OFPACPT_REQUESTFORWARD_MASTER = 11     /* RequestForward mask for master. */
+	OFPACPT_EXPERIMENTER_SLAVE    = 0xFFFE /* Experimenter for slave. */
+	OFPACPT_EXPERIMENTER_MASTER   = 0xFFFF /* Experimenter for master. */
+)
+
+// The index of the masks of the master and the slave role in AsyncConfig, the master masks are used by the
+// controllers in OFPCR_ROLE_EQUAL too.
+const (
+	AsyncRoleMaster = 0
+	AsyncRoleSlave  = 1
+)
+
+// AsyncConfig is the ofp_async_config 1.3, the body of OFPT_SET_ASYNC and OFPT_GET_ASYNC_REPLY. Each mask is
+// indexed by AsyncRoleMaster and AsyncRoleSlave, and has a bit for each reason of the message which the
+// controller wants to receive in the role, e.g., AsyncPacketInNoMatch.
+type AsyncConfig struct {
+	common.Header
+	PacketInMask    [2]uint32
+	PortStatusMask  [2]uint32
+	FlowRemovedMask [2]uint32
+}
+
+// NewSetAsync returns an OFPT_SET_ASYNC message with all the messages disabled, see AsyncConfigBuilder to set
+// the masks.
+func NewSetAsync() *AsyncConfig {
+	c := new(AsyncConfig)
+	c.Header = NewOfp13Header()
+	c.Header.Type = Type_SetAsync
+	return c
+}
+
+// NewGetAsyncRequest returns an OFPT_GET_ASYNC_REQUEST message, the switch replies with the current
+// configuration in OFPT_GET_ASYNC_REPLY.
+func NewGetAsyncRequest() *common.Header {
+	h := NewOfp13Header()
+	h.Type = Type_GetAsyncRequest
+	return &h
+}
+
+func (c *AsyncConfig) Len() (n uint16) {
+	return c.Header.Len() + 24
+}
+
+func (c *AsyncConfig) MarshalBinary() (data []byte, err error) {
+	c.Header.Length = c.Len()
+	data, err = c.Header.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 24)
+	n := 0
+	for _, mask := range [][2]uint32{c.PacketInMask, c.PortStatusMask, c.FlowRemovedMask} {
+		binary.BigEndian.PutUint32(b[n:], mask[AsyncRoleMaster])
+		n += 4
+		binary.BigEndian.PutUint32(b[n:], mask[AsyncRoleSlave])
+		n += 4
+	}
+	data = append(data, b...)
+	return
+}
+
+func (c *AsyncConfig) UnmarshalBinary(data []byte) error {
+	if len(data) < int(c.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full AsyncConfig message")
+	}
+	if err := c.Header.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	n := int(c.Header.Len())
+	for _, mask := range []*[2]uint32{&c.PacketInMask, &c.PortStatusMask, &c.FlowRemovedMask} {
+		mask[AsyncRoleMaster] = binary.BigEndian.Uint32(data[n:])
+		n += 4
+		mask[AsyncRoleSlave] = binary.BigEndian.Uint32(data[n:])
+		n += 4
+	}
+	return nil
+}
+
+// AsyncConfigPropReasons is the ofp_async_config_prop_reasons 1.4, the mask of the reasons of a message type
+// for a role, the Type is one of OFPACPT_* except the experimenter types.
+type AsyncConfigPropReasons struct {
+	Type   uint16
+	Length uint16
+	Mask   uint32
+}
+
+func NewAsyncConfigPropReasons(propType uint16, mask uint32) *AsyncConfigPropReasons {
+	return &AsyncConfigPropReasons{Type: propType, Length: 8, Mask: mask}
+}
+
+func (p *AsyncConfigPropReasons) Len() uint16 {
+	return 8
+}
+
+func (p *AsyncConfigPropReasons) MarshalBinary() (data []byte, err error) {
+	p.Length = p.Len()
+	data = make([]byte, p.Len())
+	binary.BigEndian.PutUint16(data[0:], p.Type)
+	binary.BigEndian.PutUint16(data[2:], p.Length)
+	binary.BigEndian.PutUint32(data[4:], p.Mask)
+	return
+}
+
+func (p *AsyncConfigPropReasons) UnmarshalBinary(data []byte) error {
+	if len(data) < int(p.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full AsyncConfigPropReasons message")
+	}
+	p.Type = binary.BigEndian.Uint16(data[0:])
+	p.Length = binary.BigEndian.Uint16(data[2:])
+	p.Mask = binary.BigEndian.Uint32(data[4:])
+	return nil
+}
+
+// AsyncConfigBuilder builds the asynchronous message configuration from the reasons of the messages which the
+// controller wants in each role, e.g.,
+//
+//	NewAsyncConfigBuilder().
+//		Master().PacketIn(R_NO_MATCH, R_ACTION).PortStatus(PR_ADD, PR_DELETE, PR_MODIFY).
+//		Slave().PortStatus(PR_ADD, PR_DELETE).
+//		SetAsync()
+//
+// The reasons are set for the master role until Slave is called.
+type AsyncConfigBuilder struct {
+	role   int
+	config AsyncConfig
+}
+
+func NewAsyncConfigBuilder() *AsyncConfigBuilder {
+	return &AsyncConfigBuilder{role: AsyncRoleMaster}
+}
+
+// NewAsyncConfigBuilderFrom returns a builder starting from the configuration, e.g., the OFPT_GET_ASYNC_REPLY
+// of the switch, so that the reasons are added to the current ones.
+func NewAsyncConfigBuilderFrom(config *AsyncConfig) *AsyncConfigBuilder {
+	b := NewAsyncConfigBuilder()
+	b.config.PacketInMask = config.PacketInMask
+	b.config.PortStatusMask = config.PortStatusMask
+	b.config.FlowRemovedMask = config.FlowRemovedMask
+	return b
+}
+
+// Master sets the reasons of the following calls for the master and the equal role.
+func (b *AsyncConfigBuilder) Master() *AsyncConfigBuilder {
+	b.role = AsyncRoleMaster
+	return b
+}
+
+// Slave sets the reasons of the following calls for the slave role.
+func (b *AsyncConfigBuilder) Slave() *AsyncConfigBuilder {
+	b.role = AsyncRoleSlave
+	return b
+}
+
+// PacketIn enables the PacketIn messages of the reasons, i.e., R_NO_MATCH, R_ACTION and R_INVALID_TTL.
+func (b *AsyncConfigBuilder) PacketIn(reasons ...uint8) *AsyncConfigBuilder {
+	b.config.PacketInMask[b.role] |= reasonsMask(reasons)
+	return b
+}
+
+// PortStatus enables the PortStatus messages of the reasons, i.e., PR_ADD, PR_DELETE and PR_MODIFY.
+func (b *AsyncConfigBuilder) PortStatus(reasons ...uint8) *AsyncConfigBuilder {
+	b.config.PortStatusMask[b.role] |= reasonsMask(reasons)
+	return b
+}
+
+// FlowRemoved enables the FlowRemoved messages of the reasons, i.e., RR_IDLE_TIMEOUT, RR_HARD_TIMEOUT,
+// RR_DELETE and RR_GROUP_DELETE.
+func (b *AsyncConfigBuilder) FlowRemoved(reasons ...uint8) *AsyncConfigBuilder {
+	b.config.FlowRemovedMask[b.role] |= reasonsMask(reasons)
+	return b
+}
+
+// SetAsync returns the OFPT_SET_ASYNC message of the configuration.
+func (b *AsyncConfigBuilder) SetAsync() *AsyncConfig {
+	c := NewSetAsync()
+	c.PacketInMask = b.config.PacketInMask
+	c.PortStatusMask = b.config.PortStatusMask
+	c.FlowRemovedMask = b.config.FlowRemovedMask
+	return c
+}
+
+// Properties returns the configuration as the reasons properties of OpenFlow 1.4 and later, in the order of
+// the property types.
+func (b *AsyncConfigBuilder) Properties() []*AsyncConfigPropReasons {
+	props := make([]*AsyncConfigPropReasons, 0, 6)
+	for i, mask := range [][2]uint32{b.config.PacketInMask, b.config.PortStatusMask, b.config.FlowRemovedMask} {
+		slaveType := uint16(OFPACPT_PACKET_IN_SLAVE + 2*i)
+		props = append(props,
+			NewAsyncConfigPropReasons(slaveType, mask[AsyncRoleSlave]),
+			NewAsyncConfigPropReasons(slaveType+1, mask[AsyncRoleMaster]))
+	}
+	return props
+}
+
+// reasonsMask returns the mask with the bits of the reasons set, the reasons beyond 31 are ignored.
+func reasonsMask(reasons []uint8) (mask uint32) {
+	for _, reason := range reasons {
+		if reason < 32 {
+			mask |= 1 << reason
+		}
+	}
+	return
+}
@@ -0,0 +1,46 @@
+package openflow13
+
+import (
+	"testing"
+)
+
+func TestAsyncConfigBuilder(t *testing.T) {
+	config := NewAsyncConfigBuilder().
+		Master().PacketIn(R_NO_MATCH, R_ACTION).PortStatus(PR_ADD, PR_DELETE, PR_MODIFY).FlowRemoved(RR_DELETE).
+		Slave().PortStatus(PR_ADD).
+		SetAsync()
+	if config.PacketInMask != [2]uint32{AsyncPacketInNoMatch | AsyncPacketInAction, 0} ||
+		config.PortStatusMask != [2]uint32{AsyncPortStatusAdd | AsyncPortStatusDelete | AsyncPortStatusModify, AsyncPortStatusAdd} ||
+		config.FlowRemovedMask != [2]uint32{AsyncFlowRemovedDelete, 0} {
+		t.Errorf("Unexpected AsyncConfig: %+v", config)
+	}
+
+	data, err := config.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal AsyncConfig: %v", err)
+	}
+	data[1] = Type_GetAsyncReply
+	msg, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse AsyncConfig: %v", err)
+	}
+	reply := msg.(*AsyncConfig)
+	if reply.PacketInMask != config.PacketInMask || reply.PortStatusMask != config.PortStatusMask || reply.FlowRemovedMask != config.FlowRemovedMask {
+		t.Errorf("Unexpected decoded AsyncConfig: %+v", reply)
+	}
+
+	// Enable the flow removed messages of the idle timeout on top of the configuration of the switch.
+	props := NewAsyncConfigBuilderFrom(reply).FlowRemoved(RR_IDLE_TIMEOUT).Properties()
+	if len(props) != 6 {
+		t.Fatalf("Expect 6 properties, actual: %d", len(props))
+	}
+	if props[1].Type != OFPACPT_PACKET_IN_MASTER || props[1].Mask != AsyncPacketInNoMatch|AsyncPacketInAction {
+		t.Errorf("Unexpected packet-in property: %+v", props[1])
+	}
+	if props[5].Type != OFPACPT_FLOW_REMOVED_MASTER || props[5].Mask != AsyncFlowRemovedDelete|AsyncFlowRemovedIdleTimeout {
+		t.Errorf("Unexpected flow removed property: %+v", props[5])
+	}
+	if props[2].Type != OFPACPT_PORT_STATUS_SLAVE || props[2].Mask != AsyncPortStatusAdd {
+		t.Errorf("Unexpected port status property: %+v", props[2])
+	}
+}
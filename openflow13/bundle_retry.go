@@ -0,0 +1,81 @@
+package openflow13
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+var (
+	ErrBundleTimeout   = errors.New("bundle is taking too long")
+	ErrBundleInProcess = errors.New("bundle is locking the resource")
+)
+
+var bundleID uint32
+
+// NextBundleID returns a bundle ID which is not used by the previous calls in this process.
+func NextBundleID() uint32 {
+	return atomic.AddUint32(&bundleID, 1)
+}
+
+// NewBundleMessages returns the messages to realize msgs atomically in a bundle with the given ID, i.e., the
+// open request, a BundleAdd message for each of msgs, and the commit request. The inner messages are added
+// as they are, so their xids and cookies are not changed.
+func NewBundleMessages(bundleID uint32, flags uint16, msgs []util.Message) []util.Message {
+	bundleMsgs := make([]util.Message, 0, len(msgs)+2)
+	bundleMsgs = append(bundleMsgs, NewBundleControl(&BundleControl{BundleID: bundleID, Type: OFPBCT_OPEN_REQUEST, Flags: flags}))
+	for _, msg := range msgs {
+		bundleMsgs = append(bundleMsgs, NewBundleAdd(&BundleAdd{BundleID: bundleID, Flags: flags, Message: msg}))
+	}
+	bundleMsgs = append(bundleMsgs, NewBundleControl(&BundleControl{BundleID: bundleID, Type: OFPBCT_COMMIT_REQUEST, Flags: flags}))
+	return bundleMsgs
+}
+
+// BundleSendFunc sends the bundle messages to the switch, and returns nil only if the bundle is committed.
+// If the switch replies a bundle error, the error returned by ParseBundleError should be returned, so that
+// the BundleRetryPolicy could check whether the failure is transient.
+type BundleSendFunc func(bundleID uint32, bundleMsgs []util.Message) error
+
+// BundleRetryPolicy is called after the attempt (starting from 1) to commit the bundle failed with err. It
+// returns whether to retry, and how long to wait before the retry.
+type BundleRetryPolicy func(attempt int, err error) (retry bool, delay time.Duration)
+
+// NewBundleRetryPolicy returns a BundleRetryPolicy which retries at most maxRetries times on the transient
+// errors, i.e., ErrBundleTimeout and ErrBundleInProcess. The delay starts from interval and doubles after
+// each retry.
+func NewBundleRetryPolicy(maxRetries int, interval time.Duration) BundleRetryPolicy {
+	return func(attempt int, err error) (bool, time.Duration) {
+		if attempt > maxRetries {
+			return false, 0
+		}
+		if !errors.Is(err, ErrBundleTimeout) && !errors.Is(err, ErrBundleInProcess) {
+			return false, 0
+		}
+		return true, interval << uint(attempt-1)
+	}
+}
+
+// CommitBundleWithRetry installs msgs in a bundle, and retries the bundle according to policy if it fails.
+// A fresh bundle ID is used for each attempt as the switch might still hold the failed bundle, while the
+// inner messages are reused, so that the retried messages are identical to the failed ones and the switch
+// or the controller could use their xids and cookies to deduplicate. The last error is returned if all the
+// attempts failed.
+func CommitBundleWithRetry(msgs []util.Message, flags uint16, send BundleSendFunc, policy BundleRetryPolicy) error {
+	for attempt := 1; ; attempt++ {
+		id := NextBundleID()
+		err := send(id, NewBundleMessages(id, flags, msgs))
+		if err == nil {
+			return nil
+		}
+		if policy == nil {
+			return err
+		}
+		retry, delay := policy(attempt, err)
+		if !retry {
+			return err
+		}
+		time.Sleep(delay)
+	}
+}
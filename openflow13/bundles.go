@@ -223,7 +223,7 @@ func (b *BundleAdd) UnmarshalBinary(data []byte) error {
 	n += 2
 	b.Message, err = Parse(data[n:])
 	if err != nil {
-		return err
+		return decodeErrorAt(err, "BundleAdd", n, "message")
 	}
 	n += int(b.Message.Len())
 	if n < len(data) {
@@ -232,10 +232,12 @@ func (b *BundleAdd) UnmarshalBinary(data []byte) error {
 			var property BundlePropertyExperimenter
 			err = property.UnmarshalBinary(data[n:])
 			if err != nil {
-				return err
+				return decodeErrorAt(err, "BundleAdd", n, "property")
 			}
 			b.Properties = append(b.Properties, property)
-			n += int(property.Len())
+			if n, err = safeAdvance(n, property.Len(), len(data), "bundle property"); err != nil {
+				return err
+			}
 		}
 	}
 	return err
@@ -252,16 +254,42 @@ func NewBundleAdd(bundleAdd *BundleAdd) *VendorHeader {
 	}
 }
 
+// VendorError is the experimenter error message, the Code of the ErrorMsg is the exp_type defined by the
+// experimenter, and the Type is ET_EXPERIMENTER.
 type VendorError struct {
 	*ErrorMsg
 	ExperimenterID uint32
 }
 
+// NewVendorError returns the experimenter error of the experimenter and the exp_type, the data is the beginning of
+// the failed request.
+func NewVendorError(experimenterID uint32, expType uint16, data []byte) *VendorError {
+	e := new(VendorError)
+	e.ErrorMsg = NewErrorMsg()
+	e.Header = NewOfp13Header()
+	e.Header.Type = Type_Error
+	e.Type = ET_EXPERIMENTER
+	e.Code = expType
+	e.ExperimenterID = experimenterID
+	e.Data = *util.NewBuffer(data)
+	return e
+}
+
+// NXError returns the name and the description of the Nicira extension error, ok is false if the error is not a
+// known Nicira extension error.
+func (e *VendorError) NXError() (name, description string, ok bool) {
+	if e.ExperimenterID != NxExperimenterID {
+		return "", "", false
+	}
+	return LookupNXError(e.Type, e.Code)
+}
+
 func (e *VendorError) Len() uint16 {
 	return e.ErrorMsg.Len() + uint16(unsafe.Sizeof(e.ExperimenterID))
 }
 
 func (e *VendorError) MarshalBinary() (data []byte, err error) {
+	e.Header.Length = e.Len()
 	data = make([]byte, int(e.Len()))
 	n := 0
 
@@ -281,6 +309,9 @@ func (e *VendorError) MarshalBinary() (data []byte, err error) {
 }
 
 func (e *VendorError) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("the []byte is too short to unmarshal a full VendorError message")
+	}
 	n := 0
 	e.ErrorMsg = new(ErrorMsg)
 	err := e.Header.UnmarshalBinary(data[n:])
@@ -303,12 +334,7 @@ func (e *VendorError) UnmarshalBinary(data []byte) error {
 }
 
 func NewBundleError() *VendorError {
-	e := new(VendorError)
-	e.ErrorMsg = NewErrorMsg()
-	e.Header = NewOfp13Header()
-	e.Type = ET_EXPERIMENTER
-	e.ExperimenterID = ONF_EXPERIMENTER_ID
-	return e
+	return NewVendorError(ONF_EXPERIMENTER_ID, 0, nil)
 }
 
 // ParseBundleError returns error according to bundle error code.
@@ -333,9 +359,9 @@ func ParseBundleError(errCode uint16) error {
 	case BEC_MSG_BAD_LEN:
 		return errors.New("length problem in included message")
 	case BEC_MSG_BAD_XID:
-		return errors.New("inconsistent or duplicate XID")
+		return ErrBundleMessageXid
 	case BEC_MSG_UNSUP:
-		return errors.New("unsupported message in this bundle")
+		return ErrBundleMessageUnsupported
 	case BEC_MSG_CONFLICT:
 		return errors.New("unsupported message combination in this bundle")
 	case BEC_MSG_TOO_MANY:
@@ -343,9 +369,9 @@ func ParseBundleError(errCode uint16) error {
 	case BEC_MSG_FAILD:
 		return errors.New("one message in bundle failed")
 	case BEC_TIMEOUT:
-		return errors.New("bundle is taking too long")
+		return ErrBundleTimeout
 	case BEC_BUNDLE_IN_PROCESS:
-		return errors.New("bundle is locking the resource")
+		return ErrBundleInProcess
 	}
 	return nil
 }
@@ -4,8 +4,12 @@ import (
 	"bytes"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/contiv/libOpenflow/common"
+	"github.com/contiv/libOpenflow/util"
 )
 
 func TestBundleControl(t *testing.T) {
@@ -177,3 +181,93 @@ func bundleAddEqual(bundleAdd, bundleAdd2 *BundleAdd) error {
 	}
 	return nil
 }
+
+func TestCommitBundleWithRetry(t *testing.T) {
+	flowMod := NewFlowMod()
+	flowMod.Cookie = 0x1234
+	var bundleIDs []uint32
+	send := func(bundleID uint32, bundleMsgs []util.Message) error {
+		bundleIDs = append(bundleIDs, bundleID)
+		assert.Equal(t, 3, len(bundleMsgs))
+		add := bundleMsgs[1].(*VendorHeader).VendorData.(*BundleAdd)
+		assert.Equal(t, bundleID, add.BundleID)
+		assert.Equal(t, flowMod, add.Message)
+		if len(bundleIDs) < 3 {
+			return ParseBundleError(BEC_TIMEOUT)
+		}
+		return nil
+	}
+	err := CommitBundleWithRetry([]util.Message{flowMod}, OFPBCT_ATOMIC, send, NewBundleRetryPolicy(3, time.Millisecond))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(bundleIDs))
+	assert.NotEqual(t, bundleIDs[0], bundleIDs[1])
+
+	bundleIDs = nil
+	err = CommitBundleWithRetry([]util.Message{flowMod}, OFPBCT_ATOMIC, send, NewBundleRetryPolicy(1, time.Millisecond))
+	assert.Equal(t, ErrBundleTimeout, err)
+	assert.Equal(t, 2, len(bundleIDs))
+}
+
+func TestBundleAddExperimenterMessage(t *testing.T) {
+	tlvMaps := []*TLVTableMap{{OptClass: 0xffff, OptType: 0, OptLength: 4, Index: 0}}
+	for _, msg := range []*VendorHeader{
+		NewTLVTableModMessage(NewTLVTableMod(NXTTMC_ADD, tlvMaps)),
+		NewSetControllerID(10),
+	} {
+		bundleAdd := &BundleAdd{
+			BundleID: uint32(100),
+			Flags:    OFPBCT_ATOMIC,
+			Message:  msg,
+		}
+		data, err := NewBundleAdd(bundleAdd).MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to Marshal message: %v", err)
+		}
+		parsed, err := Parse(data)
+		if err != nil {
+			t.Fatalf("Failed to parse message: %v", err)
+		}
+		bundleAdd2, ok := parsed.(*VendorHeader).VendorData.(*BundleAdd)
+		if !ok {
+			t.Fatalf("Failed to cast BundleAdd from result")
+		}
+		if err = bundleAddEqual(bundleAdd, bundleAdd2); err != nil {
+			t.Error(err.Error())
+		}
+		msg2, ok := bundleAdd2.Message.(*VendorHeader)
+		if !ok {
+			t.Fatalf("Failed to cast VendorHeader from the message in BundleAdd")
+		}
+		assert.Equal(t, msg.Vendor, msg2.Vendor)
+		assert.Equal(t, msg.ExperimenterType, msg2.ExperimenterType)
+		assert.Equal(t, msg.VendorData, msg2.VendorData)
+	}
+}
+
+func TestFlowBundle(t *testing.T) {
+	flowMod1 := NewFlowMod()
+	flowMod1.Xid = 0
+	flowMod2 := NewFlowMod()
+	groupMod := NewGroupMod()
+	msgs, err := NewFlowBundle(10).WithFlags(OFPBCT_ATOMIC|OFPBCT_ORDERED).Add(flowMod1, flowMod2).Add(groupMod).Messages()
+	assert.NoError(t, err)
+	assert.Equal(t, 5, len(msgs))
+	open := msgs[0].(*VendorHeader).VendorData.(*BundleControl)
+	commit := msgs[4].(*VendorHeader).VendorData.(*BundleControl)
+	assert.Equal(t, OFPBCT_OPEN_REQUEST, open.Type)
+	assert.Equal(t, OFPBCT_COMMIT_REQUEST, commit.Type)
+	assert.Equal(t, OFPBCT_ATOMIC|OFPBCT_ORDERED, commit.Flags)
+	assert.NotZero(t, flowMod1.Xid)
+	for i, inner := range []*common.Header{&flowMod1.Header, &flowMod2.Header, &groupMod.Header} {
+		add := msgs[i+1].(*VendorHeader)
+		assert.Equal(t, inner.Xid, add.Header.Xid)
+		assert.Equal(t, uint32(10), add.VendorData.(*BundleAdd).BundleID)
+		assert.Equal(t, open.Flags, add.VendorData.(*BundleAdd).Flags)
+	}
+
+	_, err = NewFlowBundle(11).Add(flowMod1, NewEchoRequest(), flowMod2).Messages()
+	assert.ErrorIs(t, err, ErrBundleMessageUnsupported)
+	_, err = NewFlowBundle(12).Add(flowMod1, flowMod1).Messages()
+	assert.ErrorIs(t, err, ErrBundleMessageXid)
+	assert.Equal(t, ErrBundleMessageXid, ParseBundleError(BEC_MSG_BAD_XID))
+}
@@ -0,0 +1,338 @@
+package openflow13
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/contiv/libOpenflow/common"
+	"github.com/contiv/libOpenflow/util"
+)
+
+// ErrConnClosed is returned by the requests which are pending when the Conn is closed.
+var ErrConnClosed = errors.New("OpenFlow connection is closed")
+
+// ReplyError is returned when the switch replies an OFPT_ERROR message to a request.
+type ReplyError struct {
+	Msg *ErrorMsg
+	// ExperimenterID is set if the error is an experimenter error, e.g., a bundle error.
+	ExperimenterID uint32
+}
+
+func (e *ReplyError) Error() string {
+	if e.Msg.Type == ET_EXPERIMENTER {
+		return fmt.Sprintf("request xid %d failed with experimenter 0x%x error %d", e.Msg.Header.Xid, e.ExperimenterID, e.Msg.Code)
+	}
+	return fmt.Sprintf("request xid %d failed with error type %d code %d", e.Msg.Header.Xid, e.Msg.Type, e.Msg.Code)
+}
+
+// Conn sends OpenFlow messages on a util.MessageStream, and correlates the replies to the requests by xid. The
+// flow updates are passed to the MonitorSessions started on the Conn, and the other inbound messages which are
+// not replies to the pending requests, e.g., PacketIn and PortStatus, are passed to the handler.
+type Conn struct {
+	outbound chan<- util.Message
+	handler  func(msg util.Message)
+
+	lock    sync.Mutex
+	pending map[uint32]*pendingRequest
+	// version is the OpenFlow version negotiated on the connection, 0 if it is unknown.
+	version uint8
+	// monitors are the flow monitor sessions started on the Conn.
+	monitors []*MonitorSession
+	closed   chan struct{}
+	once     sync.Once
+}
+
+// pendingRequest receives the replies of a request until it is done.
+type pendingRequest struct {
+	replies chan util.Message
+	done    chan struct{}
+}
+
+// NewConn returns a Conn which takes over the inbound messages of the stream. The handler is called in the
+// receiving goroutine for the uncorrelated messages, so it must not block; the messages are dropped if it is nil.
+func NewConn(stream *util.MessageStream, handler func(msg util.Message)) *Conn {
+	return newConn(stream.Inbound, stream.Outbound, handler)
+}
+
+func newConn(inbound <-chan util.Message, outbound chan<- util.Message, handler func(msg util.Message)) *Conn {
+	c := &Conn{
+		outbound: outbound,
+		handler:  handler,
+		pending:  make(map[uint32]*pendingRequest),
+		closed:   make(chan struct{}),
+	}
+	go c.receive(inbound)
+	return c
+}
+
+// Close stops receiving the inbound messages, the pending requests fail with ErrConnClosed.
+func (c *Conn) Close() {
+	c.once.Do(func() {
+		close(c.closed)
+	})
+}
+
+func (c *Conn) receive(inbound <-chan util.Message) {
+	for {
+		select {
+		case <-c.closed:
+			return
+		case msg, ok := <-inbound:
+			if !ok {
+				c.Close()
+				return
+			}
+			// The stream passes nil for the messages failed to parse.
+			if msg == nil {
+				continue
+			}
+			c.lock.Lock()
+			// The switch sends all the messages in the negotiated version.
+			if h := messageHeader(msg); h != nil {
+				c.version = h.Version
+			}
+			req, found := c.pending[messageXid(msg)]
+			c.lock.Unlock()
+			if found {
+				select {
+				case req.replies <- msg:
+				case <-req.done:
+				case <-c.closed:
+					return
+				}
+			} else if !c.dispatchFlowUpdates(msg) && c.handler != nil {
+				c.handler(msg)
+			}
+		}
+	}
+}
+
+// SetVersion sets the OpenFlow version negotiated on the connection, e.g., when the hello messages are exchanged
+// on the stream before the Conn takes it over. Otherwise the version is learned from the inbound messages.
+func (c *Conn) SetVersion(version uint8) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.version = version
+}
+
+// Version returns the OpenFlow version negotiated on the connection, or 0 if it is unknown. The version decides
+// the layout of some requests sent on the Conn, e.g., the flow monitor requests.
+func (c *Conn) Version() uint8 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.version
+}
+
+// Send sends the message without waiting for the reply.
+func (c *Conn) Send(msg util.Message) error {
+	select {
+	case <-c.closed:
+		return ErrConnClosed
+	case c.outbound <- msg:
+		return nil
+	}
+}
+
+// SendAndAwaitReply sends the request, and returns its replies. The replies of a multipart request are
+// aggregated until the reply without OFPMPF_REPLY_MORE, other requests are done with the first reply. A
+// ReplyError is returned if the switch replies an error, and ctx.Err() is returned if ctx is done before the
+// last reply. The xid of the request is allocated with common.NextXid if it is 0.
+func (c *Conn) SendAndAwaitReply(ctx context.Context, req util.Message) ([]util.Message, error) {
+	xid := messageXid(req)
+	if xid == 0 {
+		xid = common.NextXid()
+		if h := messageHeader(req); h != nil {
+			h.Xid = xid
+		} else {
+			return nil, fmt.Errorf("can't allocate xid for request %T", req)
+		}
+	}
+	pending := &pendingRequest{replies: make(chan util.Message), done: make(chan struct{})}
+	c.lock.Lock()
+	if _, found := c.pending[xid]; found {
+		c.lock.Unlock()
+		return nil, fmt.Errorf("a request with xid %d is pending", xid)
+	}
+	c.pending[xid] = pending
+	c.lock.Unlock()
+	defer func() {
+		c.lock.Lock()
+		delete(c.pending, xid)
+		c.lock.Unlock()
+		close(pending.done)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.closed:
+		return nil, ErrConnClosed
+	case c.outbound <- req:
+	}
+
+	var replies []util.Message
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.closed:
+			return nil, ErrConnClosed
+		case msg := <-pending.replies:
+			switch m := msg.(type) {
+			case *ErrorMsg:
+				return nil, &ReplyError{Msg: m}
+			case *VendorError:
+				return nil, &ReplyError{Msg: m.ErrorMsg, ExperimenterID: m.ExperimenterID}
+			case *MultipartReply:
+				replies = append(replies, m)
+				if m.Flags&OFPMPF_REPLY_MORE != 0 {
+					continue
+				}
+			default:
+				replies = append(replies, m)
+			}
+			return replies, nil
+		}
+	}
+}
+
+// RequestMultipart sends a multipart request of mpType with the body, and returns the bodies of all the
+// replies.
+func RequestMultipart(ctx context.Context, conn *Conn, mpType uint16, body util.Message) ([]util.Message, error) {
+	req := &MultipartRequest{Header: NewOfp13Header(), Type: mpType, Body: body}
+	req.Header.Type = Type_MultiPartRequest
+	if req.Body == nil {
+		req.Body = new(util.Buffer)
+	}
+	replies, err := conn.SendAndAwaitReply(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var bodies []util.Message
+	for _, msg := range replies {
+		reply, ok := msg.(*MultipartReply)
+		if !ok || reply.Type != mpType {
+			return nil, fmt.Errorf("unexpected reply %T to multipart request type %d", msg, mpType)
+		}
+		bodies = append(bodies, reply.Body...)
+	}
+	return bodies, nil
+}
+
+// RequestFlowStats returns the flow entries matching req.
+func RequestFlowStats(ctx context.Context, conn *Conn, req *FlowStatsRequest) ([]*FlowStats, error) {
+	bodies, err := RequestMultipart(ctx, conn, MultipartType_Flow, req)
+	if err != nil {
+		return nil, err
+	}
+	flows := make([]*FlowStats, 0, len(bodies))
+	for _, body := range bodies {
+		flow, ok := body.(*FlowStats)
+		if !ok {
+			return nil, fmt.Errorf("unexpected flow stats body %T", body)
+		}
+		flows = append(flows, flow)
+	}
+	return flows, nil
+}
+
+// RequestPortDesc returns the descriptions of all the ports of the switch.
+func RequestPortDesc(ctx context.Context, conn *Conn) ([]*PhyPort, error) {
+	bodies, err := RequestMultipart(ctx, conn, MultipartType_PortDesc, nil)
+	if err != nil {
+		return nil, err
+	}
+	ports := make([]*PhyPort, 0, len(bodies))
+	for _, body := range bodies {
+		port, ok := body.(*PhyPort)
+		if !ok {
+			return nil, fmt.Errorf("unexpected port desc body %T", body)
+		}
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
+
+// RequestQueueConfig returns the queues of the port, or of all the ports with P_ANY, with
+// OFPT_QUEUE_GET_CONFIG_REQUEST of OpenFlow 1.3.
+func RequestQueueConfig(ctx context.Context, conn *Conn, port uint32) ([]*PacketQueue, error) {
+	replies, err := conn.SendAndAwaitReply(ctx, NewQueueGetConfigRequest(port))
+	if err != nil {
+		return nil, err
+	}
+	reply, ok := replies[0].(*QueueGetConfigReply)
+	if !ok {
+		return nil, fmt.Errorf("unexpected reply %T to queue get config request", replies[0])
+	}
+	return reply.Queues, nil
+}
+
+// messageHeader returns the OpenFlow header of the message, or nil if the message type is unknown.
+func messageHeader(msg util.Message) *common.Header {
+	switch m := msg.(type) {
+	case *common.Header:
+		return m
+	case *common.Hello:
+		return &m.Header
+	case *ErrorMsg:
+		return &m.Header
+	case *VendorError:
+		return &m.Header
+	case *VendorHeader:
+		return &m.Header
+	case *MultipartRequest:
+		return &m.Header
+	case *MultipartReply:
+		return &m.Header
+	case *SwitchFeatures:
+		return &m.Header
+	case *SwitchConfig:
+		return &m.Header
+	case *PacketIn:
+		return &m.Header
+	case *PacketOut:
+		return &m.Header
+	case *FlowRemoved:
+		return &m.Header
+	case *FlowMod:
+		return &m.Header
+	case *GroupMod:
+		return &m.Header
+	case *GroupMod15:
+		return &m.Header
+	case *MeterMod:
+		return &m.Header
+	case *PortMod:
+		return &m.Header
+	case *AsyncConfig:
+		return &m.Header
+	case *PortStatus:
+		return &m.Header
+	case *RoleRequest:
+		return &m.Header
+	case *QueueGetConfigRequest:
+		return &m.Header
+	case *QueueGetConfigReply:
+		return &m.Header
+	case *EchoRequest:
+		return &m.Header
+	case *EchoReply:
+		return &m.Header
+	}
+	return nil
+}
+
+// messageXid returns the xid of the message, the message is marshaled to find the xid if its type is unknown.
+func messageXid(msg util.Message) uint32 {
+	if h := messageHeader(msg); h != nil {
+		return h.Xid
+	}
+	data, err := msg.MarshalBinary()
+	if err != nil || len(data) < 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint32(data[4:])
+}
@@ -0,0 +1,104 @@
+package openflow13
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+// fakeSwitch replies the requests received on outbound with reply.
+func fakeSwitch(outbound <-chan util.Message, inbound chan<- util.Message, reply func(req util.Message) []util.Message) {
+	for req := range outbound {
+		for _, msg := range reply(req) {
+			inbound <- msg
+		}
+	}
+}
+
+func newMultipartReply(xid uint32, mpType uint16, flags uint16, bodies ...util.Message) *MultipartReply {
+	reply := &MultipartReply{Header: NewOfp13Header(), Type: mpType, Flags: flags, Body: bodies}
+	reply.Header.Type = Type_MultiPartReply
+	reply.Header.Xid = xid
+	return reply
+}
+
+func TestConnSendAndAwaitReply(t *testing.T) {
+	inbound := make(chan util.Message)
+	outbound := make(chan util.Message)
+	defer close(outbound)
+	unsolicited := make(chan util.Message, 1)
+	conn := newConn(inbound, outbound, func(msg util.Message) {
+		unsolicited <- msg
+	})
+	defer conn.Close()
+
+	go fakeSwitch(outbound, inbound, func(req util.Message) []util.Message {
+		xid := messageXid(req)
+		mpReq, ok := req.(*MultipartRequest)
+		if !ok {
+			// Don't reply other requests.
+			return nil
+		}
+		switch mpReq.Type {
+		case MultipartType_Flow:
+			flow1, flow2 := NewFlowStats(), NewFlowStats()
+			flow1.Priority, flow2.Priority = 100, 200
+			packetIn := NewPacketIn()
+			return []util.Message{
+				newMultipartReply(xid, MultipartType_Flow, OFPMPF_REPLY_MORE, flow1),
+				packetIn,
+				newMultipartReply(xid, MultipartType_Flow, 0, flow2),
+			}
+		case MultipartType_PortDesc:
+			port := NewPhyPort()
+			port.PortNo = 1
+			return []util.Message{newMultipartReply(xid, MultipartType_PortDesc, 0, port)}
+		default:
+			errMsg := NewErrorMsg()
+			errMsg.Header.Xid = xid
+			errMsg.Type = ET_BAD_REQUEST
+			return []util.Message{errMsg}
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	flows, err := RequestFlowStats(ctx, conn, NewFlowStatsRequest())
+	if err != nil {
+		t.Fatalf("Failed to request flow stats: %v", err)
+	}
+	if len(flows) != 2 || flows[0].Priority != 100 || flows[1].Priority != 200 {
+		t.Errorf("Unexpected flows: %+v", flows)
+	}
+	select {
+	case msg := <-unsolicited:
+		if _, ok := msg.(*PacketIn); !ok {
+			t.Errorf("Unexpected uncorrelated message %T", msg)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("The uncorrelated message is not passed to the handler")
+	}
+
+	ports, err := RequestPortDesc(ctx, conn)
+	if err != nil {
+		t.Fatalf("Failed to request port desc: %v", err)
+	}
+	if len(ports) != 1 || ports[0].PortNo != 1 {
+		t.Errorf("Unexpected ports: %+v", ports)
+	}
+
+	_, err = RequestMultipart(ctx, conn, MultipartType_Desc, nil)
+	var replyErr *ReplyError
+	if !errors.As(err, &replyErr) || replyErr.Msg.Type != ET_BAD_REQUEST {
+		t.Errorf("Expect ReplyError, actual: %v", err)
+	}
+
+	timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer timeoutCancel()
+	if _, err = conn.SendAndAwaitReply(timeoutCtx, NewEchoRequest()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expect timeout, actual: %v", err)
+	}
+}
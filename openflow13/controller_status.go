@@ -0,0 +1,335 @@
+package openflow13
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/contiv/libOpenflow/common"
+	"github.com/contiv/libOpenflow/util"
+)
+
+// OFPT_CONTROLLER_STATUS is an asynchronous message introduced in OpenFlow 1.5, the switch sends it to all the
+// controllers when the status of a controller channel changes.
+const (
+	Type_ControllerStatus = 35
+
+	// MultipartType_ControllerStatus is the OpenFlow 1.5 multipart type to get the status of all the controllers.
+	MultipartType_ControllerStatus = 18
+
+	// OFP15_VERSION is the wire version of OpenFlow 1.5.
+	OFP15_VERSION = 6
+)
+
+// ofp_controller_status_reason
+const (
+	OFPCSR_REQUEST            = 0 /* Controller requested status. */
+	OFPCSR_CHANNEL_STATUS     = 1 /* Oper status of channel changed. */
+	OFPCSR_ROLE               = 2 /* Controller role changed. */
+	OFPCSR_CONTROLLER_ADDED   = 3 /* New controller added. */
+	OFPCSR_CONTROLLER_REMOVED = 4 /* Controller removed from config. */
+	OFPCSR_SHORT_ID           = 5 /* Controller ID changed. */
+	OFPCSR_EXPERIMENTER       = 6 /* Experimenter data changed. */
+)
+
+// ofp_control_channel_status
+const (
+	OFPCT_STATUS_UP   = 0 /* Control channel is operational. */
+	OFPCT_STATUS_DOWN = 1 /* Control channel is not operational. */
+)
+
+// ofp_controller_status_prop_type
+const (
+	OFPCSPT_URI          = 0      /* Connection URI property. */
+	OFPCSPT_EXPERIMENTER = 0xFFFF /* Experimenter property. */
+)
+
+// ControllerStatusPropHeader is the common header of all controller status properties.
+type ControllerStatusPropHeader struct {
+	Type   uint16
+	Length uint16
+}
+
+func (p *ControllerStatusPropHeader) Len() uint16 {
+	return 4
+}
+
+func (p *ControllerStatusPropHeader) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, p.Len())
+	binary.BigEndian.PutUint16(data[0:], p.Type)
+	binary.BigEndian.PutUint16(data[2:], p.Length)
+	return
+}
+
+func (p *ControllerStatusPropHeader) UnmarshalBinary(data []byte) error {
+	if len(data) < int(p.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full ControllerStatusPropHeader message")
+	}
+	p.Type = binary.BigEndian.Uint16(data[0:])
+	p.Length = binary.BigEndian.Uint16(data[2:])
+	return nil
+}
+
+// ControllerStatusPropUri is the URI of the controller connection, e.g., "tcp:10.0.0.1:6653". The Length
+// doesn't include the padding, and the property is padded to a multiple of 8 bytes on the wire.
+type ControllerStatusPropUri struct {
+	ControllerStatusPropHeader
+	Uri string
+}
+
+func NewControllerStatusPropUri(uri string) *ControllerStatusPropUri {
+	p := new(ControllerStatusPropUri)
+	p.Type = OFPCSPT_URI
+	p.Uri = uri
+	p.Length = p.ControllerStatusPropHeader.Len() + uint16(len(uri))
+	return p
+}
+
+// NewControllerStatusPropUriFromAddr returns the URI property of the controller at addr, the URI is in the
+// format "<network>:<ip>:<port>" as the target of the controller in OVS, e.g., "tcp:10.0.0.1:6653".
+func NewControllerStatusPropUriFromAddr(addr net.Addr) *ControllerStatusPropUri {
+	return NewControllerStatusPropUri(fmt.Sprintf("%s:%s", strings.TrimRight(addr.Network(), "46"), addr.String()))
+}
+
+// NewControllerStatusPropUriFromURL returns the URI property of the controller with the URL, e.g.,
+// "ssl://10.0.0.1:6653" is converted to "ssl:10.0.0.1:6653".
+func NewControllerStatusPropUriFromURL(u *url.URL) *ControllerStatusPropUri {
+	return NewControllerStatusPropUri(fmt.Sprintf("%s:%s", u.Scheme, u.Host))
+}
+
+// Len returns the length of the property on the wire, including the padding.
+func (p *ControllerStatusPropUri) Len() uint16 {
+	return roundUp8(p.unpaddedLen())
+}
+
+func (p *ControllerStatusPropUri) unpaddedLen() uint16 {
+	return p.ControllerStatusPropHeader.Len() + uint16(len(p.Uri))
+}
+
+// URI returns the URI without the trailing NUL bytes, which some switches count in the Length.
+func (p *ControllerStatusPropUri) URI() string {
+	return strings.TrimRight(p.Uri, "\x00")
+}
+
+func (p *ControllerStatusPropUri) MarshalBinary() (data []byte, err error) {
+	p.Length = p.unpaddedLen()
+	b, err := p.ControllerStatusPropHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	data = make([]byte, 0, p.Len())
+	data = append(data, b...)
+	data = append(data, p.Uri...)
+	// The padding is not counted in the Length.
+	data = append(data, make([]byte, p.Len()-p.Length)...)
+	return
+}
+
+func (p *ControllerStatusPropUri) UnmarshalBinary(data []byte) error {
+	if err := p.ControllerStatusPropHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if len(data) < int(p.Length) || p.Length < p.ControllerStatusPropHeader.Len() {
+		return errors.New("the []byte is too short to unmarshal a full ControllerStatusPropUri message")
+	}
+	p.Uri = string(data[p.ControllerStatusPropHeader.Len():p.Length])
+	return nil
+}
+
+// ControllerStatusPropUnknown keeps the raw data of the controller status property which is not supported,
+// e.g., experimenter property.
+type ControllerStatusPropUnknown struct {
+	ControllerStatusPropHeader
+	Data []byte
+}
+
+// Len returns the length of the property on the wire, including the padding.
+func (p *ControllerStatusPropUnknown) Len() uint16 {
+	return roundUp8(p.ControllerStatusPropHeader.Len() + uint16(len(p.Data)))
+}
+
+func (p *ControllerStatusPropUnknown) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, p.Len())
+	p.Length = p.ControllerStatusPropHeader.Len() + uint16(len(p.Data))
+	b, err := p.ControllerStatusPropHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	copy(data[n:], p.Data)
+	return
+}
+
+func (p *ControllerStatusPropUnknown) UnmarshalBinary(data []byte) error {
+	if err := p.ControllerStatusPropHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if len(data) < int(p.Length) || p.Length < p.ControllerStatusPropHeader.Len() {
+		return errors.New("the []byte is too short to unmarshal a full ControllerStatusPropUnknown message")
+	}
+	p.Data = make([]byte, int(p.Length-p.ControllerStatusPropHeader.Len()))
+	copy(p.Data, data[p.ControllerStatusPropHeader.Len():p.Length])
+	return nil
+}
+
+// roundUp8 rounds the length of a property up to a multiple of 8 bytes.
+func roundUp8(n uint16) uint16 {
+	return (n + 7) / 8 * 8
+}
+
+// DecodeControllerStatusProp decodes a controller status property according to its type.
+func DecodeControllerStatusProp(data []byte) (util.Message, error) {
+	header := new(ControllerStatusPropHeader)
+	if err := header.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	var prop util.Message
+	switch header.Type {
+	case OFPCSPT_URI:
+		prop = new(ControllerStatusPropUri)
+	default:
+		prop = new(ControllerStatusPropUnknown)
+	}
+	if err := prop.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return prop, nil
+}
+
+// ControllerStatus is the status of a controller channel. It is the body of OFPT_CONTROLLER_STATUS message, and
+// the entry of the OFPMP_CONTROLLER_STATUS reply.
+type ControllerStatus struct {
+	Length        uint16
+	ShortID       uint16
+	Role          uint32 /* One of OFPCR_ROLE_*. */
+	Reason        uint8  /* One of OFPCSR_*. */
+	ChannelStatus uint8  /* One of OFPCT_STATUS_*. */
+	pad           [6]byte
+	Properties    []util.Message
+}
+
+func NewControllerStatus(shortID uint16, role uint32, reason uint8, channelStatus uint8) *ControllerStatus {
+	s := new(ControllerStatus)
+	s.ShortID = shortID
+	s.Role = role
+	s.Reason = reason
+	s.ChannelStatus = channelStatus
+	s.Length = s.Len()
+	return s
+}
+
+func (s *ControllerStatus) AddProperty(prop util.Message) {
+	s.Properties = append(s.Properties, prop)
+	s.Length = s.Len()
+}
+
+func (s *ControllerStatus) Len() (n uint16) {
+	n = 16
+	for _, prop := range s.Properties {
+		n += prop.Len()
+	}
+	return
+}
+
+func (s *ControllerStatus) MarshalBinary() (data []byte, err error) {
+	s.Length = s.Len()
+	data = make([]byte, int(s.Length))
+	n := 0
+	binary.BigEndian.PutUint16(data[n:], s.Length)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], s.ShortID)
+	n += 2
+	binary.BigEndian.PutUint32(data[n:], s.Role)
+	n += 4
+	data[n] = s.Reason
+	n += 1
+	data[n] = s.ChannelStatus
+	n += 1
+	n += 6 // for pad
+	for _, prop := range s.Properties {
+		b, err := prop.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		copy(data[n:], b)
+		n += len(b)
+	}
+	return
+}
+
+func (s *ControllerStatus) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("the []byte is too short to unmarshal a full ControllerStatus message")
+	}
+	n := 0
+	s.Length = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	if int(s.Length) > len(data) || s.Length < 16 {
+		return errors.New("the []byte is too short to unmarshal a full ControllerStatus message")
+	}
+	s.ShortID = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	s.Role = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	s.Reason = data[n]
+	n += 1
+	s.ChannelStatus = data[n]
+	n += 1
+	n += 6 // for pad
+	s.Properties = nil
+	for n < int(s.Length) {
+		prop, err := DecodeControllerStatusProp(data[n:s.Length])
+		if err != nil {
+			return decodeErrorAt(err, "ControllerStatus", n, "property")
+		}
+		s.Properties = append(s.Properties, prop)
+		if n, err = safeAdvance(n, prop.Len(), int(s.Length), "controller status property"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ControllerStatusMsg is the OFPT_CONTROLLER_STATUS message.
+type ControllerStatusMsg struct {
+	common.Header
+	Status ControllerStatus
+}
+
+// NewControllerStatusMsg returns an OpenFlow 1.5 OFPT_CONTROLLER_STATUS message with the status.
+func NewControllerStatusMsg(status *ControllerStatus) *ControllerStatusMsg {
+	m := new(ControllerStatusMsg)
+	m.Header = NewOfp13Header()
+	m.Header.Version = OFP15_VERSION
+	m.Header.Type = Type_ControllerStatus
+	m.Status = *status
+	return m
+}
+
+func (m *ControllerStatusMsg) Len() (n uint16) {
+	return m.Header.Len() + m.Status.Len()
+}
+
+func (m *ControllerStatusMsg) MarshalBinary() (data []byte, err error) {
+	m.Header.Length = m.Len()
+	data, err = m.Header.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	b, err := m.Status.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, b...)
+	return
+}
+
+func (m *ControllerStatusMsg) UnmarshalBinary(data []byte) error {
+	if err := m.Header.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	return m.Status.UnmarshalBinary(data[m.Header.Len():])
+}
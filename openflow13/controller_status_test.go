@@ -0,0 +1,89 @@
+package openflow13
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestControllerStatus(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 6653}
+	uriProp := NewControllerStatusPropUriFromAddr(addr)
+	if uriProp.Uri != "tcp:10.0.0.1:6653" {
+		t.Errorf("Unexpected URI from net.Addr: %s", uriProp.Uri)
+	}
+	u, _ := url.Parse("ssl://10.0.0.2:6653")
+	if uri := NewControllerStatusPropUriFromURL(u).Uri; uri != "ssl:10.0.0.2:6653" {
+		t.Errorf("Unexpected URI from URL: %s", uri)
+	}
+
+	status := NewControllerStatus(1, OFPCR_ROLE_MASTER, OFPCSR_CHANNEL_STATUS, OFPCT_STATUS_UP)
+	status.AddProperty(uriProp)
+	msg := NewControllerStatusMsg(status)
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal ControllerStatusMsg: %v", err)
+	}
+	if len(data)%8 != 0 || len(data) != int(msg.Len()) {
+		t.Errorf("Unexpected length of ControllerStatusMsg: %d", len(data))
+	}
+
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse ControllerStatusMsg: %v", err)
+	}
+	msg2 := parsed.(*ControllerStatusMsg)
+	if msg2.Header.Version != OFP15_VERSION || msg2.Status.ShortID != 1 || msg2.Status.Role != OFPCR_ROLE_MASTER ||
+		msg2.Status.Reason != OFPCSR_CHANNEL_STATUS || msg2.Status.ChannelStatus != OFPCT_STATUS_UP {
+		t.Errorf("Unexpected ControllerStatusMsg: %+v", msg2)
+	}
+	if len(msg2.Status.Properties) != 1 || msg2.Status.Properties[0].(*ControllerStatusPropUri).Uri != "tcp:10.0.0.1:6653" {
+		t.Errorf("Unexpected properties of ControllerStatus: %+v", msg2.Status.Properties)
+	}
+}
+
+func TestControllerStatusPropUriPadding(t *testing.T) {
+	for length := 1; length <= 16; length++ {
+		uri := strings.Repeat("a", length)
+		status := NewControllerStatus(1, OFPCR_ROLE_EQUAL, OFPCSR_REQUEST, OFPCT_STATUS_UP)
+		status.AddProperty(NewControllerStatusPropUri(uri))
+		status.AddProperty(&ControllerStatusPropUnknown{ControllerStatusPropHeader: ControllerStatusPropHeader{Type: OFPCSPT_EXPERIMENTER}, Data: []byte{1, 2, 3}})
+		data, err := status.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to marshal ControllerStatus with URI length %d: %v", length, err)
+		}
+		if len(data)%8 != 0 || len(data) != int(status.Len()) {
+			t.Errorf("Misaligned ControllerStatus with URI length %d: %d bytes, Len(): %d", length, len(data), status.Len())
+		}
+		if propLen := binary.BigEndian.Uint16(data[18:]); int(propLen) != 4+length {
+			t.Errorf("Expect URI property length %d without padding, actual: %d", 4+length, propLen)
+		}
+
+		status2 := new(ControllerStatus)
+		if err = status2.UnmarshalBinary(data); err != nil {
+			t.Fatalf("Failed to unmarshal ControllerStatus with URI length %d: %v", length, err)
+		}
+		if len(status2.Properties) != 2 {
+			t.Fatalf("Expect 2 properties with URI length %d, actual: %d", length, len(status2.Properties))
+		}
+		if got := status2.Properties[0].(*ControllerStatusPropUri).URI(); got != uri {
+			t.Errorf("Expect URI %q, actual: %q", uri, got)
+		}
+		if unknown := status2.Properties[1].(*ControllerStatusPropUnknown); !bytes.Equal(unknown.Data, []byte{1, 2, 3}) {
+			t.Errorf("Unexpected property after the URI with URI length %d: %v", length, unknown.Data)
+		}
+		data2, _ := status2.MarshalBinary()
+		if !bytes.Equal(data, data2) {
+			t.Errorf("ControllerStatus with URI length %d changed after roundtrip", length)
+		}
+	}
+
+	// Some switches count a trailing NUL in the URI.
+	prop := NewControllerStatusPropUri("tcp:10.0.0.1:6653\x00")
+	if prop.URI() != "tcp:10.0.0.1:6653" {
+		t.Errorf("Unexpected URI with trailing NUL: %q", prop.URI())
+	}
+}
@@ -0,0 +1,289 @@
+package openflow13
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/contiv/libOpenflow/protocol"
+)
+
+// nx_ct_flush_tlv_type, the properties following nx_ct_flush.
+const (
+	NXT_CT_ORIG_TUPLE  = 0 /* Nested properties of the original direction tuple. */
+	NXT_CT_REPLY_TUPLE = 1 /* Nested properties of the reply direction tuple. */
+	NXT_CT_ZONE_ID     = 2 /* be16: Zone of the entries, all the zones if it is absent. */
+	NXT_CT_MARK        = 3 /* be32: Mark of the entries. */
+	NXT_CT_MARK_MASK   = 4 /* be32: Mask of the mark. */
+	NXT_CT_LABELS      = 5 /* be128: Labels of the entries. */
+	NXT_CT_LABELS_MASK = 6 /* be128: Mask of the labels. */
+)
+
+// nx_ct_flush_tlv_type, the properties nested in NXT_CT_ORIG_TUPLE and NXT_CT_REPLY_TUPLE.
+const (
+	NXT_CT_TUPLE_SRC       = 0 /* be128: Source IPv6 or IPv4-mapped IPv6 address. */
+	NXT_CT_TUPLE_DST       = 1 /* be128: Destination IPv6 or IPv4-mapped IPv6 address. */
+	NXT_CT_TUPLE_SRC_PORT  = 2 /* be16: Source port. */
+	NXT_CT_TUPLE_DST_PORT  = 3 /* be16: Destination port. */
+	NXT_CT_TUPLE_ICMP_ID   = 4 /* be16: ICMP ID. */
+	NXT_CT_TUPLE_ICMP_TYPE = 5 /* uint8_t: ICMP type. */
+	NXT_CT_TUPLE_ICMP_CODE = 6 /* uint8_t: ICMP code. */
+)
+
+// The L3 address families of nx_ct_flush, the values of Linux.
+const (
+	NX_CT_FAMILY_UNSPEC = 0
+	NX_CT_FAMILY_INET   = 2
+	NX_CT_FAMILY_INET6  = 10
+)
+
+// CtTuple is the tuple of a direction of the conntrack entries to flush. The zero fields are not matched,
+// except that the ICMP type and code are always matched for the ICMP protocols.
+type CtTuple struct {
+	Src      net.IP
+	Dst      net.IP
+	SrcPort  uint16
+	DstPort  uint16
+	IcmpID   uint16
+	IcmpType uint8
+	IcmpCode uint8
+}
+
+// isICMP returns whether the IP protocol carries the ICMP fields instead of the ports in the tuple.
+func isICMP(ipProto uint8) bool {
+	return ipProto == protocol.Type_ICMP || ipProto == protocol.Type_IPv6ICMP
+}
+
+func (t *CtTuple) isZero(ipProto uint8) bool {
+	if len(t.Src) != 0 || len(t.Dst) != 0 {
+		return false
+	}
+	if isICMP(ipProto) {
+		return t.IcmpID == 0 && t.IcmpType == 0 && t.IcmpCode == 0
+	}
+	return t.SrcPort == 0 && t.DstPort == 0
+}
+
+func (t *CtTuple) marshalBinary(ipProto uint8) (data []byte, err error) {
+	putAddr := func(propType uint16, ip net.IP) error {
+		if len(ip) == 0 {
+			return nil
+		}
+		ip16 := ip.To16()
+		if ip16 == nil {
+			return fmt.Errorf("invalid IP address %v in CtTuple", ip)
+		}
+		data = appendCtFlushProp(data, propType, ip16)
+		return nil
+	}
+	if err = putAddr(NXT_CT_TUPLE_SRC, t.Src); err != nil {
+		return nil, err
+	}
+	if err = putAddr(NXT_CT_TUPLE_DST, t.Dst); err != nil {
+		return nil, err
+	}
+	if isICMP(ipProto) {
+		data = appendCtFlushProp(data, NXT_CT_TUPLE_ICMP_ID, binary.BigEndian.AppendUint16(nil, t.IcmpID))
+		data = appendCtFlushProp(data, NXT_CT_TUPLE_ICMP_TYPE, []byte{t.IcmpType})
+		data = appendCtFlushProp(data, NXT_CT_TUPLE_ICMP_CODE, []byte{t.IcmpCode})
+		return data, nil
+	}
+	if t.SrcPort != 0 {
+		data = appendCtFlushProp(data, NXT_CT_TUPLE_SRC_PORT, binary.BigEndian.AppendUint16(nil, t.SrcPort))
+	}
+	if t.DstPort != 0 {
+		data = appendCtFlushProp(data, NXT_CT_TUPLE_DST_PORT, binary.BigEndian.AppendUint16(nil, t.DstPort))
+	}
+	return data, nil
+}
+
+func (t *CtTuple) UnmarshalBinary(data []byte) error {
+	*t = CtTuple{}
+	return walkCtFlushProps(data, func(propType uint16, payload []byte) error {
+		switch propType {
+		case NXT_CT_TUPLE_SRC, NXT_CT_TUPLE_DST:
+			if len(payload) < 16 {
+				return errors.New("the []byte is too short to unmarshal the address of CtTuple")
+			}
+			ip := net.IP(append([]byte(nil), payload[:16]...))
+			if propType == NXT_CT_TUPLE_SRC {
+				t.Src = ip
+			} else {
+				t.Dst = ip
+			}
+		case NXT_CT_TUPLE_SRC_PORT, NXT_CT_TUPLE_DST_PORT, NXT_CT_TUPLE_ICMP_ID:
+			if len(payload) < 2 {
+				return fmt.Errorf("the []byte is too short to unmarshal the property %d of CtTuple", propType)
+			}
+			v := binary.BigEndian.Uint16(payload)
+			switch propType {
+			case NXT_CT_TUPLE_SRC_PORT:
+				t.SrcPort = v
+			case NXT_CT_TUPLE_DST_PORT:
+				t.DstPort = v
+			default:
+				t.IcmpID = v
+			}
+		case NXT_CT_TUPLE_ICMP_TYPE, NXT_CT_TUPLE_ICMP_CODE:
+			if len(payload) < 1 {
+				return fmt.Errorf("the []byte is too short to unmarshal the property %d of CtTuple", propType)
+			}
+			if propType == NXT_CT_TUPLE_ICMP_TYPE {
+				t.IcmpType = payload[0]
+			} else {
+				t.IcmpCode = payload[0]
+			}
+		}
+		return nil
+	})
+}
+
+// CtFlush is the nx_ct_flush with its properties, the body of NXT_CT_FLUSH (since OVS v3.1) which flushes the
+// conntrack entries matching the tuples, the zone, the mark and the labels. A nil Zone flushes the entries of
+// all the zones, and the mark and the labels are matched only if their masks are not zero.
+type CtFlush struct {
+	IPProto    uint8
+	Family     uint8
+	OrigTuple  CtTuple
+	ReplyTuple CtTuple
+	Zone       *uint16
+	Mark       uint32
+	MarkMask   uint32
+	Labels     [16]byte
+	LabelsMask [16]byte
+}
+
+func (c *CtFlush) Len() uint16 {
+	data, _ := c.MarshalBinary()
+	return uint16(len(data))
+}
+
+func (c *CtFlush) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, 8)
+	data[0] = c.IPProto
+	data[1] = c.Family
+	for _, tuple := range []struct {
+		propType uint16
+		tuple    *CtTuple
+	}{{NXT_CT_ORIG_TUPLE, &c.OrigTuple}, {NXT_CT_REPLY_TUPLE, &c.ReplyTuple}} {
+		if tuple.tuple.isZero(c.IPProto) {
+			continue
+		}
+		nested, err := tuple.tuple.marshalBinary(c.IPProto)
+		if err != nil {
+			return nil, err
+		}
+		data = appendCtFlushProp(data, tuple.propType, nested)
+	}
+	if c.Zone != nil {
+		data = appendCtFlushProp(data, NXT_CT_ZONE_ID, binary.BigEndian.AppendUint16(nil, *c.Zone))
+	}
+	if c.MarkMask != 0 {
+		data = appendCtFlushProp(data, NXT_CT_MARK, binary.BigEndian.AppendUint32(nil, c.Mark))
+		data = appendCtFlushProp(data, NXT_CT_MARK_MASK, binary.BigEndian.AppendUint32(nil, c.MarkMask))
+	}
+	var zeroLabels [16]byte
+	if c.LabelsMask != zeroLabels {
+		data = appendCtFlushProp(data, NXT_CT_LABELS, c.Labels[:])
+		data = appendCtFlushProp(data, NXT_CT_LABELS_MASK, c.LabelsMask[:])
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes the nx_ct_flush and its properties, the unknown properties are skipped. A mark or
+// labels property without the mask property matches all the bits.
+func (c *CtFlush) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("the []byte is too short to unmarshal a full CtFlush message")
+	}
+	*c = CtFlush{IPProto: data[0], Family: data[1]}
+	var hasMark, hasMarkMask, hasLabels, hasLabelsMask bool
+	err := walkCtFlushProps(data[8:], func(propType uint16, payload []byte) error {
+		switch propType {
+		case NXT_CT_ORIG_TUPLE:
+			return c.OrigTuple.UnmarshalBinary(payload)
+		case NXT_CT_REPLY_TUPLE:
+			return c.ReplyTuple.UnmarshalBinary(payload)
+		case NXT_CT_ZONE_ID:
+			if len(payload) < 2 {
+				return errors.New("the []byte is too short to unmarshal the zone of CtFlush")
+			}
+			zone := binary.BigEndian.Uint16(payload)
+			c.Zone = &zone
+		case NXT_CT_MARK, NXT_CT_MARK_MASK:
+			if len(payload) < 4 {
+				return errors.New("the []byte is too short to unmarshal the mark of CtFlush")
+			}
+			if propType == NXT_CT_MARK {
+				c.Mark, hasMark = binary.BigEndian.Uint32(payload), true
+			} else {
+				c.MarkMask, hasMarkMask = binary.BigEndian.Uint32(payload), true
+			}
+		case NXT_CT_LABELS, NXT_CT_LABELS_MASK:
+			if len(payload) < 16 {
+				return errors.New("the []byte is too short to unmarshal the labels of CtFlush")
+			}
+			if propType == NXT_CT_LABELS {
+				copy(c.Labels[:], payload)
+				hasLabels = true
+			} else {
+				copy(c.LabelsMask[:], payload)
+				hasLabelsMask = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return withOffset(err, 8)
+	}
+	if hasMark && !hasMarkMask {
+		c.MarkMask = 0xffffffff
+	}
+	if hasLabels && !hasLabelsMask {
+		for i := range c.LabelsMask {
+			c.LabelsMask[i] = 0xff
+		}
+	}
+	return nil
+}
+
+// appendCtFlushProp appends the property of the type and the payload padded to 8 bytes.
+func appendCtFlushProp(data []byte, propType uint16, payload []byte) []byte {
+	prop := make([]byte, 4, 4+len(payload))
+	binary.BigEndian.PutUint16(prop[0:], propType)
+	binary.BigEndian.PutUint16(prop[2:], uint16(4+len(payload)))
+	return append(data, padPacketIn2Prop(append(prop, payload...))...)
+}
+
+// walkCtFlushProps calls fn with the type and the payload of each property in data.
+func walkCtFlushProps(data []byte, fn func(propType uint16, payload []byte) error) error {
+	n := 0
+	for n < len(data) {
+		header := new(PacketIn2PropHeader)
+		if err := header.UnmarshalBinary(data[n:]); err != nil {
+			return decodeErrorAt(err, "CtFlush property", n, "header")
+		}
+		if err := fn(header.Type, data[n+int(header.Len()):n+int(header.Length)]); err != nil {
+			return decodeErrorAt(err, "CtFlush property", n+int(header.Len()), "payload")
+		}
+		// The properties are padded to 8 bytes, the last padding could be missing.
+		length := (header.Length + 7) / 8 * 8
+		if n+int(length) > len(data) {
+			break
+		}
+		var err error
+		if n, err = safeAdvance(n, length, len(data), "CtFlush property"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewCtFlush returns the NXT_CT_FLUSH message to flush the conntrack entries matching the flush, e.g., the
+// entries of a connection in a zone. Use NewCtFlushZone to flush a zone with the switches before OVS v3.1.
+func NewCtFlush(flush *CtFlush) *VendorHeader {
+	msg := NewNXTVendorHeader(Type_CtFlush)
+	msg.VendorData = flush
+	return msg
+}
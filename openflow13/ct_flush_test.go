@@ -0,0 +1,69 @@
+package openflow13
+
+import (
+	"net"
+	"testing"
+
+	"github.com/contiv/libOpenflow/protocol"
+)
+
+func TestCtFlush(t *testing.T) {
+	zone := uint16(5)
+	for _, flush := range []*CtFlush{
+		{},
+		{Zone: &zone},
+		{
+			IPProto:    protocol.Type_TCP,
+			Family:     NX_CT_FAMILY_INET,
+			OrigTuple:  CtTuple{Src: net.ParseIP("10.0.0.1"), Dst: net.ParseIP("10.0.0.2"), DstPort: 80},
+			ReplyTuple: CtTuple{SrcPort: 80},
+			Zone:       &zone,
+			Mark:       0x10,
+			MarkMask:   0xf0,
+			Labels:     [16]byte{15: 1},
+			LabelsMask: [16]byte{15: 0xff},
+		},
+		{
+			IPProto:   protocol.Type_IPv6ICMP,
+			Family:    NX_CT_FAMILY_INET6,
+			OrigTuple: CtTuple{Src: net.ParseIP("fe80::1"), IcmpID: 7, IcmpType: 128},
+		},
+	} {
+		msg := NewCtFlush(flush)
+		data, err := msg.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to marshal CtFlush: %v", err)
+		}
+		if len(data) != int(msg.Len()) || len(data)%8 != 0 {
+			t.Errorf("Unexpected CtFlush length %d, Len(): %d", len(data), msg.Len())
+		}
+		parsed, err := Parse(data)
+		if err != nil {
+			t.Fatalf("Failed to parse CtFlush: %v", err)
+		}
+		flush2, ok := parsed.(*VendorHeader).VendorData.(*CtFlush)
+		if !ok {
+			t.Fatalf("Expect CtFlush, actual: %T", parsed.(*VendorHeader).VendorData)
+		}
+		if flush2.IPProto != flush.IPProto || flush2.Family != flush.Family || (flush2.Zone == nil) != (flush.Zone == nil) ||
+			flush2.Mark != flush.Mark || flush2.MarkMask != flush.MarkMask ||
+			flush2.Labels != flush.Labels || flush2.LabelsMask != flush.LabelsMask {
+			t.Errorf("Unexpected CtFlush: %+v, expected: %+v", flush2, flush)
+		}
+		if flush.Zone != nil && *flush2.Zone != *flush.Zone {
+			t.Errorf("Unexpected zone %d", *flush2.Zone)
+		}
+		for _, tuples := range [][2]CtTuple{{flush.OrigTuple, flush2.OrigTuple}, {flush.ReplyTuple, flush2.ReplyTuple}} {
+			expected, actual := tuples[0], tuples[1]
+			if !expected.Src.Equal(actual.Src) || !expected.Dst.Equal(actual.Dst) || expected.SrcPort != actual.SrcPort ||
+				expected.DstPort != actual.DstPort || expected.IcmpID != actual.IcmpID ||
+				expected.IcmpType != actual.IcmpType || expected.IcmpCode != actual.IcmpCode {
+				t.Errorf("Unexpected tuple %+v, expected: %+v", actual, expected)
+			}
+		}
+	}
+
+	if _, err := NewCtFlush(&CtFlush{OrigTuple: CtTuple{Src: net.IP{1, 2}}}).MarshalBinary(); err == nil {
+		t.Errorf("Expect error for an invalid address")
+	}
+}
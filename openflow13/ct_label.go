@@ -0,0 +1,119 @@
+package openflow13
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// The ct_label helpers below take the value of a range of the 128-bit label as a big.Int, and shift it to the
+// bits of the range, e.g., the value 0x1 of NewNXRange(64, 95) is the bit 64 of the label. The bit 0 is the
+// least significant bit of the label, i.e., the last bit of the [16]byte in network order.
+
+// CTLabelFromBigInt returns the ct_label of the value, an error is returned if the value is negative or
+// exceeds 128 bits.
+func CTLabelFromBigInt(value *big.Int) ([16]byte, error) {
+	var label [16]byte
+	if value.Sign() < 0 || value.BitLen() > 128 {
+		return label, fmt.Errorf("invalid ct_label value %s", value.String())
+	}
+	value.FillBytes(label[:])
+	return label, nil
+}
+
+// CTLabelToBigInt returns the value of the ct_label.
+func CTLabelToBigInt(label [16]byte) *big.Int {
+	return new(big.Int).SetBytes(label[:])
+}
+
+// CTLabelRangeMask returns the mask of the bits of the range in ct_label.
+func CTLabelRangeMask(rng *NXRange) ([16]byte, error) {
+	if err := checkCTLabelRange(rng); err != nil {
+		return [16]byte{}, err
+	}
+	mask := new(big.Int).Lsh(big.NewInt(1), uint(rng.GetNbits()))
+	mask.Sub(mask, big.NewInt(1))
+	return CTLabelFromBigInt(mask.Lsh(mask, uint(rng.GetOfs())))
+}
+
+func checkCTLabelRange(rng *NXRange) error {
+	if rng.start < 0 || rng.end < rng.start || rng.end >= 128 {
+		return fmt.Errorf("invalid ct_label range [%d..%d]", rng.start, rng.end)
+	}
+	return nil
+}
+
+// checkCTLabelRangeValue checks that the value fits in the bits of the range.
+func checkCTLabelRangeValue(value *big.Int, rng *NXRange) error {
+	if err := checkCTLabelRange(rng); err != nil {
+		return err
+	}
+	if value.Sign() < 0 || value.BitLen() > int(rng.GetNbits()) {
+		return fmt.Errorf("ct_label value %s exceeds the range [%d..%d]", value.String(), rng.start, rng.end)
+	}
+	return nil
+}
+
+// NewCTLabelRangeMatchField returns the masked ct_label field which matches the value in the bits of the range,
+// e.g., ct_label=0x10000000000000000/0xffffffff0000000000000000 for the value 0x1 in NewNXRange(64, 95).
+func NewCTLabelRangeMatchField(value *big.Int, rng *NXRange) (*MatchField, error) {
+	if err := checkCTLabelRangeValue(value, rng); err != nil {
+		return nil, err
+	}
+	label, err := CTLabelFromBigInt(new(big.Int).Lsh(value, uint(rng.GetOfs())))
+	if err != nil {
+		return nil, err
+	}
+	mask, err := CTLabelRangeMask(rng)
+	if err != nil {
+		return nil, err
+	}
+	return NewCTLabelMatchField(label, &mask), nil
+}
+
+// NewCTLabelRegLoad2 returns the NXActionRegLoad2 which loads the value to the bits of the range in ct_label,
+// i.e., set_field:value->ct_label with the mask of the range. It is used in the actions of ct commit.
+func NewCTLabelRegLoad2(value *big.Int, rng *NXRange) (*NXActionRegLoad2, error) {
+	field, err := NewCTLabelRangeMatchField(value, rng)
+	if err != nil {
+		return nil, err
+	}
+	return NewNXActionRegLoad2(field), nil
+}
+
+// NewCTLabelRegLoads returns the NXActionRegLoad actions which load the value to the bits of the range in
+// ct_label, one action for each 64 bits from the least significant bits, as NXActionRegLoad loads a uint64.
+func NewCTLabelRegLoads(value *big.Int, rng *NXRange) ([]*NXActionRegLoad, error) {
+	if err := checkCTLabelRangeValue(value, rng); err != nil {
+		return nil, err
+	}
+	dstField, _ := FindFieldHeaderByName("NXM_NX_CT_LABEL", false)
+	var actions []*NXActionRegLoad
+	remaining := new(big.Int).Set(value)
+	for start := rng.start; start <= rng.end; start += 64 {
+		end := start + 63
+		if end > rng.end {
+			end = rng.end
+		}
+		chunk := new(big.Int).And(remaining, new(big.Int).SetUint64(^uint64(0)))
+		actions = append(actions, NewNXActionRegLoad(NewNXRange(start, end).ToOfsBits(), dstField, chunk.Uint64()))
+		remaining.Rsh(remaining, 64)
+	}
+	return actions, nil
+}
+
+// NewCTLabelLearnSpecLoadFromValue returns the learn spec which loads the value to the bits of the range in the
+// ct_label of the learned flow. The value is encoded in big endian, and padded to a multiple of 16 bits.
+func NewCTLabelLearnSpecLoadFromValue(value *big.Int, rng *NXRange) (*NXLearnSpec, error) {
+	if err := checkCTLabelRangeValue(value, rng); err != nil {
+		return nil, err
+	}
+	dstField, _ := FindFieldHeaderByName("NXM_NX_CT_LABEL", false)
+	nBits := rng.GetNbits()
+	srcValue := make([]byte, 2*((nBits+15)/16))
+	value.FillBytes(srcValue)
+	return &NXLearnSpec{
+		Header:   NewLearnHeaderLoadFromValue(nBits),
+		SrcValue: srcValue,
+		DstField: &NXLearnSpecField{Field: dstField, Ofs: rng.GetOfs()},
+	}, nil
+}
@@ -0,0 +1,86 @@
+package openflow13
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestCTLabelRangeMatchField(t *testing.T) {
+	field, err := NewCTLabelRangeMatchField(big.NewInt(0x5), NewNXRange(64, 95))
+	if err != nil {
+		t.Fatalf("Failed to create ct_label field: %v", err)
+	}
+	expValue := []byte{0, 0, 0, 0, 0, 0, 0, 0x5, 0, 0, 0, 0, 0, 0, 0, 0}
+	expMask := []byte{0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 0, 0, 0, 0}
+	if value, _ := field.Value.MarshalBinary(); !bytes.Equal(value, expValue) {
+		t.Errorf("Unexpected ct_label value: %x", value)
+	}
+	if mask, _ := field.Mask.MarshalBinary(); !bytes.Equal(mask, expMask) {
+		t.Errorf("Unexpected ct_label mask: %x", mask)
+	}
+
+	if _, err = NewCTLabelRangeMatchField(big.NewInt(0x10), NewNXRange(0, 3)); err == nil {
+		t.Errorf("Expect error for the value exceeding the range")
+	}
+	if _, err = NewCTLabelRangeMatchField(big.NewInt(1), NewNXRange(120, 128)); err == nil {
+		t.Errorf("Expect error for the range beyond 128 bits")
+	}
+	label, _ := CTLabelFromBigInt(new(big.Int).Lsh(big.NewInt(1), 127))
+	if label[0] != 0x80 || CTLabelToBigInt(label).BitLen() != 128 {
+		t.Errorf("Unexpected ct_label of bit 127: %x", label)
+	}
+	if _, err = CTLabelFromBigInt(new(big.Int).Lsh(big.NewInt(1), 128)); err == nil {
+		t.Errorf("Expect error for the value exceeding 128 bits")
+	}
+}
+
+func TestCTLabelLoads(t *testing.T) {
+	value, _ := new(big.Int).SetString("123456789abcdef0fedc", 16)
+	actions, err := NewCTLabelRegLoads(value, NewNXRange(32, 111))
+	if err != nil {
+		t.Fatalf("Failed to create reg_load actions: %v", err)
+	}
+	if len(actions) != 2 {
+		t.Fatalf("Expect 2 reg_load actions, actual: %d", len(actions))
+	}
+	for i, exp := range []struct {
+		ofs, nBits uint16
+		value      uint64
+	}{
+		{32, 64, 0x56789abcdef0fedc},
+		{96, 16, 0x1234},
+	} {
+		a := actions[i]
+		if decodeOfs(a.OfsNbits) != exp.ofs || decodeNbits(a.OfsNbits) != exp.nBits || a.Value != exp.value || a.DstReg.Field != NXM_NX_CT_LABEL {
+			t.Errorf("Unexpected reg_load action %d: ofs %d, nbits %d, value 0x%x", i, decodeOfs(a.OfsNbits), decodeNbits(a.OfsNbits), a.Value)
+		}
+	}
+
+	load2, err := NewCTLabelRegLoad2(big.NewInt(1), NewNXRange(127, 127))
+	if err != nil {
+		t.Fatalf("Failed to create reg_load2 action: %v", err)
+	}
+	data, _ := load2.MarshalBinary()
+	decoded := new(NXActionRegLoad2)
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to decode reg_load2 action: %v", err)
+	}
+	if v, _ := decoded.DstField.Value.MarshalBinary(); v[0] != 0x80 || !decoded.DstField.HasMask {
+		t.Errorf("Unexpected reg_load2 field: %+v", decoded.DstField)
+	}
+
+	spec, err := NewCTLabelLearnSpecLoadFromValue(big.NewInt(0x1ffff), NewNXRange(8, 27))
+	if err != nil {
+		t.Fatalf("Failed to create learn spec: %v", err)
+	}
+	data, _ = spec.MarshalBinary()
+	decodedSpec := new(NXLearnSpec)
+	if err = decodedSpec.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to decode learn spec: %v", err)
+	}
+	if decodedSpec.Header.nBits != 20 || !decodedSpec.Header.dst || decodedSpec.DstField.Ofs != 8 ||
+		decodedSpec.DstField.Field.Field != NXM_NX_CT_LABEL || !bytes.Equal(decodedSpec.SrcValue, []byte{0, 0x1, 0xff, 0xff}) {
+		t.Errorf("Unexpected learn spec: %+v", decodedSpec)
+	}
+}
@@ -0,0 +1,66 @@
+package openflow13
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTruncated is wrapped by the DecodeError of a field which overruns the data.
+var ErrTruncated = errors.New("the []byte is too short")
+
+// DecodeError is returned by the decoders when the data is malformed, e.g., a truncated switch reply, so that the
+// controllers could log and drop the frame. The decoders of the lists, e.g., the match fields, instructions,
+// actions, buckets, properties and multipart bodies, return the error of an element at the offset of the element,
+// and ParseWithOptions in strict mode returns all the errors as DecodeError. Offset is the offset of Field from the
+// start of the data passed to the outermost decoder, e.g., the OpenFlow header of a MultipartReply.
+type DecodeError struct {
+	// Message is the name of the decoded structure, e.g., "PortStats".
+	Message string
+	Offset  int
+	Field   string
+	Err     error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode %s: field %s at offset %d: %v", e.Message, e.Field, e.Offset, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// checkBounds returns a DecodeError wrapping ErrTruncated if the field of size bytes at offset overruns data.
+func checkBounds(data []byte, offset int, size int, message string, field string) error {
+	if offset < 0 || offset+size > len(data) {
+		return &DecodeError{
+			Message: message,
+			Offset:  offset,
+			Field:   field,
+			Err:     fmt.Errorf("%w: need %d bytes, have %d", ErrTruncated, offset+size, len(data)),
+		}
+	}
+	return nil
+}
+
+// withOffset shifts the offset of a DecodeError by the offset of the nested data it was decoded from, and returns
+// the other errors unchanged.
+func withOffset(err error, offset int) error {
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) {
+		shifted := *decodeErr
+		shifted.Offset += offset
+		return &shifted
+	}
+	return err
+}
+
+// decodeErrorAt returns the error of decoding the field or element at offset of the message as a DecodeError. The
+// DecodeError of a nested decoder is returned with its offset shifted by offset, so that the innermost position is
+// reported.
+func decodeErrorAt(err error, message string, offset int, field string) error {
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) {
+		return withOffset(err, offset)
+	}
+	return &DecodeError{Message: message, Offset: offset, Field: field, Err: err}
+}
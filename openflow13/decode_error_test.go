@@ -0,0 +1,77 @@
+package openflow13
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func TestDecodeErrorTruncatedStats(t *testing.T) {
+	reply := newMultipartReply(1, MultipartType_Port, 0)
+	reply.Body = append(reply.Body, NewPortStats(), NewPortStats())
+	data, _ := reply.MarshalBinary()
+	// Cut the second PortStats short, keeping the header length consistent with the data.
+	data = data[:len(data)-8]
+	data[2], data[3] = byte(len(data)>>8), byte(len(data))
+
+	var decodeErr *DecodeError
+	err := new(MultipartReply).UnmarshalBinary(data)
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("Expect DecodeError, actual: %v", err)
+	}
+	if decodeErr.Message != "PortStats" || decodeErr.Offset != 16+104 || !errors.Is(err, ErrTruncated) {
+		t.Errorf("Unexpected DecodeError %v", decodeErr)
+	}
+
+	for _, body := range []interface{ UnmarshalBinary([]byte) error }{
+		new(DescStats), new(AggregateStats), new(AggregateStatsRequest), NewTableStats(), new(QueueStats), NewFlowStats(),
+	} {
+		if err := body.UnmarshalBinary(make([]byte, 4)); !errors.As(err, &decodeErr) {
+			t.Errorf("Expect DecodeError of %T, actual: %v", body, err)
+		}
+	}
+}
+
+func TestParseWithOptionsStrict(t *testing.T) {
+	packetIn := newBenchmarkPacketIn()
+	data, _ := packetIn.MarshalBinary()
+	// Replace the class of the in_port field with an unknown OXM class.
+	data[28], data[29] = 0x53, 0x94
+	if _, err := ParseWithOptions(data, DecodeOptions{Strict: true}); err == nil {
+		t.Errorf("Expect error to parse a PacketIn with an unknown match field")
+	}
+
+	data, _ = newBenchmarkFlowStatsReply().MarshalBinary()
+	if _, err := ParseWithOptions(data, DecodeOptions{Strict: true}); err != nil {
+		t.Errorf("Failed to parse a valid message in strict mode: %v", err)
+	}
+
+	// A plain error of the message is positioned at the start of the message.
+	_, err := ParseWithOptions([]byte{VERSION, Type_FlowMod, 0, 8, 0, 0, 0, 1}, DecodeOptions{Strict: true})
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) || decodeErr.Message != "message type 14" || decodeErr.Offset != 0 {
+		t.Errorf("Expect DecodeError of the message, actual: %v", err)
+	}
+}
+
+func TestDecodeErrorNestedField(t *testing.T) {
+	flowMod := NewFlowMod()
+	instr := NewInstrApplyActions()
+	instr.AddAction(NewActionOutput(1), false)
+	flowMod.AddInstruction(instr)
+	data, _ := flowMod.MarshalBinary()
+	// Cut the output action in the apply actions instruction short.
+	actionOffset := 48 + int(flowMod.Match.Len()) + 8
+	binary.BigEndian.PutUint16(data[actionOffset+2:], 8)
+
+	for _, opts := range []DecodeOptions{{}, {Strict: true}} {
+		_, err := ParseWithOptions(data, opts)
+		var decodeErr *DecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Fatalf("Expect DecodeError, actual: %v", err)
+		}
+		if decodeErr.Message != "InstrActions" || decodeErr.Field != "action" || decodeErr.Offset != actionOffset {
+			t.Errorf("Expect DecodeError of the action at offset %d, actual: %v", actionOffset, decodeErr)
+		}
+	}
+}
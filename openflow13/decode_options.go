@@ -0,0 +1,62 @@
+package openflow13
+
+import (
+	"fmt"
+
+	"github.com/contiv/libOpenflow/common"
+	"github.com/contiv/libOpenflow/util"
+)
+
+// DecodeOptions are the options to decode the messages on the hot path, i.e., PacketIn, PacketIn2, FlowStats and
+// MultipartReply, see ParseWithOptions.
+type DecodeOptions struct {
+	// Borrow makes the decoded message reference the []byte to decode rather than copy the bytes out of it, e.g.,
+	// PacketIn.RawData, the PacketIn2 packet and FlowStats.RawInstructions, and the decoding of the Ethernet frame
+	// and the instructions is deferred, so FlowStats.Instructions is nil until GetInstructions is called. The
+	// caller must not modify or reuse the []byte while the message is in use. It is not safe with
+	// util.MessageStream, whose buffers are reused after Parse returns.
+	Borrow bool
+	// LazyPacketIn defers the decoding of the Ethernet frame of PacketIn to GetData like Borrow, but PacketIn.RawData
+	// is a copy of the packet bytes, so it is safe with util.MessageStream. It saves CPU for the controllers which
+	// only need the metadata of PacketIn, e.g., table, cookie and match. Borrow implies it.
+	LazyPacketIn bool
+	// Strict makes ParseWithOptions return all the errors as *DecodeError, so that a controller could log the
+	// position of the malformed field, drop the frame and keep the connection. The errors of the elements in the
+	// lists are positioned at the element, and the other errors of the message at offset 0.
+	Strict bool
+}
+
+// ParseWithOptions parses an OpenFlow message with the options, PacketIn, NXT_PACKET_IN2 and MultipartReply are
+// decoded with the options, and the other messages are parsed by Parse.
+func ParseWithOptions(b []byte, opts DecodeOptions) (message util.Message, err error) {
+	if len(b) < 8 {
+		if opts.Strict {
+			return nil, &DecodeError{Message: "header", Offset: 0, Field: "length", Err: common.ErrHeaderTooShort}
+		}
+		return nil, common.ErrHeaderTooShort
+	}
+	if opts.Strict {
+		defer func() {
+			if err != nil {
+				err = decodeErrorAt(err, fmt.Sprintf("message type %d", b[1]), 0, "message")
+			}
+		}()
+	}
+	switch b[1] {
+	case Type_PacketIn:
+		pktIn := new(PacketIn)
+		err = pktIn.UnmarshalBinaryWithOptions(b, opts)
+		message = pktIn
+	case Type_MultiPartReply:
+		reply := new(MultipartReply)
+		err = reply.UnmarshalBinaryWithOptions(b, opts)
+		message = reply
+	case Type_Experimenter:
+		vendor := new(VendorHeader)
+		err = vendor.unmarshalBinary(b, opts)
+		message = vendor
+	default:
+		return Parse(b)
+	}
+	return
+}
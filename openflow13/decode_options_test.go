@@ -0,0 +1,148 @@
+package openflow13
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+func TestParseWithOptionsBorrow(t *testing.T) {
+	borrow := DecodeOptions{Borrow: true}
+
+	packetIn := newBenchmarkPacketIn()
+	packetIn.Cookie = 0x1234
+	data, _ := packetIn.MarshalBinary()
+	msg, err := ParseWithOptions(data, borrow)
+	if err != nil {
+		t.Fatalf("Failed to parse PacketIn: %v", err)
+	}
+	parsedPktIn := msg.(*PacketIn)
+	if parsedPktIn.Cookie != 0x1234 || !parsedPktIn.dataDeferred || &parsedPktIn.RawData[0] != &data[len(data)-len(parsedPktIn.RawData)] {
+		t.Errorf("Expect PacketIn data borrowed, actual: %+v", parsedPktIn)
+	}
+	if frame, err := parsedPktIn.GetData(); err != nil || frame.Len() != packetIn.Data.Len() {
+		t.Errorf("Failed to decode the borrowed PacketIn data: %v", err)
+	}
+	if parsed, _ := parsedPktIn.MarshalBinary(); !bytes.Equal(parsed, data) {
+		t.Errorf("Expect PacketIn %v, actual: %v", data, parsed)
+	}
+
+	reply := newBenchmarkFlowStatsReply()
+	data, _ = reply.MarshalBinary()
+	msg, err = ParseWithOptions(data, borrow)
+	if err != nil {
+		t.Fatalf("Failed to parse MultipartReply: %v", err)
+	}
+	parsedReply := msg.(*MultipartReply)
+	if len(parsedReply.Body) != 10 {
+		t.Fatalf("Expect 10 multipart bodies, actual: %d", len(parsedReply.Body))
+	}
+	flow := parsedReply.Body[0].(*FlowStats)
+	if flow.Instructions != nil || len(flow.RawInstructions) == 0 {
+		t.Errorf("Expect the FlowStats instructions deferred, actual: %+v", flow)
+	}
+	if dumped, err := NewFlowModFromFlowStats(flow); err != nil || len(dumped.Instructions) != 1 {
+		t.Errorf("Expect the deferred instructions decoded for the FlowMod, actual: %v, %v", dumped, err)
+	}
+	instructions, err := flow.GetInstructions()
+	if err != nil || len(instructions) != 1 || flow.Len() != reply.Body[0].Len() {
+		t.Errorf("Failed to decode the borrowed FlowStats instructions: %v, %v", instructions, err)
+	}
+	if parsed, _ := parsedReply.MarshalBinary(); !bytes.Equal(parsed, data) {
+		t.Errorf("Expect MultipartReply %v, actual: %v", data, parsed)
+	}
+
+	reply = newMultipartReply(1, MultipartType_Experimenter, 0, NewExperimenterStatsBody(NxExperimenterID, 12, []byte{1, 2, 3, 4}))
+	data, _ = reply.MarshalBinary()
+	msg, err = ParseWithOptions(data, borrow)
+	if err != nil {
+		t.Fatalf("Failed to parse MultipartReply: %v", err)
+	}
+	expBody := msg.(*MultipartReply).Body[0].(*ExperimenterStatsBody)
+	data[len(data)-1] = 5
+	if expBody.Data[3] != 5 {
+		t.Errorf("Expect ExperimenterStatsBody data borrowed, actual: %v", expBody.Data)
+	}
+
+	msg2 := NewNXTVendorHeader(Type_PacketIn2)
+	msg2.VendorData = &PacketIn2{Props: []util.Message{
+		NewPacketIn2PropBytes(NXPINT_PACKET, []byte{1, 2, 3, 4, 5}),
+		NewPacketIn2PropUint(NXPINT_TABLE_ID, 3),
+	}}
+	data, _ = msg2.MarshalBinary()
+	msg, err = ParseWithOptions(data, borrow)
+	if err != nil {
+		t.Fatalf("Failed to parse PacketIn2: %v", err)
+	}
+	pktIn2 := msg.(*VendorHeader).VendorData.(*PacketIn2)
+	packet := pktIn2.Packet()
+	if !bytes.Equal(packet, []byte{1, 2, 3, 4, 5}) {
+		t.Fatalf("Expect PacketIn2 packet [1 2 3 4 5], actual: %v", packet)
+	}
+	data[20] = 9
+	if packet[0] != 9 {
+		t.Errorf("Expect PacketIn2 packet borrowed, actual: %v", packet)
+	}
+	if tableID, ok := pktIn2.Uint(NXPINT_TABLE_ID); !ok || tableID != 3 {
+		t.Errorf("Expect PacketIn2 table 3, actual: %d", tableID)
+	}
+
+	// The borrowed bytes are copied without Borrow.
+	data, _ = msg2.MarshalBinary()
+	msg, _ = ParseWithOptions(data, DecodeOptions{})
+	packet = msg.(*VendorHeader).VendorData.(*PacketIn2).Packet()
+	data[20] = 9
+	if packet[0] != 1 {
+		t.Errorf("Expect PacketIn2 packet copied, actual: %v", packet)
+	}
+}
+
+func borrowParse(b []byte) (util.Message, error) {
+	return ParseWithOptions(b, DecodeOptions{Borrow: true})
+}
+
+func BenchmarkParsePacketInBorrow(b *testing.B) {
+	benchmarkParseFunc(b, newBenchmarkPacketIn(), borrowParse)
+}
+
+func BenchmarkParseFlowStatsBorrow(b *testing.B) {
+	benchmarkParseFunc(b, newBenchmarkFlowStatsReply(), borrowParse)
+}
+
+func TestParseWithOptionsLazyPacketIn(t *testing.T) {
+	packetIn := newBenchmarkPacketIn()
+	data, _ := packetIn.MarshalBinary()
+	if msg, _ := Parse(data); msg.(*PacketIn).dataDeferred {
+		t.Errorf("Expect PacketIn data decoded by Parse")
+	}
+
+	// The lazy decoding is set per Parser, e.g., the Parser of a util.MessageStream.
+	parser := NewParser(DecodeOptions{LazyPacketIn: true})
+	msg, err := parser.Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse PacketIn: %v", err)
+	}
+	parsed := msg.(*PacketIn)
+	if !parsed.dataDeferred || len(parsed.RawData) != int(packetIn.Data.Len()) || parsed.Data.Data != nil {
+		t.Fatalf("Expect PacketIn data deferred, actual: %+v", parsed)
+	}
+	if parsed.Len() != packetIn.Len() {
+		t.Errorf("Expect PacketIn length %d, actual: %d", packetIn.Len(), parsed.Len())
+	}
+	// RawData is a copy, so the buffer can be reused after Parse.
+	expected := append([]byte(nil), data...)
+	for i := range data {
+		data[i] = 0
+	}
+	if actual, _ := parsed.MarshalBinary(); !bytes.Equal(actual, expected) {
+		t.Errorf("Expect PacketIn %v, actual: %v", expected, actual)
+	}
+	frame, err := parsed.GetData()
+	if err != nil || !bytes.Equal(frame.HWSrc, packetIn.Data.HWSrc) || frame.Ethertype != packetIn.Data.Ethertype {
+		t.Errorf("Failed to decode the deferred PacketIn data: %+v, %v", frame, err)
+	}
+	if parsed.dataDeferred {
+		t.Errorf("Expect PacketIn data decoded by GetData")
+	}
+}
@@ -0,0 +1,144 @@
+package openflow13
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/contiv/libOpenflow/common"
+	"github.com/contiv/libOpenflow/util"
+)
+
+// echoTimestampLen is the length of the timestamp embedded by NewEchoRequestWithTimestamp, the Unix time in
+// nanoseconds.
+const echoTimestampLen = 8
+
+// EchoRequest is the OFPT_ECHO_REQUEST with the arbitrary Data, which the peer returns in the EchoReply. Parse
+// returns it only for the echo with data, the echo without data is a *common.Header.
+type EchoRequest struct {
+	common.Header
+	Data []byte
+}
+
+// EchoReply is the OFPT_ECHO_REPLY with the Data of the EchoRequest. Parse returns it only for the echo with data as
+// EchoRequest.
+type EchoReply struct {
+	common.Header
+	Data []byte
+}
+
+// NewEchoRequestWithData returns the EchoRequest with the data.
+func NewEchoRequestWithData(data []byte) *EchoRequest {
+	r := new(EchoRequest)
+	r.Header = NewOfp13Header()
+	r.Header.Type = Type_EchoRequest
+	r.Data = data
+	return r
+}
+
+// NewEchoRequestWithTimestamp returns the EchoRequest with the timestamp, the RTT is returned by the RTT method of
+// its EchoReply.
+func NewEchoRequestWithTimestamp(t time.Time) *EchoRequest {
+	data := make([]byte, echoTimestampLen)
+	binary.BigEndian.PutUint64(data, uint64(t.UnixNano()))
+	return NewEchoRequestWithData(data)
+}
+
+// NewEchoReplyTo returns the EchoReply of the EchoRequest, with the xid and the data of the request.
+func NewEchoReplyTo(req *EchoRequest) *EchoReply {
+	r := new(EchoReply)
+	r.Header = NewOfp13Header()
+	r.Header.Version = req.Header.Version
+	r.Header.Type = Type_EchoReply
+	r.Header.Xid = req.Header.Xid
+	r.Data = append([]byte(nil), req.Data...)
+	return r
+}
+
+func (r *EchoRequest) Len() (n uint16) {
+	return r.Header.Len() + uint16(len(r.Data))
+}
+
+func (r *EchoRequest) MarshalBinary() (data []byte, err error) {
+	r.Header.Length = r.Len()
+	return marshalEcho(&r.Header, r.Data)
+}
+
+func (r *EchoRequest) UnmarshalBinary(data []byte) error {
+	d, err := unmarshalEcho(&r.Header, data, "EchoRequest")
+	r.Data = d
+	return err
+}
+
+func (r *EchoReply) Len() (n uint16) {
+	return r.Header.Len() + uint16(len(r.Data))
+}
+
+func (r *EchoReply) MarshalBinary() (data []byte, err error) {
+	r.Header.Length = r.Len()
+	return marshalEcho(&r.Header, r.Data)
+}
+
+func (r *EchoReply) UnmarshalBinary(data []byte) error {
+	d, err := unmarshalEcho(&r.Header, data, "EchoReply")
+	r.Data = d
+	return err
+}
+
+// RTT returns the round trip time from the timestamp embedded by NewEchoRequestWithTimestamp to now.
+func (r *EchoReply) RTT(now time.Time) (time.Duration, error) {
+	if len(r.Data) != echoTimestampLen {
+		return 0, fmt.Errorf("the echo data of %d bytes is not a timestamp", len(r.Data))
+	}
+	return now.Sub(time.Unix(0, int64(binary.BigEndian.Uint64(r.Data)))), nil
+}
+
+func marshalEcho(h *common.Header, payload []byte) (data []byte, err error) {
+	data, err = h.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append(data, payload...), nil
+}
+
+func unmarshalEcho(h *common.Header, data []byte, name string) ([]byte, error) {
+	if err := h.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	if h.Length < h.Len() || int(h.Length) > len(data) {
+		return nil, fmt.Errorf("the []byte is too short to unmarshal a full %s message", name)
+	}
+	return append([]byte(nil), data[h.Len():h.Length]...), nil
+}
+
+// parseEcho decodes the echo request or reply. The echo without data is a *common.Header as it has always been, so
+// that the type switches on *common.Header keep answering the echoes, and the echo with data is an *EchoRequest or
+// an *EchoReply.
+func parseEcho(b []byte) (util.Message, error) {
+	h := new(common.Header)
+	if err := h.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	if h.Length == h.Len() {
+		return h, nil
+	}
+	var msg util.Message = new(EchoRequest)
+	if h.Type == Type_EchoReply {
+		msg = new(EchoReply)
+	}
+	return msg, msg.UnmarshalBinary(b)
+}
+
+// RequestEchoRTT sends a timestamped EchoRequest, and returns the RTT of the switch from its EchoReply.
+func RequestEchoRTT(ctx context.Context, conn *Conn) (time.Duration, error) {
+	replies, err := conn.SendAndAwaitReply(ctx, NewEchoRequestWithTimestamp(time.Now()))
+	if err != nil {
+		return 0, err
+	}
+	reply, ok := replies[0].(*EchoReply)
+	if !ok {
+		return 0, fmt.Errorf("unexpected reply %T to echo request", replies[0])
+	}
+	return reply.RTT(time.Now())
+}
@@ -0,0 +1,79 @@
+package openflow13
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/contiv/libOpenflow/common"
+	"github.com/contiv/libOpenflow/util"
+)
+
+func TestEchoData(t *testing.T) {
+	req := NewEchoRequestWithData([]byte{1, 2, 3, 4})
+	data, err := req.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal EchoRequest: %v", err)
+	}
+	msg, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse EchoRequest: %v", err)
+	}
+	req2, ok := msg.(*EchoRequest)
+	if !ok || !bytes.Equal(req2.Data, req.Data) || req2.Xid != req.Xid {
+		t.Fatalf("Unexpected EchoRequest: %+v", msg)
+	}
+
+	data, _ = NewEchoReplyTo(req2).MarshalBinary()
+	msg, err = Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse EchoReply: %v", err)
+	}
+	if reply, ok := msg.(*EchoReply); !ok || !bytes.Equal(reply.Data, req.Data) || reply.Xid != req.Xid {
+		t.Errorf("Unexpected EchoReply: %+v", msg)
+	}
+	if _, err = Parse(data[:len(data)-1]); err == nil {
+		t.Errorf("Expect an error of the truncated EchoReply")
+	}
+
+	// The echo without data is still a *common.Header.
+	for _, echo := range []*common.Header{NewEchoRequest(), NewEchoReply()} {
+		data, _ = echo.MarshalBinary()
+		msg, err = Parse(data)
+		if h, ok := msg.(*common.Header); err != nil || !ok || h.Type != echo.Type {
+			t.Errorf("Unexpected echo without data: %+v, %v", msg, err)
+		}
+	}
+}
+
+func TestRequestEchoRTT(t *testing.T) {
+	inbound := make(chan util.Message)
+	outbound := make(chan util.Message)
+	defer close(outbound)
+	conn := newConn(inbound, outbound, func(msg util.Message) {})
+	defer conn.Close()
+
+	go fakeSwitch(outbound, inbound, func(req util.Message) []util.Message {
+		echo, ok := req.(*EchoRequest)
+		if !ok {
+			return nil
+		}
+		time.Sleep(10 * time.Millisecond)
+		return []util.Message{NewEchoReplyTo(echo)}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rtt, err := RequestEchoRTT(ctx, conn)
+	if err != nil {
+		t.Fatalf("Failed to request echo: %v", err)
+	}
+	if rtt < 10*time.Millisecond || rtt > 5*time.Second {
+		t.Errorf("Unexpected RTT %v", rtt)
+	}
+
+	if _, err = NewEchoReplyTo(NewEchoRequestWithData([]byte{1})).RTT(time.Now()); err == nil {
+		t.Errorf("Expect an error of the echo data without timestamp")
+	}
+}
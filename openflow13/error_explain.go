@@ -0,0 +1,221 @@
+package openflow13
+
+import (
+	"fmt"
+)
+
+// errorCodeInfo is the spec name and the description of an error code. The code is only defined since
+// minVersion if it is not 0.
+type errorCodeInfo struct {
+	name        string
+	description string
+	minVersion  uint8
+}
+
+// errorTypeInfo is the spec name and the description of an error type, and its codes.
+type errorTypeInfo struct {
+	name        string
+	description string
+	codes       map[uint16]errorCodeInfo
+}
+
+var errorTypes = map[uint16]errorTypeInfo{
+	ET_HELLO_FAILED: {"OFPET_HELLO_FAILED", "hello protocol failed", map[uint16]errorCodeInfo{
+		HFC_INCOMPATIBLE: {"OFPHFC_INCOMPATIBLE", "no compatible version", 0},
+		HFC_EPERM:        {"OFPHFC_EPERM", "permissions error", 0},
+	}},
+	ET_BAD_REQUEST: {"OFPET_BAD_REQUEST", "request was not understood", map[uint16]errorCodeInfo{
+		BRC_BAD_VERSION:               {"OFPBRC_BAD_VERSION", "header version not supported", 0},
+		BRC_BAD_TYPE:                  {"OFPBRC_BAD_TYPE", "header type not supported", 0},
+		BRC_BAD_MULTIPART:             {"OFPBRC_BAD_MULTIPART", "multipart type not supported", 0},
+		BRC_BAD_EXPERIMENTER:          {"OFPBRC_BAD_EXPERIMENTER", "experimenter id not supported", 0},
+		BRC_BAD_EXP_TYPE:              {"OFPBRC_BAD_EXP_TYPE", "experimenter type not supported", 0},
+		BRC_EPERM:                     {"OFPBRC_EPERM", "permissions error", 0},
+		BRC_BAD_LEN:                   {"OFPBRC_BAD_LEN", "wrong request length for type", 0},
+		BRC_BUFFER_EMPTY:              {"OFPBRC_BUFFER_EMPTY", "specified buffer has already been used", 0},
+		BRC_BUFFER_UNKNOWN:            {"OFPBRC_BUFFER_UNKNOWN", "specified buffer does not exist", 0},
+		BRC_BAD_TABLE_ID:              {"OFPBRC_BAD_TABLE_ID", "specified table-id invalid or does not exist", 0},
+		BRC_IS_SLAVE:                  {"OFPBRC_IS_SLAVE", "denied because controller is slave", 0},
+		BRC_BAD_PORT:                  {"OFPBRC_BAD_PORT", "invalid port", 0},
+		BRC_BAD_PACKET:                {"OFPBRC_BAD_PACKET", "invalid packet in packet-out", 0},
+		BRC_MULTIPART_BUFFER_OVERFLOW: {"OFPBRC_MULTIPART_BUFFER_OVERFLOW", "multipart request overflowed the assigned buffer", 0},
+	}},
+	ET_BAD_ACTION: {"OFPET_BAD_ACTION", "error in action description", map[uint16]errorCodeInfo{
+		BAC_BAD_TYPE:           {"OFPBAC_BAD_TYPE", "unknown or unsupported action type", 0},
+		BAC_BAD_LEN:            {"OFPBAC_BAD_LEN", "length problem in actions", 0},
+		BAC_BAD_EXPERIMENTER:   {"OFPBAC_BAD_EXPERIMENTER", "unknown experimenter id specified", 0},
+		BAC_BAD_EXP_TYPE:       {"OFPBAC_BAD_EXP_TYPE", "unknown action for experimenter id", 0},
+		BAC_BAD_OUT_PORT:       {"OFPBAC_BAD_OUT_PORT", "problem validating output port", 0},
+		BAC_BAD_ARGUMENT:       {"OFPBAC_BAD_ARGUMENT", "bad action argument", 0},
+		BAC_EPERM:              {"OFPBAC_EPERM", "permissions error", 0},
+		BAC_TOO_MANY:           {"OFPBAC_TOO_MANY", "can't handle this many actions", 0},
+		BAC_BAD_QUEUE:          {"OFPBAC_BAD_QUEUE", "problem validating output queue", 0},
+		BAC_BAD_OUT_GROUP:      {"OFPBAC_BAD_OUT_GROUP", "invalid group id in forward action", 0},
+		BAC_MATCH_INCONSISTENT: {"OFPBAC_MATCH_INCONSISTENT", "action can't apply for this match, or set-field missing prerequisite", 0},
+		BAC_UNSUPPORTED_ORDER:  {"OFPBAC_UNSUPPORTED_ORDER", "action order is unsupported for the action list in an apply-actions instruction", 0},
+		BAC_BAD_TAG:            {"OFPBAC_BAD_TAG", "actions uses an unsupported tag/encap", 0},
+		BAC_BAD_SET_TYPE:       {"OFPBAC_BAD_SET_TYPE", "unsupported type in set-field action", 0},
+		BAC_BAD_SET_LEN:        {"OFPBAC_BAD_SET_LEN", "length problem in set-field action", 0},
+		BAC_BAD_SET_ARGUMENT:   {"OFPBAC_BAD_SET_ARGUMENT", "bad argument in set-field action", 0},
+	}},
+	ET_BAD_INSTRUCTION: {"OFPET_BAD_INSTRUCTION", "error in instruction list", map[uint16]errorCodeInfo{
+		BIC_UNKNOWN_INST:        {"OFPBIC_UNKNOWN_INST", "unknown instruction", 0},
+		BIC_UNSUP_INST:          {"OFPBIC_UNSUP_INST", "switch or table does not support the instruction", 0},
+		BIC_BAD_TABLE_ID:        {"OFPBIC_BAD_TABLE_ID", "invalid table-id specified", 0},
+		BIC_UNSUP_METADATA:      {"OFPBIC_UNSUP_METADATA", "metadata value unsupported by datapath", 0},
+		BIC_UNSUP_METADATA_MASK: {"OFPBIC_UNSUP_METADATA_MASK", "metadata mask value unsupported by datapath", 0},
+		BIC_BAD_EXPERIMENTER:    {"OFPBIC_BAD_EXPERIMENTER", "unknown experimenter id specified", 0},
+		BIC_BAD_EXP_TYPE:        {"OFPBIC_BAD_EXP_TYPE", "unknown instruction for experimenter id", 0},
+		BIC_BAD_LEN:             {"OFPBIC_BAD_LEN", "length problem in instructions", 0},
+		BIC_EPERM:               {"OFPBIC_EPERM", "permissions error", 0},
+	}},
+	PET_BAD_MATCH: {"OFPET_BAD_MATCH", "error in match", map[uint16]errorCodeInfo{
+		BMC_BAD_TYPE:         {"OFPBMC_BAD_TYPE", "unsupported match type specified by the match", 0},
+		BMC_BAD_LEN:          {"OFPBMC_BAD_LEN", "length problem in match", 0},
+		BMC_BAD_TAG:          {"OFPBMC_BAD_TAG", "match uses an unsupported tag/encap", 0},
+		BMC_BAD_DL_ADDR_MASK: {"OFPBMC_BAD_DL_ADDR_MASK", "unsupported datalink addr mask", 0},
+		BMC_BAD_NW_ADDR_MASK: {"OFPBMC_BAD_NW_ADDR_MASK", "unsupported network addr mask", 0},
+		BMC_BAD_WILDCARDS:    {"OFPBMC_BAD_WILDCARDS", "unsupported combination of fields masked or omitted in the match", 0},
+		BMC_BAD_FIELD:        {"OFPBMC_BAD_FIELD", "unsupported field type in the match", 0},
+		BMC_BAD_VALUE:        {"OFPBMC_BAD_VALUE", "unsupported value in a match field", 0},
+		BMC_BAD_MASK:         {"OFPBMC_BAD_MASK", "unsupported mask specified in the match", 0},
+		BMC_BAD_PREREQ:       {"OFPBMC_BAD_PREREQ", "a prerequisite was not met", 0},
+		BMC_DUP_FIELD:        {"OFPBMC_DUP_FIELD", "a field type was duplicated", 0},
+		BMC_EPERM:            {"OFPBMC_EPERM", "permissions error", 0},
+	}},
+	ET_FLOW_MOD_FAILED: {"OFPET_FLOW_MOD_FAILED", "problem modifying flow entry", map[uint16]errorCodeInfo{
+		FMFC_UNKNOWN:      {"OFPFMFC_UNKNOWN", "unspecified error", 0},
+		FMFC_TABLE_FULL:   {"OFPFMFC_TABLE_FULL", "flow not added because table was full", 0},
+		FMFC_BAD_TABLE_ID: {"OFPFMFC_BAD_TABLE_ID", "table does not exist", 0},
+		FMFC_OVERLAP:      {"OFPFMFC_OVERLAP", "attempted to add overlapping flow with CHECK_OVERLAP flag set", 0},
+		FMFC_EPERM:        {"OFPFMFC_EPERM", "permissions error", 0},
+		FMFC_BAD_TIMEOUT:  {"OFPFMFC_BAD_TIMEOUT", "flow not added because of unsupported idle/hard timeout", 0},
+		FMFC_BAD_COMMAND:  {"OFPFMFC_BAD_COMMAND", "unsupported or unknown command", 0},
+		FMFC_BAD_FLAGS:    {"OFPFMFC_BAD_FLAGS", "unsupported or unknown flags", 0},
+	}},
+	ET_GROUP_MOD_FAILED: {"OFPET_GROUP_MOD_FAILED", "problem modifying group entry", map[uint16]errorCodeInfo{
+		GMFC_GROUP_EXISTS:         {"OFPGMFC_GROUP_EXISTS", "group not added because the group already exists", 0},
+		GMFC_INVALID_GROUP:        {"OFPGMFC_INVALID_GROUP", "group not added because the group specified is invalid", 0},
+		GMFC_WEIGHT_UNSUPPORTED:   {"OFPGMFC_WEIGHT_UNSUPPORTED", "switch does not support unequal load sharing with select groups", 0},
+		GMFC_OUT_OF_GROUPS:        {"OFPGMFC_OUT_OF_GROUPS", "the group table is full", 0},
+		GMFC_OUT_OF_BUCKETS:       {"OFPGMFC_OUT_OF_BUCKETS", "the maximum number of action buckets for a group has been exceeded", 0},
+		GMFC_CHAINING_UNSUPPORTED: {"OFPGMFC_CHAINING_UNSUPPORTED", "switch does not support groups that forward to groups", 0},
+		GMFC_WATCH_UNSUPPORTED:    {"OFPGMFC_WATCH_UNSUPPORTED", "this group cannot watch the watch_port or watch_group specified", 0},
+		GMFC_LOOP:                 {"OFPGMFC_LOOP", "group entry would cause a loop", 0},
+		GMFC_UNKNOWN_GROUP:        {"OFPGMFC_UNKNOWN_GROUP", "group not modified because the group does not exist", 0},
+		GMFC_CHAINED_GROUP:        {"OFPGMFC_CHAINED_GROUP", "group not deleted because another group is forwarding to it", 0},
+		GMFC_BAD_TYPE:             {"OFPGMFC_BAD_TYPE", "unsupported or unknown group type", 0},
+		GMFC_BAD_COMMAND:          {"OFPGMFC_BAD_COMMAND", "unsupported or unknown command", 0},
+		GMFC_BAD_BUCKET:           {"OFPGMFC_BAD_BUCKET", "error in bucket", 0},
+		GMFC_BAD_WATCH:            {"OFPGMFC_BAD_WATCH", "error in watch port/group", 0},
+		GMFC_EPERM:                {"OFPGMFC_EPERM", "permissions error", 0},
+	}},
+	ET_PORT_MOD_FAILED: {"OFPET_PORT_MOD_FAILED", "port mod request failed", map[uint16]errorCodeInfo{
+		PMFC_BAD_PORT:      {"OFPPMFC_BAD_PORT", "specified port number does not exist", 0},
+		PMFC_BAD_HW_ADDR:   {"OFPPMFC_BAD_HW_ADDR", "specified hardware address does not match the port number", 0},
+		PMFC_BAD_CONFIG:    {"OFPPMFC_BAD_CONFIG", "specified config is invalid", 0},
+		PMFC_BAD_ADVERTISE: {"OFPPMFC_BAD_ADVERTISE", "specified advertise is invalid", 0},
+		PMFC_EPERM:         {"OFPPMFC_EPERM", "permissions error", 0},
+	}},
+	ET_TABLE_MOD_FAILED: {"OFPET_TABLE_MOD_FAILED", "table mod request failed", map[uint16]errorCodeInfo{
+		TMFC_BAD_TABLE:  {"OFPTMFC_BAD_TABLE", "specified table does not exist", 0},
+		TMFC_BAD_CONFIG: {"OFPTMFC_BAD_CONFIG", "specified config is invalid", 0},
+		TMFC_EPERM:      {"OFPTMFC_EPERM", "permissions error", 0},
+	}},
+	ET_QUEUE_OP_FAILED: {"OFPET_QUEUE_OP_FAILED", "queue operation failed", map[uint16]errorCodeInfo{
+		QOFC_BAD_PORT:  {"OFPQOFC_BAD_PORT", "invalid port or the port does not exist", 0},
+		QOFC_BAD_QUEUE: {"OFPQOFC_BAD_QUEUE", "queue does not exist", 0},
+		QOFC_EPERM:     {"OFPQOFC_EPERM", "permissions error", 0},
+	}},
+	ET_ROLE_REQUEST_FAILED: {"OFPET_ROLE_REQUEST_FAILED", "controller role request failed", map[uint16]errorCodeInfo{
+		RRFC_STALE:     {"OFPRRFC_STALE", "stale message: old generation_id", 0},
+		RRFC_UNSUP:     {"OFPRRFC_UNSUP", "controller role change unsupported", 0},
+		RRFC_BAD_ROLE:  {"OFPRRFC_BAD_ROLE", "invalid role", 0},
+		RRFC_ID_UNSUP:  {"OFPRRFC_ID_UNSUP", "switch doesn't support changing ID", OFP15_VERSION},
+		RRFC_ID_IN_USE: {"OFPRRFC_ID_IN_USE", "requested ID is in use", OFP15_VERSION},
+	}},
+	ET_METER_MOD_FAILED: {"OFPET_METER_MOD_FAILED", "error in meter", map[uint16]errorCodeInfo{
+		OFPMMFC_UNKNOWN:        {"OFPMMFC_UNKNOWN", "unspecified error", 0},
+		OFPMMFC_METER_EXISTS:   {"OFPMMFC_METER_EXISTS", "meter ADD attempted to replace an existing meter", 0},
+		OFPMMFC_INVALID_METER:  {"OFPMMFC_INVALID_METER", "meter specified is invalid", 0},
+		OFPMMFC_UNKNOWN_METER:  {"OFPMMFC_UNKNOWN_METER", "meter MODIFY of a non-existent meter, or bad meter in meter action", 0},
+		OFPMMFC_BAD_COMMAND:    {"OFPMMFC_BAD_COMMAND", "unsupported or unknown command", 0},
+		OFPMMFC_BAD_FLAGS:      {"OFPMMFC_BAD_FLAGS", "flag configuration unsupported", 0},
+		OFPMMFC_BAD_RATE:       {"OFPMMFC_BAD_RATE", "rate unsupported", 0},
+		OFPMMFC_BAD_BURST:      {"OFPMMFC_BAD_BURST", "burst size unsupported", 0},
+		OFPMMFC_BAD_BAND:       {"OFPMMFC_BAD_BAND", "band unsupported", 0},
+		OFPMMFC_BAD_BAND_VALUE: {"OFPMMFC_BAD_BAND_VALUE", "band value unsupported", 0},
+		OFPMMFC_OUT_OF_METERS:  {"OFPMMFC_OUT_OF_METERS", "no more meters available", 0},
+		OFPMMFC_OUT_OF_BANDS:   {"OFPMMFC_OUT_OF_BANDS", "the maximum number of properties for a meter has been exceeded", 0},
+	}},
+	ET_TABLE_FEATURES_FAILED: {"OFPET_TABLE_FEATURES_FAILED", "setting table features failed", nil},
+	ET_BAD_PROPERTY:          {"OFPET_BAD_PROPERTY", "some property is invalid", nil},
+	ET_ASYNC_CONFIG_FAILED:   {"OFPET_ASYNC_CONFIG_FAILED", "asynchronous config request failed", nil},
+	ET_FLOW_MONITOR_FAILED:   {"OFPET_FLOW_MONITOR_FAILED", "setting flow monitor failed", nil},
+	ET_BUNDLE_FAILED:         {"OFPET_BUNDLE_FAILED", "bundle operation failed", nil},
+	ET_EXPERIMENTER:          {"OFPET_EXPERIMENTER", "experimenter error", nil},
+}
+
+// errorTypeMinVersions is the version since which the error types of OpenFlow 1.4 are defined.
+var errorTypeMinVersions = map[uint16]uint8{
+	ET_BAD_PROPERTY:        VERSION + 1,
+	ET_ASYNC_CONFIG_FAILED: VERSION + 1,
+	ET_FLOW_MONITOR_FAILED: VERSION + 1,
+	ET_BUNDLE_FAILED:       VERSION + 1,
+}
+
+// bundleErrorName returns the spec name of the bundle error code of the ONF extension, i.e., BEC_*.
+func bundleErrorName(code uint16) string {
+	names := []string{"UNKNOWN", "EPERM", "BAD_ID", "BUNDLE_EXIST", "BUNDLE_CLOSED", "OUT_OF_BUNDLES", "BAD_TYPE",
+		"BAD_FLAGS", "MSG_BAD_LEN", "MSG_BAD_XID", "MSG_UNSUP", "MSG_CONFLICT", "MSG_TOO_MANY", "MSG_FAILED", "TIMEOUT",
+		"BUNDLE_IN_PROGRESS"}
+	if code < BEC_UNKNOWN || int(code-BEC_UNKNOWN) >= len(names) {
+		return ""
+	}
+	return "OFPBFC_" + names[code-BEC_UNKNOWN]
+}
+
+// ExplainError returns the spec name and the description of the error type and code of the OpenFlow version,
+// e.g., "OFPBRC_BAD_LEN" and "request was not understood: wrong request length for type". The name is the one of
+// the error type and the code number if the code is unknown, and the description is "unknown error" if the type
+// is unknown. The experimenter codes are explained by VendorError.Description.
+func ExplainError(version uint8, errType, code uint16) (name, description string) {
+	typeInfo, ok := errorTypes[errType]
+	if minVersion := errorTypeMinVersions[errType]; !ok || version < minVersion {
+		return fmt.Sprintf("OFPET_%d(%d)", errType, code), "unknown error"
+	}
+	if errType == ET_BUNDLE_FAILED {
+		// The codes of OFPET_BUNDLE_FAILED are the ones of the bundle extension of OpenFlow 1.3.
+		if err := ParseBundleError(code + BEC_UNKNOWN); err != nil {
+			return bundleErrorName(code + BEC_UNKNOWN), typeInfo.description + ": " + err.Error()
+		}
+	}
+	codeInfo, ok := typeInfo.codes[code]
+	if !ok || version < codeInfo.minVersion {
+		return fmt.Sprintf("%s(%d)", typeInfo.name, code), typeInfo.description
+	}
+	return codeInfo.name, typeInfo.description + ": " + codeInfo.description
+}
+
+// Description returns the spec name and the description of the error, e.g., "OFPBRC_BAD_LEN: request was not
+// understood: wrong request length for type".
+func (e *ErrorMsg) Description() string {
+	name, description := ExplainError(e.Header.Version, e.Type, e.Code)
+	return name + ": " + description
+}
+
+// Description returns the name and the description of the experimenter error, the Nicira extension errors and the
+// bundle errors of the ONF extension are known.
+func (e *VendorError) Description() string {
+	switch e.ExperimenterID {
+	case NxExperimenterID:
+		if name, description, ok := e.NXError(); ok {
+			return name + ": " + description
+		}
+	case ONF_EXPERIMENTER_ID:
+		if err := ParseBundleError(e.Code); err != nil {
+			return bundleErrorName(e.Code) + ": bundle operation failed: " + err.Error()
+		}
+	}
+	return fmt.Sprintf("OFPET_EXPERIMENTER(0x%08x, %d): experimenter error", e.ExperimenterID, e.Code)
+}
@@ -0,0 +1,45 @@
+package openflow13
+
+import (
+	"testing"
+)
+
+func TestExplainError(t *testing.T) {
+	for _, tc := range []struct {
+		version     uint8
+		errType     uint16
+		code        uint16
+		name        string
+		description string
+	}{
+		{VERSION, ET_BAD_REQUEST, BRC_BAD_LEN, "OFPBRC_BAD_LEN", "request was not understood: wrong request length for type"},
+		{VERSION, PET_BAD_MATCH, BMC_BAD_PREREQ, "OFPBMC_BAD_PREREQ", "error in match: a prerequisite was not met"},
+		{VERSION, ET_ROLE_REQUEST_FAILED, RRFC_ID_IN_USE, "OFPET_ROLE_REQUEST_FAILED(4)", "controller role request failed"},
+		{OFP15_VERSION, ET_ROLE_REQUEST_FAILED, RRFC_ID_IN_USE, "OFPRRFC_ID_IN_USE", "controller role request failed: requested ID is in use"},
+		{VERSION, ET_BUNDLE_FAILED, 14, "OFPET_17(14)", "unknown error"},
+		{OFP15_VERSION, ET_BUNDLE_FAILED, 14, "OFPBFC_TIMEOUT", "bundle operation failed: bundle is taking too long"},
+		{VERSION, 100, 1, "OFPET_100(1)", "unknown error"},
+	} {
+		name, description := ExplainError(tc.version, tc.errType, tc.code)
+		if name != tc.name || description != tc.description {
+			t.Errorf("Expect %s: %s for version %d type %d code %d, actual: %s: %s", tc.name, tc.description,
+				tc.version, tc.errType, tc.code, name, description)
+		}
+	}
+
+	errMsg := NewErrorMsg()
+	errMsg.Header = NewOfp13Header()
+	errMsg.Type = ET_FLOW_MOD_FAILED
+	errMsg.Code = FMFC_TABLE_FULL
+	if description := errMsg.Description(); description != "OFPFMFC_TABLE_FULL: problem modifying flow entry: flow not added because table was full" {
+		t.Errorf("Unexpected description %s", description)
+	}
+	if description := NewNXError(OFPERR_NXTTMFC_TABLE_FULL, nil).Description(); description != "OFPERR_NXTTMFC_TABLE_FULL: TLV table is full" {
+		t.Errorf("Unexpected description %s", description)
+	}
+	bundleErr := NewBundleError()
+	bundleErr.Code = BEC_BAD_ID
+	if description := bundleErr.Description(); description != "OFPBFC_BAD_ID: bundle operation failed: bundle ID doesn't exist" {
+		t.Errorf("Unexpected description %s", description)
+	}
+}
@@ -0,0 +1,82 @@
+package openflow13
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+// ExperimenterHandler handles a raw OFPT_EXPERIMENTER message, including the OpenFlow header. The data is only
+// valid during the call, as the buffer is reused by the stream, so it must be copied if it is retained. The
+// handler is called in the parser goroutines of the stream, so it must be fast and safe for concurrent use.
+type ExperimenterHandler func(data []byte)
+
+// DiscardExperimenter is an ExperimenterHandler which drops the messages, e.g., the vendor keepalives which the
+// controller has no use of.
+func DiscardExperimenter(data []byte) {}
+
+type experimenterKey struct {
+	vendor  uint32
+	expType uint32
+}
+
+// Parser is a util.Parser which parses the messages with ParseWithOptions and Options, and passes the experimenter
+// messages of the registered handlers to the handlers without decoding them. The handlers are registered per
+// Parser, e.g., per connection, so that the connections don't see the handlers of each other. A Parser is safe for
+// concurrent use, but Options must not be changed once it is in use.
+type Parser struct {
+	Options DecodeOptions
+
+	handlersLock sync.RWMutex
+	handlers     map[experimenterKey]ExperimenterHandler
+}
+
+// NewParser returns a Parser with the decode options.
+func NewParser(opts DecodeOptions) *Parser {
+	return &Parser{Options: opts, handlers: make(map[experimenterKey]ExperimenterHandler)}
+}
+
+// RegisterExperimenterHandler registers the handler of the experimenter messages of (vendor, expType). Parse
+// passes such messages to the handler without decoding them, and returns a *util.HandledMessage, so that the
+// chatty vendor keepalives or stats pushes are neither parsed nor logged as unknown vendor data. A nil handler
+// unregisters the handler.
+func (p *Parser) RegisterExperimenterHandler(vendor uint32, expType uint32, handler ExperimenterHandler) {
+	p.handlersLock.Lock()
+	defer p.handlersLock.Unlock()
+	key := experimenterKey{vendor: vendor, expType: expType}
+	if handler == nil {
+		delete(p.handlers, key)
+		return
+	}
+	if p.handlers == nil {
+		p.handlers = make(map[experimenterKey]ExperimenterHandler)
+	}
+	p.handlers[key] = handler
+}
+
+// Parse parses the message with ParseWithOptions, or returns a *util.HandledMessage of the message and no error if
+// the message is passed to a registered experimenter handler.
+func (p *Parser) Parse(b []byte) (message util.Message, err error) {
+	if len(b) >= 8 && b[1] == Type_Experimenter && p.handleExperimenter(b) {
+		return &util.HandledMessage{Data: b}, nil
+	}
+	return ParseWithOptions(b, p.Options)
+}
+
+// handleExperimenter calls the registered handler of the experimenter message, and returns false if there is
+// no handler.
+func (p *Parser) handleExperimenter(data []byte) bool {
+	if len(data) < 16 {
+		return false
+	}
+	key := experimenterKey{vendor: binary.BigEndian.Uint32(data[8:]), expType: binary.BigEndian.Uint32(data[12:])}
+	p.handlersLock.RLock()
+	handler := p.handlers[key]
+	p.handlersLock.RUnlock()
+	if handler == nil {
+		return false
+	}
+	handler(data)
+	return true
+}
@@ -0,0 +1,85 @@
+package openflow13
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+// ExperimenterMultipartHeader is the ofp_experimenter_multipart_header 1.3, it starts the body of the multipart
+// request and reply with MultipartType_Experimenter.
+type ExperimenterMultipartHeader struct {
+	Experimenter uint32 /* Experimenter ID which takes the same form as in struct ofp_experimenter_header. */
+	ExpType      uint32 /* Experimenter defined. */
+}
+
+func (h *ExperimenterMultipartHeader) Len() (n uint16) {
+	return 8
+}
+
+func (h *ExperimenterMultipartHeader) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, 8)
+	binary.BigEndian.PutUint32(data[0:], h.Experimenter)
+	binary.BigEndian.PutUint32(data[4:], h.ExpType)
+	return
+}
+
+func (h *ExperimenterMultipartHeader) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("the []byte is too short to unmarshal a full ExperimenterMultipartHeader message")
+	}
+	h.Experimenter = binary.BigEndian.Uint32(data[0:])
+	h.ExpType = binary.BigEndian.Uint32(data[4:])
+	return nil
+}
+
+// ExperimenterMultipartFactory returns an empty body of a vendor multipart reply if reply is set, or of a request
+// otherwise, as the most of the vendors use the same type for both. The body is decoded with UnmarshalBinary from
+// the data starting with the ExperimenterMultipartHeader, and its Len is the number of bytes it consumes.
+type ExperimenterMultipartFactory func(reply bool) util.Message
+
+type experimenterMultipartKey struct {
+	experimenter uint32
+	expType      uint32
+}
+
+var (
+	experimenterMultipartFactories     = make(map[experimenterMultipartKey]ExperimenterMultipartFactory)
+	experimenterMultipartFactoriesLock sync.RWMutex
+)
+
+// RegisterExperimenterMultipartDecoder registers the factory of the bodies of the experimenter multipart requests
+// and replies with the experimenter ID and the type, so that MultipartRequest and MultipartReply decode them into
+// typed bodies, e.g., the NX flow monitor updates. The bodies without a registered factory are decoded as
+// ExperimenterStatsBody. A nil factory unregisters the factory.
+func RegisterExperimenterMultipartDecoder(experimenterID uint32, expType uint32, factory ExperimenterMultipartFactory) {
+	experimenterMultipartFactoriesLock.Lock()
+	defer experimenterMultipartFactoriesLock.Unlock()
+	key := experimenterMultipartKey{experimenter: experimenterID, expType: expType}
+	if factory == nil {
+		delete(experimenterMultipartFactories, key)
+		return
+	}
+	experimenterMultipartFactories[key] = factory
+}
+
+// newExperimenterMultipartBody returns an empty body of the experimenter multipart data with the registered
+// factory, or an ExperimenterStatsBody if there is none.
+func newExperimenterMultipartBody(data []byte, reply bool) util.Message {
+	if len(data) < 8 {
+		return new(ExperimenterStatsBody)
+	}
+	key := experimenterMultipartKey{experimenter: binary.BigEndian.Uint32(data[0:]), expType: binary.BigEndian.Uint32(data[4:])}
+	experimenterMultipartFactoriesLock.RLock()
+	factory := experimenterMultipartFactories[key]
+	experimenterMultipartFactoriesLock.RUnlock()
+	if factory == nil {
+		return new(ExperimenterStatsBody)
+	}
+	if body := factory(reply); body != nil {
+		return body
+	}
+	return new(ExperimenterStatsBody)
+}
@@ -0,0 +1,109 @@
+package openflow13
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// featureMatrix is the curated list of the features, the OpenFlow version introducing them, and the exported
+// identifiers implementing them. This package implements OpenFlow 1.3 and the OpenFlow 1.4 and 1.5 features used
+// with OVS, so all of them must exist here. Package ../openflow14 defines the messages whose layout is changed in
+// OpenFlow 1.4 and shares the others with this package, openflow14 lists its identifiers of the feature.
+var featureMatrix = []struct {
+	feature     string
+	version     uint8
+	identifiers []string
+	openflow14  []string
+}{
+	{"flow mod", VERSION, []string{"FlowMod", "NewFlowMod"}, []string{"FlowMod", "NewFlowMod"}},
+	{"group mod", VERSION, []string{"GroupMod", "NewGroupMod", "Bucket", "NewBucket"}, []string{"NewGroupMod"}},
+	{"meter mod", VERSION, []string{"MeterMod", "NewMeterMod", "MeterBandDrop", "MeterBandDSCP"}, nil},
+	{"meter multipart", VERSION, []string{"MeterStats", "MeterDesc", "MeterMultipartRequest"}, nil},
+	{"multipart", VERSION, []string{"MultipartRequest", "MultipartReply"}, []string{"NewMultipartRequest", "MultipartReply"}},
+	{"packet in", VERSION, []string{"PacketIn", "NewPacketIn"}, nil},
+	{"packet out", VERSION, []string{"PacketOut", "NewPacketOut"}, nil},
+	{"port", VERSION, []string{"PhyPort", "PortMod", "PortStatus"}, []string{"PortStatus", "NewPortStatus"}},
+	{"port and queue stats", VERSION, []string{"PortStats", "QueueStats"}, []string{"PortStats", "PortStatsPropEthernet",
+		"QueueStats"}},
+	{"queue config", VERSION, []string{"QueueGetConfigRequest", "QueueGetConfigReply", "PacketQueue", "QueuePropRate"}, nil},
+	{"flow stats", VERSION, []string{"FlowStats", "FlowStatsRequest"}, nil},
+	{"role", VERSION, []string{"RoleRequest", "NewRoleRequest"}, nil},
+	{"output action", VERSION, []string{"ActionOutput", "NewActionOutput"}, nil},
+	{"set field action", VERSION, []string{"ActionSetField", "NewActionSetField"}, nil},
+	{"instructions", VERSION, []string{"InstrActions", "InstrGotoTable", "InstrWriteMetadata", "InstrMeter"}, nil},
+	{"bundle", OFP14_VERSION, []string{"BundleControl", "BundleAdd"}, []string{"BundleControl", "BundleAdd"}},
+	{"flow monitor", OFP14_VERSION, []string{"FlowMonitorRequest", "FlowUpdateFull", "MonitorSession"}, nil},
+	{"port desc properties", OFP14_VERSION, []string{"Port15", "PortDescPropEthernet", "PortDescPropOptical",
+		"PortDescPropRecirculate", "PortDescPropExperimenter"}, nil},
+	{"port mod properties", OFP14_VERSION, []string{"PortModPropEthernet", "PortModPropOptical"}, nil},
+	{"copy field action", OFP15_VERSION, []string{"ActionCopyField", "NewActionCopyField"}, nil},
+	{"controller status", OFP15_VERSION, []string{"ControllerStatusMsg", "ControllerStatusPropUri"}, nil},
+	{"OXS stats", OFP15_VERSION, []string{"Stats", "StatField", "RegisterOXSField", "FindStatFieldHeaderByName"}, nil},
+	{"flow desc", OFP15_VERSION, []string{"FlowDesc", "FlowStats15", "MultipartType_FlowDesc", "MultipartType_FlowStats"}, nil},
+	{"group bucket properties", OFP15_VERSION, []string{"Bucket15", "GroupDesc15"}, nil},
+}
+
+// exportedIdentifiers returns the kinds of the exported top level identifiers of the package in dir, excluding
+// the tests.
+func exportedIdentifiers(t *testing.T, dir string) map[string]ast.ObjKind {
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", dir, err)
+	}
+	identifiers := make(map[string]ast.ObjKind)
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Files {
+			for name, obj := range f.Scope.Objects {
+				if ast.IsExported(name) && obj.Kind != ast.Bad {
+					identifiers[name] = obj.Kind
+				}
+			}
+		}
+	}
+	return identifiers
+}
+
+func TestFeatureMatrix(t *testing.T) {
+	identifiers := exportedIdentifiers(t, ".")
+	identifiers14 := exportedIdentifiers(t, filepath.Join("..", "openflow14"))
+	if len(identifiers14) == 0 {
+		t.Fatalf("There is no openflow14 package to cross-check")
+	}
+	listed14 := make(map[string]bool)
+	for _, f := range featureMatrix {
+		if f.version != VERSION && f.version != OFP14_VERSION && f.version != OFP15_VERSION {
+			t.Errorf("Feature %q has unknown OpenFlow version 0x%x", f.feature, f.version)
+		}
+		for _, id := range f.identifiers {
+			if _, ok := identifiers[id]; !ok {
+				t.Errorf("Feature %q of OpenFlow version 0x%x is missing %s", f.feature, f.version, id)
+			}
+			// A feature implemented by openflow14 is introduced by OpenFlow 1.4 at the latest.
+			if _, ok := identifiers14[id]; ok && f.version > OFP14_VERSION {
+				t.Errorf("Feature %q of OpenFlow version 0x%x has %s in openflow14", f.feature, f.version, id)
+			}
+		}
+		if len(f.openflow14) > 0 && f.version > OFP14_VERSION {
+			t.Errorf("Feature %q of OpenFlow version 0x%x has identifiers in openflow14", f.feature, f.version)
+		}
+		for _, id := range f.openflow14 {
+			if _, ok := identifiers14[id]; !ok {
+				t.Errorf("Feature %q of OpenFlow version 0x%x is missing %s in openflow14", f.feature, f.version, id)
+			}
+			listed14[id] = true
+		}
+	}
+	// The messages defined by openflow14 must be in the matrix.
+	for id, kind := range identifiers14 {
+		if kind == ast.Typ && !listed14[id] {
+			t.Errorf("Type %s of openflow14 is not in the feature matrix", id)
+		}
+	}
+}
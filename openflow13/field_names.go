@@ -0,0 +1,38 @@
+package openflow13
+
+import (
+	"fmt"
+)
+
+// FieldNameToHeader returns the header of the match field of the OVS name, e.g., reg0, ct_state, tun_metadata3
+// or xxreg1. The alternative names, e.g., eth_src of dl_src, the OXM/NXM names, e.g., NXM_NX_REG0, and the names
+// of the fields registered by RegisterOXMField are accepted as well. The OXM field is returned if OVS has both
+// the OXM and the NXM field of the name, e.g., OXM_OF_IN_PORT of in_port. tp_src and tp_dst are not accepted as
+// they depend on the IP protocol. The header has no mask, and a new one is returned for each call.
+func FieldNameToHeader(name string) (*MatchField, error) {
+	oxxName, err := flowFieldOXXName(name, -1)
+	if err != nil {
+		if info := lookupOXMFieldByName(name); info != nil {
+			return info.header(false), nil
+		}
+		return nil, err
+	}
+	return FindFieldHeaderByName(oxxName, false)
+}
+
+// HeaderToFieldName returns the OVS name of the field of the header, e.g., reg0 of NXM_NX_REG0, which is the
+// name used by MatchField.String. The registered fields have their registered names in upper case, and take
+// precedence over the known fields of the same class and field. An error is returned if the field is unknown.
+func HeaderToFieldName(header *MatchField) (string, error) {
+	if info := lookupOXMField(header.Class, header.Field, header.ExperimenterID); info != nil {
+		return info.name, nil
+	}
+	key := oxxFieldKey{header.Class, header.Field, header.ExperimenterID}
+	if oxxName, ok := oxxFieldNames[key]; ok {
+		if format, ok := flowFieldFormats[oxxName]; ok {
+			return format.name, nil
+		}
+		return oxxName, nil
+	}
+	return "", fmt.Errorf("unknown field %d in class 0x%x", header.Field, header.Class)
+}
@@ -0,0 +1,60 @@
+package openflow13
+
+import (
+	"testing"
+)
+
+func TestFieldNames(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		class    uint16
+		field    uint8
+		length   uint8
+		expected string
+	}{
+		{"reg0", OXM_CLASS_NXM_1, NXM_NX_REG0, 4, "reg0"},
+		{"ct_state", OXM_CLASS_NXM_1, NXM_NX_CT_STATE, 4, "ct_state"},
+		{"tun_metadata3", OXM_CLASS_NXM_1, NXM_NX_TUN_METADATA3, 128, "tun_metadata3"},
+		{"tun_metadata63", OXM_CLASS_NXM_1, NXM_NX_TUN_METADATA0 + 63, 128, "tun_metadata63"},
+		{"xxreg1", OXM_CLASS_NXM_1, NXM_NX_XXREG1, 16, "xxreg1"},
+		{"in_port", OXM_CLASS_OPENFLOW_BASIC, OXM_FIELD_IN_PORT, 4, "in_port"},
+		{"eth_src", OXM_CLASS_OPENFLOW_BASIC, OXM_FIELD_ETH_SRC, 6, "dl_src"},
+		{"NXM_OF_IP_SRC", OXM_CLASS_NXM_0, NXM_OF_IP_SRC, 4, "nw_src"},
+	} {
+		header, err := FieldNameToHeader(tc.name)
+		if err != nil {
+			t.Errorf("Failed to find the header of %s: %v", tc.name, err)
+			continue
+		}
+		if header.Class != tc.class || header.Field != tc.field || header.Length != tc.length || header.HasMask {
+			t.Errorf("Unexpected header of %s: %+v", tc.name, header)
+		}
+		if name, err := HeaderToFieldName(header); err != nil || name != tc.expected {
+			t.Errorf("Expect name %s of %s, actual: %s, %v", tc.expected, tc.name, name, err)
+		}
+	}
+
+	for _, name := range []string{"tp_src", "tun_metadata64", "unknown"} {
+		if _, err := FieldNameToHeader(name); err == nil {
+			t.Errorf("Expect error for the field %s", name)
+		}
+	}
+	if _, err := HeaderToFieldName(&MatchField{Class: OXM_CLASS_NXM_1, Field: 127}); err == nil {
+		t.Errorf("Expect error for an unknown field")
+	}
+}
+
+func TestHeaderToRegisteredFieldName(t *testing.T) {
+	header, _ := FieldNameToHeader("reg0")
+	RegisterOXMField(header.Class, header.Field, 4, "TEST_REG0", newUint32)
+	defer RegisterOXMField(header.Class, header.Field, 4, "", nil)
+	// The registered field takes precedence over the known field.
+	if name, err := HeaderToFieldName(header); err != nil || name != "TEST_REG0" {
+		t.Errorf("Expect the registered name TEST_REG0, actual: %s, %v", name, err)
+	}
+
+	RegisterOXMField(header.Class, header.Field, 4, "", nil)
+	if name, err := HeaderToFieldName(header); err != nil || name != "reg0" {
+		t.Errorf("Expect the known name reg0 after the field is unregistered, actual: %s, %v", name, err)
+	}
+}
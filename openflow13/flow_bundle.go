@@ -0,0 +1,112 @@
+package openflow13
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/contiv/libOpenflow/common"
+	"github.com/contiv/libOpenflow/util"
+)
+
+var (
+	ErrBundleMessageUnsupported = errors.New("unsupported message in this bundle")
+	ErrBundleMessageXid         = errors.New("inconsistent or duplicate XID")
+)
+
+// IsBundlable returns whether the messages of msgType could be added to a bundle. Only the modification
+// messages are supported by the switches, i.e., FlowMod and PortMod required by OpenFlow 1.4, and GroupMod
+// and PacketOut supported by OVS. The other messages are rejected with BEC_MSG_UNSUP.
+func IsBundlable(msgType uint8) bool {
+	switch msgType {
+	case Type_FlowMod, Type_GroupMod, Type_PortMod, Type_PacketOut:
+		return true
+	}
+	return false
+}
+
+// FlowBundle builds the messages to realize a list of FlowMods and the other bundlable messages atomically in
+// a bundle, e.g., NewFlowBundle(id).Add(flowMod1, flowMod2).Messages(). The control messages and the BundleAdd
+// messages share the flags of the bundle, and each BundleAdd message has the xid of its inner message as
+// required by OpenFlow 1.4.
+type FlowBundle struct {
+	ID    uint32
+	Flags uint16
+	msgs  []util.Message
+	err   error
+}
+
+// NewFlowBundle returns an atomic bundle with the ID, e.g., an ID returned by NextBundleID.
+func NewFlowBundle(bundleID uint32) *FlowBundle {
+	return &FlowBundle{ID: bundleID, Flags: OFPBCT_ATOMIC}
+}
+
+// WithFlags sets the flags of the bundle, i.e., OFPBCT_ATOMIC and OFPBCT_ORDERED.
+func (b *FlowBundle) WithFlags(flags uint16) *FlowBundle {
+	b.Flags = flags
+	return b
+}
+
+// Add adds the messages to the bundle. The first message which could not be bundled is reported by Messages.
+func (b *FlowBundle) Add(msgs ...util.Message) *FlowBundle {
+	for _, msg := range msgs {
+		if b.err != nil {
+			return b
+		}
+		if msgType, ok := bundleMessageType(msg); !ok || !IsBundlable(msgType) {
+			b.err = fmt.Errorf("%w: message %d of type %T", ErrBundleMessageUnsupported, len(b.msgs), msg)
+			return b
+		}
+		b.msgs = append(b.msgs, msg)
+	}
+	return b
+}
+
+// Len returns the number of the messages added to the bundle.
+func (b *FlowBundle) Len() int {
+	return len(b.msgs)
+}
+
+// Messages returns the messages of the bundle, i.e., the open request, a BundleAdd message for each of the
+// added messages, and the commit request. The inner messages with xid 0 are allocated an xid by
+// common.NextXid, an error is returned if two inner messages have the same xid, or if an added message could
+// not be bundled.
+func (b *FlowBundle) Messages() ([]util.Message, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	bundleMsgs := make([]util.Message, 0, len(b.msgs)+2)
+	bundleMsgs = append(bundleMsgs, NewBundleControl(&BundleControl{BundleID: b.ID, Type: OFPBCT_OPEN_REQUEST, Flags: b.Flags}))
+	xids := make(map[uint32]bool, len(b.msgs))
+	for i, msg := range b.msgs {
+		h := messageHeader(msg)
+		if h == nil {
+			return nil, fmt.Errorf("%w: message %d of type %T", ErrBundleMessageUnsupported, i, msg)
+		}
+		if h.Xid == 0 {
+			h.Xid = common.NextXid()
+		}
+		if xids[h.Xid] {
+			return nil, fmt.Errorf("%w: message %d has xid %d", ErrBundleMessageXid, i, h.Xid)
+		}
+		xids[h.Xid] = true
+		add := NewBundleAdd(&BundleAdd{BundleID: b.ID, Flags: b.Flags, Message: msg})
+		add.Header.Xid = h.Xid
+		bundleMsgs = append(bundleMsgs, add)
+	}
+	bundleMsgs = append(bundleMsgs, NewBundleControl(&BundleControl{BundleID: b.ID, Type: OFPBCT_COMMIT_REQUEST, Flags: b.Flags}))
+	return bundleMsgs, nil
+}
+
+// DiscardMessage returns the discard request of the bundle, which drops the bundle if it is not committed,
+// e.g., after a message of the bundle failed.
+func (b *FlowBundle) DiscardMessage() util.Message {
+	return NewBundleControl(&BundleControl{BundleID: b.ID, Type: OFPBCT_DISCARD_REQUEST, Flags: b.Flags})
+}
+
+// bundleMessageType returns the OpenFlow message type of msg, and false if the message has no known header.
+func bundleMessageType(msg util.Message) (uint8, bool) {
+	if h := messageHeader(msg); h != nil {
+		return h.Type, true
+	}
+	return 0, false
+}
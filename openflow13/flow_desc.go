@@ -0,0 +1,238 @@
+package openflow13
+
+// This file has the OpenFlow 1.5 flow stats and flow desc, which carry the flow statistics as OXS fields. The
+// Match and Stats are named fields rather than embedded, as both of them have Len, MarshalBinary and
+// UnmarshalBinary which would be ambiguous or silently promoted with embedding.
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// MultipartType_FlowDesc is the OFPMP_FLOW_DESC of OpenFlow 1.5, which takes the value of OFPMP_FLOW of OpenFlow
+// 1.3. The request body is struct ofp_flow_stats_request, the reply body is an array of struct ofp_flow_desc.
+const MultipartType_FlowDesc = MultipartType_Flow
+
+// MultipartType_FlowStats is the OFPMP_FLOW_STATS of OpenFlow 1.5. The request body is struct
+// ofp_flow_stats_request, the reply body is an array of struct ofp_flow_stats.
+const MultipartType_FlowStats = 17
+
+// ofp_flow_stats_reason 1.5
+const (
+	OFPFSR_STATS_REQUEST = 0 /* Reply to a OFPMP_FLOW_STATS request. */
+	OFPFSR_STAT_TRIGGER  = 1 /* Status generated by OFPIT_STAT_TRIGGER. */
+)
+
+// FlowStats15 is the ofp_flow_stats of OpenFlow 1.5, the body of the OFPMP_FLOW_STATS reply.
+type FlowStats15 struct {
+	Length   uint16
+	pad      [2]byte
+	TableId  uint8
+	Reason   uint8 /* One of OFPFSR_*. */
+	Priority uint16
+	Match    Match
+	Stats    Stats
+}
+
+func NewFlowStats15() *FlowStats15 {
+	s := new(FlowStats15)
+	s.Match = *NewMatch()
+	s.Length = s.Len()
+	return s
+}
+
+func (s *FlowStats15) Len() uint16 {
+	return 8 + s.Match.Len() + s.Stats.Len()
+}
+
+func (s *FlowStats15) MarshalBinary() (data []byte, err error) {
+	s.Length = s.Len()
+	data = make([]byte, 8)
+	n := 0
+	binary.BigEndian.PutUint16(data[n:], s.Length)
+	n += 2
+	n += 2 // for pad
+	data[n] = s.TableId
+	n += 1
+	data[n] = s.Reason
+	n += 1
+	binary.BigEndian.PutUint16(data[n:], s.Priority)
+	n += 2
+
+	b, err := s.Match.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, b...)
+	b, err = s.Stats.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, b...)
+	return
+}
+
+func (s *FlowStats15) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("the []byte is too short to unmarshal a full FlowStats15 message")
+	}
+	n := 0
+	s.Length = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	if int(s.Length) > len(data) || s.Length < 8 {
+		return errors.New("the []byte is too short to unmarshal a full FlowStats15 message")
+	}
+	n += 2 // for pad
+	s.TableId = data[n]
+	n += 1
+	s.Reason = data[n]
+	n += 1
+	s.Priority = binary.BigEndian.Uint16(data[n:])
+	n += 2
+
+	s.Match = Match{}
+	if err := s.Match.UnmarshalBinary(data[n:s.Length]); err != nil {
+		return decodeErrorAt(err, "FlowStats15", n, "match")
+	}
+	n += int(s.Match.Len())
+	if err := checkBounds(data[:s.Length], n, 0, "FlowStats15", "stats"); err != nil {
+		return err
+	}
+	if err := s.Stats.UnmarshalBinary(data[n:s.Length]); err != nil {
+		return decodeErrorAt(err, "FlowStats15", n, "stats")
+	}
+	return nil
+}
+
+// FlowDesc is the ofp_flow_desc of OpenFlow 1.5, the body of the OFPMP_FLOW_DESC reply.
+type FlowDesc struct {
+	Length       uint16
+	pad          [2]byte
+	TableId      uint8
+	pad2         uint8
+	Priority     uint16
+	IdleTimeout  uint16
+	HardTimeout  uint16
+	Flags        uint16
+	Importance   uint16
+	Cookie       uint64
+	Match        Match
+	Stats        Stats
+	Instructions []Instruction
+}
+
+func NewFlowDesc() *FlowDesc {
+	d := new(FlowDesc)
+	d.Match = *NewMatch()
+	d.Length = d.Len()
+	return d
+}
+
+func (d *FlowDesc) AddInstruction(instr Instruction) {
+	d.Instructions = append(d.Instructions, instr)
+}
+
+func (d *FlowDesc) Len() uint16 {
+	n := 24 + d.Match.Len() + d.Stats.Len()
+	for _, instr := range d.Instructions {
+		n += instr.Len()
+	}
+	return n
+}
+
+func (d *FlowDesc) MarshalBinary() (data []byte, err error) {
+	d.Length = d.Len()
+	data = make([]byte, 24)
+	n := 0
+	binary.BigEndian.PutUint16(data[n:], d.Length)
+	n += 2
+	n += 2 // for pad
+	data[n] = d.TableId
+	n += 1
+	n += 1 // for pad2
+	binary.BigEndian.PutUint16(data[n:], d.Priority)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], d.IdleTimeout)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], d.HardTimeout)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], d.Flags)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], d.Importance)
+	n += 2
+	binary.BigEndian.PutUint64(data[n:], d.Cookie)
+	n += 8
+
+	b, err := d.Match.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, b...)
+	b, err = d.Stats.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, b...)
+	for _, instr := range d.Instructions {
+		b, err = instr.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return
+}
+
+func (d *FlowDesc) UnmarshalBinary(data []byte) error {
+	if len(data) < 24 {
+		return errors.New("the []byte is too short to unmarshal a full FlowDesc message")
+	}
+	n := 0
+	d.Length = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	if int(d.Length) > len(data) || d.Length < 24 {
+		return errors.New("the []byte is too short to unmarshal a full FlowDesc message")
+	}
+	n += 2 // for pad
+	d.TableId = data[n]
+	n += 1
+	n += 1 // for pad2
+	d.Priority = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	d.IdleTimeout = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	d.HardTimeout = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	d.Flags = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	d.Importance = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	d.Cookie = binary.BigEndian.Uint64(data[n:])
+	n += 8
+
+	d.Match = Match{}
+	if err := d.Match.UnmarshalBinary(data[n:d.Length]); err != nil {
+		return decodeErrorAt(err, "FlowDesc", n, "match")
+	}
+	n += int(d.Match.Len())
+	if err := checkBounds(data[:d.Length], n, 0, "FlowDesc", "stats"); err != nil {
+		return err
+	}
+	if err := d.Stats.UnmarshalBinary(data[n:d.Length]); err != nil {
+		return decodeErrorAt(err, "FlowDesc", n, "stats")
+	}
+	n += int(d.Stats.Len())
+
+	d.Instructions = nil
+	for n < int(d.Length) {
+		instr, err := decodeInstr(data[n:d.Length])
+		if err != nil {
+			return decodeErrorAt(err, "FlowDesc", n, "instruction")
+		}
+		d.Instructions = append(d.Instructions, instr)
+		if n, err = safeAdvance(n, instr.Len(), int(d.Length), "instruction"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
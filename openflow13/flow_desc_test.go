@@ -0,0 +1,126 @@
+package openflow13
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+func TestFlowDesc(t *testing.T) {
+	desc := NewFlowDesc()
+	desc.TableId = 3
+	desc.Priority = 100
+	desc.IdleTimeout = 10
+	desc.Importance = 5
+	desc.Cookie = 0x1234
+	desc.Match.AddField(*NewEthTypeField(0x0800))
+	desc.Stats.AddField(*NewDurationStatField(10, 20))
+	desc.Stats.AddField(*NewPacketCountStatField(100))
+	instr := NewInstrApplyActions()
+	instr.AddAction(NewActionOutput(P_NORMAL), false)
+	desc.AddInstruction(instr)
+
+	data, err := desc.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal FlowDesc: %v", err)
+	}
+	if len(data) != int(desc.Len()) || len(data)%8 != 0 {
+		t.Errorf("Unexpected FlowDesc length %d, Len(): %d", len(data), desc.Len())
+	}
+	desc2 := new(FlowDesc)
+	if err = desc2.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal FlowDesc: %v", err)
+	}
+	if desc2.TableId != 3 || desc2.Priority != 100 || desc2.IdleTimeout != 10 || desc2.Importance != 5 || desc2.Cookie != 0x1234 {
+		t.Errorf("Unexpected FlowDesc: %+v", desc2)
+	}
+	if len(desc2.Match.Fields) != 1 || len(desc2.Stats.Fields) != 2 || len(desc2.Instructions) != 1 {
+		t.Errorf("Unexpected FlowDesc match, stats or instructions: %+v", desc2)
+	}
+
+	stats := NewFlowStats15()
+	stats.Reason = OFPFSR_STAT_TRIGGER
+	stats.Priority = 100
+	stats.Stats.AddField(*NewByteCountStatField(1000))
+	data, err = stats.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal FlowStats15: %v", err)
+	}
+	stats2 := new(FlowStats15)
+	if err = stats2.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal FlowStats15: %v", err)
+	}
+	if stats2.Reason != OFPFSR_STAT_TRIGGER || stats2.Priority != 100 || len(stats2.Stats.Fields) != 1 {
+		t.Errorf("Unexpected FlowStats15: %+v", stats2)
+	}
+	if stats2.Stats.Fields[0].Value.(*Uint64Message).Data != 1000 {
+		t.Errorf("Unexpected byte count: %+v", stats2.Stats.Fields[0])
+	}
+}
+
+// TestFlowStatsNoEmbeddedMessage checks that the flow stats don't embed a util.Message, of which the Len or the
+// MarshalBinary would be promoted silently when the outer method is missing or misspelled.
+func TestFlowStatsNoEmbeddedMessage(t *testing.T) {
+	msgType := reflect.TypeOf((*util.Message)(nil)).Elem()
+	for _, v := range []interface{}{FlowStats{}, FlowStats15{}, FlowDesc{}} {
+		typ := reflect.TypeOf(v)
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			if f.Anonymous && (f.Type.Implements(msgType) || reflect.PtrTo(f.Type).Implements(msgType)) {
+				t.Errorf("%s embeds %s, use a named field instead", typ.Name(), f.Type)
+			}
+		}
+	}
+}
+
+func TestFlowDescMultipartReply(t *testing.T) {
+	desc := NewFlowDesc()
+	desc.Priority = 100
+	desc.Stats.AddField(*NewPacketCountStatField(10))
+	instr := NewInstrApplyActions()
+	instr.AddAction(NewActionOutput(1), false)
+	desc.AddInstruction(instr)
+	stats := NewFlowStats15()
+	stats.Stats.AddField(*NewByteCountStatField(1000))
+
+	for _, tc := range []struct {
+		mpType uint16
+		body   util.Message
+	}{
+		{MultipartType_FlowDesc, desc},
+		{MultipartType_FlowStats, stats},
+	} {
+		reply := newMultipartReply(1, tc.mpType, 0, tc.body, tc.body)
+		reply.Header.Version = OFP15_VERSION
+		data, err := reply.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to marshal MultipartReply: %v", err)
+		}
+		msg, err := Parse(data)
+		if err != nil {
+			t.Fatalf("Failed to parse MultipartReply of type %d: %v", tc.mpType, err)
+		}
+		decoded := msg.(*MultipartReply)
+		if len(decoded.Body) != 2 || reflect.TypeOf(decoded.Body[1]) != reflect.TypeOf(tc.body) {
+			t.Fatalf("Expect 2 bodies of %T, actual: %+v", tc.body, decoded.Body)
+		}
+		if b, _ := decoded.Body[1].MarshalBinary(); !bytes.Equal(b, data[len(data)-len(b):]) {
+			t.Errorf("Unexpected body of type %d: %+v", tc.mpType, decoded.Body[1])
+		}
+
+		jsonData, err := json.Marshal(decoded)
+		if err != nil {
+			t.Fatalf("Failed to marshal MultipartReply in JSON: %v", err)
+		}
+		fromJSON := new(MultipartReply)
+		if err = json.Unmarshal(jsonData, fromJSON); err != nil {
+			t.Fatalf("Failed to unmarshal MultipartReply of type %d from JSON: %v", tc.mpType, err)
+		}
+		if reflect.TypeOf(fromJSON.Body[0]) != reflect.TypeOf(tc.body) {
+			t.Errorf("Expect %T from JSON, actual: %T", tc.body, fromJSON.Body[0])
+		}
+	}
+}
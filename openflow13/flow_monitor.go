@@ -0,0 +1,656 @@
+package openflow13
+
+// This file has the OpenFlow 1.4+ flow monitor multipart messages, and MonitorSession which manages the
+// lifecycle of a flow monitor on a Conn.
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/contiv/libOpenflow/common"
+	"github.com/contiv/libOpenflow/util"
+)
+
+// MultipartType_FlowMonitor is the OFPMP_FLOW_MONITOR of OpenFlow 1.4 and later. The request body is
+// struct ofp_flow_monitor_request, the reply body is an array of struct ofp_flow_update_header.
+const MultipartType_FlowMonitor = 16
+
+// OFP14_VERSION is the wire version of OpenFlow 1.4, which introduced the flow monitor.
+const OFP14_VERSION = 5
+
+// ofp_flow_monitor_command 1.4
+const (
+	OFPFMC_ADD    = 0 /* New flow monitor. */
+	OFPFMC_MODIFY = 1 /* Modify existing flow monitor. */
+	OFPFMC_DELETE = 2 /* Delete/cancel existing flow monitor. */
+)
+
+// ofp_flow_monitor_flags 1.4
+const (
+	OFPFMF_INITIAL      = 1 << 0 /* Initially matching flows. */
+	OFPFMF_ADD          = 1 << 1 /* New matching flows as they are added. */
+	OFPFMF_REMOVED      = 1 << 2 /* Old matching flows as they are removed. */
+	OFPFMF_MODIFY       = 1 << 3 /* Matching flows as they are changed. */
+	OFPFMF_INSTRUCTIONS = 1 << 4 /* If set, instructions are included. */
+	OFPFMF_NO_ABBREV    = 1 << 5 /* If set, include own changes in full. */
+	OFPFMF_ONLY_OWN     = 1 << 6 /* If set, don't include other controllers. */
+)
+
+// ofp_flow_update_event 1.4
+const (
+	OFPFME_INITIAL  = 0 /* Flow present when flow monitor created. */
+	OFPFME_ADDED    = 1 /* Flow was added. */
+	OFPFME_REMOVED  = 2 /* Flow was removed. */
+	OFPFME_MODIFIED = 3 /* Flow instructions were changed. */
+	OFPFME_ABBREV   = 4 /* Abbreviated reply. */
+	OFPFME_PAUSED   = 5 /* Monitoring paused (out of buffer space). */
+	OFPFME_RESUMED  = 6 /* Monitoring resumed. */
+)
+
+// FlowMonitorRequest is the ofp_flow_monitor_request 1.4, the body of the OFPMP_FLOW_MONITOR request.
+type FlowMonitorRequest struct {
+	MonitorId uint32 /* Controller-assigned ID for this monitor. */
+	OutPort   uint32 /* Required output port, if not P_ANY. */
+	OutGroup  uint32 /* Required group, if not OFPG_ANY. */
+	Flags     uint16 /* OFPFMF_*. */
+	TableId   uint8  /* One table's ID or OFPTT_ALL (all tables). */
+	Command   uint8  /* One of OFPFMC_*. */
+	Match     Match  /* Fields to match. */
+}
+
+// NewFlowMonitorRequest returns a request to add the monitor of the flows in all the tables.
+func NewFlowMonitorRequest(monitorID uint32, flags uint16) *FlowMonitorRequest {
+	r := new(FlowMonitorRequest)
+	r.MonitorId = monitorID
+	r.OutPort = P_ANY
+	r.OutGroup = OFPG_ANY
+	r.Flags = flags
+	r.TableId = OFPTT_ALL
+	r.Command = OFPFMC_ADD
+	r.Match = *NewMatch()
+	return r
+}
+
+func (r *FlowMonitorRequest) Len() (n uint16) {
+	return 16 + r.Match.Len()
+}
+
+func (r *FlowMonitorRequest) MarshalBinary() (data []byte, err error) {
+	checkMatchSize("FlowMonitorRequest", &r.Match)
+	data = make([]byte, 16)
+	n := 0
+	binary.BigEndian.PutUint32(data[n:], r.MonitorId)
+	n += 4
+	binary.BigEndian.PutUint32(data[n:], r.OutPort)
+	n += 4
+	binary.BigEndian.PutUint32(data[n:], r.OutGroup)
+	n += 4
+	binary.BigEndian.PutUint16(data[n:], r.Flags)
+	n += 2
+	data[n] = r.TableId
+	n += 1
+	data[n] = r.Command
+	n += 1
+
+	b, err := r.Match.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, b...)
+	return
+}
+
+func (r *FlowMonitorRequest) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("the []byte is too short to unmarshal a full FlowMonitorRequest message")
+	}
+	n := 0
+	r.MonitorId = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	r.OutPort = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	r.OutGroup = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	r.Flags = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	r.TableId = data[n]
+	n += 1
+	r.Command = data[n]
+	n += 1
+	if err := r.Match.UnmarshalBinary(data[n:]); err != nil {
+		return decodeErrorAt(err, "FlowMonitorRequest", n, "match")
+	}
+	return nil
+}
+
+// FlowUpdateHeader is the ofp_flow_update_header 1.4, the common header of the flow updates.
+type FlowUpdateHeader struct {
+	Length uint16 /* Length of this entry. */
+	Event  uint16 /* One of OFPFME_*. */
+}
+
+func (h *FlowUpdateHeader) Len() (n uint16) {
+	return 4
+}
+
+func (h *FlowUpdateHeader) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, h.Len())
+	binary.BigEndian.PutUint16(data[0:], h.Length)
+	binary.BigEndian.PutUint16(data[2:], h.Event)
+	return
+}
+
+func (h *FlowUpdateHeader) UnmarshalBinary(data []byte) error {
+	if len(data) < int(h.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full FlowUpdateHeader message")
+	}
+	h.Length = binary.BigEndian.Uint16(data[0:])
+	h.Event = binary.BigEndian.Uint16(data[2:])
+	if h.Length < h.Len() || int(h.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full flow update")
+	}
+	return nil
+}
+
+// FlowUpdateFull is the ofp_flow_update_full 1.4, the flow update of OFPFME_INITIAL, OFPFME_ADDED,
+// OFPFME_REMOVED and OFPFME_MODIFIED.
+type FlowUpdateFull struct {
+	FlowUpdateHeader
+	TableId      uint8  /* ID of flow's table. */
+	Reason       uint8  /* OFPRR_* for OFPFME_REMOVED, else zero. */
+	IdleTimeout  uint16 /* Number of seconds idle before expiration. */
+	HardTimeout  uint16 /* Number of seconds before expiration. */
+	Priority     uint16 /* Priority of the entry. */
+	zeros        [4]uint8
+	Cookie       uint64 /* Opaque controller-issued identifier. */
+	Match        Match  /* Fields to match. */
+	Instructions []Instruction
+}
+
+func NewFlowUpdateFull(event uint16) *FlowUpdateFull {
+	u := new(FlowUpdateFull)
+	u.Event = event
+	u.Match = *NewMatch()
+	u.Length = u.Len()
+	return u
+}
+
+func (u *FlowUpdateFull) AddInstruction(instr Instruction) {
+	u.Instructions = append(u.Instructions, instr)
+}
+
+func (u *FlowUpdateFull) Len() (n uint16) {
+	n = 24 + u.Match.Len()
+	for _, instr := range u.Instructions {
+		n += instr.Len()
+	}
+	return
+}
+
+func (u *FlowUpdateFull) MarshalBinary() (data []byte, err error) {
+	u.Length = u.Len()
+	data = make([]byte, 24)
+	b, err := u.FlowUpdateHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	data[n] = u.TableId
+	n += 1
+	data[n] = u.Reason
+	n += 1
+	binary.BigEndian.PutUint16(data[n:], u.IdleTimeout)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], u.HardTimeout)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], u.Priority)
+	n += 2
+	n += 4 // for zeros
+	binary.BigEndian.PutUint64(data[n:], u.Cookie)
+	n += 8
+
+	b, err = u.Match.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, b...)
+	for _, instr := range u.Instructions {
+		b, err = instr.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return
+}
+
+func (u *FlowUpdateFull) UnmarshalBinary(data []byte) error {
+	if err := u.FlowUpdateHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if u.Length < 24 {
+		return errors.New("the []byte is too short to unmarshal a full FlowUpdateFull message")
+	}
+	n := int(u.FlowUpdateHeader.Len())
+	u.TableId = data[n]
+	n += 1
+	u.Reason = data[n]
+	n += 1
+	u.IdleTimeout = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	u.HardTimeout = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	u.Priority = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	n += 4 // for zeros
+	u.Cookie = binary.BigEndian.Uint64(data[n:])
+	n += 8
+
+	err := u.Match.UnmarshalBinary(data[n:u.Length])
+	if err != nil {
+		return decodeErrorAt(err, "FlowUpdateFull", n, "match")
+	}
+	n += int(u.Match.Len())
+
+	u.Instructions = nil
+	for n < int(u.Length) {
+		instr, err := decodeInstr(data[n:u.Length])
+		if err != nil {
+			return decodeErrorAt(err, "FlowUpdateFull", n, "instruction")
+		}
+		u.Instructions = append(u.Instructions, instr)
+		if n, err = safeAdvance(n, instr.Len(), int(u.Length), "instruction"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FlowUpdateAbbrev is the ofp_flow_update_abbrev 1.4, the flow update of OFPFME_ABBREV for the changes made by
+// this controller.
+type FlowUpdateAbbrev struct {
+	FlowUpdateHeader
+	Xid uint32 /* Controller-specified xid from flow_mod. */
+}
+
+func NewFlowUpdateAbbrev(xid uint32) *FlowUpdateAbbrev {
+	u := &FlowUpdateAbbrev{Xid: xid}
+	u.Event = OFPFME_ABBREV
+	u.Length = u.Len()
+	return u
+}
+
+func (u *FlowUpdateAbbrev) Len() (n uint16) {
+	return 8
+}
+
+func (u *FlowUpdateAbbrev) MarshalBinary() (data []byte, err error) {
+	u.Length = u.Len()
+	data = make([]byte, u.Len())
+	b, err := u.FlowUpdateHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	binary.BigEndian.PutUint32(data[n:], u.Xid)
+	return
+}
+
+func (u *FlowUpdateAbbrev) UnmarshalBinary(data []byte) error {
+	if err := u.FlowUpdateHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if u.Length < u.Len() {
+		return errors.New("the []byte is too short to unmarshal a full FlowUpdateAbbrev message")
+	}
+	u.Xid = binary.BigEndian.Uint32(data[u.FlowUpdateHeader.Len():])
+	return nil
+}
+
+// FlowUpdatePaused is the ofp_flow_update_paused 1.4, the flow update of OFPFME_PAUSED and OFPFME_RESUMED.
+type FlowUpdatePaused struct {
+	FlowUpdateHeader
+	zeros [4]uint8
+}
+
+func NewFlowUpdatePaused(event uint16) *FlowUpdatePaused {
+	u := new(FlowUpdatePaused)
+	u.Event = event
+	u.Length = u.Len()
+	return u
+}
+
+func (u *FlowUpdatePaused) Len() (n uint16) {
+	return 8
+}
+
+func (u *FlowUpdatePaused) MarshalBinary() (data []byte, err error) {
+	u.Length = u.Len()
+	data = make([]byte, u.Len())
+	b, err := u.FlowUpdateHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	copy(data, b)
+	return
+}
+
+func (u *FlowUpdatePaused) UnmarshalBinary(data []byte) error {
+	if err := u.FlowUpdateHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if u.Length < u.Len() {
+		return errors.New("the []byte is too short to unmarshal a full FlowUpdatePaused message")
+	}
+	return nil
+}
+
+// newFlowUpdate returns the flow update of the event in data, the unknown events are kept as raw bytes.
+func newFlowUpdate(data []byte) util.Message {
+	if len(data) < 4 {
+		return new(FlowUpdateHeader)
+	}
+	switch binary.BigEndian.Uint16(data[2:]) {
+	case OFPFME_INITIAL, OFPFME_ADDED, OFPFME_REMOVED, OFPFME_MODIFIED:
+		return NewFlowUpdateFull(0)
+	case OFPFME_ABBREV:
+		return new(FlowUpdateAbbrev)
+	case OFPFME_PAUSED, OFPFME_RESUMED:
+		return new(FlowUpdatePaused)
+	default:
+		return new(util.Buffer)
+	}
+}
+
+// flowUpdateEvent returns the event of the flow update, and false if the update is not a known flow update.
+func flowUpdateEvent(update util.Message) (uint16, bool) {
+	switch u := update.(type) {
+	case *FlowUpdateFull:
+		return u.Event, true
+	case *FlowUpdateAbbrev:
+		return u.Event, true
+	case *FlowUpdatePaused:
+		return u.Event, true
+	}
+	return 0, false
+}
+
+// MonitorState is the state of a MonitorSession.
+type MonitorState int
+
+const (
+	// MonitorActive means the switch is sending the flow updates.
+	MonitorActive MonitorState = iota
+	// MonitorPaused means the switch ran out of buffer space and stopped sending the flow updates until
+	// OFPFME_RESUMED, the flow changes in between are summarized in the updates after OFPFME_RESUMED.
+	MonitorPaused
+	// MonitorCancelled means the monitor is deleted with Cancel, or the Conn is closed.
+	MonitorCancelled
+)
+
+func (s MonitorState) String() string {
+	switch s {
+	case MonitorActive:
+		return "active"
+	case MonitorPaused:
+		return "paused"
+	case MonitorCancelled:
+		return "cancelled"
+	}
+	return fmt.Sprintf("MonitorState(%d)", int(s))
+}
+
+// MonitorSession is a flow monitor added on a Conn. The flow updates are sent by the switch as unsolicited
+// multipart replies, which the Conn passes to the sessions started on it instead of its handler. The session
+// buffers the updates for Updates, and tracks OFPFME_PAUSED and OFPFME_RESUMED so that the buffering is paused
+// together with the switch. Cancel deletes the monitor with OFPFMC_DELETE.
+//
+// The unsolicited updates don't carry the monitor ID, so a session keeps the updates which match its request:
+// the table, the OFPFMF_* events and the match of the request. The out_port and out_group of the request are
+// not checked. OFPFME_ABBREV is kept by the sessions without OFPFMF_NO_ABBREV, and OFPFME_PAUSED and
+// OFPFME_RESUMED, which apply to the connection, by all the sessions.
+type MonitorSession struct {
+	conn *Conn
+	xid  uint32
+	req  FlowMonitorRequest
+
+	lock    sync.Mutex
+	state   MonitorState
+	updates chan util.Message
+	dropped uint64
+}
+
+// StartMonitorSession adds the monitor of req on conn, and returns the session and the OFPFME_INITIAL updates
+// replied to the request. The subsequent updates are buffered up to bufferSize for Updates, the updates are
+// dropped and counted in Dropped if the buffer is full.
+func StartMonitorSession(ctx context.Context, conn *Conn, req *FlowMonitorRequest, bufferSize int) (*MonitorSession, []util.Message, error) {
+	req.Command = OFPFMC_ADD
+	mpReq, err := newFlowMonitorMultipartRequest(conn.Version(), req)
+	if err != nil {
+		return nil, nil, err
+	}
+	mpReq.Header.Xid = common.NextXid()
+	s := &MonitorSession{
+		conn:    conn,
+		xid:     mpReq.Header.Xid,
+		req:     *req,
+		state:   MonitorActive,
+		updates: make(chan util.Message, bufferSize),
+	}
+	// The session is added before sending the request, so that the updates sent right after the initial reply
+	// are buffered instead of passed to the handler of the Conn.
+	conn.addMonitor(s)
+	replies, err := conn.SendAndAwaitReply(ctx, mpReq)
+	if err != nil {
+		conn.removeMonitor(s)
+		return nil, nil, err
+	}
+	var initial []util.Message
+	for _, msg := range replies {
+		reply, ok := msg.(*MultipartReply)
+		if !ok || reply.Type != MultipartType_FlowMonitor {
+			conn.removeMonitor(s)
+			return nil, nil, fmt.Errorf("unexpected reply %T to flow monitor request", msg)
+		}
+		initial = append(initial, reply.Body...)
+	}
+	return s, initial, nil
+}
+
+// newFlowMonitorMultipartRequest returns the multipart request of the flow monitor request in the version of the
+// connection. The request has the same layout in OpenFlow 1.4 and 1.5, and an error is returned for the older or
+// unknown versions, which have no OFPMP_FLOW_MONITOR; NXFlowMonitorRequest could be used with OVS instead.
+func newFlowMonitorMultipartRequest(version uint8, req *FlowMonitorRequest) (*MultipartRequest, error) {
+	if version < OFP14_VERSION {
+		return nil, fmt.Errorf("flow monitor requires OpenFlow 1.4 or later, the connection version is 0x%02x", version)
+	}
+	mpReq := &MultipartRequest{Header: NewOfp13Header(), Type: MultipartType_FlowMonitor, Body: req}
+	mpReq.Header.Version = version
+	mpReq.Header.Type = Type_MultiPartRequest
+	return mpReq, nil
+}
+
+// addMonitor starts passing the flow monitor replies to the session.
+func (c *Conn) addMonitor(s *MonitorSession) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.monitors = append(c.monitors, s)
+}
+
+// removeMonitor stops passing the flow monitor replies to the session.
+func (c *Conn) removeMonitor(s *MonitorSession) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for i, m := range c.monitors {
+		if m == s {
+			c.monitors = append(c.monitors[:i], c.monitors[i+1:]...)
+			return
+		}
+	}
+}
+
+// dispatchFlowUpdates passes msg to the monitor sessions of the Conn, and returns false if msg is not a flow
+// monitor reply or there is no session.
+func (c *Conn) dispatchFlowUpdates(msg util.Message) bool {
+	reply, ok := msg.(*MultipartReply)
+	if !ok || reply.Type != MultipartType_FlowMonitor {
+		return false
+	}
+	c.lock.Lock()
+	monitors := append([]*MonitorSession(nil), c.monitors...)
+	c.lock.Unlock()
+	for _, s := range monitors {
+		s.handleMessage(reply)
+	}
+	return len(monitors) > 0
+}
+
+// MonitorID returns the ID of the monitor.
+func (s *MonitorSession) MonitorID() uint32 {
+	return s.req.MonitorId
+}
+
+// State returns the state of the session.
+func (s *MonitorSession) State() MonitorState {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.state
+}
+
+// Updates returns the channel of the flow updates, it is closed when the session is cancelled.
+func (s *MonitorSession) Updates() <-chan util.Message {
+	return s.updates
+}
+
+// Dropped returns the number of the updates dropped because the buffer was full.
+func (s *MonitorSession) Dropped() uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.dropped
+}
+
+// handleMessage consumes msg if it is a flow monitor reply, and returns false for other messages. It is called
+// in the receiving goroutine of the Conn, and never blocks. OFPFME_PAUSED moves the session to MonitorPaused and
+// OFPFME_RESUMED back to MonitorActive, both events are delivered to Updates so that the consumer knows to
+// resync the flows. The updates which are not for the session, and the updates received after Cancel, are
+// discarded.
+func (s *MonitorSession) handleMessage(msg util.Message) bool {
+	reply, ok := msg.(*MultipartReply)
+	if !ok || reply.Type != MultipartType_FlowMonitor {
+		return false
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.state == MonitorCancelled {
+		return true
+	}
+	for _, update := range reply.Body {
+		if !s.wants(reply.Header.Xid, update) {
+			continue
+		}
+		event, _ := flowUpdateEvent(update)
+		switch {
+		case event == OFPFME_PAUSED:
+			s.state = MonitorPaused
+		case event == OFPFME_RESUMED:
+			s.state = MonitorActive
+		case s.state == MonitorPaused:
+			// The switch doesn't send the updates when it is paused, drop the stale ones in flight.
+			s.dropped++
+			continue
+		}
+		select {
+		case s.updates <- update:
+		default:
+			s.dropped++
+		}
+	}
+	return true
+}
+
+// flowUpdateFlags maps the events of the full flow updates to the OFPFMF_* flags requesting them.
+var flowUpdateFlags = map[uint16]uint16{
+	OFPFME_INITIAL:  OFPFMF_INITIAL,
+	OFPFME_ADDED:    OFPFMF_ADD,
+	OFPFME_REMOVED:  OFPFMF_REMOVED,
+	OFPFME_MODIFIED: OFPFMF_MODIFY,
+}
+
+// wants returns true if the update in the flow monitor reply of xid is for the session. The replies to other
+// requests are not, and the unsolicited updates of xid 0 are checked against the request of the session.
+func (s *MonitorSession) wants(xid uint32, update util.Message) bool {
+	if xid != 0 && xid != s.xid {
+		return false
+	}
+	switch u := update.(type) {
+	case *FlowUpdateFull:
+		if s.req.TableId != OFPTT_ALL && s.req.TableId != u.TableId {
+			return false
+		}
+		if s.req.Flags&flowUpdateFlags[u.Event] == 0 {
+			return false
+		}
+		return matchCovers(&s.req.Match, &u.Match)
+	case *FlowUpdateAbbrev:
+		return s.req.Flags&OFPFMF_NO_ABBREV == 0
+	}
+	return true
+}
+
+// matchCovers returns true if the flow match is at least as specific as the monitor match in all the fields of
+// the monitor match, i.e., every packet matching the flow matches the monitor. The fields which could not be
+// compared don't restrict the flow.
+func matchCovers(monitor, flow *Match) bool {
+	for i := range monitor.Fields {
+		mf := &monitor.Fields[i]
+		var ff *MatchField
+		for j := range flow.Fields {
+			f := &flow.Fields[j]
+			if f.Class == mf.Class && f.Field == mf.Field && f.ExperimenterID == mf.ExperimenterID {
+				ff = f
+				break
+			}
+		}
+		if ff == nil {
+			return false
+		}
+		v1, m1, err1 := matchFieldBytes(mf)
+		v2, m2, err2 := matchFieldBytes(ff)
+		if err1 != nil || err2 != nil || len(v1) != len(v2) || len(m1) != len(v1) || len(m2) != len(v2) {
+			continue
+		}
+		for k := range v1 {
+			if m1[k]&^m2[k] != 0 || v1[k]&m1[k] != v2[k]&m1[k] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Cancel closes Updates and deletes the monitor with OFPFMC_DELETE. The session is cancelled even if sending
+// OFPFMC_DELETE fails, e.g., the Conn is closed. It returns nil if the session is already cancelled.
+func (s *MonitorSession) Cancel() error {
+	s.lock.Lock()
+	if s.state == MonitorCancelled {
+		s.lock.Unlock()
+		return nil
+	}
+	s.state = MonitorCancelled
+	close(s.updates)
+	s.lock.Unlock()
+
+	// The lock is released before sending, so that the receiving goroutine of the Conn is not blocked on the
+	// session while the outbound channel is full.
+	s.conn.removeMonitor(s)
+	req := NewFlowMonitorRequest(s.req.MonitorId, 0)
+	req.Command = OFPFMC_DELETE
+	mpReq, err := newFlowMonitorMultipartRequest(s.conn.Version(), req)
+	if err != nil {
+		return err
+	}
+	return s.conn.Send(mpReq)
+}
@@ -0,0 +1,297 @@
+package openflow13
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+func TestFlowMonitorMessages(t *testing.T) {
+	req := NewFlowMonitorRequest(7, OFPFMF_INITIAL|OFPFMF_ADD|OFPFMF_REMOVED|OFPFMF_INSTRUCTIONS)
+	req.TableId = 3
+	req.Match.AddField(*NewInPortField(2))
+	mpReq, err := newFlowMonitorMultipartRequest(OFP15_VERSION, req)
+	if err != nil {
+		t.Fatalf("Failed to create flow monitor request: %v", err)
+	}
+	data, err := mpReq.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal flow monitor request: %v", err)
+	}
+	msg, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse flow monitor request: %v", err)
+	}
+	parsedReq := msg.(*MultipartRequest).Body.(*FlowMonitorRequest)
+	if parsedReq.MonitorId != 7 || parsedReq.OutPort != P_ANY || parsedReq.OutGroup != OFPG_ANY ||
+		parsedReq.Flags != req.Flags || parsedReq.TableId != 3 || parsedReq.Command != OFPFMC_ADD ||
+		len(parsedReq.Match.Fields) != 1 {
+		t.Errorf("Unexpected flow monitor request: %+v", parsedReq)
+	}
+
+	full := NewFlowUpdateFull(OFPFME_ADDED)
+	full.TableId = 3
+	full.Priority = 100
+	full.Cookie = 0x1234
+	full.Match.AddField(*NewInPortField(2))
+	full.AddInstruction(NewInstrGotoTable(4))
+	reply := newMultipartReply(1, MultipartType_FlowMonitor, 0,
+		full, NewFlowUpdateAbbrev(99), NewFlowUpdatePaused(OFPFME_PAUSED), NewFlowUpdatePaused(OFPFME_RESUMED))
+	data, err = reply.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal flow updates: %v", err)
+	}
+	msg, err = Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse flow updates: %v", err)
+	}
+	body := msg.(*MultipartReply).Body
+	if len(body) != 4 {
+		t.Fatalf("Expect 4 flow updates, actual: %d", len(body))
+	}
+	parsedFull, ok := body[0].(*FlowUpdateFull)
+	if !ok || parsedFull.Event != OFPFME_ADDED || parsedFull.TableId != 3 || parsedFull.Priority != 100 ||
+		parsedFull.Cookie != 0x1234 || len(parsedFull.Match.Fields) != 1 || len(parsedFull.Instructions) != 1 ||
+		parsedFull.Length != full.Len() {
+		t.Errorf("Unexpected full flow update: %+v", body[0])
+	}
+	if abbrev, ok := body[1].(*FlowUpdateAbbrev); !ok || abbrev.Event != OFPFME_ABBREV || abbrev.Xid != 99 {
+		t.Errorf("Unexpected abbreviated flow update: %+v", body[1])
+	}
+	for i, event := range []uint16{OFPFME_PAUSED, OFPFME_RESUMED} {
+		if paused, ok := body[2+i].(*FlowUpdatePaused); !ok || paused.Event != event {
+			t.Errorf("Unexpected flow update %d: %+v", 2+i, body[2+i])
+		}
+	}
+}
+
+// newFlowMonitorReply returns the flow monitor reply sent by an OpenFlow 1.5 switch.
+func newFlowMonitorReply(xid uint32, bodies ...util.Message) *MultipartReply {
+	reply := newMultipartReply(xid, MultipartType_FlowMonitor, 0, bodies...)
+	reply.Header.Version = OFP15_VERSION
+	return reply
+}
+
+func TestMonitorSession(t *testing.T) {
+	inbound := make(chan util.Message)
+	outbound := make(chan util.Message)
+	defer close(outbound)
+	unsolicited := make(chan util.Message, 1)
+	conn := newConn(inbound, outbound, func(msg util.Message) {
+		unsolicited <- msg
+	})
+	defer conn.Close()
+	conn.SetVersion(OFP15_VERSION)
+
+	deleted := make(chan *FlowMonitorRequest, 1)
+	early := NewFlowUpdateFull(OFPFME_ADDED)
+	go fakeSwitch(outbound, inbound, func(req util.Message) []util.Message {
+		mpReq, ok := req.(*MultipartRequest)
+		if !ok || mpReq.Type != MultipartType_FlowMonitor {
+			return nil
+		}
+		monitorReq := mpReq.Body.(*FlowMonitorRequest)
+		if monitorReq.Command == OFPFMC_DELETE {
+			deleted <- monitorReq
+			return nil
+		}
+		initial := NewFlowUpdateFull(OFPFME_INITIAL)
+		initial.Priority = 100
+		// The update sent right after the initial reply, before StartMonitorSession returns.
+		return []util.Message{
+			newFlowMonitorReply(messageXid(req), initial),
+			newFlowMonitorReply(0, early),
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	flags := uint16(OFPFMF_INITIAL | OFPFMF_ADD | OFPFMF_REMOVED | OFPFMF_MODIFY)
+	session, initial, err := StartMonitorSession(ctx, conn, NewFlowMonitorRequest(5, flags), 2)
+	if err != nil {
+		t.Fatalf("Failed to start monitor session: %v", err)
+	}
+	if len(initial) != 1 || initial[0].(*FlowUpdateFull).Priority != 100 {
+		t.Errorf("Unexpected initial updates: %+v", initial)
+	}
+	select {
+	case update := <-session.Updates():
+		if update != early {
+			t.Errorf("Unexpected update after the initial reply: %+v", update)
+		}
+	case <-ctx.Done():
+		t.Fatalf("The update after the initial reply is not delivered")
+	}
+	if session.State() != MonitorActive {
+		t.Errorf("Expect session %s, actual: %s", MonitorActive, session.State())
+	}
+
+	if session.handleMessage(NewPacketIn()) {
+		t.Errorf("Expect PacketIn not consumed by the session")
+	}
+	session.handleMessage(newMultipartReply(0, MultipartType_FlowMonitor, 0,
+		NewFlowUpdateFull(OFPFME_ADDED), NewFlowUpdatePaused(OFPFME_PAUSED), NewFlowUpdateFull(OFPFME_REMOVED)))
+	if session.State() != MonitorPaused {
+		t.Errorf("Expect session %s after OFPFME_PAUSED, actual: %s", MonitorPaused, session.State())
+	}
+	if session.Dropped() != 1 {
+		t.Errorf("Expect the update after OFPFME_PAUSED dropped, actual dropped: %d", session.Dropped())
+	}
+	for _, event := range []uint16{OFPFME_ADDED, OFPFME_PAUSED} {
+		if e, _ := flowUpdateEvent(<-session.Updates()); e != event {
+			t.Errorf("Expect update event %d, actual: %d", event, e)
+		}
+	}
+	session.handleMessage(newMultipartReply(0, MultipartType_FlowMonitor, 0,
+		NewFlowUpdatePaused(OFPFME_RESUMED), NewFlowUpdateFull(OFPFME_MODIFIED), NewFlowUpdateFull(OFPFME_ADDED)))
+	if session.State() != MonitorActive {
+		t.Errorf("Expect session %s after OFPFME_RESUMED, actual: %s", MonitorActive, session.State())
+	}
+	if session.Dropped() != 2 {
+		t.Errorf("Expect the update dropped with full buffer, actual dropped: %d", session.Dropped())
+	}
+
+	if err = session.Cancel(); err != nil {
+		t.Fatalf("Failed to cancel monitor session: %v", err)
+	}
+	select {
+	case req := <-deleted:
+		if req.MonitorId != 5 {
+			t.Errorf("Expect OFPFMC_DELETE of monitor 5, actual: %d", req.MonitorId)
+		}
+	case <-ctx.Done():
+		t.Fatalf("OFPFMC_DELETE is not sent")
+	}
+	if session.State() != MonitorCancelled {
+		t.Errorf("Expect session %s after Cancel, actual: %s", MonitorCancelled, session.State())
+	}
+	var updates int
+	for range session.Updates() {
+		updates++
+	}
+	if updates != 2 {
+		t.Errorf("Expect 2 buffered updates after OFPFME_RESUMED, actual: %d", updates)
+	}
+	if !session.handleMessage(newMultipartReply(0, MultipartType_FlowMonitor, 0, NewFlowUpdateFull(OFPFME_ADDED))) {
+		t.Errorf("Expect flow updates consumed after Cancel")
+	}
+	if err = session.Cancel(); err != nil {
+		t.Errorf("Expect Cancel idempotent, actual: %v", err)
+	}
+	select {
+	case msg := <-unsolicited:
+		t.Errorf("Unexpected uncorrelated message %T", msg)
+	default:
+	}
+}
+
+func TestMonitorSessionFilter(t *testing.T) {
+	inbound := make(chan util.Message)
+	outbound := make(chan util.Message)
+	defer close(outbound)
+	unsolicited := make(chan util.Message, 1)
+	conn := newConn(inbound, outbound, func(msg util.Message) {
+		unsolicited <- msg
+	})
+	defer conn.Close()
+	conn.SetVersion(OFP15_VERSION)
+	go fakeSwitch(outbound, inbound, func(req util.Message) []util.Message {
+		if mpReq, ok := req.(*MultipartRequest); !ok || mpReq.Body.(*FlowMonitorRequest).Command != OFPFMC_ADD {
+			return nil
+		}
+		return []util.Message{newFlowMonitorReply(messageXid(req))}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req1 := NewFlowMonitorRequest(1, OFPFMF_ADD)
+	req1.TableId = 3
+	req1.Match.AddField(*NewInPortField(1))
+	s1, _, err := StartMonitorSession(ctx, conn, req1, 8)
+	if err != nil {
+		t.Fatalf("Failed to start monitor session: %v", err)
+	}
+	s2, _, err := StartMonitorSession(ctx, conn, NewFlowMonitorRequest(2, OFPFMF_ADD|OFPFMF_REMOVED|OFPFMF_NO_ABBREV), 8)
+	if err != nil {
+		t.Fatalf("Failed to start monitor session: %v", err)
+	}
+
+	newUpdate := func(event uint16, tableID uint8, inPort uint32) *FlowUpdateFull {
+		u := NewFlowUpdateFull(event)
+		u.TableId = tableID
+		if inPort != 0 {
+			u.Match.AddField(*NewInPortField(inPort))
+		}
+		return u
+	}
+	both := newUpdate(OFPFME_ADDED, 3, 1)
+	otherTable := newUpdate(OFPFME_ADDED, 4, 1)
+	removed := newUpdate(OFPFME_REMOVED, 3, 1)
+	otherPort := newUpdate(OFPFME_ADDED, 3, 2)
+	abbrev := NewFlowUpdateAbbrev(100)
+	paused := NewFlowUpdatePaused(OFPFME_PAUSED)
+	// The reply to another request is not for the sessions.
+	inbound <- newFlowMonitorReply(12345, newUpdate(OFPFME_ADDED, 3, 1))
+	inbound <- newFlowMonitorReply(0, both, otherTable, removed, otherPort, abbrev, paused)
+
+	for _, tc := range []struct {
+		session  *MonitorSession
+		expected []util.Message
+	}{
+		{s1, []util.Message{both, abbrev, paused}},
+		{s2, []util.Message{both, otherTable, removed, otherPort, paused}},
+	} {
+		for i, expected := range tc.expected {
+			select {
+			case update := <-tc.session.Updates():
+				if update != expected {
+					t.Errorf("Monitor %d: unexpected update %d: %+v", tc.session.MonitorID(), i, update)
+				}
+			case <-ctx.Done():
+				t.Fatalf("Monitor %d: update %d is not delivered", tc.session.MonitorID(), i)
+			}
+		}
+		if n := len(tc.session.Updates()); n != 0 {
+			t.Errorf("Monitor %d: expect no more updates, actual: %d", tc.session.MonitorID(), n)
+		}
+	}
+	select {
+	case msg := <-unsolicited:
+		t.Errorf("Unexpected uncorrelated message %T", msg)
+	default:
+	}
+}
+
+func TestMonitorSessionVersion(t *testing.T) {
+	inbound := make(chan util.Message)
+	outbound := make(chan util.Message, 1)
+	conn := newConn(inbound, outbound, nil)
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The version is learned from the inbound messages.
+	hello := NewOfp13Header()
+	inbound <- &hello
+	for conn.Version() != VERSION {
+		time.Sleep(time.Millisecond)
+	}
+	if _, _, err := StartMonitorSession(ctx, conn, NewFlowMonitorRequest(1, OFPFMF_ADD), 1); err == nil {
+		t.Errorf("Expect error to start monitor session on OpenFlow 1.3 connection")
+	}
+	if len(outbound) != 0 {
+		t.Fatalf("Expect no flow monitor request sent on OpenFlow 1.3 connection, actual: %+v", <-outbound)
+	}
+
+	conn.SetVersion(OFP14_VERSION)
+	shortCtx, shortCancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer shortCancel()
+	StartMonitorSession(shortCtx, conn, NewFlowMonitorRequest(1, OFPFMF_ADD), 1)
+	req := <-outbound
+	data, _ := req.MarshalBinary()
+	if data[0] != OFP14_VERSION {
+		t.Errorf("Expect flow monitor request of OpenFlow 1.4 connection in version 0x%02x, actual: 0x%02x", OFP14_VERSION, data[0])
+	}
+}
@@ -0,0 +1,231 @@
+package openflow13
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+// canonicalField is a match field in the form of OVS, i.e., the value is masked, and the mask is nil if the field
+// is exact.
+type canonicalField struct {
+	key   matchFieldKey
+	value []byte
+	mask  []byte
+}
+
+// matchFieldKeyLess orders the fields by the class, the experimenter ID and the field.
+func matchFieldKeyLess(k1, k2 matchFieldKey) bool {
+	if k1.class != k2.class {
+		return k1.class < k2.class
+	}
+	if k1.experimenterID != k2.experimenterID {
+		return k1.experimenterID < k2.experimenterID
+	}
+	return k1.field < k2.field
+}
+
+func matchFieldKeyOf(f *MatchField) matchFieldKey {
+	return matchFieldKey{class: f.Class, field: f.Field, experimenterID: f.ExperimenterID}
+}
+
+// canonicalMatchField returns the field in the form of OVS, and false if the field is fully wildcarded, i.e., it
+// has a zero mask and matches any packet.
+func canonicalMatchField(f *MatchField) (*canonicalField, bool, error) {
+	value, mask, err := matchFieldBytes(f)
+	if err != nil {
+		return nil, false, err
+	}
+	value = append([]byte(nil), value...)
+	allZeros, allOnes := true, true
+	for i := range value {
+		if i < len(mask) {
+			value[i] &= mask[i]
+			allZeros = allZeros && mask[i] == 0
+			allOnes = allOnes && mask[i] == 0xff
+		}
+	}
+	if len(mask) > 0 && allZeros {
+		return nil, false, nil
+	}
+	c := &canonicalField{key: matchFieldKeyOf(f), value: value}
+	if !allOnes {
+		c.mask = append([]byte(nil), mask...)
+	}
+	return c, true, nil
+}
+
+// canonicalMatch returns the fields of the match in the form of OVS sorted by the class and the field.
+func canonicalMatch(m *Match) ([]*canonicalField, error) {
+	fields := make([]*canonicalField, 0, len(m.Fields))
+	for i := range m.Fields {
+		c, ok, err := canonicalMatchField(&m.Fields[i])
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			fields = append(fields, c)
+		}
+	}
+	sort.SliceStable(fields, func(i, j int) bool { return matchFieldKeyLess(fields[i].key, fields[j].key) })
+	return fields, nil
+}
+
+// Normalize rewrites the match in the form of the flows dumped by OVS: the bits of the values out of the masks
+// are cleared, the fields with all-ones masks are exact, the fields with zero masks are removed, and the fields
+// are sorted by the class and the field. The fields which fail to marshal are kept unchanged. The values are
+// replaced rather than modified, as they might be shared with the fields of other matches.
+func (m *Match) Normalize() {
+	fields := make([]MatchField, 0, len(m.Fields))
+	for _, f := range m.Fields {
+		c, ok, err := canonicalMatchField(&f)
+		if err == nil && !ok {
+			continue
+		}
+		if err == nil {
+			var value util.Message
+			decode := matchFieldDecoder(f.Class, f.Field, f.ExperimenterID)
+			if value, err = decode(f.Class, f.Field, f.Length, f.HasMask, c.value); err == nil {
+				f.Value = value
+			}
+		}
+		if err == nil && f.HasMask && c.mask == nil {
+			valueLen := int(f.Length)
+			if f.ExperimenterID != 0 {
+				valueLen -= 4
+			}
+			f.Length -= uint8(valueLen / 2)
+			f.HasMask = false
+			f.Mask = nil
+		}
+		fields = append(fields, f)
+	}
+	sort.SliceStable(fields, func(i, j int) bool {
+		return matchFieldKeyLess(matchFieldKeyOf(&fields[i]), matchFieldKeyOf(&fields[j]))
+	})
+	m.Fields = fields
+	m.Length = 4
+	for i := range fields {
+		m.Length += fields[i].Len()
+	}
+}
+
+// Equals returns whether the matches match the same packets as OVS, i.e., regardless of the order of the fields,
+// the exact fields with all-ones masks, the wildcarded fields and the bits of the values out of the masks.
+func (m *Match) Equals(other *Match) bool {
+	fields1, err1 := canonicalMatch(m)
+	fields2, err2 := canonicalMatch(other)
+	if err1 != nil || err2 != nil || len(fields1) != len(fields2) {
+		return false
+	}
+	for i, f1 := range fields1 {
+		f2 := fields2[i]
+		if f1.key != f2.key || !bytes.Equal(f1.value, f2.value) || !bytes.Equal(f1.mask, f2.mask) {
+			return false
+		}
+	}
+	return true
+}
+
+// instructionOrder is the order of the instructions in the flows dumped by OVS, which is the order of their
+// execution in the OpenFlow pipeline.
+var instructionOrder = map[uint16]int{
+	InstrType_METER:          0,
+	InstrType_APPLY_ACTIONS:  1,
+	InstrType_CLEAR_ACTIONS:  2,
+	InstrType_WRITE_ACTIONS:  3,
+	InstrType_WRITE_METADATA: 4,
+	InstrType_GOTO_TABLE:     5,
+	InstrType_EXPERIMENTER:   6,
+}
+
+// NormalizeInstructions returns the instructions in the form of the flows dumped by OVS: the instructions are
+// sorted in the order of their execution, the apply-actions instructions without an action are removed, and the
+// bits of the metadata out of the mask are cleared in a copy of the write-metadata instruction.
+func NormalizeInstructions(instrs []Instruction) []Instruction {
+	type sortedInstr struct {
+		instr Instruction
+		order int
+	}
+	sorted := make([]sortedInstr, 0, len(instrs))
+	for _, instr := range instrs {
+		switch i := instr.(type) {
+		case *InstrActions:
+			if i.Type == InstrType_APPLY_ACTIONS && len(i.Actions) == 0 {
+				continue
+			}
+		case *InstrWriteMetadata:
+			c := *i
+			c.Metadata &= c.MetadataMask
+			instr = &c
+		}
+		order := len(instructionOrder)
+		if data, err := instr.MarshalBinary(); err == nil && len(data) >= 2 {
+			if o, ok := instructionOrder[binary.BigEndian.Uint16(data)]; ok {
+				order = o
+			}
+		}
+		sorted = append(sorted, sortedInstr{instr: instr, order: order})
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].order < sorted[j].order })
+	normalized := make([]Instruction, len(sorted))
+	for i := range sorted {
+		normalized[i] = sorted[i].instr
+	}
+	return normalized
+}
+
+// InstructionsEqual returns whether the normalized instructions have the same bytes, the actions are compared in
+// the order of the list.
+func InstructionsEqual(instrs1, instrs2 []Instruction) bool {
+	instrs1, instrs2 = NormalizeInstructions(instrs1), NormalizeInstructions(instrs2)
+	if len(instrs1) != len(instrs2) {
+		return false
+	}
+	for i := range instrs1 {
+		data1, err1 := instrs1[i].MarshalBinary()
+		data2, err2 := instrs2[i].MarshalBinary()
+		if err1 != nil || err2 != nil || !bytes.Equal(data1, data2) {
+			return false
+		}
+	}
+	return true
+}
+
+// Normalize rewrites the match and the instructions of the FlowMod in the form of the flows dumped by OVS, see
+// Match.Normalize and NormalizeInstructions.
+func (f *FlowMod) Normalize() {
+	f.Match.Normalize()
+	f.Instructions = NormalizeInstructions(f.Instructions)
+}
+
+// transientFlowModFlags are the flags applying to the FlowMod only, OVS doesn't keep them in the flow.
+const transientFlowModFlags = FF_CHECK_OVERLAP | FF_RESET_COUNTS
+
+// Equals returns whether the FlowMods install the same flow, i.e., the table, the priority, the cookie, the
+// timeouts, the flags kept in the flow, the match and the instructions are equal after normalization. The
+// command, the buffer, the out port and group, and FF_CHECK_OVERLAP and FF_RESET_COUNTS of the FlowMods are
+// ignored.
+func (f *FlowMod) Equals(other *FlowMod) bool {
+	return f.TableId == other.TableId && f.Priority == other.Priority && f.Cookie == other.Cookie &&
+		f.IdleTimeout == other.IdleTimeout && f.HardTimeout == other.HardTimeout &&
+		f.Flags&^transientFlowModFlags == other.Flags&^transientFlowModFlags &&
+		f.Match.Equals(&other.Match) && InstructionsEqual(f.Instructions, other.Instructions)
+}
+
+// EqualsFlowStats returns whether the flow dumped in the FlowStats is the flow installed by the FlowMod, e.g., to
+// reconcile the desired flows with the flows of the switch.
+func (f *FlowMod) EqualsFlowStats(s *FlowStats) bool {
+	dumped, err := NewFlowModFromFlowStats(s)
+	if err != nil {
+		return false
+	}
+	return f.Equals(dumped)
+}
+
+// EqualsFlowDesc returns whether the flow in the FlowDesc of OpenFlow 1.5 is the flow installed by the FlowMod.
+func (f *FlowMod) EqualsFlowDesc(d *FlowDesc) bool {
+	return f.Equals(NewFlowModFromFlowDesc(d))
+}
@@ -0,0 +1,147 @@
+package openflow13
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/contiv/libOpenflow/protocol"
+)
+
+func TestMatchNormalize(t *testing.T) {
+	hostMask := net.ParseIP("255.255.255.255").To4()
+	zero, low := uint64(0), uint64(0x0f)
+	m := NewMatch()
+	m.AddField(*NewIpv4SrcField(net.ParseIP("10.0.0.1"), &hostMask))
+	m.AddField(*NewMetadataField(0xff, &low))
+	m.AddField(*NewRegMatchField(0, 0x5, nil))
+	m.AddField(*NewTunnelIdField(1))
+	m.AddField(*NewEthTypeField(protocol.IPv4_MSG))
+	m.AddField(*NewMetadataField(0x1, &zero))
+	orig := *m
+	orig.Fields = append([]MatchField(nil), m.Fields...)
+
+	m.Normalize()
+	if len(m.Fields) != 5 {
+		t.Fatalf("Expect 5 fields without the wildcarded metadata, actual: %d", len(m.Fields))
+	}
+	for i := 1; i < len(m.Fields); i++ {
+		if matchFieldKeyLess(matchFieldKeyOf(&m.Fields[i]), matchFieldKeyOf(&m.Fields[i-1])) {
+			t.Errorf("The fields are not sorted: %s", m.String())
+		}
+	}
+	for _, f := range m.Fields {
+		switch v := f.Value.(type) {
+		case *Ipv4SrcField:
+			if f.HasMask || f.Mask != nil || f.Length != 4 {
+				t.Errorf("Expect exact ipv4_src, actual: %+v", f)
+			}
+		case *MetadataField:
+			if v.Metadata != 0x0f || !f.HasMask {
+				t.Errorf("Expect masked metadata 0xf/0xf, actual: %+v", f)
+			}
+		}
+	}
+	data, _ := m.MarshalBinary()
+	decoded := new(Match)
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to decode the normalized match: %v", err)
+	}
+	if !decoded.Equals(&orig) || !orig.Equals(m) {
+		t.Errorf("Expect the normalized match equal to the original one")
+	}
+	other := NewMatch()
+	other.AddField(*NewEthTypeField(protocol.IPv6_MSG))
+	if orig.Equals(other) {
+		t.Errorf("Expect different matches")
+	}
+}
+
+func TestMatchNormalizeSharedValue(t *testing.T) {
+	low := uint64(0x0f)
+	field := NewMetadataField(0xff, &low)
+	m := NewMatch()
+	m.AddField(*field)
+	other := NewMatch()
+	other.AddField(*field)
+
+	m.Normalize()
+	if v := m.Fields[0].Value.(*MetadataField); v.Metadata != 0x0f {
+		t.Errorf("Expect the normalized metadata 0xf, actual: 0x%x", v.Metadata)
+	}
+	// The value shared with the other match is not changed.
+	if v := other.Fields[0].Value.(*MetadataField); v.Metadata != 0xff {
+		t.Errorf("Expect the metadata of the other match unchanged, actual: 0x%x", v.Metadata)
+	}
+}
+
+func TestFlowModEquals(t *testing.T) {
+	desired := NewFlowMod()
+	desired.TableId = 1
+	desired.Priority = 100
+	desired.Match.AddField(*NewTunnelIdField(5))
+	desired.Match.AddField(*NewEthTypeField(protocol.IPv4_MSG))
+	desired.AddInstruction(NewInstrGotoTable(2))
+	desired.AddInstruction(NewInstrWriteMetadata(0x11, 0x0f))
+	apply := NewInstrApplyActions()
+	_ = apply.AddAction(NewActionOutput(1), false)
+	desired.AddInstruction(apply)
+	desired.AddInstruction(NewInstrApplyActions())
+
+	dumped := NewFlowMod()
+	dumped.TableId = 1
+	dumped.Priority = 100
+	dumped.Match.AddField(*NewEthTypeField(protocol.IPv4_MSG))
+	dumped.Match.AddField(*NewTunnelIdField(5))
+	dumped.AddInstruction(apply)
+	dumped.AddInstruction(NewInstrWriteMetadata(0x01, 0x0f))
+	dumped.AddInstruction(NewInstrGotoTable(2))
+
+	data1, _ := desired.MarshalBinary()
+	data2, _ := dumped.MarshalBinary()
+	if bytes.Equal(data1, data2) {
+		t.Fatalf("Expect the flows different in bytes")
+	}
+	if !desired.Equals(dumped) {
+		t.Errorf("Expect equal flows:\n%s\n%s", desired, dumped)
+	}
+	stats := &FlowStats{TableId: 1, Priority: 100, Match: dumped.Match, Instructions: dumped.Instructions}
+	if !desired.EqualsFlowStats(stats) {
+		t.Errorf("Expect the flow equal to the FlowStats")
+	}
+	desc := &FlowDesc{TableId: 1, Priority: 100, Match: dumped.Match, Instructions: dumped.Instructions}
+	if !desired.EqualsFlowDesc(desc) {
+		t.Errorf("Expect the flow equal to the FlowDesc")
+	}
+
+	desired.Normalize()
+	if len(desired.Instructions) != 3 {
+		t.Fatalf("Expect 3 instructions without the empty apply-actions, actual: %d", len(desired.Instructions))
+	}
+	if _, ok := desired.Instructions[0].(*InstrActions); !ok {
+		t.Errorf("Expect apply-actions first, actual: %T", desired.Instructions[0])
+	}
+
+	// OVS doesn't report FF_CHECK_OVERLAP in the dumped flows.
+	desired.Flags = FF_SEND_FLOW_REM | FF_CHECK_OVERLAP
+	stats.Flags = FF_SEND_FLOW_REM
+	if !desired.EqualsFlowStats(stats) {
+		t.Errorf("Expect the flow with FF_CHECK_OVERLAP equal to the FlowStats without it")
+	}
+	stats.Flags = 0
+	if desired.EqualsFlowStats(stats) {
+		t.Errorf("Expect the flow with FF_SEND_FLOW_REM different from the FlowStats without it")
+	}
+	stats.Flags = FF_SEND_FLOW_REM
+
+	stats.Priority = 200
+	if desired.EqualsFlowStats(stats) {
+		t.Errorf("Expect the flow different from the FlowStats of another priority")
+	}
+	other := NewInstrApplyActions()
+	_ = other.AddAction(NewActionOutput(2), false)
+	dumped.Instructions[0] = other
+	if desired.Equals(dumped) {
+		t.Errorf("Expect the flows with different actions different")
+	}
+}
@@ -0,0 +1,106 @@
+package openflow13
+
+import (
+	"sort"
+)
+
+// FlowOverlap is a pair of flows which are in the same table with the same priority, and there might be a packet
+// matching both of them. The switch behavior is undefined for such packets.
+type FlowOverlap struct {
+	Flow1 *FlowMod
+	Flow2 *FlowMod
+}
+
+type matchFieldKey struct {
+	class          uint16
+	field          uint8
+	experimenterID uint32
+}
+
+// matchFieldBytes returns the value and mask of the field in bytes, the mask is all ones if the field has no mask.
+func matchFieldBytes(f *MatchField) (value []byte, mask []byte, err error) {
+	value, err = f.Value.MarshalBinary()
+	if err != nil {
+		return nil, nil, err
+	}
+	if f.HasMask && f.Mask != nil {
+		mask, err = f.Mask.MarshalBinary()
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		mask = make([]byte, len(value))
+		for i := range mask {
+			mask[i] = 0xff
+		}
+	}
+	return value, mask, nil
+}
+
+// matchFieldsOverlap returns false only if no value could match both fields.
+func matchFieldsOverlap(f1, f2 *MatchField) bool {
+	v1, m1, err1 := matchFieldBytes(f1)
+	v2, m2, err2 := matchFieldBytes(f2)
+	if err1 != nil || err2 != nil || len(v1) != len(v2) || len(m1) != len(v1) || len(m2) != len(v2) {
+		// Be conservative if the fields could not be compared.
+		return true
+	}
+	for i := range v1 {
+		commonMask := m1[i] & m2[i]
+		if v1[i]&commonMask != v2[i]&commonMask {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesOverlap returns true if there might be a packet matching both m1 and m2. The fields which only exist
+// in one of the matches don't restrict the overlap, and the fields in both of the matches must have intersecting
+// values under their masks.
+func MatchesOverlap(m1, m2 *Match) bool {
+	fields := make(map[matchFieldKey][]*MatchField)
+	for i := range m1.Fields {
+		f := &m1.Fields[i]
+		key := matchFieldKey{class: f.Class, field: f.Field, experimenterID: f.ExperimenterID}
+		fields[key] = append(fields[key], f)
+	}
+	for i := range m2.Fields {
+		f2 := &m2.Fields[i]
+		key := matchFieldKey{class: f2.Class, field: f2.Field, experimenterID: f2.ExperimenterID}
+		for _, f1 := range fields[key] {
+			if !matchFieldsOverlap(f1, f2) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// FindFlowOverlaps checks the flows like the switch does with FF_CHECK_OVERLAP, and returns all the pairs of
+// flows which are in the same table with the same priority and overlapping matches.
+func FindFlowOverlaps(flows []*FlowMod) []FlowOverlap {
+	var overlaps []FlowOverlap
+	for i := 0; i < len(flows); i++ {
+		for j := i + 1; j < len(flows); j++ {
+			f1, f2 := flows[i], flows[j]
+			if f1.TableId != f2.TableId || f1.Priority != f2.Priority {
+				continue
+			}
+			if MatchesOverlap(&f1.Match, &f2.Match) {
+				overlaps = append(overlaps, FlowOverlap{Flow1: f1, Flow2: f2})
+			}
+		}
+	}
+	return overlaps
+}
+
+// SortFlowsByPriority sorts the flows in the order of the lookup in the switch, i.e., by table ID, and then by
+// priority from high to low. The order of the flows with the same table and priority is kept.
+func SortFlowsByPriority(flows []*FlowMod) {
+	sort.SliceStable(flows, func(i, j int) bool {
+		if flows[i].TableId != flows[j].TableId {
+			return flows[i].TableId < flows[j].TableId
+		}
+		return flows[i].Priority > flows[j].Priority
+	})
+}
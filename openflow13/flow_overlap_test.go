@@ -0,0 +1,37 @@
+package openflow13
+
+import (
+	"net"
+	"testing"
+)
+
+func TestFindFlowOverlaps(t *testing.T) {
+	newFlow := func(priority uint16, fields ...*MatchField) *FlowMod {
+		flow := NewFlowMod()
+		flow.Priority = priority
+		for _, f := range fields {
+			flow.Match.AddField(*f)
+		}
+		return flow
+	}
+	ipMask := net.ParseIP("255.255.0.0").To4()
+	flow1 := newFlow(100, NewEthTypeField(0x0800), NewIpv4SrcField(net.ParseIP("10.10.0.0").To4(), &ipMask))
+	flow2 := newFlow(100, NewEthTypeField(0x0800), NewIpv4SrcField(net.ParseIP("10.10.1.1").To4(), nil))
+	flow3 := newFlow(100, NewEthTypeField(0x0800), NewIpv4SrcField(net.ParseIP("10.20.1.1").To4(), nil))
+	flow4 := newFlow(100, NewEthTypeField(0x86dd))
+	flow5 := newFlow(200, NewEthTypeField(0x0800))
+
+	overlaps := FindFlowOverlaps([]*FlowMod{flow1, flow2, flow3, flow4, flow5})
+	if len(overlaps) != 1 {
+		t.Fatalf("Expect 1 overlap, actual: %d", len(overlaps))
+	}
+	if overlaps[0].Flow1 != flow1 || overlaps[0].Flow2 != flow2 {
+		t.Errorf("Unexpected overlapping flows")
+	}
+
+	flows := []*FlowMod{flow1, flow5, flow4}
+	SortFlowsByPriority(flows)
+	if flows[0] != flow5 || flows[1] != flow1 || flows[2] != flow4 {
+		t.Errorf("Flows are not sorted by priority")
+	}
+}
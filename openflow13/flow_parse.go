@@ -0,0 +1,1182 @@
+package openflow13
+
+// This file has the parser of the flows in the syntax of ovs-ofctl add-flow, which is the inverse of the String
+// methods in flow_string.go, e.g., "table=0,priority=10,tcp,tp_dst=80,actions=resubmit(,1)". The match fields are
+// those in flowFieldFormats, and the actions are those printed by the String methods, except learn, encap and
+// decap.
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// defaultFlowPriority is the priority of a flow without priority in ovs-ofctl, i.e., OFP_DEFAULT_PRIORITY.
+const defaultFlowPriority = 0x8000
+
+// flowFieldAliases maps the alternative ovs-ofctl names of the match fields to the names in flowFieldFormats.
+var flowFieldAliases = map[string]string{
+	"eth_src":      "dl_src",
+	"eth_dst":      "dl_dst",
+	"eth_type":     "dl_type",
+	"vlan_pcp":     "dl_vlan_pcp",
+	"ip_src":       "nw_src",
+	"ip_dst":       "nw_dst",
+	"ip_proto":     "nw_proto",
+	"ip_ecn":       "nw_ecn",
+	"icmpv4_type":  "icmp_type",
+	"icmpv4_code":  "icmp_code",
+	"tun_ipv4_src": "tun_src",
+	"tun_ipv4_dst": "tun_dst",
+	"tunnel_id":    "tun_id",
+}
+
+// flowProtocols are the Ethernet types and the IP protocols of the ovs-ofctl shorthands, the IP protocol is -1 if
+// the shorthand has none.
+var flowProtocols = map[string]struct {
+	ethType uint16
+	ipProto int
+}{
+	"ip":    {0x0800, -1},
+	"icmp":  {0x0800, 1},
+	"tcp":   {0x0800, 6},
+	"udp":   {0x0800, 17},
+	"sctp":  {0x0800, 132},
+	"ipv6":  {0x86dd, -1},
+	"icmp6": {0x86dd, 58},
+	"tcp6":  {0x86dd, 6},
+	"udp6":  {0x86dd, 17},
+	"sctp6": {0x86dd, 132},
+	"arp":   {0x0806, -1},
+	"mpls":  {0x8847, -1},
+	"mplsm": {0x8848, -1},
+}
+
+var flowFlags = map[string]uint16{
+	"send_flow_rem":    FF_SEND_FLOW_REM,
+	"check_overlap":    FF_CHECK_OVERLAP,
+	"reset_counts":     FF_RESET_COUNTS,
+	"no_packet_counts": FF_NO_PKT_COUNTS,
+	"no_byte_counts":   FF_NO_BYT_COUNTS,
+}
+
+var reservedPortNames = map[string]uint32{
+	"IN_PORT":    P_IN_PORT,
+	"TABLE":      P_TABLE,
+	"NORMAL":     P_NORMAL,
+	"FLOOD":      P_FLOOD,
+	"ALL":        P_ALL,
+	"CONTROLLER": P_CONTROLLER,
+	"LOCAL":      P_LOCAL,
+	"ANY":        P_ANY,
+	"NONE":       P_ANY,
+}
+
+// modFieldActions maps the ovs-ofctl mod_* actions to the fields they set.
+var modFieldActions = map[string]string{
+	"mod_dl_src": "dl_src",
+	"mod_dl_dst": "dl_dst",
+	"mod_nw_src": "nw_src",
+	"mod_nw_dst": "nw_dst",
+}
+
+// ParseFlow parses a flow in the syntax of ovs-ofctl add-flow, e.g.,
+// "table=0,priority=10,tcp,tp_dst=80,actions=resubmit(,1)", and returns the FlowMod to add it. The actions which
+// are not in an instruction, e.g., write_actions(...), are in one OFPIT_APPLY_ACTIONS instruction, and the
+// priority is 32768 if it is not set, the same as ovs-ofctl.
+func ParseFlow(flow string) (*FlowMod, error) {
+	args, err := splitFlowArgs(flow)
+	if err != nil {
+		return nil, err
+	}
+	f := NewFlowMod()
+	f.Priority = defaultFlowPriority
+	ipProto := -1
+	for i, arg := range args {
+		name, value, hasValue := cutFlowArg(arg, "=")
+		if name == "actions" {
+			instructions, err := parseInstructions(append([]string{value}, args[i+1:]...))
+			if err != nil {
+				return nil, err
+			}
+			f.Instructions = instructions
+			break
+		}
+		if err := parseFlowArg(f, name, value, hasValue, &ipProto); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// parseFlowArg parses an argument of the flow before the actions, i.e., a flow attribute, a flag, a protocol
+// shorthand or a match field. ipProto is the IP protocol of the match so far, which tp_src and tp_dst depend on.
+func parseFlowArg(f *FlowMod, name string, value string, hasValue bool, ipProto *int) error {
+	if !hasValue {
+		if flag, ok := flowFlags[name]; ok {
+			f.Flags |= flag
+			return nil
+		}
+		if proto, ok := flowProtocols[name]; ok {
+			f.Match.AddField(*NewEthTypeField(proto.ethType))
+			if proto.ipProto >= 0 {
+				f.Match.AddField(*NewIpProtoField(uint8(proto.ipProto)))
+				*ipProto = proto.ipProto
+			}
+			return nil
+		}
+		return fmt.Errorf("the flow argument %s has no value", name)
+	}
+	var err error
+	switch name {
+	case "cookie":
+		cookie, mask, hasMask := cutFlowArg(value, "/")
+		if f.Cookie, err = strconv.ParseUint(cookie, 0, 64); err == nil && hasMask {
+			f.CookieMask, err = strconv.ParseUint(mask, 0, 64)
+		}
+	case "table":
+		var table uint64
+		table, err = strconv.ParseUint(value, 0, 8)
+		f.TableId = uint8(table)
+	case "priority":
+		f.Priority, err = parseUint16(value)
+	case "idle_timeout":
+		f.IdleTimeout, err = parseUint16(value)
+	case "hard_timeout":
+		f.HardTimeout, err = parseUint16(value)
+	case "out_port":
+		f.OutPort, err = parsePort(value)
+	case "out_group":
+		var group uint64
+		group, err = strconv.ParseUint(value, 0, 32)
+		f.OutGroup = uint32(group)
+	default:
+		var field *MatchField
+		if field, err = parseFlowField(name, value, *ipProto); err != nil {
+			return err
+		}
+		if name := oxxFieldName(field); name == "OXM_OF_IP_PROTO" || name == "NXM_OF_IP_PROTO" {
+			b, _ := field.Value.MarshalBinary()
+			*ipProto = int(uintValue(b))
+		}
+		f.Match.AddField(*field)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %v", name, value, err)
+	}
+	return nil
+}
+
+// splitFlowArgs splits the arguments separated by the commas or the spaces outside of the parentheses.
+func splitFlowArgs(s string) ([]string, error) {
+	var args []string
+	depth, start := 0, 0
+	appendArg := func(end int) {
+		if arg := strings.TrimSpace(s[start:end]); arg != "" {
+			args = append(args, arg)
+		}
+		start = end + 1
+	}
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses in %q", s)
+			}
+		case ',', ' ', '\t':
+			if depth == 0 {
+				appendArg(i)
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses in %q", s)
+	}
+	appendArg(len(s))
+	return args, nil
+}
+
+// cutFlowArg slices s around the first sep, and reports whether sep is found.
+func cutFlowArg(s string, sep string) (before string, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+func parseUint16(s string) (uint16, error) {
+	v, err := strconv.ParseUint(s, 0, 16)
+	return uint16(v), err
+}
+
+// parsePort parses a port number or the name of a reserved port, e.g., LOCAL.
+func parsePort(s string) (uint32, error) {
+	if port, ok := reservedPortNames[strings.ToUpper(s)]; ok {
+		return port, nil
+	}
+	port, err := strconv.ParseUint(s, 0, 32)
+	return uint32(port), err
+}
+
+// parsePort16 parses a 16-bit port of the Nicira extensions, the reserved ports are in the range of 0xff00 and
+// 0xffff.
+func parsePort16(s string) (uint16, error) {
+	port, err := parsePort(s)
+	if err != nil {
+		return 0, err
+	}
+	if port >= 0xffffff00 {
+		return uint16(port), nil
+	}
+	if port > 0xffff {
+		return 0, fmt.Errorf("the port %d is out of the 16-bit range", port)
+	}
+	return uint16(port), nil
+}
+
+// flowFieldOXXName returns the OXM/NXM name of a match field in ovs-ofctl, e.g., NXM_NX_REG0 of reg0. The
+// OXM/NXM names are accepted as well. tp_src and tp_dst are resolved with the IP protocol.
+func flowFieldOXXName(name string, ipProto int) (string, error) {
+	if alias, ok := flowFieldAliases[name]; ok {
+		name = alias
+	}
+	if name == "tp_src" || name == "tp_dst" {
+		protocols := map[int]string{6: "tcp", 17: "udp", 132: "sctp"}
+		protocol, ok := protocols[ipProto]
+		if !ok {
+			return "", fmt.Errorf("the field %s requires the tcp, udp or sctp protocol", name)
+		}
+		name = protocol + name[2:]
+	}
+	if oxxName, ok := flowFieldOXXNames[name]; ok {
+		return oxxName, nil
+	}
+	if oxxName := strings.ToUpper(name); oxxFieldHeaderMap[oxxName] != nil {
+		return oxxName, nil
+	}
+	return "", fmt.Errorf("unknown field %s", name)
+}
+
+// parseFlowField parses a match field in the syntax of ovs-ofctl, e.g., nw_src=10.0.0.0/24.
+func parseFlowField(name string, value string, ipProto int) (*MatchField, error) {
+	oxxName, err := flowFieldOXXName(name, ipProto)
+	if err != nil {
+		return nil, err
+	}
+	format, ok := flowFieldFormats[oxxName]
+	if !ok {
+		format.format = fieldFormatHex
+	}
+	if strings.HasPrefix(oxxName, "NXM_NX_TUN_METADATA") {
+		return parseTunMetadataField(oxxName, value)
+	}
+	valueBytes, maskBytes, err := parseFieldValue(format.format, value, int(oxxFieldHeaderMap[oxxName].Length))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %v", name, value, err)
+	}
+	return newParsedMatchField(oxxName, valueBytes, maskBytes)
+}
+
+// newParsedMatchField returns the field with the value and the mask, which is nil if the field is not masked. The
+// value is decoded as the one received from the switch, e.g., Ipv4SrcField of nw_src, and it is kept in a
+// ByteArrayField if the field has no decoder.
+func newParsedMatchField(oxxName string, value []byte, mask []byte) (*MatchField, error) {
+	field, err := FindFieldHeaderByName(oxxName, mask != nil)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, 4, 8+len(value)+len(mask))
+	binary.BigEndian.PutUint32(data, field.MarshalHeader())
+	if field.ExperimenterID != 0 {
+		data = append(data, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(data[4:], field.ExperimenterID)
+	}
+	data = append(data, value...)
+	data = append(data, mask...)
+	decoded := new(MatchField)
+	if err := decoded.UnmarshalBinary(data); err == nil {
+		return decoded, nil
+	}
+	field.Value = &ByteArrayField{Data: value, Length: uint8(len(value))}
+	if mask != nil {
+		field.Mask = &ByteArrayField{Data: mask, Length: uint8(len(mask))}
+	}
+	return field, nil
+}
+
+// parseTunMetadataField parses a tun_metadata field, whose length is the length of the hex value.
+func parseTunMetadataField(oxxName string, value string) (*MatchField, error) {
+	idx, _ := strconv.Atoi(strings.TrimPrefix(oxxName, "NXM_NX_TUN_METADATA"))
+	valueStr, maskStr, hasMask := cutFlowArg(value, "/")
+	length := (len(strings.TrimPrefix(valueStr, "0x")) + 1) / 2
+	valueBytes, err := parseUintBytes(valueStr, length)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tun_metadata%d %q: %v", idx, value, err)
+	}
+	var maskBytes []byte
+	if hasMask {
+		if maskBytes, err = parseUintBytes(maskStr, length); err != nil {
+			return nil, fmt.Errorf("invalid tun_metadata%d %q: %v", idx, value, err)
+		}
+	}
+	return NewTunMetadataField(idx, valueBytes, maskBytes), nil
+}
+
+// parseFieldValue parses the value and the mask of a match field in the format, it is the inverse of
+// formatFieldValue. The mask is nil if the field is not masked.
+func parseFieldValue(format int, s string, length int) (value []byte, mask []byte, err error) {
+	valueStr, maskStr, hasMask := cutFlowArg(s, "/")
+	switch format {
+	case fieldFormatMAC:
+		if value, err = net.ParseMAC(valueStr); err != nil || !hasMask {
+			return
+		}
+		mask, err = net.ParseMAC(maskStr)
+		return
+	case fieldFormatIPv4, fieldFormatIPv6:
+		return parseIPValue(valueStr, maskStr, hasMask, length)
+	case fieldFormatPort:
+		if hasMask {
+			return nil, nil, fmt.Errorf("the port can't be masked")
+		}
+		var port uint32
+		if length == 2 {
+			var port16 uint16
+			port16, err = parsePort16(valueStr)
+			port = uint32(port16)
+		} else {
+			port, err = parsePort(valueStr)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		value, err = parseUintBytes(fmt.Sprint(port), length)
+		return
+	case fieldFormatCTState:
+		return parseCTState(s, length)
+	}
+	if value, err = parseUintBytes(valueStr, length); err != nil || !hasMask {
+		return
+	}
+	mask, err = parseUintBytes(maskStr, length)
+	return
+}
+
+// parseIPValue parses an IP address, the mask is a prefix length, e.g., 10.0.0.0/24, or an address.
+func parseIPValue(valueStr string, maskStr string, hasMask bool, length int) (value []byte, mask []byte, err error) {
+	parseIP := func(s string) ([]byte, error) {
+		ip := net.ParseIP(s)
+		if length == net.IPv4len {
+			ip = ip.To4()
+		}
+		if ip == nil || len(ip) != length {
+			return nil, fmt.Errorf("invalid IP address %s", s)
+		}
+		return ip, nil
+	}
+	if value, err = parseIP(valueStr); err != nil || !hasMask {
+		return
+	}
+	if ones, err := strconv.Atoi(maskStr); err == nil {
+		if mask = net.CIDRMask(ones, length*8); mask == nil {
+			return nil, nil, fmt.Errorf("invalid prefix length %d", ones)
+		}
+	} else if mask, err = parseIP(maskStr); err != nil {
+		return nil, nil, err
+	}
+	return net.IP(value).Mask(mask), mask, nil
+}
+
+// parseUintBytes parses a decimal or hex number into the big-endian bytes of the length.
+func parseUintBytes(s string, length int) ([]byte, error) {
+	v, ok := new(big.Int), false
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		_, ok = v.SetString(s[2:], 16)
+	} else {
+		_, ok = v.SetString(s, 10)
+	}
+	if !ok || v.Sign() < 0 {
+		return nil, fmt.Errorf("invalid number %s", s)
+	}
+	b := v.Bytes()
+	if len(b) > length {
+		return nil, fmt.Errorf("the number %s is out of the %d-byte range", s, length)
+	}
+	data := make([]byte, length)
+	copy(data[length-len(b):], b)
+	return data, nil
+}
+
+// parseCTState parses the conntrack states, e.g., +trk+est-rel, the states not in s are not matched.
+func parseCTState(s string, length int) (value []byte, mask []byte, err error) {
+	var state, stateMask uint64
+	for len(s) > 0 {
+		if s[0] != '+' && s[0] != '-' {
+			return nil, nil, fmt.Errorf("the state %s doesn't start with + or -", s)
+		}
+		end := strings.IndexAny(s[1:], "+-") + 1
+		if end == 0 {
+			end = len(s)
+		}
+		bit := -1
+		for i, name := range ctStateNames {
+			if name == s[1:end] {
+				bit = i
+			}
+		}
+		if bit < 0 {
+			return nil, nil, fmt.Errorf("unknown state %s", s[1:end])
+		}
+		stateMask |= 1 << uint(bit)
+		if s[0] == '+' {
+			state |= 1 << uint(bit)
+		}
+		s = s[end:]
+	}
+	value, mask = make([]byte, length), make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		value[i], mask[i] = byte(state), byte(stateMask)
+		state, stateMask = state>>8, stateMask>>8
+	}
+	return value, mask, nil
+}
+
+// parseFieldRange parses the bits of a field, e.g., NXM_NX_REG0[], NXM_NX_REG0[3] or reg0[0..15], and returns the
+// field header, the offset and the number of bits.
+func parseFieldRange(s string) (field *MatchField, ofs uint16, nBits uint16, err error) {
+	i := strings.Index(s, "[")
+	if i < 0 || !strings.HasSuffix(s, "]") {
+		return nil, 0, 0, fmt.Errorf("invalid field range %s", s)
+	}
+	oxxName, err := flowFieldOXXName(s[:i], -1)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if field, err = FindFieldHeaderByName(oxxName, false); err != nil {
+		return nil, 0, 0, err
+	}
+	size := uint64(oxxFieldHeaderMap[oxxName].Length) * 8
+	rng := s[i+1 : len(s)-1]
+	if rng == "" {
+		return field, 0, uint16(size), nil
+	}
+	startStr, endStr, isRange := cutFlowArg(rng, "..")
+	start, err := strconv.ParseUint(startStr, 10, 16)
+	end := start
+	if err == nil && isRange {
+		end, err = strconv.ParseUint(endStr, 10, 16)
+	}
+	if err != nil || end < start || end >= size {
+		return nil, 0, 0, fmt.Errorf("invalid field range %s", s)
+	}
+	return field, uint16(start), uint16(end - start + 1), nil
+}
+
+// parseInstructions parses the actions of a flow into the instructions in the order of the text, the actions not
+// in an instruction are in one OFPIT_APPLY_ACTIONS instruction.
+func parseInstructions(args []string) ([]Instruction, error) {
+	instructions := make([]Instruction, 0)
+	var apply *InstrActions
+	for _, arg := range args {
+		name, value, _ := splitAction(arg)
+		var err error
+		switch name {
+		case "drop":
+		case "goto_table":
+			var table uint64
+			if table, err = strconv.ParseUint(value, 0, 8); err == nil {
+				instructions = append(instructions, NewInstrGotoTable(uint8(table)))
+			}
+		case "write_metadata":
+			metadata, maskStr, hasMask := cutFlowArg(value, "/")
+			var v uint64
+			mask := ^uint64(0)
+			if v, err = strconv.ParseUint(metadata, 0, 64); err == nil && hasMask {
+				mask, err = strconv.ParseUint(maskStr, 0, 64)
+			}
+			instructions = append(instructions, NewInstrWriteMetadata(v, mask))
+		case "clear_actions":
+			instructions = append(instructions, NewInstrClearActions())
+		case "meter":
+			var meter uint64
+			if meter, err = strconv.ParseUint(value, 0, 32); err == nil {
+				instructions = append(instructions, NewInstrMeter(uint32(meter)))
+			}
+		case "write_actions":
+			instr := NewInstrWriteActions()
+			var actions []Action
+			if actions, err = parseActions(value); err == nil {
+				for _, act := range actions {
+					if err = instr.AddAction(act, false); err != nil {
+						break
+					}
+				}
+			}
+			instructions = append(instructions, instr)
+		default:
+			var act Action
+			if act, err = parseAction(arg); err != nil {
+				return nil, err
+			}
+			if apply == nil {
+				apply = NewInstrApplyActions()
+				instructions = append(instructions, apply)
+			}
+			err = apply.AddAction(act, false)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid instruction %s: %v", arg, err)
+		}
+	}
+	return instructions, nil
+}
+
+// splitAction splits an action into the name and the argument, e.g., output:2, ct(commit) and NORMAL.
+func splitAction(s string) (name string, arg string, paren bool) {
+	i := strings.IndexAny(s, ":(")
+	if i < 0 {
+		return s, "", false
+	}
+	if s[i] == '(' && strings.HasSuffix(s, ")") {
+		return s[:i], s[i+1 : len(s)-1], true
+	}
+	return s[:i], s[i+1:], false
+}
+
+// parseActions parses the actions separated by commas, e.g., the actions in write_actions(...).
+func parseActions(s string) ([]Action, error) {
+	args, err := splitFlowArgs(s)
+	if err != nil {
+		return nil, err
+	}
+	actions := make([]Action, 0, len(args))
+	for _, arg := range args {
+		act, err := parseAction(arg)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, act)
+	}
+	return actions, nil
+}
+
+// parseAction parses an action in the syntax of ovs-ofctl, it is the inverse of the String methods of the actions.
+func parseAction(s string) (Action, error) {
+	name, arg, paren := splitAction(s)
+	act, err := parseNamedAction(name, arg, paren)
+	if err != nil {
+		return nil, fmt.Errorf("invalid action %s: %v", s, err)
+	}
+	return act, nil
+}
+
+func parseNamedAction(name string, arg string, paren bool) (Action, error) {
+	if strings.EqualFold(name, "controller") {
+		if paren {
+			return parseControllerAction(arg)
+		}
+		act := NewActionOutput(P_CONTROLLER)
+		act.MaxLen = OFPCML_NO_BUFFER
+		if arg != "" {
+			maxLen, err := parseUint16(arg)
+			if err != nil {
+				return nil, err
+			}
+			act.MaxLen = maxLen
+		}
+		return act, nil
+	}
+	if port, ok := reservedPortNames[strings.ToUpper(name)]; ok && arg == "" {
+		return NewActionOutput(port), nil
+	}
+	if _, err := strconv.ParseUint(name, 10, 32); err == nil && arg == "" {
+		name, arg = "output", name
+	}
+	switch name {
+	case "output":
+		if paren {
+			return parseOutputTruncAction(arg)
+		}
+		if strings.Contains(arg, "[") {
+			field, ofs, nBits, err := parseFieldRange(arg)
+			if err != nil {
+				return nil, err
+			}
+			return NewOutputFromField(field, encodeOfsNbits(ofs, nBits)), nil
+		}
+		port, err := parsePort(arg)
+		if err != nil {
+			return nil, err
+		}
+		return NewActionOutput(port), nil
+	case "set_queue":
+		queue, err := strconv.ParseUint(arg, 0, 32)
+		return NewActionSetQueue(uint32(queue)), err
+	case "group":
+		group, err := strconv.ParseUint(arg, 0, 32)
+		return NewActionGroup(uint32(group)), err
+	case "dec_ttl":
+		if arg == "" {
+			return NewActionDecNwTtl(), nil
+		}
+		var ids []uint16
+		for _, idStr := range strings.Split(arg, ",") {
+			id, err := parseUint16(strings.TrimSpace(idStr))
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		return NewNXActionDecTTLCntIDs(uint16(len(ids)), ids...), nil
+	case "push_vlan", "push_mpls", "pop_mpls":
+		etherType, err := parseUint16(arg)
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "push_vlan":
+			return NewActionPushVlan(etherType), nil
+		case "push_mpls":
+			return NewActionPushMpls(etherType), nil
+		}
+		return NewActionPopMpls(etherType), nil
+	case "strip_vlan", "pop_vlan":
+		return NewActionPopVlan(), nil
+	case "set_field":
+		value, fieldName, ok := cutFlowArg(arg, "->")
+		if !ok {
+			return nil, fmt.Errorf("no field to set")
+		}
+		return parseSetFieldAction(fieldName, value)
+	case "mod_dl_src", "mod_dl_dst", "mod_nw_src", "mod_nw_dst":
+		return parseSetFieldAction(modFieldActions[name], arg)
+	case "load":
+		valueStr, dst, ok := cutFlowArg(arg, "->")
+		if !ok {
+			return nil, fmt.Errorf("no field to load")
+		}
+		value, err := strconv.ParseUint(valueStr, 0, 64)
+		if err != nil {
+			return nil, err
+		}
+		field, ofs, nBits, err := parseFieldRange(dst)
+		if err != nil {
+			return nil, err
+		}
+		return NewNXActionRegLoad(encodeOfsNbits(ofs, nBits), field, value), nil
+	case "move":
+		src, dst, ok := cutFlowArg(arg, "->")
+		if !ok {
+			return nil, fmt.Errorf("no field to move to")
+		}
+		srcField, srcOfs, nBits, err := parseFieldRange(src)
+		if err != nil {
+			return nil, err
+		}
+		dstField, dstOfs, dstNBits, err := parseFieldRange(dst)
+		if err != nil {
+			return nil, err
+		}
+		if nBits != dstNBits {
+			return nil, fmt.Errorf("the source has %d bits but the destination has %d bits", nBits, dstNBits)
+		}
+		return NewNXActionRegMove(nBits, srcOfs, dstOfs, srcField, dstField), nil
+	case "resubmit":
+		if paren {
+			return parseResubmitAction(arg)
+		}
+		port, err := parsePort16(arg)
+		return NewNXActionResubmit(port), err
+	case "ct":
+		return parseCTAction(arg)
+	case "conjunction":
+		idStr, clauses, _ := cutFlowArg(arg, ",")
+		clauseStr, nClauseStr, _ := cutFlowArg(clauses, "/")
+		id, err := strconv.ParseUint(idStr, 0, 32)
+		if err != nil {
+			return nil, err
+		}
+		clause, err := strconv.ParseUint(clauseStr, 10, 8)
+		if err != nil || clause == 0 {
+			return nil, fmt.Errorf("invalid clause %s", clauseStr)
+		}
+		nClause, err := strconv.ParseUint(nClauseStr, 10, 8)
+		if err != nil || nClause < clause {
+			return nil, fmt.Errorf("invalid number of clauses %s", nClauseStr)
+		}
+		// The clause is 1-based in the syntax of ovs-ofctl and 0-based in the action.
+		return NewNXActionConjunction(uint8(clause-1), uint8(nClause), uint32(id)), nil
+	case "note":
+		return parseNoteAction(arg)
+	case "fin_timeout":
+		return parseFinTimeoutAction(arg)
+	case "sample":
+		return parseSampleAction(arg)
+	case "multipath":
+		return parseMultipathAction(arg)
+	case "bundle", "bundle_load":
+		return parseBundleAction(name, arg)
+	case "ct_clear":
+		return NewNXActionCTClear(), nil
+	case "dec_nsh_ttl":
+		return NewNXActionDecNshTTL(), nil
+	case "exit":
+		return NewNXActionExit(), nil
+	}
+	return nil, fmt.Errorf("unsupported action %s", name)
+}
+
+// parseSetFieldAction returns the set_field action of the value, it is ActionSetField for an OXM field, and
+// NXActionRegLoad2 for an NXM field, e.g., ct_mark.
+func parseSetFieldAction(fieldName string, value string) (Action, error) {
+	field, err := parseFlowField(fieldName, value, -1)
+	if err != nil {
+		return nil, err
+	}
+	if field.Class == OXM_CLASS_OPENFLOW_BASIC {
+		act := NewActionSetField(*field)
+		return act, act.Validate()
+	}
+	return NewNXActionRegLoad2(field), nil
+}
+
+// parseResubmitAction parses the arguments of resubmit(port,table[,ct]), the port is OFPP_IN_PORT if it is empty,
+// and the table is 255 if it is empty.
+func parseResubmitAction(arg string) (Action, error) {
+	args := strings.Split(arg, ",")
+	if len(args) < 2 || len(args) > 3 || (len(args) == 3 && strings.TrimSpace(args[2]) != "ct") {
+		return nil, fmt.Errorf("invalid resubmit arguments %s", arg)
+	}
+	port, table := uint16(OFPP_IN_PORT), uint8(0xff)
+	if portStr := strings.TrimSpace(args[0]); portStr != "" {
+		var err error
+		if port, err = parsePort16(portStr); err != nil {
+			return nil, err
+		}
+	}
+	if tableStr := strings.TrimSpace(args[1]); tableStr != "" {
+		v, err := strconv.ParseUint(tableStr, 0, 8)
+		if err != nil {
+			return nil, err
+		}
+		table = uint8(v)
+	}
+	if len(args) == 3 {
+		return NewNXActionResubmitTableCT(port, table), nil
+	}
+	return NewNXActionResubmitTableAction(port, table), nil
+}
+
+// parseCTAction parses the arguments of ct(...), e.g., ct(commit,table=1,zone=NXM_NX_REG0[0..15],exec(...),nat).
+func parseCTAction(arg string) (Action, error) {
+	ct := NewNXActionConnTrack()
+	args, err := splitFlowArgs(arg)
+	if err != nil {
+		return nil, err
+	}
+	var nat Action
+	for _, a := range args {
+		name, value, _ := cutFlowArg(a, "=")
+		switch {
+		case name == "commit":
+			ct.Commit()
+		case name == "force":
+			ct.Force()
+		case name == "table":
+			table, err := strconv.ParseUint(value, 0, 8)
+			if err != nil {
+				return nil, err
+			}
+			ct.Table(uint8(table))
+		case name == "zone":
+			if strings.Contains(value, "[") {
+				field, ofs, nBits, err := parseFieldRange(value)
+				if err != nil {
+					return nil, err
+				}
+				ct.ZoneRange(field, NewNXRangeByOfsNBits(int(ofs), int(nBits)))
+				break
+			}
+			zone, err := parseUint16(value)
+			if err != nil {
+				return nil, err
+			}
+			ct.ZoneImm(zone)
+		case name == "alg":
+			if value == "ftp" {
+				ct.Alg = 21
+				break
+			}
+			alg, err := parseUint16(value)
+			if err != nil {
+				return nil, err
+			}
+			ct.Alg = alg
+		case strings.HasPrefix(a, "exec("):
+			_, execArg, _ := splitAction(a)
+			actions, err := parseActions(execArg)
+			if err != nil {
+				return nil, err
+			}
+			ct.AddAction(actions...)
+		case a == "nat" || strings.HasPrefix(a, "nat("):
+			_, natArg, _ := splitAction(a)
+			if nat, err = parseNATAction(natArg); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unknown ct argument %s", a)
+		}
+	}
+	// The nat action follows the actions of exec(...), the same as ovs-ofctl.
+	if nat != nil {
+		ct.AddAction(nat)
+	}
+	return ct, nil
+}
+
+// parseNATAction parses the arguments of nat(...), e.g., nat(src=10.0.0.1-10.0.0.2:1000-2000,random).
+func parseNATAction(arg string) (Action, error) {
+	nat := NewNXActionCTNAT()
+	args, err := splitFlowArgs(arg)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range args {
+		name, value, hasValue := cutFlowArg(a, "=")
+		switch name {
+		case "src":
+			err = nat.SetSNAT()
+		case "dst":
+			err = nat.SetDNAT()
+		case "persistent":
+			err = nat.SetPersistent()
+		case "hash":
+			err = nat.SetProtoHash()
+		case "random":
+			err = nat.SetRandom()
+		default:
+			err = fmt.Errorf("unknown nat argument %s", a)
+		}
+		if err == nil && hasValue {
+			err = parseNATRange(nat, value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nat, nil
+}
+
+// parseNATRange parses the address range and the port range of nat, e.g., 10.0.0.1-10.0.0.2:1000-2000 or
+// [fe80::1]-[fe80::2]:1000.
+func parseNATRange(nat *NXActionCTNAT, s string) error {
+	addrs, ports := s, ""
+	if i := strings.LastIndex(s, ":"); i >= 0 && (!strings.Contains(s, "[") || strings.HasSuffix(s[:i], "]")) {
+		addrs, ports = s[:i], s[i+1:]
+	}
+	minStr, maxStr, hasMax := cutFlowArg(addrs, "-")
+	parseIP := func(s string) (net.IP, error) {
+		ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"))
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %s", s)
+		}
+		return ip, nil
+	}
+	ipMin, err := parseIP(minStr)
+	if err != nil {
+		return err
+	}
+	ipMax := ipMin
+	if hasMax {
+		if ipMax, err = parseIP(maxStr); err != nil {
+			return err
+		}
+	}
+	if ipMin.To4() != nil {
+		nat.SetRangeIPv4Min(ipMin.To4())
+		if hasMax {
+			nat.SetRangeIPv4Max(ipMax.To4())
+		}
+	} else {
+		nat.SetRangeIPv6Min(ipMin)
+		if hasMax {
+			nat.SetRangeIPv6Max(ipMax)
+		}
+	}
+	if ports == "" {
+		return nil
+	}
+	minPortStr, maxPortStr, hasMaxPort := cutFlowArg(ports, "-")
+	minPort, err := parseUint16(minPortStr)
+	if err != nil {
+		return err
+	}
+	nat.SetRangeProtoMin(&minPort)
+	if hasMaxPort {
+		maxPort, err := parseUint16(maxPortStr)
+		if err != nil {
+			return err
+		}
+		nat.SetRangeProtoMax(&maxPort)
+	}
+	return nil
+}
+
+// parseControllerAction parses the arguments of controller(...), e.g., controller(reason=no_match,max_len=128,id=1).
+func parseControllerAction(arg string) (Action, error) {
+	act := NewNXActionController(0)
+	act.MaxLen = OFPCML_NO_BUFFER
+	act.Reason = R_ACTION
+	args, err := splitFlowArgs(arg)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range args {
+		name, value, _ := cutFlowArg(a, "=")
+		switch name {
+		case "reason":
+			reason, ok := uint8(0), false
+			for r, reasonName := range controllerReasonNames {
+				if reasonName == value {
+					reason, ok = r, true
+				}
+			}
+			if !ok {
+				v, err := strconv.ParseUint(value, 0, 8)
+				if err != nil {
+					return nil, fmt.Errorf("unknown reason %s", value)
+				}
+				reason = uint8(v)
+			}
+			act.Reason = reason
+		case "max_len":
+			if act.MaxLen, err = parseUint16(value); err != nil {
+				return nil, err
+			}
+		case "id":
+			if act.ControllerID, err = parseUint16(value); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unknown controller argument %s", a)
+		}
+	}
+	return act, nil
+}
+
+// parseNoteAction parses the hex bytes of note, which are optionally separated by periods, e.g., note:01.02.
+func parseNoteAction(arg string) (Action, error) {
+	note := NewNXActionNote()
+	hex := strings.Replace(arg, ".", "", -1)
+	if len(hex)%2 != 0 {
+		return nil, fmt.Errorf("odd number of hex digits in the note %s", arg)
+	}
+	for i := 0; i < len(hex); i += 2 {
+		b, err := strconv.ParseUint(hex[i:i+2], 16, 8)
+		if err != nil {
+			return nil, err
+		}
+		note.Note = append(note.Note, byte(b))
+	}
+	return note, nil
+}
+
+// parseFinTimeoutAction parses the arguments of fin_timeout(...), e.g., fin_timeout(idle_timeout=10,hard_timeout=30).
+func parseFinTimeoutAction(arg string) (Action, error) {
+	args, err := splitFlowArgs(arg)
+	if err != nil {
+		return nil, err
+	}
+	var idle, hard uint16
+	for _, a := range args {
+		name, value, _ := cutFlowArg(a, "=")
+		switch name {
+		case "idle_timeout":
+			idle, err = parseUint16(value)
+		case "hard_timeout":
+			hard, err = parseUint16(value)
+		default:
+			err = fmt.Errorf("unknown fin_timeout argument %s", a)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return NewNXActionFinTimeout(idle, hard), nil
+}
+
+// parseSampleAction parses the arguments of sample(...), e.g.,
+// sample(probability=65535,collector_set_id=1,obs_domain_id=2,obs_point_id=3,sampling_port=10,egress).
+func parseSampleAction(arg string) (Action, error) {
+	args, err := splitFlowArgs(arg)
+	if err != nil {
+		return nil, err
+	}
+	var probability, samplingPort uint16
+	var collectorSetID, obsDomainID, obsPointID uint64
+	var direction uint8
+	for _, a := range args {
+		name, value, _ := cutFlowArg(a, "=")
+		switch name {
+		case "probability":
+			probability, err = parseUint16(value)
+		case "collector_set_id":
+			collectorSetID, err = strconv.ParseUint(value, 0, 32)
+		case "obs_domain_id":
+			obsDomainID, err = strconv.ParseUint(value, 0, 32)
+		case "obs_point_id":
+			obsPointID, err = strconv.ParseUint(value, 0, 32)
+		case "sampling_port":
+			samplingPort, err = parsePort16(value)
+		case "ingress":
+			direction = NX_ACTION_SAMPLE_INGRESS
+		case "egress":
+			direction = NX_ACTION_SAMPLE_EGRESS
+		default:
+			err = fmt.Errorf("unknown sample argument %s", a)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if probability == 0 {
+		return nil, fmt.Errorf("invalid sample probability 0")
+	}
+	if samplingPort == 0 && direction == NX_ACTION_SAMPLE_DEFAULT {
+		return NewNXActionSample(probability, uint32(collectorSetID), uint32(obsDomainID), uint32(obsPointID)), nil
+	}
+	return NewNXActionSample2(probability, uint32(collectorSetID), uint32(obsDomainID), uint32(obsPointID), samplingPort, direction), nil
+}
+
+// parseOutputTruncAction parses the arguments of output(...), e.g., output(port=1,max_len=100).
+func parseOutputTruncAction(arg string) (Action, error) {
+	args, err := splitFlowArgs(arg)
+	if err != nil {
+		return nil, err
+	}
+	var port uint16
+	var maxLen uint64
+	for _, a := range args {
+		name, value, _ := cutFlowArg(a, "=")
+		switch name {
+		case "port":
+			port, err = parsePort16(value)
+		case "max_len":
+			maxLen, err = strconv.ParseUint(value, 0, 32)
+		default:
+			err = fmt.Errorf("unknown output argument %s", a)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return NewNXActionOutputTrunc(port, uint32(maxLen)), nil
+}
+
+// parseEnumName returns the value of the name in names, the name could be a number too.
+func parseEnumName(names map[uint16]string, s string) (uint16, error) {
+	for value, name := range names {
+		if name == s {
+			return value, nil
+		}
+	}
+	return parseUint16(s)
+}
+
+// parseMultipathAction parses the arguments of multipath(...), e.g.,
+// multipath(symmetric_l4,50,hrw,4,0,NXM_NX_REG0[0..1]).
+func parseMultipathAction(arg string) (Action, error) {
+	args := strings.Split(arg, ",")
+	if len(args) != 6 {
+		return nil, fmt.Errorf("multipath requires 6 arguments")
+	}
+	fields, err := parseEnumName(hashFieldsNames, args[0])
+	if err != nil {
+		return nil, err
+	}
+	basis, err := parseUint16(args[1])
+	if err != nil {
+		return nil, err
+	}
+	algorithm, err := parseEnumName(multipathAlgorithmNames, args[2])
+	if err != nil {
+		return nil, err
+	}
+	nLinks, err := parseUint16(args[3])
+	if err != nil || nLinks == 0 {
+		return nil, fmt.Errorf("invalid number of links %s", args[3])
+	}
+	mpArg, err := strconv.ParseUint(args[4], 0, 32)
+	if err != nil {
+		return nil, err
+	}
+	field, ofs, nBits, err := parseFieldRange(args[5])
+	if err != nil {
+		return nil, err
+	}
+	if nBits < 16 && nLinks > 1<<nBits {
+		return nil, fmt.Errorf("the %d bits of %s are too few for %d links", nBits, args[5], nLinks)
+	}
+	return NewNXActionMultipath(fields, basis, algorithm, nLinks-1, uint32(mpArg), encodeOfsNbits(ofs, nBits), field), nil
+}
+
+// parseBundleAction parses the arguments of bundle(...) and bundle_load(...), e.g.,
+// bundle(eth_src,0,hrw,ofport,members:1,2) and bundle_load(eth_src,0,hrw,ofport,NXM_NX_REG0[],members:1,2). The
+// slave ports could follow slaves: too.
+func parseBundleAction(name string, arg string) (Action, error) {
+	args := strings.Split(arg, ",")
+	nArgs := 5
+	if name == "bundle_load" {
+		nArgs = 6
+	}
+	if len(args) < nArgs {
+		return nil, fmt.Errorf("%s requires %d arguments", name, nArgs)
+	}
+	fields, err := parseEnumName(hashFieldsNames, args[0])
+	if err != nil {
+		return nil, err
+	}
+	basis, err := parseUint16(args[1])
+	if err != nil {
+		return nil, err
+	}
+	algorithm, err := parseEnumName(bundleAlgorithmNames, args[2])
+	if err != nil {
+		return nil, err
+	}
+	if args[3] != "ofport" {
+		return nil, fmt.Errorf("unsupported slave type %s", args[3])
+	}
+	slaveArgs := append([]string(nil), args[nArgs-1:]...)
+	listName, first, _ := cutFlowArg(slaveArgs[0], ":")
+	if listName != "members" && listName != "slaves" {
+		return nil, fmt.Errorf("invalid %s members %s", name, slaveArgs[0])
+	}
+	slaveArgs[0] = first
+	var slaves []uint16
+	for _, s := range slaveArgs {
+		if s == "" {
+			continue
+		}
+		slave, err := parsePort16(s)
+		if err != nil {
+			return nil, err
+		}
+		slaves = append(slaves, slave)
+	}
+	if name == "bundle" {
+		return NewNXActionBundle(algorithm, fields, basis, slaves...), nil
+	}
+	field, ofs, nBits, err := parseFieldRange(args[4])
+	if err != nil {
+		return nil, err
+	}
+	return NewNXActionBundleLoad(algorithm, fields, basis, encodeOfsNbits(ofs, nBits), field, slaves...), nil
+}
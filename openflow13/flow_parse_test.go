@@ -0,0 +1,96 @@
+package openflow13
+
+import (
+	"testing"
+)
+
+func TestParseFlow(t *testing.T) {
+	flow, err := ParseFlow("table=0,priority=10,tcp,tp_dst=80,actions=resubmit(,1)")
+	if err != nil {
+		t.Fatalf("Failed to parse flow: %v", err)
+	}
+	if flow.Command != FC_ADD || flow.TableId != 0 || flow.Priority != 10 {
+		t.Errorf("Unexpected flow: %+v", flow)
+	}
+	if len(flow.Match.Fields) != 3 {
+		t.Fatalf("Expect 3 match fields, actual: %d", len(flow.Match.Fields))
+	}
+	if port, ok := flow.Match.Fields[2].Value.(*PortField); !ok || port.port != 80 || flow.Match.Fields[2].Field != OXM_FIELD_TCP_DST {
+		t.Errorf("Unexpected tp_dst field: %+v", flow.Match.Fields[2])
+	}
+	if len(flow.Instructions) != 1 {
+		t.Fatalf("Expect 1 instruction, actual: %d", len(flow.Instructions))
+	}
+	instr, ok := flow.Instructions[0].(*InstrActions)
+	if !ok || instr.Type != InstrType_APPLY_ACTIONS || len(instr.Actions) != 1 {
+		t.Fatalf("Unexpected instruction: %+v", flow.Instructions[0])
+	}
+	if resubmit, ok := instr.Actions[0].(*NXActionResubmitTable); !ok || resubmit.InPort != OFPP_IN_PORT || resubmit.TableID != 1 {
+		t.Errorf("Unexpected action: %+v", instr.Actions[0])
+	}
+
+	// The flows are parsed back to the same strings, and the decoded flows have the same strings too.
+	for _, s := range []string{
+		"priority=100,ip,nw_src=10.0.0.0/24 actions=ct(commit),output:2",
+		"cookie=0x12,table=1,priority=200,send_flow_rem,tcp,in_port=LOCAL,tcp_dst=80,ct_state=-new+trk," +
+			"reg1=0x10/0xffff,dl_dst=aa:bb:cc:dd:ee:ff actions=load:0x5->NXM_NX_REG0[0..15]," +
+			"move:NXM_OF_ETH_SRC[]->NXM_OF_ETH_DST[],set_field:10.0.0.5->nw_dst,conjunction(7,2/2)," +
+			"ct(commit,table=3,zone=NXM_NX_REG0[0..15],exec(load:0x1->NXM_NX_CT_MARK[]),nat(dst=10.0.0.1-10.0.0.2:1000-2000,random))," +
+			"resubmit(,4),CONTROLLER:65535,goto_table:5",
+		"table=2,idle_timeout=10,hard_timeout=20,priority=32768,arp,arp_tpa=10.0.0.1,arp_op=1 actions=" +
+			"set_field:00:00:00:00:00:01->dl_src,set_field:0x1->ct_mark,write_actions(output:3),write_metadata:0x1/0xff",
+		"priority=5,ipv6,ipv6_src=fe80::/64 actions=dec_ttl,push_vlan:0x8100,group:1,note:01.02.03.04.05.06,NORMAL",
+		"priority=1 actions=controller(reason=no_match,max_len=128,id=1),fin_timeout(idle_timeout=10),resubmit:3",
+		"priority=1,udp,udp_src=53 actions=clear_actions,meter:1",
+		"priority=1 actions=sample(probability=65535,collector_set_id=1,obs_domain_id=2,obs_point_id=3),ct_clear," +
+			"sample(probability=100,collector_set_id=1,obs_domain_id=0,obs_point_id=0,sampling_port=2,ingress)," +
+			"dec_nsh_ttl,output(port=1,max_len=100)",
+		"priority=1 actions=multipath(symmetric_l4,50,hrw,4,0,NXM_NX_REG0[0..1]),bundle(eth_src,0,hrw,ofport,members:1,2)," +
+			"bundle_load(symmetric_l3l4+udp,10,active_backup,ofport,NXM_NX_REG1[],members:3),resubmit(,1),exit",
+		"priority=0 actions=drop",
+	} {
+		flow, err := ParseFlow(s)
+		if err != nil {
+			t.Fatalf("Failed to parse flow %s: %v", s, err)
+		}
+		if actual := flow.String(); actual != s {
+			t.Errorf("Expect flow:\n%s\nactual:\n%s", s, actual)
+		}
+		data, err := flow.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to marshal flow %s: %v", s, err)
+		}
+		decoded := new(FlowMod)
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatalf("Failed to unmarshal flow %s: %v", s, err)
+		}
+		if actual := decoded.String(); actual != s {
+			t.Errorf("Expect decoded flow:\n%s\nactual:\n%s", s, actual)
+		}
+	}
+
+	// The default priority, the aliases and the separators of ovs-ofctl.
+	flow, err = ParseFlow("udp, ip_dst=10.0.0.1, tp_src=68 actions=output:1")
+	if err != nil {
+		t.Fatalf("Failed to parse flow: %v", err)
+	}
+	if s := flow.String(); s != "priority=32768,udp,nw_dst=10.0.0.1,udp_src=68 actions=output:1" {
+		t.Errorf("Unexpected flow: %s", s)
+	}
+
+	for _, s := range []string{
+		"priority=1,tp_dst=80 actions=drop",
+		"priority=1,foo=1 actions=drop",
+		"priority=1,nw_src=10.0.0.300 actions=drop",
+		"priority=1,reg0=0x100000000 actions=drop",
+		"priority=1 actions=resubmit(,1",
+		"priority=1 actions=load:0x1->NXM_NX_REG0[0..32]",
+		"priority=1 actions=move:NXM_NX_REG0[0..15]->NXM_NX_REG1[]",
+		"priority=1 actions=set_field:0x1->metadata",
+		"priority=1 actions=learn(table=1)",
+	} {
+		if _, err := ParseFlow(s); err == nil {
+			t.Errorf("Expect an error to parse flow %s", s)
+		}
+	}
+}
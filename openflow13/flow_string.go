@@ -0,0 +1,986 @@
+package openflow13
+
+// This file has the String methods of the flow mods, matches, instructions and actions, which render them in the
+// syntax of ovs-ofctl, e.g., "priority=100,ip,nw_src=10.0.0.0/24 actions=ct(commit),output:2". The fields are
+// printed in the order of the Match rather than the canonical order of OVS, and the unknown fields are printed
+// with the OXM/NXM names or the class and field numbers.
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+)
+
+// The formats of the match field values.
+const (
+	fieldFormatHex = iota
+	fieldFormatDecimal
+	fieldFormatPort
+	fieldFormatMAC
+	fieldFormatIPv4
+	fieldFormatIPv6
+	fieldFormatCTState
+)
+
+// flowFieldFormat is the ovs-ofctl name and the value format of a match field.
+type flowFieldFormat struct {
+	name   string
+	format int
+}
+
+// flowFieldFormats maps the OXM/NXM names in oxxFieldHeaderMap to the ovs-ofctl names and value formats.
+var flowFieldFormats = map[string]flowFieldFormat{
+	"NXM_OF_IN_PORT":   {"in_port", fieldFormatPort},
+	"NXM_OF_ETH_DST":   {"dl_dst", fieldFormatMAC},
+	"NXM_OF_ETH_SRC":   {"dl_src", fieldFormatMAC},
+	"NXM_OF_ETH_TYPE":  {"dl_type", fieldFormatHex},
+	"NXM_OF_VLAN_TCI":  {"vlan_tci", fieldFormatHex},
+	"NXM_OF_IP_TOS":    {"nw_tos", fieldFormatDecimal},
+	"NXM_OF_IP_PROTO":  {"nw_proto", fieldFormatDecimal},
+	"NXM_OF_IP_SRC":    {"nw_src", fieldFormatIPv4},
+	"NXM_OF_IP_DST":    {"nw_dst", fieldFormatIPv4},
+	"NXM_OF_TCP_SRC":   {"tcp_src", fieldFormatDecimal},
+	"NXM_OF_TCP_DST":   {"tcp_dst", fieldFormatDecimal},
+	"NXM_OF_UDP_SRC":   {"udp_src", fieldFormatDecimal},
+	"NXM_OF_UDP_DST":   {"udp_dst", fieldFormatDecimal},
+	"NXM_OF_ICMP_TYPE": {"icmp_type", fieldFormatDecimal},
+	"NXM_OF_ICMP_CODE": {"icmp_code", fieldFormatDecimal},
+	"NXM_OF_ARP_OP":    {"arp_op", fieldFormatDecimal},
+	"NXM_OF_ARP_SPA":   {"arp_spa", fieldFormatIPv4},
+	"NXM_OF_ARP_TPA":   {"arp_tpa", fieldFormatIPv4},
+
+	"NXM_NX_TUN_ID":        {"tun_id", fieldFormatHex},
+	"NXM_NX_ARP_SHA":       {"arp_sha", fieldFormatMAC},
+	"NXM_NX_ARP_THA":       {"arp_tha", fieldFormatMAC},
+	"NXM_NX_IPV6_SRC":      {"ipv6_src", fieldFormatIPv6},
+	"NXM_NX_IPV6_DST":      {"ipv6_dst", fieldFormatIPv6},
+	"NXM_NX_ICMPV6_TYPE":   {"icmpv6_type", fieldFormatDecimal},
+	"NXM_NX_ICMPV6_CODE":   {"icmpv6_code", fieldFormatDecimal},
+	"NXM_NX_ND_TARGET":     {"nd_target", fieldFormatIPv6},
+	"NXM_NX_ND_SLL":        {"nd_sll", fieldFormatMAC},
+	"NXM_NX_ND_TLL":        {"nd_tll", fieldFormatMAC},
+	"NXM_NX_IP_FRAG":       {"ip_frag", fieldFormatHex},
+	"NXM_NX_IPV6_LABEL":    {"ipv6_label", fieldFormatHex},
+	"NXM_NX_IP_ECN":        {"nw_ecn", fieldFormatDecimal},
+	"NXM_NX_IP_TTL":        {"nw_ttl", fieldFormatDecimal},
+	"NXM_NX_MPLS_TTL":      {"mpls_ttl", fieldFormatDecimal},
+	"NXM_NX_TUN_IPV4_SRC":  {"tun_src", fieldFormatIPv4},
+	"NXM_NX_TUN_IPV4_DST":  {"tun_dst", fieldFormatIPv4},
+	"NXM_NX_PKT_MARK":      {"pkt_mark", fieldFormatHex},
+	"NXM_NX_TCP_FLAGS":     {"tcp_flags", fieldFormatHex},
+	"NXM_NX_CONJ_ID":       {"conj_id", fieldFormatDecimal},
+	"NXM_NX_TUN_GBP_ID":    {"tun_gbp_id", fieldFormatDecimal},
+	"NXM_NX_TUN_GBP_FLAGS": {"tun_gbp_flags", fieldFormatHex},
+	"NXM_NX_TUN_FLAGS":     {"tun_flags", fieldFormatHex},
+	"NXM_NX_CT_STATE":      {"ct_state", fieldFormatCTState},
+	"NXM_NX_CT_ZONE":       {"ct_zone", fieldFormatDecimal},
+	"NXM_NX_CT_MARK":       {"ct_mark", fieldFormatHex},
+	"NXM_NX_CT_LABEL":      {"ct_label", fieldFormatHex},
+	"NXM_NX_TUN_IPV6_SRC":  {"tun_ipv6_src", fieldFormatIPv6},
+	"NXM_NX_TUN_IPV6_DST":  {"tun_ipv6_dst", fieldFormatIPv6},
+	"NXM_NX_CT_NW_PROTO":   {"ct_nw_proto", fieldFormatDecimal},
+	"NXM_NX_CT_NW_SRC":     {"ct_nw_src", fieldFormatIPv4},
+	"NXM_NX_CT_NW_DST":     {"ct_nw_dst", fieldFormatIPv4},
+	"NXM_NX_CT_IPV6_SRC":   {"ct_ipv6_src", fieldFormatIPv6},
+	"NXM_NX_CT_IPV6_DST":   {"ct_ipv6_dst", fieldFormatIPv6},
+	"NXM_NX_CT_TP_SRC":     {"ct_tp_src", fieldFormatDecimal},
+	"NXM_NX_CT_TP_DST":     {"ct_tp_dst", fieldFormatDecimal},
+
+	"OXM_OF_IN_PORT":        {"in_port", fieldFormatPort},
+	"OXM_OF_IN_PHY_PORT":    {"in_phy_port", fieldFormatPort},
+	"OXM_OF_METADATA":       {"metadata", fieldFormatHex},
+	"OXM_OF_ETH_DST":        {"dl_dst", fieldFormatMAC},
+	"OXM_OF_ETH_SRC":        {"dl_src", fieldFormatMAC},
+	"OXM_OF_ETH_TYPE":       {"dl_type", fieldFormatHex},
+	"OXM_OF_VLAN_VID":       {"vlan_vid", fieldFormatDecimal},
+	"OXM_OF_VLAN_PCP":       {"dl_vlan_pcp", fieldFormatDecimal},
+	"OXM_OF_IP_DSCP":        {"ip_dscp", fieldFormatDecimal},
+	"OXM_OF_IP_ECN":         {"nw_ecn", fieldFormatDecimal},
+	"OXM_OF_IP_PROTO":       {"nw_proto", fieldFormatDecimal},
+	"OXM_OF_IPV4_SRC":       {"nw_src", fieldFormatIPv4},
+	"OXM_OF_IPV4_DST":       {"nw_dst", fieldFormatIPv4},
+	"OXM_OF_TCP_SRC":        {"tcp_src", fieldFormatDecimal},
+	"OXM_OF_TCP_DST":        {"tcp_dst", fieldFormatDecimal},
+	"OXM_OF_UDP_SRC":        {"udp_src", fieldFormatDecimal},
+	"OXM_OF_UDP_DST":        {"udp_dst", fieldFormatDecimal},
+	"OXM_OF_SCTP_SRC":       {"sctp_src", fieldFormatDecimal},
+	"OXM_OF_SCTP_DST":       {"sctp_dst", fieldFormatDecimal},
+	"OXM_OF_ICMPV4_TYPE":    {"icmp_type", fieldFormatDecimal},
+	"OXM_OF_ICMPV4_CODE":    {"icmp_code", fieldFormatDecimal},
+	"OXM_OF_ARP_OP":         {"arp_op", fieldFormatDecimal},
+	"OXM_OF_ARP_SPA":        {"arp_spa", fieldFormatIPv4},
+	"OXM_OF_ARP_TPA":        {"arp_tpa", fieldFormatIPv4},
+	"OXM_OF_ARP_SHA":        {"arp_sha", fieldFormatMAC},
+	"OXM_OF_ARP_THA":        {"arp_tha", fieldFormatMAC},
+	"OXM_OF_IPV6_SRC":       {"ipv6_src", fieldFormatIPv6},
+	"OXM_OF_IPV6_DST":       {"ipv6_dst", fieldFormatIPv6},
+	"OXM_OF_IPV6_FLABEL":    {"ipv6_label", fieldFormatHex},
+	"OXM_OF_ICMPV6_TYPE":    {"icmpv6_type", fieldFormatDecimal},
+	"OXM_OF_ICMPV6_CODE":    {"icmpv6_code", fieldFormatDecimal},
+	"OXM_OF_IPV6_ND_TARGET": {"nd_target", fieldFormatIPv6},
+	"OXM_OF_IPV6_ND_SLL":    {"nd_sll", fieldFormatMAC},
+	"OXM_OF_IPV6_ND_TLL":    {"nd_tll", fieldFormatMAC},
+	"OXM_OF_MPLS_LABEL":     {"mpls_label", fieldFormatDecimal},
+	"OXM_OF_MPLS_TC":        {"mpls_tc", fieldFormatDecimal},
+	"OXM_OF_MPLS_BOS":       {"mpls_bos", fieldFormatDecimal},
+	"OXM_OF_PBB_ISID":       {"pbb_isid", fieldFormatHex},
+	"OXM_OF_TUNNEL_ID":      {"tun_id", fieldFormatHex},
+	"OXM_OF_IPV6_EXTHDR":    {"ipv6_exthdr", fieldFormatHex},
+	"OXM_OF_TCP_FLAGS":      {"tcp_flags", fieldFormatHex},
+	"OXM_OF_ACTSET_OUTPUT":  {"actset_output", fieldFormatPort},
+
+	"NXOXM_ET_ERSPAN_IDX":  {"erspan_idx", fieldFormatHex},
+	"NXOXM_ET_ERSPAN_VER":  {"erspan_ver", fieldFormatDecimal},
+	"NXOXM_ET_ERSPAN_DIR":  {"erspan_dir", fieldFormatDecimal},
+	"NXOXM_ET_ERSPAN_HWID": {"erspan_hwid", fieldFormatHex},
+
+	"NXOXM_NSH_FLAGS":  {"nsh_flags", fieldFormatDecimal},
+	"NXOXM_NSH_MDTYPE": {"nsh_mdtype", fieldFormatDecimal},
+	"NXOXM_NSH_NP":     {"nsh_np", fieldFormatDecimal},
+	"NXOXM_NSH_SPI":    {"nsh_spi", fieldFormatHex},
+	"NXOXM_NSH_SI":     {"nsh_si", fieldFormatDecimal},
+	"NXOXM_NSH_C1":     {"nsh_c1", fieldFormatHex},
+	"NXOXM_NSH_C2":     {"nsh_c2", fieldFormatHex},
+	"NXOXM_NSH_C3":     {"nsh_c3", fieldFormatHex},
+	"NXOXM_NSH_C4":     {"nsh_c4", fieldFormatHex},
+	"NXOXM_NSH_TTL":    {"nsh_ttl", fieldFormatDecimal},
+}
+
+// oxxFieldKey identifies a field in oxxFieldHeaderMap.
+type oxxFieldKey struct {
+	class          uint16
+	field          uint8
+	experimenterID uint32
+}
+
+// oxxFieldNames maps the fields in oxxFieldHeaderMap to their OXM/NXM names.
+var oxxFieldNames = make(map[oxxFieldKey]string)
+
+// flowFieldOXXNames maps the ovs-ofctl names in flowFieldFormats back to the OXM/NXM names for ParseFlow, the OXM
+// fields are preferred over the NXM fields of the same name, e.g., in_port is OXM_OF_IN_PORT.
+var flowFieldOXXNames = make(map[string]string)
+
+func init() {
+	for i := 0; i < 16; i++ {
+		flowFieldFormats[fmt.Sprintf("NXM_NX_REG%d", i)] = flowFieldFormat{fmt.Sprintf("reg%d", i), fieldFormatHex}
+	}
+	for i := 0; i < 4; i++ {
+		flowFieldFormats[fmt.Sprintf("NXM_NX_XXREG%d", i)] = flowFieldFormat{fmt.Sprintf("xxreg%d", i), fieldFormatHex}
+	}
+	// Only tun_metadata0-7 have the constants, the others up to tun_metadata63 follow them.
+	for i := 0; i < NXM_NX_TUN_METADATA_NUM; i++ {
+		name := fmt.Sprintf("NXM_NX_TUN_METADATA%d", i)
+		if oxxFieldHeaderMap[name] == nil {
+			oxxFieldHeaderMap[name] = newMatchFieldHeader(OXM_CLASS_NXM_1, uint8(NXM_NX_TUN_METADATA0+i), 128)
+		}
+		flowFieldFormats[name] = flowFieldFormat{fmt.Sprintf("tun_metadata%d", i), fieldFormatHex}
+	}
+	for name, field := range oxxFieldHeaderMap {
+		oxxFieldNames[oxxFieldKey{field.Class, field.Field, field.ExperimenterID}] = name
+	}
+	for oxxName, format := range flowFieldFormats {
+		if name, ok := flowFieldOXXNames[format.name]; ok && strings.HasPrefix(name, "OXM_OF_") {
+			continue
+		}
+		flowFieldOXXNames[format.name] = oxxName
+	}
+}
+
+// oxxFieldName returns the OXM/NXM name of the field, e.g., NXM_NX_REG0. The registered field takes precedence
+// over the known field, as it does in the decoding.
+func oxxFieldName(field *MatchField) string {
+	if info := lookupOXMField(field.Class, field.Field, field.ExperimenterID); info != nil {
+		return info.name
+	}
+	if name, ok := oxxFieldNames[oxxFieldKey{field.Class, field.Field, field.ExperimenterID}]; ok {
+		return name
+	}
+	if field.ExperimenterID != 0 {
+		return fmt.Sprintf("field(class=0x%x,field=%d,experimenter=0x%x)", field.Class, field.Field, field.ExperimenterID)
+	}
+	return fmt.Sprintf("field(class=0x%x,field=%d)", field.Class, field.Field)
+}
+
+// oxxFieldRange returns the bits of the field in the syntax of ovs-ofctl, e.g., NXM_NX_REG0[] for the whole field,
+// NXM_NX_REG0[3] for a bit and NXM_NX_REG0[0..15] for a range.
+func oxxFieldRange(field *MatchField, ofs uint16, nBits uint16) string {
+	name := oxxFieldName(field)
+	if header, ok := oxxFieldHeaderMap[name]; ok && ofs == 0 && int(nBits) == int(header.Length)*8 {
+		return name + "[]"
+	}
+	if nBits == 1 {
+		return fmt.Sprintf("%s[%d]", name, ofs)
+	}
+	return fmt.Sprintf("%s[%d..%d]", name, ofs, ofs+nBits-1)
+}
+
+// portString returns the name of a reserved port, e.g., LOCAL, or the port number.
+func portString(port uint32) string {
+	switch port {
+	case P_IN_PORT:
+		return "IN_PORT"
+	case P_TABLE:
+		return "TABLE"
+	case P_NORMAL:
+		return "NORMAL"
+	case P_FLOOD:
+		return "FLOOD"
+	case P_ALL:
+		return "ALL"
+	case P_CONTROLLER:
+		return "CONTROLLER"
+	case P_LOCAL:
+		return "LOCAL"
+	case P_ANY:
+		return "ANY"
+	}
+	return fmt.Sprint(port)
+}
+
+// port16String returns the name of a 16-bit port of the Nicira extensions, the reserved ports are in the range of
+// 0xff00 and 0xffff.
+func port16String(port uint16) string {
+	if port >= 0xff00 {
+		return portString(0xffff0000 | uint32(port))
+	}
+	return fmt.Sprint(port)
+}
+
+func hexString(b []byte) string {
+	return "0x" + new(big.Int).SetBytes(b).Text(16)
+}
+
+func uintValue(b []byte) (v uint64) {
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return
+}
+
+var ctStateNames = []string{"new", "est", "rel", "rpl", "inv", "trk", "snat", "dnat"}
+
+// formatFieldValue formats the value and the mask of a match field, the mask is nil if the field is not masked.
+func formatFieldValue(format int, value []byte, mask []byte) string {
+	switch format {
+	case fieldFormatMAC:
+		if mask != nil {
+			return net.HardwareAddr(value).String() + "/" + net.HardwareAddr(mask).String()
+		}
+		return net.HardwareAddr(value).String()
+	case fieldFormatIPv4, fieldFormatIPv6:
+		if mask == nil {
+			return net.IP(value).String()
+		}
+		if ones, bits := net.IPMask(mask).Size(); bits != 0 {
+			return fmt.Sprintf("%s/%d", net.IP(value), ones)
+		}
+		return net.IP(value).String() + "/" + net.IP(mask).String()
+	case fieldFormatPort:
+		if len(value) == 2 {
+			return port16String(uint16(uintValue(value)))
+		}
+		return portString(uint32(uintValue(value)))
+	case fieldFormatCTState:
+		state, stateMask := uintValue(value), uint64(0xff)
+		if mask != nil {
+			stateMask = uintValue(mask)
+		}
+		var s string
+		for i, name := range ctStateNames {
+			if stateMask&(1<<uint(i)) == 0 {
+				continue
+			}
+			if state&(1<<uint(i)) != 0 {
+				s += "+" + name
+			} else {
+				s += "-" + name
+			}
+		}
+		return s
+	case fieldFormatDecimal:
+		if mask == nil && len(value) <= 8 {
+			return fmt.Sprint(uintValue(value))
+		}
+	}
+	if mask != nil {
+		return hexString(value) + "/" + hexString(mask)
+	}
+	return hexString(value)
+}
+
+// formatValue returns the ovs-ofctl name and the formatted value of the field.
+func (m *MatchField) formatValue() (name string, value string) {
+	name = oxxFieldName(m)
+	format, ok := flowFieldFormats[name]
+	if !ok {
+		format = flowFieldFormat{name, fieldFormatHex}
+	}
+	var valueBytes, maskBytes []byte
+	if m.Value != nil {
+		valueBytes, _ = m.Value.MarshalBinary()
+	}
+	if m.HasMask && m.Mask != nil {
+		maskBytes, _ = m.Mask.MarshalBinary()
+	}
+	return format.name, formatFieldValue(format.format, valueBytes, maskBytes)
+}
+
+// String returns the field in the syntax of ovs-ofctl, e.g., "nw_src=10.0.0.0/24".
+func (m *MatchField) String() string {
+	name, value := m.formatValue()
+	return name + "=" + value
+}
+
+func isExactField(m *MatchField, names ...string) bool {
+	if m.HasMask {
+		return false
+	}
+	name := oxxFieldName(m)
+	for _, n := range names {
+		if name == n {
+			return true
+		}
+	}
+	return false
+}
+
+// protocolShorthand returns the ovs-ofctl shorthand of the Ethernet type and the IP protocol, e.g., "tcp", and if
+// the IP protocol is covered by the shorthand.
+func protocolShorthand(ethType uint64, ipProto uint64, hasIPProto bool) (string, bool) {
+	var protocols map[uint64]string
+	var name string
+	switch ethType {
+	case 0x0800:
+		name, protocols = "ip", map[uint64]string{1: "icmp", 6: "tcp", 17: "udp", 132: "sctp"}
+	case 0x86dd:
+		name, protocols = "ipv6", map[uint64]string{58: "icmp6", 6: "tcp6", 17: "udp6", 132: "sctp6"}
+	case 0x0806:
+		return "arp", false
+	case 0x8847:
+		return "mpls", false
+	case 0x8848:
+		return "mplsm", false
+	default:
+		return "", false
+	}
+	if proto, ok := protocols[ipProto]; ok && hasIPProto {
+		return proto, true
+	}
+	return name, false
+}
+
+// String returns the match in the syntax of ovs-ofctl, e.g., "tcp,nw_src=10.0.0.0/24,tcp_dst=80". The Ethernet
+// type and the IP protocol are printed as the shorthand, e.g., "ip" and "tcp", if possible.
+func (m *Match) String() string {
+	ethType, ipProto := -1, -1
+	var ethTypeValue, ipProtoValue uint64
+	for i := range m.Fields {
+		field := &m.Fields[i]
+		if field.Value == nil {
+			continue
+		}
+		b, _ := field.Value.MarshalBinary()
+		if ethType < 0 && isExactField(field, "OXM_OF_ETH_TYPE", "NXM_OF_ETH_TYPE") {
+			ethType, ethTypeValue = i, uintValue(b)
+		} else if ipProto < 0 && isExactField(field, "OXM_OF_IP_PROTO", "NXM_OF_IP_PROTO") {
+			ipProto, ipProtoValue = i, uintValue(b)
+		}
+	}
+	var fields []string
+	shorthand, withProto := protocolShorthand(ethTypeValue, ipProtoValue, ipProto >= 0)
+	if ethType >= 0 && shorthand != "" {
+		fields = append(fields, shorthand)
+	}
+	for i := range m.Fields {
+		if (i == ethType && shorthand != "") || (i == ipProto && withProto) {
+			continue
+		}
+		fields = append(fields, m.Fields[i].String())
+	}
+	return strings.Join(fields, ",")
+}
+
+// actionsString returns the actions separated by commas.
+func actionsString(actions []Action) string {
+	strs := make([]string, 0, len(actions))
+	for _, act := range actions {
+		if s := fmt.Sprint(act); s != "" {
+			strs = append(strs, s)
+		}
+	}
+	return strings.Join(strs, ",")
+}
+
+// String returns the flow mod in the syntax of ovs-ofctl add-flow, e.g.,
+// "table=1,priority=100,ip,nw_src=10.0.0.0/24 actions=ct(commit),output:2". The actions are omitted for the
+// delete commands, and the instructions without actions are printed as "drop".
+func (f *FlowMod) String() string {
+	var parts []string
+	if f.Cookie != 0 || f.CookieMask != 0 {
+		cookie := fmt.Sprintf("cookie=0x%x", f.Cookie)
+		if f.CookieMask != 0 && f.CookieMask != ^uint64(0) {
+			cookie += fmt.Sprintf("/0x%x", f.CookieMask)
+		}
+		parts = append(parts, cookie)
+	}
+	if f.TableId != 0 {
+		parts = append(parts, fmt.Sprintf("table=%d", f.TableId))
+	}
+	if f.IdleTimeout != 0 {
+		parts = append(parts, fmt.Sprintf("idle_timeout=%d", f.IdleTimeout))
+	}
+	if f.HardTimeout != 0 {
+		parts = append(parts, fmt.Sprintf("hard_timeout=%d", f.HardTimeout))
+	}
+	parts = append(parts, fmt.Sprintf("priority=%d", f.Priority))
+	for _, flag := range []struct {
+		flag uint16
+		name string
+	}{
+		{FF_SEND_FLOW_REM, "send_flow_rem"},
+		{FF_CHECK_OVERLAP, "check_overlap"},
+		{FF_RESET_COUNTS, "reset_counts"},
+		{FF_NO_PKT_COUNTS, "no_packet_counts"},
+		{FF_NO_BYT_COUNTS, "no_byte_counts"},
+	} {
+		if f.Flags&flag.flag != 0 {
+			parts = append(parts, flag.name)
+		}
+	}
+	if match := f.Match.String(); match != "" {
+		parts = append(parts, match)
+	}
+	s := strings.Join(parts, ",")
+	if f.Command == FC_DELETE || f.Command == FC_DELETE_STRICT {
+		return s
+	}
+	strs := make([]string, 0, len(f.Instructions))
+	for _, instr := range f.Instructions {
+		if instrStr := fmt.Sprint(instr); instrStr != "" {
+			strs = append(strs, instrStr)
+		}
+	}
+	if len(strs) == 0 {
+		return s + " actions=drop"
+	}
+	return s + " actions=" + strings.Join(strs, ",")
+}
+
+// String returns the flow filter of the request in the syntax of ovs-ofctl.
+func (a *AggregateStatsRequest) String() string {
+	parts := []string{fmt.Sprintf("table=%d", a.TableId)}
+	if a.CookieMask != 0 {
+		parts = append(parts, fmt.Sprintf("cookie=0x%x/0x%x", a.Cookie, a.CookieMask))
+	}
+	if a.OutPort != P_ANY {
+		parts = append(parts, "out_port="+portString(a.OutPort))
+	}
+	if a.OutGroup != OFPG_ANY {
+		parts = append(parts, fmt.Sprintf("out_group=%d", a.OutGroup))
+	}
+	if match := a.Match.String(); match != "" {
+		parts = append(parts, match)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (instr *InstrHeader) String() string {
+	if instr.Type == InstrType_CLEAR_ACTIONS {
+		return "clear_actions"
+	}
+	return fmt.Sprintf("instruction(type=%d)", instr.Type)
+}
+
+func (instr *InstrGotoTable) String() string {
+	return fmt.Sprintf("goto_table:%d", instr.TableId)
+}
+
+func (instr *InstrWriteMetadata) String() string {
+	if instr.MetadataMask == ^uint64(0) {
+		return fmt.Sprintf("write_metadata:0x%x", instr.Metadata)
+	}
+	return fmt.Sprintf("write_metadata:0x%x/0x%x", instr.Metadata, instr.MetadataMask)
+}
+
+// String returns the actions of OFPIT_APPLY_ACTIONS, write_actions(...) of OFPIT_WRITE_ACTIONS, or clear_actions
+// of OFPIT_CLEAR_ACTIONS.
+func (instr *InstrActions) String() string {
+	switch instr.Type {
+	case InstrType_WRITE_ACTIONS:
+		return "write_actions(" + actionsString(instr.Actions) + ")"
+	case InstrType_CLEAR_ACTIONS:
+		return "clear_actions"
+	}
+	return actionsString(instr.Actions)
+}
+
+func (instr *InstrMeter) String() string {
+	return fmt.Sprintf("meter:%d", instr.MeterId)
+}
+
+func (instr *InstrExperimenter) String() string {
+	return fmt.Sprintf("experimenter(0x%x,0x%x)", instr.Experimenter, instr.Data)
+}
+
+// String returns the actions without a body, e.g., copy_ttl_in.
+func (a *ActionHeader) String() string {
+	switch a.Type {
+	case ActionType_CopyTtlOut:
+		return "copy_ttl_out"
+	case ActionType_CopyTtlIn:
+		return "copy_ttl_in"
+	case ActionType_DecMplsTtl:
+		return "dec_mpls_ttl"
+	case ActionType_PopPbb:
+		return "pop_pbb"
+	}
+	return fmt.Sprintf("action(type=%d)", a.Type)
+}
+
+func (a *ActionOutput) String() string {
+	switch a.Port {
+	case P_CONTROLLER:
+		return fmt.Sprintf("CONTROLLER:%d", a.MaxLen)
+	case P_IN_PORT, P_TABLE, P_NORMAL, P_FLOOD, P_ALL, P_LOCAL:
+		return portString(a.Port)
+	}
+	return "output:" + portString(a.Port)
+}
+
+func (a *ActionSetqueue) String() string {
+	return fmt.Sprintf("set_queue:%d", a.QueueId)
+}
+
+func (a *ActionGroup) String() string {
+	return fmt.Sprintf("group:%d", a.GroupId)
+}
+
+func (a *ActionMplsTtl) String() string {
+	return fmt.Sprintf("set_mpls_ttl(%d)", a.MplsTtl)
+}
+
+func (a *ActionDecNwTtl) String() string {
+	return "dec_ttl"
+}
+
+func (a *ActionNwTtl) String() string {
+	return fmt.Sprintf("mod_nw_ttl:%d", a.NwTtl)
+}
+
+func (a *ActionPush) String() string {
+	switch a.Type {
+	case ActionType_PushMpls:
+		return fmt.Sprintf("push_mpls:0x%04x", a.EtherType)
+	case ActionType_PushPbb:
+		return fmt.Sprintf("push_pbb:0x%04x", a.EtherType)
+	}
+	return fmt.Sprintf("push_vlan:0x%04x", a.EtherType)
+}
+
+func (a *ActionPopVlan) String() string {
+	return "strip_vlan"
+}
+
+func (a *ActionPopMpls) String() string {
+	return fmt.Sprintf("pop_mpls:0x%04x", a.EtherType)
+}
+
+func setFieldString(field *MatchField) string {
+	name, value := field.formatValue()
+	return "set_field:" + value + "->" + name
+}
+
+func (a *ActionSetField) String() string {
+	return setFieldString(&a.Field)
+}
+
+// String returns the Nicira extension actions without a body, e.g., ct_clear.
+func (a *NXActionHeader) String() string {
+	switch a.Subtype {
+	case NXAST_CT_CLEAR:
+		return "ct_clear"
+	case NXAST_EXIT:
+		return "exit"
+	case NXAST_DEC_NSH_TTL:
+		return "dec_nsh_ttl"
+	}
+	return fmt.Sprintf("experimenter(vendor=0x%x,subtype=%d)", a.Vendor, a.Subtype)
+}
+
+func (a *NXActionConjunction) String() string {
+	// The clause is 0-based in the action and 1-based in the syntax of ovs-ofctl.
+	return fmt.Sprintf("conjunction(%d,%d/%d)", a.ID, a.Clause+1, a.NClause)
+}
+
+func (a *NXActionConnTrack) String() string {
+	var args []string
+	if a.Flags&NX_CT_F_COMMIT != 0 {
+		args = append(args, "commit")
+	}
+	if a.Flags&NX_CT_F_FORCE != 0 {
+		args = append(args, "force")
+	}
+	if a.RecircTable != NX_CT_RECIRC_NONE {
+		args = append(args, fmt.Sprintf("table=%d", a.RecircTable))
+	}
+	if a.ZoneSrc != 0 {
+		field := new(MatchField)
+		field.Class, field.Field, field.HasMask, field.Length = UnpackOXMHeader(a.ZoneSrc)
+		args = append(args, "zone="+oxxFieldRange(field, decodeOfs(a.ZoneOfsNbits), decodeNbits(a.ZoneOfsNbits)))
+	} else if a.ZoneOfsNbits != 0 {
+		args = append(args, fmt.Sprintf("zone=%d", a.ZoneOfsNbits))
+	}
+	var exec []Action
+	var nat string
+	for _, act := range a.actions {
+		if natAct, ok := act.(*NXActionCTNAT); ok {
+			nat = natAct.String()
+		} else {
+			exec = append(exec, act)
+		}
+	}
+	if len(exec) > 0 {
+		args = append(args, "exec("+actionsString(exec)+")")
+	}
+	if a.Alg == 21 {
+		args = append(args, "alg=ftp")
+	} else if a.Alg != 0 {
+		args = append(args, fmt.Sprintf("alg=%d", a.Alg))
+	}
+	if nat != "" {
+		args = append(args, nat)
+	}
+	if len(args) == 0 {
+		return "ct"
+	}
+	return "ct(" + strings.Join(args, ",") + ")"
+}
+
+func natIPString(ip net.IP) string {
+	if ip.To4() == nil {
+		return "[" + ip.String() + "]"
+	}
+	return ip.String()
+}
+
+// String returns the nat action, e.g., nat(src=10.0.0.1-10.0.0.2:1000-2000,random).
+func (a *NXActionCTNAT) String() string {
+	var args []string
+	var target string
+	if a.Flags&NX_NAT_F_SRC != 0 {
+		target = "src"
+	} else if a.Flags&NX_NAT_F_DST != 0 {
+		target = "dst"
+	}
+	if target != "" {
+		ipMin, ipMax := a.rangeIPv4Min, a.rangeIPv4Max
+		if ipMin == nil {
+			ipMin, ipMax = a.rangeIPv6Min, a.rangeIPv6Max
+		}
+		var addr string
+		if ipMin != nil {
+			addr = natIPString(ipMin)
+			if ipMax != nil && !ipMax.Equal(ipMin) {
+				addr += "-" + natIPString(ipMax)
+			}
+		}
+		if a.rangeProtoMin != nil {
+			addr += fmt.Sprintf(":%d", *a.rangeProtoMin)
+			if a.rangeProtoMax != nil && *a.rangeProtoMax != *a.rangeProtoMin {
+				addr += fmt.Sprintf("-%d", *a.rangeProtoMax)
+			}
+		}
+		if addr != "" {
+			target += "=" + addr
+		}
+		args = append(args, target)
+	}
+	if a.Flags&NX_NAT_F_PERSISTENT != 0 {
+		args = append(args, "persistent")
+	}
+	if a.Flags&NX_NAT_F_PROTO_HASH != 0 {
+		args = append(args, "hash")
+	}
+	if a.Flags&NX_NAT_F_PROTO_RANDOM != 0 {
+		args = append(args, "random")
+	}
+	if len(args) == 0 {
+		return "nat"
+	}
+	return "nat(" + strings.Join(args, ",") + ")"
+}
+
+func (a *NXActionRegLoad) String() string {
+	return fmt.Sprintf("load:0x%x->%s", a.Value, oxxFieldRange(a.DstReg, decodeOfs(a.OfsNbits), decodeNbits(a.OfsNbits)))
+}
+
+// String returns the copy-field action as ovs-ofctl, which shows it as the move action.
+func (a *ActionCopyField) String() string {
+	return fmt.Sprintf("move:%s->%s", oxxFieldRange(&a.SrcField, a.SrcOffset, a.NBits), oxxFieldRange(&a.DstField, a.DstOffset, a.NBits))
+}
+
+func (a *NXActionRegMove) String() string {
+	return fmt.Sprintf("move:%s->%s", oxxFieldRange(a.SrcField, a.SrcOfs, a.Nbits), oxxFieldRange(a.DstField, a.DstOfs, a.Nbits))
+}
+
+func (a *NXActionResubmit) String() string {
+	return "resubmit:" + port16String(a.InPort)
+}
+
+// String returns the resubmit action, e.g., resubmit(,1), the port is omitted if it is OFPP_IN_PORT.
+func (a *NXActionResubmitTable) String() string {
+	var port, table string
+	if a.InPort != OFPP_IN_PORT {
+		port = port16String(a.InPort)
+	}
+	if a.TableID != 0xff {
+		table = fmt.Sprint(a.TableID)
+	}
+	if a.withCT {
+		return fmt.Sprintf("resubmit(%s,%s,ct)", port, table)
+	}
+	return fmt.Sprintf("resubmit(%s,%s)", port, table)
+}
+
+func (a *NXActionOutputReg) String() string {
+	return "output:" + oxxFieldRange(a.SrcField, decodeOfs(a.OfsNbits), decodeNbits(a.OfsNbits))
+}
+
+func (a *NXActionDecTTL) String() string {
+	return "dec_ttl"
+}
+
+func (a *NXActionDecTTLCntIDs) String() string {
+	ids := make([]string, len(a.cntIDs))
+	for i, id := range a.cntIDs {
+		ids[i] = fmt.Sprint(id)
+	}
+	return "dec_ttl(" + strings.Join(ids, ",") + ")"
+}
+
+func (a *NXActionNote) String() string {
+	bytes := make([]string, len(a.Note))
+	for i, b := range a.Note {
+		bytes[i] = fmt.Sprintf("%02x", b)
+	}
+	return "note:" + strings.Join(bytes, ".")
+}
+
+func (a *NXActionRegLoad2) String() string {
+	return setFieldString(a.DstField)
+}
+
+var controllerReasonNames = map[uint8]string{R_NO_MATCH: "no_match", R_ACTION: "action", R_INVALID_TTL: "invalid_ttl"}
+
+// String returns the controller action, which is CONTROLLER:max_len if the reason and the controller ID are the
+// defaults.
+func (a *NXActionController) String() string {
+	if a.Reason == R_ACTION && a.ControllerID == 0 {
+		return fmt.Sprintf("CONTROLLER:%d", a.MaxLen)
+	}
+	var args []string
+	if a.Reason != R_ACTION {
+		if name, ok := controllerReasonNames[a.Reason]; ok {
+			args = append(args, "reason="+name)
+		} else {
+			args = append(args, fmt.Sprintf("reason=%d", a.Reason))
+		}
+	}
+	if a.MaxLen != OFPCML_NO_BUFFER {
+		args = append(args, fmt.Sprintf("max_len=%d", a.MaxLen))
+	}
+	if a.ControllerID != 0 {
+		args = append(args, fmt.Sprintf("id=%d", a.ControllerID))
+	}
+	return "controller(" + strings.Join(args, ",") + ")"
+}
+
+func (a *NXActionFinTimeout) String() string {
+	var args []string
+	if a.FinIdleTimeout != 0 {
+		args = append(args, fmt.Sprintf("idle_timeout=%d", a.FinIdleTimeout))
+	}
+	if a.FinHardTimeout != 0 {
+		args = append(args, fmt.Sprintf("hard_timeout=%d", a.FinHardTimeout))
+	}
+	return "fin_timeout(" + strings.Join(args, ",") + ")"
+}
+
+func sampleString(probability uint16, collectorSetID, obsDomainID, obsPointID uint32) string {
+	return fmt.Sprintf("sample(probability=%d,collector_set_id=%d,obs_domain_id=%d,obs_point_id=%d", probability,
+		collectorSetID, obsDomainID, obsPointID)
+}
+
+func (a *NXActionSample) String() string {
+	return sampleString(a.Probability, a.CollectorSetID, a.ObsDomainID, a.ObsPointID) + ")"
+}
+
+func (a *NXActionSample2) String() string {
+	s := sampleString(a.Probability, a.CollectorSetID, a.ObsDomainID, a.ObsPointID)
+	if a.SamplingPort != 0 {
+		s += fmt.Sprintf(",sampling_port=%d", a.SamplingPort)
+	}
+	switch a.Direction {
+	case NX_ACTION_SAMPLE_INGRESS:
+		s += ",ingress"
+	case NX_ACTION_SAMPLE_EGRESS:
+		s += ",egress"
+	}
+	return s + ")"
+}
+
+func (a *NXActionOutputTrunc) String() string {
+	return fmt.Sprintf("output(port=%d,max_len=%d)", a.Port, a.MaxLen)
+}
+
+func (a *NXActionCTClear) String() string {
+	return "ct_clear"
+}
+
+func (a *NXActionExit) String() string {
+	return "exit"
+}
+
+func (a *NXActionDecNshTTL) String() string {
+	return "dec_nsh_ttl"
+}
+
+var packetTypeNames = map[uint32]string{PT_ETH: "ethernet", PT_NSH: "nsh", PT_MPLS: "mpls"}
+
+// String returns the encap action, e.g., encap(ethernet) or encap(nsh(md_type=2,tlv(0x1000,10,0x12345678))).
+func (a *NXActionEncap) String() string {
+	name, ok := packetTypeNames[a.NewPktType]
+	if !ok {
+		name = fmt.Sprintf("packet_type(ns=%d,type=0x%x)", a.NewPktType>>16, a.NewPktType&0xffff)
+	}
+	var props []string
+	for _, p := range a.Props {
+		switch {
+		case p.Class == OFPPPC_NSH && p.Type == OFPPPT_PROP_NSH_MDTYPE && len(p.Data) > 0:
+			props = append(props, fmt.Sprintf("md_type=%d", p.Data[0]))
+		case p.Class == OFPPPC_NSH && p.Type == OFPPPT_PROP_NSH_TLV && len(p.Data) >= 4 && len(p.Data) >= 4+int(p.Data[3]):
+			props = append(props, fmt.Sprintf("tlv(0x%x,%d,0x%x)", binary.BigEndian.Uint16(p.Data), p.Data[2], p.Data[4:4+p.Data[3]]))
+		default:
+			props = append(props, fmt.Sprintf("prop(class=0x%x,type=%d)", p.Class, p.Type))
+		}
+	}
+	if len(props) > 0 {
+		name += "(" + strings.Join(props, ",") + ")"
+	}
+	return "encap(" + name + ")"
+}
+
+// String returns the decap action, which is decap() for PT_USE_NEXT_PROTO.
+func (a *NXActionDecap) String() string {
+	if a.NewPktType == PT_USE_NEXT_PROTO {
+		return "decap()"
+	}
+	return fmt.Sprintf("decap(packet_type(ns=%d,type=0x%x))", a.NewPktType>>16, a.NewPktType&0xffff)
+}
+
+var hashFieldsNames = map[uint16]string{
+	NX_HASH_FIELDS_ETH_SRC:            "eth_src",
+	NX_HASH_FIELDS_SYMMETRIC_L4:       "symmetric_l4",
+	NX_HASH_FIELDS_SYMMETRIC_L3L4:     "symmetric_l3l4",
+	NX_HASH_FIELDS_SYMMETRIC_L3L4_UDP: "symmetric_l3l4+udp",
+	NX_HASH_FIELDS_NW_SRC:             "nw_src",
+	NX_HASH_FIELDS_NW_DST:             "nw_dst",
+	NX_HASH_FIELDS_SYMMETRIC_L3:       "symmetric_l3",
+}
+
+var multipathAlgorithmNames = map[uint16]string{
+	NX_MP_ALG_MODULO_N:       "modulo_n",
+	NX_MP_ALG_HASH_THRESHOLD: "hash_threshold",
+	NX_MP_ALG_HRW:            "hrw",
+	NX_MP_ALG_ITER_HASH:      "iter_hash",
+}
+
+var bundleAlgorithmNames = map[uint16]string{NX_BD_ALG_ACTIVE_BACKUP: "active_backup", NX_BD_ALG_HRW: "hrw"}
+
+// enumName returns the name of the value in names, or the value if it has no name.
+func enumName(names map[uint16]string, value uint16) string {
+	if name, ok := names[value]; ok {
+		return name
+	}
+	return fmt.Sprint(value)
+}
+
+// String returns the multipath action, the number of links is MaxLink+1.
+func (a *NXActionMultipath) String() string {
+	return fmt.Sprintf("multipath(%s,%d,%s,%d,%d,%s)", enumName(hashFieldsNames, a.Fields), a.Basis,
+		enumName(multipathAlgorithmNames, a.Algorithm), int(a.MaxLink)+1, a.Arg,
+		oxxFieldRange(a.DstField, decodeOfs(a.OfsNbits), decodeNbits(a.OfsNbits)))
+}
+
+// String returns the bundle or the bundle_load action, the slave ports are printed after members: as OVS.
+func (a *NXActionBundle) String() string {
+	args := []string{enumName(hashFieldsNames, a.Fields), fmt.Sprint(a.Basis), enumName(bundleAlgorithmNames, a.Algorithm), "ofport"}
+	name := "bundle"
+	if a.Subtype == NXAST_BUNDLE_LOAD {
+		name = "bundle_load"
+		args = append(args, oxxFieldRange(a.DstField, decodeOfs(a.OfsNbits), decodeNbits(a.OfsNbits)))
+	}
+	slaves := make([]string, len(a.Slaves))
+	for i, slave := range a.Slaves {
+		slaves[i] = fmt.Sprint(slave)
+	}
+	return name + "(" + strings.Join(args, ",") + ",members:" + strings.Join(slaves, ",") + ")"
+}
+
+// String returns the learn spec, e.g., NXM_OF_ETH_DST[]=NXM_OF_ETH_SRC[], load:NXM_NX_REG0[]->NXM_NX_REG1[] or
+// output:NXM_OF_IN_PORT[].
+func (s *NXLearnSpec) String() string {
+	nBits := s.Header.nBits
+	var src string
+	if s.Header.src {
+		src = hexString(s.SrcValue)
+	} else if s.SrcField != nil {
+		src = oxxFieldRange(s.SrcField.Field, s.SrcField.Ofs, nBits)
+	}
+	if s.Header.output {
+		return "output:" + src
+	}
+	var dst string
+	if s.DstField != nil {
+		dst = oxxFieldRange(s.DstField.Field, s.DstField.Ofs, nBits)
+	}
+	if s.Header.dst {
+		return "load:" + src + "->" + dst
+	}
+	if src == dst {
+		return dst
+	}
+	return dst + "=" + src
+}
+
+func (a *NXActionLearn) String() string {
+	args := []string{fmt.Sprintf("table=%d", a.TableID)}
+	if a.IdleTimeout != 0 {
+		args = append(args, fmt.Sprintf("idle_timeout=%d", a.IdleTimeout))
+	}
+	if a.HardTimeout != 0 {
+		args = append(args, fmt.Sprintf("hard_timeout=%d", a.HardTimeout))
+	}
+	if a.FinIdleTimeout != 0 {
+		args = append(args, fmt.Sprintf("fin_idle_timeout=%d", a.FinIdleTimeout))
+	}
+	if a.FinHardTimeout != 0 {
+		args = append(args, fmt.Sprintf("fin_hard_timeout=%d", a.FinHardTimeout))
+	}
+	args = append(args, fmt.Sprintf("priority=%d", a.Priority))
+	if a.Cookie != 0 {
+		args = append(args, fmt.Sprintf("cookie=0x%x", a.Cookie))
+	}
+	if a.Flags&NX_LEARN_F_SEND_FLOW_REM != 0 {
+		args = append(args, "send_flow_rem")
+	}
+	if a.Flags&NX_LEARN_F_DELETE_LEARNED != 0 {
+		args = append(args, "delete_learned")
+	}
+	for _, spec := range a.LearnSpecs {
+		args = append(args, spec.String())
+	}
+	return "learn(" + strings.Join(args, ",") + ")"
+}
@@ -0,0 +1,140 @@
+package openflow13
+
+import (
+	"net"
+	"testing"
+
+	"github.com/contiv/libOpenflow/ofptest"
+)
+
+func TestFlowModString(t *testing.T) {
+	_, ipNet, _ := net.ParseCIDR("10.0.0.0/24")
+	flow := NewFlowMod()
+	flow.Priority = 100
+	flow.Match.AddField(*NewEthTypeField(0x0800))
+	flow.Match.AddField(*NewIpv4SrcField(ipNet.IP, (*net.IP)(&ipNet.Mask)))
+	ct := NewNXActionConnTrack().Commit()
+	instr := NewInstrApplyActions()
+	instr.AddAction(ct, false)
+	instr.AddAction(NewActionOutput(2), false)
+	flow.AddInstruction(instr)
+	if s := flow.String(); s != "priority=100,ip,nw_src=10.0.0.0/24 actions=ct(commit),output:2" {
+		t.Errorf("Unexpected flow: %s", s)
+	}
+
+	states := NewCTStates()
+	states.SetTrk()
+	states.UnsetNew()
+	mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	flow = NewFlowMod()
+	flow.TableId = 1
+	flow.Cookie = 0x12
+	flow.Priority = 200
+	flow.Flags = FF_SEND_FLOW_REM
+	flow.Match.AddField(*NewInPortField(P_LOCAL))
+	flow.Match.AddField(*NewEthTypeField(0x0800))
+	flow.Match.AddField(*NewIpProtoField(6))
+	flow.Match.AddField(*NewTcpDstField(80))
+	flow.Match.AddField(*NewCTStateMatchField(states))
+	flow.Match.AddField(*NewRegMatchField(1, 0x10, NewNXRange(0, 15)))
+	flow.Match.AddField(*NewEthDstField(mac, nil))
+	nat := NewNXActionCTNAT()
+	nat.SetDNAT()
+	nat.SetRangeIPv4Min(net.ParseIP("10.0.0.1"))
+	nat.SetRangeIPv4Max(net.ParseIP("10.0.0.2"))
+	portMin, portMax := uint16(1000), uint16(2000)
+	nat.SetRangeProtoMin(&portMin)
+	nat.SetRangeProtoMax(&portMax)
+	nat.SetRandom()
+	reg0, _ := FindFieldHeaderByName("NXM_NX_REG0", false)
+	markField, _ := FindFieldHeaderByName("NXM_NX_CT_MARK", false)
+	ct = NewNXActionConnTrack().Commit().Table(3).ZoneImm(10).AddAction(NewNXActionRegLoad(NewNXRange(0, 31).ToOfsBits(), markField, 1), nat)
+	ethSrc, _ := FindFieldHeaderByName("NXM_OF_ETH_SRC", false)
+	ethDst, _ := FindFieldHeaderByName("NXM_OF_ETH_DST", false)
+	toController := NewActionOutput(P_CONTROLLER)
+	toController.MaxLen = OFPCML_NO_BUFFER
+	instr = NewInstrApplyActions()
+	for _, act := range []Action{
+		NewNXActionRegLoad(NewNXRange(0, 15).ToOfsBits(), reg0, 0x5),
+		NewNXActionRegMove(48, 0, 0, ethSrc, ethDst),
+		NewActionSetField(*NewIpv4DstField(net.ParseIP("10.0.0.5"), nil)),
+		NewNXActionConjunction(1, 2, 7),
+		ct,
+		NewNXActionResubmitTableAction(OFPP_IN_PORT, 4),
+		toController,
+	} {
+		instr.AddAction(act, false)
+	}
+	flow.AddInstruction(instr)
+	flow.AddInstruction(NewInstrGotoTable(5))
+	expected := "cookie=0x12,table=1,priority=200,send_flow_rem,tcp,in_port=LOCAL,tcp_dst=80,ct_state=-new+trk," +
+		"reg1=0x10/0xffff,dl_dst=aa:bb:cc:dd:ee:ff actions=load:0x5->NXM_NX_REG0[0..15]," +
+		"move:NXM_OF_ETH_SRC[]->NXM_OF_ETH_DST[],set_field:10.0.0.5->nw_dst,conjunction(7,2/2)," +
+		"ct(commit,table=3,zone=10,exec(load:0x1->NXM_NX_CT_MARK[]),nat(dst=10.0.0.1-10.0.0.2:1000-2000,random))," +
+		"resubmit(,4),CONTROLLER:65535,goto_table:5"
+	if s := flow.String(); s != expected {
+		t.Errorf("Expect flow:\n%s\nactual:\n%s", expected, s)
+	}
+	// The decoded flow has the same string.
+	data, err := flow.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal FlowMod: %v", err)
+	}
+	parsed := NewFlowMod()
+	if err = parsed.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal FlowMod: %v", err)
+	}
+	if s := parsed.String(); s != expected {
+		t.Errorf("Expect decoded flow:\n%s\nactual:\n%s", expected, s)
+	}
+
+	flow = NewFlowMod()
+	flow.Command = FC_DELETE
+	flow.Match.AddField(*NewEthTypeField(0x86dd))
+	if s := flow.String(); s != "priority=1000,ipv6" {
+		t.Errorf("Unexpected flow delete: %s", s)
+	}
+	flow.Command = FC_ADD
+	flow.AddInstruction(NewInstrWriteMetadata(0x1, 0xff))
+	writeActions := NewInstrWriteActions()
+	writeActions.AddAction(NewActionGroup(3), false)
+	flow.AddInstruction(writeActions)
+	if s := flow.String(); s != "priority=1000,ipv6 actions=write_metadata:0x1/0xff,write_actions(group:3)" {
+		t.Errorf("Unexpected flow: %s", s)
+	}
+	if s := NewFlowMod().String(); s != "priority=1000 actions=drop" {
+		t.Errorf("Unexpected flow without instructions: %s", s)
+	}
+}
+
+// TestFlowModConformance checks the encoding of the flow mods with ovs-ofctl in the conformance mode of ofptest.
+func TestFlowModConformance(t *testing.T) {
+	_, ipNet, _ := net.ParseCIDR("10.0.0.0/24")
+	ip := NewFlowMod()
+	ip.Priority = 100
+	ip.Match.AddField(*NewEthTypeField(0x0800))
+	ip.Match.AddField(*NewIpv4SrcField(ipNet.IP, (*net.IP)(&ipNet.Mask)))
+	instr := NewInstrApplyActions()
+	instr.AddAction(NewNXActionConnTrack().Commit(), false)
+	instr.AddAction(NewActionOutput(2), false)
+	ip.AddInstruction(instr)
+
+	tcp := NewFlowMod()
+	tcp.TableId = 1
+	tcp.Cookie = 0x12
+	tcp.Priority = 200
+	tcp.Match.AddField(*NewInPortField(3))
+	tcp.Match.AddField(*NewEthTypeField(0x0800))
+	tcp.Match.AddField(*NewIpProtoField(6))
+	tcp.Match.AddField(*NewTcpDstField(80))
+	tcp.AddInstruction(NewInstrGotoTable(5))
+
+	drop := NewFlowMod()
+	drop.Priority = 0
+
+	for name, flow := range map[string]*FlowMod{"ip": ip, "tcp": tcp, "drop": drop} {
+		t.Run(name, func(t *testing.T) {
+			ofptest.CheckParseFlow(t, flow)
+		})
+	}
+}
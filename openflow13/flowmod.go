@@ -2,9 +2,10 @@ package openflow13
 
 import (
 	"encoding/binary"
+	"errors"
+	"time"
 
 	"github.com/contiv/libOpenflow/common"
-	log "github.com/sirupsen/logrus"
 )
 
 // ofp_flow_mod     1.3
@@ -26,7 +27,7 @@ type FlowMod struct {
 	OutGroup uint32
 	Flags    uint16
 
-	pad []byte // 2bytes
+	pad [2]byte // 2bytes
 
 	Match        Match         // Fields to match
 	Instructions []Instruction //  Instruction set - 0 or more.
@@ -47,7 +48,7 @@ func NewFlowMod() *FlowMod {
 	f.HardTimeout = 0
 	// Add a priority gen here
 	f.Priority = 1000
-	f.BufferId = 0xffffffff
+	f.BufferId = NO_BUFFER
 	f.OutPort = P_ANY
 	f.OutGroup = OFPG_ANY
 	f.Flags = 0
@@ -57,6 +58,63 @@ func NewFlowMod() *FlowMod {
 	return f
 }
 
+// BuildNormalForwardingFlow returns the flow in the table which outputs the packets to P_NORMAL, i.e., the
+// traditional L2/L3 switching of OVS, with the priority.
+func BuildNormalForwardingFlow(table uint8, priority uint16) *FlowMod {
+	f := NewFlowMod()
+	f.TableId = table
+	f.Priority = priority
+	instr := NewInstrApplyActions()
+	instr.AddAction(NewActionOutput(P_NORMAL), false)
+	f.AddInstruction(instr)
+	return f
+}
+
+// BuildDefaultDropFlow returns the table-miss flow of the table which drops the packets. The flow has an
+// OFPIT_CLEAR_ACTIONS instruction, so that the packets are dropped even if the previous tables have written
+// actions into the action set with OFPIT_WRITE_ACTIONS, which would be executed at the end of the pipeline.
+func BuildDefaultDropFlow(table uint8) *FlowMod {
+	f := NewFlowMod()
+	f.TableId = table
+	f.Priority = 0
+	f.AddInstruction(NewInstrClearActions())
+	return f
+}
+
+// newFlowModFromFlow returns the OFPFC_ADD FlowMod of a dumped flow, the fields and the instructions are copied
+// into new slices, so that the FlowMod could be changed without the dumped flow.
+func newFlowModFromFlow(tableID uint8, priority uint16, cookie uint64, idleTimeout, hardTimeout, flags uint16, match *Match,
+	instrs []Instruction) *FlowMod {
+	f := NewFlowMod()
+	f.TableId = tableID
+	f.Priority = priority
+	f.Cookie = cookie
+	f.IdleTimeout = idleTimeout
+	f.HardTimeout = hardTimeout
+	f.Flags = flags
+	f.Match = *match
+	f.Match.Fields = append([]MatchField(nil), match.Fields...)
+	f.Instructions = append(f.Instructions, instrs...)
+	return f
+}
+
+// NewFlowModFromFlowStats returns the OFPFC_ADD FlowMod which installs the flow of the FlowStats in a flow stats
+// reply, e.g., to restore the dumped flows. The cookie, table, priority, timeouts, flags, match and instructions
+// are preserved, the counters and the durations are dropped.
+func NewFlowModFromFlowStats(s *FlowStats) (*FlowMod, error) {
+	instrs, err := s.GetInstructions()
+	if err != nil {
+		return nil, err
+	}
+	return newFlowModFromFlow(s.TableId, s.Priority, s.Cookie, s.IdleTimeout, s.HardTimeout, s.Flags, &s.Match, instrs), nil
+}
+
+// NewFlowModFromFlowDesc returns the OFPFC_ADD FlowMod which installs the flow of the FlowDesc of OpenFlow 1.5 as
+// NewFlowModFromFlowStats, the importance is dropped as the FlowMod of OpenFlow 1.3 has no importance.
+func NewFlowModFromFlowDesc(d *FlowDesc) *FlowMod {
+	return newFlowModFromFlow(d.TableId, d.Priority, d.Cookie, d.IdleTimeout, d.HardTimeout, d.Flags, &d.Match, d.Instructions)
+}
+
 func (f *FlowMod) AddInstruction(instr Instruction) {
 	f.Instructions = append(f.Instructions, instr)
 }
@@ -75,6 +133,7 @@ func (f *FlowMod) Len() (n uint16) {
 }
 
 func (f *FlowMod) MarshalBinary() (data []byte, err error) {
+	checkMatchSize("FlowMod", &f.Match)
 	f.Header.Length = f.Len()
 	data, err = f.Header.MarshalBinary()
 
@@ -98,7 +157,7 @@ func (f *FlowMod) MarshalBinary() (data []byte, err error) {
 	n += 4
 	binary.BigEndian.PutUint32(bytes[n:], f.OutPort)
 	n += 4
-	binary.BigEndian.PutUint32(bytes[n:], f.OutPort)
+	binary.BigEndian.PutUint32(bytes[n:], f.OutGroup)
 	n += 4
 	binary.BigEndian.PutUint16(bytes[n:], f.Flags)
 	n += 2
@@ -111,17 +170,22 @@ func (f *FlowMod) MarshalBinary() (data []byte, err error) {
 	for _, instr := range f.Instructions {
 		bytes, err = instr.MarshalBinary()
 		data = append(data, bytes...)
-		log.Debugf("flowmod instr: %v", bytes)
 	}
 
-	log.Debugf("Flowmod(%d): %v", len(data), data)
 	return
 }
 
 func (f *FlowMod) UnmarshalBinary(data []byte) error {
-	n := 0
-	f.Header.UnmarshalBinary(data[n:])
-	n += int(f.Header.Len())
+	if len(data) < 48 {
+		return errors.New("the []byte is too short to unmarshal a full FlowMod message")
+	}
+	if err := f.Header.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if int(f.Header.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full FlowMod message")
+	}
+	n := int(f.Header.Len())
 
 	f.Cookie = binary.BigEndian.Uint64(data[n:])
 	n += 8
@@ -147,13 +211,20 @@ func (f *FlowMod) UnmarshalBinary(data []byte) error {
 	n += 2
 	n += 2 // for pad
 
-	f.Match.UnmarshalBinary(data[n:])
+	if err := f.Match.UnmarshalBinary(data[n:]); err != nil {
+		return decodeErrorAt(err, "FlowMod", n, "match")
+	}
 	n += int(f.Match.Len())
 
 	for n < int(f.Header.Length) {
-		instr := DecodeInstr(data[n:])
+		instr, err := decodeInstr(data[n:])
+		if err != nil {
+			return decodeErrorAt(err, "FlowMod", n, "instruction")
+		}
 		f.Instructions = append(f.Instructions, instr)
-		n += int(instr.Len())
+		if n, err = safeAdvance(n, instr.Len(), int(f.Header.Length), "instruction"); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -177,6 +248,9 @@ const (
 )
 
 // BEGIN: ofp13 - 7.4.2
+// FlowRemoved is the OFPT_FLOW_REMOVED message. In OpenFlow 1.5, i.e., the version in the header is
+// OFP15_VERSION, the duration and the counters are OXS fields in Stats instead of the fixed fields, use
+// GetDuration, GetPacketCount and GetByteCount to read them regardless of the version.
 type FlowRemoved struct {
 	common.Header
 	Cookie   uint64
@@ -194,6 +268,7 @@ type FlowRemoved struct {
 	ByteCount   uint64
 
 	Match Match
+	Stats Stats
 }
 
 func NewFlowRemoved() *FlowRemoved {
@@ -203,14 +278,24 @@ func NewFlowRemoved() *FlowRemoved {
 	return f
 }
 
+func (f *FlowRemoved) useStatsLayout() bool {
+	return f.Header.Version > VERSION
+}
+
 func (f *FlowRemoved) Len() (n uint16) {
 	n = f.Header.Len()
 	n += f.Match.Len()
+	if f.useStatsLayout() {
+		return n + 16 + f.Stats.Len()
+	}
 	n += 40
 	return
 }
 
 func (f *FlowRemoved) MarshalBinary() (data []byte, err error) {
+	if f.useStatsLayout() {
+		return f.marshalStatsLayout()
+	}
 	data = make([]byte, int(f.Len()))
 	next := 0
 
@@ -247,7 +332,47 @@ func (f *FlowRemoved) MarshalBinary() (data []byte, err error) {
 	return
 }
 
+// marshalStatsLayout marshals the ofp_flow_removed of OpenFlow 1.5, whose fixed part is table_id, reason,
+// priority, idle_timeout, hard_timeout and cookie, followed by the match and the stats.
+func (f *FlowRemoved) marshalStatsLayout() (data []byte, err error) {
+	f.Header.Length = f.Len()
+	data, err = f.Header.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 16)
+	n := 0
+	b[n] = f.TableId
+	n += 1
+	b[n] = f.Reason
+	n += 1
+	binary.BigEndian.PutUint16(b[n:], f.Priority)
+	n += 2
+	binary.BigEndian.PutUint16(b[n:], f.IdleTimeout)
+	n += 2
+	binary.BigEndian.PutUint16(b[n:], f.HardTimeout)
+	n += 2
+	binary.BigEndian.PutUint64(b[n:], f.Cookie)
+	data = append(data, b...)
+
+	if b, err = f.Match.MarshalBinary(); err != nil {
+		return nil, err
+	}
+	data = append(data, b...)
+	if b, err = f.Stats.MarshalBinary(); err != nil {
+		return nil, err
+	}
+	data = append(data, b...)
+	return
+}
+
 func (f *FlowRemoved) UnmarshalBinary(data []byte) error {
+	if len(data) > 0 && data[0] > VERSION {
+		return f.unmarshalStatsLayout(data)
+	}
+	if len(data) < 48 {
+		return errors.New("the []byte is too short to unmarshal a full FlowRemoved message")
+	}
 	next := 0
 	var err error
 	err = f.Header.UnmarshalBinary(data[next:])
@@ -274,10 +399,73 @@ func (f *FlowRemoved) UnmarshalBinary(data []byte) error {
 	f.ByteCount = binary.BigEndian.Uint64(data[next:])
 	next += 8
 
-	err = f.Match.UnmarshalBinary(data[next:])
-	next += int(f.Match.Len())
+	if err = f.Match.UnmarshalBinary(data[next:]); err != nil {
+		return decodeErrorAt(err, "FlowRemoved", next, "match")
+	}
+	return nil
+}
+
+func (f *FlowRemoved) unmarshalStatsLayout(data []byte) error {
+	if err := f.Header.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	n := int(f.Header.Len())
+	if int(f.Header.Length) > len(data) || int(f.Header.Length) < n+16 {
+		return errors.New("the []byte is too short to unmarshal a full FlowRemoved message")
+	}
+	data = data[:f.Header.Length]
+	f.TableId = data[n]
+	n += 1
+	f.Reason = data[n]
+	n += 1
+	f.Priority = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	f.IdleTimeout = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	f.HardTimeout = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	f.Cookie = binary.BigEndian.Uint64(data[n:])
+	n += 8
+
+	f.Match = Match{}
+	if err := f.Match.UnmarshalBinary(data[n:]); err != nil {
+		return decodeErrorAt(err, "FlowRemoved", n, "match")
+	}
+	n += int(f.Match.Len())
+	if err := checkBounds(data, n, 0, "FlowRemoved", "stats"); err != nil {
+		return err
+	}
+	if err := f.Stats.UnmarshalBinary(data[n:]); err != nil {
+		return decodeErrorAt(err, "FlowRemoved", n, "stats")
+	}
+	return nil
+}
+
+// GetDuration returns the time the flow entry was alive.
+func (f *FlowRemoved) GetDuration() time.Duration {
+	if f.useStatsLayout() {
+		d, _ := f.Stats.GetDuration()
+		return d
+	}
+	return time.Duration(f.DurationSec)*time.Second + time.Duration(f.DurationNSec)
+}
 
-	return err
+// GetPacketCount returns the number of packets in the flow entry, it is 0 if the switch doesn't report it.
+func (f *FlowRemoved) GetPacketCount() uint64 {
+	if f.useStatsLayout() {
+		count, _ := f.Stats.GetPacketCount()
+		return count
+	}
+	return f.PacketCount
+}
+
+// GetByteCount returns the number of bytes in the flow entry, it is 0 if the switch doesn't report it.
+func (f *FlowRemoved) GetByteCount() uint64 {
+	if f.useStatsLayout() {
+		count, _ := f.Stats.GetByteCount()
+		return count
+	}
+	return f.ByteCount
 }
 
 // ofp_flow_removed_reason 1.3
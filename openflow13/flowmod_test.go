@@ -0,0 +1,237 @@
+package openflow13
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+func TestBuildNormalForwardingFlow(t *testing.T) {
+	flow := BuildNormalForwardingFlow(2, 100)
+	data, err := flow.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal flow: %v", err)
+	}
+	msg, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse flow: %v", err)
+	}
+	parsed := msg.(*FlowMod)
+	if parsed.TableId != 2 || parsed.Priority != 100 || parsed.Command != FC_ADD || len(parsed.Match.Fields) != 0 {
+		t.Errorf("Unexpected flow: %+v", parsed)
+	}
+	if len(parsed.Instructions) != 1 {
+		t.Fatalf("Expect 1 instruction, actual: %d", len(parsed.Instructions))
+	}
+	instr, ok := parsed.Instructions[0].(*InstrActions)
+	if !ok || instr.Type != InstrType_APPLY_ACTIONS || len(instr.Actions) != 1 {
+		t.Fatalf("Expect apply actions, actual: %+v", parsed.Instructions[0])
+	}
+	if output, ok := instr.Actions[0].(*ActionOutput); !ok || output.Port != P_NORMAL {
+		t.Errorf("Expect output to P_NORMAL, actual: %+v", instr.Actions[0])
+	}
+}
+
+func TestBuildDefaultDropFlow(t *testing.T) {
+	flow := BuildDefaultDropFlow(5)
+	data, err := flow.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal flow: %v", err)
+	}
+	msg, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse flow: %v", err)
+	}
+	parsed := msg.(*FlowMod)
+	if parsed.TableId != 5 || parsed.Priority != 0 || len(parsed.Match.Fields) != 0 || len(parsed.Instructions) != 1 {
+		t.Fatalf("Expect table-miss flow with 1 instruction, actual: %+v", parsed)
+	}
+	if instr, ok := parsed.Instructions[0].(*InstrActions); !ok || instr.Type != InstrType_CLEAR_ACTIONS {
+		t.Errorf("Expect clear actions, actual: %+v", parsed.Instructions[0])
+	}
+}
+
+func TestNewFlowModFromFlowStats(t *testing.T) {
+	stats := NewFlowStats()
+	stats.TableId = 3
+	stats.Priority = 200
+	stats.IdleTimeout = 10
+	stats.HardTimeout = 20
+	stats.Flags = FF_SEND_FLOW_REM
+	stats.Cookie = 0x1234
+	stats.PacketCount = 5
+	stats.Match.AddField(*NewInPortField(1))
+	stats.Instructions = append(stats.Instructions, NewInstrGotoTable(4))
+	stats.Length = stats.Len()
+	data, err := stats.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal FlowStats: %v", err)
+	}
+	dumped := new(FlowStats)
+	if err := dumped.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal FlowStats: %v", err)
+	}
+
+	flowMod, err := NewFlowModFromFlowStats(dumped)
+	if err != nil {
+		t.Fatalf("Failed to convert FlowStats: %v", err)
+	}
+	if flowMod.Command != FC_ADD || flowMod.TableId != 3 || flowMod.Priority != 200 || flowMod.IdleTimeout != 10 ||
+		flowMod.HardTimeout != 20 || flowMod.Flags != FF_SEND_FLOW_REM || flowMod.Cookie != 0x1234 {
+		t.Errorf("Unexpected FlowMod: %s", flowMod)
+	}
+	if !flowMod.EqualsFlowStats(dumped) {
+		t.Errorf("Expect the FlowMod equal to the FlowStats")
+	}
+	if _, err := flowMod.MarshalBinary(); err != nil {
+		t.Fatalf("Failed to marshal FlowMod: %v", err)
+	}
+	flowMod.Match.AddField(*NewTunnelIdField(1))
+	if len(dumped.Match.Fields) != 1 {
+		t.Errorf("Expect the FlowStats not changed with the FlowMod")
+	}
+
+	desc := NewFlowDesc()
+	desc.TableId = 3
+	desc.Priority = 200
+	desc.Match = dumped.Match
+	desc.Instructions = dumped.Instructions
+	if flowMod := NewFlowModFromFlowDesc(desc); !flowMod.EqualsFlowDesc(desc) || flowMod.Command != FC_ADD {
+		t.Errorf("Expect the FlowMod equal to the FlowDesc")
+	}
+}
+
+func TestFlowRemoved15(t *testing.T) {
+	removed := NewFlowRemoved()
+	removed.Header.Version = OFP15_VERSION
+	removed.Header.Type = Type_FlowRemoved
+	removed.TableId = 2
+	removed.Reason = RR_IDLE_TIMEOUT
+	removed.Priority = 100
+	removed.IdleTimeout = 30
+	removed.Cookie = 0x1234
+	removed.Match.AddField(*NewInPortField(1))
+	removed.Stats.AddField(*NewDurationStatField(3, 500))
+	removed.Stats.AddField(*NewPacketCountStatField(7))
+	removed.Stats.AddField(*NewByteCountStatField(700))
+	data, err := removed.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal FlowRemoved: %v", err)
+	}
+	if len(data) != int(removed.Len()) {
+		t.Fatalf("Expect %d bytes, got %d", removed.Len(), len(data))
+	}
+	msg, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse FlowRemoved: %v", err)
+	}
+	parsed := msg.(*FlowRemoved)
+	if parsed.TableId != 2 || parsed.Reason != RR_IDLE_TIMEOUT || parsed.Priority != 100 || parsed.IdleTimeout != 30 ||
+		parsed.Cookie != 0x1234 || len(parsed.Match.Fields) != 1 {
+		t.Errorf("Unexpected FlowRemoved: %+v", parsed)
+	}
+	if parsed.GetPacketCount() != 7 || parsed.GetByteCount() != 700 || parsed.GetDuration() != 3*time.Second+500 {
+		t.Errorf("Unexpected counters %d, %d, %v", parsed.GetPacketCount(), parsed.GetByteCount(), parsed.GetDuration())
+	}
+
+	removed13 := NewFlowRemoved()
+	removed13.Header.Type = Type_FlowRemoved
+	removed13.DurationSec = 3
+	removed13.PacketCount = 7
+	removed13.ByteCount = 700
+	data, err = removed13.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal FlowRemoved: %v", err)
+	}
+	parsed = new(FlowRemoved)
+	if err := parsed.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal FlowRemoved: %v", err)
+	}
+	if parsed.GetPacketCount() != 7 || parsed.GetByteCount() != 700 || parsed.GetDuration() != 3*time.Second {
+		t.Errorf("Unexpected counters %d, %d, %v", parsed.GetPacketCount(), parsed.GetByteCount(), parsed.GetDuration())
+	}
+}
+
+func TestFlowModOutPortAndOutGroup(t *testing.T) {
+	flow := NewFlowMod()
+	flow.Command = FC_DELETE
+	flow.OutPort = 3
+	flow.OutGroup = 7
+	data, err := flow.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal flow: %v", err)
+	}
+	// out_port and out_group follow the buffer_id in ofp_flow_mod.
+	if outPort, outGroup := binary.BigEndian.Uint32(data[36:]), binary.BigEndian.Uint32(data[40:]); outPort != 3 || outGroup != 7 {
+		t.Errorf("Expect out_port 3 and out_group 7, actual: %d and %d", outPort, outGroup)
+	}
+	msg, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse flow: %v", err)
+	}
+	if parsed := msg.(*FlowMod); parsed.OutPort != 3 || parsed.OutGroup != 7 {
+		t.Errorf("Unexpected out_port %d and out_group %d", parsed.OutPort, parsed.OutGroup)
+	}
+}
+
+// countingTracer counts the traced messages.
+type countingTracer chan util.Message
+
+func (c countingTracer) TraceMessage(msg util.Message, data []byte) {
+	c <- msg
+}
+
+// streamParser parses the messages received by a MessageStream.
+type streamParser struct{}
+
+func (p streamParser) Parse(b []byte) (util.Message, error) {
+	return Parse(append([]byte{}, b...))
+}
+
+func TestFlowModTracedOnce(t *testing.T) {
+	tracer := make(countingTracer, 10)
+	util.SetMessageTracer(tracer)
+	defer util.SetMessageTracer(nil)
+
+	// Marshaling the messages doesn't trace them, only the stream does.
+	for _, msg := range []util.Message{NewFlowMod(), NewGroupMod(), NewGroupMod15(OFPGC_ADD, OFPGT_ALL, 1), NewMeterMod(),
+		&MultipartRequest{Header: NewOfp13Header(), Type: MultipartType_Port, Body: NewPortStatsRequestAll()}} {
+		if _, err := msg.MarshalBinary(); err != nil {
+			t.Fatalf("Failed to marshal %T: %v", msg, err)
+		}
+	}
+	select {
+	case msg := <-tracer:
+		t.Fatalf("Expect no message traced when marshaled, actual: %T", msg)
+	default:
+	}
+
+	local, remote := net.Pipe()
+	defer remote.Close()
+	stream := util.NewMessageStream(local, streamParser{})
+	defer func() { stream.Shutdown <- true }()
+	flow := NewFlowMod()
+	data, _ := flow.MarshalBinary()
+	stream.Outbound <- flow
+	buf := make([]byte, len(data))
+	remote.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := remote.Read(buf); err != nil {
+		t.Fatalf("Failed to read the sent flow: %v", err)
+	}
+	select {
+	case msg := <-tracer:
+		if msg != flow {
+			t.Errorf("Expect the sent flow traced, actual: %T", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("The sent flow is not traced")
+	}
+	select {
+	case msg := <-tracer:
+		t.Errorf("Expect the sent flow traced once, actual another %T", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,77 @@
+package openflow13
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+// fuzzSeeds returns the encoded messages of the common types as the seed corpus of the fuzz targets.
+func fuzzSeeds() [][]byte {
+	flowMod := NewFlowMod()
+	flowMod.Match.AddField(*NewInPortField(1))
+	flowMod.Match.AddField(*NewCTStateMatchField(NewCTStates()))
+	instr := NewInstrApplyActions()
+	instr.AddAction(NewActionOutput(P_NORMAL), false)
+	instr.AddAction(NewNXActionConnTrack(), false)
+	flowMod.AddInstruction(instr)
+
+	groupMod := NewGroupMod()
+	bkt := NewBucket()
+	bkt.AddAction(NewActionOutput(1))
+	groupMod.AddBucket(*bkt)
+
+	experimenterReply := newMultipartReply(1, MultipartType_Experimenter, 0, NewExperimenterStatsBody(NxExperimenterID, 0, make([]byte, 8)))
+
+	var seeds [][]byte
+	for _, msg := range []util.Message{
+		NewEchoRequest(), NewFeaturesReply(), flowMod, groupMod, newBenchmarkPacketIn(), newBenchmarkFlowStatsReply(),
+		experimenterReply, NewTLVTableRequest(), NewMeterMod(), NewPortStatus(),
+	} {
+		data, err := msg.MarshalBinary()
+		if err == nil {
+			seeds = append(seeds, data)
+		}
+	}
+	return seeds
+}
+
+// FuzzParse checks Parse and ParseWithOptions never panic or loop forever on arbitrary input, and all the errors
+// are DecodeError in strict mode, run it with go test -fuzz FuzzParse ./openflow13.
+func FuzzParse(f *testing.F) {
+	for _, seed := range fuzzSeeds() {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Parse(data)
+		ParseWithOptions(data, DecodeOptions{Borrow: true})
+		var decodeErr *DecodeError
+		if _, err := ParseWithOptions(data, DecodeOptions{Strict: true}); err != nil && !errors.As(err, &decodeErr) {
+			t.Errorf("Expect DecodeError in strict mode, actual: %v", err)
+		}
+	})
+}
+
+func TestDecodeInnerLengths(t *testing.T) {
+	// A resubmit action declaring a length shorter than its fixed fields.
+	resubmit, _ := NewNXActionResubmitTableAction(1, 2).MarshalBinary()
+	resubmit[3] = 12
+	if _, err := DecodeAction(resubmit[:12]); err == nil {
+		t.Errorf("Expect error to decode an NX action shorter than its fixed fields")
+	}
+
+	// An apply actions instruction declaring a length beyond the data.
+	instr := NewInstrApplyActions()
+	instr.AddAction(NewActionOutput(1), false)
+	data, _ := instr.MarshalBinary()
+	if err := new(InstrActions).UnmarshalBinary(data[:len(data)-4]); err == nil {
+		t.Errorf("Expect error to decode a truncated instruction")
+	}
+
+	// A match field whose value is cut short.
+	field, _ := NewInPortField(1).MarshalBinary()
+	if err := new(MatchField).UnmarshalBinary(field[:6]); err == nil {
+		t.Errorf("Expect error to decode a truncated match field")
+	}
+}
@@ -0,0 +1,246 @@
+//go:build ignore
+// +build ignore
+
+// This program generates schema.json, a machine-readable manifest of the messages, actions, instructions and
+// match fields supported by the openflow13 package. Run it with "go generate" in the openflow13 directory, and
+// check in the result. An entry is marked as supported if the package decodes it into a typed message. The schema
+// is written to the file given as the argument in place of schema.json, which is used to check the drift in tests.
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"go/ast"
+	"go/constant"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/contiv/libOpenflow/openflow13"
+	"github.com/contiv/libOpenflow/util"
+)
+
+type constEntry struct {
+	Name      string `json:"name"`
+	Value     uint64 `json:"value"`
+	Supported bool   `json:"supported"`
+}
+
+type matchFieldEntry struct {
+	Name           string `json:"name"`
+	Class          uint16 `json:"class"`
+	Field          uint8  `json:"field"`
+	Length         uint8  `json:"length"`
+	ExperimenterID uint32 `json:"experimenter_id,omitempty"`
+}
+
+type schema struct {
+	Package              string            `json:"package"`
+	Version              uint8             `json:"version"`
+	Messages             []constEntry      `json:"messages"`
+	ExperimenterMessages []constEntry      `json:"experimenter_messages"`
+	MultipartTypes       []constEntry      `json:"multipart_types"`
+	Actions              []constEntry      `json:"actions"`
+	NXActions            []constEntry      `json:"nx_actions"`
+	Instructions         []constEntry      `json:"instructions"`
+	MatchFields          []matchFieldEntry `json:"match_fields"`
+}
+
+// packageConstants returns the integer constants declared in the files of the package by name prefix, and
+// the names of the match fields in oxxFieldHeaderMap.
+func packageConstants() (map[string][]constEntry, []string) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go") && info.Name() != "gen_schema.go"
+	}, 0)
+	if err != nil {
+		log.Fatalf("Failed to parse package: %v", err)
+	}
+	var files []*ast.File
+	for _, f := range pkgs["openflow13"].Files {
+		files = append(files, f)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("openflow13", fset, files, nil)
+	if err != nil {
+		log.Fatalf("Failed to type check package: %v", err)
+	}
+
+	consts := make(map[string][]constEntry)
+	for _, name := range pkg.Scope().Names() {
+		c, ok := pkg.Scope().Lookup(name).(*types.Const)
+		if !ok || c.Val().Kind() != constant.Int {
+			continue
+		}
+		value, ok := constant.Uint64Val(c.Val())
+		if !ok {
+			continue
+		}
+		file := filepath.Base(fset.Position(c.Pos()).Filename)
+		prefix := name[:strings.Index(name, "_")+1]
+		if prefix == "Type_" && (file == "nxt_message.go" || file == "bundles.go") {
+			prefix = "ExperimenterType_"
+		}
+		consts[prefix] = append(consts[prefix], constEntry{Name: name, Value: value})
+	}
+
+	var fieldNames []string
+	for _, f := range files {
+		ast.Inspect(f, func(node ast.Node) bool {
+			spec, ok := node.(*ast.ValueSpec)
+			if !ok || len(spec.Names) != 1 || spec.Names[0].Name != "oxxFieldHeaderMap" {
+				return true
+			}
+			for _, elt := range spec.Values[0].(*ast.CompositeLit).Elts {
+				key := elt.(*ast.KeyValueExpr).Key.(*ast.BasicLit)
+				name, _ := strconv.Unquote(key.Value)
+				fieldNames = append(fieldNames, name)
+			}
+			return false
+		})
+	}
+	sort.Strings(fieldNames)
+	return consts, fieldNames
+}
+
+// newMessageData returns the data of a message with the given type and body.
+func newMessageData(msgType uint8, body []byte) []byte {
+	data := make([]byte, 8+len(body))
+	data[0] = openflow13.VERSION
+	data[1] = msgType
+	binary.BigEndian.PutUint16(data[2:], uint16(len(data)))
+	copy(data[8:], body)
+	return data
+}
+
+func sortEntries(entries []constEntry) []constEntry {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Value != entries[j].Value {
+			return entries[i].Value < entries[j].Value
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+func main() {
+	consts, fieldNames := packageConstants()
+	s := schema{Package: "openflow13", Version: openflow13.VERSION}
+
+	for _, e := range consts["Type_"] {
+		// A message type is supported if Parse decodes it into a message.
+		msg, _ := openflow13.Parse(newMessageData(uint8(e.Value), make([]byte, 64)))
+		e.Supported = msg != nil
+		s.Messages = append(s.Messages, e)
+	}
+	for _, e := range consts["ExperimenterType_"] {
+		for _, vendor := range []uint32{openflow13.NxExperimenterID, openflow13.ONF_EXPERIMENTER_ID} {
+			body := make([]byte, 64)
+			binary.BigEndian.PutUint32(body[0:], vendor)
+			binary.BigEndian.PutUint32(body[4:], uint32(e.Value))
+			_, err := openflow13.Parse(newMessageData(openflow13.Type_Experimenter, body))
+			if err == nil || !strings.HasPrefix(err.Error(), "unsupported experimenter message type") {
+				e.Supported = true
+			}
+		}
+		s.ExperimenterMessages = append(s.ExperimenterMessages, e)
+	}
+	for _, e := range consts["MultipartType_"] {
+		// A multipart type is supported if its reply body is not kept as raw bytes in OpenFlow 1.3 or 1.5, as some
+		// bodies are only decoded in the replies of OpenFlow 1.5. Raw bytes are always accepted, so an error or a
+		// panic on the zero body also means the type is decoded.
+		body := make([]byte, 8+64)
+		binary.BigEndian.PutUint16(body[0:], uint16(e.Value))
+		for _, version := range []uint8{openflow13.VERSION, openflow13.OFP15_VERSION} {
+			reply := new(openflow13.MultipartReply)
+			data := newMessageData(openflow13.Type_MultiPartReply, body)
+			data[0] = version
+			e.Supported = e.Supported || func() (decoded bool) {
+				defer func() {
+					if recover() != nil {
+						decoded = true
+					}
+				}()
+				if err := reply.UnmarshalBinary(data); err != nil {
+					return true
+				}
+				if len(reply.Body) == 0 {
+					return false
+				}
+				_, raw := reply.Body[0].(*util.Buffer)
+				return !raw
+			}()
+		}
+		s.MultipartTypes = append(s.MultipartTypes, e)
+	}
+	for _, e := range consts["ActionType_"] {
+		data := make([]byte, 64)
+		data[0], data[1] = uint8(e.Value>>8), uint8(e.Value)
+		data[3] = 16
+		if e.Value == openflow13.ActionType_Experimenter {
+			binary.BigEndian.PutUint32(data[4:], openflow13.NxExperimenterID)
+			binary.BigEndian.PutUint16(data[8:], openflow13.NXAST_RESUBMIT)
+		} else {
+			// OXM_OF_IN_PORT as the field of set-field action.
+			copy(data[4:], []byte{0x80, 0x00, 0x00, 0x04})
+		}
+		_, err := openflow13.DecodeAction(data)
+		e.Supported = err == nil || !strings.HasPrefix(err.Error(), "unsupported action type")
+		s.Actions = append(s.Actions, e)
+	}
+	for _, e := range consts["NXAST_"] {
+		data := make([]byte, 16)
+		data[8], data[9] = uint8(e.Value>>8), uint8(e.Value)
+		e.Supported = openflow13.DecodeNxAction(data) != nil
+		s.NXActions = append(s.NXActions, e)
+	}
+	for _, e := range consts["InstrType_"] {
+		data := make([]byte, 64)
+		data[0], data[1] = uint8(e.Value>>8), uint8(e.Value)
+		// The instructions without a list are 8 bytes long, except the 24-byte OFPIT_WRITE_METADATA.
+		for _, length := range []uint8{8, 24} {
+			data[3] = length
+			e.Supported = e.Supported || openflow13.DecodeInstr(data) != nil
+		}
+		s.Instructions = append(s.Instructions, e)
+	}
+	for _, name := range fieldNames {
+		header, err := openflow13.FindFieldHeaderByName(name, false)
+		if err != nil {
+			log.Fatalf("Failed to find match field %s: %v", name, err)
+		}
+		length := header.Length
+		if header.ExperimenterID != 0 {
+			length -= 4
+		}
+		s.MatchFields = append(s.MatchFields, matchFieldEntry{
+			Name:           name,
+			Class:          header.Class,
+			Field:          header.Field,
+			Length:         length,
+			ExperimenterID: header.ExperimenterID,
+		})
+	}
+	for _, entries := range [][]constEntry{s.Messages, s.ExperimenterMessages, s.MultipartTypes, s.Actions, s.NXActions, s.Instructions} {
+		sortEntries(entries)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to marshal schema: %v", err)
+	}
+	output := "schema.json"
+	if len(os.Args) > 1 {
+		output = os.Args[1]
+	}
+	if err := os.WriteFile(output, append(data, '\n'), 0644); err != nil {
+		log.Fatalf("Failed to write schema: %v", err)
+	}
+}
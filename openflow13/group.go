@@ -4,17 +4,11 @@ package openflow13
 
 import (
 	"encoding/binary"
+	"errors"
+	"fmt"
 
 	"github.com/contiv/libOpenflow/common"
-	log "github.com/sirupsen/logrus"
-)
-
-const (
-	OFPG_MAX = 0xffffff00 /* Last usable group number. */
-	/* Fake groups. */
-	OFPG_ALL = 0xfffffffc /* Represents all groups for group delete commands. */
-	OFPG_ANY = 0xffffffff /* Wildcard group used only for flow stats requests. Selects all flows regardless of group (including flows with no group).
-	 */
+	"github.com/contiv/libOpenflow/util"
 )
 
 const (
@@ -23,6 +17,8 @@ const (
 	OFPGC_DELETE        = 2 /* Delete all matching groups. */
 	OFPGC_INSERT_BUCKET = 3 /* Insert action buckets to the already available
 	list of action buckets in a matching group */
+	OFPGC_REMOVE_BUCKET = 4 /* Remove all action buckets or any specific action bucket from matching group, since
+	OpenFlow 1.5. */
 )
 
 const (
@@ -93,18 +89,20 @@ func (g *GroupMod) MarshalBinary() (data []byte, err error) {
 	for _, bkt := range g.Buckets {
 		bytes, err = bkt.MarshalBinary()
 		data = append(data, bytes...)
-		log.Debugf("Groupmod bucket: %v", bytes)
 	}
 
-	log.Debugf("GroupMod(%d): %v", len(data), data)
-
 	return
 }
 
 func (g *GroupMod) UnmarshalBinary(data []byte) error {
 	n := 0
-	g.Header.UnmarshalBinary(data[n:])
+	if err := g.Header.UnmarshalBinary(data[n:]); err != nil {
+		return err
+	}
 	n += int(g.Header.Len())
+	if len(data) < 16 || int(g.Header.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full GroupMod message")
+	}
 
 	g.Command = binary.BigEndian.Uint16(data[n:])
 	n += 2
@@ -117,9 +115,14 @@ func (g *GroupMod) UnmarshalBinary(data []byte) error {
 
 	for n < int(g.Header.Length) {
 		bkt := new(Bucket)
-		bkt.UnmarshalBinary(data[n:])
+		if err := bkt.UnmarshalBinary(data[n:g.Header.Length]); err != nil {
+			return decodeErrorAt(err, "GroupMod", n, "bucket")
+		}
 		g.Buckets = append(g.Buckets, *bkt)
-		n += int(bkt.Len())
+		var err error
+		if n, err = safeAdvance(n, bkt.Length, int(g.Header.Length), "bucket"); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -130,7 +133,7 @@ type Bucket struct {
 	Weight     uint16   /* Relative weight of bucket. Only defined for select groups. */
 	WatchPort  uint32   /* Used for FRR groups */
 	WatchGroup uint32   /* Used for FRR groups */
-	pad        []byte   /* 4 bytes */
+	pad        [4]byte  /* 4 bytes */
 	Actions    []Action /* zero or more actions */
 }
 
@@ -139,7 +142,6 @@ func NewBucket() *Bucket {
 	bkt := new(Bucket)
 
 	bkt.Weight = 0
-	bkt.pad = make([]byte, 4)
 	bkt.Actions = make([]Action, 0)
 	bkt.WatchPort = P_ANY
 	bkt.WatchGroup = OFPG_ANY
@@ -188,9 +190,15 @@ func (b *Bucket) MarshalBinary() (data []byte, err error) {
 }
 
 func (b *Bucket) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("the []byte is too short to unmarshal a full Bucket message")
+	}
 	n := 0
 	b.Length = binary.BigEndian.Uint16(data[n:])
 	n += 2
+	if int(b.Length) > len(data) || b.Length < 16 {
+		return errors.New("the []byte is too short to unmarshal a full Bucket message")
+	}
 	b.Weight = binary.BigEndian.Uint16(data[n:])
 	n += 2
 	b.WatchPort = binary.BigEndian.Uint32(data[n:])
@@ -200,13 +208,696 @@ func (b *Bucket) UnmarshalBinary(data []byte) error {
 	n += 4 // for padding
 
 	for n < int(b.Length) {
-		a, err := DecodeAction(data[n:])
+		a, err := DecodeAction(data[n:b.Length])
 		if err != nil {
+			return decodeErrorAt(err, "Bucket", n, "action")
+		}
+		b.Actions = append(b.Actions, a)
+		if n, err = safeAdvance(n, a.Len(), int(b.Length), "action"); err != nil {
 			return err
 		}
+	}
+
+	return nil
+}
+
+// ofp_group_bucket_prop_type 1.5
+const (
+	OFPGBPT_WEIGHT       = 0      /* Select groups only. */
+	OFPGBPT_WATCH_PORT   = 1      /* Fast failover groups only. */
+	OFPGBPT_WATCH_GROUP  = 2      /* Fast failover groups only. */
+	OFPGBPT_EXPERIMENTER = 0xffff /* Experimenter defined. */
+)
+
+// GroupBucketPropHeader is the common header of all OpenFlow 1.5 bucket properties.
+type GroupBucketPropHeader struct {
+	Type   uint16
+	Length uint16
+}
+
+func (p *GroupBucketPropHeader) Len() uint16 {
+	return 4
+}
+
+func (p *GroupBucketPropHeader) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, p.Len())
+	binary.BigEndian.PutUint16(data[0:], p.Type)
+	binary.BigEndian.PutUint16(data[2:], p.Length)
+	return
+}
+
+func (p *GroupBucketPropHeader) UnmarshalBinary(data []byte) error {
+	if len(data) < int(p.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full GroupBucketPropHeader message")
+	}
+	p.Type = binary.BigEndian.Uint16(data[0:])
+	p.Length = binary.BigEndian.Uint16(data[2:])
+	return nil
+}
+
+// GroupBucketPropWeight is the weight of the bucket in a select group.
+type GroupBucketPropWeight struct {
+	GroupBucketPropHeader
+	Weight uint16
+	pad    [2]byte // 2 bytes
+}
+
+func NewGroupBucketPropWeight(weight uint16) *GroupBucketPropWeight {
+	p := new(GroupBucketPropWeight)
+	p.Type = OFPGBPT_WEIGHT
+	p.Length = p.Len()
+	p.Weight = weight
+	return p
+}
+
+func (p *GroupBucketPropWeight) Len() uint16 {
+	return p.GroupBucketPropHeader.Len() + 4
+}
+
+func (p *GroupBucketPropWeight) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, p.Len())
+	p.Length = p.Len()
+	b, err := p.GroupBucketPropHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	binary.BigEndian.PutUint16(data[n:], p.Weight)
+	return
+}
+
+func (p *GroupBucketPropWeight) UnmarshalBinary(data []byte) error {
+	if len(data) < int(p.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full GroupBucketPropWeight message")
+	}
+	if err := p.GroupBucketPropHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	p.Weight = binary.BigEndian.Uint16(data[p.GroupBucketPropHeader.Len():])
+	return nil
+}
+
+// GroupBucketPropWatch is the port or group whose liveness is watched by the bucket in a fast failover group,
+// Type is OFPGBPT_WATCH_PORT or OFPGBPT_WATCH_GROUP.
+type GroupBucketPropWatch struct {
+	GroupBucketPropHeader
+	Watch uint32
+}
+
+func NewGroupBucketPropWatchPort(port uint32) *GroupBucketPropWatch {
+	p := new(GroupBucketPropWatch)
+	p.Type = OFPGBPT_WATCH_PORT
+	p.Length = p.Len()
+	p.Watch = port
+	return p
+}
+
+func NewGroupBucketPropWatchGroup(group uint32) *GroupBucketPropWatch {
+	p := NewGroupBucketPropWatchPort(group)
+	p.Type = OFPGBPT_WATCH_GROUP
+	return p
+}
+
+func (p *GroupBucketPropWatch) Len() uint16 {
+	return p.GroupBucketPropHeader.Len() + 4
+}
+
+func (p *GroupBucketPropWatch) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, p.Len())
+	p.Length = p.Len()
+	b, err := p.GroupBucketPropHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	binary.BigEndian.PutUint32(data[n:], p.Watch)
+	return
+}
+
+func (p *GroupBucketPropWatch) UnmarshalBinary(data []byte) error {
+	if len(data) < int(p.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full GroupBucketPropWatch message")
+	}
+	if err := p.GroupBucketPropHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	p.Watch = binary.BigEndian.Uint32(data[p.GroupBucketPropHeader.Len():])
+	return nil
+}
+
+// GroupBucketPropUnknown keeps the raw data of the bucket property which is not supported, e.g., experimenter
+// property.
+type GroupBucketPropUnknown struct {
+	GroupBucketPropHeader
+	Data []byte
+}
+
+func (p *GroupBucketPropUnknown) Len() uint16 {
+	return p.GroupBucketPropHeader.Len() + uint16(len(p.Data))
+}
+
+func (p *GroupBucketPropUnknown) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, p.Len())
+	p.Length = p.Len()
+	b, err := p.GroupBucketPropHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	copy(data[n:], p.Data)
+	return
+}
+
+func (p *GroupBucketPropUnknown) UnmarshalBinary(data []byte) error {
+	if err := p.GroupBucketPropHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if len(data) < int(p.Length) || p.Length < p.GroupBucketPropHeader.Len() {
+		return errors.New("the []byte is too short to unmarshal a full GroupBucketPropUnknown message")
+	}
+	p.Data = make([]byte, int(p.Length-p.GroupBucketPropHeader.Len()))
+	copy(p.Data, data[p.GroupBucketPropHeader.Len():p.Length])
+	return nil
+}
+
+// DecodeGroupBucketProp decodes a bucket property according to its type.
+func DecodeGroupBucketProp(data []byte) (util.Message, error) {
+	header := new(GroupBucketPropHeader)
+	if err := header.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	if header.Length < header.Len() || int(header.Length) > len(data) {
+		return nil, errors.New("the []byte is too short to unmarshal a full bucket property")
+	}
+	// The property is decoded within its own length.
+	data = data[:header.Length]
+	var prop util.Message
+	switch header.Type {
+	case OFPGBPT_WEIGHT:
+		prop = new(GroupBucketPropWeight)
+	case OFPGBPT_WATCH_PORT, OFPGBPT_WATCH_GROUP:
+		prop = new(GroupBucketPropWatch)
+	default:
+		prop = new(GroupBucketPropUnknown)
+	}
+	if err := prop.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return prop, nil
+}
+
+// Bucket15 is the bucket layout since OpenFlow 1.5. Every bucket has an ID, and the weight and watch port/group
+// are carried in properties instead of fixed fields.
+type Bucket15 struct {
+	Length         uint16 /* Length the bucket in bytes, including this header and any padding to make it 64-bit aligned. */
+	ActionArrayLen uint16 /* Length of all actions in bytes. */
+	BucketId       uint32
+	Actions        []Action       /* zero or more actions */
+	Properties     []util.Message /* zero or more bucket properties */
+}
+
+// NewBucket15 creates a Bucket15 with the given bucket ID.
+func NewBucket15(bucketID uint32) *Bucket15 {
+	bkt := new(Bucket15)
+	bkt.BucketId = bucketID
+	bkt.Actions = make([]Action, 0)
+	bkt.Properties = make([]util.Message, 0)
+	bkt.Length = bkt.Len()
+	return bkt
+}
+
+// Add an action to the bucket
+func (b *Bucket15) AddAction(act Action) {
+	b.Actions = append(b.Actions, act)
+}
+
+// Add a property to the bucket
+func (b *Bucket15) AddProperty(prop util.Message) {
+	b.Properties = append(b.Properties, prop)
+}
+
+func (b *Bucket15) actionsLen() (n uint16) {
+	for _, a := range b.Actions {
+		n += a.Len()
+	}
+	return
+}
+
+func (b *Bucket15) Len() (n uint16) {
+	n = 8
+	n += b.actionsLen()
+	for _, p := range b.Properties {
+		n += p.Len()
+	}
+	// Round it to closest multiple of 8
+	n = ((n + 7) / 8) * 8
+	return
+}
+
+func (b *Bucket15) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(b.Len()))
+	n := 0
+	b.Length = b.Len()
+	b.ActionArrayLen = b.actionsLen()
+	binary.BigEndian.PutUint16(data[n:], b.Length)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], b.ActionArrayLen)
+	n += 2
+	binary.BigEndian.PutUint32(data[n:], b.BucketId)
+	n += 4
+
+	for _, a := range b.Actions {
+		bytes, err := a.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		copy(data[n:], bytes)
+		n += len(bytes)
+	}
+	for _, p := range b.Properties {
+		bytes, err := p.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		copy(data[n:], bytes)
+		n += len(bytes)
+	}
+	return
+}
+
+func (b *Bucket15) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("the []byte is too short to unmarshal a full Bucket15 message")
+	}
+	n := 0
+	b.Length = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	b.ActionArrayLen = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	b.BucketId = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	if len(data) < int(b.Length) || int(b.Length) < n+int(b.ActionArrayLen) {
+		return errors.New("the []byte is too short to unmarshal a full Bucket15 message")
+	}
+
+	b.Actions = make([]Action, 0)
+	actionsEnd := n + int(b.ActionArrayLen)
+	for n < actionsEnd {
+		a, err := DecodeAction(data[n:actionsEnd])
+		if err != nil {
+			return decodeErrorAt(err, "Bucket15", n, "action")
+		}
 		b.Actions = append(b.Actions, a)
-		n += int(a.Len())
+		if n, err = safeAdvance(n, a.Len(), actionsEnd, "action"); err != nil {
+			return err
+		}
+	}
+
+	b.Properties = make([]util.Message, 0)
+	// The bucket is padded to 64 bits, and the padding is shorter than any property.
+	for int(b.Length)-n >= minBucketPropLen {
+		header := new(GroupBucketPropHeader)
+		if err := header.UnmarshalBinary(data[n:b.Length]); err != nil {
+			return decodeErrorAt(err, "Bucket15", n, "property")
+		}
+		next, err := safeAdvance(n, header.Length, int(b.Length), "bucket property")
+		if err != nil {
+			return err
+		}
+		p, err := DecodeGroupBucketProp(data[n:next])
+		if err != nil {
+			return decodeErrorAt(err, "Bucket15", n, "property")
+		}
+		b.Properties = append(b.Properties, p)
+		n = next
+	}
+	return nil
+}
+
+// minBucketPropLen is the length of the shortest bucket properties, i.e., the weight and watch properties.
+const minBucketPropLen = 8
+
+// ToBucket15 converts the OpenFlow 1.3 bucket to the OpenFlow 1.5 layout. The weight property is only added for
+// select groups, and the watch properties are only added for fast failover groups.
+func (b *Bucket) ToBucket15(bucketID uint32, groupType uint8) *Bucket15 {
+	bkt := NewBucket15(bucketID)
+	bkt.Actions = append(bkt.Actions, b.Actions...)
+	switch groupType {
+	case OFPGT_SELECT:
+		bkt.AddProperty(NewGroupBucketPropWeight(b.Weight))
+	case OFPGT_FF:
+		if b.WatchPort != P_ANY {
+			bkt.AddProperty(NewGroupBucketPropWatchPort(b.WatchPort))
+		}
+		if b.WatchGroup != OFPG_ANY {
+			bkt.AddProperty(NewGroupBucketPropWatchGroup(b.WatchGroup))
+		}
+	}
+	return bkt
+}
+
+// ToBucket converts the OpenFlow 1.5 bucket to the OpenFlow 1.3 layout, the bucket ID and the unknown properties
+// are dropped.
+func (b *Bucket15) ToBucket() *Bucket {
+	bkt := NewBucket()
+	bkt.Actions = append(bkt.Actions, b.Actions...)
+	for _, prop := range b.Properties {
+		switch p := prop.(type) {
+		case *GroupBucketPropWeight:
+			bkt.Weight = p.Weight
+		case *GroupBucketPropWatch:
+			if p.Type == OFPGBPT_WATCH_PORT {
+				bkt.WatchPort = p.Watch
+			} else {
+				bkt.WatchGroup = p.Watch
+			}
+		}
+	}
+	bkt.Length = bkt.Len()
+	return bkt
+}
+
+// GroupMod15 is the ofp_group_mod 1.5. The buckets have IDs, so that OFPGC_INSERT_BUCKET and OFPGC_REMOVE_BUCKET
+// modify the buckets before or of CommandBucketId without re-sending the whole group.
+type GroupMod15 struct {
+	common.Header
+	Command         uint16     /* One of OFPGC_*. */
+	Type            uint8      /* One of OFPGT_*. */
+	pad             uint8      /* Pad to 64 bits. */
+	GroupId         uint32     /* Group identifier. */
+	BucketArrayLen  uint16     /* Length of action buckets data. */
+	pad2            [2]byte    /* Pad to 64 bits. */
+	CommandBucketId uint32     /* Bucket ID used as the position of OFPGC_INSERT_BUCKET and OFPGC_REMOVE_BUCKET. */
+	Buckets         []Bucket15 /* List of buckets */
+	Properties      []byte     /* Raw group properties, i.e., the experimenter properties. */
+}
+
+// NewGroupMod15 returns an OpenFlow 1.5 group mod of the command, the group type and the group ID, whose
+// CommandBucketId is OFPG_BUCKET_ALL as required by OFPGC_ADD, OFPGC_MODIFY and OFPGC_DELETE.
+func NewGroupMod15(command uint16, groupType uint8, groupID uint32) *GroupMod15 {
+	g := new(GroupMod15)
+	g.Header = NewOfp13Header()
+	g.Header.Version = OFP15_VERSION
+	g.Header.Type = Type_GroupMod
+	g.Command = command
+	g.Type = groupType
+	g.GroupId = groupID
+	g.CommandBucketId = OFPG_BUCKET_ALL
+	g.Buckets = make([]Bucket15, 0)
+	return g
+}
+
+// NewInsertBucket returns the OFPGC_INSERT_BUCKET group mod which inserts the buckets before the bucket of
+// commandBucketID, or at the start or the end of the bucket list with OFPG_BUCKET_FIRST and OFPG_BUCKET_LAST.
+// The group type must be the type of the existing group.
+func NewInsertBucket(groupType uint8, groupID uint32, commandBucketID uint32, buckets ...*Bucket15) *GroupMod15 {
+	g := NewGroupMod15(OFPGC_INSERT_BUCKET, groupType, groupID)
+	g.CommandBucketId = commandBucketID
+	for _, bkt := range buckets {
+		g.AddBucket(*bkt)
+	}
+	return g
+}
+
+// NewRemoveBucket returns the OFPGC_REMOVE_BUCKET group mod which removes the bucket of commandBucketID, the
+// first or the last bucket with OFPG_BUCKET_FIRST and OFPG_BUCKET_LAST, or all the buckets with OFPG_BUCKET_ALL.
+func NewRemoveBucket(groupID uint32, commandBucketID uint32) *GroupMod15 {
+	g := NewGroupMod15(OFPGC_REMOVE_BUCKET, OFPGT_ALL, groupID)
+	g.CommandBucketId = commandBucketID
+	return g
+}
+
+// Add a bucket to group mod
+func (g *GroupMod15) AddBucket(bkt Bucket15) {
+	g.Buckets = append(g.Buckets, bkt)
+}
+
+func (g *GroupMod15) bucketsLen() (n uint16) {
+	for _, b := range g.Buckets {
+		n += b.Len()
+	}
+	return
+}
+
+func (g *GroupMod15) Len() (n uint16) {
+	return g.Header.Len() + 16 + g.bucketsLen() + uint16(len(g.Properties))
+}
+
+func (g *GroupMod15) MarshalBinary() (data []byte, err error) {
+	g.Header.Length = g.Len()
+	g.BucketArrayLen = g.bucketsLen()
+	data, err = g.Header.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	bytes := make([]byte, 16)
+	n := 0
+	binary.BigEndian.PutUint16(bytes[n:], g.Command)
+	n += 2
+	bytes[n] = g.Type
+	n += 1
+	bytes[n] = g.pad
+	n += 1
+	binary.BigEndian.PutUint32(bytes[n:], g.GroupId)
+	n += 4
+	binary.BigEndian.PutUint16(bytes[n:], g.BucketArrayLen)
+	n += 2
+	n += 2 // for padding
+	binary.BigEndian.PutUint32(bytes[n:], g.CommandBucketId)
+	data = append(data, bytes...)
+
+	for _, bkt := range g.Buckets {
+		bytes, err = bkt.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, bytes...)
 	}
+	data = append(data, g.Properties...)
+
+	return
+}
+
+func (g *GroupMod15) UnmarshalBinary(data []byte) error {
+	n := 0
+	if err := g.Header.UnmarshalBinary(data[n:]); err != nil {
+		return err
+	}
+	n += int(g.Header.Len())
+	if len(data) < 24 || int(g.Header.Length) > len(data) || g.Header.Length < 24 {
+		return errors.New("the []byte is too short to unmarshal a full GroupMod15 message")
+	}
+
+	g.Command = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	g.Type = data[n]
+	n += 1
+	g.pad = data[n]
+	n += 1
+	g.GroupId = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	g.BucketArrayLen = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	n += 2 // for padding
+	g.CommandBucketId = binary.BigEndian.Uint32(data[n:])
+	n += 4
 
+	bucketsEnd := n + int(g.BucketArrayLen)
+	if bucketsEnd > int(g.Header.Length) {
+		return errors.New("the []byte is too short to unmarshal the buckets of GroupMod15")
+	}
+	g.Buckets = make([]Bucket15, 0)
+	for n < bucketsEnd {
+		bkt := new(Bucket15)
+		if err := bkt.UnmarshalBinary(data[n:bucketsEnd]); err != nil {
+			return decodeErrorAt(err, "GroupMod15", n, "bucket")
+		}
+		g.Buckets = append(g.Buckets, *bkt)
+		var err error
+		if n, err = safeAdvance(n, bkt.Length, bucketsEnd, "bucket"); err != nil {
+			return err
+		}
+	}
+	g.Properties = nil
+	if n < int(g.Header.Length) {
+		g.Properties = append([]byte(nil), data[n:g.Header.Length]...)
+	}
+	return nil
+}
+
+// Validate checks the command bucket ID and the buckets of the group mod, as the switch rejects them with
+// OFPGMFC_BAD_COMMAND, OFPGMFC_UNKNOWN_BUCKET or OFPGMFC_BUCKET_EXISTS otherwise. The bucket IDs must be unique
+// and not reserved, and the buckets of a select group must carry a weight property.
+func (g *GroupMod15) Validate() error {
+	switch g.Command {
+	case OFPGC_ADD, OFPGC_MODIFY, OFPGC_DELETE:
+		if g.CommandBucketId != OFPG_BUCKET_ALL {
+			return fmt.Errorf("command bucket ID 0x%x of group command %d is not OFPG_BUCKET_ALL", g.CommandBucketId, g.Command)
+		}
+	case OFPGC_INSERT_BUCKET:
+		if g.CommandBucketId > OFPG_BUCKET_MAX && g.CommandBucketId != OFPG_BUCKET_FIRST && g.CommandBucketId != OFPG_BUCKET_LAST {
+			return fmt.Errorf("invalid command bucket ID 0x%x to insert buckets", g.CommandBucketId)
+		}
+		if len(g.Buckets) == 0 {
+			return errors.New("no bucket to insert")
+		}
+	case OFPGC_REMOVE_BUCKET:
+		if g.CommandBucketId > OFPG_BUCKET_MAX && g.CommandBucketId != OFPG_BUCKET_FIRST &&
+			g.CommandBucketId != OFPG_BUCKET_LAST && g.CommandBucketId != OFPG_BUCKET_ALL {
+			return fmt.Errorf("invalid command bucket ID 0x%x to remove buckets", g.CommandBucketId)
+		}
+		if len(g.Buckets) != 0 {
+			return errors.New("buckets are not allowed to remove buckets")
+		}
+	default:
+		return fmt.Errorf("unknown group command %d", g.Command)
+	}
+
+	bucketIDs := make(map[uint32]bool, len(g.Buckets))
+	for i := range g.Buckets {
+		bkt := &g.Buckets[i]
+		if bkt.BucketId > OFPG_BUCKET_MAX {
+			return fmt.Errorf("bucket %d has the reserved bucket ID 0x%x", i, bkt.BucketId)
+		}
+		if bucketIDs[bkt.BucketId] {
+			return fmt.Errorf("bucket %d has the duplicate bucket ID %d", i, bkt.BucketId)
+		}
+		bucketIDs[bkt.BucketId] = true
+		if g.Type == OFPGT_SELECT && bkt.Weight() == nil {
+			return fmt.Errorf("bucket %d of the select group has no weight", bkt.BucketId)
+		}
+	}
+	return nil
+}
+
+// Weight returns the weight property of the bucket, or nil if there is none.
+func (b *Bucket15) Weight() *GroupBucketPropWeight {
+	for _, prop := range b.Properties {
+		if p, ok := prop.(*GroupBucketPropWeight); ok {
+			return p
+		}
+	}
+	return nil
+}
+
+// WatchPort returns the port watched by the bucket, or P_ANY if there is none.
+func (b *Bucket15) WatchPort() uint32 {
+	return b.watch(OFPGBPT_WATCH_PORT, P_ANY)
+}
+
+// WatchGroup returns the group watched by the bucket, or OFPG_ANY if there is none.
+func (b *Bucket15) WatchGroup() uint32 {
+	return b.watch(OFPGBPT_WATCH_GROUP, OFPG_ANY)
+}
+
+func (b *Bucket15) watch(propType uint16, none uint32) uint32 {
+	for _, prop := range b.Properties {
+		if p, ok := prop.(*GroupBucketPropWatch); ok && p.Type == propType {
+			return p.Watch
+		}
+	}
+	return none
+}
+
+// GroupDesc15 is the ofp_group_desc 1.5, the body of the OFPMP_GROUP_DESC reply of OpenFlow 1.5. The buckets have
+// IDs and properties in the Bucket15 layout.
+type GroupDesc15 struct {
+	Length         uint16     /* Length of this entry. */
+	Type           uint8      /* One of OFPGT_*. */
+	pad            uint8      /* Pad to 64 bits. */
+	GroupId        uint32     /* Group identifier. */
+	BucketArrayLen uint16     /* Length of action buckets data. */
+	pad2           [6]byte    /* Pad to 64 bits. */
+	Buckets        []Bucket15 /* List of buckets */
+	Properties     []byte     /* Raw group properties, i.e., the experimenter properties. */
+}
+
+func NewGroupDesc15(groupType uint8, groupID uint32) *GroupDesc15 {
+	d := new(GroupDesc15)
+	d.Type = groupType
+	d.GroupId = groupID
+	d.Buckets = make([]Bucket15, 0)
+	d.Length = d.Len()
+	return d
+}
+
+func (d *GroupDesc15) bucketsLen() (n uint16) {
+	for _, b := range d.Buckets {
+		n += b.Len()
+	}
+	return
+}
+
+func (d *GroupDesc15) Len() (n uint16) {
+	return 16 + d.bucketsLen() + uint16(len(d.Properties))
+}
+
+func (d *GroupDesc15) MarshalBinary() (data []byte, err error) {
+	d.Length = d.Len()
+	d.BucketArrayLen = d.bucketsLen()
+	data = make([]byte, 16)
+	n := 0
+	binary.BigEndian.PutUint16(data[n:], d.Length)
+	n += 2
+	data[n] = d.Type
+	n += 1
+	n += 1 // for pad
+	binary.BigEndian.PutUint32(data[n:], d.GroupId)
+	n += 4
+	binary.BigEndian.PutUint16(data[n:], d.BucketArrayLen)
+	n += 2
+	n += 6 // for pad2
+
+	for _, bkt := range d.Buckets {
+		bytes, err := bkt.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, bytes...)
+	}
+	data = append(data, d.Properties...)
+	return
+}
+
+func (d *GroupDesc15) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("the []byte is too short to unmarshal a full GroupDesc15 message")
+	}
+	n := 0
+	d.Length = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	if d.Length < 16 || int(d.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full GroupDesc15 message")
+	}
+	d.Type = data[n]
+	n += 1
+	n += 1 // for pad
+	d.GroupId = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	d.BucketArrayLen = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	n += 6 // for pad2
+
+	bucketsEnd := n + int(d.BucketArrayLen)
+	if bucketsEnd > int(d.Length) {
+		return errors.New("the []byte is too short to unmarshal the buckets of GroupDesc15")
+	}
+	d.Buckets = make([]Bucket15, 0)
+	for n < bucketsEnd {
+		bkt := new(Bucket15)
+		if err := bkt.UnmarshalBinary(data[n:bucketsEnd]); err != nil {
+			return decodeErrorAt(err, "GroupDesc15", n, "bucket")
+		}
+		d.Buckets = append(d.Buckets, *bkt)
+		var err error
+		if n, err = safeAdvance(n, bkt.Length, bucketsEnd, "bucket"); err != nil {
+			return err
+		}
+	}
+	d.Properties = nil
+	if n < int(d.Length) {
+		d.Properties = append([]byte(nil), data[n:d.Length]...)
+	}
 	return nil
 }
@@ -0,0 +1,163 @@
+package openflow13
+
+import (
+	"testing"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+func TestGroupMod15Buckets(t *testing.T) {
+	bkt1 := NewBucket15(1)
+	bkt1.AddAction(NewActionOutput(1))
+	bkt1.AddProperty(NewGroupBucketPropWeight(50))
+	bkt2 := NewBucket15(2)
+	bkt2.AddAction(NewActionOutput(2))
+	bkt2.AddProperty(NewGroupBucketPropWeight(100))
+	insert := NewInsertBucket(OFPGT_SELECT, 10, OFPG_BUCKET_LAST, bkt1, bkt2)
+	if err := insert.Validate(); err != nil {
+		t.Fatalf("Unexpected invalid insert bucket: %v", err)
+	}
+
+	data, err := insert.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal GroupMod15: %v", err)
+	}
+	if len(data) != int(insert.Len()) || data[0] != OFP15_VERSION {
+		t.Errorf("Unexpected GroupMod15 length %d or version %d", len(data), data[0])
+	}
+	decoded := new(GroupMod15)
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal GroupMod15: %v", err)
+	}
+	if decoded.Command != OFPGC_INSERT_BUCKET || decoded.GroupId != 10 || decoded.CommandBucketId != OFPG_BUCKET_LAST ||
+		len(decoded.Buckets) != 2 || decoded.BucketArrayLen != bkt1.Len()+bkt2.Len() {
+		t.Fatalf("Unexpected GroupMod15: %+v", decoded)
+	}
+	if w := decoded.Buckets[1].Weight(); w == nil || w.Weight != 100 || decoded.Buckets[1].WatchPort() != P_ANY {
+		t.Errorf("Unexpected bucket properties: %+v", decoded.Buckets[1].Properties)
+	}
+
+	remove := NewRemoveBucket(10, 2)
+	data, _ = remove.MarshalBinary()
+	decoded = new(GroupMod15)
+	if err := decoded.UnmarshalBinary(data); err != nil || decoded.Command != OFPGC_REMOVE_BUCKET ||
+		decoded.CommandBucketId != 2 || len(decoded.Buckets) != 0 {
+		t.Errorf("Unexpected remove bucket: %+v, error: %v", decoded, err)
+	}
+
+	noWeight := NewBucket15(3)
+	ff := NewBucket15(4)
+	ff.AddProperty(NewGroupBucketPropWatchPort(5))
+	for name, tc := range map[string]struct {
+		g       *GroupMod15
+		invalid bool
+	}{
+		"remove all":            {NewRemoveBucket(10, OFPG_BUCKET_ALL), false},
+		"fast failover":         {NewInsertBucket(OFPGT_FF, 10, OFPG_BUCKET_FIRST, ff), false},
+		"select without weight": {NewInsertBucket(OFPGT_SELECT, 10, OFPG_BUCKET_FIRST, bkt1, noWeight), true},
+		"duplicate bucket ID":   {NewInsertBucket(OFPGT_SELECT, 10, OFPG_BUCKET_FIRST, bkt1, bkt1), true},
+		"insert before all":     {NewInsertBucket(OFPGT_SELECT, 10, OFPG_BUCKET_ALL, bkt1), true},
+		"insert nothing":        {NewInsertBucket(OFPGT_SELECT, 10, OFPG_BUCKET_LAST), true},
+		"reserved bucket ID":    {NewInsertBucket(OFPGT_ALL, 10, 1, NewBucket15(OFPG_BUCKET_FIRST)), true},
+		"add with bucket ID":    {&GroupMod15{Command: OFPGC_ADD, CommandBucketId: 1}, true},
+	} {
+		if err := tc.g.Validate(); (err != nil) != tc.invalid {
+			t.Errorf("%s: unexpected validation error: %v", name, err)
+		}
+	}
+	if ff.WatchPort() != 5 || ff.WatchGroup() != OFPG_ANY {
+		t.Errorf("Unexpected watch port %d or group %d", ff.WatchPort(), ff.WatchGroup())
+	}
+}
+
+func TestBucket15Properties(t *testing.T) {
+	bkt := NewBucket15(3)
+	bkt.AddAction(NewActionOutput(1))
+	bkt.AddProperty(NewGroupBucketPropWatchPort(2))
+	// The experimenter property of 13 bytes, the bucket is padded with 3 bytes.
+	bkt.AddProperty(&GroupBucketPropUnknown{GroupBucketPropHeader{Type: OFPGBPT_EXPERIMENTER}, make([]byte, 9)})
+	data, err := bkt.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal Bucket15: %v", err)
+	}
+	if len(data) != 8+16+8+16 {
+		t.Errorf("Expect %d bytes of Bucket15, actual: %d", 8+16+8+16, len(data))
+	}
+	decoded := new(Bucket15)
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal Bucket15: %v", err)
+	}
+	if len(decoded.Actions) != 1 || len(decoded.Properties) != 2 || decoded.WatchPort() != 2 ||
+		decoded.Properties[1].Len() != 13 {
+		t.Errorf("Unexpected Bucket15: %+v", decoded)
+	}
+
+	// The padding of 4 or more bytes must not be decoded as a property.
+	bkt = NewBucket15(4)
+	bkt.AddProperty(&GroupBucketPropUnknown{GroupBucketPropHeader{Type: OFPGBPT_EXPERIMENTER}, make([]byte, 8)})
+	if data, err = bkt.MarshalBinary(); err != nil {
+		t.Fatalf("Failed to marshal Bucket15: %v", err)
+	}
+	decoded = new(Bucket15)
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal Bucket15 with 4 bytes of padding: %v", err)
+	}
+	if len(decoded.Properties) != 1 {
+		t.Errorf("Expect 1 property of Bucket15, actual: %+v", decoded.Properties)
+	}
+
+	// The property over the end of the bucket.
+	data[10], data[11] = 0, 20
+	if err = new(Bucket15).UnmarshalBinary(data); err == nil {
+		t.Errorf("Expect an error of the property over the end of the bucket")
+	}
+}
+
+func TestGroupDesc15(t *testing.T) {
+	desc := NewGroupDesc15(OFPGT_SELECT, 10)
+	for _, id := range []uint32{1, 2} {
+		bkt := NewBucket15(id)
+		bkt.AddAction(NewActionOutput(id))
+		bkt.AddProperty(NewGroupBucketPropWeight(uint16(id * 50)))
+		desc.Buckets = append(desc.Buckets, *bkt)
+	}
+	reply := &MultipartReply{Header: NewOfp13Header(), Type: MultipartType_GroupDesc, Body: []util.Message{desc}}
+	reply.Header.Version = OFP15_VERSION
+	reply.Header.Type = Type_MultiPartReply
+	data, err := reply.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal MultipartReply: %v", err)
+	}
+
+	decoded := new(MultipartReply)
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal MultipartReply: %v", err)
+	}
+	if len(decoded.Body) != 1 {
+		t.Fatalf("Expect 1 group desc, actual: %+v", decoded.Body)
+	}
+	decodedDesc, ok := decoded.Body[0].(*GroupDesc15)
+	if !ok {
+		t.Fatalf("Expect GroupDesc15 in the reply of OpenFlow 1.5, actual: %T", decoded.Body[0])
+	}
+	if decodedDesc.Type != OFPGT_SELECT || decodedDesc.GroupId != 10 || len(decodedDesc.Buckets) != 2 ||
+		decodedDesc.Buckets[1].BucketId != 2 || decodedDesc.Buckets[1].Weight().Weight != 100 {
+		t.Errorf("Unexpected GroupDesc15: %+v", decodedDesc)
+	}
+
+	// The group desc of OpenFlow 1.3 is still kept as raw bytes.
+	data[0] = VERSION
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal MultipartReply: %v", err)
+	}
+	if _, ok = decoded.Body[0].(*util.Buffer); !ok {
+		t.Errorf("Expect raw bytes in the reply of OpenFlow 1.3, actual: %T", decoded.Body[0])
+	}
+
+	// The bucket array over the end of the group desc.
+	data = data[16:]
+	data[9] = 0xff
+	if err = new(GroupDesc15).UnmarshalBinary(data); err == nil {
+		t.Errorf("Expect an error of the bucket array over the end of the group desc")
+	}
+}
@@ -0,0 +1,114 @@
+package openflow13
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+)
+
+// InstrExperimenter is the ofp_instruction_experimenter, an instruction of a vendor pipeline extension. It keeps
+// the experimenter defined data as raw bytes if there is no decoder registered for it.
+type InstrExperimenter struct {
+	InstrHeader
+	Experimenter uint32 /* Experimenter ID which takes the same form as in struct ofp_experimenter_header. */
+	Data         []byte /* Experimenter defined data, the most of the vendors start it with a 4-byte subtype. */
+}
+
+func NewInstrExperimenter(experimenter uint32, data []byte) *InstrExperimenter {
+	instr := new(InstrExperimenter)
+	instr.Type = InstrType_EXPERIMENTER
+	instr.Experimenter = experimenter
+	instr.Data = data
+	instr.Length = instr.Len()
+	return instr
+}
+
+// ExpType returns the subtype in the first 4 bytes of the data, and false if the data is shorter.
+func (instr *InstrExperimenter) ExpType() (uint32, bool) {
+	if len(instr.Data) < 4 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(instr.Data), true
+}
+
+func (instr *InstrExperimenter) Len() (n uint16) {
+	return 8 + uint16(len(instr.Data))
+}
+
+func (instr *InstrExperimenter) MarshalBinary() (data []byte, err error) {
+	instr.Length = instr.Len()
+	data, err = instr.InstrHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, instr.Experimenter)
+	data = append(data, b...)
+	data = append(data, instr.Data...)
+	return
+}
+
+func (instr *InstrExperimenter) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("the []byte is too short to unmarshal a full InstrExperimenter message")
+	}
+	instr.InstrHeader.UnmarshalBinary(data[:4])
+	if instr.Length < 8 || int(instr.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full InstrExperimenter message")
+	}
+	instr.Experimenter = binary.BigEndian.Uint32(data[4:8])
+	instr.Data = make([]byte, instr.Length-8)
+	copy(instr.Data, data[8:instr.Length])
+	return nil
+}
+
+func (instr *InstrExperimenter) AddAction(act Action, prepend bool) error {
+	return errors.New("Not supported on this instrction")
+}
+
+// InstrExperimenterDecoder decodes an experimenter instruction, the data starts with the instruction header.
+type InstrExperimenterDecoder func(data []byte) (Instruction, error)
+
+type instrExperimenterKey struct {
+	experimenter uint32
+	expType      uint32
+}
+
+var (
+	instrExperimenterDecoders     = make(map[instrExperimenterKey]InstrExperimenterDecoder)
+	instrExperimenterDecodersLock sync.RWMutex
+)
+
+// RegisterInstrExperimenterDecoder registers the decoder of the experimenter instructions with the experimenter
+// ID and the subtype, so that DecodeInstr decodes them into typed instructions. The instructions without a
+// registered decoder are decoded as InstrExperimenter. A nil decoder unregisters the decoder.
+func RegisterInstrExperimenterDecoder(experimenter uint32, expType uint32, decoder InstrExperimenterDecoder) {
+	instrExperimenterDecodersLock.Lock()
+	defer instrExperimenterDecodersLock.Unlock()
+	key := instrExperimenterKey{experimenter: experimenter, expType: expType}
+	if decoder == nil {
+		delete(instrExperimenterDecoders, key)
+		return
+	}
+	instrExperimenterDecoders[key] = decoder
+}
+
+// decodeInstrExperimenter decodes the experimenter instruction with the registered decoder, or as
+// InstrExperimenter if there is none.
+func decodeInstrExperimenter(data []byte) (Instruction, error) {
+	instr := new(InstrExperimenter)
+	if err := instr.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	expType, ok := instr.ExpType()
+	if !ok {
+		return instr, nil
+	}
+	instrExperimenterDecodersLock.RLock()
+	decoder := instrExperimenterDecoders[instrExperimenterKey{experimenter: instr.Experimenter, expType: expType}]
+	instrExperimenterDecodersLock.RUnlock()
+	if decoder == nil {
+		return instr, nil
+	}
+	return decoder(data[:instr.Length])
+}
@@ -5,6 +5,7 @@ package openflow13
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 
 	"github.com/contiv/libOpenflow/util"
 )
@@ -51,8 +52,28 @@ func (a *InstrHeader) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
+// DecodeInstr decodes an instruction according to its type, nil is returned if the instruction is malformed or of
+// an unknown type.
 func DecodeInstr(data []byte) Instruction {
+	instr, _ := decodeInstr(data)
+	return instr
+}
+
+// decodeInstr decodes an instruction according to its type, and returns the error of the malformed instruction.
+func decodeInstr(data []byte) (Instruction, error) {
+	if err := checkBounds(data, 0, 4, "instruction", "length"); err != nil {
+		return nil, err
+	}
 	t := binary.BigEndian.Uint16(data[:2])
+	// The instruction is decoded within its length, so that a malformed length can't make it read the next one.
+	length := int(binary.BigEndian.Uint16(data[2:4]))
+	if length < 4 {
+		return nil, &DecodeError{Message: "instruction", Offset: 0, Field: "length", Err: fmt.Errorf("invalid length %d", length)}
+	}
+	if err := checkBounds(data, 0, length, "instruction", "length"); err != nil {
+		return nil, err
+	}
+	data = data[:length]
 	var a Instruction
 	switch t {
 	case InstrType_GOTO_TABLE:
@@ -68,16 +89,22 @@ func DecodeInstr(data []byte) Instruction {
 	case InstrType_METER:
 		a = new(InstrMeter)
 	case InstrType_EXPERIMENTER:
+		return decodeInstrExperimenter(data)
+	}
+	if a == nil {
+		return nil, fmt.Errorf("unknown instruction type %d", t)
 	}
 
-	a.UnmarshalBinary(data)
-	return a
+	if err := a.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return a, nil
 }
 
 type InstrGotoTable struct {
 	InstrHeader
 	TableId uint8
-	pad     []byte // 3 bytes
+	pad     [3]byte // 3 bytes
 }
 
 func (instr *InstrGotoTable) Len() (n uint16) {
@@ -89,17 +116,20 @@ func (instr *InstrGotoTable) MarshalBinary() (data []byte, err error) {
 
 	b := make([]byte, 4)
 	b[0] = instr.TableId
-	copy(b[3:], instr.pad)
+	copy(b[3:], instr.pad[:])
 
 	data = append(data, b...)
 	return
 }
 
 func (instr *InstrGotoTable) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("the []byte is too short to unmarshal a full InstrGotoTable message")
+	}
 	instr.InstrHeader.UnmarshalBinary(data[:4])
 
 	instr.TableId = data[4]
-	copy(instr.pad, data[5:8])
+	copy(instr.pad[:], data[5:8])
 
 	return nil
 }
@@ -108,7 +138,6 @@ func NewInstrGotoTable(tableId uint8) *InstrGotoTable {
 	instr := new(InstrGotoTable)
 	instr.Type = InstrType_GOTO_TABLE
 	instr.TableId = tableId
-	instr.pad = make([]byte, 3)
 	instr.Length = instr.Len()
 
 	return instr
@@ -120,9 +149,9 @@ func (instr *InstrGotoTable) AddAction(act Action, prepend bool) error {
 
 type InstrWriteMetadata struct {
 	InstrHeader
-	pad          []byte // 4 bytes
-	Metadata     uint64 /* Metadata value to write */
-	MetadataMask uint64 /* Metadata write bitmask */
+	pad          [4]byte // 4 bytes
+	Metadata     uint64  /* Metadata value to write */
+	MetadataMask uint64  /* Metadata write bitmask */
 }
 
 // FIXME: we need marshall/unmarshall/len/new functions for write metadata instr
@@ -134,7 +163,7 @@ func (instr *InstrWriteMetadata) MarshalBinary() (data []byte, err error) {
 	data, err = instr.InstrHeader.MarshalBinary()
 
 	b := make([]byte, 20)
-	copy(b, instr.pad)
+	copy(b, instr.pad[:])
 	binary.BigEndian.PutUint64(b[4:], instr.Metadata)
 	binary.BigEndian.PutUint64(b[12:], instr.MetadataMask)
 
@@ -143,9 +172,12 @@ func (instr *InstrWriteMetadata) MarshalBinary() (data []byte, err error) {
 }
 
 func (instr *InstrWriteMetadata) UnmarshalBinary(data []byte) error {
+	if len(data) < 24 {
+		return errors.New("the []byte is too short to unmarshal a full InstrWriteMetadata message")
+	}
 	instr.InstrHeader.UnmarshalBinary(data[:4])
 
-	copy(instr.pad, data[4:8])
+	copy(instr.pad[:], data[4:8])
 	instr.Metadata = binary.BigEndian.Uint64(data[8:16])
 	instr.MetadataMask = binary.BigEndian.Uint64(data[16:24])
 
@@ -155,7 +187,6 @@ func (instr *InstrWriteMetadata) UnmarshalBinary(data []byte) error {
 func NewInstrWriteMetadata(metadata, metadataMask uint64) *InstrWriteMetadata {
 	instr := new(InstrWriteMetadata)
 	instr.Type = InstrType_WRITE_METADATA
-	instr.pad = make([]byte, 4)
 	instr.Metadata = metadata
 	instr.MetadataMask = metadataMask
 	instr.Length = instr.Len()
@@ -167,10 +198,32 @@ func (instr *InstrWriteMetadata) AddAction(act Action, prepend bool) error {
 	return errors.New("Not supported on this instrction")
 }
 
+// Validate checks the mask of the write-metadata instruction. The mask must not be zero, and the metadata must
+// not have the bits which are not in the mask.
+func (instr *InstrWriteMetadata) Validate() error {
+	if instr.MetadataMask == 0 {
+		return errors.New("the mask of write-metadata instruction is zero")
+	}
+	if instr.Metadata&^instr.MetadataMask != 0 {
+		return fmt.Errorf("the metadata 0x%x has bits outside of the mask 0x%x", instr.Metadata, instr.MetadataMask)
+	}
+	return nil
+}
+
+// WriteMetadata returns a write-metadata instruction which writes value to the bits of the metadata in mask.
+// The metadata can't be written with a set-field action, this instruction must be used instead.
+func WriteMetadata(value, mask uint64) (*InstrWriteMetadata, error) {
+	instr := NewInstrWriteMetadata(value, mask)
+	if err := instr.Validate(); err != nil {
+		return nil, err
+	}
+	return instr, nil
+}
+
 // *_ACTION instructions
 type InstrActions struct {
 	InstrHeader
-	pad     []byte   // 4 bytes
+	pad     [4]byte  // 4 bytes
 	Actions []Action /* 0 or more actions associated with OFPIT_WRITE_ACTIONS and OFPIT_APPLY_ACTIONS */
 }
 
@@ -188,10 +241,16 @@ func (instr *InstrActions) MarshalBinary() (data []byte, err error) {
 	data, err = instr.InstrHeader.MarshalBinary()
 
 	b := make([]byte, 4)
-	copy(b, instr.pad)
+	copy(b, instr.pad[:])
 	data = append(data, b...)
 
-	for _, act := range instr.Actions {
+	actions := instr.Actions
+	if instr.Type == InstrType_WRITE_ACTIONS {
+		// The actions in the action set are executed in the defined order, marshal them in the same order as the
+		// switch dumps them.
+		actions = SortActionSet(actions)
+	}
+	for _, act := range actions {
 		b, err = act.MarshalBinary()
 		data = append(data, b...)
 	}
@@ -200,22 +259,35 @@ func (instr *InstrActions) MarshalBinary() (data []byte, err error) {
 }
 
 func (instr *InstrActions) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("the []byte is too short to unmarshal a full InstrActions message")
+	}
 	instr.InstrHeader.UnmarshalBinary(data[:4])
 
+	if int(instr.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full InstrActions message")
+	}
 	n := 8
 	for n < int(instr.Length) {
-		act, err := DecodeAction(data[n:])
+		act, err := DecodeAction(data[n:instr.Length])
 		if err != nil {
-			return err
+			return decodeErrorAt(err, "InstrActions", n, "action")
 		}
 		instr.Actions = append(instr.Actions, act)
-		n += int(act.Len())
+		if n, err = safeAdvance(n, act.Len(), int(instr.Length), "action"); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
 func (instr *InstrActions) AddAction(act Action, prepend bool) error {
+	if setField, ok := act.(*ActionSetField); ok {
+		if err := setField.Validate(); err != nil {
+			return err
+		}
+	}
 	// Append or prepend to the list
 	if prepend {
 		instr.Actions = append([]Action{act}, instr.Actions...)
@@ -230,7 +302,6 @@ func (instr *InstrActions) AddAction(act Action, prepend bool) error {
 func NewInstrWriteActions() *InstrActions {
 	instr := new(InstrActions)
 	instr.Type = InstrType_WRITE_ACTIONS
-	instr.pad = make([]byte, 4)
 	instr.Actions = make([]Action, 0)
 	instr.Length = instr.Len()
 
@@ -240,7 +311,16 @@ func NewInstrWriteActions() *InstrActions {
 func NewInstrApplyActions() *InstrActions {
 	instr := new(InstrActions)
 	instr.Type = InstrType_APPLY_ACTIONS
-	instr.pad = make([]byte, 4)
+	instr.Actions = make([]Action, 0)
+	instr.Length = instr.Len()
+
+	return instr
+}
+
+// NewInstrClearActions returns the OFPIT_CLEAR_ACTIONS instruction, which has no action.
+func NewInstrClearActions() *InstrActions {
+	instr := new(InstrActions)
+	instr.Type = InstrType_CLEAR_ACTIONS
 	instr.Actions = make([]Action, 0)
 	instr.Length = instr.Len()
 
@@ -277,6 +357,9 @@ func (instr *InstrMeter) MarshalBinary() (data []byte, err error) {
 }
 
 func (instr *InstrMeter) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("the []byte is too short to unmarshal a full InstrMeter message")
+	}
 	instr.InstrHeader.UnmarshalBinary(data[:4])
 
 	instr.MeterId = binary.BigEndian.Uint32(data[4:8])
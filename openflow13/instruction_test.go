@@ -0,0 +1,130 @@
+package openflow13
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestWriteMetadata(t *testing.T) {
+	instr, err := WriteMetadata(0x1200, 0xff00)
+	if err != nil {
+		t.Fatalf("Failed to create write-metadata instruction: %v", err)
+	}
+	data, err := instr.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal write-metadata instruction: %v", err)
+	}
+	instr2 := DecodeInstr(data).(*InstrWriteMetadata)
+	if instr2.Metadata != 0x1200 || instr2.MetadataMask != 0xff00 {
+		t.Errorf("Unexpected write-metadata instruction: %+v", instr2)
+	}
+
+	if _, err := WriteMetadata(0x1200, 0); err == nil {
+		t.Errorf("Expect error with zero mask")
+	}
+	if _, err := WriteMetadata(0x1234, 0xff00); err == nil {
+		t.Errorf("Expect error with metadata bits outside of the mask")
+	}
+
+	mask := uint64(0xff00)
+	applyActions := NewInstrApplyActions()
+	if err := applyActions.AddAction(NewActionSetField(*NewMetadataField(0x1200, &mask)), false); err == nil {
+		t.Errorf("Expect error when setting metadata with set-field action")
+	}
+	if err := applyActions.AddAction(NewActionSetField(*NewTunnelIdField(100)), false); err != nil {
+		t.Errorf("Failed to add set-field action: %v", err)
+	}
+}
+
+func TestWriteActionsOrder(t *testing.T) {
+	output := NewActionOutput(1)
+	group := NewActionGroup(2)
+	setField := NewActionSetField(*NewTunnelIdField(100))
+	popVlan := NewActionPopVlan()
+	pushVlan := NewActionPushVlan(0x8100)
+
+	writeActions := NewInstrWriteActions()
+	applyActions := NewInstrApplyActions()
+	for _, act := range []Action{output, group, setField, pushVlan, popVlan} {
+		writeActions.AddAction(act, false)
+		applyActions.AddAction(act, false)
+	}
+
+	data, err := writeActions.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal write-actions instruction: %v", err)
+	}
+	writeActions2 := DecodeInstr(data).(*InstrActions)
+	expectedTypes := []uint16{ActionType_PopVlan, ActionType_PushVlan, ActionType_SetField, ActionType_Group, ActionType_Output}
+	for i, act := range writeActions2.Actions {
+		if act.Header().Type != expectedTypes[i] {
+			t.Errorf("Expect action type %d at %d in write-actions, actual: %d", expectedTypes[i], i, act.Header().Type)
+		}
+	}
+
+	data, err = applyActions.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal apply-actions instruction: %v", err)
+	}
+	applyActions2 := DecodeInstr(data).(*InstrActions)
+	for i, act := range applyActions2.Actions {
+		if act.Header().Type != applyActions.Actions[i].Header().Type {
+			t.Errorf("The order of apply-actions should not be changed")
+		}
+	}
+}
+
+// instrTestExperimenter is a typed experimenter instruction decoded by a registered decoder.
+type instrTestExperimenter struct {
+	InstrExperimenter
+	Value uint32
+}
+
+func TestInstrExperimenter(t *testing.T) {
+	const experimenter, expType = ONF_EXPERIMENTER_ID, 0x10
+	data := []byte{0, 0, 0, expType, 0, 0, 0x12, 0x34}
+	flow := NewFlowMod()
+	flow.AddInstruction(NewInstrExperimenter(experimenter, data))
+	flow.AddInstruction(NewInstrGotoTable(1))
+	b, err := flow.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal FlowMod: %v", err)
+	}
+
+	// The instruction is kept raw without a decoder.
+	flow2 := new(FlowMod)
+	if err = flow2.UnmarshalBinary(b); err != nil {
+		t.Fatalf("Failed to unmarshal FlowMod: %v", err)
+	}
+	if len(flow2.Instructions) != 2 {
+		t.Fatalf("Expect 2 instructions, actual: %d", len(flow2.Instructions))
+	}
+	raw, ok := flow2.Instructions[0].(*InstrExperimenter)
+	if !ok || raw.Experimenter != experimenter || !bytes.Equal(raw.Data, data) {
+		t.Errorf("Unexpected raw experimenter instruction: %+v", flow2.Instructions[0])
+	}
+	if b2, _ := flow2.MarshalBinary(); !bytes.Equal(b, b2) {
+		t.Errorf("FlowMod is changed after re-marshaling the raw experimenter instruction")
+	}
+
+	RegisterInstrExperimenterDecoder(experimenter, expType, func(data []byte) (Instruction, error) {
+		instr := new(instrTestExperimenter)
+		if err := instr.InstrExperimenter.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+		instr.Value = binary.BigEndian.Uint32(instr.Data[4:])
+		return instr, nil
+	})
+	defer RegisterInstrExperimenterDecoder(experimenter, expType, nil)
+	flow2 = new(FlowMod)
+	if err = flow2.UnmarshalBinary(b); err != nil {
+		t.Fatalf("Failed to unmarshal FlowMod: %v", err)
+	}
+	if typed, ok := flow2.Instructions[0].(*instrTestExperimenter); !ok || typed.Value != 0x1234 {
+		t.Errorf("Unexpected typed experimenter instruction: %+v", flow2.Instructions[0])
+	}
+	if _, ok := flow2.Instructions[1].(*InstrGotoTable); !ok {
+		t.Errorf("Unexpected instruction after the experimenter instruction: %+v", flow2.Instructions[1])
+	}
+}
@@ -0,0 +1,599 @@
+package openflow13
+
+// This file has the JSON encoding of the messages with the polymorphic fields, i.e., the match fields, the actions,
+// the instructions and the multipart bodies, so that the messages can be logged, inspected with jq and replayed.
+// The other fields are encoded by encoding/json with their Go names, and the fields of the message header are
+// promoted to the message, e.g., {"Version":4,"Type":14,"Length":56,"Xid":1,"Cookie":0,...} of a FlowMod.
+//
+// A match field is encoded as its OXM/NXM name and the value in the syntax of ovs-ofctl, e.g.,
+// {"Name":"OXM_OF_IPV4_SRC","Value":"10.0.0.0/24"}, and an action as its text in ovs-ofctl, e.g.,
+// {"Action":"output:2"}. The wire bytes are kept in Data if the text is not parsed back to the same bytes, e.g.,
+// {"Action":"output:2","Data":"AAAAEAAAAAL/5QAAAAAAAA=="} of an output action with the max_len 0xffe5, so that
+// the decoded message is always the same as the encoded one.
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+// marshalsTo reports whether msg is marshaled to data.
+func marshalsTo(msg util.Message, data []byte) bool {
+	b, err := msg.MarshalBinary()
+	return err == nil && bytes.Equal(b, data)
+}
+
+type matchFieldJSON struct {
+	Name  string
+	Value string `json:",omitempty"`
+	Data  []byte `json:",omitempty"`
+}
+
+func (m *MatchField) MarshalJSON() ([]byte, error) {
+	data, err := m.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	_, value := m.formatValue()
+	field := matchFieldJSON{Name: oxxFieldName(m), Value: value}
+	if parsed, err := parseFlowField(field.Name, field.Value, -1); err != nil || !marshalsTo(parsed, data) {
+		field.Data = data
+	}
+	return json.Marshal(field)
+}
+
+func (m *MatchField) UnmarshalJSON(data []byte) error {
+	var field matchFieldJSON
+	if err := json.Unmarshal(data, &field); err != nil {
+		return err
+	}
+	if field.Data != nil {
+		if len(field.Data) < 4 {
+			return errors.New("the []byte is too short to unmarshal a full MatchField message")
+		}
+		return m.UnmarshalBinary(field.Data)
+	}
+	parsed, err := parseFlowField(field.Name, field.Value, -1)
+	if err != nil {
+		return err
+	}
+	*m = *parsed
+	return nil
+}
+
+type actionJSON struct {
+	Action string
+	Data   []byte `json:",omitempty"`
+}
+
+func newActionJSON(act Action) (actionJSON, error) {
+	data, err := act.MarshalBinary()
+	if err != nil {
+		return actionJSON{}, err
+	}
+	a := actionJSON{Action: fmt.Sprintf("action(type=%d)", act.Header().Type)}
+	if s, ok := act.(fmt.Stringer); ok {
+		a.Action = s.String()
+	}
+	if parsed, err := parseAction(a.Action); err != nil || !marshalsTo(parsed, data) {
+		a.Data = data
+	}
+	return a, nil
+}
+
+func (a actionJSON) decode() (Action, error) {
+	if a.Data == nil {
+		return parseAction(a.Action)
+	}
+	if len(a.Data) < 4 {
+		return nil, errors.New("the []byte is too short to decode an action")
+	}
+	return DecodeAction(a.Data)
+}
+
+func newActionsJSON(actions []Action) ([]actionJSON, error) {
+	result := make([]actionJSON, 0, len(actions))
+	for _, act := range actions {
+		a, err := newActionJSON(act)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+func decodeActionsJSON(actions []actionJSON) ([]Action, error) {
+	result := make([]Action, 0, len(actions))
+	for _, a := range actions {
+		act, err := a.decode()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, act)
+	}
+	return result, nil
+}
+
+// instrActionsNames are the names of the instructions with actions in JSON.
+var instrActionsNames = map[uint16]string{
+	InstrType_APPLY_ACTIONS: "apply_actions",
+	InstrType_WRITE_ACTIONS: "write_actions",
+	InstrType_CLEAR_ACTIONS: "clear_actions",
+}
+
+// instructionJSON is an instruction in JSON, the instructions with actions are encoded as the name and the
+// actions, e.g., {"Instruction":"apply_actions","Actions":[{"Action":"output:2"}]}, and the others as the text in
+// ovs-ofctl, e.g., {"Instruction":"goto_table:1"}.
+type instructionJSON struct {
+	Instruction string
+	Actions     []actionJSON `json:",omitempty"`
+	Data        []byte       `json:",omitempty"`
+}
+
+func newInstructionJSON(instr Instruction) (instructionJSON, error) {
+	if actions, ok := instr.(*InstrActions); ok {
+		if name, ok := instrActionsNames[actions.Type]; ok {
+			acts, err := newActionsJSON(actions.Actions)
+			return instructionJSON{Instruction: name, Actions: acts}, err
+		}
+	}
+	data, err := instr.MarshalBinary()
+	if err != nil {
+		return instructionJSON{}, err
+	}
+	i := instructionJSON{Instruction: fmt.Sprint(instr)}
+	if parsed, err := parseInstructions([]string{i.Instruction}); err != nil || len(parsed) != 1 || !marshalsTo(parsed[0], data) {
+		i.Data = data
+	}
+	return i, nil
+}
+
+func (i instructionJSON) decode() (Instruction, error) {
+	for instrType, name := range instrActionsNames {
+		if i.Instruction != name {
+			continue
+		}
+		instr := &InstrActions{InstrHeader: InstrHeader{Type: instrType}, Actions: make([]Action, 0)}
+		actions, err := decodeActionsJSON(i.Actions)
+		if err != nil {
+			return nil, err
+		}
+		for _, act := range actions {
+			if err := instr.AddAction(act, false); err != nil {
+				return nil, err
+			}
+		}
+		instr.Length = instr.Len()
+		return instr, nil
+	}
+	if i.Data != nil {
+		if instr := DecodeInstr(i.Data); instr != nil {
+			return instr, nil
+		}
+		return nil, fmt.Errorf("failed to decode the instruction %s", i.Instruction)
+	}
+	instructions, err := parseInstructions([]string{i.Instruction})
+	if err != nil {
+		return nil, err
+	}
+	if len(instructions) != 1 {
+		return nil, fmt.Errorf("invalid instruction %s", i.Instruction)
+	}
+	return instructions[0], nil
+}
+
+func newInstructionsJSON(instructions []Instruction) ([]instructionJSON, error) {
+	result := make([]instructionJSON, 0, len(instructions))
+	for _, instr := range instructions {
+		i, err := newInstructionJSON(instr)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, i)
+	}
+	return result, nil
+}
+
+func decodeInstructionsJSON(instructions []instructionJSON) ([]Instruction, error) {
+	result := make([]Instruction, 0, len(instructions))
+	for _, i := range instructions {
+		instr, err := i.decode()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, instr)
+	}
+	return result, nil
+}
+
+func (f *FlowMod) MarshalJSON() ([]byte, error) {
+	type flowMod FlowMod
+	instructions, err := newInstructionsJSON(f.Instructions)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&struct {
+		*flowMod
+		Instructions []instructionJSON
+	}{(*flowMod)(f), instructions})
+}
+
+func (f *FlowMod) UnmarshalJSON(data []byte) error {
+	type flowMod FlowMod
+	aux := struct {
+		*flowMod
+		Instructions []instructionJSON
+	}{flowMod: (*flowMod)(f)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	instructions, err := decodeInstructionsJSON(aux.Instructions)
+	if err != nil {
+		return err
+	}
+	f.Instructions = instructions
+	return nil
+}
+
+// MarshalJSON encodes the PacketIn with the packet bytes in Data.
+func (p *PacketIn) MarshalJSON() ([]byte, error) {
+	type packetIn PacketIn
+	packet := p.RawData
+	if !p.dataDeferred {
+		var err error
+		if packet, err = p.Data.MarshalBinary(); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(&struct {
+		*packetIn
+		Data    []byte
+		RawData *struct{} `json:",omitempty"`
+	}{packetIn: (*packetIn)(p), Data: packet})
+}
+
+func (p *PacketIn) UnmarshalJSON(data []byte) error {
+	type packetIn PacketIn
+	aux := struct {
+		*packetIn
+		Data    []byte
+		RawData *struct{} `json:",omitempty"`
+	}{packetIn: (*packetIn)(p)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	p.RawData, p.dataDeferred = nil, false
+	return p.Data.UnmarshalBinary(aux.Data)
+}
+
+// MarshalJSON encodes the PacketOut with the packet bytes in Data.
+func (p *PacketOut) MarshalJSON() ([]byte, error) {
+	type packetOut PacketOut
+	actions, err := newActionsJSON(p.Actions)
+	if err != nil {
+		return nil, err
+	}
+	var packet []byte
+	if p.Data != nil {
+		if packet, err = p.Data.MarshalBinary(); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(&struct {
+		*packetOut
+		Actions []actionJSON
+		Data    []byte
+	}{(*packetOut)(p), actions, packet})
+}
+
+// UnmarshalJSON decodes the PacketOut, the packet is kept as a util.Buffer, the same as UnmarshalBinary.
+func (p *PacketOut) UnmarshalJSON(data []byte) error {
+	type packetOut PacketOut
+	aux := struct {
+		*packetOut
+		Actions []actionJSON
+		Data    []byte
+	}{packetOut: (*packetOut)(p)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	actions, err := decodeActionsJSON(aux.Actions)
+	if err != nil {
+		return err
+	}
+	p.Actions = actions
+	p.Data = nil
+	if aux.Data != nil {
+		p.Data = util.NewBuffer(aux.Data)
+	}
+	return nil
+}
+
+func (b *Bucket) MarshalJSON() ([]byte, error) {
+	type bucket Bucket
+	actions, err := newActionsJSON(b.Actions)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&struct {
+		*bucket
+		Actions []actionJSON
+	}{(*bucket)(b), actions})
+}
+
+func (b *Bucket) UnmarshalJSON(data []byte) error {
+	type bucket Bucket
+	aux := struct {
+		*bucket
+		Actions []actionJSON
+	}{bucket: (*bucket)(b)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	actions, err := decodeActionsJSON(aux.Actions)
+	b.Actions = actions
+	return err
+}
+
+// UnmarshalJSON decodes the GroupMod, the message type is not in JSON as the group type hides it.
+func (g *GroupMod) UnmarshalJSON(data []byte) error {
+	type groupMod GroupMod
+	if err := json.Unmarshal(data, (*groupMod)(g)); err != nil {
+		return err
+	}
+	g.Header.Type = Type_GroupMod
+	return nil
+}
+
+// MarshalJSON encodes the ErrorMsg with the bytes of the failed request in Data.
+func (e *ErrorMsg) MarshalJSON() ([]byte, error) {
+	type errorMsg ErrorMsg
+	return json.Marshal(&struct {
+		*errorMsg
+		Data []byte
+	}{(*errorMsg)(e), e.Data.Bytes()})
+}
+
+// UnmarshalJSON decodes the ErrorMsg, the message type is not in JSON as the error type hides it.
+func (e *ErrorMsg) UnmarshalJSON(data []byte) error {
+	type errorMsg ErrorMsg
+	aux := struct {
+		*errorMsg
+		Data []byte
+	}{errorMsg: (*errorMsg)(e)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	e.Header.Type = Type_Error
+	e.Data = *util.NewBuffer(aux.Data)
+	return nil
+}
+
+// MarshalJSON encodes the FlowStats, the deferred instructions are decoded first.
+func (s *FlowStats) MarshalJSON() ([]byte, error) {
+	type flowStats FlowStats
+	instructions, err := s.GetInstructions()
+	if err != nil {
+		return nil, err
+	}
+	instructionsJSON, err := newInstructionsJSON(instructions)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&struct {
+		*flowStats
+		Instructions    []instructionJSON
+		RawInstructions *struct{} `json:",omitempty"`
+	}{flowStats: (*flowStats)(s), Instructions: instructionsJSON})
+}
+
+func (s *FlowStats) UnmarshalJSON(data []byte) error {
+	type flowStats FlowStats
+	aux := struct {
+		*flowStats
+		Instructions    []instructionJSON
+		RawInstructions *struct{} `json:",omitempty"`
+	}{flowStats: (*flowStats)(s)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	instructions, err := decodeInstructionsJSON(aux.Instructions)
+	if err != nil {
+		return err
+	}
+	s.Instructions, s.RawInstructions, s.instrDeferred = instructions, nil, false
+	return nil
+}
+
+// rawBodyJSON is a multipart body in JSON whose type has no JSON decoder, e.g., OFPMP_METER_CONFIG with the
+// polymorphic meter bands, it keeps the wire bytes of the body.
+type rawBodyJSON struct {
+	Data []byte
+}
+
+// hasRawReplyBodyJSON reports whether the reply bodies of the multipart type are encoded as rawBodyJSON. The
+// OFPMP_FLOW_DESC of OpenFlow 1.5 has the value of OFPMP_FLOW, but its bodies are FlowDesc.
+func hasRawReplyBodyJSON(version uint8, mpType uint16) bool {
+	if version >= OFP15_VERSION && mpType == MultipartType_FlowDesc {
+		return true
+	}
+	switch mpType {
+	case MultipartType_Aggregate, MultipartType_Desc, MultipartType_Flow, MultipartType_Port, MultipartType_Table,
+		MultipartType_Queue, MultipartType_PortDesc, MultipartType_Meter, MultipartType_Experimenter:
+		return false
+	}
+	return true
+}
+
+// newMultipartBodyJSON returns the body in JSON, it is rawBodyJSON if raw is set.
+func newMultipartBodyJSON(body util.Message, raw bool) (json.RawMessage, error) {
+	if body == nil {
+		return json.RawMessage("null"), nil
+	}
+	if !raw {
+		return json.Marshal(body)
+	}
+	data, err := body.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(rawBodyJSON{Data: data})
+}
+
+// rawExperimenterBody returns a body decoded with a factory registered by RegisterExperimenterMultipartDecoder as
+// an ExperimenterStatsBody, so that it is encoded in JSON with its wire bytes.
+func rawExperimenterBody(body util.Message) (util.Message, error) {
+	if body == nil {
+		return nil, nil
+	}
+	if _, ok := body.(*ExperimenterStatsBody); ok {
+		return body, nil
+	}
+	data, err := body.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	raw := new(ExperimenterStatsBody)
+	if err := raw.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// typedExperimenterBody decodes an ExperimenterStatsBody decoded from JSON with the registered factory of the
+// request or the reply, and returns the other bodies unchanged.
+func typedExperimenterBody(body util.Message, reply bool) (util.Message, error) {
+	raw, ok := body.(*ExperimenterStatsBody)
+	if !ok {
+		return body, nil
+	}
+	data, err := raw.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	typed := newExperimenterMultipartBody(data, reply)
+	if _, ok := typed.(*ExperimenterStatsBody); ok {
+		return body, nil
+	}
+	if err := typed.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return typed, nil
+}
+
+// MarshalJSON encodes the MultipartRequest, the body of the unsupported types is encoded as the raw bytes.
+func (s *MultipartRequest) MarshalJSON() ([]byte, error) {
+	type multipartRequest MultipartRequest
+	reqBody := s.Body
+	if s.Type == MultipartType_Experimenter {
+		var err error
+		if reqBody, err = rawExperimenterBody(reqBody); err != nil {
+			return nil, err
+		}
+	}
+	body, err := newMultipartBodyJSON(reqBody, newMultipartRequestBody(s.Type, nil) == nil)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&struct {
+		*multipartRequest
+		Body json.RawMessage
+	}{(*multipartRequest)(s), body})
+}
+
+// UnmarshalJSON decodes the MultipartRequest, the message type is not in JSON as the multipart type hides it.
+func (s *MultipartRequest) UnmarshalJSON(data []byte) error {
+	type multipartRequest MultipartRequest
+	aux := struct {
+		*multipartRequest
+		Body json.RawMessage
+	}{multipartRequest: (*multipartRequest)(s)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	s.Header.Type = Type_MultiPartRequest
+	s.Body = nil
+	if len(aux.Body) == 0 || string(aux.Body) == "null" {
+		return nil
+	}
+	if body := newMultipartRequestBody(s.Type, nil); body != nil {
+		if err := json.Unmarshal(aux.Body, body); err != nil {
+			return err
+		}
+		var err error
+		s.Body, err = typedExperimenterBody(body, false)
+		return err
+	}
+	var raw rawBodyJSON
+	if err := json.Unmarshal(aux.Body, &raw); err != nil {
+		return err
+	}
+	s.Body = util.NewBuffer(raw.Data)
+	return nil
+}
+
+// MarshalJSON encodes the MultipartReply, the bodies of the types without a JSON decoder are encoded as the raw
+// bytes, see hasRawReplyBodyJSON.
+func (s *MultipartReply) MarshalJSON() ([]byte, error) {
+	type multipartReply MultipartReply
+	bodies := make([]json.RawMessage, 0, len(s.Body))
+	for _, body := range s.Body {
+		if s.Type == MultipartType_Experimenter {
+			var err error
+			if body, err = rawExperimenterBody(body); err != nil {
+				return nil, err
+			}
+		}
+		b, err := newMultipartBodyJSON(body, hasRawReplyBodyJSON(s.Header.Version, s.Type))
+		if err != nil {
+			return nil, err
+		}
+		bodies = append(bodies, b)
+	}
+	return json.Marshal(&struct {
+		*multipartReply
+		Body []json.RawMessage
+	}{(*multipartReply)(s), bodies})
+}
+
+// UnmarshalJSON decodes the MultipartReply, the message type is not in JSON as the multipart type hides it.
+func (s *MultipartReply) UnmarshalJSON(data []byte) error {
+	type multipartReply MultipartReply
+	aux := struct {
+		*multipartReply
+		Body []json.RawMessage
+	}{multipartReply: (*multipartReply)(s)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	s.Header.Type = Type_MultiPartReply
+	s.Body = nil
+	for _, b := range aux.Body {
+		if !hasRawReplyBodyJSON(s.Header.Version, s.Type) {
+			body := newMultipartReplyBody(s.Header.Version, s.Type, nil)
+			if err := json.Unmarshal(b, body); err != nil {
+				return err
+			}
+			body, err := typedExperimenterBody(body, true)
+			if err != nil {
+				return err
+			}
+			s.Body = append(s.Body, body)
+			continue
+		}
+		var raw rawBodyJSON
+		if err := json.Unmarshal(b, &raw); err != nil {
+			return err
+		}
+		body := newMultipartReplyBody(s.Header.Version, s.Type, raw.Data)
+		if err := body.UnmarshalBinary(raw.Data); err != nil {
+			return err
+		}
+		s.Body = append(s.Body, body)
+	}
+	return nil
+}
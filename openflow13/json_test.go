@@ -0,0 +1,162 @@
+package openflow13
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/contiv/libOpenflow/protocol"
+	"github.com/contiv/libOpenflow/util"
+)
+
+// jsonRoundTrip encodes msg in JSON, decodes it into decoded, and verifies the decoded message has the same bytes.
+func jsonRoundTrip(t *testing.T, msg util.Message, decoded util.Message) []byte {
+	t.Helper()
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Failed to encode %T in JSON: %v", msg, err)
+	}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("Failed to decode %T from JSON %s: %v", msg, data, err)
+	}
+	expected, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal %T: %v", msg, err)
+	}
+	actual, err := decoded.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal decoded %T: %v", decoded, err)
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("Decoded %T from JSON %s is different:\n%x\n%x", msg, data, expected, actual)
+	}
+	return data
+}
+
+func TestFlowModJSON(t *testing.T) {
+	flow, err := ParseFlow("cookie=0x12,table=1,priority=200,tcp,in_port=LOCAL,tcp_dst=80,ct_state=-new+trk," +
+		"reg1=0x10/0xffff,nw_src=10.0.0.0/24 actions=load:0x5->NXM_NX_REG0[0..15],set_field:0x1->ct_mark," +
+		"ct(commit,table=3,zone=10,exec(load:0x1->NXM_NX_CT_MARK[]),nat(dst=10.0.0.1-10.0.0.2:1000-2000,random))," +
+		"write_actions(group:1),goto_table:5")
+	if err != nil {
+		t.Fatalf("Failed to parse flow: %v", err)
+	}
+	// The output action has the max_len which is not in the text.
+	output := NewActionOutput(2)
+	output.MaxLen = 0xffe5
+	flow.Instructions[0].AddAction(output, false)
+	data := jsonRoundTrip(t, flow, new(FlowMod))
+	for _, s := range []string{
+		`"Version":4,"Type":14,`,
+		`"Priority":200,`,
+		`{"Name":"OXM_OF_TCP_DST","Value":"80"}`,
+		`{"Name":"NXM_NX_CT_STATE","Value":"-new+trk"}`,
+		`{"Name":"OXM_OF_IPV4_SRC","Value":"10.0.0.0/24"}`,
+		`{"Instruction":"apply_actions","Actions":[{"Action":"load:0x5-\u003eNXM_NX_REG0[0..15]"}`,
+		`{"Action":"output:2","Data":"`,
+		`{"Instruction":"write_actions","Actions":[{"Action":"group:1"}]}`,
+		`{"Instruction":"goto_table:5"}`,
+	} {
+		if !strings.Contains(string(data), s) {
+			t.Errorf("Expect %s in JSON %s", s, data)
+		}
+	}
+}
+
+func TestMessagesJSON(t *testing.T) {
+	pktIn := NewPacketIn()
+	pktIn.TableId = 2
+	pktIn.Match.AddField(*NewInPortField(3))
+	pktIn.Data = *protocol.NewEthernet()
+	pktIn.Data.HWSrc, _ = net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	pktIn.Data.Ethertype = 0x0806
+	pktIn.Data.Data, _ = protocol.NewARP(protocol.Type_Request)
+	decodedPktIn := new(PacketIn)
+	jsonRoundTrip(t, pktIn, decodedPktIn)
+	if _, ok := decodedPktIn.Data.Data.(*protocol.ARP); !ok {
+		t.Errorf("Expect an ARP packet, actual: %T", decodedPktIn.Data.Data)
+	}
+
+	pktOut := NewPacketOut()
+	pktOut.InPort = 3
+	pktOut.AddAction(NewActionOutput(P_IN_PORT))
+	pktOut.Data = util.NewBuffer([]byte{1, 2, 3, 4})
+	jsonRoundTrip(t, pktOut, new(PacketOut))
+
+	group := NewGroupMod()
+	group.GroupId = 10
+	group.Type = OFPGT_SELECT
+	bucket := NewBucket()
+	bucket.Weight = 50
+	bucket.AddAction(NewActionOutput(P_LOCAL))
+	group.AddBucket(*bucket)
+	decodedGroup := new(GroupMod)
+	jsonRoundTrip(t, group, decodedGroup)
+	if decodedGroup.Header.Type != Type_GroupMod {
+		t.Errorf("Expect the GroupMod type, actual: %d", decodedGroup.Header.Type)
+	}
+
+	errMsg := NewErrorMsg()
+	errMsg.Header = NewOfp13Header()
+	errMsg.Header.Type = Type_Error
+	errMsg.Type = ET_BAD_REQUEST
+	errMsg.Data = *util.NewBuffer([]byte{4, 14, 0, 8, 0, 0, 0, 1})
+	jsonRoundTrip(t, errMsg, new(ErrorMsg))
+
+	jsonRoundTrip(t, NewFlowStatsRequest(), new(FlowStatsRequest))
+	req := &MultipartRequest{Header: NewOfp13Header(), Type: MultipartType_Flow, Body: NewFlowStatsRequest()}
+	req.Header.Type = Type_MultiPartRequest
+	decodedReq := new(MultipartRequest)
+	jsonRoundTrip(t, req, decodedReq)
+	if decodedReq.Header.Type != Type_MultiPartRequest {
+		t.Errorf("Expect the MultipartRequest type, actual: %d", decodedReq.Header.Type)
+	}
+	req = &MultipartRequest{Header: NewOfp13Header(), Type: MultipartType_Desc, Body: util.NewBuffer(nil)}
+	req.Header.Type = Type_MultiPartRequest
+	jsonRoundTrip(t, req, new(MultipartRequest))
+}
+
+func TestMultipartReplyJSON(t *testing.T) {
+	stats := NewFlowStats()
+	stats.TableId = 1
+	stats.PacketCount = 10
+	stats.Match.AddField(*NewEthTypeField(0x0800))
+	instr := NewInstrApplyActions()
+	instr.AddAction(NewNXActionResubmitTableAction(OFPP_IN_PORT, 2), false)
+	stats.Instructions = append(stats.Instructions, instr)
+	stats.Length = stats.Len()
+	reply := &MultipartReply{Header: NewOfp13Header(), Type: MultipartType_Flow, Body: []util.Message{stats, stats}}
+	reply.Header.Type = Type_MultiPartReply
+	reply.Header.Length = reply.Len()
+	data := jsonRoundTrip(t, reply, new(MultipartReply))
+	if !strings.Contains(string(data), `"PacketCount":10,`) || !strings.Contains(string(data), `{"Action":"resubmit(,2)"}`) {
+		t.Errorf("Unexpected JSON %s", data)
+	}
+
+	// The deferred instructions are decoded in JSON.
+	b, _ := reply.MarshalBinary()
+	borrowed := new(MultipartReply)
+	if err := borrowed.UnmarshalBinaryWithOptions(b, DecodeOptions{Borrow: true}); err != nil {
+		t.Fatalf("Failed to unmarshal MultipartReply: %v", err)
+	}
+	if borrowedData, err := json.Marshal(borrowed); err != nil || !bytes.Equal(borrowedData, data) {
+		t.Errorf("Unexpected JSON of the borrowed reply %s: %v", borrowedData, err)
+	}
+
+	// The meter bands are polymorphic, the bodies are kept as the raw bytes.
+	desc := NewMeterDesc()
+	desc.MeterId = 1
+	drop := &MeterBandDrop{MeterBandHeader: *NewMeterBandHeader()}
+	drop.Type = OFPMBT13_DROP
+	drop.Rate = 1000
+	desc.AddMeterBand(drop)
+	reply = &MultipartReply{Header: NewOfp13Header(), Type: MultipartType_MeterConfig, Body: []util.Message{desc}}
+	reply.Header.Type = Type_MultiPartReply
+	decoded := new(MultipartReply)
+	jsonRoundTrip(t, reply, decoded)
+	if decodedDesc, ok := decoded.Body[0].(*MeterDesc); !ok || decodedDesc.MeterId != 1 {
+		t.Errorf("Unexpected meter config: %+v", decoded.Body[0])
+	}
+}
@@ -0,0 +1,210 @@
+package openflow13
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// LearnBuilder builds an NXActionLearn from the specs in the syntax of ovs-ofctl, e.g.,
+//
+//	NewLearnBuilder().Table(10).IdleTimeout(60).
+//		MatchValue([]byte{0x08, 0x00}, ethType, nil).
+//		MatchField(ethSrc, ethDst, 48).
+//		LoadValue([]byte{0x1}, reg0, NewNXRange(0, 15)).
+//		OutputToPort(inPort, nil).
+//		Build()
+//
+// is learn(table=10,idle_timeout=60,eth_type=0x800,NXM_OF_ETH_DST[]=NXM_OF_ETH_SRC[],load:0x1->NXM_NX_REG0[0..15],
+// output:NXM_OF_IN_PORT[]). The fields are the headers returned by FindFieldHeaderByName without the mask, and a nil
+// range is the whole field. The number of bits of each spec is checked against the fields, and the values are
+// encoded in the 2-byte units of the spec. The first invalid spec fails Build.
+type LearnBuilder struct {
+	learn *NXActionLearn
+	err   error
+}
+
+func NewLearnBuilder() *LearnBuilder {
+	return &LearnBuilder{learn: NewNXActionLearn()}
+}
+
+// Table sets the table of the learned flow.
+func (b *LearnBuilder) Table(tableID uint8) *LearnBuilder {
+	b.learn.TableID = tableID
+	return b
+}
+
+// Priority sets the priority of the learned flow.
+func (b *LearnBuilder) Priority(priority uint16) *LearnBuilder {
+	b.learn.Priority = priority
+	return b
+}
+
+// Cookie sets the cookie of the learned flow.
+func (b *LearnBuilder) Cookie(cookie uint64) *LearnBuilder {
+	b.learn.Cookie = cookie
+	return b
+}
+
+// Flags sets the flags of the learn action, a bitmap of NX_LEARN_F_*.
+func (b *LearnBuilder) Flags(flags uint16) *LearnBuilder {
+	b.learn.Flags = flags
+	return b
+}
+
+// IdleTimeout sets the idle timeout of the learned flow in seconds.
+func (b *LearnBuilder) IdleTimeout(timeout uint16) *LearnBuilder {
+	b.learn.IdleTimeout = timeout
+	return b
+}
+
+// HardTimeout sets the hard timeout of the learned flow in seconds.
+func (b *LearnBuilder) HardTimeout(timeout uint16) *LearnBuilder {
+	b.learn.HardTimeout = timeout
+	return b
+}
+
+// FinTimeout sets the idle and hard timeouts of the learned flow after a TCP FIN or RST is seen.
+func (b *LearnBuilder) FinTimeout(idleTimeout, hardTimeout uint16) *LearnBuilder {
+	b.learn.FinIdleTimeout = idleTimeout
+	b.learn.FinHardTimeout = hardTimeout
+	return b
+}
+
+func (b *LearnBuilder) fail(format string, args ...interface{}) *LearnBuilder {
+	if b.err == nil {
+		b.err = fmt.Errorf("learn spec %d: %s", len(b.learn.LearnSpecs), fmt.Sprintf(format, args...))
+	}
+	return b
+}
+
+// oxmFieldBits returns the number of bits of the field value, without the mask and the experimenter ID.
+func oxmFieldBits(field *MatchField) int {
+	length := int(field.Length)
+	if field.ExperimenterID != 0 {
+		length -= 4
+	}
+	if field.HasMask {
+		length /= 2
+	}
+	return length * 8
+}
+
+// fieldRange returns the spec field and the number of bits of the range in the field, and false if the range
+// is invalid.
+func (b *LearnBuilder) fieldRange(field *MatchField, rng *NXRange) (*NXLearnSpecField, uint16, bool) {
+	if field == nil {
+		b.fail("missing field")
+		return nil, 0, false
+	}
+	size := oxmFieldBits(field)
+	if rng == nil {
+		rng = NewNXRange(0, size-1)
+	}
+	if rng.start < 0 || rng.end < rng.start || rng.end >= size {
+		b.fail("invalid range [%d..%d] of the %d-bit field %s", rng.start, rng.end, size, oxxFieldName(field))
+		return nil, 0, false
+	}
+	return &NXLearnSpecField{Field: field, Ofs: rng.GetOfs()}, rng.GetNbits(), true
+}
+
+// learnSpecValue returns the value in the 2-byte units of nBits, and false if the value exceeds nBits.
+func (b *LearnBuilder) learnSpecValue(value []byte, nBits uint16) ([]byte, bool) {
+	length := int(2 * ((nBits + 15) / 16))
+	data := make([]byte, length)
+	for i, v := range value {
+		bitOfs := (len(value) - 1 - i) * 8
+		if v == 0 {
+			continue
+		}
+		if bitOfs+bits.Len8(v) > int(nBits) {
+			b.fail("value %x exceeds %d bits", value, nBits)
+			return nil, false
+		}
+		data[length-1-(len(value)-1-i)] = v
+	}
+	return data, true
+}
+
+func (b *LearnBuilder) addSpec(spec *NXLearnSpec) *LearnBuilder {
+	b.learn.LearnSpecs = append(b.learn.LearnSpecs, spec)
+	return b
+}
+
+// MatchField adds the spec dst[]=src[] which matches the first nBits of dst in the learned flow with the first
+// nBits of src in the packet.
+func (b *LearnBuilder) MatchField(src, dst *MatchField, nBits uint16) *LearnBuilder {
+	if nBits == 0 {
+		return b.fail("invalid number of bits 0")
+	}
+	return b.MatchFieldRange(src, NewNXRangeByOfsNBits(0, int(nBits)), dst, NewNXRangeByOfsNBits(0, int(nBits)))
+}
+
+// MatchFieldRange adds the spec which matches the range of dst in the learned flow with the range of src in the
+// packet, the ranges must have the same number of bits.
+func (b *LearnBuilder) MatchFieldRange(src *MatchField, srcRng *NXRange, dst *MatchField, dstRng *NXRange) *LearnBuilder {
+	return b.fieldSpec(NewLearnHeaderMatchFromField, src, srcRng, dst, dstRng)
+}
+
+// MatchValue adds the spec which matches the range of dst in the learned flow with the value, the value is in
+// big endian.
+func (b *LearnBuilder) MatchValue(value []byte, dst *MatchField, dstRng *NXRange) *LearnBuilder {
+	return b.valueSpec(NewLearnHeaderMatchFromValue, value, dst, dstRng)
+}
+
+// LoadValue adds the spec load:value->dst which loads the value to the range of dst in the learned flow, the
+// value is in big endian.
+func (b *LearnBuilder) LoadValue(value []byte, dst *MatchField, dstRng *NXRange) *LearnBuilder {
+	return b.valueSpec(NewLearnHeaderLoadFromValue, value, dst, dstRng)
+}
+
+// LoadField adds the spec load:src->dst which loads the range of src in the packet to the range of dst in the
+// learned flow, the ranges must have the same number of bits.
+func (b *LearnBuilder) LoadField(src *MatchField, srcRng *NXRange, dst *MatchField, dstRng *NXRange) *LearnBuilder {
+	return b.fieldSpec(NewLearnHeaderLoadFromField, src, srcRng, dst, dstRng)
+}
+
+// OutputToPort adds the spec output:src which outputs the packets of the learned flow to the port in the range
+// of src in the packet, e.g., NXM_OF_IN_PORT[].
+func (b *LearnBuilder) OutputToPort(src *MatchField, srcRng *NXRange) *LearnBuilder {
+	srcField, nBits, ok := b.fieldRange(src, srcRng)
+	if !ok {
+		return b
+	}
+	return b.addSpec(&NXLearnSpec{Header: NewLearnHeaderOutputFromField(nBits), SrcField: srcField})
+}
+
+func (b *LearnBuilder) fieldSpec(header func(uint16) *NXLearnSpecHeader, src *MatchField, srcRng *NXRange, dst *MatchField, dstRng *NXRange) *LearnBuilder {
+	srcField, srcBits, ok := b.fieldRange(src, srcRng)
+	if !ok {
+		return b
+	}
+	dstField, dstBits, ok := b.fieldRange(dst, dstRng)
+	if !ok {
+		return b
+	}
+	if srcBits != dstBits {
+		return b.fail("the %d bits of the source differ from the %d bits of the destination", srcBits, dstBits)
+	}
+	return b.addSpec(&NXLearnSpec{Header: header(srcBits), SrcField: srcField, DstField: dstField})
+}
+
+func (b *LearnBuilder) valueSpec(header func(uint16) *NXLearnSpecHeader, value []byte, dst *MatchField, dstRng *NXRange) *LearnBuilder {
+	dstField, nBits, ok := b.fieldRange(dst, dstRng)
+	if !ok {
+		return b
+	}
+	srcValue, ok := b.learnSpecValue(value, nBits)
+	if !ok {
+		return b
+	}
+	return b.addSpec(&NXLearnSpec{Header: header(nBits), SrcValue: srcValue, DstField: dstField})
+}
+
+// Build returns the learn action with the length of the specs, or the error of the first invalid spec.
+func (b *LearnBuilder) Build() (*NXActionLearn, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	b.learn.Length = b.learn.Len()
+	return b.learn, nil
+}
@@ -0,0 +1,53 @@
+package openflow13
+
+import (
+	"testing"
+)
+
+func TestLearnBuilder(t *testing.T) {
+	field := func(name string) *MatchField {
+		f, err := FindFieldHeaderByName(name, false)
+		if err != nil {
+			t.Fatalf("Failed to find field %s: %v", name, err)
+		}
+		return f
+	}
+	learn, err := NewLearnBuilder().Table(10).Priority(100).IdleTimeout(60).
+		MatchValue([]byte{0x08, 0x00}, field("NXM_OF_ETH_TYPE"), nil).
+		MatchField(field("NXM_OF_ETH_SRC"), field("NXM_OF_ETH_DST"), 48).
+		LoadValue([]byte{0x0, 0x1}, field("NXM_NX_REG0"), NewNXRange(0, 2)).
+		LoadField(field("NXM_NX_REG1"), NewNXRange(16, 31), field("NXM_NX_REG2"), NewNXRange(0, 15)).
+		OutputToPort(field("NXM_OF_IN_PORT"), nil).
+		Build()
+	if err != nil {
+		t.Fatalf("Failed to build learn action: %v", err)
+	}
+	data, err := learn.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal learn action: %v", err)
+	}
+	if len(data)%8 != 0 || int(learn.Length) != len(data) {
+		t.Errorf("Unexpected learn action length %d of %d bytes", learn.Length, len(data))
+	}
+	decoded := new(NXActionLearn)
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal learn action: %v", err)
+	}
+	exp := "learn(table=10,idle_timeout=60,priority=100,NXM_OF_ETH_TYPE[]=0x800,NXM_OF_ETH_DST[]=NXM_OF_ETH_SRC[]," +
+		"load:0x1->NXM_NX_REG0[0..2],load:NXM_NX_REG1[16..31]->NXM_NX_REG2[0..15],output:NXM_OF_IN_PORT[])"
+	if s := decoded.String(); s != exp {
+		t.Errorf("Unexpected learn action:\n%s\nexpected:\n%s", s, exp)
+	}
+
+	for name, b := range map[string]*LearnBuilder{
+		"value exceeds bits":  NewLearnBuilder().LoadValue([]byte{0x8}, field("NXM_NX_REG0"), NewNXRange(0, 2)),
+		"range beyond field":  NewLearnBuilder().MatchValue([]byte{0x1}, field("NXM_OF_ETH_TYPE"), NewNXRange(8, 16)),
+		"different bits":      NewLearnBuilder().LoadField(field("NXM_NX_REG1"), nil, field("NXM_NX_REG2"), NewNXRange(0, 15)),
+		"nbits beyond fields": NewLearnBuilder().MatchField(field("NXM_OF_ETH_TYPE"), field("NXM_NX_REG0"), 32),
+		"missing field":       NewLearnBuilder().OutputToPort(nil, nil),
+	} {
+		if _, err = b.Build(); err == nil {
+			t.Errorf("Expect error for %s", name)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package openflow13
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/contiv/libOpenflow/protocol"
+)
+
+// ReverseFlowLearnConfig is the config of the learned reverse flows built by NewReverseFlowLearnActions. The
+// learned flow matches the reply direction of the connection, i.e., the IP addresses and L4 ports of the packet
+// are swapped.
+type ReverseFlowLearnConfig struct {
+	// TableID is the table to install the learned flows.
+	TableID  uint8
+	Priority uint16
+	Cookie   uint64
+	// Flags is a bitmap of NX_LEARN_F_*.
+	Flags       uint16
+	IdleTimeout uint16
+	HardTimeout uint16
+	// FinIdleTimeout and FinHardTimeout are the idle and hard timeouts of the learned flow after a TCP FIN or RST
+	// is seen, they are only valid if Protocol is TCP.
+	FinIdleTimeout uint16
+	FinHardTimeout uint16
+	// IPv6 selects NXM_NX_IPV6_SRC/NXM_NX_IPV6_DST instead of NXM_OF_IP_SRC/NXM_OF_IP_DST.
+	IPv6 bool
+	// Protocol is the L4 protocol, protocol.Type_TCP or protocol.Type_UDP.
+	Protocol uint8
+	// ExtraSpecs are appended to the generated learn specs, e.g., to load a register in the learned flow.
+	ExtraSpecs []*NXLearnSpec
+}
+
+// NewLearnSpecMatchFromField returns a learn spec which matches the dst field in the learned flow with the value
+// of the src field in the packet.
+func NewLearnSpecMatchFromField(srcName, dstName string, nBits uint16) (*NXLearnSpec, error) {
+	src, err := FindFieldHeaderByName(srcName, false)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := FindFieldHeaderByName(dstName, false)
+	if err != nil {
+		return nil, err
+	}
+	return &NXLearnSpec{
+		Header:   NewLearnHeaderMatchFromField(nBits),
+		SrcField: &NXLearnSpecField{Field: src},
+		DstField: &NXLearnSpecField{Field: dst},
+	}, nil
+}
+
+// NewLearnSpecMatchFromValue returns a learn spec which matches the dst field in the learned flow with the
+// value. The value is encoded in big endian, and padded to a multiple of 16 bits.
+func NewLearnSpecMatchFromValue(value uint64, dstName string, nBits uint16) (*NXLearnSpec, error) {
+	dst, err := FindFieldHeaderByName(dstName, false)
+	if err != nil {
+		return nil, err
+	}
+	if nBits == 0 || nBits > 64 {
+		return nil, fmt.Errorf("invalid number of bits %d for a value", nBits)
+	}
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, value)
+	return &NXLearnSpec{
+		Header:   NewLearnHeaderMatchFromValue(nBits),
+		SrcValue: b[8-2*((nBits+15)/16):],
+		DstField: &NXLearnSpecField{Field: dst},
+	}, nil
+}
+
+// NewReverseFlowLearn returns the learn action which installs the reverse flow of the connection. The learned
+// flow matches the EtherType and the IP protocol, and the swapped IP addresses and L4 ports of the packet.
+func NewReverseFlowLearn(config *ReverseFlowLearnConfig) (*NXActionLearn, error) {
+	var tpSrc, tpDst string
+	switch config.Protocol {
+	case protocol.Type_TCP:
+		tpSrc, tpDst = "NXM_OF_TCP_SRC", "NXM_OF_TCP_DST"
+	case protocol.Type_UDP:
+		if config.FinIdleTimeout != 0 || config.FinHardTimeout != 0 {
+			return nil, fmt.Errorf("fin timeouts are only valid for TCP")
+		}
+		tpSrc, tpDst = "NXM_OF_UDP_SRC", "NXM_OF_UDP_DST"
+	default:
+		return nil, fmt.Errorf("unsupported protocol %d for reverse flow learning", config.Protocol)
+	}
+	ethType := uint64(protocol.IPv4_MSG)
+	ipSrc, ipDst, ipBits := "NXM_OF_IP_SRC", "NXM_OF_IP_DST", uint16(32)
+	if config.IPv6 {
+		ethType = protocol.IPv6_MSG
+		ipSrc, ipDst, ipBits = "NXM_NX_IPV6_SRC", "NXM_NX_IPV6_DST", 128
+	}
+
+	learn := NewNXActionLearn()
+	learn.TableID = config.TableID
+	learn.Priority = config.Priority
+	learn.Cookie = config.Cookie
+	learn.Flags = config.Flags
+	learn.IdleTimeout = config.IdleTimeout
+	learn.HardTimeout = config.HardTimeout
+	learn.FinIdleTimeout = config.FinIdleTimeout
+	learn.FinHardTimeout = config.FinHardTimeout
+
+	spec, err := NewLearnSpecMatchFromValue(ethType, "NXM_OF_ETH_TYPE", 16)
+	if err != nil {
+		return nil, err
+	}
+	learn.LearnSpecs = append(learn.LearnSpecs, spec)
+	spec, err = NewLearnSpecMatchFromValue(uint64(config.Protocol), "NXM_OF_IP_PROTO", 8)
+	if err != nil {
+		return nil, err
+	}
+	learn.LearnSpecs = append(learn.LearnSpecs, spec)
+	for _, f := range []struct {
+		src, dst string
+		nBits    uint16
+	}{
+		{ipDst, ipSrc, ipBits},
+		{ipSrc, ipDst, ipBits},
+		{tpDst, tpSrc, 16},
+		{tpSrc, tpDst, 16},
+	} {
+		spec, err = NewLearnSpecMatchFromField(f.src, f.dst, f.nBits)
+		if err != nil {
+			return nil, err
+		}
+		learn.LearnSpecs = append(learn.LearnSpecs, spec)
+	}
+	learn.LearnSpecs = append(learn.LearnSpecs, config.ExtraSpecs...)
+	learn.Length = learn.Len()
+	return learn, nil
+}
+
+// NewReverseFlowLearnActions returns the actions of the common OVS pattern to track a TCP or UDP connection: the
+// learn action which installs the reverse flow with the fin timeouts, preceded by the fin_timeout action which
+// shortens the timeouts of the matched flow itself when the connection is closed, if the fin timeouts are set.
+func NewReverseFlowLearnActions(config *ReverseFlowLearnConfig) ([]Action, error) {
+	learn, err := NewReverseFlowLearn(config)
+	if err != nil {
+		return nil, err
+	}
+	var actions []Action
+	if config.FinIdleTimeout != 0 || config.FinHardTimeout != 0 {
+		actions = append(actions, NewNXActionFinTimeout(config.FinIdleTimeout, config.FinHardTimeout))
+	}
+	return append(actions, learn), nil
+}
@@ -0,0 +1,72 @@
+package openflow13
+
+import (
+	"testing"
+
+	"github.com/contiv/libOpenflow/protocol"
+)
+
+func TestReverseFlowLearnActions(t *testing.T) {
+	config := &ReverseFlowLearnConfig{
+		TableID:        10,
+		Priority:       200,
+		Cookie:         0x1234,
+		Flags:          NX_LEARN_F_DELETE_LEARNED,
+		IdleTimeout:    60,
+		FinIdleTimeout: 5,
+		FinHardTimeout: 10,
+		Protocol:       protocol.Type_TCP,
+	}
+	actions, err := NewReverseFlowLearnActions(config)
+	if err != nil {
+		t.Fatalf("Failed to build actions: %v", err)
+	}
+	if len(actions) != 2 {
+		t.Fatalf("Expect 2 actions, actual: %d", len(actions))
+	}
+
+	data, err := actions[0].MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal fin_timeout: %v", err)
+	}
+	decoded, err := DecodeAction(data)
+	if err != nil {
+		t.Fatalf("Failed to decode fin_timeout: %v", err)
+	}
+	finTimeout, ok := decoded.(*NXActionFinTimeout)
+	if !ok || finTimeout.FinIdleTimeout != 5 || finTimeout.FinHardTimeout != 10 || finTimeout.Length != 16 {
+		t.Errorf("Unexpected fin_timeout: %+v", decoded)
+	}
+
+	learn := actions[1].(*NXActionLearn)
+	data, err = learn.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal learn: %v", err)
+	}
+	decoded, err = DecodeAction(data)
+	if err != nil {
+		t.Fatalf("Failed to decode learn: %v", err)
+	}
+	if err = nsLearnEquals(learn, decoded.(*NXActionLearn)); err != nil {
+		t.Error(err)
+	}
+	if len(learn.LearnSpecs) != 6 {
+		t.Fatalf("Expect 6 learn specs, actual: %d", len(learn.LearnSpecs))
+	}
+	// The IP source of the learned flow is loaded from the IP destination of the packet.
+	ipSpec := learn.LearnSpecs[2]
+	if ipSpec.SrcField.Field.Field != NXM_OF_IP_DST || ipSpec.DstField.Field.Field != NXM_OF_IP_SRC {
+		t.Errorf("Unexpected IP learn spec: %+v %+v", ipSpec.SrcField.Field, ipSpec.DstField.Field)
+	}
+
+	config.Protocol = protocol.Type_UDP
+	if _, err = NewReverseFlowLearnActions(config); err == nil {
+		t.Errorf("Expect error for fin timeouts with UDP")
+	}
+	config.FinIdleTimeout, config.FinHardTimeout = 0, 0
+	config.IPv6 = true
+	actions, err = NewReverseFlowLearnActions(config)
+	if err != nil || len(actions) != 1 {
+		t.Fatalf("Expect only the learn action without fin timeouts: %v %v", actions, err)
+	}
+}
@@ -80,20 +80,35 @@ func (m *Match) MarshalBinary() (data []byte, err error) {
 }
 
 func (m *Match) UnmarshalBinary(data []byte) error {
+	return m.UnmarshalBinaryWithContext(data, nil)
+}
+
+// UnmarshalBinaryWithContext unmarshals the Match with the field lengths of the connection, ctx could be nil if
+// the connection has no TLV table maps.
+func (m *Match) UnmarshalBinaryWithContext(data []byte, ctx *FieldLengthContext) error {
+	if err := checkBounds(data, 0, 4, "Match", "length"); err != nil {
+		return err
+	}
 
 	n := 0
 	m.Type = binary.BigEndian.Uint16(data[n:])
 	n += 2
 	m.Length = binary.BigEndian.Uint16(data[n:])
 	n += 2
+	if err := checkBounds(data, 0, int(m.Length), "Match", "length"); err != nil {
+		return err
+	}
 
 	for n < int(m.Length) {
 		field := new(MatchField)
-		if err := field.UnmarshalBinary(data[n:]); err != nil {
-			return err
+		if err := field.UnmarshalBinaryWithContext(data[n:], ctx); err != nil {
+			return decodeErrorAt(err, "Match", n, "match field")
 		}
 		m.Fields = append(m.Fields, *field)
-		n += int(field.Len())
+		var err error
+		if n, err = safeAdvance(n, field.Len(), int(m.Length), "match field"); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -135,6 +150,11 @@ func (m *MatchField) MarshalBinary() (data []byte, err error) {
 	data[n] = m.Length
 	n += 1
 
+	if m.ExperimenterID != 0 {
+		binary.BigEndian.PutUint32(data[n:], m.ExperimenterID)
+		n += 4
+	}
+
 	b, err := m.Value.MarshalBinary()
 	copy(data[n:], b)
 	n += len(b)
@@ -148,8 +168,17 @@ func (m *MatchField) MarshalBinary() (data []byte, err error) {
 }
 
 func (m *MatchField) UnmarshalBinary(data []byte) error {
+	return m.UnmarshalBinaryWithContext(data, nil)
+}
+
+// UnmarshalBinaryWithContext unmarshals the MatchField, the length of a tun_metadata field is checked against
+// the TLV table maps in ctx if ctx is not nil.
+func (m *MatchField) UnmarshalBinaryWithContext(data []byte, ctx *FieldLengthContext) error {
 	var n uint16
 	var err error
+	if len(data) < 4 {
+		return errors.New("the []byte is too short to unmarshal a full MatchField message")
+	}
 	m.Class = binary.BigEndian.Uint16(data[n:])
 	n += 2
 
@@ -164,24 +193,41 @@ func (m *MatchField) UnmarshalBinary(data []byte) error {
 
 	m.Length = data[n]
 	n += 1
+	if int(n)+int(m.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full MatchField message")
+	}
+	data = data[:int(n)+int(m.Length)]
+
+	if ctx != nil && isTunMetadataField(m.Class, m.Field) {
+		if err := ctx.checkTunMetadataField(m); err != nil {
+			return err
+		}
+	}
 
 	if m.Class == OXM_CLASS_EXPERIMENTER {
+		if len(data) < 8 {
+			return errors.New("the []byte is too short to unmarshal the experimenter ID of a MatchField")
+		}
 		experimenterID := binary.BigEndian.Uint32(data[n:])
-		if experimenterID == ONF_EXPERIMENTER_ID {
-			n += 4
-			m.ExperimenterID = experimenterID
-		} else {
-			return fmt.Errorf("Unsupported experimenter id: %d in class: %d ", experimenterID, m.Class)
+		switch experimenterID {
+		case ONF_EXPERIMENTER_ID, NXOXM_NSH_EXPERIMENTER_ID, NxExperimenterID:
+		default:
+			if lookupOXMField(m.Class, m.Field, experimenterID) == nil {
+				return fmt.Errorf("Unsupported experimenter id: %d in class: %d ", experimenterID, m.Class)
+			}
 		}
+		n += 4
+		m.ExperimenterID = experimenterID
 	}
 
-	if m.Value, err = DecodeMatchField(m.Class, m.Field, m.Length, m.HasMask, data[n:]); err != nil {
+	decode := matchFieldDecoder(m.Class, m.Field, m.ExperimenterID)
+	if m.Value, err = decode(m.Class, m.Field, m.Length, m.HasMask, data[n:]); err != nil {
 		return err
 	}
 	n += m.Value.Len()
 
 	if m.HasMask {
-		if m.Mask, err = DecodeMatchField(m.Class, m.Field, m.Length, m.HasMask, data[n:]); err != nil {
+		if m.Mask, err = decode(m.Class, m.Field, m.Length, m.HasMask, data[n:]); err != nil {
 			return err
 		}
 		n += m.Mask.Len()
@@ -189,14 +235,43 @@ func (m *MatchField) UnmarshalBinary(data []byte) error {
 	return err
 }
 
-func (m *MatchField) MarshalHeader() uint32 {
+// matchFieldDecoder returns the function to decode the value and the mask of the match field, the registered
+// fields are decoded by their factories.
+func matchFieldDecoder(class uint16, field uint8, experimenterID uint32) func(class uint16, field uint8, length uint8,
+	hasMask bool, data []byte) (util.Message, error) {
+	if info := lookupOXMField(class, field, experimenterID); info != nil {
+		return info.decode
+	}
+	switch experimenterID {
+	case NXOXM_NSH_EXPERIMENTER_ID:
+		return DecodeNSHMatchField
+	case NxExperimenterID:
+		return DecodeNXOXMMatchField
+	}
+	return DecodeMatchField
+}
+
+// PackOXMHeader packs the OXM/NXM header fields into the 32-bit header representation,
+// the layout is class(16 bits) | field(7 bits) | hasmask(1 bit) | length(8 bits).
+func PackOXMHeader(class uint16, field uint8, hasMask bool, length uint8) uint32 {
 	var maskData uint32
-	if m.HasMask {
+	if hasMask {
 		maskData = 1 << 8
-	} else {
-		maskData = 0 << 8
 	}
-	return uint32(m.Class)<<16 | uint32(m.Field)<<9 | maskData | uint32(m.Length)
+	return uint32(class)<<16 | uint32(field&0x7f)<<9 | maskData | uint32(length)
+}
+
+// UnpackOXMHeader unpacks the 32-bit OXM/NXM header into class, field, hasmask and length.
+func UnpackOXMHeader(header uint32) (class uint16, field uint8, hasMask bool, length uint8) {
+	class = uint16(header >> 16)
+	field = uint8(header>>9) & 0x7f
+	hasMask = (header>>8)&1 == 1
+	length = uint8(header)
+	return
+}
+
+func (m *MatchField) MarshalHeader() uint32 {
+	return PackOXMHeader(m.Class, m.Field, m.HasMask, m.Length)
 }
 
 func (m *MatchField) UnmarshalHeader(data []byte) error {
@@ -205,14 +280,7 @@ func (m *MatchField) UnmarshalHeader(data []byte) error {
 		err = fmt.Errorf("the []byte is too short to unmarshal MatchField header")
 		return err
 	}
-	n := 0
-	m.Class = binary.BigEndian.Uint16(data[n:])
-	n += 2
-	fieldWithMask := data[n]
-	m.HasMask = fieldWithMask&1 == 1
-	m.Field = fieldWithMask >> 1
-	n += 1
-	m.Length = data[n] & 0xff
+	m.Class, m.Field, m.HasMask, m.Length = UnpackOXMHeader(binary.BigEndian.Uint32(data))
 	return err
 }
 
@@ -305,11 +373,7 @@ func DecodeMatchField(class uint16, field uint8, length uint8, hasMask bool, dat
 			return nil, fmt.Errorf("Bad pkt class: %v field: %v data: %v", class, field, data)
 		}
 
-		err := val.UnmarshalBinary(data)
-		if err != nil {
-			return nil, err
-		}
-		return val, nil
+		return unmarshalFieldValue(val, class, field, data)
 	} else if class == OXM_CLASS_NXM_1 {
 		var val util.Message
 		switch field {
@@ -397,14 +461,9 @@ func DecodeMatchField(class uint16, field uint8, length uint8, hasMask bool, dat
 		case NXM_NX_TUN_METADATA6:
 			fallthrough
 		case NXM_NX_TUN_METADATA7:
-			msg := new(ByteArrayField)
-			if !hasMask {
-				msg.Length = length
-			} else {
-				msg.Length = length / 2
-			}
-			val = msg
+			val = newTunMetadataValue(length, hasMask)
 		case NXM_NX_TUN_FLAGS:
+			val = new(Uint16Message)
 		case NXM_NX_CT_STATE:
 			val = new(Uint32Message)
 		case NXM_NX_CT_ZONE:
@@ -446,15 +505,16 @@ func DecodeMatchField(class uint16, field uint8, length uint8, hasMask bool, dat
 			}
 			val = msg
 		default:
+			// Only tun_metadata0-7 have the constants, the others up to tun_metadata63 follow them.
+			if isTunMetadataField(class, field) {
+				val = newTunMetadataValue(length, hasMask)
+				break
+			}
 			log.Printf("Unhandled Field: %d in Class: %d", field, class)
 			return nil, fmt.Errorf("Bad pkt class: %v field: %v data: %v", class, field, data)
 		}
 
-		err := val.UnmarshalBinary(data)
-		if err != nil {
-			return nil, err
-		}
-		return val, nil
+		return unmarshalFieldValue(val, class, field, data)
 	} else if class == OXM_CLASS_EXPERIMENTER {
 		var val util.Message
 		switch field {
@@ -463,16 +523,24 @@ func DecodeMatchField(class uint16, field uint8, length uint8, hasMask bool, dat
 		case OXM_FIELD_ACTSET_OUTPUT:
 			val = new(ActsetOutputField)
 		}
-		err := val.UnmarshalBinary(data)
-		if err != nil {
-			return nil, err
-		}
-		return val, nil
-	} else {
-		log.Panicf("Unsupported match field: %d in class: %d", field, class)
+		return unmarshalFieldValue(val, class, field, data)
 	}
+	return nil, fmt.Errorf("unsupported match field: %d in class: %d", field, class)
+}
 
-	return nil, nil
+// unmarshalFieldValue unmarshals the value or mask of a match field, an error is returned if the field has no
+// value type, or if data is shorter than the value.
+func unmarshalFieldValue(val util.Message, class uint16, field uint8, data []byte) (util.Message, error) {
+	if val == nil {
+		return nil, fmt.Errorf("unsupported match field: %d in class: %d", field, class)
+	}
+	if len(data) < int(val.Len()) {
+		return nil, fmt.Errorf("the []byte is too short to unmarshal match field: %d in class: %d", field, class)
+	}
+	if err := val.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return val, nil
 }
 
 //  ofp_match_type 1.3
@@ -488,7 +556,8 @@ const (
 	OXM_CLASS_OPENFLOW_BASIC = 0x8000 /* Basic class for OpenFlow */
 	OXM_CLASS_EXPERIMENTER   = 0xFFFF /* Experimenter class */
 
-	ONF_EXPERIMENTER_ID = 0x4f4e4600 /* ONF Experimenter ID */
+	ONF_EXPERIMENTER_ID       = 0x4f4e4600 /* ONF Experimenter ID */
+	NXOXM_NSH_EXPERIMENTER_ID = 0x005ad650 /* Nicira Experimenter ID for NSH fields */
 )
 
 const (
@@ -606,6 +675,28 @@ const (
 	NXM_NX_CT_TP_DST     = 125 /* nicira extension: ct_tp_dst, transport layer destination port of the original direction tuple of the conntrack entry */
 )
 
+// Nicira extension fields in class OXM_CLASS_EXPERIMENTER with experimenter ID NxExperimenterID.
+const (
+	NXOXM_ET_ERSPAN_IDX  = 11 /* nicira extension: tun_erspan_idx, ERSPAN version 1 index, the least 20 bits are used */
+	NXOXM_ET_ERSPAN_VER  = 12 /* nicira extension: tun_erspan_ver, ERSPAN version */
+	NXOXM_ET_ERSPAN_DIR  = 13 /* nicira extension: tun_erspan_dir, ERSPAN version 2 direction */
+	NXOXM_ET_ERSPAN_HWID = 14 /* nicira extension: tun_erspan_hwid, ERSPAN version 2 hardware ID */
+)
+
+// NSH fields. These fields are in class OXM_CLASS_EXPERIMENTER with experimenter ID NXOXM_NSH_EXPERIMENTER_ID.
+const (
+	NXOXM_NSH_FLAGS  = 1  /* nicira extension: nsh_flags, flags in NSH base header */
+	NXOXM_NSH_MDTYPE = 2  /* nicira extension: nsh_mdtype, metadata type in NSH base header */
+	NXOXM_NSH_NP     = 3  /* nicira extension: nsh_np, next protocol in NSH base header */
+	NXOXM_NSH_SPI    = 4  /* nicira extension: nsh_spi, service path identifier, the least 24 bits are used */
+	NXOXM_NSH_SI     = 5  /* nicira extension: nsh_si, service index */
+	NXOXM_NSH_C1     = 6  /* nicira extension: nsh_c1, MD type 1 context header 1 */
+	NXOXM_NSH_C2     = 7  /* nicira extension: nsh_c2, MD type 1 context header 2 */
+	NXOXM_NSH_C3     = 8  /* nicira extension: nsh_c3, MD type 1 context header 3 */
+	NXOXM_NSH_C4     = 9  /* nicira extension: nsh_c4, MD type 1 context header 4 */
+	NXOXM_NSH_TTL    = 10 /* nicira extension: nsh_ttl, time-to-live in NSH base header */
+)
+
 // IN_PORT field
 type InPortField struct {
 	InPort uint32
@@ -655,6 +746,7 @@ func (m *EthDstField) MarshalBinary() (data []byte, err error) {
 }
 
 func (m *EthDstField) UnmarshalBinary(data []byte) error {
+	m.EthDst = make([]byte, 6)
 	copy(m.EthDst, data)
 	return nil
 }
@@ -698,6 +790,7 @@ func (m *EthSrcField) MarshalBinary() (data []byte, err error) {
 }
 
 func (m *EthSrcField) UnmarshalBinary(data []byte) error {
+	m.EthSrc = make([]byte, 6)
 	copy(m.EthSrc, data)
 	return nil
 }
@@ -1497,7 +1590,7 @@ func NewSctpDstField(port uint16) *MatchField {
 	return f
 }
 
-// SCTP_DST field
+// SCTP_SRC field
 func NewSctpSrcField(port uint16) *MatchField {
 	f := new(MatchField)
 	f.Class = OXM_CLASS_OPENFLOW_BASIC
@@ -1530,6 +1623,7 @@ func (m *ArpXHaField) UnmarshalBinary(data []byte) error {
 	if len(data) < int(m.Len()) {
 		return errors.New("The byte array has wrong size to unmarshal ArpXHaField message")
 	}
+	m.ArpHa = make([]byte, 6)
 	copy(m.ArpHa, data[:6])
 	return nil
 }
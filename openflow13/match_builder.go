@@ -0,0 +1,249 @@
+package openflow13
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/contiv/libOpenflow/protocol"
+)
+
+// ErrMatchPrerequisite is wrapped by the errors of MatchBuilder if a field conflicts with the prerequisites of
+// the other fields, which the switch would reject with BMC_BAD_PREREQ.
+var ErrMatchPrerequisite = errors.New("conflicting match prerequisites")
+
+// MatchBuilder builds a Match from the typed fields, and inserts the prerequisites of the fields before them,
+// i.e., the eth_type of the IP and ARP fields, and the ip_proto of the L4 fields, e.g.,
+//
+//	NewMatchBuilder().SetIPv4Dst(ipNet).SetTCPDstPort(80, 0xffff).Build()
+//
+// matches eth_type=0x0800, ip_proto=6, ipv4_dst and tcp_dst. The eth_type of ip_proto is IPv4 unless an IPv6
+// field or eth_type is set before. A field conflicting with the prerequisites already set, e.g., SetUDPDstPort
+// after SetTCPDstPort, fails Build with an error wrapping ErrMatchPrerequisite. Setting a field again replaces
+// its value.
+type MatchBuilder struct {
+	match   *Match
+	ethType *uint16
+	ipProto *uint8
+	err     error
+}
+
+func NewMatchBuilder() *MatchBuilder {
+	return &MatchBuilder{match: NewMatch()}
+}
+
+// setField replaces the field of the same class and field, or appends the field.
+func (b *MatchBuilder) setField(f *MatchField) *MatchBuilder {
+	for i := range b.match.Fields {
+		if b.match.Fields[i].Class == f.Class && b.match.Fields[i].Field == f.Field {
+			b.match.Fields[i] = *f
+			return b
+		}
+	}
+	b.match.AddField(*f)
+	return b
+}
+
+func (b *MatchBuilder) fail(format string, args ...interface{}) *MatchBuilder {
+	if b.err == nil {
+		b.err = fmt.Errorf("%w: %s", ErrMatchPrerequisite, fmt.Sprintf(format, args...))
+	}
+	return b
+}
+
+// requireEthType adds the eth_type prerequisite, and returns false if another eth_type is set.
+func (b *MatchBuilder) requireEthType(ethTypes ...uint16) bool {
+	if b.ethType == nil {
+		b.SetEthType(ethTypes[0])
+		return true
+	}
+	for _, ethType := range ethTypes {
+		if *b.ethType == ethType {
+			return true
+		}
+	}
+	b.fail("eth_type 0x%04x is set, but 0x%04x is required", *b.ethType, ethTypes[0])
+	return false
+}
+
+// requireIPProto adds the ip_proto prerequisite and its eth_type, and returns false if another ip_proto is set.
+func (b *MatchBuilder) requireIPProto(ipProto uint8) bool {
+	if b.ipProto == nil {
+		b.SetIPProto(ipProto)
+		return b.ipProto != nil
+	}
+	if *b.ipProto != ipProto {
+		b.fail("ip_proto %d is set, but %d is required", *b.ipProto, ipProto)
+		return false
+	}
+	return true
+}
+
+// SetInPort matches the input port.
+func (b *MatchBuilder) SetInPort(port uint32) *MatchBuilder {
+	return b.setField(NewInPortField(port))
+}
+
+// SetEthSrc matches the Ethernet source address, the mask is optional.
+func (b *MatchBuilder) SetEthSrc(addr net.HardwareAddr, mask *net.HardwareAddr) *MatchBuilder {
+	return b.setField(NewEthSrcField(addr, mask))
+}
+
+// SetEthDst matches the Ethernet destination address, the mask is optional.
+func (b *MatchBuilder) SetEthDst(addr net.HardwareAddr, mask *net.HardwareAddr) *MatchBuilder {
+	return b.setField(NewEthDstField(addr, mask))
+}
+
+// SetEthType matches the Ethernet type, it conflicts with the eth_type required by the fields already set.
+func (b *MatchBuilder) SetEthType(ethType uint16) *MatchBuilder {
+	if b.ethType != nil && *b.ethType != ethType {
+		return b.fail("eth_type 0x%04x conflicts with eth_type 0x%04x", ethType, *b.ethType)
+	}
+	b.ethType = &ethType
+	return b.setField(NewEthTypeField(ethType))
+}
+
+// SetVlanID matches the VLAN ID, the mask is optional.
+func (b *MatchBuilder) SetVlanID(vlanID uint16, mask *uint16) *MatchBuilder {
+	return b.setField(NewVlanIdField(vlanID, mask))
+}
+
+// SetIPProto matches the IP protocol, and adds eth_type IPv4 if no eth_type is set.
+func (b *MatchBuilder) SetIPProto(ipProto uint8) *MatchBuilder {
+	if b.ipProto != nil && *b.ipProto != ipProto {
+		return b.fail("ip_proto %d conflicts with ip_proto %d", ipProto, *b.ipProto)
+	}
+	if !b.requireEthType(protocol.IPv4_MSG, protocol.IPv6_MSG) {
+		return b
+	}
+	b.ipProto = &ipProto
+	return b.setField(NewIpProtoField(ipProto))
+}
+
+// SetIPDSCP matches the DSCP of IPv4 or IPv6, and adds eth_type IPv4 if no eth_type is set.
+func (b *MatchBuilder) SetIPDSCP(dscp uint8) *MatchBuilder {
+	if !b.requireEthType(protocol.IPv4_MSG, protocol.IPv6_MSG) {
+		return b
+	}
+	return b.setField(NewIpDscpField(dscp))
+}
+
+// ipNetMask returns the mask of the IP network, or nil if the network is a single address.
+func ipNetMask(ipNet net.IPNet) *net.IP {
+	if ones, bits := ipNet.Mask.Size(); ipNet.Mask == nil || (ones == bits && bits != 0) {
+		return nil
+	}
+	mask := net.IP(ipNet.Mask)
+	return &mask
+}
+
+// SetIPv4Src matches the IPv4 source network, and adds eth_type IPv4.
+func (b *MatchBuilder) SetIPv4Src(ipNet net.IPNet) *MatchBuilder {
+	if !b.requireEthType(protocol.IPv4_MSG) {
+		return b
+	}
+	return b.setField(NewIpv4SrcField(ipNet.IP, ipNetMask(ipNet)))
+}
+
+// SetIPv4Dst matches the IPv4 destination network, and adds eth_type IPv4.
+func (b *MatchBuilder) SetIPv4Dst(ipNet net.IPNet) *MatchBuilder {
+	if !b.requireEthType(protocol.IPv4_MSG) {
+		return b
+	}
+	return b.setField(NewIpv4DstField(ipNet.IP, ipNetMask(ipNet)))
+}
+
+// SetIPv6Src matches the IPv6 source network, and adds eth_type IPv6.
+func (b *MatchBuilder) SetIPv6Src(ipNet net.IPNet) *MatchBuilder {
+	if !b.requireEthType(protocol.IPv6_MSG) {
+		return b
+	}
+	return b.setField(NewIpv6SrcField(ipNet.IP, ipNetMask(ipNet)))
+}
+
+// SetIPv6Dst matches the IPv6 destination network, and adds eth_type IPv6.
+func (b *MatchBuilder) SetIPv6Dst(ipNet net.IPNet) *MatchBuilder {
+	if !b.requireEthType(protocol.IPv6_MSG) {
+		return b
+	}
+	return b.setField(NewIpv6DstField(ipNet.IP, ipNetMask(ipNet)))
+}
+
+// setPort matches the L4 port with the mask, and adds ip_proto and its eth_type.
+func (b *MatchBuilder) setPort(ipProto uint8, field uint8, port, mask uint16) *MatchBuilder {
+	if !b.requireIPProto(ipProto) {
+		return b
+	}
+	f, err := NewPortMaskField(field, PortMask{Port: port, Mask: mask})
+	if err != nil {
+		if b.err == nil {
+			b.err = err
+		}
+		return b
+	}
+	return b.setField(f)
+}
+
+// SetTCPSrcPort matches the TCP source port with the mask, 0xffff matches the exact port.
+func (b *MatchBuilder) SetTCPSrcPort(port, mask uint16) *MatchBuilder {
+	return b.setPort(protocol.Type_TCP, OXM_FIELD_TCP_SRC, port, mask)
+}
+
+// SetTCPDstPort matches the TCP destination port with the mask, 0xffff matches the exact port.
+func (b *MatchBuilder) SetTCPDstPort(port, mask uint16) *MatchBuilder {
+	return b.setPort(protocol.Type_TCP, OXM_FIELD_TCP_DST, port, mask)
+}
+
+// SetUDPSrcPort matches the UDP source port with the mask, 0xffff matches the exact port.
+func (b *MatchBuilder) SetUDPSrcPort(port, mask uint16) *MatchBuilder {
+	return b.setPort(protocol.Type_UDP, OXM_FIELD_UDP_SRC, port, mask)
+}
+
+// SetUDPDstPort matches the UDP destination port with the mask, 0xffff matches the exact port.
+func (b *MatchBuilder) SetUDPDstPort(port, mask uint16) *MatchBuilder {
+	return b.setPort(protocol.Type_UDP, OXM_FIELD_UDP_DST, port, mask)
+}
+
+// SetSCTPSrcPort matches the SCTP source port with the mask, 0xffff matches the exact port.
+func (b *MatchBuilder) SetSCTPSrcPort(port, mask uint16) *MatchBuilder {
+	return b.setPort(protocol.Type_SCTP, OXM_FIELD_SCTP_SRC, port, mask)
+}
+
+// SetSCTPDstPort matches the SCTP destination port with the mask, 0xffff matches the exact port.
+func (b *MatchBuilder) SetSCTPDstPort(port, mask uint16) *MatchBuilder {
+	return b.setPort(protocol.Type_SCTP, OXM_FIELD_SCTP_DST, port, mask)
+}
+
+// SetTCPFlags matches the TCP flags, the mask is optional.
+func (b *MatchBuilder) SetTCPFlags(flags uint16, mask *uint16) *MatchBuilder {
+	if !b.requireIPProto(protocol.Type_TCP) {
+		return b
+	}
+	return b.setField(NewTcpFlagsField(flags, mask))
+}
+
+// SetARPOp matches the ARP opcode, and adds eth_type ARP.
+func (b *MatchBuilder) SetARPOp(op uint16) *MatchBuilder {
+	if !b.requireEthType(protocol.ARP_MSG) {
+		return b
+	}
+	return b.setField(NewArpOperField(op))
+}
+
+// SetTunnelID matches the tunnel ID, e.g., the VNI of VXLAN.
+func (b *MatchBuilder) SetTunnelID(tunnelID uint64) *MatchBuilder {
+	return b.setField(NewTunnelIdField(tunnelID))
+}
+
+// SetMetadata matches the metadata, the mask is optional.
+func (b *MatchBuilder) SetMetadata(metadata uint64, mask *uint64) *MatchBuilder {
+	return b.setField(NewMetadataField(metadata, mask))
+}
+
+// Build returns the Match, or the first error of the fields.
+func (b *MatchBuilder) Build() (*Match, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.match, nil
+}
@@ -0,0 +1,54 @@
+package openflow13
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/contiv/libOpenflow/protocol"
+)
+
+func TestMatchBuilder(t *testing.T) {
+	_, ipNet, _ := net.ParseCIDR("10.1.0.0/16")
+	match, err := NewMatchBuilder().SetInPort(3).SetIPv4Dst(*ipNet).SetTCPDstPort(80, 0xffff).SetTCPFlags(0x02, nil).
+		SetTunnelID(100).Build()
+	if err != nil {
+		t.Fatalf("Failed to build Match: %v", err)
+	}
+	expectedFields := []uint8{OXM_FIELD_IN_PORT, OXM_FIELD_ETH_TYPE, OXM_FIELD_IPV4_DST, OXM_FIELD_IP_PROTO,
+		OXM_FIELD_TCP_DST, OXM_FIELD_TCP_FLAGS, OXM_FIELD_TUNNEL_ID}
+	if len(match.Fields) != len(expectedFields) {
+		t.Fatalf("Unexpected match fields: %+v", match.Fields)
+	}
+	for i, field := range expectedFields {
+		if match.Fields[i].Field != field {
+			t.Errorf("Expect field %d at %d, actual: %d", field, i, match.Fields[i].Field)
+		}
+	}
+	if match.Fields[1].Value.(*EthTypeField).EthType != protocol.IPv4_MSG || match.Fields[3].Value.(*IpProtoField).protocol != protocol.Type_TCP {
+		t.Errorf("Unexpected prerequisites: %+v, %+v", match.Fields[1].Value, match.Fields[3].Value)
+	}
+	if !match.Fields[2].HasMask || match.Fields[4].HasMask {
+		t.Errorf("Unexpected masks of the address and the port")
+	}
+
+	_, ipv6Net, _ := net.ParseCIDR("fd00::1/128")
+	match, err = NewMatchBuilder().SetIPv6Src(*ipv6Net).SetUDPSrcPort(0x1000, 0xf000).Build()
+	if err != nil {
+		t.Fatalf("Failed to build Match: %v", err)
+	}
+	if match.Fields[0].Value.(*EthTypeField).EthType != protocol.IPv6_MSG || match.Fields[1].HasMask || !match.Fields[3].HasMask {
+		t.Errorf("Unexpected IPv6 match fields: %+v", match.Fields)
+	}
+
+	for name, b := range map[string]*MatchBuilder{
+		"tcp and udp":       NewMatchBuilder().SetTCPDstPort(80, 0xffff).SetUDPDstPort(53, 0xffff),
+		"ipv4 and ipv6":     NewMatchBuilder().SetIPv4Src(*ipNet).SetIPv6Dst(*ipv6Net),
+		"arp and ip_proto":  NewMatchBuilder().SetARPOp(1).SetIPProto(protocol.Type_UDP),
+		"eth_type after ip": NewMatchBuilder().SetIPv4Src(*ipNet).SetEthType(protocol.ARP_MSG),
+	} {
+		if _, err := b.Build(); !errors.Is(err, ErrMatchPrerequisite) {
+			t.Errorf("%s: expect ErrMatchPrerequisite, actual: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package openflow13
+
+import (
+	"sync/atomic"
+)
+
+// MatchSizeLimits are the limits of a single Match, beyond which the match is considered oversized, e.g., by a
+// bug in the flow generation which adds the same fields again and again. A zero limit is not checked.
+type MatchSizeLimits struct {
+	MaxFields int    // Maximum number of the OXM/NXM fields.
+	MaxBytes  uint16 // Maximum length of the match in bytes, including the ofp_match header and padding.
+}
+
+// OversizedMatchHandler is called with the match exceeding the MatchSizeLimits when the message carrying it is
+// marshaled. msgType is the type of the message, e.g., "FlowMod", to tell the call site. It is called in the
+// goroutine marshaling the message, so it must be fast and safe for concurrent use, e.g., log a warning.
+type OversizedMatchHandler func(msgType string, match *Match)
+
+type oversizedMatchHook struct {
+	limits  MatchSizeLimits
+	handler OversizedMatchHandler
+}
+
+var matchSizeHook atomic.Value
+
+// SetOversizedMatchHook sets the global handler of the oversized matches with the limits, a nil handler
+// disables the check. The matches are checked when FlowMod, FlowStatsRequest, AggregateStatsRequest and
+// FlowMonitorRequest are marshaled.
+func SetOversizedMatchHook(limits MatchSizeLimits, handler OversizedMatchHandler) {
+	matchSizeHook.Store(oversizedMatchHook{limits: limits, handler: handler})
+}
+
+// checkMatchSize passes the match to the OversizedMatchHandler if it exceeds the limits.
+func checkMatchSize(msgType string, m *Match) {
+	hook, ok := matchSizeHook.Load().(oversizedMatchHook)
+	if !ok || hook.handler == nil {
+		return
+	}
+	if (hook.limits.MaxFields > 0 && len(m.Fields) > hook.limits.MaxFields) ||
+		(hook.limits.MaxBytes > 0 && m.Len() > hook.limits.MaxBytes) {
+		hook.handler(msgType, m)
+	}
+}
@@ -0,0 +1,46 @@
+package openflow13
+
+import (
+	"testing"
+)
+
+func TestOversizedMatchHook(t *testing.T) {
+	var oversized []string
+	SetOversizedMatchHook(MatchSizeLimits{MaxFields: 2, MaxBytes: 32}, func(msgType string, match *Match) {
+		oversized = append(oversized, msgType)
+	})
+	defer SetOversizedMatchHook(MatchSizeLimits{}, nil)
+
+	flow := NewFlowMod()
+	flow.Match.AddField(*NewInPortField(1))
+	flow.Match.AddField(*NewEthTypeField(0x0800))
+	if _, err := flow.MarshalBinary(); err != nil {
+		t.Fatalf("Failed to marshal FlowMod: %v", err)
+	}
+	if len(oversized) != 0 {
+		t.Errorf("Expect no oversized match, actual: %v", oversized)
+	}
+
+	// Too many fields.
+	flow.Match.AddField(*NewIpProtoField(6))
+	flow.MarshalBinary()
+	// Too many bytes.
+	req := NewFlowStatsRequest()
+	mask := uint64(0xffff)
+	req.Match.AddField(*NewMetadataField(1, &mask))
+	req.Match.AddField(*NewTunnelIdField(1))
+	req.MarshalBinary()
+	// Within the limits.
+	aggReq := NewAggregateStatsRequest()
+	aggReq.Match.AddField(*NewInPortField(1))
+	aggReq.MarshalBinary()
+	if len(oversized) != 2 || oversized[0] != "FlowMod" || oversized[1] != "FlowStatsRequest" {
+		t.Errorf("Expect the oversized matches of FlowMod and FlowStatsRequest, actual: %v", oversized)
+	}
+
+	SetOversizedMatchHook(MatchSizeLimits{MaxFields: 1}, nil)
+	flow.MarshalBinary()
+	if len(oversized) != 2 {
+		t.Errorf("Expect no check with nil handler, actual: %v", oversized)
+	}
+}
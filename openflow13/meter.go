@@ -4,8 +4,8 @@ package openflow13
 
 import (
 	"encoding/binary"
-
-	log "github.com/sirupsen/logrus"
+	"errors"
+	"fmt"
 
 	"github.com/contiv/libOpenflow/common"
 	"github.com/contiv/libOpenflow/util"
@@ -25,12 +25,6 @@ const (
 	OFPMF13_BURST = 0b0100 /* Do burst size. */
 	OFPMF13_STATS = 0b1000 /* Collect statistics. */
 
-	/* Meter numbering. Flow meters can use any number up to OFPM_MAX. */
-	OFPM13_MAX        = 0xffff0000 /* Last usable meter. */
-	OFPM13_SLOWPATH   = 0xfffffffd /* Meter for slow datapath. */
-	OFPM13_CONTROLLER = 0xfffffffe /* Meter for controller connection. */
-	OFPM13_ALL        = 0xffffffff /* Represents all meters for stat requests commands. */
-
 	METER_BAND_HEADER_LEN = 12
 	METER_BAND_LEN        = 16
 )
@@ -232,18 +226,20 @@ func (m *MeterMod) MarshalBinary() (data []byte, err error) {
 		}
 		copy(data[n:], mbBytes)
 		n += METER_BAND_LEN
-		log.Debugf("Metermod band: %v", mbBytes)
 	}
 
-	log.Debugf("Metermod(%d): %v", len(data), data)
-
 	return
 }
 
 func (m *MeterMod) UnmarshalBinary(data []byte) error {
 	n := 0
-	m.Header.UnmarshalBinary(data[n:])
+	if err := m.Header.UnmarshalBinary(data[n:]); err != nil {
+		return err
+	}
 	n += int(m.Header.Len())
+	if len(data) < 16 || int(m.Header.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full MeterMod message")
+	}
 
 	m.Command = binary.BigEndian.Uint16(data[n:])
 	n += 2
@@ -253,27 +249,162 @@ func (m *MeterMod) UnmarshalBinary(data []byte) error {
 	n += 4
 
 	for n < int(m.Header.Length) {
-		mbh := new(MeterBandHeader)
-		mbh.UnmarshalBinary(data[n:])
-		n += int(mbh.Len())
-		switch mbh.Type {
-		case OFPMBT13_DROP:
-			mbDrop := new(MeterBandDrop)
-			mbDrop.MeterBandHeader = *mbh
-			m.MeterBands = append(m.MeterBands, mbDrop)
-		case OFPMBT13_DSCP_REMARK:
-			mbDscp := new(MeterBandDSCP)
-			mbDscp.MeterBandHeader = *mbh
-			mbDscp.PrecLevel = data[n]
-			m.MeterBands = append(m.MeterBands, mbDscp)
-		case OFPMBT13_EXPERIMENTER:
-			mbExp := new(MeterBandExperimenter)
-			mbExp.MeterBandHeader = *mbh
-			mbExp.Experimenter = binary.BigEndian.Uint32(data[n:])
-			m.MeterBands = append(m.MeterBands, mbExp)
+		if err := checkBounds(data[:m.Header.Length], n, METER_BAND_LEN, "MeterMod", "meter band"); err != nil {
+			return err
+		}
+		mb, length := decodeMeterBand(data[n:])
+		if mb != nil {
+			m.MeterBands = append(m.MeterBands, mb)
+		}
+		var err error
+		if n, err = safeAdvance(n, length, int(m.Header.Length), "meter band"); err != nil {
+			return err
 		}
-		n += 4
 	}
 
 	return nil
 }
+
+// decodeMeterBand decodes the meter band at the beginning of data, which must have at least METER_BAND_LEN
+// bytes. It returns the band, or nil if the band type is unknown, and the length of the band in its header.
+func decodeMeterBand(data []byte) (util.Message, uint16) {
+	mbh := new(MeterBandHeader)
+	mbh.UnmarshalBinary(data)
+	n := int(mbh.Len())
+	switch mbh.Type {
+	case OFPMBT13_DROP:
+		mbDrop := new(MeterBandDrop)
+		mbDrop.MeterBandHeader = *mbh
+		return mbDrop, mbh.Length
+	case OFPMBT13_DSCP_REMARK:
+		mbDscp := new(MeterBandDSCP)
+		mbDscp.MeterBandHeader = *mbh
+		mbDscp.PrecLevel = data[n]
+		return mbDscp, mbh.Length
+	case OFPMBT13_EXPERIMENTER:
+		mbExp := new(MeterBandExperimenter)
+		mbExp.MeterBandHeader = *mbh
+		mbExp.Experimenter = binary.BigEndian.Uint32(data[n:])
+		return mbExp, mbh.Length
+	}
+	return nil, mbh.Length
+}
+
+// ofp_meter_mod_failed_code 1.3
+const (
+	OFPMMFC_UNKNOWN        = 0  /* Unspecified error. */
+	OFPMMFC_METER_EXISTS   = 1  /* Meter ADD attempted to replace an existing Meter. */
+	OFPMMFC_INVALID_METER  = 2  /* Meter specified is invalid, or invalid meter in meter action. */
+	OFPMMFC_UNKNOWN_METER  = 3  /* Meter MODIFY of a non-existent Meter, or bad meter in meter action. */
+	OFPMMFC_BAD_COMMAND    = 4  /* Unsupported or unknown command. */
+	OFPMMFC_BAD_FLAGS      = 5  /* Flag configuration unsupported. */
+	OFPMMFC_BAD_RATE       = 6  /* Rate unsupported. */
+	OFPMMFC_BAD_BURST      = 7  /* Burst size unsupported. */
+	OFPMMFC_BAD_BAND       = 8  /* Band unsupported. */
+	OFPMMFC_BAD_BAND_VALUE = 9  /* Band value unsupported. */
+	OFPMMFC_OUT_OF_METERS  = 10 /* No more meters available. */
+	OFPMMFC_OUT_OF_BANDS   = 11 /* The maximum number of properties for a meter has been exceeded. */
+)
+
+// MeterModError is returned by MeterMod.Validate for the MeterMod which the switch would reject, Code is the
+// OFPMMFC_* code of the error which the switch would reply with, e.g., to check it with errors.As.
+type MeterModError struct {
+	Code   uint16
+	Reason string
+}
+
+func (e *MeterModError) Error() string {
+	return fmt.Sprintf("invalid MeterMod, code %d: %s", e.Code, e.Reason)
+}
+
+func newMeterModError(code uint16, format string, args ...interface{}) *MeterModError {
+	return &MeterModError{Code: code, Reason: fmt.Sprintf(format, args...)}
+}
+
+// NewMeterModAdd returns the OFPMC_ADD MeterMod of the meter ID and the flags, the bands are added with
+// AddDropBand and AddDSCPBand, e.g., NewMeterModAdd(1, OFPMF13_KBPS|OFPMF13_BURST).AddDropBand(10000, 1000).
+func NewMeterModAdd(meterID uint32, flags uint16) *MeterMod {
+	m := NewMeterMod()
+	m.Command = OFPMC_ADD
+	m.MeterId = meterID
+	m.Flags = flags
+	return m
+}
+
+// NewMeterModModify returns the OFPMC_MODIFY MeterMod which replaces the flags and the bands of the meter.
+func NewMeterModModify(meterID uint32, flags uint16) *MeterMod {
+	m := NewMeterModAdd(meterID, flags)
+	m.Command = OFPMC_MODIFY
+	return m
+}
+
+// NewMeterModDelete returns the OFPMC_DELETE MeterMod of the meter ID, or of all the meters with OFPM13_ALL.
+func NewMeterModDelete(meterID uint32) *MeterMod {
+	m := NewMeterModAdd(meterID, 0)
+	m.Command = OFPMC_DELETE
+	return m
+}
+
+// AddDropBand adds the band which drops the packets beyond the rate, in kb/s or packet/s as the meter flags.
+func (m *MeterMod) AddDropBand(rate uint32, burstSize uint32) *MeterMod {
+	band := &MeterBandDrop{MeterBandHeader: *NewMeterBandHeader()}
+	band.Type = OFPMBT13_DROP
+	band.Rate = rate
+	band.BurstSize = burstSize
+	m.AddMeterBand(band)
+	return m
+}
+
+// AddDSCPBand adds the band which increases the drop precedence of the DSCP of the packets beyond the rate by
+// precLevel.
+func (m *MeterMod) AddDSCPBand(rate uint32, burstSize uint32, precLevel uint8) *MeterMod {
+	band := &MeterBandDSCP{MeterBandHeader: *NewMeterBandHeader(), PrecLevel: precLevel}
+	band.Type = OFPMBT13_DSCP_REMARK
+	band.Rate = rate
+	band.BurstSize = burstSize
+	m.AddMeterBand(band)
+	return m
+}
+
+// Validate checks the MeterMod before sending it to the switch, the returned error is a *MeterModError with
+// the OFPMMFC_* code. The meter ID must be a usable or virtual meter, the flags must not have both OFPMF13_KBPS
+// and OFPMF13_PKTPS, and the bands must have non-zero rates. The bands are not checked for OFPMC_DELETE.
+func (m *MeterMod) Validate() error {
+	switch m.Command {
+	case OFPMC_ADD, OFPMC_MODIFY:
+	case OFPMC_DELETE:
+		if m.MeterId == 0 || (m.MeterId > OFPM13_MAX && m.MeterId != OFPM13_SLOWPATH && m.MeterId != OFPM13_CONTROLLER &&
+			m.MeterId != OFPM13_ALL) {
+			return newMeterModError(OFPMMFC_INVALID_METER, "meter ID 0x%x", m.MeterId)
+		}
+		return nil
+	default:
+		return newMeterModError(OFPMMFC_BAD_COMMAND, "command %d", m.Command)
+	}
+	if m.MeterId == 0 || (m.MeterId > OFPM13_MAX && m.MeterId != OFPM13_SLOWPATH && m.MeterId != OFPM13_CONTROLLER) {
+		return newMeterModError(OFPMMFC_INVALID_METER, "meter ID 0x%x", m.MeterId)
+	}
+	if m.Flags&^(OFPMF13_KBPS|OFPMF13_PKTPS|OFPMF13_BURST|OFPMF13_STATS) != 0 {
+		return newMeterModError(OFPMMFC_BAD_FLAGS, "unknown flags 0x%x", m.Flags)
+	}
+	if m.Flags&OFPMF13_KBPS != 0 && m.Flags&OFPMF13_PKTPS != 0 {
+		return newMeterModError(OFPMMFC_BAD_FLAGS, "both OFPMF13_KBPS and OFPMF13_PKTPS are set")
+	}
+	for i, mb := range m.MeterBands {
+		var header *MeterBandHeader
+		switch band := mb.(type) {
+		case *MeterBandDrop:
+			header = &band.MeterBandHeader
+		case *MeterBandDSCP:
+			header = &band.MeterBandHeader
+		case *MeterBandExperimenter:
+			header = &band.MeterBandHeader
+		default:
+			return newMeterModError(OFPMMFC_BAD_BAND, "band %d of type %T", i, mb)
+		}
+		if header.Rate == 0 {
+			return newMeterModError(OFPMMFC_BAD_RATE, "band %d has zero rate", i)
+		}
+	}
+	return nil
+}
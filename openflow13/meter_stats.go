@@ -0,0 +1,351 @@
+package openflow13
+
+// This file has the meter multipart messages, and the text format of the meters same as ovs-ofctl.
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+// MeterMultipartRequest is the ofp_meter_multipart_request 1.3, the body of the meter stats and the meter config
+// requests.
+type MeterMultipartRequest struct {
+	MeterId uint32 /* Meter instance, or OFPM13_ALL. */
+	pad     [4]uint8
+}
+
+func NewMeterMultipartRequest(meterID uint32) *MeterMultipartRequest {
+	return &MeterMultipartRequest{MeterId: meterID}
+}
+
+func (r *MeterMultipartRequest) Len() (n uint16) {
+	return 8
+}
+
+func (r *MeterMultipartRequest) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, r.Len())
+	binary.BigEndian.PutUint32(data, r.MeterId)
+	return
+}
+
+func (r *MeterMultipartRequest) UnmarshalBinary(data []byte) error {
+	if len(data) < int(r.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full MeterMultipartRequest message")
+	}
+	r.MeterId = binary.BigEndian.Uint32(data)
+	return nil
+}
+
+// MeterBandStats is the ofp_meter_band_stats 1.3.
+type MeterBandStats struct {
+	PacketBandCount uint64 /* Number of packets in band. */
+	ByteBandCount   uint64 /* Number of bytes in band. */
+}
+
+func (s *MeterBandStats) Len() (n uint16) {
+	return 16
+}
+
+func (s *MeterBandStats) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, s.Len())
+	binary.BigEndian.PutUint64(data, s.PacketBandCount)
+	binary.BigEndian.PutUint64(data[8:], s.ByteBandCount)
+	return
+}
+
+func (s *MeterBandStats) UnmarshalBinary(data []byte) error {
+	if len(data) < int(s.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full MeterBandStats message")
+	}
+	s.PacketBandCount = binary.BigEndian.Uint64(data)
+	s.ByteBandCount = binary.BigEndian.Uint64(data[8:])
+	return nil
+}
+
+// MeterStats is the ofp_meter_stats 1.3, the body of the OFPMP_METER reply.
+type MeterStats struct {
+	MeterId       uint32 /* Meter instance. */
+	Length        uint16 /* Length in bytes of this stats. */
+	pad           [6]uint8
+	FlowCount     uint32 /* Number of flows bound to meter. */
+	PacketInCount uint64 /* Number of packets in input. */
+	ByteInCount   uint64 /* Number of bytes in input. */
+	DurationSec   uint32 /* Time meter has been alive in seconds. */
+	DurationNSec  uint32 /* Time meter has been alive in nanoseconds beyond duration_sec. */
+	BandStats     []MeterBandStats
+}
+
+func (s *MeterStats) Len() (n uint16) {
+	n = 40
+	for i := range s.BandStats {
+		n += s.BandStats[i].Len()
+	}
+	return
+}
+
+func (s *MeterStats) MarshalBinary() (data []byte, err error) {
+	s.Length = s.Len()
+	data = make([]byte, 40)
+	n := 0
+	binary.BigEndian.PutUint32(data[n:], s.MeterId)
+	n += 4
+	binary.BigEndian.PutUint16(data[n:], s.Length)
+	n += 2
+	n += 6 // for pad
+	binary.BigEndian.PutUint32(data[n:], s.FlowCount)
+	n += 4
+	binary.BigEndian.PutUint64(data[n:], s.PacketInCount)
+	n += 8
+	binary.BigEndian.PutUint64(data[n:], s.ByteInCount)
+	n += 8
+	binary.BigEndian.PutUint32(data[n:], s.DurationSec)
+	n += 4
+	binary.BigEndian.PutUint32(data[n:], s.DurationNSec)
+	n += 4
+	for i := range s.BandStats {
+		b, err := s.BandStats[i].MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return
+}
+
+func (s *MeterStats) UnmarshalBinary(data []byte) error {
+	if len(data) < 40 {
+		return errors.New("the []byte is too short to unmarshal a full MeterStats message")
+	}
+	n := 0
+	s.MeterId = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	s.Length = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	if s.Length < 40 || int(s.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full MeterStats message")
+	}
+	n += 6 // for pad
+	s.FlowCount = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	s.PacketInCount = binary.BigEndian.Uint64(data[n:])
+	n += 8
+	s.ByteInCount = binary.BigEndian.Uint64(data[n:])
+	n += 8
+	s.DurationSec = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	s.DurationNSec = binary.BigEndian.Uint32(data[n:])
+	n += 4
+
+	s.BandStats = nil
+	for n < int(s.Length) {
+		var bs MeterBandStats
+		if err := bs.UnmarshalBinary(data[n:s.Length]); err != nil {
+			return err
+		}
+		s.BandStats = append(s.BandStats, bs)
+		n += int(bs.Len())
+	}
+	return nil
+}
+
+// MeterDesc is the ofp_meter_config 1.3, which is renamed to ofp_meter_desc in 1.5, the body of the
+// OFPMP_METER_CONFIG reply.
+type MeterDesc struct {
+	Length     uint16         /* Length of this entry. */
+	Flags      uint16         /* Set of OFPMF_*. */
+	MeterId    uint32         /* Meter instance. */
+	MeterBands []util.Message /* List of MeterBand*. */
+}
+
+func NewMeterDesc() *MeterDesc {
+	d := new(MeterDesc)
+	d.MeterBands = make([]util.Message, 0)
+	return d
+}
+
+func (d *MeterDesc) AddMeterBand(mb util.Message) {
+	d.MeterBands = append(d.MeterBands, mb)
+}
+
+func (d *MeterDesc) Len() (n uint16) {
+	n = 8
+	for _, b := range d.MeterBands {
+		n += b.Len()
+	}
+	return
+}
+
+func (d *MeterDesc) MarshalBinary() (data []byte, err error) {
+	d.Length = d.Len()
+	data = make([]byte, 8)
+	n := 0
+	binary.BigEndian.PutUint16(data[n:], d.Length)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], d.Flags)
+	n += 2
+	binary.BigEndian.PutUint32(data[n:], d.MeterId)
+	n += 4
+	for _, mb := range d.MeterBands {
+		b, err := mb.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return
+}
+
+func (d *MeterDesc) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("the []byte is too short to unmarshal a full MeterDesc message")
+	}
+	n := 0
+	d.Length = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	if d.Length < 8 || int(d.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full MeterDesc message")
+	}
+	d.Flags = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	d.MeterId = binary.BigEndian.Uint32(data[n:])
+	n += 4
+
+	d.MeterBands = make([]util.Message, 0)
+	for n < int(d.Length) {
+		if err := checkBounds(data[:d.Length], n, METER_BAND_LEN, "MeterDesc", "meter band"); err != nil {
+			return err
+		}
+		mb, length := decodeMeterBand(data[n:])
+		if mb != nil {
+			d.MeterBands = append(d.MeterBands, mb)
+		}
+		var err error
+		if n, err = safeAdvance(n, length, int(d.Length), "meter band"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatMeterID formats the meter ID same as ofputil_format_meter_id of OVS.
+func formatMeterID(b *strings.Builder, meterID uint32, separator byte) {
+	b.WriteString("meter")
+	b.WriteByte(separator)
+	if meterID <= OFPM13_MAX {
+		fmt.Fprintf(b, "%d", meterID)
+		return
+	}
+	switch meterID {
+	case OFPM13_SLOWPATH:
+		b.WriteString("slowpath")
+	case OFPM13_CONTROLLER:
+		b.WriteString("controller")
+	case OFPM13_ALL:
+		b.WriteString("all")
+	default:
+		b.WriteString("unknown")
+	}
+}
+
+// FormatMeterDesc returns the text of the meter config same as a meter in `ovs-ofctl dump-meters`, e.g.,
+//
+//	meter=1 kbps burst stats bands=
+//	type=drop rate=1000 burst_size=100
+func FormatMeterDesc(d *MeterDesc) string {
+	b := new(strings.Builder)
+	formatMeterID(b, d.MeterId, '=')
+	b.WriteByte(' ')
+	flags := d.Flags
+	for _, f := range []struct {
+		flag uint16
+		name string
+	}{{OFPMF13_KBPS, "kbps"}, {OFPMF13_PKTPS, "pktps"}, {OFPMF13_BURST, "burst"}, {OFPMF13_STATS, "stats"}} {
+		if flags&f.flag != 0 {
+			b.WriteString(f.name)
+			b.WriteByte(' ')
+			flags &^= f.flag
+		}
+	}
+	if flags != 0 {
+		fmt.Fprintf(b, "flags:0x%x ", flags)
+	}
+	b.WriteString("bands=")
+	for _, mb := range d.MeterBands {
+		var hdr *MeterBandHeader
+		var precLevel uint8
+		switch band := mb.(type) {
+		case *MeterBandDrop:
+			hdr = &band.MeterBandHeader
+		case *MeterBandDSCP:
+			hdr = &band.MeterBandHeader
+			precLevel = band.PrecLevel
+		case *MeterBandExperimenter:
+			hdr = &band.MeterBandHeader
+		default:
+			continue
+		}
+		b.WriteString("\ntype=")
+		switch hdr.Type {
+		case OFPMBT13_DROP:
+			b.WriteString("drop")
+		case OFPMBT13_DSCP_REMARK:
+			b.WriteString("dscp_remark")
+		default:
+			fmt.Fprintf(b, "%d", hdr.Type)
+		}
+		fmt.Fprintf(b, " rate=%d", hdr.Rate)
+		if d.Flags&OFPMF13_BURST != 0 {
+			fmt.Fprintf(b, " burst_size=%d", hdr.BurstSize)
+		}
+		if hdr.Type == OFPMBT13_DSCP_REMARK {
+			fmt.Fprintf(b, " prec_level=%d", precLevel)
+		}
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+// FormatMeterStats returns the text of the meter stats same as a meter in `ovs-ofctl meter-stats`, e.g.,
+//
+//	meter:1 flow_count:1 packet_in_count:10 byte_in_count:600 duration:3.5s bands:
+//	0: packet_count:2 byte_count:120
+func FormatMeterStats(s *MeterStats) string {
+	b := new(strings.Builder)
+	formatMeterID(b, s.MeterId, ':')
+	fmt.Fprintf(b, " flow_count:%d packet_in_count:%d byte_in_count:%d duration:%s bands:\n",
+		s.FlowCount, s.PacketInCount, s.ByteInCount, formatDuration(s.DurationSec, s.DurationNSec))
+	for i, bs := range s.BandStats {
+		fmt.Fprintf(b, "%d: packet_count:%d byte_count:%d\n", i, bs.PacketBandCount, bs.ByteBandCount)
+	}
+	return b.String()
+}
+
+// DumpMeters returns the text of the meters same as the body of `ovs-ofctl dump-meters`.
+func DumpMeters(descs []*MeterDesc) string {
+	b := new(strings.Builder)
+	for _, d := range descs {
+		b.WriteString(FormatMeterDesc(d))
+	}
+	return b.String()
+}
+
+// DumpMeterStats returns the text of the meter stats same as the body of `ovs-ofctl meter-stats`.
+func DumpMeterStats(stats []*MeterStats) string {
+	b := new(strings.Builder)
+	for _, s := range stats {
+		b.WriteString(FormatMeterStats(s))
+	}
+	return b.String()
+}
+
+// formatDuration formats the duration same as ofp_print_duration of OVS, e.g., 3.5s.
+func formatDuration(sec, nsec uint32) string {
+	if nsec == 0 {
+		return fmt.Sprintf("%ds", sec)
+	}
+	return fmt.Sprintf("%d.%s", sec, strings.TrimRight(fmt.Sprintf("%09d", nsec), "0")) + "s"
+}
@@ -0,0 +1,63 @@
+package openflow13
+
+import (
+	"testing"
+)
+
+func TestMeterDescFormat(t *testing.T) {
+	desc := NewMeterDesc()
+	desc.MeterId = 1
+	desc.Flags = OFPMF13_KBPS | OFPMF13_BURST | OFPMF13_STATS
+	drop := &MeterBandDrop{MeterBandHeader: *NewMeterBandHeader()}
+	drop.Type = OFPMBT13_DROP
+	drop.Rate = 1000
+	drop.BurstSize = 100
+	desc.AddMeterBand(drop)
+	dscp := &MeterBandDSCP{MeterBandHeader: *NewMeterBandHeader(), PrecLevel: 1}
+	dscp.Type = OFPMBT13_DSCP_REMARK
+	dscp.Rate = 2000
+	desc.AddMeterBand(dscp)
+
+	data, err := desc.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal MeterDesc: %v", err)
+	}
+	desc2 := new(MeterDesc)
+	if err = desc2.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal MeterDesc: %v", err)
+	}
+	expected := "meter=1 kbps burst stats bands=\ntype=drop rate=1000 burst_size=100\ntype=dscp_remark rate=2000 burst_size=0 prec_level=1\n"
+	if text := FormatMeterDesc(desc2); text != expected {
+		t.Errorf("Unexpected meter text %q, expect %q", text, expected)
+	}
+	desc2.MeterId = OFPM13_CONTROLLER
+	desc2.Flags = OFPMF13_PKTPS
+	desc2.MeterBands = desc2.MeterBands[:1]
+	if text := DumpMeters([]*MeterDesc{desc2}); text != "meter=controller pktps bands=\ntype=drop rate=1000\n" {
+		t.Errorf("Unexpected controller meter text %q", text)
+	}
+}
+
+func TestMeterStatsFormat(t *testing.T) {
+	stats := &MeterStats{
+		MeterId:       1,
+		FlowCount:     2,
+		PacketInCount: 10,
+		ByteInCount:   600,
+		DurationSec:   3,
+		DurationNSec:  500000000,
+		BandStats:     []MeterBandStats{{PacketBandCount: 2, ByteBandCount: 120}},
+	}
+	data, err := stats.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal MeterStats: %v", err)
+	}
+	stats2 := new(MeterStats)
+	if err = stats2.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal MeterStats: %v", err)
+	}
+	expected := "meter:1 flow_count:2 packet_in_count:10 byte_in_count:600 duration:3.5s bands:\n0: packet_count:2 byte_count:120\n"
+	if text := DumpMeterStats([]*MeterStats{stats2}); text != expected {
+		t.Errorf("Unexpected meter stats text %q, expect %q", text, expected)
+	}
+}
@@ -0,0 +1,50 @@
+package openflow13
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMeterModBuilder(t *testing.T) {
+	meterMod := NewMeterModAdd(1, OFPMF13_KBPS|OFPMF13_BURST|OFPMF13_STATS).AddDropBand(10000, 1000).AddDSCPBand(5000, 500, 1)
+	if err := meterMod.Validate(); err != nil {
+		t.Fatalf("Unexpected invalid MeterMod: %v", err)
+	}
+	data, err := meterMod.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal MeterMod: %v", err)
+	}
+	decoded := new(MeterMod)
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal MeterMod: %v", err)
+	}
+	if decoded.Command != OFPMC_ADD || decoded.MeterId != 1 || len(decoded.MeterBands) != 2 {
+		t.Fatalf("Unexpected MeterMod: %+v", decoded)
+	}
+	if drop, ok := decoded.MeterBands[0].(*MeterBandDrop); !ok || drop.Type != OFPMBT13_DROP || drop.Rate != 10000 || drop.BurstSize != 1000 {
+		t.Errorf("Unexpected drop band: %+v", decoded.MeterBands[0])
+	}
+	if dscp, ok := decoded.MeterBands[1].(*MeterBandDSCP); !ok || dscp.Type != OFPMBT13_DSCP_REMARK || dscp.Rate != 5000 || dscp.PrecLevel != 1 {
+		t.Errorf("Unexpected DSCP band: %+v", decoded.MeterBands[1])
+	}
+
+	for name, tc := range map[string]struct {
+		meterMod *MeterMod
+		code     uint16
+	}{
+		"both kbps and pktps": {NewMeterModAdd(1, OFPMF13_KBPS|OFPMF13_PKTPS).AddDropBand(100, 0), OFPMMFC_BAD_FLAGS},
+		"unknown flags":       {NewMeterModAdd(1, 0x10).AddDropBand(100, 0), OFPMMFC_BAD_FLAGS},
+		"zero rate":           {NewMeterModModify(1, OFPMF13_PKTPS).AddDropBand(100, 0).AddDSCPBand(0, 0, 1), OFPMMFC_BAD_RATE},
+		"zero meter ID":       {NewMeterModAdd(0, OFPMF13_KBPS).AddDropBand(100, 0), OFPMMFC_INVALID_METER},
+		"add all meters":      {NewMeterModAdd(OFPM13_ALL, OFPMF13_KBPS).AddDropBand(100, 0), OFPMMFC_INVALID_METER},
+		"unknown command":     {&MeterMod{Command: 3, MeterId: 1}, OFPMMFC_BAD_COMMAND},
+	} {
+		var meterModErr *MeterModError
+		if err := tc.meterMod.Validate(); !errors.As(err, &meterModErr) || meterModErr.Code != tc.code {
+			t.Errorf("%s: expect error code %d, actual: %v", name, tc.code, err)
+		}
+	}
+	if err := NewMeterModDelete(OFPM13_ALL).Validate(); err != nil {
+		t.Errorf("Unexpected invalid delete of all meters: %v", err)
+	}
+}
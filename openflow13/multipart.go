@@ -2,6 +2,8 @@ package openflow13
 
 import (
 	"encoding/binary"
+	"errors"
+	"fmt"
 
 	log "github.com/sirupsen/logrus"
 
@@ -14,7 +16,7 @@ type MultipartRequest struct {
 	common.Header
 	Type  uint16
 	Flags uint16
-	pad   []byte // 4 bytes
+	pad   [4]byte // 4 bytes
 	Body  util.Message
 }
 
@@ -38,12 +40,13 @@ func (s *MultipartRequest) MarshalBinary() (data []byte, err error) {
 	b, err = s.Body.MarshalBinary()
 	data = append(data, b...)
 
-	log.Debugf("Sending MultipartRequest (%d): %v", len(data), data)
-
 	return
 }
 
 func (s *MultipartRequest) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("the []byte is too short to unmarshal a full MultipartRequest message")
+	}
 	err := s.Header.UnmarshalBinary(data)
 	n := s.Header.Len()
 
@@ -53,28 +56,31 @@ func (s *MultipartRequest) UnmarshalBinary(data []byte) error {
 	n += 2
 	n += 4 // for padding
 
-	var req util.Message
-	switch s.Type {
-	case MultipartType_Aggregate:
-		req = s.Body.(*AggregateStatsRequest)
+	if req := newMultipartRequestBody(s.Type, data[n:]); req != nil {
 		err = req.UnmarshalBinary(data[n:])
-	case MultipartType_Desc:
-		break
+		s.Body = req
+	}
+	return err
+}
+
+// newMultipartRequestBody returns an empty body of the multipart type to decode data, it is nil if the type has
+// no request body, e.g., OFPMP_DESC, or it is not supported.
+func newMultipartRequestBody(mpType uint16, data []byte) util.Message {
+	switch mpType {
+	case MultipartType_Aggregate:
+		return new(AggregateStatsRequest)
 	case MultipartType_Flow:
-		req = s.Body.(*FlowStatsRequest)
-		err = req.UnmarshalBinary(data[n:])
+		return new(FlowStatsRequest)
 	case MultipartType_Port:
-		req = s.Body.(*PortStatsRequest)
-		err = req.UnmarshalBinary(data[n:])
-	case MultipartType_Table:
-		break
+		return new(PortStatsRequest)
 	case MultipartType_Queue:
-		req = s.Body.(*QueueStatsRequest)
-		err = req.UnmarshalBinary(data[n:])
+		return new(QueueStatsRequest)
+	case MultipartType_FlowMonitor:
+		return new(FlowMonitorRequest)
 	case MultipartType_Experimenter:
-		break
+		return newExperimenterMultipartBody(data, false)
 	}
-	return err
+	return nil
 }
 
 // ofp_multipart_reply 1.3
@@ -82,7 +88,7 @@ type MultipartReply struct {
 	common.Header
 	Type  uint16
 	Flags uint16
-	pad   []byte // 4 bytes
+	pad   [4]byte // 4 bytes
 	Body  []util.Message
 }
 
@@ -117,8 +123,24 @@ func (s *MultipartReply) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *MultipartReply) UnmarshalBinary(data []byte) error {
+	return s.UnmarshalBinaryWithOptions(data, DecodeOptions{})
+}
+
+// UnmarshalBinaryWithOptions decodes the MultipartReply with the options. If opts.Borrow is set, the FlowStats
+// bodies are decoded with the options, and the Data of ExperimenterStatsBody and the raw bodies of the unsupported
+// types reference data.
+func (s *MultipartReply) UnmarshalBinaryWithOptions(data []byte, opts DecodeOptions) error {
+	if err := checkBounds(data, 0, 16, "MultipartReply", "type"); err != nil {
+		return err
+	}
 	err := s.Header.UnmarshalBinary(data)
 	n := s.Header.Len()
+	if s.Header.Length < 16 {
+		return &DecodeError{Message: "MultipartReply", Offset: 2, Field: "length", Err: fmt.Errorf("invalid length %d", s.Header.Length)}
+	}
+	if err := checkBounds(data, 0, int(s.Header.Length), "MultipartReply", "length"); err != nil {
+		return err
+	}
 
 	s.Type = binary.BigEndian.Uint16(data[n:])
 	n += 2
@@ -127,30 +149,30 @@ func (s *MultipartReply) UnmarshalBinary(data []byte) error {
 	n += 4 // for padding
 	var req []util.Message
 	for n < s.Header.Length {
-		var repl util.Message
-		switch s.Type {
-		case MultipartType_Aggregate:
-			repl = new(AggregateStats)
-		case MultipartType_Desc:
-			repl = new(DescStats)
-		case MultipartType_Flow:
-			repl = new(FlowStats)
-		case MultipartType_Port:
-			repl = new(PortStats)
-		case MultipartType_Table:
-			repl = new(TableStats)
-		case MultipartType_Queue:
-			repl = new(QueueStats)
-		// FIXME: Support all types
-		case MultipartType_Experimenter:
-			break
+		repl := newMultipartReplyBody(s.Header.Version, s.Type, data[n:s.Header.Length])
+		switch r := repl.(type) {
+		case *FlowStats:
+			err = r.UnmarshalBinaryWithOptions(data[n:s.Header.Length], opts)
+		case *ExperimenterStatsBody:
+			err = r.unmarshalBinary(data[n:s.Header.Length], opts)
+		case *util.Buffer:
+			if opts.Borrow {
+				repl = util.NewBuffer(data[n:s.Header.Length:s.Header.Length])
+			} else {
+				err = r.UnmarshalBinary(data[n:s.Header.Length])
+			}
+		default:
+			err = repl.UnmarshalBinary(data[n:s.Header.Length])
 		}
-
-		err = repl.UnmarshalBinary(data[n:])
 		if err != nil {
 			log.Printf("Error parsing stats reply")
+			return decodeErrorAt(err, "MultipartReply", int(n), "body")
+		}
+		next, err := safeAdvance(int(n), repl.Len(), int(s.Header.Length), "multipart reply body")
+		if err != nil {
+			return err
 		}
-		n += repl.Len()
+		n = uint16(next)
 		req = append(req, repl)
 
 	}
@@ -160,6 +182,48 @@ func (s *MultipartReply) UnmarshalBinary(data []byte) error {
 	return err
 }
 
+// newMultipartReplyBody returns an empty body of the multipart type to decode data, the body of the unsupported
+// types is kept as raw bytes. The version is the version in the header of the reply, as some bodies have a
+// different layout since OpenFlow 1.5.
+func newMultipartReplyBody(version uint8, mpType uint16, data []byte) util.Message {
+	if version >= OFP15_VERSION {
+		switch mpType {
+		case MultipartType_FlowDesc:
+			return new(FlowDesc)
+		case MultipartType_FlowStats:
+			return new(FlowStats15)
+		case MultipartType_GroupDesc:
+			return new(GroupDesc15)
+		}
+	}
+	switch mpType {
+	case MultipartType_Aggregate:
+		return new(AggregateStats)
+	case MultipartType_Desc:
+		return new(DescStats)
+	case MultipartType_Flow:
+		return new(FlowStats)
+	case MultipartType_Port:
+		return new(PortStats)
+	case MultipartType_Table:
+		return new(TableStats)
+	case MultipartType_Queue:
+		return new(QueueStats)
+	case MultipartType_PortDesc:
+		return NewPhyPort()
+	case MultipartType_Meter:
+		return new(MeterStats)
+	case MultipartType_MeterConfig:
+		return NewMeterDesc()
+	case MultipartType_FlowMonitor:
+		return newFlowUpdate(data)
+	case MultipartType_Experimenter:
+		return newExperimenterMultipartBody(data, true)
+	}
+	// FIXME: Support all types
+	return new(util.Buffer)
+}
+
 // ofp_multipart_request_flags & ofp_multipart_reply_flags 1.3
 const (
 	OFPMPF_REQ_MORE   = 1 << 0 /* More requests to follow. */
@@ -288,6 +352,9 @@ func (s *DescStats) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *DescStats) UnmarshalBinary(data []byte) error {
+	if err := checkBounds(data, 0, int(s.Len()), "DescStats", "dp_desc"); err != nil {
+		return err
+	}
 	n := 0
 	copy(s.MfrDesc, data[n:])
 	n += len(s.MfrDesc)
@@ -307,19 +374,13 @@ const (
 	SERIAL_NUM_LEN = 32
 )
 
-const (
-	OFPTT_MAX = 0xfe
-	/* Fake tables. */
-	OFPTT_ALL = 0xff /* Wildcard table used for table config, flow stats and flow deletes. */
-)
-
 // ofp_flow_stats_request 1.3
 type FlowStatsRequest struct {
 	TableId    uint8
-	pad        []byte // 3 bytes
+	pad        [3]byte // 3 bytes
 	OutPort    uint32
 	OutGroup   uint32
-	pad2       []byte // 4 bytes
+	pad2       [4]byte // 4 bytes
 	Cookie     uint64
 	CookieMask uint64
 	Match      Match
@@ -329,8 +390,6 @@ func NewFlowStatsRequest() *FlowStatsRequest {
 	s := new(FlowStatsRequest)
 	s.OutPort = P_ANY
 	s.OutGroup = OFPG_ANY
-	s.pad = make([]byte, 3)
-	s.pad2 = make([]byte, 4)
 	s.Match = *NewMatch()
 	return s
 }
@@ -340,17 +399,18 @@ func (s *FlowStatsRequest) Len() (n uint16) {
 }
 
 func (s *FlowStatsRequest) MarshalBinary() (data []byte, err error) {
+	checkMatchSize("FlowStatsRequest", &s.Match)
 	data = make([]byte, 32)
 	n := 0
 	data[n] = s.TableId
 	n += 1
-	copy(data[n:], s.pad)
+	copy(data[n:], s.pad[:])
 	n += 3
 	binary.BigEndian.PutUint32(data[n:], s.OutPort)
 	n += 4
 	binary.BigEndian.PutUint32(data[n:], s.OutGroup)
 	n += 4
-	copy(data[n:], s.pad2)
+	copy(data[n:], s.pad2[:])
 	n += 4
 	binary.BigEndian.PutUint64(data[n:], s.Cookie)
 	n += 8
@@ -363,26 +423,29 @@ func (s *FlowStatsRequest) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *FlowStatsRequest) UnmarshalBinary(data []byte) error {
+	if err := checkBounds(data, 0, 32, "FlowStatsRequest", "cookie_mask"); err != nil {
+		return err
+	}
 	n := 0
 	s.TableId = data[n]
 	n += 1
-	copy(s.pad, data[n:n+3])
+	copy(s.pad[:], data[n:n+3])
 	n += 3
 	s.OutPort = binary.BigEndian.Uint32(data[n:])
 	n += 4
 	s.OutGroup = binary.BigEndian.Uint32(data[n:])
 	n += 4
-	copy(s.pad2, data[n:n+4])
+	copy(s.pad2[:], data[n:n+4])
 	n += 4
 	s.Cookie = binary.BigEndian.Uint64(data[n:])
 	n += 8
 	s.CookieMask = binary.BigEndian.Uint64(data[n:])
 	n += 8
 
-	err := s.Match.UnmarshalBinary(data[n:])
-	n += int(s.Match.Len())
-
-	return err
+	if err := s.Match.UnmarshalBinary(data[n:]); err != nil {
+		return decodeErrorAt(err, "FlowStatsRequest", n, "match")
+	}
+	return nil
 }
 
 // ofp_flow_stats 1.3
@@ -396,24 +459,48 @@ type FlowStats struct {
 	IdleTimeout  uint16
 	HardTimeout  uint16
 	Flags        uint16
-	pad2         []uint8 // Size 4
+	pad2         [4]uint8 // Size 4
 	Cookie       uint64
 	PacketCount  uint64
 	ByteCount    uint64
 	Match        Match
+	// Instructions is nil if the decoding is deferred with DecodeOptions.Borrow, use GetInstructions to read the
+	// instructions of a decoded FlowStats.
 	Instructions []Instruction
+	// RawInstructions keeps the instruction bytes if the decoding of Instructions is deferred, see
+	// UnmarshalBinaryWithOptions.
+	RawInstructions []byte
+
+	instrDeferred bool
 }
 
 func NewFlowStats() *FlowStats {
 	f := new(FlowStats)
 	f.Match = *NewMatch()
-	f.pad2 = make([]byte, 4)
 	f.Instructions = make([]Instruction, 0)
 	return f
 }
 
+// GetInstructions returns the instructions of the FlowStats, the instructions are decoded from RawInstructions on
+// the first call if the decoding is deferred. It is not safe to call GetInstructions concurrently on the same
+// FlowStats.
+func (s *FlowStats) GetInstructions() ([]Instruction, error) {
+	if s.instrDeferred {
+		instructions, err := decodeFlowStatsInstructions(s.RawInstructions)
+		if err != nil {
+			return nil, err
+		}
+		s.Instructions = instructions
+		s.instrDeferred = false
+	}
+	return s.Instructions, nil
+}
+
 func (s *FlowStats) Len() (n uint16) {
 	n = 48 + s.Match.Len()
+	if s.instrDeferred {
+		return n + uint16(len(s.RawInstructions))
+	}
 	for _, instr := range s.Instructions {
 		n += instr.Len()
 	}
@@ -443,7 +530,7 @@ func (s *FlowStats) MarshalBinary() (data []byte, err error) {
 	n += 2
 	binary.BigEndian.PutUint16(data[n:], s.Flags)
 	n += 2
-	copy(data[n:], s.pad2)
+	copy(data[n:], s.pad2[:])
 	n += len(s.pad2)
 	binary.BigEndian.PutUint64(data[n:], s.Cookie)
 	n += 8
@@ -456,6 +543,10 @@ func (s *FlowStats) MarshalBinary() (data []byte, err error) {
 	data = append(data, b...)
 	n += len(b)
 
+	if s.instrDeferred {
+		data = append(data, s.RawInstructions...)
+		return
+	}
 	for _, instr := range s.Instructions {
 		b, err = instr.MarshalBinary()
 		data = append(data, b...)
@@ -465,9 +556,25 @@ func (s *FlowStats) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *FlowStats) UnmarshalBinary(data []byte) error {
+	return s.UnmarshalBinaryWithOptions(data, DecodeOptions{})
+}
+
+// UnmarshalBinaryWithOptions decodes the FlowStats with the options. If opts.Borrow is set, RawInstructions
+// references the instruction bytes in data, Instructions is left nil, and the instructions are decoded on the
+// first call of GetInstructions.
+func (s *FlowStats) UnmarshalBinaryWithOptions(data []byte, opts DecodeOptions) error {
+	if err := checkBounds(data, 0, 48, "FlowStats", "header"); err != nil {
+		return err
+	}
 	n := 0
 	s.Length = binary.BigEndian.Uint16(data[n:])
 	n += 2
+	if s.Length < 48 {
+		return &DecodeError{Message: "FlowStats", Offset: 0, Field: "length", Err: fmt.Errorf("invalid length %d", s.Length)}
+	}
+	if err := checkBounds(data, 0, int(s.Length), "FlowStats", "length"); err != nil {
+		return err
+	}
 	s.TableId = data[n]
 	n += 1
 	s.pad = data[n]
@@ -484,7 +591,7 @@ func (s *FlowStats) UnmarshalBinary(data []byte) error {
 	n += 2
 	s.Flags = binary.BigEndian.Uint16(data[n:])
 	n += 2
-	copy(s.pad2, data[n:n+4])
+	copy(s.pad2[:], data[n:n+4])
 	n += 4
 	s.Cookie = binary.BigEndian.Uint64(data[n:])
 	n += 8
@@ -492,24 +599,50 @@ func (s *FlowStats) UnmarshalBinary(data []byte) error {
 	n += 8
 	s.ByteCount = binary.BigEndian.Uint64(data[n:])
 	n += 8
-	err := s.Match.UnmarshalBinary(data[n:])
+	err := s.Match.UnmarshalBinary(data[n:s.Length])
+	if err != nil {
+		return decodeErrorAt(err, "FlowStats", n, "match")
+	}
+	if err := checkBounds(data[:s.Length], n, int(s.Match.Len()), "FlowStats", "match"); err != nil {
+		return err
+	}
 	n += int(s.Match.Len())
 
-	for n < int(s.Length) {
-		instr := DecodeInstr(data[n:])
-		s.Instructions = append(s.Instructions, instr)
-		n += int(instr.Len())
+	if opts.Borrow {
+		s.Instructions = nil
+		s.RawInstructions = data[n:s.Length:s.Length]
+		s.instrDeferred = true
+		return nil
 	}
-	return err
+	s.Instructions, err = decodeFlowStatsInstructions(data[n:s.Length])
+	if err != nil {
+		return decodeErrorAt(err, "FlowStats", n, "instructions")
+	}
+	return nil
+}
+
+func decodeFlowStatsInstructions(data []byte) (instructions []Instruction, err error) {
+	n := 0
+	for n < len(data) {
+		instr, err := decodeInstr(data[n:])
+		if err != nil {
+			return nil, decodeErrorAt(err, "FlowStats", n, "instruction")
+		}
+		instructions = append(instructions, instr)
+		if n, err = safeAdvance(n, instr.Len(), len(data), "instruction"); err != nil {
+			return nil, err
+		}
+	}
+	return instructions, nil
 }
 
 // ofp_aggregate_stats_request 1.3
 type AggregateStatsRequest struct {
 	TableId    uint8
-	pad        []byte // 3 bytes
+	pad        [3]byte // 3 bytes
 	OutPort    uint32
 	OutGroup   uint32
-	pad2       []byte // 4 bytes
+	pad2       [4]byte // 4 bytes
 	Cookie     uint64
 	CookieMask uint64
 	Match
@@ -517,8 +650,6 @@ type AggregateStatsRequest struct {
 
 func NewAggregateStatsRequest() *AggregateStatsRequest {
 	a := new(AggregateStatsRequest)
-	a.pad = make([]byte, 3)
-	a.pad2 = make([]byte, 4)
 	a.Match = *NewMatch()
 
 	return a
@@ -529,17 +660,18 @@ func (s *AggregateStatsRequest) Len() (n uint16) {
 }
 
 func (s *AggregateStatsRequest) MarshalBinary() (data []byte, err error) {
+	checkMatchSize("AggregateStatsRequest", &s.Match)
 	data = make([]byte, 32)
 	n := 0
 	data[n] = s.TableId
 	n += 1
-	copy(data[n:], s.pad)
+	copy(data[n:], s.pad[:])
 	n += 3
 	binary.BigEndian.PutUint32(data[n:], s.OutPort)
 	n += 4
 	binary.BigEndian.PutUint32(data[n:], s.OutGroup)
 	n += 4
-	copy(data[n:], s.pad2)
+	copy(data[n:], s.pad2[:])
 	n += 4
 	binary.BigEndian.PutUint64(data[n:], s.Cookie)
 	n += 8
@@ -552,24 +684,28 @@ func (s *AggregateStatsRequest) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *AggregateStatsRequest) UnmarshalBinary(data []byte) error {
+	if err := checkBounds(data, 0, 32, "AggregateStatsRequest", "header"); err != nil {
+		return err
+	}
 	n := 0
 	s.TableId = data[n]
 	n += 1
-	copy(s.pad, data[n:n+3])
+	copy(s.pad[:], data[n:n+3])
 	n += 3
 	s.OutPort = binary.BigEndian.Uint32(data[n:])
 	n += 4
 	s.OutGroup = binary.BigEndian.Uint32(data[n:])
 	n += 4
-	copy(s.pad2, data[n:n+4])
+	copy(s.pad2[:], data[n:n+4])
 	n += 4
 	s.Cookie = binary.BigEndian.Uint64(data[n:])
 	n += 8
 	s.CookieMask = binary.BigEndian.Uint64(data[n:])
 	n += 8
 
-	s.Match.UnmarshalBinary(data[n:])
-	n += int(s.Match.Len())
+	if err := s.Match.UnmarshalBinary(data[n:]); err != nil {
+		return decodeErrorAt(err, "AggregateStatsRequest", n, "match")
+	}
 	return nil
 }
 
@@ -578,12 +714,11 @@ type AggregateStats struct {
 	PacketCount uint64
 	ByteCount   uint64
 	FlowCount   uint32
-	pad         []uint8 // Size 4
+	pad         [4]uint8 // Size 4
 }
 
 func NewAggregateStats() *AggregateStats {
 	s := new(AggregateStats)
-	s.pad = make([]byte, 4)
 	return s
 }
 
@@ -600,12 +735,15 @@ func (s *AggregateStats) MarshalBinary() (data []byte, err error) {
 	n += 8
 	binary.BigEndian.PutUint32(data[n:], s.FlowCount)
 	n += 4
-	copy(data[n:], s.pad)
+	copy(data[n:], s.pad[:])
 	n += 4
 	return
 }
 
 func (s *AggregateStats) UnmarshalBinary(data []byte) error {
+	if err := checkBounds(data, 0, int(s.Len()), "AggregateStats", "flow_count"); err != nil {
+		return err
+	}
 	n := 0
 	s.PacketCount = binary.BigEndian.Uint64(data[n:])
 	n += 8
@@ -613,7 +751,7 @@ func (s *AggregateStats) UnmarshalBinary(data []byte) error {
 	n += 8
 	s.FlowCount = binary.BigEndian.Uint32(data[n:])
 	n += 4
-	copy(s.pad, data[n:])
+	copy(s.pad[:], data[n:])
 	return nil
 }
 
@@ -621,8 +759,8 @@ func (s *AggregateStats) UnmarshalBinary(data []byte) error {
 // ofp_table_stats 1.0
 type TableStats struct {
 	TableId      uint8
-	pad          []uint8 // Size 3
-	Name         []byte  // Size MAX_TABLE_NAME_LEN
+	pad          [3]uint8 // Size 3
+	Name         []byte   // Size MAX_TABLE_NAME_LEN
 	Wildcards    uint32
 	MaxEntries   uint32
 	ActiveCount  uint32
@@ -632,7 +770,6 @@ type TableStats struct {
 
 func NewTableStats() *TableStats {
 	s := new(TableStats)
-	s.pad = make([]byte, 3)
 	s.Name = make([]byte, MAX_TABLE_NAME_LEN)
 	return s
 }
@@ -646,7 +783,7 @@ func (s *TableStats) MarshalBinary() (data []byte, err error) {
 	n := 0
 	data[n] = s.TableId
 	n += 1
-	copy(data[n:], s.pad)
+	copy(data[n:], s.pad[:])
 	n += len(s.pad)
 	copy(data[n:], s.Name)
 	n += len(s.Name)
@@ -664,10 +801,16 @@ func (s *TableStats) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *TableStats) UnmarshalBinary(data []byte) error {
+	if err := checkBounds(data, 0, int(s.Len()), "TableStats", "matched_count"); err != nil {
+		return err
+	}
+	if len(s.Name) != MAX_TABLE_NAME_LEN {
+		s.Name = make([]byte, MAX_TABLE_NAME_LEN)
+	}
 	n := 0
 	s.TableId = data[0]
 	n += 1
-	copy(s.pad, data[n:])
+	copy(s.pad[:], data[n:])
 	n += len(s.pad)
 	copy(s.Name, data[n:])
 	n += len(s.Name)
@@ -688,15 +831,22 @@ const (
 	MAX_TABLE_NAME_LEN = 32
 )
 
-// ofp_port_stats_request 1.0
+// ofp_port_stats_request 1.3
 type PortStatsRequest struct {
-	PortNo uint16
-	pad    []uint8 // Size 6
+	PortNo uint32
+	pad    [4]uint8 // Size 4
 }
 
 func NewPortStatsRequest() *PortStatsRequest {
 	p := new(PortStatsRequest)
-	p.pad = make([]byte, 6)
+	return p
+}
+
+// NewPortStatsRequestAll returns a PortStatsRequest to query the statistics of all ports. Note the zero value
+// of PortNo only queries port 0.
+func NewPortStatsRequestAll() *PortStatsRequest {
+	p := NewPortStatsRequest()
+	p.PortNo = P_ANY
 	return p
 }
 
@@ -707,18 +857,21 @@ func (s *PortStatsRequest) Len() (n uint16) {
 func (s *PortStatsRequest) MarshalBinary() (data []byte, err error) {
 	data = make([]byte, int(s.Len()))
 	n := 0
-	binary.BigEndian.PutUint16(data[n:], s.PortNo)
-	n += 2
-	copy(data[n:], s.pad)
+	binary.BigEndian.PutUint32(data[n:], s.PortNo)
+	n += 4
+	copy(data[n:], s.pad[:])
 	n += len(s.pad)
 	return
 }
 
 func (s *PortStatsRequest) UnmarshalBinary(data []byte) error {
+	if len(data) < int(s.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full PortStatsRequest message")
+	}
 	n := 0
-	s.PortNo = binary.BigEndian.Uint16(data[n:])
-	n += 2
-	copy(s.pad, data[n:])
+	s.PortNo = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	copy(s.pad[:], data[n:])
 	n += len(s.pad)
 	return nil
 }
@@ -726,7 +879,7 @@ func (s *PortStatsRequest) UnmarshalBinary(data []byte) error {
 // ofp_port_stats 1.0
 type PortStats struct {
 	PortNo     uint16
-	pad        []uint8 // Size 6
+	pad        [6]uint8 // Size 6
 	RxPackets  uint64
 	TxPackets  uint64
 	RxBytes    uint64
@@ -743,7 +896,6 @@ type PortStats struct {
 
 func NewPortStats() *PortStats {
 	p := new(PortStats)
-	p.pad = make([]byte, 6)
 	return p
 }
 
@@ -756,7 +908,7 @@ func (s *PortStats) MarshalBinary() (data []byte, err error) {
 	n := 0
 	binary.BigEndian.PutUint16(data[n:], s.PortNo)
 	n += 2
-	copy(data[n:], s.pad)
+	copy(data[n:], s.pad[:])
 	n += len(s.pad)
 	binary.BigEndian.PutUint64(data[n:], s.RxPackets)
 	n += 8
@@ -786,10 +938,13 @@ func (s *PortStats) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *PortStats) UnmarshalBinary(data []byte) error {
+	if err := checkBounds(data, 0, int(s.Len()), "PortStats", "collisions"); err != nil {
+		return err
+	}
 	n := 0
 	s.PortNo = binary.BigEndian.Uint16(data[n:])
 	n += 2
-	copy(s.pad, data[n:])
+	copy(s.pad[:], data[n:])
 	n += len(s.pad)
 	s.RxPackets = binary.BigEndian.Uint64(data[n:])
 	n += 8
@@ -818,16 +973,22 @@ func (s *PortStats) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
-// ofp_queue_stats_request 1.0
+// ofp_queue_stats_request 1.3
 type QueueStatsRequest struct {
-	PortNo  uint16
-	pad     []uint8 // Size 2
+	PortNo  uint32
 	QueueId uint32
 }
 
 func NewQueueStatsRequest() *QueueStatsRequest {
 	q := new(QueueStatsRequest)
-	q.pad = make([]byte, 2)
+	return q
+}
+
+// NewQueueStatsRequestAll returns a QueueStatsRequest to query the statistics of all queues on all ports.
+func NewQueueStatsRequestAll() *QueueStatsRequest {
+	q := NewQueueStatsRequest()
+	q.PortNo = P_ANY
+	q.QueueId = OFPQ_ALL
 	return q
 }
 
@@ -838,29 +999,73 @@ func (s *QueueStatsRequest) Len() (n uint16) {
 func (s *QueueStatsRequest) MarshalBinary() (data []byte, err error) {
 	data = make([]byte, int(s.Len()))
 	n := 0
-	binary.BigEndian.PutUint16(data[n:], s.PortNo)
-	n += 2
-	copy(data[n:], s.pad)
-	n += 2
+	binary.BigEndian.PutUint32(data[n:], s.PortNo)
+	n += 4
 	binary.BigEndian.PutUint32(data[n:], s.QueueId)
 	n += 4
 	return
 }
 
 func (s *QueueStatsRequest) UnmarshalBinary(data []byte) error {
+	if len(data) < int(s.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full QueueStatsRequest message")
+	}
 	n := 0
-	s.PortNo = binary.BigEndian.Uint16(data[n:])
-	n += 2
-	copy(s.pad, data[n:])
-	n += 2
+	s.PortNo = binary.BigEndian.Uint32(data[n:])
+	n += 4
 	s.QueueId = binary.BigEndian.Uint32(data[n:])
 	return nil
 }
 
+// ExperimenterStatsBody is the body of the multipart request and reply with MultipartType_Experimenter, i.e.,
+// ofp_experimenter_multipart_header followed by the experimenter-defined data. The data is kept as raw bytes,
+// so that the callers could decode the vendor statistics according to Experimenter and ExpType, unless a typed
+// body is registered with RegisterExperimenterMultipartDecoder.
+type ExperimenterStatsBody struct {
+	ExperimenterMultipartHeader
+	Data []byte
+}
+
+func NewExperimenterStatsBody(experimenter uint32, expType uint32, data []byte) *ExperimenterStatsBody {
+	return &ExperimenterStatsBody{
+		ExperimenterMultipartHeader: ExperimenterMultipartHeader{Experimenter: experimenter, ExpType: expType},
+		Data:                        data,
+	}
+}
+
+func (s *ExperimenterStatsBody) Len() (n uint16) {
+	return 8 + uint16(len(s.Data))
+}
+
+func (s *ExperimenterStatsBody) MarshalBinary() (data []byte, err error) {
+	data, err = s.ExperimenterMultipartHeader.MarshalBinary()
+	data = append(data, s.Data...)
+	return
+}
+
+func (s *ExperimenterStatsBody) UnmarshalBinary(data []byte) error {
+	return s.unmarshalBinary(data, DecodeOptions{})
+}
+
+func (s *ExperimenterStatsBody) unmarshalBinary(data []byte, opts DecodeOptions) error {
+	if len(data) < 8 {
+		return errors.New("the []byte is too short to unmarshal a full ExperimenterStatsBody message")
+	}
+	s.ExperimenterMultipartHeader.UnmarshalBinary(data)
+	n := int(s.ExperimenterMultipartHeader.Len())
+	if opts.Borrow {
+		s.Data = data[n:len(data):len(data)]
+		return nil
+	}
+	s.Data = make([]byte, len(data[n:]))
+	copy(s.Data, data[n:])
+	return nil
+}
+
 // ofp_queue_stats 1.0
 type QueueStats struct {
 	PortNo    uint16
-	pad       []uint8 // Size 2
+	pad       [2]uint8 // Size 2
 	QueueId   uint32
 	TxBytes   uint64
 	TxPackets uint64
@@ -877,7 +1082,7 @@ func (s *QueueStats) MarshalBinary() (data []byte, err error) {
 
 	binary.BigEndian.PutUint16(data[n:], s.PortNo)
 	n += 2
-	copy(data[n:], s.pad)
+	copy(data[n:], s.pad[:])
 	n += 2
 	binary.BigEndian.PutUint32(data[n:], s.QueueId)
 	n += 4
@@ -891,10 +1096,13 @@ func (s *QueueStats) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *QueueStats) UnmarshalBinary(data []byte) error {
+	if err := checkBounds(data, 0, int(s.Len()), "QueueStats", "tx_errors"); err != nil {
+		return err
+	}
 	n := 0
 	s.PortNo = binary.BigEndian.Uint16(data[n:])
 	n += 2
-	copy(s.pad, data[n:])
+	copy(s.pad[:], data[n:])
 	n += len(s.pad)
 	s.QueueId = binary.BigEndian.Uint32(data[n:])
 	n += 4
@@ -907,24 +1115,32 @@ func (s *QueueStats) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
-// ofp_port_status
+// ofp_port_status. Since OpenFlow 1.4, the port has the properties, Desc15 is used instead of Desc if the version
+// in the header is newer than 1.3.
 type PortStatus struct {
 	common.Header
 	Reason uint8
-	pad    []uint8 // Size 7
+	pad    [7]uint8 // Size 7
 	Desc   PhyPort
+	Desc15 Port15
 }
 
 func NewPortStatus() *PortStatus {
 	p := new(PortStatus)
 	p.Header = NewOfp13Header()
-	p.pad = make([]byte, 7)
 	return p
 }
 
+func (p *PortStatus) usePropertiesLayout() bool {
+	return p.Header.Version > VERSION
+}
+
 func (p *PortStatus) Len() (n uint16) {
 	n = p.Header.Len()
 	n += 8
+	if p.usePropertiesLayout() {
+		return n + p.Desc15.Len()
+	}
 	n += p.Desc.Len()
 	return
 }
@@ -937,27 +1153,53 @@ func (s *PortStatus) MarshalBinary() (data []byte, err error) {
 	n := 0
 	b[0] = s.Reason
 	n += 1
-	copy(b[n:], s.pad)
+	copy(b[n:], s.pad[:])
 	data = append(data, b...)
 
-	b, err = s.Desc.MarshalBinary()
+	if s.usePropertiesLayout() {
+		b, err = s.Desc15.MarshalBinary()
+	} else {
+		b, err = s.Desc.MarshalBinary()
+	}
 	data = append(data, b...)
 	return
 }
 
 func (s *PortStatus) UnmarshalBinary(data []byte) error {
 	err := s.Header.UnmarshalBinary(data)
+	if err != nil {
+		return err
+	}
+	// The ofp_port 1.3 is 64 bytes, the properties of Desc15 after its 40 bytes are checked by Port15.
+	minLen := int(s.Header.Len()) + 8 + 64
+	if s.usePropertiesLayout() {
+		minLen = int(s.Header.Len()) + 8 + 40
+	}
+	if len(data) < minLen {
+		return errors.New("the []byte is too short to unmarshal a full PortStatus message")
+	}
 	n := int(s.Header.Len())
 
 	s.Reason = data[n]
 	n += 1
-	copy(s.pad, data[n:])
+	copy(s.pad[:], data[n:])
 	n += len(s.pad)
 
+	if s.usePropertiesLayout() {
+		return s.Desc15.UnmarshalBinary(data[n:])
+	}
 	err = s.Desc.UnmarshalBinary(data[n:])
 	return err
 }
 
+// Port returns the version agnostic view of the port in the PortStatus.
+func (s *PortStatus) Port() *Port {
+	if s.usePropertiesLayout() {
+		return NewPortFromPort15(&s.Desc15)
+	}
+	return NewPortFromPhyPort(&s.Desc)
+}
+
 // ofp_port_reason 1.0
 const (
 	PR_ADD = iota
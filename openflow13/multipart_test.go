@@ -0,0 +1,179 @@
+package openflow13
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+func TestExperimenterMultipartReply(t *testing.T) {
+	reply := new(MultipartReply)
+	reply.Header = NewOfp13Header()
+	reply.Header.Type = Type_MultiPartReply
+	reply.Type = MultipartType_Experimenter
+	reply.Body = []util.Message{NewExperimenterStatsBody(NxExperimenterID, 12, []byte{1, 2, 3, 4, 5, 6, 7, 8})}
+	data, err := reply.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal MultipartReply: %v", err)
+	}
+
+	msg, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse MultipartReply: %v", err)
+	}
+	reply2 := msg.(*MultipartReply)
+	if len(reply2.Body) != 1 {
+		t.Fatalf("Expect 1 body in MultipartReply, actual: %d", len(reply2.Body))
+	}
+	body, ok := reply2.Body[0].(*ExperimenterStatsBody)
+	if !ok {
+		t.Fatalf("Expect ExperimenterStatsBody, actual: %T", reply2.Body[0])
+	}
+	if body.Experimenter != NxExperimenterID || body.ExpType != 12 || !bytes.Equal(body.Data, []byte{1, 2, 3, 4, 5, 6, 7, 8}) {
+		t.Errorf("Unexpected ExperimenterStatsBody: %+v", body)
+	}
+}
+
+// multipartTestCounter is a vendor stats body with a counter after the experimenter multipart header.
+type multipartTestCounter struct {
+	ExperimenterMultipartHeader
+	Count uint64
+}
+
+func (c *multipartTestCounter) Len() uint16 {
+	return 16
+}
+
+func (c *multipartTestCounter) MarshalBinary() ([]byte, error) {
+	data, _ := c.ExperimenterMultipartHeader.MarshalBinary()
+	return binary.BigEndian.AppendUint64(data, c.Count), nil
+}
+
+func (c *multipartTestCounter) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("the []byte is too short to unmarshal a full multipartTestCounter message")
+	}
+	c.ExperimenterMultipartHeader.UnmarshalBinary(data)
+	c.Count = binary.BigEndian.Uint64(data[8:])
+	return nil
+}
+
+func TestRegisterExperimenterMultipartDecoder(t *testing.T) {
+	const expType = 0x77
+	RegisterExperimenterMultipartDecoder(NxExperimenterID, expType, func(reply bool) util.Message { return new(multipartTestCounter) })
+	defer RegisterExperimenterMultipartDecoder(NxExperimenterID, expType, nil)
+
+	counter := &multipartTestCounter{ExperimenterMultipartHeader{NxExperimenterID, expType}, 42}
+	reply := newMultipartReply(1, MultipartType_Experimenter, 0, counter, NewExperimenterStatsBody(ONF_EXPERIMENTER_ID, 1, []byte{1, 2}))
+	data, _ := reply.MarshalBinary()
+	msg, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse MultipartReply: %v", err)
+	}
+	reply2 := msg.(*MultipartReply)
+	if len(reply2.Body) != 2 {
+		t.Fatalf("Expect 2 bodies in MultipartReply, actual: %d", len(reply2.Body))
+	}
+	if c, ok := reply2.Body[0].(*multipartTestCounter); !ok || c.Count != 42 {
+		t.Errorf("Unexpected typed experimenter body: %+v", reply2.Body[0])
+	}
+	if raw, ok := reply2.Body[1].(*ExperimenterStatsBody); !ok || raw.ExpType != 1 {
+		t.Errorf("Unexpected raw experimenter body: %+v", reply2.Body[1])
+	}
+
+	jsonData, err := json.Marshal(reply2)
+	if err != nil {
+		t.Fatalf("Failed to encode MultipartReply in JSON: %v", err)
+	}
+	reply3 := new(MultipartReply)
+	if err := json.Unmarshal(jsonData, reply3); err != nil {
+		t.Fatalf("Failed to decode MultipartReply from JSON: %v", err)
+	}
+	if c, ok := reply3.Body[0].(*multipartTestCounter); !ok || c.Count != 42 {
+		t.Errorf("Unexpected typed experimenter body decoded from JSON %s", jsonData)
+	}
+
+	req := &MultipartRequest{Header: NewOfp13Header(), Type: MultipartType_Experimenter, Body: counter}
+	req.Header.Type = Type_MultiPartRequest
+	data, _ = req.MarshalBinary()
+	msg, err = Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse MultipartRequest: %v", err)
+	}
+	if c, ok := msg.(*MultipartRequest).Body.(*multipartTestCounter); !ok || c.Count != 42 {
+		t.Errorf("Unexpected typed experimenter request body: %+v", msg.(*MultipartRequest).Body)
+	}
+}
+
+func TestParseMultipartRequest(t *testing.T) {
+	aggregate := NewAggregateStatsRequest()
+	aggregate.TableId = 1
+	for _, body := range []util.Message{NewFlowStatsRequest(), aggregate, NewPortStatsRequestAll(), NewQueueStatsRequestAll()} {
+		req := &MultipartRequest{Header: NewOfp13Header(), Body: body}
+		req.Header.Type = Type_MultiPartRequest
+		switch body.(type) {
+		case *FlowStatsRequest:
+			req.Type = MultipartType_Flow
+		case *AggregateStatsRequest:
+			req.Type = MultipartType_Aggregate
+		case *PortStatsRequest:
+			req.Type = MultipartType_Port
+		case *QueueStatsRequest:
+			req.Type = MultipartType_Queue
+		}
+		data, err := req.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to marshal %T: %v", body, err)
+		}
+		// The request is decoded without a preset Body.
+		msg, err := Parse(data)
+		if err != nil {
+			t.Fatalf("Failed to parse %T: %v", body, err)
+		}
+		parsed, ok := msg.(*MultipartRequest)
+		if !ok || parsed.Body == nil {
+			t.Fatalf("Unexpected multipart request: %+v", msg)
+		}
+		if actual, _ := parsed.Body.MarshalBinary(); !bytes.Equal(actual, data[16:]) {
+			t.Errorf("Unexpected %T of multipart request: %+v", body, parsed.Body)
+		}
+	}
+}
+
+func TestPortAndQueueStatsRequest(t *testing.T) {
+	portReq := NewPortStatsRequest()
+	portReq.PortNo = 0x10002
+	data, err := portReq.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal PortStatsRequest: %v", err)
+	}
+	// The port_no of OpenFlow 1.3 is 32 bits followed by 4 bytes of padding.
+	if !bytes.Equal(data, []byte{0, 1, 0, 2, 0, 0, 0, 0}) {
+		t.Errorf("Unexpected PortStatsRequest: %v", data)
+	}
+	if data, _ = NewPortStatsRequestAll().MarshalBinary(); binary.BigEndian.Uint32(data) != P_ANY {
+		t.Errorf("Expect OFPP_ANY in PortStatsRequest of all ports: %v", data)
+	}
+
+	queueReq := NewQueueStatsRequest()
+	queueReq.PortNo = 0x10002
+	queueReq.QueueId = 0x30004
+	if data, err = queueReq.MarshalBinary(); err != nil {
+		t.Fatalf("Failed to marshal QueueStatsRequest: %v", err)
+	}
+	if !bytes.Equal(data, []byte{0, 1, 0, 2, 0, 3, 0, 4}) {
+		t.Errorf("Unexpected QueueStatsRequest: %v", data)
+	}
+	data, _ = NewQueueStatsRequestAll().MarshalBinary()
+	if binary.BigEndian.Uint32(data) != P_ANY || binary.BigEndian.Uint32(data[4:]) != OFPQ_ALL {
+		t.Errorf("Expect OFPP_ANY and OFPQ_ALL in QueueStatsRequest of all queues: %v", data)
+	}
+	decoded := new(QueueStatsRequest)
+	if err = decoded.UnmarshalBinary(data); err != nil || decoded.PortNo != P_ANY || decoded.QueueId != OFPQ_ALL {
+		t.Errorf("Unexpected QueueStatsRequest: %+v, %v", decoded, err)
+	}
+}
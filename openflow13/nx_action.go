@@ -23,8 +23,8 @@ const (
 	NXAST_REG_LOAD         = 7  // Nicira extended action: load:data->dstField[m..n]
 	NXAST_NOTE             = 8  // Nicira extended action: note
 	NXAST_SET_TUNNEL_V6    = 9  // Nicira extended action: set_tunnel64
-	NXAST_MULTIPATH        = 10 // Nicira extended action: set_tunnel
-	NXAST_AUTOPATH         = 11 // Nicira extended action: multipath
+	NXAST_MULTIPATH        = 10 // Nicira extended action: multipath
+	NXAST_AUTOPATH         = 11 // Nicira extended action: autopath
 	NXAST_BUNDLE           = 12 // Nicira extended action: bundle
 	NXAST_BUNDLE_LOAD      = 13 // Nicira extended action: bundle_load
 	NXAST_RESUBMIT_TABLE   = 14 // Nicira extended action: resubmit(port, table)
@@ -52,6 +52,7 @@ const (
 	NXAST_CONTROLLER2      = 37 // Nicira extended action: controller(userdata=xxx,pause)
 	NXAST_SAMPLE2          = 38 // Nicira extended action: sample, support for exporting egress tunnel
 	NXAST_OUTPUT_TRUNC     = 39 // Nicira extended action: truncate output action
+	NXAST_SAMPLE3          = 41 // Nicira extended action: sample, support for the sampling direction
 	NXAST_CT_CLEAR         = 43 // Nicira extended action: ct_clear
 	NXAST_CT_RESUBMIT      = 44 // Nicira extended action: resubmit to table in ct
 	NXAST_RAW_ENCAP        = 46 // Nicira extended action: encap
@@ -129,9 +130,10 @@ func DecodeNxAction(data []byte) Action {
 		a = new(NXActionNote)
 	case NXAST_SET_TUNNEL_V6:
 	case NXAST_MULTIPATH:
+		a = new(NXActionMultipath)
 	case NXAST_AUTOPATH:
-	case NXAST_BUNDLE:
-	case NXAST_BUNDLE_LOAD:
+	case NXAST_BUNDLE, NXAST_BUNDLE_LOAD:
+		a = new(NXActionBundle)
 	case NXAST_RESUBMIT_TABLE:
 		a = new(NXActionResubmitTable)
 	case NXAST_OUTPUT_REG:
@@ -139,9 +141,11 @@ func DecodeNxAction(data []byte) Action {
 	case NXAST_LEARN:
 		a = new(NXActionLearn)
 	case NXAST_EXIT:
+		a = new(NXActionExit)
 	case NXAST_DEC_TTL:
 		a = new(NXActionDecTTL)
 	case NXAST_FIN_TIMEOUT:
+		a = new(NXActionFinTimeout)
 	case NXAST_CONTROLLER:
 		a = new(NXActionController)
 	case NXAST_DEC_TTL_CNT_IDS:
@@ -153,6 +157,7 @@ func DecodeNxAction(data []byte) Action {
 	case NXAST_STACK_PUSH:
 	case NXAST_STACK_POP:
 	case NXAST_SAMPLE:
+		a = new(NXActionSample)
 	case NXAST_SET_MPLS_LABEL:
 	case NXAST_SET_MPLS_TC:
 	case NXAST_OUTPUT_REG2:
@@ -166,15 +171,21 @@ func DecodeNxAction(data []byte) Action {
 	case NXAST_NAT:
 		a = new(NXActionCTNAT)
 	case NXAST_CONTROLLER2:
-	case NXAST_SAMPLE2:
+	case NXAST_SAMPLE2, NXAST_SAMPLE3:
+		a = new(NXActionSample2)
 	case NXAST_OUTPUT_TRUNC:
+		a = new(NXActionOutputTrunc)
 	case NXAST_CT_CLEAR:
+		a = new(NXActionCTClear)
 	case NXAST_CT_RESUBMIT:
 		a = new(NXActionResubmitTable)
 		a.(*NXActionResubmitTable).withCT = true
 	case NXAST_RAW_ENCAP:
+		a = new(NXActionEncap)
 	case NXAST_RAW_DECAP:
+		a = new(NXActionDecap)
 	case NXAST_DEC_NSH_TTL:
+		a = new(NXActionDecNshTTL)
 	}
 	return a
 }
@@ -225,7 +236,7 @@ func (a *NXActionConjunction) UnmarshalBinary(data []byte) error {
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if a.Length < 16 || len(data) < int(a.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionConjunction message")
 	}
 	a.Clause = uint8(data[n])
@@ -244,7 +255,7 @@ type NXActionConnTrack struct {
 	ZoneSrc      uint32
 	ZoneOfsNbits uint16
 	RecircTable  uint8
-	pad          []byte // 3bytes
+	pad          [3]byte // 3bytes
 	Alg          uint16
 	actions      []Action
 }
@@ -269,7 +280,7 @@ func (a *NXActionConnTrack) MarshalBinary() (data []byte, err error) {
 	n += 2
 	data[n] = a.RecircTable
 	n++
-	copy(data[n:], a.pad)
+	copy(data[n:], a.pad[:])
 	n += 3
 	binary.BigEndian.PutUint16(data[n:], a.Alg)
 	n += 2
@@ -290,7 +301,7 @@ func (a *NXActionConnTrack) UnmarshalBinary(data []byte) error {
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if a.Length < 24 || len(data) < int(a.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionConnTrack message")
 	}
 	a.Flags = binary.BigEndian.Uint16(data[n:])
@@ -301,7 +312,7 @@ func (a *NXActionConnTrack) UnmarshalBinary(data []byte) error {
 	n += 2
 	a.RecircTable = data[n]
 	n++
-	copy(a.pad, data[n:n+3])
+	copy(a.pad[:], data[n:n+3])
 	n += 3
 	a.Alg = binary.BigEndian.Uint16(data[n:])
 	n += 2
@@ -309,10 +320,12 @@ func (a *NXActionConnTrack) UnmarshalBinary(data []byte) error {
 	for n < int(a.Len()) {
 		act, err := DecodeAction(data[n:])
 		if err != nil {
-			return errors.New("failed to decode actions")
+			return decodeErrorAt(err, "NXActionConnTrack", n, "action")
 		}
 		a.actions = append(a.actions, act)
-		n += int(act.Len())
+		if n, err = safeAdvance(n, act.Len(), int(a.Len()), "action"); err != nil {
+			return err
+		}
 	}
 	a.Length = uint16(n)
 	return err
@@ -407,7 +420,7 @@ func (a *NXActionRegLoad) UnmarshalBinary(data []byte) error {
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if a.Length < 24 || len(data) < int(a.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionRegLoad message")
 	}
 	a.OfsNbits = binary.BigEndian.Uint16(data[n:])
@@ -475,7 +488,7 @@ func (a *NXActionRegMove) UnmarshalBinary(data []byte) error {
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Length) {
+	if a.Length < 24 || len(data) < int(a.Length) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionRegMove message")
 	}
 	a.Nbits = binary.BigEndian.Uint16(data[n:])
@@ -503,7 +516,6 @@ type NXActionResubmit struct {
 func NewNXActionResubmit(inPort uint16) *NXActionResubmit {
 	a := new(NXActionResubmit)
 	a.NXActionHeader = NewNxActionHeader(NXAST_RESUBMIT)
-	a.Type = Type_Experimenter
 	a.Length = a.NXActionHeader.Len() + 6
 	a.InPort = inPort
 	a.pad = [3]byte{}
@@ -537,7 +549,7 @@ func (a *NXActionResubmit) UnmarshalBinary(data []byte) error {
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if a.Length < 16 || len(data) < int(a.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionConjunction message")
 	}
 	a.InPort = binary.BigEndian.Uint16(data[n:])
@@ -612,7 +624,7 @@ func (a *NXActionResubmitTable) UnmarshalBinary(data []byte) error {
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if a.Length < 16 || len(data) < int(a.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionResubmitTable message")
 	}
 	a.InPort = binary.BigEndian.Uint16(data[n:])
@@ -642,9 +654,9 @@ func NewNXActionResubmitTableCTNoInPort(tableID uint8) *NXActionResubmitTable {
 // NXActionCTNAT is NX action to set NAT in conntrack.
 type NXActionCTNAT struct {
 	*NXActionHeader
-	pad          []byte // 2 bytes
-	Flags        uint16 // nat Flags to identify snat/dnat, and nat algorithms: random/protocolHash, connection persistent
-	rangePresent uint16 // mark if has set nat range, including ipv4/ipv6 range, port range
+	pad          [2]byte // 2 bytes
+	Flags        uint16  // nat Flags to identify snat/dnat, and nat algorithms: random/protocolHash, connection persistent
+	rangePresent uint16  // mark if has set nat range, including ipv4/ipv6 range, port range
 
 	rangeIPv4Min  net.IP
 	rangeIPv4Max  net.IP
@@ -658,12 +670,30 @@ func NewNXActionCTNAT() *NXActionCTNAT {
 	a := new(NXActionCTNAT)
 	a.NXActionHeader = NewNxActionHeader(NXAST_NAT)
 	a.Length = 16
-	a.pad = make([]byte, 2)
 	return a
 }
 
 func (a *NXActionCTNAT) Len() (n uint16) {
-	a.Length = ((a.Length + 7) / 8) * 8
+	n = a.NXActionHeader.Len() + 6
+	if a.rangeIPv4Min != nil {
+		n += 4
+	}
+	if a.rangeIPv4Max != nil {
+		n += 4
+	}
+	if a.rangeIPv6Min != nil {
+		n += 16
+	}
+	if a.rangeIPv6Max != nil {
+		n += 16
+	}
+	if a.rangeProtoMin != nil {
+		n += 2
+	}
+	if a.rangeProtoMax != nil {
+		n += 2
+	}
+	a.Length = ((n + 7) / 8) * 8
 	return a.Length
 }
 
@@ -702,7 +732,7 @@ func (a *NXActionCTNAT) MarshalBinary() (data []byte, err error) {
 		binary.BigEndian.PutUint16(data[n:], *a.rangeProtoMin)
 		n += 2
 	}
-	if a.rangeProtoMin != nil {
+	if a.rangeProtoMax != nil {
 		binary.BigEndian.PutUint16(data[n:], *a.rangeProtoMax)
 		n += 2
 	}
@@ -750,32 +780,26 @@ func (a *NXActionCTNAT) SetPersistent() error {
 func (a *NXActionCTNAT) SetRangeIPv4Min(ipMin net.IP) {
 	a.rangeIPv4Min = ipMin
 	a.rangePresent |= NX_NAT_RANGE_IPV4_MIN
-	a.Length += 4
 }
 func (a *NXActionCTNAT) SetRangeIPv4Max(ipMax net.IP) {
 	a.rangeIPv4Max = ipMax
 	a.rangePresent |= NX_NAT_RANGE_IPV4_MAX
-	a.Length += 4
 }
 func (a *NXActionCTNAT) SetRangeIPv6Min(ipMin net.IP) {
 	a.rangeIPv6Min = ipMin
 	a.rangePresent |= NX_NAT_RANGE_IPV6_MIN
-	a.Length += 16
 }
 func (a *NXActionCTNAT) SetRangeIPv6Max(ipMax net.IP) {
 	a.rangeIPv6Max = ipMax
 	a.rangePresent |= NX_NAT_RANGE_IPV6_MAX
-	a.Length += 16
 }
 func (a *NXActionCTNAT) SetRangeProtoMin(protoMin *uint16) {
 	a.rangeProtoMin = protoMin
 	a.rangePresent |= NX_NAT_RANGE_PROTO_MIN
-	a.Length += 2
 }
 func (a *NXActionCTNAT) SetRangeProtoMax(protoMax *uint16) {
 	a.rangeProtoMax = protoMax
 	a.rangePresent |= NX_NAT_RANGE_PROTO_MAX
-	a.Length += 2
 }
 
 func (a *NXActionCTNAT) UnmarshalBinary(data []byte) error {
@@ -783,7 +807,7 @@ func (a *NXActionCTNAT) UnmarshalBinary(data []byte) error {
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if a.Length < 16 || len(data) < int(a.Length) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionCTNAT message")
 	}
 	// Skip padding bytes
@@ -863,7 +887,7 @@ func (a *NXActionOutputReg) UnmarshalBinary(data []byte) error {
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if a.Length < 24 || len(data) < int(a.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionOutputReg message")
 	}
 	a.OfsNbits = binary.BigEndian.Uint16(data[n:])
@@ -929,7 +953,7 @@ func (a *NXActionDecTTL) UnmarshalBinary(data []byte) error {
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if a.Length < 16 || len(data) < int(a.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionDecTTL message")
 	}
 	a.controllers = binary.BigEndian.Uint16(data[n:])
@@ -982,7 +1006,7 @@ func (a *NXActionDecTTLCntIDs) UnmarshalBinary(data []byte) error {
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Len()) {
+	if a.Length < 16 || len(data) < int(a.Len()) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionDecTTLCntIDs message")
 	}
 	a.controllers = binary.BigEndian.Uint16(data[n:])
@@ -1004,7 +1028,8 @@ func NewNXActionDecTTLCntIDs(controllers uint16, ids ...uint16) *NXActionDecTTLC
 		zeros:          [4]uint8{},
 		cntIDs:         ids,
 	}
-	a.Length = 16 + uint16(2*len(ids))
+	// The controller IDs are padded to make the action 64-bit aligned.
+	a.Length = 16 + uint16(8*((2*len(ids)+7)/8))
 	return a
 }
 
@@ -1208,7 +1233,6 @@ type NXActionLearn struct {
 	FinIdleTimeout uint16
 	FinHardTimeout uint16
 	LearnSpecs     []*NXLearnSpec
-	pad2           []byte
 }
 
 func (a *NXActionLearn) Len() uint16 {
@@ -1261,7 +1285,7 @@ func (a *NXActionLearn) UnmarshalBinary(data []byte) error {
 	if err != nil {
 		return err
 	}
-	if len(data) < int(a.Length) {
+	if a.Length < 32 || len(data) < int(a.Length) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionLearn message")
 	}
 	n += int(a.NXActionHeader.Len())
@@ -1288,10 +1312,12 @@ func (a *NXActionLearn) UnmarshalBinary(data []byte) error {
 		spec := new(NXLearnSpec)
 		err = spec.UnmarshalBinary(data[n:])
 		if err != nil {
-			return err
+			return decodeErrorAt(err, "NXActionLearn", n, "learn spec")
 		}
 		a.LearnSpecs = append(a.LearnSpecs, spec)
-		n += int(spec.Len())
+		if n, err = safeAdvance(n, spec.Len(), int(a.Length), "learn spec"); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -1332,7 +1358,7 @@ func (a *NXActionNote) UnmarshalBinary(data []byte) error {
 	if err != nil {
 		return err
 	}
-	if len(data) < int(a.Length) {
+	if a.Length < 10 || len(data) < int(a.Length) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionNote message")
 	}
 	n := a.NXActionHeader.Len()
@@ -1350,7 +1376,6 @@ func NewNXActionNote() *NXActionNote {
 type NXActionRegLoad2 struct {
 	*NXActionHeader
 	DstField *MatchField
-	pad      []byte
 }
 
 func NewNXActionRegLoad2(dstField *MatchField) *NXActionRegLoad2 {
@@ -1386,7 +1411,7 @@ func (a *NXActionRegLoad2) UnmarshalBinary(data []byte) error {
 	a.NXActionHeader = new(NXActionHeader)
 	err := a.NXActionHeader.UnmarshalBinary(data[n:])
 	n += int(a.NXActionHeader.Len())
-	if len(data) < int(a.Length) {
+	if a.Length < 14 || len(data) < int(a.Length) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionRegLoad2 message")
 	}
 	a.DstField = new(MatchField)
@@ -1438,7 +1463,7 @@ func (a *NXActionController) UnmarshalBinary(data []byte) error {
 	if err != nil {
 		return err
 	}
-	if len(data) < int(a.Length) {
+	if a.Length < 16 || len(data) < int(a.Length) {
 		return errors.New("the []byte is too short to unmarshal a full NXActionController message")
 	}
 	n += int(a.NXActionHeader.Len())
@@ -1458,3 +1483,803 @@ func NewNXActionController(controllerID uint16) *NXActionController {
 	a.Length = a.NXActionHeader.Len() + 6
 	return a
 }
+
+// NXActionFinTimeout changes the idle and hard timeouts of the flow entry when a TCP FIN or RST is seen.
+type NXActionFinTimeout struct {
+	*NXActionHeader
+	FinIdleTimeout uint16
+	FinHardTimeout uint16
+	pad            [2]byte
+}
+
+func (a *NXActionFinTimeout) Len() uint16 {
+	return a.NXActionHeader.Len() + 6
+}
+
+func (a *NXActionFinTimeout) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, a.Len())
+	var b []byte
+	n := 0
+	a.Length = a.Len()
+	b, err = a.NXActionHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	copy(data[n:], b)
+	n += len(b)
+	binary.BigEndian.PutUint16(data[n:], a.FinIdleTimeout)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], a.FinHardTimeout)
+	n += 2
+	return data, nil
+}
+
+func (a *NXActionFinTimeout) UnmarshalBinary(data []byte) error {
+	a.NXActionHeader = new(NXActionHeader)
+	n := 0
+	err := a.NXActionHeader.UnmarshalBinary(data[n:])
+	if err != nil {
+		return err
+	}
+	if a.Length < 16 || len(data) < int(a.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full NXActionFinTimeout message")
+	}
+	n += int(a.NXActionHeader.Len())
+	a.FinIdleTimeout = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	a.FinHardTimeout = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	return nil
+}
+
+func NewNXActionFinTimeout(finIdleTimeout, finHardTimeout uint16) *NXActionFinTimeout {
+	a := new(NXActionFinTimeout)
+	a.NXActionHeader = NewNxActionHeader(NXAST_FIN_TIMEOUT)
+	a.FinIdleTimeout = finIdleTimeout
+	a.FinHardTimeout = finHardTimeout
+	a.Length = a.Len()
+	return a
+}
+
+// The directions of the sampled packets in NXActionSample2.
+const (
+	NX_ACTION_SAMPLE_DEFAULT = 0 // Sampled in the direction of the port of the flow
+	NX_ACTION_SAMPLE_INGRESS = 1 // Sampled on the ingress of the packet
+	NX_ACTION_SAMPLE_EGRESS  = 2 // Sampled on the egress of the packet
+)
+
+// NXActionSample is NX action to sample the packets with the probability, and send them to the IPFIX collectors
+// of the collector set, the action in flow entry is like sample(probability=N,collector_set_id=N,...).
+type NXActionSample struct {
+	*NXActionHeader
+	Probability    uint16 // The number of sampled packets out of 65535
+	CollectorSetID uint32
+	ObsDomainID    uint32
+	ObsPointID     uint32
+}
+
+func NewNXActionSample(probability uint16, collectorSetID, obsDomainID, obsPointID uint32) *NXActionSample {
+	a := new(NXActionSample)
+	a.NXActionHeader = NewNxActionHeader(NXAST_SAMPLE)
+	a.Probability = probability
+	a.CollectorSetID = collectorSetID
+	a.ObsDomainID = obsDomainID
+	a.ObsPointID = obsPointID
+	a.Length = a.Len()
+	return a
+}
+
+func (a *NXActionSample) Len() uint16 {
+	return a.NXActionHeader.Len() + 14
+}
+
+func (a *NXActionSample) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, a.Len())
+	a.Length = a.Len()
+	b, err := a.NXActionHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	binary.BigEndian.PutUint16(data[n:], a.Probability)
+	n += 2
+	binary.BigEndian.PutUint32(data[n:], a.CollectorSetID)
+	n += 4
+	binary.BigEndian.PutUint32(data[n:], a.ObsDomainID)
+	n += 4
+	binary.BigEndian.PutUint32(data[n:], a.ObsPointID)
+	return data, nil
+}
+
+func (a *NXActionSample) UnmarshalBinary(data []byte) error {
+	a.NXActionHeader = new(NXActionHeader)
+	if err := a.NXActionHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if a.Length < a.Len() || len(data) < int(a.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full NXActionSample message")
+	}
+	n := int(a.NXActionHeader.Len())
+	a.Probability = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	a.CollectorSetID = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	a.ObsDomainID = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	a.ObsPointID = binary.BigEndian.Uint32(data[n:])
+	return nil
+}
+
+// NXActionSample2 is NX action to sample the packets as NXActionSample, and export the output port of the
+// sampled packets, e.g., the egress tunnel, in the sampling port. It is encoded as NXAST_SAMPLE2, or as
+// NXAST_SAMPLE3 if the direction is set, as OVS ignores the direction in NXAST_SAMPLE2.
+type NXActionSample2 struct {
+	*NXActionHeader
+	Probability    uint16
+	CollectorSetID uint32
+	ObsDomainID    uint32
+	ObsPointID     uint32
+	SamplingPort   uint16
+	Direction      uint8 // One of NX_ACTION_SAMPLE_*
+	pad            [5]byte
+}
+
+func NewNXActionSample2(probability uint16, collectorSetID, obsDomainID, obsPointID uint32, samplingPort uint16, direction uint8) *NXActionSample2 {
+	subtype := uint16(NXAST_SAMPLE2)
+	if direction != NX_ACTION_SAMPLE_DEFAULT {
+		subtype = NXAST_SAMPLE3
+	}
+	a := new(NXActionSample2)
+	a.NXActionHeader = NewNxActionHeader(subtype)
+	a.Probability = probability
+	a.CollectorSetID = collectorSetID
+	a.ObsDomainID = obsDomainID
+	a.ObsPointID = obsPointID
+	a.SamplingPort = samplingPort
+	a.Direction = direction
+	a.Length = a.Len()
+	return a
+}
+
+func (a *NXActionSample2) Len() uint16 {
+	return a.NXActionHeader.Len() + 22
+}
+
+func (a *NXActionSample2) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, a.Len())
+	a.Length = a.Len()
+	b, err := a.NXActionHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	binary.BigEndian.PutUint16(data[n:], a.Probability)
+	n += 2
+	binary.BigEndian.PutUint32(data[n:], a.CollectorSetID)
+	n += 4
+	binary.BigEndian.PutUint32(data[n:], a.ObsDomainID)
+	n += 4
+	binary.BigEndian.PutUint32(data[n:], a.ObsPointID)
+	n += 4
+	binary.BigEndian.PutUint16(data[n:], a.SamplingPort)
+	n += 2
+	data[n] = a.Direction
+	return data, nil
+}
+
+func (a *NXActionSample2) UnmarshalBinary(data []byte) error {
+	a.NXActionHeader = new(NXActionHeader)
+	if err := a.NXActionHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if a.Length < a.Len() || len(data) < int(a.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full NXActionSample2 message")
+	}
+	n := int(a.NXActionHeader.Len())
+	a.Probability = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	a.CollectorSetID = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	a.ObsDomainID = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	a.ObsPointID = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	a.SamplingPort = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	a.Direction = data[n]
+	return nil
+}
+
+// NXActionOutputTrunc is NX action to output the packet to the port truncated to the max length, the action in
+// flow entry is like output(port=1,max_len=100).
+type NXActionOutputTrunc struct {
+	*NXActionHeader
+	Port   uint16
+	MaxLen uint32
+}
+
+func NewNXActionOutputTrunc(port uint16, maxLen uint32) *NXActionOutputTrunc {
+	a := new(NXActionOutputTrunc)
+	a.NXActionHeader = NewNxActionHeader(NXAST_OUTPUT_TRUNC)
+	a.Port = port
+	a.MaxLen = maxLen
+	a.Length = a.Len()
+	return a
+}
+
+func (a *NXActionOutputTrunc) Len() uint16 {
+	return a.NXActionHeader.Len() + 6
+}
+
+func (a *NXActionOutputTrunc) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, a.Len())
+	a.Length = a.Len()
+	b, err := a.NXActionHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	binary.BigEndian.PutUint16(data[n:], a.Port)
+	n += 2
+	binary.BigEndian.PutUint32(data[n:], a.MaxLen)
+	return data, nil
+}
+
+func (a *NXActionOutputTrunc) UnmarshalBinary(data []byte) error {
+	a.NXActionHeader = new(NXActionHeader)
+	if err := a.NXActionHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if a.Length < a.Len() || len(data) < int(a.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full NXActionOutputTrunc message")
+	}
+	n := int(a.NXActionHeader.Len())
+	a.Port = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	a.MaxLen = binary.BigEndian.Uint32(data[n:])
+	return nil
+}
+
+// NXActionCTClear is NX action to clear the conntrack state of the packet, the action in flow entry is ct_clear.
+type NXActionCTClear struct {
+	*NXActionHeader
+	pad [6]byte
+}
+
+func NewNXActionCTClear() *NXActionCTClear {
+	a := &NXActionCTClear{NXActionHeader: NewNxActionHeader(NXAST_CT_CLEAR)}
+	a.Length = a.Len()
+	return a
+}
+
+func (a *NXActionCTClear) Len() uint16 {
+	return a.NXActionHeader.Len() + 6
+}
+
+func (a *NXActionCTClear) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, a.Len())
+	a.Length = a.Len()
+	b, err := a.NXActionHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	copy(data, b)
+	return data, nil
+}
+
+func (a *NXActionCTClear) UnmarshalBinary(data []byte) error {
+	a.NXActionHeader = new(NXActionHeader)
+	if err := a.NXActionHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if a.Length < a.Len() || len(data) < int(a.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full NXActionCTClear message")
+	}
+	return nil
+}
+
+// NXActionExit is NX action to stop the processing of the actions, the actions already executed take effect, and
+// the packet is not resubmitted to the following tables. The action in flow entry is exit.
+type NXActionExit struct {
+	*NXActionHeader
+	pad [6]byte
+}
+
+func NewNXActionExit() *NXActionExit {
+	a := &NXActionExit{NXActionHeader: NewNxActionHeader(NXAST_EXIT)}
+	a.Length = a.Len()
+	return a
+}
+
+func (a *NXActionExit) Len() uint16 {
+	return a.NXActionHeader.Len() + 6
+}
+
+func (a *NXActionExit) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, a.Len())
+	a.Length = a.Len()
+	b, err := a.NXActionHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	copy(data, b)
+	return data, nil
+}
+
+func (a *NXActionExit) UnmarshalBinary(data []byte) error {
+	a.NXActionHeader = new(NXActionHeader)
+	if err := a.NXActionHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if a.Length < a.Len() || len(data) < int(a.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full NXActionExit message")
+	}
+	return nil
+}
+
+// NXActionDecNshTTL is NX action to decrement the TTL of the NSH header, the action in flow entry is dec_nsh_ttl.
+type NXActionDecNshTTL struct {
+	*NXActionHeader
+	pad [6]byte
+}
+
+func NewNXActionDecNshTTL() *NXActionDecNshTTL {
+	a := &NXActionDecNshTTL{NXActionHeader: NewNxActionHeader(NXAST_DEC_NSH_TTL)}
+	a.Length = a.Len()
+	return a
+}
+
+func (a *NXActionDecNshTTL) Len() uint16 {
+	return a.NXActionHeader.Len() + 6
+}
+
+func (a *NXActionDecNshTTL) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, a.Len())
+	a.Length = a.Len()
+	b, err := a.NXActionHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	copy(data, b)
+	return data, nil
+}
+
+func (a *NXActionDecNshTTL) UnmarshalBinary(data []byte) error {
+	a.NXActionHeader = new(NXActionHeader)
+	if err := a.NXActionHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if a.Length < a.Len() || len(data) < int(a.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full NXActionDecNshTTL message")
+	}
+	return nil
+}
+
+// The packet types of the encap and decap actions, the namespace is in the upper 16 bits, i.e., 0 for the
+// Ethernet packets and 1 for the packets of an EtherType.
+const (
+	PT_ETH            = 0x00000000 // Ethernet
+	PT_USE_NEXT_PROTO = 0x0000fffe // The next protocol of the decapsulated header
+	PT_IPV4           = 0x00010800 // IPv4
+	PT_IPV6           = 0x000186dd // IPv6
+	PT_MPLS           = 0x00018847 // MPLS
+	PT_NSH            = 0x0001894f // NSH
+)
+
+// ofp_ed_prop_class, the classes of the properties of the encap action.
+const (
+	OFPPPC_BASIC        = 0
+	OFPPPC_MPLS         = 1
+	OFPPPC_GRE          = 2
+	OFPPPC_GTP          = 3
+	OFPPPC_NSH          = 4
+	OFPPPC_EXPERIMENTER = 0xffff
+)
+
+// ofp_ed_nsh_prop_type, the properties of the encap(nsh) action.
+const (
+	OFPPPT_PROP_NSH_NONE   = 0 /* unused */
+	OFPPPT_PROP_NSH_MDTYPE = 1 /* property of type uint8_t: the MD type of NSH. */
+	OFPPPT_PROP_NSH_TLV    = 2 /* property of the NSH TLV of MD type 2. */
+)
+
+// NXEncapProp is the ofp_ed_prop of the encap action, the Data is the property after the header. The property is
+// padded to a multiple of 8 bytes.
+type NXEncapProp struct {
+	Class  uint16
+	Type   uint8
+	Length uint8
+	Data   []byte
+}
+
+// NewNXEncapPropNshMdType returns the property of the MD type of encap(nsh), i.e., 1 or 2.
+func NewNXEncapPropNshMdType(mdType uint8) *NXEncapProp {
+	return &NXEncapProp{Class: OFPPPC_NSH, Type: OFPPPT_PROP_NSH_MDTYPE, Data: []byte{mdType, 0, 0, 0}}
+}
+
+// NewNXEncapPropNshTLV returns the property of an NSH TLV of MD type 2.
+func NewNXEncapPropNshTLV(tlvClass uint16, tlvType uint8, value []byte) *NXEncapProp {
+	data := make([]byte, 4, 4+len(value))
+	binary.BigEndian.PutUint16(data[0:], tlvClass)
+	data[2] = tlvType
+	data[3] = uint8(len(value))
+	return &NXEncapProp{Class: OFPPPC_NSH, Type: OFPPPT_PROP_NSH_TLV, Data: append(data, value...)}
+}
+
+func (p *NXEncapProp) Len() uint16 {
+	return uint16(8 * ((4 + len(p.Data) + 7) / 8))
+}
+
+func (p *NXEncapProp) MarshalBinary() (data []byte, err error) {
+	p.Length = uint8(4 + len(p.Data))
+	data = make([]byte, p.Len())
+	binary.BigEndian.PutUint16(data[0:], p.Class)
+	data[2] = p.Type
+	data[3] = p.Length
+	copy(data[4:], p.Data)
+	return data, nil
+}
+
+func (p *NXEncapProp) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("the []byte is too short to unmarshal a full NXEncapProp message")
+	}
+	p.Class = binary.BigEndian.Uint16(data[0:])
+	p.Type = data[2]
+	p.Length = data[3]
+	if p.Length < 4 || len(data) < int(p.Length) {
+		return errors.New("the []byte is too short to unmarshal a full NXEncapProp message")
+	}
+	p.Data = append([]byte(nil), data[4:p.Length]...)
+	return nil
+}
+
+// NXActionEncap is NX action to encapsulate the packet in a header of the packet type, e.g., PT_NSH, the action in
+// flow entry is like encap(nsh(md_type=1)).
+type NXActionEncap struct {
+	*NXActionHeader
+	HdrSize    uint16 // The size of the new header, 0 for the default size of the packet type
+	NewPktType uint32 // One of PT_*
+	Props      []*NXEncapProp
+}
+
+func NewNXActionEncap(newPktType uint32, props ...*NXEncapProp) *NXActionEncap {
+	a := new(NXActionEncap)
+	a.NXActionHeader = NewNxActionHeader(NXAST_RAW_ENCAP)
+	a.NewPktType = newPktType
+	a.Props = props
+	a.Length = a.Len()
+	return a
+}
+
+func (a *NXActionEncap) Len() uint16 {
+	length := a.NXActionHeader.Len() + 6
+	for _, p := range a.Props {
+		length += p.Len()
+	}
+	return length
+}
+
+func (a *NXActionEncap) MarshalBinary() (data []byte, err error) {
+	a.Length = a.Len()
+	data, err = a.NXActionHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 6)
+	binary.BigEndian.PutUint16(b[0:], a.HdrSize)
+	binary.BigEndian.PutUint32(b[2:], a.NewPktType)
+	data = append(data, b...)
+	for _, p := range a.Props {
+		if b, err = p.MarshalBinary(); err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return data, nil
+}
+
+func (a *NXActionEncap) UnmarshalBinary(data []byte) error {
+	a.NXActionHeader = new(NXActionHeader)
+	if err := a.NXActionHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	n := int(a.NXActionHeader.Len())
+	if a.Length < 16 || len(data) < int(a.Length) {
+		return errors.New("the []byte is too short to unmarshal a full NXActionEncap message")
+	}
+	a.HdrSize = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	a.NewPktType = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	a.Props = nil
+	for n < int(a.Length) {
+		p := new(NXEncapProp)
+		if err := p.UnmarshalBinary(data[n:a.Length]); err != nil {
+			return decodeErrorAt(err, "NXActionEncap", n, "property")
+		}
+		a.Props = append(a.Props, p)
+		var err error
+		if n, err = safeAdvance(n, p.Len(), int(a.Length), "encap property"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NXActionDecap is NX action to decapsulate the outer header of the packet, the new packet type is
+// PT_USE_NEXT_PROTO to use the next protocol of the header, the action in flow entry is like decap().
+type NXActionDecap struct {
+	*NXActionHeader
+	pad        [2]byte
+	NewPktType uint32
+}
+
+func NewNXActionDecap(newPktType uint32) *NXActionDecap {
+	a := new(NXActionDecap)
+	a.NXActionHeader = NewNxActionHeader(NXAST_RAW_DECAP)
+	a.NewPktType = newPktType
+	a.Length = a.Len()
+	return a
+}
+
+func (a *NXActionDecap) Len() uint16 {
+	return a.NXActionHeader.Len() + 6
+}
+
+func (a *NXActionDecap) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, a.Len())
+	a.Length = a.Len()
+	b, err := a.NXActionHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	n += 2
+	binary.BigEndian.PutUint32(data[n:], a.NewPktType)
+	return data, nil
+}
+
+func (a *NXActionDecap) UnmarshalBinary(data []byte) error {
+	a.NXActionHeader = new(NXActionHeader)
+	if err := a.NXActionHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if a.Length < a.Len() || len(data) < int(a.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full NXActionDecap message")
+	}
+	a.NewPktType = binary.BigEndian.Uint32(data[a.NXActionHeader.Len()+2:])
+	return nil
+}
+
+// nx_hash_fields, the fields hashed by the multipath and bundle actions.
+const (
+	NX_HASH_FIELDS_ETH_SRC            = 0 // Ethernet source address only
+	NX_HASH_FIELDS_SYMMETRIC_L4       = 1 // Ethernet, VLAN, IP and L4 addresses, the same hash in both directions
+	NX_HASH_FIELDS_SYMMETRIC_L3L4     = 2 // IP protocol, IP addresses and TCP/SCTP ports, symmetric
+	NX_HASH_FIELDS_SYMMETRIC_L3L4_UDP = 3 // As NX_HASH_FIELDS_SYMMETRIC_L3L4 plus the UDP ports
+	NX_HASH_FIELDS_NW_SRC             = 4 // IPv4 or IPv6 source address only
+	NX_HASH_FIELDS_NW_DST             = 5 // IPv4 or IPv6 destination address only
+	NX_HASH_FIELDS_SYMMETRIC_L3       = 6 // IP protocol and IP addresses, symmetric
+)
+
+// nx_mp_algorithm, the algorithms of the multipath action to select a link of the hash.
+const (
+	NX_MP_ALG_MODULO_N       = 0 // The hash modulo the number of links
+	NX_MP_ALG_HASH_THRESHOLD = 1 // The link of the range of the hash, i.e., the hash divided by the number of links
+	NX_MP_ALG_HRW            = 2 // Highest random weight
+	NX_MP_ALG_ITER_HASH      = 3 // Iterative hash, the arg is the number of iterations
+)
+
+// nx_bd_algorithm, the algorithms of the bundle actions to select a slave port.
+const (
+	NX_BD_ALG_ACTIVE_BACKUP = 0 // The first live slave port
+	NX_BD_ALG_HRW           = 1 // Highest random weight of the live slave ports
+)
+
+// NXActionMultipath is NX action to hash the fields of the packet with the basis, select one of the links
+// 0..MaxLink with the algorithm, and store the link in the bits of the dst field, the action in flow entry is like
+// multipath(symmetric_l4,50,hrw,4,0,NXM_NX_REG0[0..1]).
+type NXActionMultipath struct {
+	*NXActionHeader
+	Fields    uint16 // One of NX_HASH_FIELDS_*
+	Basis     uint16
+	pad       [2]byte
+	Algorithm uint16 // One of NX_MP_ALG_*
+	MaxLink   uint16 // The number of links minus 1
+	Arg       uint32 // The argument of the algorithm
+	pad2      [2]byte
+	OfsNbits  uint16
+	DstField  *MatchField
+}
+
+func NewNXActionMultipath(fields, basis, algorithm, maxLink uint16, arg uint32, ofsNbits uint16, dstField *MatchField) *NXActionMultipath {
+	a := new(NXActionMultipath)
+	a.NXActionHeader = NewNxActionHeader(NXAST_MULTIPATH)
+	a.Fields = fields
+	a.Basis = basis
+	a.Algorithm = algorithm
+	a.MaxLink = maxLink
+	a.Arg = arg
+	a.OfsNbits = ofsNbits
+	a.DstField = dstField
+	a.Length = a.Len()
+	return a
+}
+
+func (a *NXActionMultipath) Len() uint16 {
+	return a.NXActionHeader.Len() + 22
+}
+
+func (a *NXActionMultipath) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, a.Len())
+	a.Length = a.Len()
+	b, err := a.NXActionHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	binary.BigEndian.PutUint16(data[n:], a.Fields)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], a.Basis)
+	n += 4
+	binary.BigEndian.PutUint16(data[n:], a.Algorithm)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], a.MaxLink)
+	n += 2
+	binary.BigEndian.PutUint32(data[n:], a.Arg)
+	n += 6
+	binary.BigEndian.PutUint16(data[n:], a.OfsNbits)
+	n += 2
+	binary.BigEndian.PutUint32(data[n:], a.DstField.MarshalHeader())
+	return data, nil
+}
+
+func (a *NXActionMultipath) UnmarshalBinary(data []byte) error {
+	a.NXActionHeader = new(NXActionHeader)
+	if err := a.NXActionHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if a.Length < a.Len() || len(data) < int(a.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full NXActionMultipath message")
+	}
+	n := int(a.NXActionHeader.Len())
+	a.Fields = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	a.Basis = binary.BigEndian.Uint16(data[n:])
+	n += 4
+	a.Algorithm = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	a.MaxLink = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	a.Arg = binary.BigEndian.Uint32(data[n:])
+	n += 6
+	a.OfsNbits = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	a.DstField = new(MatchField)
+	return a.DstField.UnmarshalHeader(data[n:])
+}
+
+// NXActionBundle is NX action to select one of the slave ports with the algorithm, and the hash of the fields
+// of the packet with the basis. NXAST_BUNDLE outputs the packet to the selected port, the action in flow entry
+// is like bundle(eth_src,0,hrw,ofport,members:1,2). NXAST_BUNDLE_LOAD stores the selected port in the bits of
+// the dst field, the action in flow entry is like bundle_load(eth_src,0,hrw,ofport,NXM_NX_REG0[],members:1,2).
+type NXActionBundle struct {
+	*NXActionHeader
+	Algorithm uint16 // One of NX_BD_ALG_*
+	Fields    uint16 // One of NX_HASH_FIELDS_*
+	Basis     uint16
+	SlaveType uint32 // The header of NXM_OF_IN_PORT, the only slave type
+	NSlaves   uint16
+	OfsNbits  uint16
+	DstField  *MatchField // nil for NXAST_BUNDLE
+	pad       [4]byte
+	Slaves    []uint16
+}
+
+func newNXActionBundle(subtype uint16, algorithm, fields, basis uint16, slaves []uint16) *NXActionBundle {
+	inPort, _ := FindFieldHeaderByName("NXM_OF_IN_PORT", false)
+	a := new(NXActionBundle)
+	a.NXActionHeader = NewNxActionHeader(subtype)
+	a.Algorithm = algorithm
+	a.Fields = fields
+	a.Basis = basis
+	a.SlaveType = inPort.MarshalHeader()
+	a.Slaves = slaves
+	a.NSlaves = uint16(len(slaves))
+	return a
+}
+
+// NewNXActionBundle returns the bundle action which outputs the packet to one of the slave ports.
+func NewNXActionBundle(algorithm, fields, basis uint16, slaves ...uint16) *NXActionBundle {
+	a := newNXActionBundle(NXAST_BUNDLE, algorithm, fields, basis, slaves)
+	a.Length = a.Len()
+	return a
+}
+
+// NewNXActionBundleLoad returns the bundle_load action which stores one of the slave ports in the bits of the dst
+// field.
+func NewNXActionBundleLoad(algorithm, fields, basis uint16, ofsNbits uint16, dstField *MatchField, slaves ...uint16) *NXActionBundle {
+	a := newNXActionBundle(NXAST_BUNDLE_LOAD, algorithm, fields, basis, slaves)
+	a.OfsNbits = ofsNbits
+	a.DstField = dstField
+	a.Length = a.Len()
+	return a
+}
+
+func (a *NXActionBundle) Len() uint16 {
+	length := a.NXActionHeader.Len() + 22 + 2*uint16(len(a.Slaves))
+	return 8 * ((length + 7) / 8)
+}
+
+func (a *NXActionBundle) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, a.Len())
+	a.Length = a.Len()
+	a.NSlaves = uint16(len(a.Slaves))
+	b, err := a.NXActionHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	binary.BigEndian.PutUint16(data[n:], a.Algorithm)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], a.Fields)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], a.Basis)
+	n += 2
+	binary.BigEndian.PutUint32(data[n:], a.SlaveType)
+	n += 4
+	binary.BigEndian.PutUint16(data[n:], a.NSlaves)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], a.OfsNbits)
+	n += 2
+	if a.DstField != nil {
+		binary.BigEndian.PutUint32(data[n:], a.DstField.MarshalHeader())
+	}
+	n += 8
+	for _, slave := range a.Slaves {
+		binary.BigEndian.PutUint16(data[n:], slave)
+		n += 2
+	}
+	return data, nil
+}
+
+func (a *NXActionBundle) UnmarshalBinary(data []byte) error {
+	a.NXActionHeader = new(NXActionHeader)
+	if err := a.NXActionHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if a.Length < 32 || len(data) < int(a.Length) {
+		return errors.New("the []byte is too short to unmarshal a full NXActionBundle message")
+	}
+	n := int(a.NXActionHeader.Len())
+	a.Algorithm = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	a.Fields = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	a.Basis = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	a.SlaveType = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	a.NSlaves = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	a.OfsNbits = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	a.DstField = nil
+	if dst := binary.BigEndian.Uint32(data[n:]); dst != 0 {
+		a.DstField = new(MatchField)
+		if err := a.DstField.UnmarshalHeader(data[n:]); err != nil {
+			return err
+		}
+	}
+	n += 8
+	if n+2*int(a.NSlaves) > int(a.Length) {
+		return errors.New("the []byte is too short to unmarshal the slaves of NXActionBundle")
+	}
+	a.Slaves = make([]uint16, a.NSlaves)
+	for i := range a.Slaves {
+		a.Slaves[i] = binary.BigEndian.Uint16(data[n:])
+		n += 2
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package openflow13
+
+// Nicira extension error codes, which are the exp_type of the experimenter errors of NxExperimenterID since
+// OpenFlow 1.2, the NX1.2+ codes of lib/ofp-errors.h of Open vSwitch. The codes of the TLV table mod errors are OFPERR_NXTTMFC_*.
+const (
+	OFPERR_NXBRC_NXM_INVALID       = 2  /* Invalid NXM flow match. */
+	OFPERR_NXBRC_NXM_BAD_TYPE      = 3  /* Invalid or unimplemented nxm_type, nxm_hasmask or nxm_length. */
+	OFPERR_NXBRC_MUST_BE_ZERO      = 4  /* Must-be-zero field had nonzero value. */
+	OFPERR_NXBRC_BAD_REASON        = 5  /* The reason in an ofp_port_status message is not valid. */
+	OFPERR_NXBRC_FM_BAD_EVENT      = 6  /* The event in a flow monitor reply is not valid. */
+	OFPERR_NXBRC_UNENCODABLE_ERROR = 7  /* The error can't be represented in this OpenFlow version. */
+	OFPERR_NXBAC_MUST_BE_ZERO      = 11 /* Must-be-zero action argument had nonzero value. */
+	OFPERR_NXBAC_BAD_CONJUNCTION   = 15 /* Conjunction action is not the only action, or its k/n is invalid. */
+
+	// OFPERR_NXTTMFC_BAD_FIELD_IDX is ERR_NXTTMFC_BAD_FIELD_IDX with the prefix of the other codes.
+	OFPERR_NXTTMFC_BAD_FIELD_IDX = ERR_NXTTMFC_BAD_FIELD_IDX
+)
+
+// nxErrors is the name and the description of the Nicira extension error codes.
+var nxErrors = map[uint16]struct {
+	name        string
+	description string
+}{
+	OFPERR_NXBRC_NXM_INVALID:       {"OFPERR_NXBRC_NXM_INVALID", "invalid NXM flow match"},
+	OFPERR_NXBRC_NXM_BAD_TYPE:      {"OFPERR_NXBRC_NXM_BAD_TYPE", "invalid or unimplemented NXM type"},
+	OFPERR_NXBRC_MUST_BE_ZERO:      {"OFPERR_NXBRC_MUST_BE_ZERO", "must-be-zero field had nonzero value"},
+	OFPERR_NXBRC_BAD_REASON:        {"OFPERR_NXBRC_BAD_REASON", "invalid reason in port status"},
+	OFPERR_NXBRC_FM_BAD_EVENT:      {"OFPERR_NXBRC_FM_BAD_EVENT", "invalid flow monitor event"},
+	OFPERR_NXBRC_UNENCODABLE_ERROR: {"OFPERR_NXBRC_UNENCODABLE_ERROR", "error not representable in this OpenFlow version"},
+	OFPERR_NXBAC_MUST_BE_ZERO:      {"OFPERR_NXBAC_MUST_BE_ZERO", "must-be-zero action argument had nonzero value"},
+	OFPERR_NXBAC_BAD_CONJUNCTION:   {"OFPERR_NXBAC_BAD_CONJUNCTION", "conjunction action not alone or with invalid k/n"},
+	OFPERR_NXTTMFC_BAD_COMMAND:     {"OFPERR_NXTTMFC_BAD_COMMAND", "invalid TLV table mod command"},
+	OFPERR_NXTTMFC_BAD_OPT_LEN:     {"OFPERR_NXTTMFC_BAD_OPT_LEN", "invalid TLV option length"},
+	OFPERR_NXTTMFC_BAD_FIELD_IDX:   {"OFPERR_NXTTMFC_BAD_FIELD_IDX", "invalid tun_metadata field index"},
+	OFPERR_NXTTMFC_TABLE_FULL:      {"OFPERR_NXTTMFC_TABLE_FULL", "TLV table is full"},
+	OFPERR_NXTTMFC_ALREADY_MAPPED:  {"OFPERR_NXTTMFC_ALREADY_MAPPED", "tun_metadata field is already mapped"},
+	OFPERR_NXTTMFC_DUP_ENTRY:       {"OFPERR_NXTTMFC_DUP_ENTRY", "TLV option is mapped more than once"},
+	OFPERR_NXTTMFC_INVALID_TLV_DEL: {"OFPERR_NXTTMFC_INVALID_TLV_DEL", "deleted TLV mapping is in use"},
+}
+
+// LookupNXError returns the name and the description of the Nicira extension error of the error type and code,
+// i.e., ET_EXPERIMENTER and the exp_type of an experimenter error of NxExperimenterID. ok is false if the error is
+// not a known Nicira extension error.
+func LookupNXError(errType, code uint16) (name, description string, ok bool) {
+	if errType != ET_EXPERIMENTER {
+		return "", "", false
+	}
+	info, ok := nxErrors[code]
+	return info.name, info.description, ok
+}
+
+// NewNXError returns the experimenter error of NxExperimenterID with the Nicira extension error code, the data is
+// the beginning of the failed request.
+func NewNXError(code uint16, data []byte) *VendorError {
+	return NewVendorError(NxExperimenterID, code, data)
+}
@@ -0,0 +1,72 @@
+package openflow13
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNXError(t *testing.T) {
+	nxErr := NewNXError(OFPERR_NXTTMFC_ALREADY_MAPPED, []byte{1, 2, 3, 4})
+	data, err := nxErr.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal VendorError: %v", err)
+	}
+	msg, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse VendorError: %v", err)
+	}
+	vendorErr, ok := msg.(*VendorError)
+	if !ok || vendorErr.ExperimenterID != NxExperimenterID || vendorErr.Code != OFPERR_NXTTMFC_ALREADY_MAPPED ||
+		!bytes.Equal(vendorErr.Data.Bytes(), []byte{1, 2, 3, 4}) || vendorErr.Header.Length != uint16(len(data)) {
+		t.Fatalf("Unexpected VendorError: %+v", msg)
+	}
+	if name, _, ok := vendorErr.NXError(); !ok || name != "OFPERR_NXTTMFC_ALREADY_MAPPED" {
+		t.Errorf("Unexpected NX error name %s", name)
+	}
+
+	if _, _, ok = NewBundleError().NXError(); ok {
+		t.Errorf("Expect the bundle error not a NX error")
+	}
+	if _, _, ok = LookupNXError(ET_BAD_REQUEST, OFPERR_NXBRC_NXM_INVALID); ok {
+		t.Errorf("Expect the NX error only of ET_EXPERIMENTER")
+	}
+	if name, description, ok := LookupNXError(ET_EXPERIMENTER, OFPERR_NXBAC_BAD_CONJUNCTION); !ok ||
+		name != "OFPERR_NXBAC_BAD_CONJUNCTION" || description == "" {
+		t.Errorf("Unexpected NX error %s: %s", name, description)
+	}
+}
+
+func TestNXErrorCodes(t *testing.T) {
+	// The NX1.2+ codes of lib/ofp-errors.h of Open vSwitch.
+	for code, name := range map[uint16]string{
+		2:  "OFPERR_NXBRC_NXM_INVALID",
+		3:  "OFPERR_NXBRC_NXM_BAD_TYPE",
+		4:  "OFPERR_NXBRC_MUST_BE_ZERO",
+		5:  "OFPERR_NXBRC_BAD_REASON",
+		6:  "OFPERR_NXBRC_FM_BAD_EVENT",
+		7:  "OFPERR_NXBRC_UNENCODABLE_ERROR",
+		11: "OFPERR_NXBAC_MUST_BE_ZERO",
+		15: "OFPERR_NXBAC_BAD_CONJUNCTION",
+		16: "OFPERR_NXTTMFC_BAD_COMMAND",
+		17: "OFPERR_NXTTMFC_BAD_OPT_LEN",
+		18: "OFPERR_NXTTMFC_BAD_FIELD_IDX",
+		19: "OFPERR_NXTTMFC_TABLE_FULL",
+		20: "OFPERR_NXTTMFC_ALREADY_MAPPED",
+		21: "OFPERR_NXTTMFC_DUP_ENTRY",
+		38: "OFPERR_NXTTMFC_INVALID_TLV_DEL",
+	} {
+		// OFPT_ERROR of OFPET_EXPERIMENTER with the exp_type and NX_VENDOR_ID on the wire.
+		data := []byte{0x04, 0x01, 0x00, 0x10, 0x00, 0x00, 0x00, 0x01, 0xff, 0xff, 0x00, byte(code), 0x00, 0x00, 0x23, 0x20}
+		msg, err := Parse(data)
+		if err != nil {
+			t.Fatalf("Failed to parse the NX error %d: %v", code, err)
+		}
+		vendorErr, ok := msg.(*VendorError)
+		if !ok {
+			t.Fatalf("Unexpected NX error message %T", msg)
+		}
+		if actual, _, ok := vendorErr.NXError(); !ok || actual != name {
+			t.Errorf("Expect NX error %d %s, actual: %s", code, name, actual)
+		}
+	}
+}
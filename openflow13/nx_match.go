@@ -5,8 +5,36 @@ import (
 	"errors"
 	"fmt"
 	"net"
+
+	"github.com/contiv/libOpenflow/util"
 )
 
+type Uint8Message struct {
+	Data uint8
+}
+
+func newUint8Message(data uint8) *Uint8Message {
+	return &Uint8Message{Data: data}
+}
+
+func (m *Uint8Message) Len() uint16 {
+	return 1
+}
+
+func (m *Uint8Message) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, m.Len())
+	data[0] = m.Data
+	return
+}
+
+func (m *Uint8Message) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 {
+		return errors.New("the []byte is too short to unmarshal a full Uint8Message")
+	}
+	m.Data = data[0]
+	return nil
+}
+
 type Uint16Message struct {
 	Data uint16
 }
@@ -59,6 +87,32 @@ func (m *Uint32Message) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
+type Uint64Message struct {
+	Data uint64
+}
+
+func newUint64Message(data uint64) *Uint64Message {
+	return &Uint64Message{Data: data}
+}
+
+func (m *Uint64Message) Len() uint16 {
+	return 8
+}
+
+func (m *Uint64Message) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, m.Len())
+	binary.BigEndian.PutUint64(data, m.Data)
+	return
+}
+
+func (m *Uint64Message) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("the []byte is too short to unmarshal a full Uint64Message")
+	}
+	m.Data = binary.BigEndian.Uint64(data[:8])
+	return nil
+}
+
 type ByteArrayField struct {
 	Data   []byte
 	Length uint8
@@ -212,9 +266,8 @@ func NewRegMatchField(idx int, data uint32, dataRng *NXRange) *MatchField {
 }
 
 func newNXTunMetadataHeader(idx int, hasMask bool) *MatchField {
-	idKey := fmt.Sprintf("NXM_NX_TUN_METADATA%d", idx)
-	header, _ := FindFieldHeaderByName(idKey, hasMask)
-	return header
+	// The field table has only tun_metadata0-7, the length is set by the caller with the data.
+	return &MatchField{Class: OXM_CLASS_NXM_1, Field: NXM_NX_TUN_METADATA0 + uint8(idx), HasMask: hasMask}
 }
 
 func NewTunMetadataField(idx int, data []byte, mask []byte) *MatchField {
@@ -304,6 +357,73 @@ func NewCTLabelMatchField(label [16]byte, mask *[16]byte) *MatchField {
 	return field
 }
 
+// NewCTNwProtoMatchField returns a MatchField for ct_nw_proto, the protocol of the original direction tuple.
+func NewCTNwProtoMatchField(protocol uint8) *MatchField {
+	field, _ := FindFieldHeaderByName("NXM_NX_CT_NW_PROTO", false)
+	field.Value = &IpProtoField{protocol: protocol}
+	return field
+}
+
+// NewCTNwSrcMatchField returns a MatchField for ct_nw_src, the source IPv4 address of the original direction tuple.
+func NewCTNwSrcMatchField(addr net.IP, mask net.IP) *MatchField {
+	field, _ := FindFieldHeaderByName("NXM_NX_CT_NW_SRC", mask != nil)
+	field.Value = &Ipv4SrcField{Ipv4Src: addr}
+	if mask != nil {
+		field.Mask = &Ipv4SrcField{Ipv4Src: mask}
+	}
+	return field
+}
+
+// NewCTNwDstMatchField returns a MatchField for ct_nw_dst, the destination IPv4 address of the original direction tuple.
+func NewCTNwDstMatchField(addr net.IP, mask net.IP) *MatchField {
+	field, _ := FindFieldHeaderByName("NXM_NX_CT_NW_DST", mask != nil)
+	field.Value = &Ipv4DstField{Ipv4Dst: addr}
+	if mask != nil {
+		field.Mask = &Ipv4DstField{Ipv4Dst: mask}
+	}
+	return field
+}
+
+// NewCTIPv6SrcMatchField returns a MatchField for ct_ipv6_src, the source IPv6 address of the original direction tuple.
+func NewCTIPv6SrcMatchField(addr net.IP, mask net.IP) *MatchField {
+	field, _ := FindFieldHeaderByName("NXM_NX_CT_IPV6_SRC", mask != nil)
+	field.Value = &Ipv6SrcField{Ipv6Src: addr}
+	if mask != nil {
+		field.Mask = &Ipv6SrcField{Ipv6Src: mask}
+	}
+	return field
+}
+
+// NewCTIPv6DstMatchField returns a MatchField for ct_ipv6_dst, the destination IPv6 address of the original direction tuple.
+func NewCTIPv6DstMatchField(addr net.IP, mask net.IP) *MatchField {
+	field, _ := FindFieldHeaderByName("NXM_NX_CT_IPV6_DST", mask != nil)
+	field.Value = &Ipv6DstField{Ipv6Dst: addr}
+	if mask != nil {
+		field.Mask = &Ipv6DstField{Ipv6Dst: mask}
+	}
+	return field
+}
+
+// NewCTTpSrcMatchField returns a MatchField for ct_tp_src, the transport source port of the original direction tuple.
+func NewCTTpSrcMatchField(port uint16, mask *uint16) *MatchField {
+	field, _ := FindFieldHeaderByName("NXM_NX_CT_TP_SRC", mask != nil)
+	field.Value = NewPortField(port)
+	if mask != nil {
+		field.Mask = NewPortField(*mask)
+	}
+	return field
+}
+
+// NewCTTpDstMatchField returns a MatchField for ct_tp_dst, the transport destination port of the original direction tuple.
+func NewCTTpDstMatchField(port uint16, mask *uint16) *MatchField {
+	field, _ := FindFieldHeaderByName("NXM_NX_CT_TP_DST", mask != nil)
+	field.Value = NewPortField(port)
+	if mask != nil {
+		field.Mask = NewPortField(*mask)
+	}
+	return field
+}
+
 func NewConjIDMatchField(conjID uint32) *MatchField {
 	field, _ := FindFieldHeaderByName("NXM_NX_CONJ_ID", false)
 	field.Value = newUint32Message(conjID)
@@ -358,3 +478,121 @@ func NewNxARPTpaMatchField(addr net.IP, mask net.IP) *MatchField {
 
 	return field
 }
+
+// DecodeNSHMatchField decodes the value or mask of a NSH field. The experimenter ID should have been consumed
+// from data by the caller.
+func DecodeNSHMatchField(class uint16, field uint8, length uint8, hasMask bool, data []byte) (util.Message, error) {
+	var val util.Message
+	switch field {
+	case NXOXM_NSH_FLAGS, NXOXM_NSH_MDTYPE, NXOXM_NSH_NP, NXOXM_NSH_SI, NXOXM_NSH_TTL:
+		val = new(Uint8Message)
+	case NXOXM_NSH_SPI, NXOXM_NSH_C1, NXOXM_NSH_C2, NXOXM_NSH_C3, NXOXM_NSH_C4:
+		val = new(Uint32Message)
+	default:
+		return nil, fmt.Errorf("unsupported NSH field: %d in class: %d", field, class)
+	}
+	return unmarshalFieldValue(val, class, field, data)
+}
+
+// DecodeNXOXMMatchField decodes the value or mask of a Nicira extension field in the experimenter class. The
+// experimenter ID should have been consumed from data by the caller.
+func DecodeNXOXMMatchField(class uint16, field uint8, length uint8, hasMask bool, data []byte) (util.Message, error) {
+	var val util.Message
+	switch field {
+	case NXOXM_ET_ERSPAN_VER, NXOXM_ET_ERSPAN_DIR, NXOXM_ET_ERSPAN_HWID:
+		val = new(Uint8Message)
+	case NXOXM_ET_ERSPAN_IDX:
+		val = new(Uint32Message)
+	default:
+		return nil, fmt.Errorf("unsupported Nicira experimenter field: %d in class: %d", field, class)
+	}
+	return unmarshalFieldValue(val, class, field, data)
+}
+
+func newUint8MatchField(fieldName string, data uint8, mask *uint8) *MatchField {
+	field, _ := FindFieldHeaderByName(fieldName, mask != nil)
+	field.Value = newUint8Message(data)
+	if mask != nil {
+		field.Mask = newUint8Message(*mask)
+	}
+	return field
+}
+
+func newUint16MatchField(fieldName string, data uint16, mask *uint16) *MatchField {
+	field, _ := FindFieldHeaderByName(fieldName, mask != nil)
+	field.Value = newUint16Message(data)
+	if mask != nil {
+		field.Mask = newUint16Message(*mask)
+	}
+	return field
+}
+
+func newUint32MatchField(fieldName string, data uint32, mask *uint32) *MatchField {
+	field, _ := FindFieldHeaderByName(fieldName, mask != nil)
+	field.Value = newUint32Message(data)
+	if mask != nil {
+		field.Mask = newUint32Message(*mask)
+	}
+	return field
+}
+
+// NewNSHFlagsMatchField returns a MatchField for nsh_flags. It could also be used in set_field action.
+func NewNSHFlagsMatchField(flags uint8, mask *uint8) *MatchField {
+	return newUint8MatchField("NXOXM_NSH_FLAGS", flags, mask)
+}
+
+// NewNSHMdTypeMatchField returns a MatchField for nsh_mdtype.
+func NewNSHMdTypeMatchField(mdType uint8) *MatchField {
+	return newUint8MatchField("NXOXM_NSH_MDTYPE", mdType, nil)
+}
+
+// NewNSHNextProtoMatchField returns a MatchField for nsh_np.
+func NewNSHNextProtoMatchField(np uint8) *MatchField {
+	return newUint8MatchField("NXOXM_NSH_NP", np, nil)
+}
+
+// NewNSHSpiMatchField returns a MatchField for nsh_spi, only the least 24 bits of spi are used.
+func NewNSHSpiMatchField(spi uint32) *MatchField {
+	return newUint32MatchField("NXOXM_NSH_SPI", spi&0xffffff, nil)
+}
+
+// NewNSHSiMatchField returns a MatchField for nsh_si.
+func NewNSHSiMatchField(si uint8) *MatchField {
+	return newUint8MatchField("NXOXM_NSH_SI", si, nil)
+}
+
+// NewNSHTTLMatchField returns a MatchField for nsh_ttl.
+func NewNSHTTLMatchField(ttl uint8) *MatchField {
+	return newUint8MatchField("NXOXM_NSH_TTL", ttl, nil)
+}
+
+// NewNSHContextMatchField returns a MatchField for the MD type 1 context header nsh_c<idx>, idx is in range [1, 4].
+func NewNSHContextMatchField(idx int, data uint32, mask *uint32) *MatchField {
+	return newUint32MatchField(fmt.Sprintf("NXOXM_NSH_C%d", idx), data, mask)
+}
+
+// NewTunFlagsMatchField returns a MatchField for tun_flags, e.g., use NX_TUN_FLAG_OAM as both flags and mask to
+// match OAM frames.
+func NewTunFlagsMatchField(flags uint16, mask *uint16) *MatchField {
+	return newUint16MatchField("NXM_NX_TUN_FLAGS", flags, mask)
+}
+
+// NewTunErspanVerMatchField returns a MatchField for tun_erspan_ver.
+func NewTunErspanVerMatchField(ver uint8, mask *uint8) *MatchField {
+	return newUint8MatchField("NXOXM_ET_ERSPAN_VER", ver, mask)
+}
+
+// NewTunErspanIdxMatchField returns a MatchField for tun_erspan_idx, only the least 20 bits of idx are used.
+func NewTunErspanIdxMatchField(idx uint32, mask *uint32) *MatchField {
+	return newUint32MatchField("NXOXM_ET_ERSPAN_IDX", idx&0xfffff, mask)
+}
+
+// NewTunErspanDirMatchField returns a MatchField for tun_erspan_dir.
+func NewTunErspanDirMatchField(dir uint8, mask *uint8) *MatchField {
+	return newUint8MatchField("NXOXM_ET_ERSPAN_DIR", dir, mask)
+}
+
+// NewTunErspanHwIDMatchField returns a MatchField for tun_erspan_hwid.
+func NewTunErspanHwIDMatchField(hwID uint8, mask *uint8) *MatchField {
+	return newUint8MatchField("NXOXM_ET_ERSPAN_HWID", hwID, mask)
+}
@@ -0,0 +1,702 @@
+package openflow13
+
+// This file has the Nicira vendor statistics, i.e., the bodies of the OFPMP_EXPERIMENTER multipart messages with
+// NxExperimenterID. They are sent with RequestMultipart(ctx, conn, MultipartType_Experimenter, body), and the
+// reply bodies are decoded into the typed bodies as they are registered with RegisterExperimenterMultipartDecoder.
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+// Nicira vendor statistics subtypes, the ExpType of the experimenter multipart header.
+const (
+	NXST_FLOW         = 0 /* nx_flow_stats_request, reply of nx_flow_stats. */
+	NXST_AGGREGATE    = 1 /* nx_aggregate_stats_request, reply of ofp_aggregate_stats_reply. */
+	NXST_FLOW_MONITOR = 2 /* nx_flow_monitor_request, reply of nx_flow_update_header. */
+)
+
+// OFPP10_NONE is the OpenFlow 1.0 port number of no port, the Nicira statistics use the 16-bit port numbers.
+const OFPP10_NONE = 0xffff
+
+// nx_flow_monitor_flags
+const (
+	NXFMF_INITIAL = 1 << 0 /* Initially matching flows. */
+	NXFMF_ADD     = 1 << 1 /* New matching flows as they are added. */
+	NXFMF_DELETE  = 1 << 2 /* Old matching flows as they are removed. */
+	NXFMF_MODIFY  = 1 << 3 /* Matching flows as they are changed. */
+	NXFMF_ACTIONS = 1 << 4 /* If set, actions are included. */
+	NXFMF_OWN     = 1 << 5 /* If set, include own changes in full. */
+)
+
+// nx_flow_update_event
+const (
+	NXFME_ADDED    = 0 /* Flow was added. */
+	NXFME_DELETED  = 1 /* Flow was deleted. */
+	NXFME_MODIFIED = 2 /* Flow (generally its actions) was changed. */
+	NXFME_ABBREV   = 3 /* Abbreviated reply. */
+)
+
+func init() {
+	RegisterExperimenterMultipartDecoder(NxExperimenterID, NXST_FLOW, func(reply bool) util.Message {
+		if reply {
+			return new(NXFlowStatsReply)
+		}
+		return new(NXFlowStatsRequest)
+	})
+	RegisterExperimenterMultipartDecoder(NxExperimenterID, NXST_AGGREGATE, func(reply bool) util.Message {
+		if reply {
+			return new(NXAggregateStatsReply)
+		}
+		return new(NXFlowStatsRequest)
+	})
+	RegisterExperimenterMultipartDecoder(NxExperimenterID, NXST_FLOW_MONITOR, func(reply bool) util.Message {
+		if reply {
+			return new(NXFlowMonitorReply)
+		}
+		return new(NXFlowMonitorRequest)
+	})
+}
+
+// nxMatchLen returns the length of the nx_match of the match fields, i.e., the fields without the ofp_match
+// header and the padding.
+func nxMatchLen(m *Match) (n uint16) {
+	for i := range m.Fields {
+		n += m.Fields[i].Len()
+	}
+	return
+}
+
+// paddedNXMatchLen returns the length of the nx_match padded to a multiple of 8 bytes.
+func paddedNXMatchLen(m *Match) uint16 {
+	return (nxMatchLen(m) + 7) / 8 * 8
+}
+
+// marshalNXMatch returns the nx_match of the match fields padded to a multiple of 8 bytes.
+func marshalNXMatch(m *Match) (data []byte, err error) {
+	data = make([]byte, 0, paddedNXMatchLen(m))
+	for i := range m.Fields {
+		b, err := m.Fields[i].MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return data[:cap(data)], nil
+}
+
+// unmarshalNXMatch decodes the nx_match of matchLen bytes at the start of data into m, the padding is not
+// consumed.
+func unmarshalNXMatch(data []byte, matchLen int, m *Match) error {
+	if len(data) < matchLen {
+		return errors.New("the []byte is too short to unmarshal a full nx_match")
+	}
+	*m = *NewMatch()
+	n := 0
+	for n < matchLen {
+		if err := checkBounds(data[:matchLen], n, minElemLen, "nx_match", "match field"); err != nil {
+			return err
+		}
+		field := new(MatchField)
+		if err := field.UnmarshalBinary(data[n:matchLen]); err != nil {
+			return decodeErrorAt(err, "nx_match", n, "match field")
+		}
+		m.AddField(*field)
+		var err error
+		if n, err = safeAdvance(n, field.Len(), matchLen, "nx_match field"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeNXActions decodes the actions of data.
+func decodeNXActions(data []byte) ([]Action, error) {
+	var actions []Action
+	n := 0
+	for n < len(data) {
+		act, err := DecodeAction(data[n:])
+		if err != nil {
+			return nil, decodeErrorAt(err, "actions", n, "action")
+		}
+		actions = append(actions, act)
+		if n, err = safeAdvance(n, act.Len(), len(data), "action"); err != nil {
+			return nil, err
+		}
+	}
+	return actions, nil
+}
+
+// NXFlowStatsRequest is the body of the NXST_FLOW and NXST_AGGREGATE requests, the nx_flow_stats_request and
+// nx_aggregate_stats_request which have the same layout.
+type NXFlowStatsRequest struct {
+	ExperimenterMultipartHeader
+	OutPort uint16 /* Require matching entries to include this as an output port, OFPP10_NONE for any. */
+	TableID uint8  /* ID of table to read, 0xff for all tables. */
+	Match   Match  /* Fields to match, encoded as nx_match. */
+}
+
+// NewNXFlowStatsRequest returns the NXST_FLOW request of the flows in the table, OFPTT_ALL for all tables.
+func NewNXFlowStatsRequest(tableID uint8) *NXFlowStatsRequest {
+	return newNXFlowStatsRequest(NXST_FLOW, tableID)
+}
+
+// NewNXAggregateStatsRequest returns the NXST_AGGREGATE request of the flows in the table, OFPTT_ALL for all
+// tables.
+func NewNXAggregateStatsRequest(tableID uint8) *NXFlowStatsRequest {
+	return newNXFlowStatsRequest(NXST_AGGREGATE, tableID)
+}
+
+func newNXFlowStatsRequest(expType uint32, tableID uint8) *NXFlowStatsRequest {
+	r := new(NXFlowStatsRequest)
+	r.Experimenter = NxExperimenterID
+	r.ExpType = expType
+	r.OutPort = OFPP10_NONE
+	r.TableID = tableID
+	r.Match = *NewMatch()
+	return r
+}
+
+func (r *NXFlowStatsRequest) Len() (n uint16) {
+	return r.ExperimenterMultipartHeader.Len() + 8 + paddedNXMatchLen(&r.Match)
+}
+
+func (r *NXFlowStatsRequest) MarshalBinary() (data []byte, err error) {
+	data, err = r.ExperimenterMultipartHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 8)
+	n := 0
+	binary.BigEndian.PutUint16(b[n:], r.OutPort)
+	n += 2
+	binary.BigEndian.PutUint16(b[n:], nxMatchLen(&r.Match))
+	n += 2
+	b[n] = r.TableID
+	data = append(data, b...)
+	b, err = marshalNXMatch(&r.Match)
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, b...)
+	return
+}
+
+func (r *NXFlowStatsRequest) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("the []byte is too short to unmarshal a full NXFlowStatsRequest message")
+	}
+	r.ExperimenterMultipartHeader.UnmarshalBinary(data)
+	n := int(r.ExperimenterMultipartHeader.Len())
+	r.OutPort = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	matchLen := int(binary.BigEndian.Uint16(data[n:]))
+	n += 2
+	r.TableID = data[n]
+	n += 4 // for table_id and padding
+	return withOffset(unmarshalNXMatch(data[n:], matchLen, &r.Match), n)
+}
+
+// NXFlowStats is the nx_flow_stats, the statistics of a flow in the NXST_FLOW reply. Unlike FlowStats, it has
+// the idle and hard ages of the flow, and the actions of the flow instead of the instructions.
+type NXFlowStats struct {
+	Length       uint16 /* Length of this entry. */
+	TableID      uint8  /* ID of table flow came from. */
+	DurationSec  uint32 /* Time flow has been alive in seconds. */
+	DurationNSec uint32 /* Time flow has been alive in nanoseconds beyond duration_sec. */
+	Priority     uint16 /* Priority of the entry. */
+	IdleTimeout  uint16 /* Number of seconds idle before expiration. */
+	HardTimeout  uint16 /* Number of seconds before expiration. */
+	IdleAge      uint16 /* Seconds since last packet, plus one, zero if unknown. */
+	HardAge      uint16 /* Seconds since last modification, plus one, zero if unknown. */
+	Cookie       uint64 /* Opaque controller-issued identifier. */
+	PacketCount  uint64 /* Number of packets, UINT64_MAX if unknown. */
+	ByteCount    uint64 /* Number of bytes, UINT64_MAX if unknown. */
+	Match        Match  /* Description of fields, encoded as nx_match. */
+	Actions      []Action
+}
+
+func NewNXFlowStats() *NXFlowStats {
+	s := new(NXFlowStats)
+	s.Match = *NewMatch()
+	s.Length = s.Len()
+	return s
+}
+
+func (s *NXFlowStats) Len() (n uint16) {
+	n = 48 + paddedNXMatchLen(&s.Match)
+	for _, act := range s.Actions {
+		n += act.Len()
+	}
+	return
+}
+
+func (s *NXFlowStats) MarshalBinary() (data []byte, err error) {
+	s.Length = s.Len()
+	data = make([]byte, 48)
+	n := 0
+	binary.BigEndian.PutUint16(data[n:], s.Length)
+	n += 2
+	data[n] = s.TableID
+	n += 2 // for table_id and padding
+	binary.BigEndian.PutUint32(data[n:], s.DurationSec)
+	n += 4
+	binary.BigEndian.PutUint32(data[n:], s.DurationNSec)
+	n += 4
+	binary.BigEndian.PutUint16(data[n:], s.Priority)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], s.IdleTimeout)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], s.HardTimeout)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], nxMatchLen(&s.Match))
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], s.IdleAge)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], s.HardAge)
+	n += 2
+	binary.BigEndian.PutUint64(data[n:], s.Cookie)
+	n += 8
+	binary.BigEndian.PutUint64(data[n:], s.PacketCount)
+	n += 8
+	binary.BigEndian.PutUint64(data[n:], s.ByteCount)
+
+	b, err := marshalNXMatch(&s.Match)
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, b...)
+	for _, act := range s.Actions {
+		b, err = act.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return
+}
+
+func (s *NXFlowStats) UnmarshalBinary(data []byte) error {
+	if len(data) < 48 {
+		return errors.New("the []byte is too short to unmarshal a full NXFlowStats message")
+	}
+	n := 0
+	s.Length = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	if s.Length < 48 || int(s.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full NXFlowStats message")
+	}
+	s.TableID = data[n]
+	n += 2 // for table_id and padding
+	s.DurationSec = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	s.DurationNSec = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	s.Priority = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	s.IdleTimeout = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	s.HardTimeout = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	matchLen := int(binary.BigEndian.Uint16(data[n:]))
+	n += 2
+	s.IdleAge = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	s.HardAge = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	s.Cookie = binary.BigEndian.Uint64(data[n:])
+	n += 8
+	s.PacketCount = binary.BigEndian.Uint64(data[n:])
+	n += 8
+	s.ByteCount = binary.BigEndian.Uint64(data[n:])
+	n += 8
+
+	if n+(matchLen+7)/8*8 > int(s.Length) {
+		return errors.New("the []byte is too short to unmarshal the nx_match of NXFlowStats")
+	}
+	if err := unmarshalNXMatch(data[n:s.Length], matchLen, &s.Match); err != nil {
+		return withOffset(err, n)
+	}
+	n += (matchLen + 7) / 8 * 8
+	var err error
+	s.Actions, err = decodeNXActions(data[n:s.Length])
+	return withOffset(err, n)
+}
+
+// NXFlowStatsReply is the body of the NXST_FLOW reply, the experimenter multipart header followed by the
+// statistics of the flows.
+type NXFlowStatsReply struct {
+	ExperimenterMultipartHeader
+	Flows []*NXFlowStats
+}
+
+func (r *NXFlowStatsReply) Len() (n uint16) {
+	n = r.ExperimenterMultipartHeader.Len()
+	for _, flow := range r.Flows {
+		n += flow.Len()
+	}
+	return
+}
+
+func (r *NXFlowStatsReply) MarshalBinary() (data []byte, err error) {
+	data, err = r.ExperimenterMultipartHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	for _, flow := range r.Flows {
+		b, err := flow.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return
+}
+
+func (r *NXFlowStatsReply) UnmarshalBinary(data []byte) error {
+	if err := r.ExperimenterMultipartHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	r.Flows = nil
+	n := int(r.ExperimenterMultipartHeader.Len())
+	for n < len(data) {
+		flow := new(NXFlowStats)
+		if err := flow.UnmarshalBinary(data[n:]); err != nil {
+			return decodeErrorAt(err, "NXFlowStatsReply", n, "flow")
+		}
+		r.Flows = append(r.Flows, flow)
+		var err error
+		if n, err = safeAdvance(n, flow.Length, len(data), "nx_flow_stats"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NXAggregateStatsReply is the body of the NXST_AGGREGATE reply, the experimenter multipart header followed by
+// the ofp_aggregate_stats_reply.
+type NXAggregateStatsReply struct {
+	ExperimenterMultipartHeader
+	PacketCount uint64
+	ByteCount   uint64
+	FlowCount   uint32
+	pad         [4]uint8
+}
+
+func (r *NXAggregateStatsReply) Len() (n uint16) {
+	return r.ExperimenterMultipartHeader.Len() + 24
+}
+
+func (r *NXAggregateStatsReply) MarshalBinary() (data []byte, err error) {
+	data, err = r.ExperimenterMultipartHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 24)
+	n := 0
+	binary.BigEndian.PutUint64(b[n:], r.PacketCount)
+	n += 8
+	binary.BigEndian.PutUint64(b[n:], r.ByteCount)
+	n += 8
+	binary.BigEndian.PutUint32(b[n:], r.FlowCount)
+	data = append(data, b...)
+	return
+}
+
+func (r *NXAggregateStatsReply) UnmarshalBinary(data []byte) error {
+	if len(data) < int(r.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full NXAggregateStatsReply message")
+	}
+	r.ExperimenterMultipartHeader.UnmarshalBinary(data)
+	n := int(r.ExperimenterMultipartHeader.Len())
+	r.PacketCount = binary.BigEndian.Uint64(data[n:])
+	n += 8
+	r.ByteCount = binary.BigEndian.Uint64(data[n:])
+	n += 8
+	r.FlowCount = binary.BigEndian.Uint32(data[n:])
+	return nil
+}
+
+// NXFlowMonitor is the nx_flow_monitor_request, a monitor of the flows in the NXST_FLOW_MONITOR request.
+type NXFlowMonitor struct {
+	ID      uint32 /* Controller-assigned ID for this monitor. */
+	Flags   uint16 /* NXFMF_*. */
+	OutPort uint16 /* Required output port, if not OFPP10_NONE. */
+	TableID uint8  /* One table's ID or 0xff for all tables. */
+	Match   Match  /* Fields to match, encoded as nx_match. */
+}
+
+// NewNXFlowMonitor returns the monitor of the flows in all the tables.
+func NewNXFlowMonitor(id uint32, flags uint16) *NXFlowMonitor {
+	m := new(NXFlowMonitor)
+	m.ID = id
+	m.Flags = flags
+	m.OutPort = OFPP10_NONE
+	m.TableID = OFPTT_ALL
+	m.Match = *NewMatch()
+	return m
+}
+
+func (m *NXFlowMonitor) Len() (n uint16) {
+	return 16 + paddedNXMatchLen(&m.Match)
+}
+
+func (m *NXFlowMonitor) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, 16)
+	n := 0
+	binary.BigEndian.PutUint32(data[n:], m.ID)
+	n += 4
+	binary.BigEndian.PutUint16(data[n:], m.Flags)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], m.OutPort)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], nxMatchLen(&m.Match))
+	n += 2
+	data[n] = m.TableID
+
+	b, err := marshalNXMatch(&m.Match)
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, b...)
+	return
+}
+
+func (m *NXFlowMonitor) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("the []byte is too short to unmarshal a full NXFlowMonitor message")
+	}
+	n := 0
+	m.ID = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	m.Flags = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	m.OutPort = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	matchLen := int(binary.BigEndian.Uint16(data[n:]))
+	n += 2
+	m.TableID = data[n]
+	n += 6 // for table_id and zeros
+	return withOffset(unmarshalNXMatch(data[n:], matchLen, &m.Match), n)
+}
+
+// NXFlowMonitorRequest is the body of the NXST_FLOW_MONITOR request, the experimenter multipart header followed
+// by the monitors.
+type NXFlowMonitorRequest struct {
+	ExperimenterMultipartHeader
+	Monitors []*NXFlowMonitor
+}
+
+func NewNXFlowMonitorRequest(monitors ...*NXFlowMonitor) *NXFlowMonitorRequest {
+	r := &NXFlowMonitorRequest{Monitors: monitors}
+	r.Experimenter = NxExperimenterID
+	r.ExpType = NXST_FLOW_MONITOR
+	return r
+}
+
+func (r *NXFlowMonitorRequest) Len() (n uint16) {
+	n = r.ExperimenterMultipartHeader.Len()
+	for _, m := range r.Monitors {
+		n += m.Len()
+	}
+	return
+}
+
+func (r *NXFlowMonitorRequest) MarshalBinary() (data []byte, err error) {
+	data, err = r.ExperimenterMultipartHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range r.Monitors {
+		b, err := m.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return
+}
+
+func (r *NXFlowMonitorRequest) UnmarshalBinary(data []byte) error {
+	if err := r.ExperimenterMultipartHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	r.Monitors = nil
+	n := int(r.ExperimenterMultipartHeader.Len())
+	for n < len(data) {
+		m := new(NXFlowMonitor)
+		if err := m.UnmarshalBinary(data[n:]); err != nil {
+			return decodeErrorAt(err, "NXFlowMonitorRequest", n, "monitor")
+		}
+		r.Monitors = append(r.Monitors, m)
+		var err error
+		if n, err = safeAdvance(n, m.Len(), len(data), "nx_flow_monitor_request"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NXFlowUpdateFull is the nx_flow_update_full, the flow update of NXFME_ADDED, NXFME_DELETED and
+// NXFME_MODIFIED. The updates of NXFME_ABBREV are decoded as FlowUpdateAbbrev, which has the same layout.
+type NXFlowUpdateFull struct {
+	FlowUpdateHeader
+	Reason      uint16 /* OFPRR_* for NXFME_DELETED, else zero. */
+	Priority    uint16 /* Priority of the entry. */
+	IdleTimeout uint16 /* Number of seconds idle before expiration. */
+	HardTimeout uint16 /* Number of seconds before expiration. */
+	TableID     uint8  /* ID of flow's table. */
+	Cookie      uint64 /* Opaque controller-issued identifier. */
+	Match       Match  /* Fields to match, encoded as nx_match. */
+	Actions     []Action
+}
+
+func NewNXFlowUpdateFull(event uint16) *NXFlowUpdateFull {
+	u := new(NXFlowUpdateFull)
+	u.Event = event
+	u.Match = *NewMatch()
+	u.Length = u.Len()
+	return u
+}
+
+func (u *NXFlowUpdateFull) Len() (n uint16) {
+	n = 24 + paddedNXMatchLen(&u.Match)
+	for _, act := range u.Actions {
+		n += act.Len()
+	}
+	return
+}
+
+func (u *NXFlowUpdateFull) MarshalBinary() (data []byte, err error) {
+	u.Length = u.Len()
+	data = make([]byte, 24)
+	b, err := u.FlowUpdateHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	binary.BigEndian.PutUint16(data[n:], u.Reason)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], u.Priority)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], u.IdleTimeout)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], u.HardTimeout)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], nxMatchLen(&u.Match))
+	n += 2
+	data[n] = u.TableID
+	n += 2 // for table_id and padding
+	binary.BigEndian.PutUint64(data[n:], u.Cookie)
+
+	b, err = marshalNXMatch(&u.Match)
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, b...)
+	for _, act := range u.Actions {
+		b, err = act.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return
+}
+
+func (u *NXFlowUpdateFull) UnmarshalBinary(data []byte) error {
+	if err := u.FlowUpdateHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if u.Length < 24 {
+		return errors.New("the []byte is too short to unmarshal a full NXFlowUpdateFull message")
+	}
+	n := int(u.FlowUpdateHeader.Len())
+	u.Reason = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	u.Priority = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	u.IdleTimeout = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	u.HardTimeout = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	matchLen := int(binary.BigEndian.Uint16(data[n:]))
+	n += 2
+	u.TableID = data[n]
+	n += 2 // for table_id and padding
+	u.Cookie = binary.BigEndian.Uint64(data[n:])
+	n += 8
+
+	if n+(matchLen+7)/8*8 > int(u.Length) {
+		return errors.New("the []byte is too short to unmarshal the nx_match of NXFlowUpdateFull")
+	}
+	if err := unmarshalNXMatch(data[n:u.Length], matchLen, &u.Match); err != nil {
+		return withOffset(err, n)
+	}
+	n += (matchLen + 7) / 8 * 8
+	var err error
+	u.Actions, err = decodeNXActions(data[n:u.Length])
+	return withOffset(err, n)
+}
+
+// NXFlowMonitorReply is the body of the NXST_FLOW_MONITOR reply, the experimenter multipart header followed by
+// the flow updates, which are NXFlowUpdateFull or FlowUpdateAbbrev. OVS sends the updates of the flow changes
+// after the initial reply in the NXST_FLOW_MONITOR replies too.
+type NXFlowMonitorReply struct {
+	ExperimenterMultipartHeader
+	Updates []util.Message
+}
+
+func (r *NXFlowMonitorReply) Len() (n uint16) {
+	n = r.ExperimenterMultipartHeader.Len()
+	for _, u := range r.Updates {
+		n += u.Len()
+	}
+	return
+}
+
+func (r *NXFlowMonitorReply) MarshalBinary() (data []byte, err error) {
+	data, err = r.ExperimenterMultipartHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range r.Updates {
+		b, err := u.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return
+}
+
+func (r *NXFlowMonitorReply) UnmarshalBinary(data []byte) error {
+	if err := r.ExperimenterMultipartHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	r.Updates = nil
+	n := int(r.ExperimenterMultipartHeader.Len())
+	for n < len(data) {
+		var header FlowUpdateHeader
+		if err := header.UnmarshalBinary(data[n:]); err != nil {
+			return decodeErrorAt(err, "NXFlowMonitorReply", n, "update")
+		}
+		var u util.Message
+		if header.Event == NXFME_ABBREV {
+			u = new(FlowUpdateAbbrev)
+		} else {
+			u = new(NXFlowUpdateFull)
+		}
+		if err := u.UnmarshalBinary(data[n : n+int(header.Length)]); err != nil {
+			return decodeErrorAt(err, "NXFlowMonitorReply", n, "update")
+		}
+		r.Updates = append(r.Updates, u)
+		var err error
+		if n, err = safeAdvance(n, header.Length, len(data), "nx_flow_update"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
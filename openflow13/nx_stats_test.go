@@ -0,0 +1,92 @@
+package openflow13
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+func TestNXFlowStats(t *testing.T) {
+	req := NewNXFlowStatsRequest(OFPTT_ALL)
+	req.Match.AddField(*NewInPortField(3))
+	mpReq := &MultipartRequest{Header: NewOfp13Header(), Type: MultipartType_Experimenter, Body: req}
+	mpReq.Header.Type = Type_MultiPartRequest
+	data, err := mpReq.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal NXST_FLOW request: %v", err)
+	}
+	msg, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse NXST_FLOW request: %v", err)
+	}
+	req2, ok := msg.(*MultipartRequest).Body.(*NXFlowStatsRequest)
+	if !ok || req2.ExpType != NXST_FLOW || req2.OutPort != OFPP10_NONE || len(req2.Match.Fields) != 1 {
+		t.Fatalf("Unexpected NXST_FLOW request body: %+v", msg.(*MultipartRequest).Body)
+	}
+
+	flow := NewNXFlowStats()
+	flow.Priority = 100
+	flow.IdleAge = 5
+	flow.PacketCount = 10
+	flow.Match.AddField(*NewInPortField(3))
+	flow.Match.AddField(*NewEthTypeField(0x0800))
+	flow.Actions = append(flow.Actions, NewActionOutput(2), NewNXActionResubmitTableAction(OFPP_IN_PORT, 1))
+	reply := &NXFlowStatsReply{Flows: []*NXFlowStats{flow, NewNXFlowStats()}}
+	reply.Experimenter, reply.ExpType = NxExperimenterID, NXST_FLOW
+	checkNXStatsReply(t, reply)
+	data, _ = newMultipartReply(1, MultipartType_Experimenter, 0, reply).MarshalBinary()
+	msg, _ = Parse(data)
+	flow2 := msg.(*MultipartReply).Body[0].(*NXFlowStatsReply).Flows[0]
+	if flow2.Priority != 100 || flow2.IdleAge != 5 || flow2.PacketCount != 10 || len(flow2.Match.Fields) != 2 || len(flow2.Actions) != 2 {
+		t.Errorf("Unexpected NXFlowStats: %+v", flow2)
+	}
+
+	aggregate := &NXAggregateStatsReply{PacketCount: 1, ByteCount: 2, FlowCount: 3}
+	aggregate.Experimenter, aggregate.ExpType = NxExperimenterID, NXST_AGGREGATE
+	checkNXStatsReply(t, aggregate)
+
+	monitorReply := &NXFlowMonitorReply{Updates: []util.Message{NewNXFlowUpdateFull(NXFME_ADDED), NewFlowUpdateAbbrev(7)}}
+	monitorReply.Updates[0].(*NXFlowUpdateFull).Actions = []Action{NewActionOutput(1)}
+	monitorReply.Updates[1].(*FlowUpdateAbbrev).Event = NXFME_ABBREV
+	monitorReply.Experimenter, monitorReply.ExpType = NxExperimenterID, NXST_FLOW_MONITOR
+	checkNXStatsReply(t, monitorReply)
+}
+
+// checkNXStatsReply checks the Nicira stats body is decoded into the same type from a multipart reply, and it is
+// encoded into the same bytes again.
+func checkNXStatsReply(t *testing.T, body util.Message) {
+	data, err := newMultipartReply(1, MultipartType_Experimenter, 0, body).MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal %T: %v", body, err)
+	}
+	msg, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse the reply of %T: %v", body, err)
+	}
+	reply := msg.(*MultipartReply)
+	if len(reply.Body) != 1 {
+		t.Fatalf("Expect 1 body of %T, actual: %d", body, len(reply.Body))
+	}
+	data2, _ := reply.MarshalBinary()
+	if !bytes.Equal(data, data2) {
+		t.Errorf("%T is changed after decoding:\n%x\n%x", reply.Body[0], data, data2)
+	}
+}
+
+func TestNXFlowMonitorRequest(t *testing.T) {
+	monitor := NewNXFlowMonitor(1, NXFMF_INITIAL|NXFMF_ADD|NXFMF_ACTIONS)
+	monitor.Match.AddField(*NewMetadataField(1, nil))
+	req := NewNXFlowMonitorRequest(monitor, NewNXFlowMonitor(2, NXFMF_DELETE))
+	data, _ := req.MarshalBinary()
+	req2 := new(NXFlowMonitorRequest)
+	if err := req2.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal NXFlowMonitorRequest: %v", err)
+	}
+	if len(req2.Monitors) != 2 || req2.Monitors[0].Flags != monitor.Flags || len(req2.Monitors[0].Match.Fields) != 1 || req2.Monitors[1].ID != 2 {
+		t.Errorf("Unexpected NXFlowMonitorRequest: %+v", req2)
+	}
+	if err := req2.UnmarshalBinary(data[:len(data)-4]); err == nil {
+		t.Errorf("Expect error to unmarshal a truncated NXFlowMonitorRequest")
+	}
+}
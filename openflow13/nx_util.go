@@ -84,6 +84,11 @@ const (
 	NXM_OF_ARP_TPA
 )
 
+// Tunnel flags in NXM_NX_TUN_FLAGS.
+const (
+	NX_TUN_FLAG_OAM = 1 << 0 /* The packet is an OAM frame. */
+)
+
 // TLV_Table_Mod commands.
 const (
 	NXTTMC_ADD = iota
@@ -96,6 +101,12 @@ func newMatchFieldHeader(class uint16, field uint8, length uint8) *MatchField {
 	return &MatchField{Class: class, Field: field, Length: fieldLength, HasMask: false}
 }
 
+func newExperimenterMatchFieldHeader(experimenterID uint32, field uint8, length uint8) *MatchField {
+	header := newMatchFieldHeader(OXM_CLASS_EXPERIMENTER, field, length)
+	header.ExperimenterID = experimenterID
+	return header
+}
+
 // oxxFieldHeaderMap is map to find target field header without mask using an OVS known OXX field name
 var oxxFieldHeaderMap = map[string]*MatchField{
 	"NXM_OF_IN_PORT":   newMatchFieldHeader(OXM_CLASS_NXM_0, NXM_OF_IN_PORT, 2),
@@ -222,6 +233,24 @@ var oxxFieldHeaderMap = map[string]*MatchField{
 	"OXM_OF_PBB_ISID":       newMatchFieldHeader(OXM_CLASS_OPENFLOW_BASIC, OXM_FIELD_PBB_ISID, 3),
 	"OXM_OF_TUNNEL_ID":      newMatchFieldHeader(OXM_CLASS_OPENFLOW_BASIC, OXM_FIELD_TUNNEL_ID, 8),
 	"OXM_OF_IPV6_EXTHDR":    newMatchFieldHeader(OXM_CLASS_OPENFLOW_BASIC, OXM_FIELD_IPV6_EXTHDR, 2),
+	"OXM_OF_TCP_FLAGS":      newMatchFieldHeader(OXM_CLASS_OPENFLOW_BASIC, OXM_FIELD_TCP_FLAGS, 2),
+	"OXM_OF_ACTSET_OUTPUT":  newMatchFieldHeader(OXM_CLASS_OPENFLOW_BASIC, OXM_FIELD_ACTSET_OUTPUT, 4),
+
+	"NXOXM_ET_ERSPAN_IDX":  newExperimenterMatchFieldHeader(NxExperimenterID, NXOXM_ET_ERSPAN_IDX, 4),
+	"NXOXM_ET_ERSPAN_VER":  newExperimenterMatchFieldHeader(NxExperimenterID, NXOXM_ET_ERSPAN_VER, 1),
+	"NXOXM_ET_ERSPAN_DIR":  newExperimenterMatchFieldHeader(NxExperimenterID, NXOXM_ET_ERSPAN_DIR, 1),
+	"NXOXM_ET_ERSPAN_HWID": newExperimenterMatchFieldHeader(NxExperimenterID, NXOXM_ET_ERSPAN_HWID, 1),
+
+	"NXOXM_NSH_FLAGS":  newExperimenterMatchFieldHeader(NXOXM_NSH_EXPERIMENTER_ID, NXOXM_NSH_FLAGS, 1),
+	"NXOXM_NSH_MDTYPE": newExperimenterMatchFieldHeader(NXOXM_NSH_EXPERIMENTER_ID, NXOXM_NSH_MDTYPE, 1),
+	"NXOXM_NSH_NP":     newExperimenterMatchFieldHeader(NXOXM_NSH_EXPERIMENTER_ID, NXOXM_NSH_NP, 1),
+	"NXOXM_NSH_SPI":    newExperimenterMatchFieldHeader(NXOXM_NSH_EXPERIMENTER_ID, NXOXM_NSH_SPI, 4),
+	"NXOXM_NSH_SI":     newExperimenterMatchFieldHeader(NXOXM_NSH_EXPERIMENTER_ID, NXOXM_NSH_SI, 1),
+	"NXOXM_NSH_C1":     newExperimenterMatchFieldHeader(NXOXM_NSH_EXPERIMENTER_ID, NXOXM_NSH_C1, 4),
+	"NXOXM_NSH_C2":     newExperimenterMatchFieldHeader(NXOXM_NSH_EXPERIMENTER_ID, NXOXM_NSH_C2, 4),
+	"NXOXM_NSH_C3":     newExperimenterMatchFieldHeader(NXOXM_NSH_EXPERIMENTER_ID, NXOXM_NSH_C3, 4),
+	"NXOXM_NSH_C4":     newExperimenterMatchFieldHeader(NXOXM_NSH_EXPERIMENTER_ID, NXOXM_NSH_C4, 4),
+	"NXOXM_NSH_TTL":    newExperimenterMatchFieldHeader(NXOXM_NSH_EXPERIMENTER_ID, NXOXM_NSH_TTL, 1),
 }
 
 // FindFieldHeaderByName finds OXM/NXM field by name and mask.
@@ -229,18 +258,26 @@ func FindFieldHeaderByName(fieldName string, hasMask bool) (*MatchField, error)
 	fieldKey := strings.ToUpper(fieldName)
 	field, found := oxxFieldHeaderMap[fieldKey]
 	if !found {
+		if info := lookupOXMFieldByName(fieldKey); info != nil {
+			return info.header(hasMask), nil
+		}
 		return nil, fmt.Errorf("failed to find header by name %s", fieldName)
 	}
 	length := field.Length
 	if hasMask {
 		length = field.Length * 2
 	}
+	// The length of an experimenter field also counts the 4-byte experimenter ID.
+	if field.ExperimenterID != 0 {
+		length += 4
+	}
 	// Create a new MatchField and return it to the caller, then it could avoid race condition.
 	return &MatchField{
-		Class:   field.Class,
-		Field:   field.Field,
-		HasMask: hasMask,
-		Length:  length,
+		Class:          field.Class,
+		Field:          field.Field,
+		HasMask:        hasMask,
+		Length:         length,
+		ExperimenterID: field.ExperimenterID,
 	}, nil
 }
 
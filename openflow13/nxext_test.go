@@ -6,8 +6,11 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"math/rand"
 	"net"
 	"testing"
+
+	"github.com/contiv/libOpenflow/util"
 )
 
 func TestNXActionResubmit(t *testing.T) {
@@ -178,6 +181,26 @@ func TestNXMFieldHeader(t *testing.T) {
 	}
 }
 
+func TestPackOXMHeader(t *testing.T) {
+	header := PackOXMHeader(OXM_CLASS_NXM_1, NXM_NX_CT_MARK, true, 8)
+	if header != 0x0001d708 {
+		t.Errorf("Failed to pack OXM header, expect: %x, actual: %x", 0x0001d708, header)
+	}
+	class, field, hasMask, length := UnpackOXMHeader(header)
+	if class != OXM_CLASS_NXM_1 || field != NXM_NX_CT_MARK || !hasMask || length != 8 {
+		t.Errorf("Failed to unpack OXM header: class %d, field %d, hasMask %v, length %d", class, field, hasMask, length)
+	}
+
+	header = PackOXMHeader(OXM_CLASS_OPENFLOW_BASIC, OXM_FIELD_IN_PORT, false, 4)
+	if header != 0x80000004 {
+		t.Errorf("Failed to pack OXM header, expect: %x, actual: %x", 0x80000004, header)
+	}
+	class, field, hasMask, length = UnpackOXMHeader(header)
+	if class != OXM_CLASS_OPENFLOW_BASIC || field != OXM_FIELD_IN_PORT || hasMask || length != 4 {
+		t.Errorf("Failed to unpack OXM header: class %d, field %d, hasMask %v, length %d", class, field, hasMask, length)
+	}
+}
+
 func TestCTLabel(t *testing.T) {
 	var label = [16]byte{}
 	testData, err := hex.DecodeString(fmt.Sprintf("%d", 0x12345678))
@@ -403,6 +426,307 @@ func TestNewNXActionRegLoad2(t *testing.T) {
 	testFunc(load2)
 }
 
+func TestNSHMatchField(t *testing.T) {
+	mask := uint32(0xffff0000)
+	fields := []*MatchField{
+		NewNSHFlagsMatchField(0x2, nil),
+		NewNSHMdTypeMatchField(1),
+		NewNSHNextProtoMatchField(3),
+		NewNSHSpiMatchField(0x123456),
+		NewNSHSiMatchField(255),
+		NewNSHTTLMatchField(63),
+		NewNSHContextMatchField(1, 0x11223344, nil),
+		NewNSHContextMatchField(4, 0x55667788, &mask),
+	}
+	for _, oriField := range fields {
+		data, err := oriField.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to Marshal NSH field: %v", err)
+		}
+		if int(oriField.Length)+4 != len(data) {
+			t.Errorf("NSH field length is incorrect, expect: %d, actual: %d", len(data)-4, oriField.Length)
+		}
+		newField := new(MatchField)
+		if err = newField.UnmarshalBinary(data); err != nil {
+			t.Fatalf("Failed to Unmarshal NSH field: %v", err)
+		}
+		if newField.ExperimenterID != NXOXM_NSH_EXPERIMENTER_ID {
+			t.Errorf("Unmarshalled NSH field has incorrect experimenter ID: %x", newField.ExperimenterID)
+		}
+		newData, err := newField.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to Marshal NSH field: %v", err)
+		}
+		if !bytes.Equal(data, newData) {
+			t.Errorf("Unmarshalled NSH field is not equal to the original one, expect: %v, actual: %v", data, newData)
+		}
+
+		load2 := NewNXActionRegLoad2(oriField)
+		data, err = load2.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to Marshal NXActionRegLoad2: %v", err)
+		}
+		newAction := new(NXActionRegLoad2)
+		if err = newAction.UnmarshalBinary(data); err != nil {
+			t.Fatalf("Failed to Unmarshal NXActionRegLoad2: %v", err)
+		}
+		if newAction.DstField.Field != oriField.Field || newAction.DstField.ExperimenterID != oriField.ExperimenterID {
+			t.Errorf("NSH field in NXActionRegLoad2 is not equal to the original one")
+		}
+	}
+}
+
+func TestTunnelMatchField(t *testing.T) {
+	oamFlag := uint16(NX_TUN_FLAG_OAM)
+	hwIDMask := uint8(0x3f)
+	fields := []*MatchField{
+		NewTunFlagsMatchField(NX_TUN_FLAG_OAM, &oamFlag),
+		NewTunErspanVerMatchField(2, nil),
+		NewTunErspanIdxMatchField(0x12345, nil),
+		NewTunErspanDirMatchField(1, nil),
+		NewTunErspanHwIDMatchField(0x1f, &hwIDMask),
+	}
+	for _, oriField := range fields {
+		data, err := oriField.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to Marshal tunnel field: %v", err)
+		}
+		newField := new(MatchField)
+		if err = newField.UnmarshalBinary(data); err != nil {
+			t.Fatalf("Failed to Unmarshal tunnel field: %v", err)
+		}
+		if newField.Len() != oriField.Len() || newField.ExperimenterID != oriField.ExperimenterID {
+			t.Errorf("Unmarshalled tunnel field is not equal to the original one")
+		}
+		newData, _ := newField.MarshalBinary()
+		if !bytes.Equal(data, newData) {
+			t.Errorf("Unmarshalled tunnel field is not equal to the original one, expect: %v, actual: %v", data, newData)
+		}
+	}
+}
+
+// TestNXActionsRoundTrip creates every NX action with its constructor using random values, and verifies that the
+// length of the action is consistent with the marshalled data, and the data is not changed after unmarshalling.
+func TestNXActionsRoundTrip(t *testing.T) {
+	// The seed is fixed so that a failure is reproducible.
+	const seed = 20201016
+	r := rand.New(rand.NewSource(seed))
+
+	regField := func() *MatchField {
+		return NewRegMatchField(r.Intn(16), 0, nil)
+	}
+	randIPv4 := func() net.IP {
+		return net.IPv4(byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)), byte(r.Intn(256)))
+	}
+	randIPv6 := func() net.IP {
+		ip := make(net.IP, 16)
+		r.Read(ip)
+		return ip
+	}
+
+	tests := []struct {
+		name   string
+		action func() Action
+	}{
+		{"conjunction", func() Action {
+			return NewNXActionConjunction(uint8(r.Intn(4)+1), 4, r.Uint32())
+		}},
+		{"ct", func() Action {
+			return NewNXActionConnTrack().Commit().Table(uint8(r.Intn(255))).ZoneImm(uint16(r.Intn(65536)))
+		}},
+		{"ct_with_nat", func() Action {
+			nat := NewNXActionCTNAT()
+			_ = nat.SetSNAT()
+			nat.SetRangeIPv4Min(randIPv4())
+			return NewNXActionConnTrack().Commit().ZoneRange(regField(), NewNXRange(0, 15)).AddAction(nat)
+		}},
+		{"reg_load", func() Action {
+			return NewNXActionRegLoad(NewNXRange(0, 31).ToOfsBits(), regField(), uint64(r.Uint32()))
+		}},
+		{"reg_move", func() Action {
+			return NewNXActionRegMove(uint16(r.Intn(32)+1), 0, 0, regField(), regField())
+		}},
+		{"resubmit", func() Action {
+			return NewNXActionResubmit(uint16(r.Intn(65536)))
+		}},
+		{"resubmit_table", func() Action {
+			return NewNXActionResubmitTableAction(uint16(r.Intn(65536)), uint8(r.Intn(255)))
+		}},
+		{"ct_resubmit", func() Action {
+			return NewNXActionResubmitTableCT(uint16(r.Intn(65536)), uint8(r.Intn(255)))
+		}},
+		{"ct_resubmit_no_in_port", func() Action {
+			return NewNXActionResubmitTableCTNoInPort(uint8(r.Intn(255)))
+		}},
+		{"nat_ipv4", func() Action {
+			nat := NewNXActionCTNAT()
+			_ = nat.SetDNAT()
+			_ = nat.SetRandom()
+			minPort, maxPort := uint16(r.Intn(1000)), uint16(r.Intn(1000)+1000)
+			nat.SetRangeIPv4Min(randIPv4())
+			nat.SetRangeIPv4Max(randIPv4())
+			nat.SetRangeProtoMin(&minPort)
+			nat.SetRangeProtoMax(&maxPort)
+			return nat
+		}},
+		{"nat_ipv6", func() Action {
+			nat := NewNXActionCTNAT()
+			_ = nat.SetSNAT()
+			_ = nat.SetPersistent()
+			minPort := uint16(r.Intn(65536))
+			nat.SetRangeIPv6Min(randIPv6())
+			nat.SetRangeIPv6Max(randIPv6())
+			nat.SetRangeProtoMin(&minPort)
+			return nat
+		}},
+		{"output_reg", func() Action {
+			return NewOutputFromField(regField(), NewNXRange(0, 15).ToOfsBits())
+		}},
+		{"output_reg_max_len", func() Action {
+			return NewOutputFromFieldWithMaxLen(regField(), NewNXRange(0, 15).ToOfsBits(), uint16(r.Intn(65536)))
+		}},
+		{"dec_ttl", func() Action {
+			return NewNXActionDecTTL()
+		}},
+		{"dec_ttl_cnt_ids", func() Action {
+			ids := make([]uint16, r.Intn(5)+1)
+			for i := range ids {
+				ids[i] = uint16(r.Intn(65536))
+			}
+			return NewNXActionDecTTLCntIDs(uint16(len(ids)), ids...)
+		}},
+		{"learn", func() Action {
+			learn := NewNXActionLearn()
+			learn.IdleTimeout = uint16(r.Intn(65536))
+			learn.Priority = uint16(r.Intn(65536))
+			learn.Cookie = r.Uint64()
+			learn.TableID = uint8(r.Intn(255))
+			learn.LearnSpecs = prepareLearnSpecs()
+			return learn
+		}},
+		{"note", func() Action {
+			note := NewNXActionNote()
+			note.Note = make([]byte, r.Intn(20)+1)
+			r.Read(note.Note)
+			return note
+		}},
+		{"reg_load2", func() Action {
+			field := NewCTMarkMatchField(r.Uint32(), nil)
+			return NewNXActionRegLoad2(field)
+		}},
+		{"controller", func() Action {
+			return NewNXActionController(uint16(r.Intn(65536)))
+		}},
+		{"sample", func() Action {
+			return NewNXActionSample(uint16(r.Intn(65536)), r.Uint32(), r.Uint32(), r.Uint32())
+		}},
+		{"sample2", func() Action {
+			return NewNXActionSample2(uint16(r.Intn(65536)), r.Uint32(), r.Uint32(), r.Uint32(), uint16(r.Intn(65536)), uint8(r.Intn(3)))
+		}},
+		{"output_trunc", func() Action {
+			return NewNXActionOutputTrunc(uint16(r.Intn(65536)), r.Uint32())
+		}},
+		{"ct_clear", func() Action {
+			return NewNXActionCTClear()
+		}},
+		{"exit", func() Action {
+			return NewNXActionExit()
+		}},
+		{"fin_timeout", func() Action {
+			return NewNXActionFinTimeout(uint16(r.Intn(65536)), uint16(r.Intn(65536)))
+		}},
+		{"dec_nsh_ttl", func() Action {
+			return NewNXActionDecNshTTL()
+		}},
+		{"encap", func() Action {
+			value := make([]byte, r.Intn(16)+1)
+			r.Read(value)
+			return NewNXActionEncap(PT_NSH, NewNXEncapPropNshMdType(2), NewNXEncapPropNshTLV(uint16(r.Intn(65536)), uint8(r.Intn(256)), value))
+		}},
+		{"decap", func() Action {
+			return NewNXActionDecap(PT_USE_NEXT_PROTO)
+		}},
+		{"multipath", func() Action {
+			return NewNXActionMultipath(uint16(r.Intn(7)), uint16(r.Intn(65536)), uint16(r.Intn(4)), uint16(r.Intn(16)), r.Uint32(), NewNXRange(0, 15).ToOfsBits(), regField())
+		}},
+		{"bundle", func() Action {
+			slaves := make([]uint16, r.Intn(8))
+			for i := range slaves {
+				slaves[i] = uint16(r.Intn(65536))
+			}
+			return NewNXActionBundle(uint16(r.Intn(2)), uint16(r.Intn(7)), uint16(r.Intn(65536)), slaves...)
+		}},
+		{"bundle_load", func() Action {
+			return NewNXActionBundleLoad(NX_BD_ALG_HRW, NX_HASH_FIELDS_ETH_SRC, 0, NewNXRange(0, 15).ToOfsBits(), regField(), uint16(r.Intn(65536)), 2, 3)
+		}},
+	}
+
+	for _, tc := range tests {
+		for i := 0; i < 10; i++ {
+			action := tc.action()
+			data, err := action.MarshalBinary()
+			if err != nil {
+				t.Fatalf("%s: failed to Marshal action: %v", tc.name, err)
+			}
+			if int(action.Len()) != len(data) {
+				t.Errorf("%s: Len() is %d, but the marshalled data has %d bytes", tc.name, action.Len(), len(data))
+			}
+			if len(data)%8 != 0 {
+				t.Errorf("%s: the marshalled action has %d bytes, which is not 64-bit aligned", tc.name, len(data))
+			}
+			newAction, err := DecodeAction(data)
+			if err != nil {
+				t.Fatalf("%s: failed to decode action: %v", tc.name, err)
+			}
+			if int(newAction.Len()) != len(data) {
+				t.Errorf("%s: Len() of the decoded action is %d, expect %d", tc.name, newAction.Len(), len(data))
+			}
+			newData, err := newAction.MarshalBinary()
+			if err != nil {
+				t.Fatalf("%s: failed to Marshal decoded action: %v", tc.name, err)
+			}
+			if !bytes.Equal(data, newData) {
+				t.Errorf("%s: decoded action is not equal to the original one, expect: %x, actual: %x", tc.name, data, newData)
+			}
+		}
+	}
+}
+
+func TestCTTupleMatchField(t *testing.T) {
+	_, ipv6Net, _ := net.ParseCIDR("2001:db8::/64")
+	_, ipv4Net, _ := net.ParseCIDR("10.10.0.0/16")
+	portMask := uint16(0xff00)
+	fields := []*MatchField{
+		NewCTNwProtoMatchField(6),
+		NewCTNwSrcMatchField(ipv4Net.IP, net.IP(ipv4Net.Mask)),
+		NewCTNwDstMatchField(net.ParseIP("10.10.1.1").To4(), nil),
+		NewCTIPv6SrcMatchField(ipv6Net.IP, net.IP(ipv6Net.Mask)),
+		NewCTIPv6DstMatchField(net.ParseIP("2001:db8::1"), nil),
+		NewCTTpSrcMatchField(0x1200, &portMask),
+		NewCTTpDstMatchField(443, nil),
+	}
+	for _, oriField := range fields {
+		data, err := oriField.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to Marshal ct tuple field: %v", err)
+		}
+		if int(oriField.Length)+4 != len(data) {
+			t.Errorf("ct tuple field length is incorrect, expect: %d, actual: %d", len(data)-4, oriField.Length)
+		}
+		newField := new(MatchField)
+		if err = newField.UnmarshalBinary(data); err != nil {
+			t.Fatalf("Failed to Unmarshal ct tuple field: %v", err)
+		}
+		if newField.HasMask != oriField.HasMask {
+			t.Errorf("Unmarshalled ct tuple field has incorrect mask flag")
+		}
+		newData, _ := newField.MarshalBinary()
+		if !bytes.Equal(data, newData) {
+			t.Errorf("Unmarshalled ct tuple field is not equal to the original one, expect: %v, actual: %v", data, newData)
+		}
+	}
+}
+
 func TestNXActionController(t *testing.T) {
 	testFunc := func(oriAction *NXActionController) {
 		data, err := oriAction.MarshalBinary()
@@ -457,6 +781,51 @@ func TestSetControllerID(t *testing.T) {
 	testFunc(message)
 }
 
+func TestNXTMessagesOnOF13(t *testing.T) {
+	pktIn2 := &PacketIn2{Props: []util.Message{
+		NewPacketIn2PropBytes(NXPINT_PACKET, []byte{1, 2, 3, 4, 5}),
+		NewPacketIn2PropUint(NXPINT_TABLE_ID, 3),
+		NewPacketIn2PropBytes(NXPINT_CONTINUATION, []byte{6, 7, 8, 9, 10, 11, 12, 13}),
+	}}
+	for _, tc := range []struct {
+		message *VendorHeader
+		check   func(data util.Message) bool
+	}{
+		{NewSetPacketInFormat(NXPIF_NXT_PACKET_IN2), func(data util.Message) bool {
+			format, ok := data.(*PacketInFormat)
+			return ok && format.Format == NXPIF_NXT_PACKET_IN2
+		}},
+		{NewResume(pktIn2), func(data util.Message) bool {
+			resume, ok := data.(*Resume)
+			if !ok || len(resume.Props) != 3 || !bytes.Equal(resume.Packet(), []byte{1, 2, 3, 4, 5}) {
+				return false
+			}
+			continuation, ok := resume.findProp(NXPINT_CONTINUATION).(*PacketIn2PropBytes)
+			return ok && bytes.Equal(continuation.Data, []byte{6, 7, 8, 9, 10, 11, 12, 13})
+		}},
+		{NewCtFlushZone(65535), func(data util.Message) bool {
+			zone, ok := data.(*ZoneID)
+			return ok && zone.ZoneID == 65535
+		}},
+	} {
+		if tc.message.Header.Version != VERSION {
+			t.Errorf("Expect OpenFlow 1.3 header of experimenter type %d", tc.message.ExperimenterType)
+		}
+		data, err := tc.message.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to marshal experimenter type %d: %v", tc.message.ExperimenterType, err)
+		}
+		msg, err := Parse(data)
+		if err != nil {
+			t.Fatalf("Failed to parse experimenter type %d: %v", tc.message.ExperimenterType, err)
+		}
+		vendor, ok := msg.(*VendorHeader)
+		if !ok || vendor.ExperimenterType != tc.message.ExperimenterType || !tc.check(vendor.VendorData) {
+			t.Errorf("Unexpected experimenter message %+v", msg)
+		}
+	}
+}
+
 func TestTLVTableMap(t *testing.T) {
 	testFunc := func(oriMessage *TLVTableMap) {
 		data, err := oriMessage.MarshalBinary()
@@ -930,3 +1299,34 @@ func testMatchFieldHeaderMarshalUnMarshal(tgtField *MatchField, t *testing.T) {
 		t.Errorf("Unmarshalled header has incorrect 'Length' field, expect: %d, actual: %d", testMFHeader.Length, tgtField.Length)
 	}
 }
+
+func TestNXActionEncapDecap(t *testing.T) {
+	for _, tc := range []struct {
+		action Action
+		expStr string
+	}{
+		{NewNXActionEncap(PT_ETH), "encap(ethernet)"},
+		{NewNXActionEncap(PT_NSH, NewNXEncapPropNshMdType(2), NewNXEncapPropNshTLV(0x1000, 10, []byte{0x12, 0x34, 0x56})),
+			"encap(nsh(md_type=2,tlv(0x1000,10,0x123456)))"},
+		{NewNXActionDecap(PT_USE_NEXT_PROTO), "decap()"},
+		{NewNXActionDecap(PT_NSH), "decap(packet_type(ns=1,type=0x894f))"},
+		{NewNXActionSample2(100, 1, 2, 3, 10, NX_ACTION_SAMPLE_EGRESS),
+			"sample(probability=100,collector_set_id=1,obs_domain_id=2,obs_point_id=3,sampling_port=10,egress)"},
+	} {
+		data, err := tc.action.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to marshal action %s: %v", tc.expStr, err)
+		}
+		decoded, err := DecodeAction(data)
+		if err != nil {
+			t.Fatalf("Failed to decode action %s: %v", tc.expStr, err)
+		}
+		if s := decoded.(fmt.Stringer).String(); s != tc.expStr {
+			t.Errorf("Expect action %s, actual: %s", tc.expStr, s)
+		}
+	}
+	data, _ := NewNXActionSample2(100, 1, 2, 3, 0, NX_ACTION_SAMPLE_INGRESS).MarshalBinary()
+	if sample3, err := DecodeAction(data); err != nil || sample3.(*NXActionSample2).Subtype != NXAST_SAMPLE3 {
+		t.Errorf("Expect the sample action with the direction in NXAST_SAMPLE3")
+	}
+}
@@ -3,6 +3,7 @@ package openflow13
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 
 	"github.com/contiv/libOpenflow/util"
 )
@@ -18,6 +19,8 @@ const (
 	Type_TlvTableReply     = 26
 	Type_Resume            = 28
 	Type_CtFlushZone       = 29
+	Type_PacketIn2         = 30
+	Type_CtFlush           = 32
 )
 
 // ofpet_tlv_table_mod_failed_code 1.3
@@ -31,6 +34,13 @@ const (
 	OFPERR_NXTTMFC_INVALID_TLV_DEL = 38
 )
 
+// nx_packet_in_format
+const (
+	NXPIF_STANDARD       = 0 /* OFPT_PACKET_IN for this OpenFlow version. */
+	NXPIF_NXT_PACKET_IN  = 1 /* NXT_PACKET_IN (since OVS v1.1). */
+	NXPIF_NXT_PACKET_IN2 = 2 /* NXT_PACKET_IN2 (since OVS v2.6). */
+)
+
 func NewNXTVendorHeader(msgType uint32) *VendorHeader {
 	h := NewOfp13Header()
 	h.Type = Type_Experimenter
@@ -74,6 +84,115 @@ func NewSetControllerID(id uint16) *VendorHeader {
 	return msg
 }
 
+// PacketInFormat is the nx_set_packet_in_format, the body of NXT_SET_PACKET_IN_FORMAT.
+type PacketInFormat struct {
+	Format uint32 /* One of NXPIF_*. */
+}
+
+func (p *PacketInFormat) Len() uint16 {
+	return 4
+}
+
+func (p *PacketInFormat) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(p.Len()))
+	binary.BigEndian.PutUint32(data, p.Format)
+	return data, nil
+}
+
+func (p *PacketInFormat) UnmarshalBinary(data []byte) error {
+	if len(data) < int(p.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full PacketInFormat message")
+	}
+	p.Format = binary.BigEndian.Uint32(data)
+	return nil
+}
+
+// NewSetPacketInFormat returns the NXT_SET_PACKET_IN_FORMAT message, e.g., with NXPIF_NXT_PACKET_IN2 to receive
+// the PacketIn2 messages which can be resumed.
+func NewSetPacketInFormat(format uint32) *VendorHeader {
+	msg := NewNXTVendorHeader(Type_SetPacketInFormat)
+	msg.VendorData = &PacketInFormat{
+		Format: format,
+	}
+	return msg
+}
+
+// Resume is the body of NXT_RESUME, which has the same properties as PacketIn2. The NXPINT_CONTINUATION
+// property must be kept as received in the PacketIn2 for the switch to resume the pipeline.
+type Resume struct {
+	PacketIn2
+}
+
+// NewResume returns the NXT_RESUME message to resume the pipeline of the PacketIn2, the properties, e.g., the
+// packet and the metadata, may be modified before resuming.
+func NewResume(pktIn2 *PacketIn2) *VendorHeader {
+	msg := NewNXTVendorHeader(Type_Resume)
+	resume := new(Resume)
+	resume.Props = append(resume.Props, pktIn2.Props...)
+	msg.VendorData = resume
+	return msg
+}
+
+// ErrNoContinuation is returned by NewResumeFromPacketIn2 if the pipeline of the PacketIn2 is not paused.
+var ErrNoContinuation = errors.New("the PacketIn2 has no continuation to resume")
+
+// NewResumeFromPacketIn2 returns the NXT_RESUME message to resume the pipeline of the PacketIn2 sent by the
+// NXAST_CONTROLLER2 action with the pause flag. Only the packet, the metadata and the continuation are copied,
+// which are what the switch needs to resume the pipeline. The packet and the metadata may be modified with
+// SetPacket and SetMetadata of the Resume in VendorData, the PacketIn2 is not changed.
+func NewResumeFromPacketIn2(pktIn2 *PacketIn2) (*VendorHeader, error) {
+	resume := new(Resume)
+	for _, propType := range []uint16{NXPINT_PACKET, NXPINT_METADATA, NXPINT_CONTINUATION} {
+		if prop := pktIn2.findProp(propType); prop != nil {
+			resume.Props = append(resume.Props, prop)
+		}
+	}
+	if resume.Continuation() == nil {
+		return nil, ErrNoContinuation
+	}
+	if resume.Packet() == nil {
+		return nil, errors.New("the PacketIn2 has no packet to resume")
+	}
+	msg := NewNXTVendorHeader(Type_Resume)
+	msg.VendorData = resume
+	return msg, nil
+}
+
+// ZoneID is the nx_zone_id, the body of NXT_CT_FLUSH_ZONE.
+type ZoneID struct {
+	pad    [6]byte
+	ZoneID uint16
+}
+
+func (z *ZoneID) Len() uint16 {
+	return uint16(len(z.pad) + 2)
+}
+
+func (z *ZoneID) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(z.Len()))
+	n := 6
+	binary.BigEndian.PutUint16(data[n:], z.ZoneID)
+	return data, nil
+}
+
+func (z *ZoneID) UnmarshalBinary(data []byte) error {
+	if len(data) < int(z.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full ZoneID message")
+	}
+	n := 6
+	z.ZoneID = binary.BigEndian.Uint16(data[n:])
+	return nil
+}
+
+// NewCtFlushZone returns the NXT_CT_FLUSH_ZONE message to flush the conntrack entries in the zone.
+func NewCtFlushZone(zoneID uint16) *VendorHeader {
+	msg := NewNXTVendorHeader(Type_CtFlushZone)
+	msg.VendorData = &ZoneID{
+		ZoneID: zoneID,
+	}
+	return msg
+}
+
 type TLVTableMap struct {
 	OptClass  uint16
 	OptType   uint8
@@ -158,9 +277,11 @@ func (t *TLVTableMod) UnmarshalBinary(data []byte) error {
 		tlvMap := new(TLVTableMap)
 		err := tlvMap.UnmarshalBinary(data[n:])
 		if err != nil {
+			return decodeErrorAt(err, "TLVTableMod", n, "map")
+		}
+		if n, err = safeAdvance(n, tlvMap.Len(), len(data), "TLV table map"); err != nil {
 			return err
 		}
-		n += int(tlvMap.Len())
 		t.TlvMaps = append(t.TlvMaps, tlvMap)
 	}
 	return nil
@@ -214,6 +335,9 @@ func (t *TLVTableReply) MarshalBinary() (data []byte, err error) {
 }
 
 func (t *TLVTableReply) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("the []byte is too short to unmarshal a full TLVTableReply message")
+	}
 	n := 0
 	t.MaxSpace = binary.BigEndian.Uint32(data[n:])
 	n += 4
@@ -226,9 +350,11 @@ func (t *TLVTableReply) UnmarshalBinary(data []byte) error {
 		tlvMap := new(TLVTableMap)
 		err := tlvMap.UnmarshalBinary(data[n:])
 		if err != nil {
+			return decodeErrorAt(err, "TLVTableReply", n, "map")
+		}
+		if n, err = safeAdvance(n, tlvMap.Len(), len(data), "TLV table map"); err != nil {
 			return err
 		}
-		n += int(tlvMap.Len())
 		t.TlvMaps = append(t.TlvMaps, tlvMap)
 	}
 	return nil
@@ -238,18 +364,40 @@ func NewTLVTableRequest() *VendorHeader {
 	return NewNXTVendorHeader(Type_TlvTableRequest)
 }
 
-func decodeVendorData(experimenterType uint32, data []byte) (msg util.Message, err error) {
-	switch experimenterType {
-	case Type_SetControllerId:
-		msg = new(ControllerID)
-	case Type_TlvTableMod:
-		msg = new(TLVTableMod)
-	case Type_TlvTableReply:
-		msg = new(TLVTableReply)
-	case Type_BundleCtrl:
-		msg = new(BundleControl)
-	case Type_BundleAdd:
-		msg = new(BundleAdd)
+// decodeVendorData decodes the body of an experimenter message. The experimenter types are defined per
+// experimenter, so both the experimenter ID and the type are needed, e.g., for the experimenter messages
+// nested in a BundleAdd message.
+func decodeVendorData(vendor uint32, experimenterType uint32, data []byte) (msg util.Message, err error) {
+	switch vendor {
+	case NxExperimenterID:
+		switch experimenterType {
+		case Type_SetPacketInFormat:
+			msg = new(PacketInFormat)
+		case Type_SetControllerId:
+			msg = new(ControllerID)
+		case Type_TlvTableMod:
+			msg = new(TLVTableMod)
+		case Type_TlvTableReply:
+			msg = new(TLVTableReply)
+		case Type_Resume:
+			msg = new(Resume)
+		case Type_CtFlushZone:
+			msg = new(ZoneID)
+		case Type_PacketIn2:
+			msg = new(PacketIn2)
+		case Type_CtFlush:
+			msg = new(CtFlush)
+		}
+	case ONF_EXPERIMENTER_ID:
+		switch experimenterType {
+		case Type_BundleCtrl:
+			msg = new(BundleControl)
+		case Type_BundleAdd:
+			msg = new(BundleAdd)
+		}
+	}
+	if msg == nil {
+		return nil, fmt.Errorf("unsupported experimenter message type %d of experimenter 0x%x", experimenterType, vendor)
 	}
 	err = msg.UnmarshalBinary(data)
 	if err != nil {
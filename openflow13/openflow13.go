@@ -8,6 +8,8 @@ package openflow13
 // Specification Version 1.3.3.
 // https://www.opennetworking.org/images/stories/downloads/sdn-resources/onf-specifications/openflow/openflow-spec-v1.3.3.pdf
 
+//go:generate go run gen_schema.go
+
 import (
 	"encoding/binary"
 	"errors"
@@ -99,6 +101,9 @@ const (
 )
 
 func Parse(b []byte) (message util.Message, err error) {
+	if len(b) < 8 {
+		return nil, common.ErrHeaderTooShort
+	}
 	switch b[1] {
 	case Type_Hello:
 		message = new(common.Hello)
@@ -116,12 +121,8 @@ func Parse(b []byte) (message util.Message, err error) {
 		default:
 			message = errMsg
 		}
-	case Type_EchoRequest:
-		message = new(common.Header)
-		err = message.UnmarshalBinary(b)
-	case Type_EchoReply:
-		message = new(common.Header)
-		err = message.UnmarshalBinary(b)
+	case Type_EchoRequest, Type_EchoReply:
+		message, err = parseEcho(b)
 	case Type_Experimenter:
 		message = new(VendorHeader)
 		err = message.UnmarshalBinary(b)
@@ -167,15 +168,32 @@ func Parse(b []byte) (message util.Message, err error) {
 		message = new(common.Header)
 		err = message.UnmarshalBinary(b)
 	case Type_QueueGetConfigRequest:
-		break
+		message = new(QueueGetConfigRequest)
+		err = message.UnmarshalBinary(b)
 	case Type_QueueGetConfigReply:
-		break
+		message = new(QueueGetConfigReply)
+		err = message.UnmarshalBinary(b)
 	case Type_MultiPartRequest:
 		message = new(MultipartRequest)
 		err = message.UnmarshalBinary(b)
 	case Type_MultiPartReply:
 		message = new(MultipartReply)
 		err = message.UnmarshalBinary(b)
+	case Type_RoleRequest:
+		message = NewRoleRequest(OFPCR_ROLE_NOCHANGE, 0)
+		err = message.UnmarshalBinary(b)
+	case Type_RoleReply:
+		message = NewRoleReply()
+		err = message.UnmarshalBinary(b)
+	case Type_ControllerStatus:
+		message = new(ControllerStatusMsg)
+		err = message.UnmarshalBinary(b)
+	case Type_GetAsyncRequest:
+		message = new(common.Header)
+		err = message.UnmarshalBinary(b)
+	case Type_GetAsyncReply, Type_SetAsync:
+		message = new(AsyncConfig)
+		err = message.UnmarshalBinary(b)
 	default:
 		err = errors.New("An unknown v1.0 packet type was received. Parse function will discard data.")
 	}
@@ -194,7 +212,7 @@ type PacketOut struct {
 	BufferId   uint32
 	InPort     uint32
 	ActionsLen uint16
-	pad        []byte
+	pad        [6]byte
 	Actions    []Action
 	Data       util.Message
 }
@@ -203,10 +221,9 @@ func NewPacketOut() *PacketOut {
 	p := new(PacketOut)
 	p.Header = NewOfp13Header()
 	p.Header.Type = Type_PacketOut
-	p.BufferId = 0xffffffff
+	p.BufferId = NO_BUFFER
 	p.InPort = P_ANY
 	p.ActionsLen = 0
-	p.pad = make([]byte, 6)
 	p.Actions = make([]Action, 0)
 	return p
 }
@@ -258,7 +275,13 @@ func (p *PacketOut) MarshalBinary() (data []byte, err error) {
 }
 
 func (p *PacketOut) UnmarshalBinary(data []byte) error {
+	if len(data) < 24 {
+		return errors.New("the []byte is too short to unmarshal a full PacketOut message")
+	}
 	err := p.Header.UnmarshalBinary(data)
+	if err != nil {
+		return err
+	}
 	n := p.Header.Len()
 
 	p.BufferId = binary.BigEndian.Uint32(data[n:])
@@ -270,15 +293,26 @@ func (p *PacketOut) UnmarshalBinary(data []byte) error {
 
 	n += 6 // for pad
 
-	for n < (n + p.ActionsLen) {
-		a, err := DecodeAction(data[n:])
+	actionsEnd := int(n + p.ActionsLen)
+	if actionsEnd > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full PacketOut message")
+	}
+	for int(n) < actionsEnd {
+		a, err := DecodeAction(data[n:actionsEnd])
 		if err != nil {
-			return err
+			return decodeErrorAt(err, "PacketOut", int(n), "action")
 		}
 		p.Actions = append(p.Actions, a)
-		n += a.Len()
+		next, err := safeAdvance(int(n), a.Len(), actionsEnd, "action")
+		if err != nil {
+			return err
+		}
+		n = uint16(next)
 	}
 
+	if p.Data == nil {
+		p.Data = new(util.Buffer)
+	}
 	err = p.Data.UnmarshalBinary(data[n:])
 	return err
 }
@@ -292,15 +326,20 @@ type PacketIn struct {
 	TableId  uint8
 	Cookie   uint64
 	Match    Match
-	pad      []uint8
+	pad      [2]uint8
 	Data     protocol.Ethernet
+	// RawData keeps the packet bytes if the parsing of Data is deferred with DecodeOptions.LazyPacketIn or
+	// Borrow, Data is decoded from it by GetData.
+	RawData []byte
+
+	dataDeferred bool
 }
 
 func NewPacketIn() *PacketIn {
 	p := new(PacketIn)
 	p.Header = NewOfp13Header()
 	p.Header.Type = Type_PacketIn
-	p.BufferId = 0xffffffff
+	p.BufferId = NO_BUFFER
 	p.Reason = 0
 	p.TableId = 0
 	p.Cookie = 0
@@ -308,12 +347,28 @@ func NewPacketIn() *PacketIn {
 	return p
 }
 
+// GetData returns the Ethernet frame in the PacketIn, the frame is decoded from RawData on the first call if the
+// parsing is deferred. It is not safe to call GetData concurrently on the same PacketIn.
+func (p *PacketIn) GetData() (*protocol.Ethernet, error) {
+	if p.dataDeferred {
+		if err := p.Data.UnmarshalBinary(p.RawData); err != nil {
+			return nil, err
+		}
+		p.dataDeferred = false
+	}
+	return &p.Data, nil
+}
+
 func (p *PacketIn) Len() (n uint16) {
 	n += p.Header.Len()
 	n += 16
 	n += p.Match.Len()
 	n += 2
-	n += p.Data.Len()
+	if p.dataDeferred {
+		n += uint16(len(p.RawData))
+	} else {
+		n += p.Data.Len()
+	}
 	return
 }
 
@@ -330,7 +385,7 @@ func (p *PacketIn) MarshalBinary() (data []byte, err error) {
 	n += 1
 	b[n] = p.TableId
 	n += 1
-	binary.BigEndian.PutUint64(b, p.Cookie)
+	binary.BigEndian.PutUint64(b[n:], p.Cookie)
 	n += 8
 	data = append(data, b...)
 
@@ -338,15 +393,29 @@ func (p *PacketIn) MarshalBinary() (data []byte, err error) {
 	data = append(data, b...)
 
 	b = make([]byte, 2)
-	copy(b[0:], p.pad)
+	copy(b[0:], p.pad[:])
 	data = append(data, b...)
 
+	if p.dataDeferred {
+		data = append(data, p.RawData...)
+		return
+	}
 	b, err = p.Data.MarshalBinary()
 	data = append(data, b...)
 	return
 }
 
 func (p *PacketIn) UnmarshalBinary(data []byte) error {
+	return p.UnmarshalBinaryWithOptions(data, DecodeOptions{})
+}
+
+// UnmarshalBinaryWithOptions decodes the PacketIn with the options. If opts.Borrow or opts.LazyPacketIn is set,
+// the Ethernet frame is decoded on the first call of GetData, from RawData which references the packet bytes in
+// data with opts.Borrow, or else is a copy of them.
+func (p *PacketIn) UnmarshalBinaryWithOptions(data []byte, opts DecodeOptions) error {
+	if len(data) < 24 {
+		return errors.New("the []byte is too short to unmarshal a full PacketIn message")
+	}
 	err := p.Header.UnmarshalBinary(data)
 	n := p.Header.Len()
 
@@ -362,15 +431,31 @@ func (p *PacketIn) UnmarshalBinary(data []byte) error {
 	n += 8
 
 	if err := p.Match.UnmarshalBinary(data[n:]); err != nil {
-		return err
+		return decodeErrorAt(err, "PacketIn", int(n), "match")
 	}
 	n += p.Match.Len()
+	if err := checkBounds(data, int(n), 2, "PacketIn", "pad"); err != nil {
+		return err
+	}
 
-	copy(p.pad, data[n:])
+	copy(p.pad[:], data[n:])
 	n += 2
 
-	err = p.Data.UnmarshalBinary(data[n:])
-	return err
+	if opts.Borrow || opts.LazyPacketIn {
+		if opts.Borrow {
+			p.RawData = data[n:len(data):len(data)]
+		} else {
+			// The data might be reused by the caller after the message is parsed, so keep a copy of it.
+			p.RawData = make([]byte, len(data[n:]))
+			copy(p.RawData, data[n:])
+		}
+		p.dataDeferred = true
+		return err
+	}
+	if err = p.Data.UnmarshalBinary(data[n:]); err != nil {
+		return decodeErrorAt(err, "PacketIn", int(n), "data")
+	}
+	return nil
 }
 
 // ofp_packet_in_reason 1.3
@@ -433,6 +518,9 @@ func (c *SwitchConfig) MarshalBinary() (data []byte, err error) {
 }
 
 func (c *SwitchConfig) UnmarshalBinary(data []byte) error {
+	if len(data) < 12 {
+		return errors.New("the []byte is too short to unmarshal a full SwitchConfig message")
+	}
 	var err error
 	next := 0
 
@@ -486,6 +574,9 @@ func (e *ErrorMsg) MarshalBinary() (data []byte, err error) {
 }
 
 func (e *ErrorMsg) UnmarshalBinary(data []byte) error {
+	if len(data) < 12 {
+		return errors.New("the []byte is too short to unmarshal a full ErrorMsg message")
+	}
 	next := 0
 	e.Header.UnmarshalBinary(data[next:])
 	next += int(e.Header.Len())
@@ -513,6 +604,10 @@ const (
 	ET_ROLE_REQUEST_FAILED   = 11     /* Controller Role request failed. */
 	ET_METER_MOD_FAILED      = 12     /* Error in meter. */
 	ET_TABLE_FEATURES_FAILED = 13     /* Setting table features failed. */
+	ET_BAD_PROPERTY          = 14     /* Some property is invalid (from OpenFlow 1.4). */
+	ET_ASYNC_CONFIG_FAILED   = 15     /* Asynchronous config request failed (from OpenFlow 1.4). */
+	ET_FLOW_MONITOR_FAILED   = 16     /* Setting flow monitor failed (from OpenFlow 1.4). */
+	ET_BUNDLE_FAILED         = 17     /* Bundle operation failed (from OpenFlow 1.4). */
 	ET_EXPERIMENTER          = 0xffff /* Experimenter error messages. */
 )
 
@@ -653,7 +748,7 @@ type SwitchFeatures struct {
 	Buffers      uint32
 	NumTables    uint8
 	AuxilaryId   uint8
-	pad          []uint8 // Size 2
+	pad          [2]uint8 // Size 2
 	Capabilities uint32
 	Actions      uint32
 
@@ -673,7 +768,6 @@ func NewFeaturesReply() *SwitchFeatures {
 	res.Header = NewOfp13Header()
 	res.Header.Type = Type_FeaturesReply
 	res.DPID = make([]byte, 8)
-	res.pad = make([]byte, 2)
 	res.Ports = make([]PhyPort, 0)
 	return res
 }
@@ -703,7 +797,7 @@ func (s *SwitchFeatures) MarshalBinary() (data []byte, err error) {
 	next += 1
 	data[next] = s.AuxilaryId
 	next += 1
-	copy(data[next:], s.pad)
+	copy(data[next:], s.pad[:])
 	next += len(s.pad)
 	binary.BigEndian.PutUint32(data[next:], s.Capabilities)
 	next += 4
@@ -722,6 +816,9 @@ func (s *SwitchFeatures) MarshalBinary() (data []byte, err error) {
 }
 
 func (s *SwitchFeatures) UnmarshalBinary(data []byte) error {
+	if len(data) < 32 {
+		return errors.New("the []byte is too short to unmarshal a full SwitchFeatures message")
+	}
 	var err error
 	next := 0
 
@@ -735,7 +832,7 @@ func (s *SwitchFeatures) UnmarshalBinary(data []byte) error {
 	next += 1
 	s.AuxilaryId = data[next]
 	next += 1
-	copy(s.pad, data[next:])
+	copy(s.pad[:], data[next:])
 	next += len(s.pad)
 	s.Capabilities = binary.BigEndian.Uint32(data[next:])
 	next += 4
@@ -744,7 +841,9 @@ func (s *SwitchFeatures) UnmarshalBinary(data []byte) error {
 
 	for next < len(data) {
 		p := NewPhyPort()
-		err = p.UnmarshalBinary(data[next:])
+		if err = p.UnmarshalBinary(data[next:]); err != nil {
+			return err
+		}
 		next += int(p.Len())
 	}
 	return err
@@ -801,19 +900,32 @@ func (v *VendorHeader) MarshalBinary() (data []byte, err error) {
 }
 
 func (v *VendorHeader) UnmarshalBinary(data []byte) error {
+	return v.unmarshalBinary(data, DecodeOptions{})
+}
+
+func (v *VendorHeader) unmarshalBinary(data []byte, opts DecodeOptions) error {
 	if len(data) < 16 {
 		return errors.New("The []byte the wrong size to unmarshal an " +
 			"VendorHeader message.")
 	}
 	v.Header.UnmarshalBinary(data)
+	if int(v.Header.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full VendorHeader message")
+	}
 	n := int(v.Header.Len())
 	v.Vendor = binary.BigEndian.Uint32(data[n:])
 	n += 4
 	v.ExperimenterType = binary.BigEndian.Uint32(data[n:])
 	n += 4
-	if n < int(v.Header.Length) {
+	if n < int(v.Header.Length) && opts.Borrow && v.Vendor == NxExperimenterID && v.ExperimenterType == Type_PacketIn2 {
+		pktIn2 := new(PacketIn2)
+		if err := pktIn2.UnmarshalBinaryWithOptions(data[n:v.Header.Length], opts); err != nil {
+			return err
+		}
+		v.VendorData = pktIn2
+	} else if n < int(v.Header.Length) {
 		var err error
-		v.VendorData, err = decodeVendorData(v.ExperimenterType, data[n:v.Header.Length])
+		v.VendorData, err = decodeVendorData(v.Vendor, v.ExperimenterType, data[n:v.Header.Length])
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,120 @@
+package openflow13
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+// OXMFieldFactory returns an empty value of a registered OXM field, which is used to decode both the value and
+// the mask of the field. Its Len is the number of bytes the value consumes.
+type OXMFieldFactory func() util.Message
+
+// oxmFieldKey identifies a registered field, the experimenter ID is 0 for the fields not in OXM_CLASS_EXPERIMENTER.
+type oxmFieldKey struct {
+	class          uint16
+	field          uint8
+	experimenterID uint32
+}
+
+type oxmFieldInfo struct {
+	key     oxmFieldKey
+	length  uint8
+	name    string
+	factory OXMFieldFactory
+}
+
+var (
+	oxmFields       = make(map[oxmFieldKey]*oxmFieldInfo)
+	oxmFieldsByName = make(map[string]*oxmFieldInfo)
+	oxmFieldsLock   sync.RWMutex
+)
+
+// RegisterOXMField registers a field of the class which is not known by this package, e.g., a field of a custom
+// class, so that Match.UnmarshalBinary decodes it with the value returned by factory instead of failing, and
+// MatchField.String and FindFieldHeaderByName know it by the name. The length is the length of the value in
+// bytes, without the mask. The registered field takes precedence over the known field of the same class and
+// field. A nil factory unregisters the field. An error is returned if the field does not fit in the 7 bits of
+// the OXM header, or the length of the masked field does not fit in the 8 bits of the OXM header.
+func RegisterOXMField(class uint16, field uint8, length uint8, name string, factory OXMFieldFactory) error {
+	return registerOXMField(oxmFieldKey{class: class, field: field}, length, name, factory)
+}
+
+// RegisterExperimenterOXMField registers a field of OXM_CLASS_EXPERIMENTER as RegisterOXMField, e.g., an ONF
+// OXM_EXP field or a custom NSH field. The experimenter ID is not counted in the length.
+func RegisterExperimenterOXMField(experimenterID uint32, field uint8, length uint8, name string, factory OXMFieldFactory) error {
+	return registerOXMField(oxmFieldKey{class: OXM_CLASS_EXPERIMENTER, field: field, experimenterID: experimenterID}, length, name, factory)
+}
+
+func registerOXMField(key oxmFieldKey, length uint8, name string, factory OXMFieldFactory) error {
+	if factory != nil {
+		if key.field > 0x7f {
+			return fmt.Errorf("invalid OXM field %d of class 0x%x, the maximum is 127", key.field, key.class)
+		}
+		// The masked field has the value and the mask, and the experimenter ID is in the length.
+		maskedLen := 2 * int(length)
+		if key.experimenterID != 0 {
+			maskedLen += 4
+		}
+		if length == 0 || maskedLen > math.MaxUint8 {
+			return fmt.Errorf("invalid length %d of OXM field %s, the masked field is %d bytes", length, name, maskedLen)
+		}
+	}
+	oxmFieldsLock.Lock()
+	defer oxmFieldsLock.Unlock()
+	if info, ok := oxmFields[key]; ok {
+		// The name may have been taken by another field registered later.
+		if oxmFieldsByName[info.name] == info {
+			delete(oxmFieldsByName, info.name)
+		}
+		delete(oxmFields, key)
+	}
+	if factory == nil {
+		return nil
+	}
+	info := &oxmFieldInfo{key: key, length: length, name: strings.ToUpper(name), factory: factory}
+	oxmFields[key] = info
+	oxmFieldsByName[info.name] = info
+	return nil
+}
+
+// lookupOXMField returns the registered field, or nil if the field is not registered.
+func lookupOXMField(class uint16, field uint8, experimenterID uint32) *oxmFieldInfo {
+	oxmFieldsLock.RLock()
+	defer oxmFieldsLock.RUnlock()
+	return oxmFields[oxmFieldKey{class: class, field: field, experimenterID: experimenterID}]
+}
+
+// lookupOXMFieldByName returns the registered field of the name, which is case-insensitive, or nil if there is
+// none.
+func lookupOXMFieldByName(name string) *oxmFieldInfo {
+	oxmFieldsLock.RLock()
+	defer oxmFieldsLock.RUnlock()
+	return oxmFieldsByName[strings.ToUpper(name)]
+}
+
+// decode decodes the value or mask of the registered field.
+func (info *oxmFieldInfo) decode(class uint16, field uint8, length uint8, hasMask bool, data []byte) (util.Message, error) {
+	return unmarshalFieldValue(info.factory(), class, field, data)
+}
+
+// header returns the header of the registered field as FindFieldHeaderByName.
+func (info *oxmFieldInfo) header(hasMask bool) *MatchField {
+	length := info.length
+	if hasMask {
+		length *= 2
+	}
+	if info.key.experimenterID != 0 {
+		length += 4
+	}
+	return &MatchField{
+		Class:          info.key.class,
+		Field:          info.key.field,
+		HasMask:        hasMask,
+		Length:         length,
+		ExperimenterID: info.key.experimenterID,
+	}
+}
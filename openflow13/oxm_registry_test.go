@@ -0,0 +1,127 @@
+package openflow13
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+func TestRegisterOXMField(t *testing.T) {
+	const experimenterID = 0x00abcdef
+	newUint32 := func() util.Message { return new(Uint32Message) }
+	RegisterExperimenterOXMField(experimenterID, 1, 4, "TEST_EXP_FIELD", newUint32)
+	RegisterOXMField(0x0002, 3, 4, "TEST_CLASS_FIELD", newUint32)
+	defer RegisterExperimenterOXMField(experimenterID, 1, 4, "TEST_EXP_FIELD", nil)
+	defer RegisterOXMField(0x0002, 3, 4, "TEST_CLASS_FIELD", nil)
+
+	expField, err := FindFieldHeaderByName("test_exp_field", true)
+	if err != nil {
+		t.Fatalf("Failed to find the registered field: %v", err)
+	}
+	if expField.Class != OXM_CLASS_EXPERIMENTER || expField.ExperimenterID != experimenterID || expField.Length != 12 {
+		t.Errorf("Unexpected field header: %+v", expField)
+	}
+	expField.Value = newUint32Message(0x10)
+	expField.Mask = newUint32Message(0xf0)
+	classField, _ := FindFieldHeaderByName("TEST_CLASS_FIELD", false)
+	classField.Value = newUint32Message(7)
+
+	match := NewMatch()
+	match.AddField(*expField)
+	match.AddField(*classField)
+	data, err := match.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal Match: %v", err)
+	}
+	decoded := new(Match)
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal Match with the registered fields: %v", err)
+	}
+	if len(decoded.Fields) != 2 || decoded.Fields[0].Mask.(*Uint32Message).Data != 0xf0 || decoded.Fields[1].Value.(*Uint32Message).Data != 7 {
+		t.Fatalf("Unexpected fields: %+v", decoded.Fields)
+	}
+	if s := decoded.Fields[0].String(); !strings.HasPrefix(s, "TEST_EXP_FIELD=") {
+		t.Errorf("Unexpected field string %s", s)
+	}
+
+	RegisterExperimenterOXMField(experimenterID, 1, 4, "TEST_EXP_FIELD", nil)
+	if err := new(Match).UnmarshalBinary(data); err == nil {
+		t.Errorf("Expect error to unmarshal the unregistered field")
+	}
+	if _, err := FindFieldHeaderByName("TEST_EXP_FIELD", false); err == nil {
+		t.Errorf("Expect error to find the unregistered field")
+	}
+}
+
+func TestRegisterOXMFieldName(t *testing.T) {
+	newUint32 := func() util.Message { return new(Uint32Message) }
+	if err := RegisterOXMField(0x0002, 4, 4, "TEST_OLD_NAME", newUint32); err != nil {
+		t.Fatalf("Failed to register the field: %v", err)
+	}
+	defer RegisterOXMField(0x0002, 4, 4, "", nil)
+	// Re-register the field with a new name, the old name is removed.
+	RegisterOXMField(0x0002, 4, 4, "TEST_NEW_NAME", newUint32)
+	if _, err := FindFieldHeaderByName("TEST_OLD_NAME", false); err == nil {
+		t.Errorf("Expect error to find the old name of the re-registered field")
+	}
+	if header, err := FindFieldHeaderByName("TEST_NEW_NAME", false); err != nil || header.Field != 4 {
+		t.Errorf("Failed to find the new name of the re-registered field: %+v, %v", header, err)
+	}
+
+	// The name taken by another field is kept when the first field is unregistered.
+	RegisterOXMField(0x0002, 5, 4, "TEST_NEW_NAME", newUint32)
+	defer RegisterOXMField(0x0002, 5, 4, "", nil)
+	RegisterOXMField(0x0002, 4, 4, "", nil)
+	if header, err := FindFieldHeaderByName("TEST_NEW_NAME", false); err != nil || header.Field != 5 {
+		t.Errorf("Expect the name of the field registered later, actual: %+v, %v", header, err)
+	}
+}
+
+func TestRegisterOXMFieldLength(t *testing.T) {
+	newBytes := func() util.Message { return new(util.Buffer) }
+	for _, tc := range []struct {
+		field          uint8
+		length         uint8
+		experimenterID uint32
+		valid          bool
+	}{
+		{field: 1, length: 127, valid: true},
+		{field: 1, length: 128},
+		{field: 1, length: 125, experimenterID: 0x00abcdef, valid: true},
+		{field: 1, length: 126, experimenterID: 0x00abcdef},
+		{field: 1, length: 0},
+		{field: 128, length: 4},
+	} {
+		var err error
+		if tc.experimenterID != 0 {
+			err = RegisterExperimenterOXMField(tc.experimenterID, tc.field, tc.length, "TEST_LENGTH", newBytes)
+			RegisterExperimenterOXMField(tc.experimenterID, tc.field, tc.length, "", nil)
+		} else {
+			err = RegisterOXMField(0x0002, tc.field, tc.length, "TEST_LENGTH", newBytes)
+			RegisterOXMField(0x0002, tc.field, tc.length, "", nil)
+		}
+		if (err == nil) != tc.valid {
+			t.Errorf("Unexpected error of field %d of length %d: %v", tc.field, tc.length, err)
+		}
+	}
+}
+
+func TestRegisterOXMFieldOverKnownField(t *testing.T) {
+	header, _ := FindFieldHeaderByName("NXM_NX_REG0", false)
+	header.Value = newUint32Message(5)
+	match := NewMatch()
+	match.AddField(*header)
+	data, _ := match.MarshalBinary()
+
+	RegisterOXMField(header.Class, header.Field, 4, "TEST_REG0", func() util.Message { return new(Uint32Message) })
+	defer RegisterOXMField(header.Class, header.Field, 4, "", nil)
+	decoded := new(Match)
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal Match: %v", err)
+	}
+	// The field is decoded and printed as the registered field.
+	if s := decoded.Fields[0].String(); !strings.HasPrefix(s, "TEST_REG0=") {
+		t.Errorf("Expect the registered name in the field string, actual: %s", s)
+	}
+}
@@ -0,0 +1,377 @@
+package openflow13
+
+// This file contains the OpenFlow eXtensible Statistics (OXS) introduced in OpenFlow 1.5.
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+// ofp_oxs_class
+const (
+	OFPXSC_OPENFLOW_BASIC = 0x8002 /* Basic stats class for OpenFlow */
+	OFPXSC_EXPERIMENTER   = 0xFFFF /* Experimenter class */
+)
+
+// oxs_ofb_stat_fields
+const (
+	OFPXST_OFB_DURATION     = 0 /* Time flow entry has been alive. */
+	OFPXST_OFB_IDLE_TIME    = 1 /* Time flow entry has been idle. */
+	OFPXST_OFB_FLOW_COUNT   = 3 /* Number of aggregated flow entries. */
+	OFPXST_OFB_PACKET_COUNT = 4 /* Number of packets in flow entry. */
+	OFPXST_OFB_BYTE_COUNT   = 5 /* Number of bytes in flow entry. */
+)
+
+// OXSExperimenterDecoder decodes the value of an experimenter OXS field. The data doesn't include the OXS
+// header and the experimenter ID.
+type OXSExperimenterDecoder func(field uint8, data []byte) (util.Message, error)
+
+var (
+	oxsExperimenterDecoders     = make(map[uint32]OXSExperimenterDecoder)
+	oxsExperimenterDecodersLock sync.RWMutex
+)
+
+// RegisterOXSExperimenterDecoder registers the decoder of the OXS fields of the experimenter, so that the
+// vendor-extended statistics are decoded into typed values. The value of an experimenter field without a
+// registered decoder is kept as raw bytes in util.Buffer.
+func RegisterOXSExperimenterDecoder(experimenterID uint32, decoder OXSExperimenterDecoder) {
+	oxsExperimenterDecodersLock.Lock()
+	defer oxsExperimenterDecodersLock.Unlock()
+	if decoder == nil {
+		delete(oxsExperimenterDecoders, experimenterID)
+		return
+	}
+	oxsExperimenterDecoders[experimenterID] = decoder
+}
+
+func getOXSExperimenterDecoder(experimenterID uint32) OXSExperimenterDecoder {
+	oxsExperimenterDecodersLock.RLock()
+	defer oxsExperimenterDecodersLock.RUnlock()
+	return oxsExperimenterDecoders[experimenterID]
+}
+
+// DecodeStatField decodes the value of an OXS field according to its class and field. A field registered by
+// RegisterOXSField or RegisterExperimenterOXSField is decoded with its factory.
+func DecodeStatField(class uint16, field uint8, experimenterID uint32, data []byte) (util.Message, error) {
+	var val util.Message
+	if info := lookupOXSField(class, field, experimenterID); info != nil {
+		val = info.factory()
+	} else {
+		switch class {
+		case OFPXSC_OPENFLOW_BASIC:
+			return nil, fmt.Errorf("unsupported OXS field %d in class 0x%x", field, class)
+		case OFPXSC_EXPERIMENTER:
+			if decoder := getOXSExperimenterDecoder(experimenterID); decoder != nil {
+				return decoder(field, data)
+			}
+			val = new(util.Buffer)
+		default:
+			return nil, fmt.Errorf("unsupported OXS class 0x%x", class)
+		}
+	}
+	if err := val.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+// StatField is an OXS field. ExperimenterID is only used in the class OFPXSC_EXPERIMENTER, and Length includes
+// the 4-byte experimenter ID in that case. Field is 7 bits, as the lowest bit of its byte on the wire is reserved.
+type StatField struct {
+	Class          uint16
+	Field          uint8
+	Length         uint8
+	ExperimenterID uint32
+	Value          util.Message
+}
+
+// NewStatField returns a basic OXS field.
+func NewStatField(field uint8, value util.Message) *StatField {
+	return &StatField{
+		Class:  OFPXSC_OPENFLOW_BASIC,
+		Field:  field,
+		Length: uint8(value.Len()),
+		Value:  value,
+	}
+}
+
+// NewExperimenterStatField returns an experimenter OXS field.
+func NewExperimenterStatField(experimenterID uint32, field uint8, value util.Message) *StatField {
+	return &StatField{
+		Class:          OFPXSC_EXPERIMENTER,
+		Field:          field,
+		Length:         uint8(4 + value.Len()),
+		ExperimenterID: experimenterID,
+		Value:          value,
+	}
+}
+
+// NewDurationStatField returns the OXS field of the duration of the flow entry.
+func NewDurationStatField(sec uint32, nsec uint32) *StatField {
+	return NewStatField(OFPXST_OFB_DURATION, newUint64Message(uint64(sec)<<32|uint64(nsec)))
+}
+
+// NewIdleTimeStatField returns the OXS field of the time the flow entry has been idle.
+func NewIdleTimeStatField(sec uint32, nsec uint32) *StatField {
+	return NewStatField(OFPXST_OFB_IDLE_TIME, newUint64Message(uint64(sec)<<32|uint64(nsec)))
+}
+
+// NewFlowCountStatField returns the OXS field of the number of the aggregated flow entries.
+func NewFlowCountStatField(count uint32) *StatField {
+	return NewStatField(OFPXST_OFB_FLOW_COUNT, newUint32Message(count))
+}
+
+// NewPacketCountStatField returns the OXS field of the packet count of the flow entry.
+func NewPacketCountStatField(count uint64) *StatField {
+	return NewStatField(OFPXST_OFB_PACKET_COUNT, newUint64Message(count))
+}
+
+// NewByteCountStatField returns the OXS field of the byte count of the flow entry.
+func NewByteCountStatField(count uint64) *StatField {
+	return NewStatField(OFPXST_OFB_BYTE_COUNT, newUint64Message(count))
+}
+
+// Len returns the length of the field on the wire by the header, as the value decoded from an unknown or a
+// variable-length field may not have the same length.
+func (f *StatField) Len() uint16 {
+	return 4 + uint16(f.Length)
+}
+
+func (f *StatField) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(f.Len()))
+	n := 0
+	binary.BigEndian.PutUint16(data[n:], f.Class)
+	n += 2
+	// The lowest bit of the field is reserved.
+	data[n] = (f.Field & 0x7f) << 1
+	n += 1
+	data[n] = f.Length
+	n += 1
+	if f.Class == OFPXSC_EXPERIMENTER {
+		if f.Length < 4 {
+			return nil, fmt.Errorf("invalid length %d of the experimenter OXS field %d", f.Length, f.Field)
+		}
+		binary.BigEndian.PutUint32(data[n:], f.ExperimenterID)
+		n += 4
+	}
+	b, err := f.Value.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if n+len(b) > len(data) {
+		return nil, fmt.Errorf("the value of %d bytes overruns the length %d of the OXS field %d", len(b), f.Length, f.Field)
+	}
+	copy(data[n:], b)
+	return
+}
+
+func (f *StatField) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("the []byte is too short to unmarshal a full StatField message")
+	}
+	n := 0
+	f.Class = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	f.Field = data[n] >> 1
+	n += 1
+	f.Length = data[n]
+	n += 1
+	if len(data) < n+int(f.Length) {
+		return errors.New("the []byte is too short to unmarshal a full StatField message")
+	}
+	end := n + int(f.Length)
+	if f.Class == OFPXSC_EXPERIMENTER {
+		if f.Length < 4 {
+			return errors.New("the []byte is too short to unmarshal a full StatField message")
+		}
+		f.ExperimenterID = binary.BigEndian.Uint32(data[n:])
+		n += 4
+	}
+	val, err := DecodeStatField(f.Class, f.Field, f.ExperimenterID, data[n:end])
+	if err != nil {
+		return err
+	}
+	f.Value = val
+	return nil
+}
+
+// Stats is ofp_stats, a list of OXS fields padded to a multiple of 8 bytes.
+type Stats struct {
+	Reserved uint16
+	Length   uint16
+	Fields   []StatField
+}
+
+func (s *Stats) AddField(f StatField) {
+	s.Fields = append(s.Fields, f)
+	s.Length = s.fieldsLen()
+}
+
+// GetField returns the OXS field of the class and field, the experimenter ID is only used in OFPXSC_EXPERIMENTER.
+// It returns nil if there is no such field.
+func (s *Stats) GetField(class uint16, field uint8, experimenterID uint32) *StatField {
+	for i := range s.Fields {
+		f := &s.Fields[i]
+		if f.Class == class && f.Field == field && (class != OFPXSC_EXPERIMENTER || f.ExperimenterID == experimenterID) {
+			return f
+		}
+	}
+	return nil
+}
+
+// SetField replaces the OXS field of the same class and field, or adds it if there is none.
+func (s *Stats) SetField(f StatField) {
+	if old := s.GetField(f.Class, f.Field, f.ExperimenterID); old != nil {
+		*old = f
+		s.Length = s.fieldsLen()
+		return
+	}
+	s.AddField(f)
+}
+
+func (s *Stats) uint64Field(field uint8) (value uint64, ok bool) {
+	if f := s.GetField(OFPXSC_OPENFLOW_BASIC, field, 0); f != nil {
+		if v, ok := f.Value.(*Uint64Message); ok {
+			return v.Data, true
+		}
+	}
+	return 0, false
+}
+
+// oxsDuration converts the value of OXS_OF_DURATION and OXS_OF_IDLE_TIME, the seconds in the upper 32 bits and the
+// nanoseconds in the lower 32 bits.
+func oxsDuration(value uint64) time.Duration {
+	return time.Duration(value>>32)*time.Second + time.Duration(uint32(value))
+}
+
+func splitDuration(d time.Duration) (sec uint32, nsec uint32) {
+	return uint32(d / time.Second), uint32(d % time.Second)
+}
+
+// GetDuration returns the time the flow entry has been alive, ok is false if there is no OXS_OF_DURATION.
+func (s *Stats) GetDuration() (d time.Duration, ok bool) {
+	value, ok := s.uint64Field(OFPXST_OFB_DURATION)
+	return oxsDuration(value), ok
+}
+
+func (s *Stats) SetDuration(d time.Duration) {
+	s.SetField(*NewDurationStatField(splitDuration(d)))
+}
+
+// GetIdleTime returns the time the flow entry has been idle, ok is false if there is no OXS_OF_IDLE_TIME.
+func (s *Stats) GetIdleTime() (d time.Duration, ok bool) {
+	value, ok := s.uint64Field(OFPXST_OFB_IDLE_TIME)
+	return oxsDuration(value), ok
+}
+
+func (s *Stats) SetIdleTime(d time.Duration) {
+	s.SetField(*NewIdleTimeStatField(splitDuration(d)))
+}
+
+// GetFlowCount returns the number of the aggregated flow entries, ok is false if there is no OXS_OF_FLOW_COUNT.
+func (s *Stats) GetFlowCount() (count uint32, ok bool) {
+	if f := s.GetField(OFPXSC_OPENFLOW_BASIC, OFPXST_OFB_FLOW_COUNT, 0); f != nil {
+		if v, ok := f.Value.(*Uint32Message); ok {
+			return v.Data, true
+		}
+	}
+	return 0, false
+}
+
+func (s *Stats) SetFlowCount(count uint32) {
+	s.SetField(*NewFlowCountStatField(count))
+}
+
+// GetPacketCount returns the number of packets, ok is false if there is no OXS_OF_PACKET_COUNT.
+func (s *Stats) GetPacketCount() (count uint64, ok bool) {
+	return s.uint64Field(OFPXST_OFB_PACKET_COUNT)
+}
+
+func (s *Stats) SetPacketCount(count uint64) {
+	s.SetField(*NewPacketCountStatField(count))
+}
+
+// GetByteCount returns the number of bytes, ok is false if there is no OXS_OF_BYTE_COUNT.
+func (s *Stats) GetByteCount() (count uint64, ok bool) {
+	return s.uint64Field(OFPXST_OFB_BYTE_COUNT)
+}
+
+func (s *Stats) SetByteCount(count uint64) {
+	s.SetField(*NewByteCountStatField(count))
+}
+
+// GetExperimenter returns the value of the experimenter OXS field, ok is false if there is no such field.
+func (s *Stats) GetExperimenter(experimenterID uint32, field uint8) (value util.Message, ok bool) {
+	if f := s.GetField(OFPXSC_EXPERIMENTER, field, experimenterID); f != nil {
+		return f.Value, true
+	}
+	return nil, false
+}
+
+func (s *Stats) SetExperimenter(experimenterID uint32, field uint8, value util.Message) {
+	s.SetField(*NewExperimenterStatField(experimenterID, field, value))
+}
+
+func (s *Stats) fieldsLen() uint16 {
+	n := uint16(4)
+	for _, f := range s.Fields {
+		n += f.Len()
+	}
+	return n
+}
+
+func (s *Stats) Len() uint16 {
+	// Round it to closest multiple of 8
+	return (s.fieldsLen() + 7) / 8 * 8
+}
+
+func (s *Stats) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(s.Len()))
+	s.Length = s.fieldsLen()
+	n := 0
+	binary.BigEndian.PutUint16(data[n:], s.Reserved)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], s.Length)
+	n += 2
+	for _, f := range s.Fields {
+		b, err := f.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		copy(data[n:], b)
+		n += len(b)
+	}
+	return
+}
+
+func (s *Stats) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("the []byte is too short to unmarshal a full Stats message")
+	}
+	n := 0
+	s.Reserved = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	s.Length = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	if int(s.Length) > len(data) || s.Length < 4 {
+		return errors.New("the []byte is too short to unmarshal a full Stats message")
+	}
+	s.Fields = nil
+	for n < int(s.Length) {
+		var f StatField
+		if err := f.UnmarshalBinary(data[n:s.Length]); err != nil {
+			return decodeErrorAt(err, "Stats", n, "OXS field")
+		}
+		s.Fields = append(s.Fields, f)
+		var err error
+		if n, err = safeAdvance(n, f.Len(), int(s.Length), "OXS field"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package openflow13
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+// OXSFieldFactory returns an empty value of a registered OXS field, which is used to decode the value of the field.
+type OXSFieldFactory func() util.Message
+
+// oxsFieldKey identifies an OXS field, the experimenter ID is 0 for the fields not in OFPXSC_EXPERIMENTER.
+type oxsFieldKey struct {
+	class          uint16
+	field          uint8
+	experimenterID uint32
+}
+
+type oxsFieldInfo struct {
+	key     oxsFieldKey
+	name    string
+	factory OXSFieldFactory
+}
+
+// oxsBasicFields is the OXS fields of OFPXSC_OPENFLOW_BASIC defined by OpenFlow 1.5.
+var oxsBasicFields = map[uint8]*oxsFieldInfo{
+	OFPXST_OFB_DURATION:     newOXSBasicFieldInfo(OFPXST_OFB_DURATION, "OXS_OF_DURATION", newUint64),
+	OFPXST_OFB_IDLE_TIME:    newOXSBasicFieldInfo(OFPXST_OFB_IDLE_TIME, "OXS_OF_IDLE_TIME", newUint64),
+	OFPXST_OFB_FLOW_COUNT:   newOXSBasicFieldInfo(OFPXST_OFB_FLOW_COUNT, "OXS_OF_FLOW_COUNT", newUint32),
+	OFPXST_OFB_PACKET_COUNT: newOXSBasicFieldInfo(OFPXST_OFB_PACKET_COUNT, "OXS_OF_PACKET_COUNT", newUint64),
+	OFPXST_OFB_BYTE_COUNT:   newOXSBasicFieldInfo(OFPXST_OFB_BYTE_COUNT, "OXS_OF_BYTE_COUNT", newUint64),
+}
+
+func newOXSBasicFieldInfo(field uint8, name string, factory OXSFieldFactory) *oxsFieldInfo {
+	return &oxsFieldInfo{key: oxsFieldKey{class: OFPXSC_OPENFLOW_BASIC, field: field}, name: name, factory: factory}
+}
+
+func newUint32() util.Message { return new(Uint32Message) }
+func newUint64() util.Message { return new(Uint64Message) }
+
+var (
+	oxsFields       = make(map[oxsFieldKey]*oxsFieldInfo)
+	oxsFieldsByName = make(map[string]*oxsFieldInfo)
+	oxsFieldsLock   sync.RWMutex
+)
+
+// RegisterOXSField registers an OXS field which is not known by this package, e.g., a field of a custom class, so
+// that Stats.UnmarshalBinary decodes it with the value returned by factory instead of failing, and
+// StatField.String and FindStatFieldHeaderByName know it by the name. The registered field takes precedence over
+// the known field of the same class and field. A nil factory unregisters the field.
+func RegisterOXSField(class uint16, field uint8, name string, factory OXSFieldFactory) {
+	registerOXSField(oxsFieldKey{class: class, field: field}, name, factory)
+}
+
+// RegisterExperimenterOXSField registers a field of OFPXSC_EXPERIMENTER as RegisterOXSField. The registered field
+// takes precedence over the decoder registered by RegisterOXSExperimenterDecoder.
+func RegisterExperimenterOXSField(experimenterID uint32, field uint8, name string, factory OXSFieldFactory) {
+	registerOXSField(oxsFieldKey{class: OFPXSC_EXPERIMENTER, field: field, experimenterID: experimenterID}, name, factory)
+}
+
+func registerOXSField(key oxsFieldKey, name string, factory OXSFieldFactory) {
+	oxsFieldsLock.Lock()
+	defer oxsFieldsLock.Unlock()
+	if info, ok := oxsFields[key]; ok {
+		delete(oxsFieldsByName, info.name)
+		delete(oxsFields, key)
+	}
+	if factory == nil {
+		return
+	}
+	info := &oxsFieldInfo{key: key, name: strings.ToUpper(name), factory: factory}
+	oxsFields[key] = info
+	oxsFieldsByName[info.name] = info
+}
+
+// lookupOXSField returns the registered field, or the known basic field, or nil if the field is unknown.
+func lookupOXSField(class uint16, field uint8, experimenterID uint32) *oxsFieldInfo {
+	oxsFieldsLock.RLock()
+	info := oxsFields[oxsFieldKey{class: class, field: field, experimenterID: experimenterID}]
+	oxsFieldsLock.RUnlock()
+	if info == nil && class == OFPXSC_OPENFLOW_BASIC {
+		info = oxsBasicFields[field]
+	}
+	return info
+}
+
+// lookupOXSFieldByName returns the registered or the known basic field of the name, which is case-insensitive, or
+// nil if there is none.
+func lookupOXSFieldByName(name string) *oxsFieldInfo {
+	name = strings.ToUpper(name)
+	oxsFieldsLock.RLock()
+	info := oxsFieldsByName[name]
+	oxsFieldsLock.RUnlock()
+	if info != nil {
+		return info
+	}
+	for _, basic := range oxsBasicFields {
+		if basic.name == name {
+			return basic
+		}
+	}
+	return nil
+}
+
+// FindStatFieldHeaderByName returns a StatField of the OXS field of the name, e.g., OXS_OF_PACKET_COUNT, without
+// the value. The Length is the length of the value returned by the factory of the field, update it if the value
+// has another length.
+func FindStatFieldHeaderByName(name string) (*StatField, error) {
+	info := lookupOXSFieldByName(name)
+	if info == nil {
+		return nil, fmt.Errorf("failed to find OXS field by name %s", name)
+	}
+	length := uint8(info.factory().Len())
+	if info.key.class == OFPXSC_EXPERIMENTER {
+		length += 4
+	}
+	return &StatField{
+		Class:          info.key.class,
+		Field:          info.key.field,
+		Length:         length,
+		ExperimenterID: info.key.experimenterID,
+	}, nil
+}
+
+// String returns the field as NAME=value, the name of an unknown field has the class and the field number.
+func (f *StatField) String() string {
+	name := fmt.Sprintf("OXS(0x%x:%d)", f.Class, f.Field)
+	if info := lookupOXSField(f.Class, f.Field, f.ExperimenterID); info != nil {
+		name = info.name
+	} else if f.Class == OFPXSC_EXPERIMENTER {
+		name = fmt.Sprintf("OXS_EXP(0x%x:%d)", f.ExperimenterID, f.Field)
+	}
+	switch v := f.Value.(type) {
+	case *Uint64Message:
+		if f.Class == OFPXSC_OPENFLOW_BASIC && (f.Field == OFPXST_OFB_DURATION || f.Field == OFPXST_OFB_IDLE_TIME) {
+			return fmt.Sprintf("%s=%s", name, oxsDuration(v.Data))
+		}
+		return fmt.Sprintf("%s=%d", name, v.Data)
+	case *Uint32Message:
+		return fmt.Sprintf("%s=%d", name, v.Data)
+	case *util.Buffer:
+		return fmt.Sprintf("%s=0x%x", name, v.Bytes())
+	default:
+		return fmt.Sprintf("%s=%v", name, f.Value)
+	}
+}
@@ -0,0 +1,94 @@
+package openflow13
+
+import (
+	"testing"
+	"time"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+func TestStatsAccessors(t *testing.T) {
+	const experimenterID = 0x00abcdef
+	stats := new(Stats)
+	stats.SetDuration(3*time.Second + 500)
+	stats.SetIdleTime(time.Second)
+	stats.SetFlowCount(2)
+	stats.SetPacketCount(7)
+	stats.SetByteCount(700)
+	stats.SetExperimenter(experimenterID, 1, newUint32Message(9))
+	stats.SetPacketCount(8)
+	if len(stats.Fields) != 6 {
+		t.Fatalf("Expect the field replaced by the setter, got %d fields", len(stats.Fields))
+	}
+	data, err := stats.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal Stats: %v", err)
+	}
+
+	RegisterExperimenterOXSField(experimenterID, 1, "test_exp_stat", func() util.Message { return new(Uint32Message) })
+	defer RegisterExperimenterOXSField(experimenterID, 1, "test_exp_stat", nil)
+	decoded := new(Stats)
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal Stats: %v", err)
+	}
+	if d, ok := decoded.GetDuration(); !ok || d != 3*time.Second+500 {
+		t.Errorf("Unexpected duration %v", d)
+	}
+	if d, ok := decoded.GetIdleTime(); !ok || d != time.Second {
+		t.Errorf("Unexpected idle time %v", d)
+	}
+	if count, ok := decoded.GetFlowCount(); !ok || count != 2 {
+		t.Errorf("Unexpected flow count %d", count)
+	}
+	if count, ok := decoded.GetPacketCount(); !ok || count != 8 {
+		t.Errorf("Unexpected packet count %d", count)
+	}
+	if count, ok := decoded.GetByteCount(); !ok || count != 700 {
+		t.Errorf("Unexpected byte count %d", count)
+	}
+	if v, ok := decoded.GetExperimenter(experimenterID, 1); !ok || v.(*Uint32Message).Data != 9 {
+		t.Errorf("Unexpected experimenter value %v", v)
+	}
+	if _, ok := decoded.GetExperimenter(experimenterID+1, 1); ok {
+		t.Errorf("Expect no value of another experimenter")
+	}
+	if s := decoded.Fields[3].String(); s != "OXS_OF_PACKET_COUNT=8" {
+		t.Errorf("Unexpected field string %s", s)
+	}
+	if s := decoded.Fields[5].String(); s != "TEST_EXP_STAT=9" {
+		t.Errorf("Unexpected field string %s", s)
+	}
+
+	field, err := FindStatFieldHeaderByName("test_exp_stat")
+	if err != nil || field.Class != OFPXSC_EXPERIMENTER || field.ExperimenterID != experimenterID || field.Field != 1 ||
+		field.Length != 8 {
+		t.Errorf("Unexpected field header %+v: %v", field, err)
+	}
+	if field, err := FindStatFieldHeaderByName("OXS_OF_BYTE_COUNT"); err != nil || field.Field != OFPXST_OFB_BYTE_COUNT ||
+		field.Length != 8 {
+		t.Errorf("Unexpected field header %+v: %v", field, err)
+	}
+	if _, ok := new(Stats).GetPacketCount(); ok {
+		t.Errorf("Expect no packet count in the empty Stats")
+	}
+}
+
+func TestStatFieldReservedBit(t *testing.T) {
+	f := NewStatField(OFPXST_OFB_PACKET_COUNT|0x80, newUint64Message(1))
+	data, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal StatField: %v", err)
+	}
+	if data[2] != OFPXST_OFB_PACKET_COUNT<<1 {
+		t.Errorf("Unexpected field byte 0x%x", data[2])
+	}
+	// The reserved bit set by the peer is ignored.
+	data[2] |= 1
+	decoded := new(StatField)
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal StatField: %v", err)
+	}
+	if decoded.Field != OFPXST_OFB_PACKET_COUNT || decoded.Value.(*Uint64Message).Data != 1 {
+		t.Errorf("Unexpected StatField %+v", decoded)
+	}
+}
@@ -0,0 +1,89 @@
+package openflow13
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+func TestOXSExperimenterStats(t *testing.T) {
+	const experimenterID = 0x12345678
+	stats := new(Stats)
+	stats.AddField(*NewDurationStatField(10, 20))
+	stats.AddField(*NewPacketCountStatField(100))
+	stats.AddField(*NewExperimenterStatField(experimenterID, 1, newUint32Message(0xabcd)))
+	data, err := stats.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal Stats: %v", err)
+	}
+	if len(data)%8 != 0 {
+		t.Errorf("Stats is not padded to 8 bytes: %d", len(data))
+	}
+
+	// The experimenter field is kept as raw bytes without decoder.
+	stats2 := new(Stats)
+	if err := stats2.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal Stats: %v", err)
+	}
+	if len(stats2.Fields) != 3 {
+		t.Fatalf("Expect 3 fields, actual: %d", len(stats2.Fields))
+	}
+	if stats2.Fields[0].Value.(*Uint64Message).Data != 10<<32|20 || stats2.Fields[1].Value.(*Uint64Message).Data != 100 {
+		t.Errorf("Unexpected basic stats: %+v", stats2.Fields)
+	}
+	if raw, ok := stats2.Fields[2].Value.(*util.Buffer); !ok || !bytes.Equal(raw.Bytes(), []byte{0, 0, 0xab, 0xcd}) {
+		t.Errorf("Unexpected experimenter stats without decoder: %+v", stats2.Fields[2].Value)
+	}
+
+	RegisterOXSExperimenterDecoder(experimenterID, func(field uint8, data []byte) (util.Message, error) {
+		val := new(Uint32Message)
+		err := val.UnmarshalBinary(data)
+		return val, err
+	})
+	defer RegisterOXSExperimenterDecoder(experimenterID, nil)
+	stats3 := new(Stats)
+	if err := stats3.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal Stats: %v", err)
+	}
+	f := stats3.Fields[2]
+	if f.ExperimenterID != experimenterID || f.Field != 1 || f.Value.(*Uint32Message).Data != 0xabcd {
+		t.Errorf("Unexpected experimenter stats with decoder: %+v", f)
+	}
+}
+
+func TestStatFieldLength(t *testing.T) {
+	const experimenterID = 0x12345678
+	stats := new(Stats)
+	stats.AddField(*NewExperimenterStatField(experimenterID, 1, util.NewBuffer([]byte{1, 2, 3, 4, 5, 6})))
+	stats.AddField(*NewPacketCountStatField(100))
+	data, err := stats.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal Stats: %v", err)
+	}
+
+	// The decoder only consumes the first 4 bytes of the 6-byte value, the next field is after the wire length.
+	RegisterOXSExperimenterDecoder(experimenterID, func(field uint8, data []byte) (util.Message, error) {
+		val := new(Uint32Message)
+		err := val.UnmarshalBinary(data)
+		return val, err
+	})
+	defer RegisterOXSExperimenterDecoder(experimenterID, nil)
+	decoded := new(Stats)
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal Stats: %v", err)
+	}
+	if len(decoded.Fields) != 2 || decoded.Fields[0].Len() != 14 || decoded.Fields[1].Value.(*Uint64Message).Data != 100 {
+		t.Fatalf("Unexpected fields: %+v", decoded.Fields)
+	}
+	// The field is marshaled with the wire length.
+	if b, err := decoded.MarshalBinary(); err != nil || len(b) != len(data) {
+		t.Errorf("Expect %d bytes of Stats, actual: %d, %v", len(data), len(b), err)
+	}
+
+	f := NewPacketCountStatField(100)
+	f.Length = 4
+	if _, err := f.MarshalBinary(); err == nil {
+		t.Errorf("Expect an error of the value over the length of the field")
+	}
+}
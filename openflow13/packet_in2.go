@@ -0,0 +1,433 @@
+package openflow13
+
+// This file has the Nicira extension message NXT_PACKET_IN2, which carries the PacketIn fields as properties.
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+// nx_packet_in2_prop_type
+const (
+	NXPINT_PACKET       = 0 /* Raw packet data. */
+	NXPINT_FULL_LEN     = 1 /* ovs_be32: Full packet len, if truncated. */
+	NXPINT_BUFFER_ID    = 2 /* ovs_be32: Buffer ID, if buffered. */
+	NXPINT_TABLE_ID     = 3 /* uint8_t: Table ID. */
+	NXPINT_COOKIE       = 4 /* ovs_be64: Flow cookie. */
+	NXPINT_REASON       = 5 /* uint8_t, one of OFPR_*. */
+	NXPINT_METADATA     = 6 /* NXM or OXM for metadata fields. */
+	NXPINT_USERDATA     = 7 /* From NXAST_CONTROLLER2 userdata. */
+	NXPINT_CONTINUATION = 8 /* Private data for continuing processing. */
+)
+
+// PacketIn2PropHeader is the common header of all PacketIn2 properties. The properties are padded to 8 bytes,
+// the padding is not counted in the Length.
+type PacketIn2PropHeader struct {
+	Type   uint16
+	Length uint16
+}
+
+func (p *PacketIn2PropHeader) Len() uint16 {
+	return 4
+}
+
+func (p *PacketIn2PropHeader) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, p.Len())
+	binary.BigEndian.PutUint16(data[0:], p.Type)
+	binary.BigEndian.PutUint16(data[2:], p.Length)
+	return
+}
+
+func (p *PacketIn2PropHeader) UnmarshalBinary(data []byte) error {
+	if len(data) < int(p.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full PacketIn2PropHeader message")
+	}
+	p.Type = binary.BigEndian.Uint16(data[0:])
+	p.Length = binary.BigEndian.Uint16(data[2:])
+	if p.Length < p.Len() || int(p.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full PacketIn2 property")
+	}
+	return nil
+}
+
+// padPacketIn2Prop pads the marshaled property to 8 bytes.
+func padPacketIn2Prop(data []byte) []byte {
+	if pad := len(data) % 8; pad != 0 {
+		data = append(data, make([]byte, 8-pad)...)
+	}
+	return data
+}
+
+// PacketIn2PropBytes is a property of raw bytes, e.g., NXPINT_PACKET, NXPINT_USERDATA, NXPINT_CONTINUATION and
+// the unknown properties.
+type PacketIn2PropBytes struct {
+	PacketIn2PropHeader
+	Data []byte
+}
+
+func NewPacketIn2PropBytes(propType uint16, data []byte) *PacketIn2PropBytes {
+	p := &PacketIn2PropBytes{Data: data}
+	p.Type = propType
+	p.Length = p.Len()
+	return p
+}
+
+func (p *PacketIn2PropBytes) Len() uint16 {
+	return p.PacketIn2PropHeader.Len() + uint16(len(p.Data))
+}
+
+func (p *PacketIn2PropBytes) MarshalBinary() (data []byte, err error) {
+	p.Length = p.Len()
+	data, err = p.PacketIn2PropHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return padPacketIn2Prop(append(data, p.Data...)), nil
+}
+
+func (p *PacketIn2PropBytes) UnmarshalBinary(data []byte) error {
+	return p.unmarshalBinary(data, DecodeOptions{})
+}
+
+func (p *PacketIn2PropBytes) unmarshalBinary(data []byte, opts DecodeOptions) error {
+	if err := p.PacketIn2PropHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if opts.Borrow {
+		p.Data = data[p.PacketIn2PropHeader.Len():p.Length:p.Length]
+		return nil
+	}
+	p.Data = make([]byte, p.Length-p.PacketIn2PropHeader.Len())
+	copy(p.Data, data[p.PacketIn2PropHeader.Len():p.Length])
+	return nil
+}
+
+// PacketIn2PropUint is a property of an integer, NXPINT_FULL_LEN and NXPINT_BUFFER_ID are 32 bits,
+// NXPINT_TABLE_ID and NXPINT_REASON are 8 bits, and NXPINT_COOKIE is 64 bits after 4 bytes of padding.
+type PacketIn2PropUint struct {
+	PacketIn2PropHeader
+	Value uint64
+}
+
+func NewPacketIn2PropUint(propType uint16, value uint64) *PacketIn2PropUint {
+	p := &PacketIn2PropUint{Value: value}
+	p.Type = propType
+	p.Length = p.Len()
+	return p
+}
+
+func (p *PacketIn2PropUint) Len() uint16 {
+	switch p.Type {
+	case NXPINT_TABLE_ID, NXPINT_REASON:
+		return p.PacketIn2PropHeader.Len() + 1
+	case NXPINT_COOKIE:
+		return p.PacketIn2PropHeader.Len() + 12
+	default:
+		return p.PacketIn2PropHeader.Len() + 4
+	}
+}
+
+func (p *PacketIn2PropUint) MarshalBinary() (data []byte, err error) {
+	p.Length = p.Len()
+	data = make([]byte, (p.Len()+7)/8*8)
+	b, err := p.PacketIn2PropHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	switch p.Type {
+	case NXPINT_TABLE_ID, NXPINT_REASON:
+		data[n] = uint8(p.Value)
+	case NXPINT_COOKIE:
+		n += 4 // for pad
+		binary.BigEndian.PutUint64(data[n:], p.Value)
+	default:
+		binary.BigEndian.PutUint32(data[n:], uint32(p.Value))
+	}
+	return
+}
+
+func (p *PacketIn2PropUint) UnmarshalBinary(data []byte) error {
+	if err := p.PacketIn2PropHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if p.Length < p.Len() {
+		return fmt.Errorf("the []byte is too short to unmarshal a full PacketIn2 property %d", p.Type)
+	}
+	n := p.PacketIn2PropHeader.Len()
+	switch p.Type {
+	case NXPINT_TABLE_ID, NXPINT_REASON:
+		p.Value = uint64(data[n])
+	case NXPINT_COOKIE:
+		n += 4 // for pad
+		p.Value = binary.BigEndian.Uint64(data[n:])
+	default:
+		p.Value = uint64(binary.BigEndian.Uint32(data[n:]))
+	}
+	return nil
+}
+
+// PacketIn2PropMetadata is the NXPINT_METADATA property, the metadata fields of the packet, e.g., registers,
+// tunnel and conntrack fields, same as PacketIn.Match. The property carries the OXM/NXM fields without the
+// ofp_match header.
+type PacketIn2PropMetadata struct {
+	PacketIn2PropHeader
+	Match Match
+}
+
+func NewPacketIn2PropMetadata(match *Match) *PacketIn2PropMetadata {
+	p := &PacketIn2PropMetadata{Match: *match}
+	p.Type = NXPINT_METADATA
+	p.Length = p.Len()
+	return p
+}
+
+func (p *PacketIn2PropMetadata) fieldsLen() (n uint16) {
+	for i := range p.Match.Fields {
+		n += p.Match.Fields[i].Len()
+	}
+	return
+}
+
+func (p *PacketIn2PropMetadata) Len() uint16 {
+	return p.PacketIn2PropHeader.Len() + p.fieldsLen()
+}
+
+func (p *PacketIn2PropMetadata) MarshalBinary() (data []byte, err error) {
+	p.Length = p.Len()
+	data, err = p.PacketIn2PropHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	for i := range p.Match.Fields {
+		b, err := p.Match.Fields[i].MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return padPacketIn2Prop(data), nil
+}
+
+func (p *PacketIn2PropMetadata) UnmarshalBinary(data []byte) error {
+	if err := p.PacketIn2PropHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	p.Match = *NewMatch()
+	n := int(p.PacketIn2PropHeader.Len())
+	for n < int(p.Length) {
+		if err := checkBounds(data[:p.Length], n, minElemLen, "PacketIn2PropMetadata", "match field"); err != nil {
+			return err
+		}
+		field := new(MatchField)
+		if err := field.UnmarshalBinary(data[n:p.Length]); err != nil {
+			return decodeErrorAt(err, "PacketIn2PropMetadata", n, "match field")
+		}
+		p.Match.AddField(*field)
+		var err error
+		if n, err = safeAdvance(n, field.Len(), int(p.Length), "metadata field"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodePacketIn2Prop decodes a PacketIn2 property according to its type.
+func DecodePacketIn2Prop(data []byte) (util.Message, error) {
+	return decodePacketIn2Prop(data, DecodeOptions{})
+}
+
+func decodePacketIn2Prop(data []byte, opts DecodeOptions) (util.Message, error) {
+	header := new(PacketIn2PropHeader)
+	if err := header.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	var prop util.Message
+	switch header.Type {
+	case NXPINT_FULL_LEN, NXPINT_BUFFER_ID, NXPINT_TABLE_ID, NXPINT_COOKIE, NXPINT_REASON:
+		prop = new(PacketIn2PropUint)
+	case NXPINT_METADATA:
+		prop = new(PacketIn2PropMetadata)
+	default:
+		bytesProp := new(PacketIn2PropBytes)
+		if err := bytesProp.unmarshalBinary(data, opts); err != nil {
+			return nil, err
+		}
+		return bytesProp, nil
+	}
+	if err := prop.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return prop, nil
+}
+
+// PacketIn2 is the body of NXT_PACKET_IN2, which is sent by OVS if the packet in format is NXPIF_NXT_PACKET_IN2.
+type PacketIn2 struct {
+	Props []util.Message
+}
+
+func (p *PacketIn2) Len() (n uint16) {
+	for _, prop := range p.Props {
+		n += (prop.Len() + 7) / 8 * 8
+	}
+	return
+}
+
+func (p *PacketIn2) MarshalBinary() (data []byte, err error) {
+	for _, prop := range p.Props {
+		b, err := prop.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return
+}
+
+func (p *PacketIn2) UnmarshalBinary(data []byte) error {
+	return p.UnmarshalBinaryWithOptions(data, DecodeOptions{})
+}
+
+// UnmarshalBinaryWithOptions decodes the PacketIn2 with the options. If opts.Borrow is set, the Data of the
+// PacketIn2PropBytes properties, e.g., the packet, references data.
+func (p *PacketIn2) UnmarshalBinaryWithOptions(data []byte, opts DecodeOptions) error {
+	p.Props = nil
+	n := 0
+	for n < len(data) {
+		prop, err := decodePacketIn2Prop(data[n:], opts)
+		if err != nil {
+			return decodeErrorAt(err, "PacketIn2", n, "property")
+		}
+		p.Props = append(p.Props, prop)
+		// The properties are padded to 8 bytes, the last padding could be missing.
+		length := (binary.BigEndian.Uint16(data[n+2:]) + 7) / 8 * 8
+		if n+int(length) > len(data) {
+			break
+		}
+		if n, err = safeAdvance(n, length, len(data), "PacketIn2 property"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *PacketIn2) findProp(propType uint16) util.Message {
+	for _, prop := range p.Props {
+		if t, ok := packetIn2PropType(prop); ok && t == propType {
+			return prop
+		}
+	}
+	return nil
+}
+
+// packetIn2PropType returns the type of the property, and false if the property is not a PacketIn2 property.
+func packetIn2PropType(prop util.Message) (uint16, bool) {
+	switch pr := prop.(type) {
+	case *PacketIn2PropBytes:
+		return pr.Type, true
+	case *PacketIn2PropUint:
+		return pr.Type, true
+	case *PacketIn2PropMetadata:
+		return pr.Type, true
+	}
+	return 0, false
+}
+
+// Packet returns the packet data, or nil if the NXPINT_PACKET property is missing.
+func (p *PacketIn2) Packet() []byte {
+	if prop, ok := p.findProp(NXPINT_PACKET).(*PacketIn2PropBytes); ok {
+		return prop.Data
+	}
+	return nil
+}
+
+// Metadata returns the metadata fields of the packet as a Match, same as PacketIn.Match, or nil if the
+// NXPINT_METADATA property is missing.
+func (p *PacketIn2) Metadata() *Match {
+	if prop, ok := p.findProp(NXPINT_METADATA).(*PacketIn2PropMetadata); ok {
+		return &prop.Match
+	}
+	return nil
+}
+
+// Uint returns the value of the integer property, e.g., NXPINT_TABLE_ID, and false if the property is missing.
+func (p *PacketIn2) Uint(propType uint16) (uint64, bool) {
+	if prop, ok := p.findProp(propType).(*PacketIn2PropUint); ok {
+		return prop.Value, true
+	}
+	return 0, false
+}
+
+// TableID returns the ID of the table which sent the packet, or 0 if the NXPINT_TABLE_ID property is missing.
+func (p *PacketIn2) TableID() uint8 {
+	v, _ := p.Uint(NXPINT_TABLE_ID)
+	return uint8(v)
+}
+
+// Cookie returns the cookie of the flow which sent the packet, or 0 if the NXPINT_COOKIE property is missing.
+func (p *PacketIn2) Cookie() uint64 {
+	v, _ := p.Uint(NXPINT_COOKIE)
+	return v
+}
+
+// Reason returns the reason of the PacketIn2, i.e., R_NO_MATCH, R_ACTION or R_INVALID_TTL, or R_NO_MATCH if the
+// NXPINT_REASON property is missing.
+func (p *PacketIn2) Reason() uint8 {
+	v, _ := p.Uint(NXPINT_REASON)
+	return uint8(v)
+}
+
+// Userdata returns the userdata of the NXAST_CONTROLLER2 action which sent the packet, or nil if the
+// NXPINT_USERDATA property is missing.
+func (p *PacketIn2) Userdata() []byte {
+	if prop, ok := p.findProp(NXPINT_USERDATA).(*PacketIn2PropBytes); ok {
+		return prop.Data
+	}
+	return nil
+}
+
+// Continuation returns the raw payload of the NXPINT_CONTINUATION property, or nil if the pipeline of the
+// packet is not paused. It is sent back unchanged by NewResume and NewResumeFromPacketIn2.
+func (p *PacketIn2) Continuation() []byte {
+	if prop, ok := p.findProp(NXPINT_CONTINUATION).(*PacketIn2PropBytes); ok {
+		return prop.Data
+	}
+	return nil
+}
+
+// DecodeContinuation decodes the NXPINT_CONTINUATION property, and returns nil if it is missing.
+func (p *PacketIn2) DecodeContinuation() (*PacketIn2Continuation, error) {
+	data := p.Continuation()
+	if data == nil {
+		return nil, nil
+	}
+	c := new(PacketIn2Continuation)
+	if err := c.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// setProp replaces the property of the same type, or appends the property if there is none.
+func (p *PacketIn2) setProp(propType uint16, prop util.Message) {
+	for i := range p.Props {
+		if t, ok := packetIn2PropType(p.Props[i]); ok && t == propType {
+			p.Props[i] = prop
+			return
+		}
+	}
+	p.Props = append(p.Props, prop)
+}
+
+// SetPacket sets the packet data, e.g., to modify the packet before resuming the pipeline.
+func (p *PacketIn2) SetPacket(packet []byte) {
+	p.setProp(NXPINT_PACKET, NewPacketIn2PropBytes(NXPINT_PACKET, packet))
+}
+
+// SetMetadata sets the metadata fields of the packet, e.g., to load a register before resuming the pipeline.
+func (p *PacketIn2) SetMetadata(match *Match) {
+	p.setProp(NXPINT_METADATA, NewPacketIn2PropMetadata(match))
+}
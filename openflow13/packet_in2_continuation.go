@@ -0,0 +1,177 @@
+package openflow13
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// nx_continuation_prop_type, the properties nested in the NXPINT_CONTINUATION property.
+const (
+	NXCPT_BRIDGE      = 0x8000 /* UUID of the bridge. */
+	NXCPT_STACK       = 0x8001 /* A value on the stack of NXAST_STACK_PUSH, one property for each value. */
+	NXCPT_MIRRORS     = 0x8002 /* ovs_be32: Mirrors which have output the packet. */
+	NXCPT_CONNTRACKED = 0x8003 /* Flag: the packet has been sent through conntrack. */
+	NXCPT_TABLE_ID    = 0x8004 /* uint8_t: Table ID of the flow which paused the pipeline. */
+	NXCPT_COOKIE      = 0x8005 /* ovs_be64: Cookie of the flow which paused the pipeline. */
+	NXCPT_ACTIONS     = 0x8006 /* Nested actions to execute after resuming. */
+	NXCPT_ACTION_SET  = 0x8007 /* Nested actions of the action set. */
+	NXCPT_ODP_PORT    = 0x8008 /* ovs_be32: Datapath port of the output action which paused the pipeline. */
+)
+
+// PacketIn2Continuation is the decoded NXPINT_CONTINUATION property, i.e., the state of the pipeline paused by
+// the NXAST_CONTROLLER2 action with the pause flag. The continuation is private to OVS and should be sent back
+// unchanged in NXT_RESUME, it is decoded for the debugging and the logging of the paused packets.
+type PacketIn2Continuation struct {
+	Bridge      [16]byte
+	Stack       [][]byte
+	Mirrors     uint32
+	Conntracked bool
+	TableID     uint8
+	Cookie      uint64
+	Actions     []Action
+	ActionSet   []Action
+	OdpPort     uint32
+}
+
+func (c *PacketIn2Continuation) Len() (n uint16) {
+	data, _ := c.MarshalBinary()
+	return uint16(len(data))
+}
+
+// MarshalBinary encodes the continuation as the payload of the NXPINT_CONTINUATION property, i.e., the nested
+// properties after 4 bytes of padding.
+func (c *PacketIn2Continuation) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, 4)
+	putProp := func(propType uint16, payload []byte) {
+		b := make([]byte, 4, 4+len(payload))
+		binary.BigEndian.PutUint16(b[0:], propType)
+		binary.BigEndian.PutUint16(b[2:], uint16(4+len(payload)))
+		data = append(data, padPacketIn2Prop(append(b, payload...))...)
+	}
+	putActions := func(propType uint16, actions []Action) error {
+		payload := make([]byte, 4)
+		for _, act := range actions {
+			b, err := act.MarshalBinary()
+			if err != nil {
+				return err
+			}
+			payload = append(payload, b...)
+		}
+		putProp(propType, payload)
+		return nil
+	}
+	var zeroBridge [16]byte
+	if c.Bridge != zeroBridge {
+		putProp(NXCPT_BRIDGE, c.Bridge[:])
+	}
+	for _, value := range c.Stack {
+		putProp(NXCPT_STACK, value)
+	}
+	if c.Mirrors != 0 {
+		putProp(NXCPT_MIRRORS, binary.BigEndian.AppendUint32(nil, c.Mirrors))
+	}
+	if c.Conntracked {
+		putProp(NXCPT_CONNTRACKED, nil)
+	}
+	if len(c.Actions) > 0 {
+		if err = putActions(NXCPT_ACTIONS, c.Actions); err != nil {
+			return nil, err
+		}
+	}
+	if len(c.ActionSet) > 0 {
+		if err = putActions(NXCPT_ACTION_SET, c.ActionSet); err != nil {
+			return nil, err
+		}
+	}
+	if c.TableID != 0 {
+		putProp(NXCPT_TABLE_ID, []byte{c.TableID})
+	}
+	if c.Cookie != 0 {
+		putProp(NXCPT_COOKIE, binary.BigEndian.AppendUint64(make([]byte, 4), c.Cookie))
+	}
+	if c.OdpPort != 0 {
+		putProp(NXCPT_ODP_PORT, binary.BigEndian.AppendUint32(nil, c.OdpPort))
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes the payload of the NXPINT_CONTINUATION property. The unknown properties are skipped,
+// as the continuation format is changed with the versions of OVS.
+func (c *PacketIn2Continuation) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("the []byte is too short to unmarshal a full PacketIn2Continuation message")
+	}
+	*c = PacketIn2Continuation{}
+	n := 4 // for padding
+	for n < len(data) {
+		header := new(PacketIn2PropHeader)
+		if err := header.UnmarshalBinary(data[n:]); err != nil {
+			return decodeErrorAt(err, "PacketIn2Continuation", n, "property")
+		}
+		payload := data[n+int(header.Len()) : n+int(header.Length)]
+		var err error
+		switch header.Type {
+		case NXCPT_BRIDGE:
+			if len(payload) < 16 {
+				return errors.New("the []byte is too short to unmarshal the bridge of PacketIn2Continuation")
+			}
+			copy(c.Bridge[:], payload)
+		case NXCPT_STACK:
+			c.Stack = append(c.Stack, append([]byte(nil), payload...))
+		case NXCPT_MIRRORS, NXCPT_ODP_PORT:
+			if len(payload) < 4 {
+				return fmt.Errorf("the []byte is too short to unmarshal the property %d of PacketIn2Continuation", header.Type)
+			}
+			if header.Type == NXCPT_MIRRORS {
+				c.Mirrors = binary.BigEndian.Uint32(payload)
+			} else {
+				c.OdpPort = binary.BigEndian.Uint32(payload)
+			}
+		case NXCPT_CONNTRACKED:
+			c.Conntracked = true
+		case NXCPT_TABLE_ID:
+			if len(payload) < 1 {
+				return errors.New("the []byte is too short to unmarshal the table ID of PacketIn2Continuation")
+			}
+			c.TableID = payload[0]
+		case NXCPT_COOKIE:
+			if len(payload) < 12 {
+				return errors.New("the []byte is too short to unmarshal the cookie of PacketIn2Continuation")
+			}
+			c.Cookie = binary.BigEndian.Uint64(payload[4:])
+		case NXCPT_ACTIONS, NXCPT_ACTION_SET:
+			if len(payload) < 4 {
+				return errors.New("the []byte is too short to unmarshal the actions of PacketIn2Continuation")
+			}
+			var actions []Action
+			if actions, err = decodeNXActions(payload[4:]); err != nil {
+				return decodeErrorAt(err, "PacketIn2Continuation", n+int(header.Len())+4, "actions")
+			}
+			if header.Type == NXCPT_ACTIONS {
+				c.Actions = actions
+			} else {
+				c.ActionSet = actions
+			}
+		}
+		// The properties are padded to 8 bytes, the last padding could be missing.
+		length := (header.Length + 7) / 8 * 8
+		if n+int(length) > len(data) {
+			break
+		}
+		if n, err = safeAdvance(n, length, len(data), "continuation property"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewPacketIn2PropContinuation returns the NXPINT_CONTINUATION property of the continuation, e.g., to simulate
+// the PacketIn2 of a paused pipeline in the tests.
+func NewPacketIn2PropContinuation(c *PacketIn2Continuation) (*PacketIn2PropBytes, error) {
+	data, err := c.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return NewPacketIn2PropBytes(NXPINT_CONTINUATION, data), nil
+}
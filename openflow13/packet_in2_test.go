@@ -0,0 +1,159 @@
+package openflow13
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+func TestPacketIn2Metadata(t *testing.T) {
+	metadata := NewMatch()
+	metadata.AddField(*NewInPortField(3))
+	metadata.AddField(*NewRegMatchField(0, 0x1234, nil))
+	metadata.AddField(*NewCTStateMatchField(NewCTStates()))
+	packet := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13}
+
+	msg := NewNXTVendorHeader(Type_PacketIn2)
+	msg.VendorData = &PacketIn2{Props: []util.Message{
+		NewPacketIn2PropBytes(NXPINT_PACKET, packet),
+		NewPacketIn2PropUint(NXPINT_TABLE_ID, 10),
+		NewPacketIn2PropUint(NXPINT_COOKIE, 0x1234567890),
+		NewPacketIn2PropMetadata(metadata),
+	}}
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal PacketIn2: %v", err)
+	}
+	if len(data) != int(msg.Len()) || len(data)%8 != 0 {
+		t.Errorf("Unexpected PacketIn2 length %d, Len(): %d", len(data), msg.Len())
+	}
+
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse PacketIn2: %v", err)
+	}
+	pin2, ok := parsed.(*VendorHeader).VendorData.(*PacketIn2)
+	if !ok {
+		t.Fatalf("Expect PacketIn2, actual: %T", parsed.(*VendorHeader).VendorData)
+	}
+	if !bytes.Equal(pin2.Packet(), packet) {
+		t.Errorf("Unexpected packet %v", pin2.Packet())
+	}
+	if v, ok := pin2.Uint(NXPINT_TABLE_ID); !ok || v != 10 {
+		t.Errorf("Unexpected table ID %d", v)
+	}
+	if v, ok := pin2.Uint(NXPINT_COOKIE); !ok || v != 0x1234567890 {
+		t.Errorf("Unexpected cookie 0x%x", v)
+	}
+	match := pin2.Metadata()
+	if match == nil || len(match.Fields) != 3 {
+		t.Fatalf("Unexpected metadata: %+v", match)
+	}
+	if match.Fields[0].Value.(*InPortField).InPort != 3 || match.Fields[1].Value.(*Uint32Message).Data != 0x1234 {
+		t.Errorf("Unexpected metadata fields: %+v", match.Fields)
+	}
+
+	// The metadata is marshaled back to the same bytes.
+	data2, err := parsed.MarshalBinary()
+	if err != nil || !bytes.Equal(data, data2) {
+		t.Errorf("PacketIn2 is changed after re-marshaling, error: %v", err)
+	}
+}
+
+func TestPacketIn2Continuation(t *testing.T) {
+	continuation := &PacketIn2Continuation{
+		Bridge:      [16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		Stack:       [][]byte{{0, 0, 0, 1}},
+		Conntracked: true,
+		TableID:     5,
+		Cookie:      0x1234,
+		Actions:     []Action{NewActionOutput(1), NewNXActionResubmitTableAction(OFPP_IN_PORT, 10)},
+		OdpPort:     3,
+	}
+	contProp, err := NewPacketIn2PropContinuation(continuation)
+	if err != nil {
+		t.Fatalf("Failed to marshal PacketIn2Continuation: %v", err)
+	}
+	msg := NewNXTVendorHeader(Type_PacketIn2)
+	msg.VendorData = &PacketIn2{Props: []util.Message{
+		NewPacketIn2PropBytes(NXPINT_PACKET, []byte{1, 2, 3}),
+		NewPacketIn2PropUint(NXPINT_TABLE_ID, 10),
+		NewPacketIn2PropUint(NXPINT_COOKIE, 0xabcd),
+		NewPacketIn2PropUint(NXPINT_REASON, R_ACTION),
+		NewPacketIn2PropBytes(NXPINT_USERDATA, []byte{7, 8}),
+		contProp,
+	}}
+	data, _ := msg.MarshalBinary()
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse PacketIn2: %v", err)
+	}
+	pin2 := parsed.(*VendorHeader).VendorData.(*PacketIn2)
+	if pin2.TableID() != 10 || pin2.Cookie() != 0xabcd || pin2.Reason() != R_ACTION || !bytes.Equal(pin2.Userdata(), []byte{7, 8}) {
+		t.Errorf("Unexpected PacketIn2 fields: %d, 0x%x, %d, %v", pin2.TableID(), pin2.Cookie(), pin2.Reason(), pin2.Userdata())
+	}
+	continuation2, err := pin2.DecodeContinuation()
+	if err != nil {
+		t.Fatalf("Failed to decode continuation: %v", err)
+	}
+	if continuation2.Bridge != continuation.Bridge || len(continuation2.Stack) != 1 || !continuation2.Conntracked ||
+		continuation2.TableID != 5 || continuation2.Cookie != 0x1234 || len(continuation2.Actions) != 2 || continuation2.OdpPort != 3 {
+		t.Errorf("Unexpected continuation: %+v", continuation2)
+	}
+	if b, _ := continuation2.MarshalBinary(); !bytes.Equal(b, pin2.Continuation()) {
+		t.Errorf("Continuation is changed after re-marshaling")
+	}
+
+	if c, err := new(PacketIn2).DecodeContinuation(); c != nil || err != nil {
+		t.Errorf("Expect no continuation, actual: %v, %v", c, err)
+	}
+}
+
+func TestNewResumeFromPacketIn2(t *testing.T) {
+	contProp, _ := NewPacketIn2PropContinuation(&PacketIn2Continuation{TableID: 5, Cookie: 0x1234})
+	metadata := NewMatch()
+	metadata.AddField(*NewInPortField(3))
+	pin2 := &PacketIn2{Props: []util.Message{
+		NewPacketIn2PropBytes(NXPINT_PACKET, []byte{1, 2, 3}),
+		NewPacketIn2PropUint(NXPINT_TABLE_ID, 10),
+		NewPacketIn2PropMetadata(metadata),
+		NewPacketIn2PropBytes(NXPINT_USERDATA, []byte{7, 8}),
+		contProp,
+	}}
+	msg, err := NewResumeFromPacketIn2(pin2)
+	if err != nil {
+		t.Fatalf("Failed to build Resume: %v", err)
+	}
+	msg.VendorData.(*Resume).SetPacket([]byte{4, 5, 6, 7})
+	if !bytes.Equal(pin2.Packet(), []byte{1, 2, 3}) {
+		t.Errorf("PacketIn2 is changed by Resume: %v", pin2.Packet())
+	}
+
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal Resume: %v", err)
+	}
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse Resume: %v", err)
+	}
+	resume, ok := parsed.(*VendorHeader).VendorData.(*Resume)
+	if !ok {
+		t.Fatalf("Expect Resume, actual: %T", parsed.(*VendorHeader).VendorData)
+	}
+	if len(resume.Props) != 3 || !bytes.Equal(resume.Packet(), []byte{4, 5, 6, 7}) || resume.Userdata() != nil {
+		t.Errorf("Unexpected Resume properties: %+v", resume.Props)
+	}
+	if m := resume.Metadata(); m == nil || len(m.Fields) != 1 {
+		t.Errorf("Unexpected Resume metadata: %+v", m)
+	}
+	if !bytes.Equal(resume.Continuation(), contProp.Data) {
+		t.Errorf("Continuation is changed in Resume")
+	}
+
+	pin2.Props = pin2.Props[:4]
+	if _, err := NewResumeFromPacketIn2(pin2); err != ErrNoContinuation {
+		t.Errorf("Expect ErrNoContinuation, actual: %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package openflow13
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+// validMaxLen returns an error if the max_len of an output to the controller is not in the valid range.
+func validMaxLen(maxLen uint16) error {
+	if maxLen == 0 {
+		return errors.New("max_len of an output to the controller is 0, no bytes of the packet will be sent")
+	}
+	if maxLen > OFPCML_MAX && maxLen != OFPCML_NO_BUFFER {
+		return fmt.Errorf("max_len 0x%x of an output to the controller exceeds OFPCML_MAX", maxLen)
+	}
+	return nil
+}
+
+// validPacketOutInPort returns an error if the port can't be the in_port of a PacketOut. Same as OVS, a
+// physical port, OFPP_LOCAL, OFPP_CONTROLLER and OFPP_ANY are accepted.
+func validPacketOutInPort(port uint32) error {
+	if port < P_MAX || port == P_LOCAL || port == P_CONTROLLER || port == P_ANY {
+		return nil
+	}
+	return fmt.Errorf("invalid in_port 0x%x of PacketOut", port)
+}
+
+// Validate checks the PacketOut the same way as OVS does, which would otherwise reject it with an error message,
+// or send it silently to nowhere:
+//   - the packet data must be present if BufferId is NO_BUFFER;
+//   - the in_port must be a physical port, OFPP_LOCAL, OFPP_CONTROLLER or OFPP_ANY;
+//   - the outputs to OFPP_TABLE and OFPP_IN_PORT require the in_port, which is the in_port of the pipeline
+//     processing;
+//   - the outputs to the controller must have a max_len which sends some bytes of the packet.
+func (p *PacketOut) Validate() error {
+	if p.BufferId == NO_BUFFER && (p.Data == nil || p.Data.Len() == 0) {
+		return errors.New("PacketOut has neither buffer ID nor packet data")
+	}
+	if err := validPacketOutInPort(p.InPort); err != nil {
+		return err
+	}
+	for _, act := range p.Actions {
+		switch a := act.(type) {
+		case *ActionOutput:
+			switch a.Port {
+			case P_TABLE, P_IN_PORT:
+				if p.InPort == P_ANY {
+					return fmt.Errorf("PacketOut to port 0x%x requires the in_port", a.Port)
+				}
+			case P_CONTROLLER:
+				if err := validMaxLen(a.MaxLen); err != nil {
+					return err
+				}
+			}
+		case *NXActionController:
+			if err := validMaxLen(a.MaxLen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// NewPacketOutToTable returns a PacketOut which submits the packet to the OpenFlow pipeline with the output to
+// OFPP_TABLE, as the packet is received from inPort.
+func NewPacketOutToTable(inPort uint32, data util.Message) (*PacketOut, error) {
+	if inPort == P_ANY {
+		return nil, errors.New("PacketOut to OFPP_TABLE requires the in_port")
+	}
+	if err := validPacketOutInPort(inPort); err != nil {
+		return nil, err
+	}
+	p := NewPacketOut()
+	p.InPort = inPort
+	p.Data = data
+	p.AddAction(NewActionOutput(P_TABLE))
+	return p, nil
+}
+
+// NewActionOutputToController returns the output action to the controller with the max_len, which must be in
+// (0, OFPCML_MAX] or OFPCML_NO_BUFFER to send the whole packet.
+func NewActionOutputToController(maxLen uint16) (*ActionOutput, error) {
+	if err := validMaxLen(maxLen); err != nil {
+		return nil, err
+	}
+	act := NewActionOutput(P_CONTROLLER)
+	act.MaxLen = maxLen
+	return act, nil
+}
@@ -0,0 +1,60 @@
+package openflow13
+
+import (
+	"testing"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+func TestPacketOutValidate(t *testing.T) {
+	data := util.NewBuffer(make([]byte, 64))
+
+	p, err := NewPacketOutToTable(3, data)
+	if err != nil {
+		t.Fatalf("Failed to build PacketOut to table: %v", err)
+	}
+	if err = p.Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err = NewPacketOutToTable(P_ANY, data); err == nil {
+		t.Errorf("Expect error for PacketOut to table without in_port")
+	}
+	if _, err = NewPacketOutToTable(P_NORMAL, data); err == nil {
+		t.Errorf("Expect error for PacketOut to table with a reserved in_port")
+	}
+
+	p = NewPacketOut()
+	p.Data = data
+	p.AddAction(NewActionOutput(P_TABLE))
+	if err = p.Validate(); err == nil {
+		t.Errorf("Expect error for PacketOut to table without in_port")
+	}
+
+	p = NewPacketOut()
+	if err = p.Validate(); err == nil {
+		t.Errorf("Expect error for PacketOut without buffer and data")
+	}
+
+	for _, tc := range []struct {
+		maxLen uint16
+		valid  bool
+	}{
+		{0, false},
+		{128, true},
+		{OFPCML_MAX, true},
+		{OFPCML_MAX + 1, false},
+		{OFPCML_NO_BUFFER, true},
+	} {
+		p = NewPacketOut()
+		p.Data = data
+		output := NewActionOutput(P_CONTROLLER)
+		output.MaxLen = tc.maxLen
+		p.AddAction(output)
+		if err = p.Validate(); (err == nil) != tc.valid {
+			t.Errorf("Unexpected validation result of max_len 0x%x: %v", tc.maxLen, err)
+		}
+		if _, err = NewActionOutputToController(tc.maxLen); (err == nil) != tc.valid {
+			t.Errorf("Unexpected result of NewActionOutputToController with max_len 0x%x: %v", tc.maxLen, err)
+		}
+	}
+}
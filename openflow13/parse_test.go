@@ -0,0 +1,150 @@
+package openflow13
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/contiv/libOpenflow/common"
+	"github.com/contiv/libOpenflow/protocol"
+	"github.com/contiv/libOpenflow/util"
+)
+
+// TestParseShortData parses a corpus of tiny frames of every message type, Parse should return an error instead
+// of panicking.
+func TestParseShortData(t *testing.T) {
+	for _, b := range [][]byte{nil, {}, {4}, {4, Type_Hello}, {4, Type_Hello, 0, 8, 0, 0, 0}} {
+		if _, err := Parse(b); err != common.ErrHeaderTooShort {
+			t.Errorf("Expect ErrHeaderTooShort when parsing %v, actual: %v", b, err)
+		}
+	}
+	for msgType := 0; msgType <= 255; msgType++ {
+		for length := 8; length <= 24; length++ {
+			b := make([]byte, length)
+			b[0] = VERSION
+			b[1] = uint8(msgType)
+			b[3] = uint8(length)
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Errorf("Parse panics with type %d and length %d: %v", msgType, length, r)
+					}
+				}()
+				Parse(b)
+			}()
+		}
+	}
+}
+
+func TestPacketInCookie(t *testing.T) {
+	packetIn := NewPacketIn()
+	packetIn.BufferId = 0x0a0b0c0d
+	packetIn.TotalLen = 64
+	packetIn.Reason = R_ACTION
+	packetIn.TableId = 3
+	packetIn.Cookie = 0x0102030405060708
+	data, err := packetIn.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal PacketIn: %v", err)
+	}
+	// The cookie follows the buffer_id, total_len, reason and table_id in ofp_packet_in, and doesn't overwrite them.
+	if !bytes.Equal(data[8:24], []byte{0x0a, 0x0b, 0x0c, 0x0d, 0, 64, R_ACTION, 3, 1, 2, 3, 4, 5, 6, 7, 8}) {
+		t.Errorf("Unexpected PacketIn fields %x", data[8:24])
+	}
+	msg, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse PacketIn: %v", err)
+	}
+	if parsed := msg.(*PacketIn); parsed.BufferId != 0x0a0b0c0d || parsed.Cookie != 0x0102030405060708 || parsed.TableId != 3 {
+		t.Errorf("Unexpected PacketIn: %+v", parsed)
+	}
+}
+
+func TestParseExperimenterHandler(t *testing.T) {
+	const vendor, expType = 0x00abcdef, 7
+	keepalive := &VendorHeader{Header: NewOfp13Header(), Vendor: vendor, ExperimenterType: expType, VendorData: util.NewBuffer([]byte{1, 2, 3, 4})}
+	keepalive.Header.Type = Type_Experimenter
+	data, _ := keepalive.MarshalBinary()
+
+	// Without a handler the unknown vendor data fails the parsing.
+	parser := NewParser(DecodeOptions{})
+	if _, err := parser.Parse(data); err == nil {
+		t.Errorf("Expect error to parse unknown experimenter message")
+	}
+
+	var handled int
+	parser.RegisterExperimenterHandler(vendor, expType, func(b []byte) {
+		handled++
+	})
+	msg, err := parser.Parse(data)
+	if h, ok := msg.(*util.HandledMessage); !ok || err != nil || handled != 1 || len(h.Data) != len(data) {
+		t.Errorf("Expect the message handled, actual message: %v, error: %v, handled: %d", msg, err, handled)
+	}
+
+	// The handlers are per Parser, and Parse never calls them.
+	if _, err := NewParser(DecodeOptions{}).Parse(data); err == nil || handled != 1 {
+		t.Errorf("Expect the message not handled by another parser, error: %v, handled: %d", err, handled)
+	}
+	if msg, err := Parse(data); msg == nil || err == nil || handled != 1 {
+		t.Errorf("Expect the message not handled by Parse, error: %v, handled: %d", err, handled)
+	}
+
+	// The other experimenter types are parsed as usual.
+	other := NewTLVTableRequest()
+	otherData, _ := other.MarshalBinary()
+	if msg, err = parser.Parse(otherData); err != nil || msg == nil || handled != 1 {
+		t.Errorf("Expect the TLV table request parsed, actual message: %v, error: %v", msg, err)
+	}
+
+	parser.RegisterExperimenterHandler(vendor, expType, nil)
+	if _, err := parser.Parse(data); err == nil || handled != 1 {
+		t.Errorf("Expect the message not handled after the handler is unregistered, error: %v", err)
+	}
+}
+
+func benchmarkParse(b *testing.B, msg util.Message) {
+	benchmarkParseFunc(b, msg, Parse)
+}
+
+func benchmarkParseFunc(b *testing.B, msg util.Message, parse func([]byte) (util.Message, error)) {
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		b.Fatalf("Failed to marshal %T: %v", msg, err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parse(data); err != nil {
+			b.Fatalf("Failed to parse %T: %v", msg, err)
+		}
+	}
+}
+
+func newBenchmarkPacketIn() *PacketIn {
+	packetIn := NewPacketIn()
+	packetIn.Match.AddField(*NewInPortField(1))
+	packetIn.Data = *protocol.NewGenerator(1, protocol.GeneratorOptions{Ethertype: protocol.IPv4_MSG, Protocol: protocol.Type_TCP, PayloadLen: 64}).Next()
+	return packetIn
+}
+
+func BenchmarkParsePacketIn(b *testing.B) {
+	benchmarkParse(b, newBenchmarkPacketIn())
+}
+
+func newBenchmarkFlowStatsReply() *MultipartReply {
+	reply := newMultipartReply(1, MultipartType_Flow, 0)
+	for i := 0; i < 10; i++ {
+		flow := NewFlowStats()
+		flow.Priority = uint16(i)
+		flow.Match.AddField(*NewInPortField(uint32(i)))
+		instr := NewInstrApplyActions()
+		instr.AddAction(NewActionOutput(P_NORMAL), false)
+		flow.Instructions = append(flow.Instructions, instr)
+		flow.Length = flow.Len()
+		reply.Body = append(reply.Body, flow)
+	}
+	return reply
+}
+
+func BenchmarkParseFlowStats(b *testing.B) {
+	benchmarkParse(b, newBenchmarkFlowStatsReply())
+}
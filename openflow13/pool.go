@@ -0,0 +1,142 @@
+package openflow13
+
+// This file has the pools of the messages sent or received at a high rate, i.e., PacketIn, PacketOut, FlowMod,
+// MultipartRequest and MultipartReply, so that long-running controllers can reuse the messages to reduce the
+// GC pressure.
+//
+// A message returned by AcquireXxx is initialized the same as the one returned by NewXxx, with a new Xid, and the
+// slices of the released message are reused with the length 0. A message must not be used, e.g., kept in a
+// channel or referenced by another message, after it is released by ReleaseXxx, and it must be released at most
+// once.
+
+import (
+	"sync"
+)
+
+var (
+	packetInPool         = sync.Pool{New: func() interface{} { return new(PacketIn) }}
+	packetOutPool        = sync.Pool{New: func() interface{} { return new(PacketOut) }}
+	flowModPool          = sync.Pool{New: func() interface{} { return new(FlowMod) }}
+	multipartRequestPool = sync.Pool{New: func() interface{} { return new(MultipartRequest) }}
+	multipartReplyPool   = sync.Pool{New: func() interface{} { return new(MultipartReply) }}
+)
+
+// resetMatch resets the match to an empty OXM match, and keeps the capacity of the fields.
+func resetMatch(m *Match) {
+	fields := m.Fields
+	for i := range fields {
+		fields[i] = MatchField{}
+	}
+	if fields == nil {
+		fields = make([]MatchField, 0)
+	}
+	*m = Match{Type: MatchType_OXM, Length: 4, Fields: fields[:0]}
+}
+
+// AcquirePacketIn returns a PacketIn from the pool, the same as NewPacketIn.
+func AcquirePacketIn() *PacketIn {
+	p := packetInPool.Get().(*PacketIn)
+	p.Header = NewOfp13Header()
+	p.Header.Type = Type_PacketIn
+	p.BufferId = NO_BUFFER
+	if p.Match.Fields == nil {
+		resetMatch(&p.Match)
+	}
+	return p
+}
+
+// ReleasePacketIn resets the PacketIn and puts it back to the pool.
+func ReleasePacketIn(p *PacketIn) {
+	match := p.Match
+	resetMatch(&match)
+	*p = PacketIn{Match: match}
+	packetInPool.Put(p)
+}
+
+// AcquirePacketOut returns a PacketOut from the pool, the same as NewPacketOut.
+func AcquirePacketOut() *PacketOut {
+	p := packetOutPool.Get().(*PacketOut)
+	p.Header = NewOfp13Header()
+	p.Header.Type = Type_PacketOut
+	p.BufferId = NO_BUFFER
+	p.InPort = P_ANY
+	if p.Actions == nil {
+		p.Actions = make([]Action, 0)
+	}
+	return p
+}
+
+// ReleasePacketOut resets the PacketOut and puts it back to the pool.
+func ReleasePacketOut(p *PacketOut) {
+	actions := p.Actions
+	for i := range actions {
+		actions[i] = nil
+	}
+	*p = PacketOut{Actions: actions[:0]}
+	packetOutPool.Put(p)
+}
+
+// AcquireFlowMod returns a FlowMod from the pool, the same as NewFlowMod.
+func AcquireFlowMod() *FlowMod {
+	f := flowModPool.Get().(*FlowMod)
+	f.Header = NewOfp13Header()
+	f.Header.Type = Type_FlowMod
+	f.Command = FC_ADD
+	f.Priority = 1000
+	f.BufferId = NO_BUFFER
+	f.OutPort = P_ANY
+	f.OutGroup = OFPG_ANY
+	if f.Match.Fields == nil {
+		resetMatch(&f.Match)
+	}
+	if f.Instructions == nil {
+		f.Instructions = make([]Instruction, 0)
+	}
+	return f
+}
+
+// ReleaseFlowMod resets the FlowMod and puts it back to the pool.
+func ReleaseFlowMod(f *FlowMod) {
+	match := f.Match
+	resetMatch(&match)
+	instructions := f.Instructions
+	for i := range instructions {
+		instructions[i] = nil
+	}
+	*f = FlowMod{Match: match, Instructions: instructions[:0]}
+	flowModPool.Put(f)
+}
+
+// AcquireMultipartRequest returns a MultipartRequest of the type from the pool, the Body is set by the caller.
+func AcquireMultipartRequest(mpType uint16) *MultipartRequest {
+	s := multipartRequestPool.Get().(*MultipartRequest)
+	s.Header = NewOfp13Header()
+	s.Header.Type = Type_MultiPartRequest
+	s.Type = mpType
+	return s
+}
+
+// ReleaseMultipartRequest resets the MultipartRequest and puts it back to the pool.
+func ReleaseMultipartRequest(s *MultipartRequest) {
+	*s = MultipartRequest{}
+	multipartRequestPool.Put(s)
+}
+
+// AcquireMultipartReply returns an empty MultipartReply from the pool, e.g., to decode a reply with
+// UnmarshalBinary.
+func AcquireMultipartReply() *MultipartReply {
+	s := multipartReplyPool.Get().(*MultipartReply)
+	s.Header = NewOfp13Header()
+	s.Header.Type = Type_MultiPartReply
+	return s
+}
+
+// ReleaseMultipartReply resets the MultipartReply and puts it back to the pool.
+func ReleaseMultipartReply(s *MultipartReply) {
+	body := s.Body
+	for i := range body {
+		body[i] = nil
+	}
+	*s = MultipartReply{Body: body[:0]}
+	multipartReplyPool.Put(s)
+}
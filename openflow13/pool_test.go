@@ -0,0 +1,79 @@
+package openflow13
+
+import (
+	"testing"
+)
+
+func TestMessagePools(t *testing.T) {
+	pktIn := AcquirePacketIn()
+	data, _ := newBenchmarkPacketIn().MarshalBinary()
+	if err := pktIn.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal PacketIn: %v", err)
+	}
+	ReleasePacketIn(pktIn)
+	if len(pktIn.Match.Fields) != 0 || pktIn.Data.Len() != NewPacketIn().Data.Len() || pktIn.RawData != nil {
+		t.Errorf("Expect PacketIn reset after release, actual: %+v", pktIn)
+	}
+	pktIn = AcquirePacketIn()
+	if pktIn.BufferId != NO_BUFFER || pktIn.Header.Type != Type_PacketIn || len(pktIn.Match.Fields) != 0 {
+		t.Errorf("Unexpected acquired PacketIn: %+v", pktIn)
+	}
+
+	flowMod := AcquireFlowMod()
+	flowMod.Priority = 10
+	flowMod.Match.AddField(*NewInPortField(1))
+	flowMod.AddInstruction(NewInstrGotoTable(1))
+	xid := flowMod.Xid
+	ReleaseFlowMod(flowMod)
+	if len(flowMod.Match.Fields) != 0 || len(flowMod.Instructions) != 0 || cap(flowMod.Instructions) == 0 {
+		t.Errorf("Expect FlowMod reset with the capacity kept after release, actual: %+v", flowMod)
+	}
+	flowMod = AcquireFlowMod()
+	expected := NewFlowMod()
+	if flowMod.Xid == xid || flowMod.Priority != expected.Priority || flowMod.Command != expected.Command ||
+		flowMod.OutPort != expected.OutPort || flowMod.OutGroup != expected.OutGroup || flowMod.Len() != expected.Len() {
+		t.Errorf("Expect acquired FlowMod %+v, actual: %+v", expected, flowMod)
+	}
+
+	pktOut := AcquirePacketOut()
+	pktOut.AddAction(NewActionOutput(1))
+	pktOut.Data = pktIn
+	ReleasePacketOut(pktOut)
+	if len(pktOut.Actions) != 0 || pktOut.ActionsLen != 0 || pktOut.Data != nil {
+		t.Errorf("Expect PacketOut reset after release, actual: %+v", pktOut)
+	}
+	pktOut = AcquirePacketOut()
+	if pktOut.InPort != P_ANY || pktOut.BufferId != NO_BUFFER || pktOut.Header.Type != Type_PacketOut {
+		t.Errorf("Unexpected acquired PacketOut: %+v", pktOut)
+	}
+
+	mpReq := AcquireMultipartRequest(MultipartType_Flow)
+	mpReq.Body = NewFlowStatsRequest()
+	ReleaseMultipartRequest(mpReq)
+	if mpReq.Body != nil || mpReq.Type != 0 {
+		t.Errorf("Expect MultipartRequest reset after release, actual: %+v", mpReq)
+	}
+
+	reply := AcquireMultipartReply()
+	data, _ = newBenchmarkFlowStatsReply().MarshalBinary()
+	if err := reply.UnmarshalBinary(data); err != nil || len(reply.Body) != 10 {
+		t.Fatalf("Failed to unmarshal MultipartReply: %v", err)
+	}
+	ReleaseMultipartReply(reply)
+	if len(reply.Body) != 0 || reply.Type != 0 {
+		t.Errorf("Expect MultipartReply reset after release, actual: %+v", reply)
+	}
+}
+
+func BenchmarkFlowModPool(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		flowMod := AcquireFlowMod()
+		flowMod.Match.AddField(*NewInPortField(1))
+		flowMod.AddInstruction(NewInstrGotoTable(1))
+		if _, err := flowMod.MarshalBinary(); err != nil {
+			b.Fatalf("Failed to marshal FlowMod: %v", err)
+		}
+		ReleaseFlowMod(flowMod)
+	}
+}
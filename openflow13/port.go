@@ -2,18 +2,20 @@ package openflow13
 
 import (
 	"encoding/binary"
+	"errors"
 	"net"
 
 	"github.com/contiv/libOpenflow/common"
+	"github.com/contiv/libOpenflow/util"
 )
 
 // ofp_port 1.3
 type PhyPort struct {
 	PortNo uint32
-	pad    []byte // 4 bytes
+	pad    [4]byte // 4 bytes
 	HWAddr net.HardwareAddr
-	pad2   []byte // 2 bytes for 64bit alignment
-	Name   []byte // Size 16
+	pad2   [2]byte // 2 bytes for 64bit alignment
+	Name   []byte  // Size 16
 
 	Config uint32
 	State  uint32
@@ -46,11 +48,11 @@ func (p *PhyPort) MarshalBinary() (data []byte, err error) {
 	data = make([]byte, int(p.Len()))
 	binary.BigEndian.PutUint32(data, p.PortNo)
 	n := 4
-	copy(data[n:], p.pad)
+	copy(data[n:], p.pad[:])
 	n += 4
 	copy(data[n:], p.HWAddr)
 	n += len(p.HWAddr)
-	copy(data[n:], p.pad2)
+	copy(data[n:], p.pad2[:])
 	n += 2
 	copy(data[n:], p.Name)
 	n += len(p.Name)
@@ -75,13 +77,16 @@ func (p *PhyPort) MarshalBinary() (data []byte, err error) {
 }
 
 func (p *PhyPort) UnmarshalBinary(data []byte) error {
+	if len(data) < int(p.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full PhyPort message")
+	}
 	p.PortNo = binary.BigEndian.Uint32(data)
 	n := 4
-	copy(p.pad, data[n:n+4])
+	copy(p.pad[:], data[n:n+4])
 	n += 4
 	copy(p.HWAddr, data[n:n+6])
 	n += 6
-	copy(p.pad2, data[n:n+2])
+	copy(p.pad2[:], data[n:n+2])
 	n += 2
 	copy(p.Name, data[n:n+16])
 	n += 16
@@ -105,18 +110,21 @@ func (p *PhyPort) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
-// ofp_port_mod 1.3
+// PortMod is the message to modify the behavior of the port. Since OpenFlow 1.4, the Advertise field is
+// replaced by a list of properties, the properties layout is used if the version in the header is newer than 1.3.
 type PortMod struct {
 	common.Header
 	PortNo uint32
-	pad    []byte // 4 bytes
+	pad    [4]byte // 4 bytes
 	HWAddr []uint8
-	pad2   []byte // 2 bytes for 64byte alignment
+	pad2   [2]byte // 2 bytes for 64byte alignment
 
 	Config    uint32
 	Mask      uint32
 	Advertise uint32
-	pad3      []uint8 // Size 4
+	pad3      [4]uint8 // Size 4
+
+	Properties []util.Message
 }
 
 func NewPortMod(port int) *PortMod {
@@ -124,65 +132,331 @@ func NewPortMod(port int) *PortMod {
 	p.Header.Type = Type_PortMod
 	p.PortNo = uint32(port)
 	p.HWAddr = make([]byte, ETH_ALEN)
-	p.pad = make([]byte, 4)
-	p.pad2 = make([]byte, 2)
-	p.pad3 = make([]byte, 4)
 	return p
 }
 
+// AddProperty adds a port mod property, it requires OpenFlow 1.4 or later.
+func (p *PortMod) AddProperty(prop util.Message) {
+	p.Properties = append(p.Properties, prop)
+}
+
+func (p *PortMod) usePropertiesLayout() bool {
+	return p.Header.Version > VERSION
+}
+
 func (p *PortMod) Len() (n uint16) {
-	return p.Header.Len() + 4 + 4 + ETH_ALEN + 2 + 12 + 4
+	n = p.Header.Len() + 4 + 4 + ETH_ALEN + 2 + 8
+	if p.usePropertiesLayout() {
+		for _, prop := range p.Properties {
+			n += prop.Len()
+		}
+		return
+	}
+	return n + 4 + 4
 }
 
 func (p *PortMod) MarshalBinary() (data []byte, err error) {
 	p.Header.Length = p.Len()
 	data, err = p.Header.MarshalBinary()
 
-	b := make([]byte, 32)
+	b := make([]byte, int(p.Len()-p.Header.Len()))
 	n := 0
 	binary.BigEndian.PutUint32(b[n:], p.PortNo)
 	n += 4
-	copy(b[n:], p.pad)
+	copy(b[n:], p.pad[:])
 	n += 4
 	copy(b[n:], p.HWAddr)
 	n += ETH_ALEN
-	copy(b[n:], p.pad2)
+	copy(b[n:], p.pad2[:])
 	n += 2
 	binary.BigEndian.PutUint32(b[n:], p.Config)
 	n += 4
 	binary.BigEndian.PutUint32(b[n:], p.Mask)
 	n += 4
-	binary.BigEndian.PutUint32(b[n:], p.Advertise)
-	n += 4
-	copy(b[n:], p.pad3)
-	n += 4
+	if p.usePropertiesLayout() {
+		for _, prop := range p.Properties {
+			propData, err := prop.MarshalBinary()
+			if err != nil {
+				return nil, err
+			}
+			copy(b[n:], propData)
+			n += len(propData)
+		}
+	} else {
+		binary.BigEndian.PutUint32(b[n:], p.Advertise)
+		n += 4
+		copy(b[n:], p.pad3[:])
+		n += 4
+	}
 	data = append(data, b...)
 	return
 }
 
 func (p *PortMod) UnmarshalBinary(data []byte) error {
 	err := p.Header.UnmarshalBinary(data)
+	if err != nil {
+		return err
+	}
+	// The fixed part of the message, the length of the properties is only known from the header.
+	minLen := int(p.Header.Len()) + 4 + 4 + ETH_ALEN + 2 + 8
+	if !p.usePropertiesLayout() {
+		minLen += 4 + 4
+	}
+	if len(data) < int(p.Header.Length) || int(p.Header.Length) < minLen {
+		return errors.New("the []byte is too short to unmarshal a full PortMod message")
+	}
 	n := int(p.Header.Len())
 
 	p.PortNo = binary.BigEndian.Uint32(data[n:])
 	n += 4
-	copy(p.pad, data[n:n+4])
+	copy(p.pad[:], data[n:n+4])
 	n += 4
+	p.HWAddr = make([]byte, ETH_ALEN)
 	copy(p.HWAddr, data[n:])
-	n += len(p.HWAddr)
-	copy(p.pad2, data[n:n+2])
+	n += ETH_ALEN
+	copy(p.pad2[:], data[n:n+2])
 	n += 2
 	p.Config = binary.BigEndian.Uint32(data[n:])
 	n += 4
 	p.Mask = binary.BigEndian.Uint32(data[n:])
 	n += 4
+	if p.usePropertiesLayout() {
+		p.Properties = nil
+		for n < int(p.Header.Length) {
+			header := new(PortModPropHeader)
+			if err := header.UnmarshalBinary(data[n:p.Header.Length]); err != nil {
+				return decodeErrorAt(err, "PortMod", n, "property")
+			}
+			next, err := safeAdvance(n, header.Length, int(p.Header.Length), "port mod property")
+			if err != nil {
+				return err
+			}
+			prop, err := DecodePortModProp(data[n:next])
+			if err != nil {
+				return decodeErrorAt(err, "PortMod", n, "property")
+			}
+			p.Properties = append(p.Properties, prop)
+			n = next
+		}
+		return nil
+	}
 	p.Advertise = binary.BigEndian.Uint32(data[n:])
 	n += 4
-	copy(p.pad3, data[n:])
+	copy(p.pad3[:], data[n:])
 	n += 4
 	return err
 }
 
+// ofp_port_mod_prop_type 1.5
+const (
+	PMPT_ETHERNET     = 0      /* Ethernet property. */
+	PMPT_OPTICAL      = 1      /* Optical property. */
+	PMPT_EXPERIMENTER = 0xffff /* Experimenter property. */
+)
+
+// ofp_optical_port_features 1.5
+const (
+	OPF_RX_TUNE  = 1 << 0 /* Receiver is tunable. */
+	OPF_TX_TUNE  = 1 << 1 /* Transmit is tunable. */
+	OPF_TX_PWR   = 1 << 2 /* Power is configurable. */
+	OPF_USE_FREQ = 1 << 3 /* Use Frequency, not wavelength. */
+)
+
+// PortModPropHeader is the common header of all port mod properties.
+type PortModPropHeader struct {
+	Type   uint16
+	Length uint16
+}
+
+func (p *PortModPropHeader) Len() uint16 {
+	return 4
+}
+
+func (p *PortModPropHeader) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, p.Len())
+	binary.BigEndian.PutUint16(data[0:], p.Type)
+	binary.BigEndian.PutUint16(data[2:], p.Length)
+	return
+}
+
+func (p *PortModPropHeader) UnmarshalBinary(data []byte) error {
+	if len(data) < int(p.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full PortModPropHeader message")
+	}
+	p.Type = binary.BigEndian.Uint16(data[0:])
+	p.Length = binary.BigEndian.Uint16(data[2:])
+	return nil
+}
+
+// PortModPropEthernet is used to modify the advertised features of an ethernet port.
+type PortModPropEthernet struct {
+	PortModPropHeader
+	Advertise uint32 /* Bitmap of PF_*. Zero all bits to prevent any action taking place. */
+}
+
+func NewPortModPropEthernet(advertise uint32) *PortModPropEthernet {
+	p := new(PortModPropEthernet)
+	p.Type = PMPT_ETHERNET
+	p.Length = p.Len()
+	p.Advertise = advertise
+	return p
+}
+
+func (p *PortModPropEthernet) Len() uint16 {
+	return p.PortModPropHeader.Len() + 4
+}
+
+func (p *PortModPropEthernet) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, p.Len())
+	p.Length = p.Len()
+	b, err := p.PortModPropHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	binary.BigEndian.PutUint32(data[n:], p.Advertise)
+	return
+}
+
+func (p *PortModPropEthernet) UnmarshalBinary(data []byte) error {
+	if len(data) < int(p.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full PortModPropEthernet message")
+	}
+	if err := p.PortModPropHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	p.Advertise = binary.BigEndian.Uint32(data[p.PortModPropHeader.Len():])
+	return nil
+}
+
+// PortModPropOptical is used to configure an optical port.
+type PortModPropOptical struct {
+	PortModPropHeader
+	Configure uint32 /* Bitmap of OPF_*. */
+	FreqLmda  uint32 /* The "center" frequency. */
+	FlOffset  int32  /* Signed frequency offset. */
+	GridSpan  uint32 /* The size of the grid for this port. */
+	TxPwr     uint32 /* Tx power setting. */
+}
+
+func NewPortModPropOptical(configure uint32, freqLmda uint32, flOffset int32, gridSpan uint32, txPwr uint32) *PortModPropOptical {
+	p := new(PortModPropOptical)
+	p.Type = PMPT_OPTICAL
+	p.Length = p.Len()
+	p.Configure = configure
+	p.FreqLmda = freqLmda
+	p.FlOffset = flOffset
+	p.GridSpan = gridSpan
+	p.TxPwr = txPwr
+	return p
+}
+
+func (p *PortModPropOptical) Len() uint16 {
+	return p.PortModPropHeader.Len() + 20
+}
+
+func (p *PortModPropOptical) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, p.Len())
+	p.Length = p.Len()
+	b, err := p.PortModPropHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	binary.BigEndian.PutUint32(data[n:], p.Configure)
+	n += 4
+	binary.BigEndian.PutUint32(data[n:], p.FreqLmda)
+	n += 4
+	binary.BigEndian.PutUint32(data[n:], uint32(p.FlOffset))
+	n += 4
+	binary.BigEndian.PutUint32(data[n:], p.GridSpan)
+	n += 4
+	binary.BigEndian.PutUint32(data[n:], p.TxPwr)
+	n += 4
+	return
+}
+
+func (p *PortModPropOptical) UnmarshalBinary(data []byte) error {
+	if len(data) < int(p.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full PortModPropOptical message")
+	}
+	if err := p.PortModPropHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	n := int(p.PortModPropHeader.Len())
+	p.Configure = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	p.FreqLmda = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	p.FlOffset = int32(binary.BigEndian.Uint32(data[n:]))
+	n += 4
+	p.GridSpan = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	p.TxPwr = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	return nil
+}
+
+// PortModPropUnknown keeps the raw data of the port mod property which is not supported, e.g., experimenter property.
+type PortModPropUnknown struct {
+	PortModPropHeader
+	Data []byte
+}
+
+func (p *PortModPropUnknown) Len() uint16 {
+	return p.PortModPropHeader.Len() + uint16(len(p.Data))
+}
+
+func (p *PortModPropUnknown) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, p.Len())
+	p.Length = p.Len()
+	b, err := p.PortModPropHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	copy(data[n:], p.Data)
+	return
+}
+
+func (p *PortModPropUnknown) UnmarshalBinary(data []byte) error {
+	if err := p.PortModPropHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if len(data) < int(p.Length) || p.Length < p.PortModPropHeader.Len() {
+		return errors.New("the []byte is too short to unmarshal a full PortModPropUnknown message")
+	}
+	p.Data = make([]byte, int(p.Length-p.PortModPropHeader.Len()))
+	copy(p.Data, data[p.PortModPropHeader.Len():p.Length])
+	return nil
+}
+
+// DecodePortModProp decodes a port mod property according to its type.
+func DecodePortModProp(data []byte) (util.Message, error) {
+	header := new(PortModPropHeader)
+	if err := header.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	if header.Length < header.Len() || int(header.Length) > len(data) {
+		return nil, errors.New("the []byte is too short to unmarshal a full port mod property")
+	}
+	// The property is decoded within its own length.
+	data = data[:header.Length]
+	var prop util.Message
+	switch header.Type {
+	case PMPT_ETHERNET:
+		prop = new(PortModPropEthernet)
+	case PMPT_OPTICAL:
+		prop = new(PortModPropOptical)
+	default:
+		prop = new(PortModPropUnknown)
+	}
+	if err := prop.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return prop, nil
+}
+
 const (
 	ETH_ALEN          = 6
 	MAX_PORT_NAME_LEN = 16
@@ -204,22 +478,6 @@ const (
 	PS_LIVE      = 1 << 2
 )
 
-// ofp_port_no 1.3
-const (
-	P_MAX = 0xffffff00
-
-	P_IN_PORT = 0xfffffff8
-	P_TABLE   = 0xfffffff9
-
-	P_NORMAL = 0xfffffffa
-	P_FLOOD  = 0xfffffffb
-
-	P_ALL        = 0xfffffffc
-	P_CONTROLLER = 0xfffffffd
-	P_LOCAL      = 0xfffffffe
-	P_ANY        = 0xffffffff
-)
-
 // ofp_port_features 1.3
 const (
 	PF_10MB_HD  = 1 << 0
@@ -0,0 +1,667 @@
+package openflow13
+
+// This file has the OpenFlow 1.5 port description, and the version agnostic Port view of the ports.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+// ofp_port_desc_prop_type 1.5
+const (
+	PDPT_ETHERNET        = 0      /* Ethernet property. */
+	PDPT_OPTICAL         = 1      /* Optical property. */
+	PDPT_PIPELINE_INPUT  = 2      /* Ingress pipeline fields. */
+	PDPT_PIPELINE_OUTPUT = 3      /* Egress pipeline fields. */
+	PDPT_RECIRCULATE     = 4      /* Recirculation property. */
+	PDPT_EXPERIMENTER    = 0xffff /* Experimenter property. */
+)
+
+// PortDescPropHeader is the common header of all port description properties.
+type PortDescPropHeader struct {
+	Type   uint16
+	Length uint16
+}
+
+func (p *PortDescPropHeader) Len() uint16 {
+	return 4
+}
+
+func (p *PortDescPropHeader) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, p.Len())
+	binary.BigEndian.PutUint16(data[0:], p.Type)
+	binary.BigEndian.PutUint16(data[2:], p.Length)
+	return
+}
+
+func (p *PortDescPropHeader) UnmarshalBinary(data []byte) error {
+	if len(data) < int(p.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full PortDescPropHeader message")
+	}
+	p.Type = binary.BigEndian.Uint16(data[0:])
+	p.Length = binary.BigEndian.Uint16(data[2:])
+	return nil
+}
+
+// PortDescPropEthernet has the features and the speeds of an ethernet port, which are the flat fields of
+// ofp_port in OpenFlow 1.3.
+type PortDescPropEthernet struct {
+	PortDescPropHeader
+	pad        [4]byte
+	Curr       uint32 /* Current features. */
+	Advertised uint32 /* Features being advertised by the port. */
+	Supported  uint32 /* Features supported by the port. */
+	Peer       uint32 /* Features advertised by peer. */
+	CurrSpeed  uint32 /* Current port bitrate in kbps. */
+	MaxSpeed   uint32 /* Max port bitrate in kbps. */
+}
+
+func NewPortDescPropEthernet() *PortDescPropEthernet {
+	p := new(PortDescPropEthernet)
+	p.Type = PDPT_ETHERNET
+	p.Length = p.Len()
+	return p
+}
+
+func (p *PortDescPropEthernet) Len() uint16 {
+	return 32
+}
+
+func (p *PortDescPropEthernet) MarshalBinary() (data []byte, err error) {
+	p.Length = p.Len()
+	data = make([]byte, p.Len())
+	b, err := p.PortDescPropHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	n += 4 // for pad
+	for _, v := range []uint32{p.Curr, p.Advertised, p.Supported, p.Peer, p.CurrSpeed, p.MaxSpeed} {
+		binary.BigEndian.PutUint32(data[n:], v)
+		n += 4
+	}
+	return
+}
+
+func (p *PortDescPropEthernet) UnmarshalBinary(data []byte) error {
+	if len(data) < int(p.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full PortDescPropEthernet message")
+	}
+	if err := p.PortDescPropHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	n := 8
+	for _, v := range []*uint32{&p.Curr, &p.Advertised, &p.Supported, &p.Peer, &p.CurrSpeed, &p.MaxSpeed} {
+		*v = binary.BigEndian.Uint32(data[n:])
+		n += 4
+	}
+	return nil
+}
+
+// PortDescPropOptical has the features, the frequency or wavelength ranges and the power range of an optical
+// port.
+type PortDescPropOptical struct {
+	PortDescPropHeader
+	pad            [4]byte
+	Supported      uint32 /* Bitmap of OPF_*. */
+	TxMinFreqLmda  uint32 /* Minimum TX Frequency/Wavelength. */
+	TxMaxFreqLmda  uint32 /* Maximum TX Frequency/Wavelength. */
+	TxGridFreqLmda uint32 /* TX Grid Spacing Frequency/Wavelength. */
+	RxMinFreqLmda  uint32 /* Minimum RX Frequency/Wavelength. */
+	RxMaxFreqLmda  uint32 /* Maximum RX Frequency/Wavelength. */
+	RxGridFreqLmda uint32 /* RX Grid Spacing Frequency/Wavelength. */
+	TxPwrMin       uint16 /* Minimum TX power. */
+	TxPwrMax       uint16 /* Maximum TX power. */
+}
+
+func NewPortDescPropOptical() *PortDescPropOptical {
+	p := new(PortDescPropOptical)
+	p.Type = PDPT_OPTICAL
+	p.Length = p.Len()
+	return p
+}
+
+func (p *PortDescPropOptical) Len() uint16 {
+	return 40
+}
+
+func (p *PortDescPropOptical) MarshalBinary() (data []byte, err error) {
+	p.Length = p.Len()
+	data = make([]byte, p.Len())
+	b, err := p.PortDescPropHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	n += 4 // for pad
+	for _, v := range []uint32{p.Supported, p.TxMinFreqLmda, p.TxMaxFreqLmda, p.TxGridFreqLmda, p.RxMinFreqLmda,
+		p.RxMaxFreqLmda, p.RxGridFreqLmda} {
+		binary.BigEndian.PutUint32(data[n:], v)
+		n += 4
+	}
+	binary.BigEndian.PutUint16(data[n:], p.TxPwrMin)
+	n += 2
+	binary.BigEndian.PutUint16(data[n:], p.TxPwrMax)
+	return
+}
+
+func (p *PortDescPropOptical) UnmarshalBinary(data []byte) error {
+	if len(data) < int(p.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full PortDescPropOptical message")
+	}
+	if err := p.PortDescPropHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	n := 8
+	for _, v := range []*uint32{&p.Supported, &p.TxMinFreqLmda, &p.TxMaxFreqLmda, &p.TxGridFreqLmda, &p.RxMinFreqLmda,
+		&p.RxMaxFreqLmda, &p.RxGridFreqLmda} {
+		*v = binary.BigEndian.Uint32(data[n:])
+		n += 4
+	}
+	p.TxPwrMin = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	p.TxPwrMax = binary.BigEndian.Uint16(data[n:])
+	return nil
+}
+
+// padPortDescProp pads the marshaled property to 8 bytes, the padding is not counted in the length.
+func padPortDescProp(data []byte) []byte {
+	if pad := len(data) % 8; pad != 0 {
+		data = append(data, make([]byte, 8-pad)...)
+	}
+	return data
+}
+
+// PortDescPropRecirculate has the port numbers on which the packets output to the port may be received again,
+// e.g., the ports of a loopback or of a recirculation pipeline.
+type PortDescPropRecirculate struct {
+	PortDescPropHeader
+	PortNos []uint32
+}
+
+func NewPortDescPropRecirculate(portNos ...uint32) *PortDescPropRecirculate {
+	p := new(PortDescPropRecirculate)
+	p.Type = PDPT_RECIRCULATE
+	p.PortNos = portNos
+	p.Length = p.Len()
+	return p
+}
+
+func (p *PortDescPropRecirculate) Len() uint16 {
+	return p.PortDescPropHeader.Len() + uint16(4*len(p.PortNos))
+}
+
+func (p *PortDescPropRecirculate) MarshalBinary() (data []byte, err error) {
+	p.Length = p.Len()
+	data = make([]byte, p.Len())
+	b, err := p.PortDescPropHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	for _, portNo := range p.PortNos {
+		binary.BigEndian.PutUint32(data[n:], portNo)
+		n += 4
+	}
+	return padPortDescProp(data), nil
+}
+
+func (p *PortDescPropRecirculate) UnmarshalBinary(data []byte) error {
+	if err := p.PortDescPropHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if p.Length < p.PortDescPropHeader.Len() || int(p.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full PortDescPropRecirculate message")
+	}
+	p.PortNos = nil
+	for n := int(p.PortDescPropHeader.Len()); n+4 <= int(p.Length); n += 4 {
+		p.PortNos = append(p.PortNos, binary.BigEndian.Uint32(data[n:]))
+	}
+	return nil
+}
+
+// PortDescPropExperimenter is the port description property of an experimenter, Data is the experimenter
+// defined data after the experimenter type.
+type PortDescPropExperimenter struct {
+	PortDescPropHeader
+	Experimenter uint32
+	ExpType      uint32
+	Data         []byte
+}
+
+func NewPortDescPropExperimenter(experimenter, expType uint32, data []byte) *PortDescPropExperimenter {
+	p := new(PortDescPropExperimenter)
+	p.Type = PDPT_EXPERIMENTER
+	p.Experimenter = experimenter
+	p.ExpType = expType
+	p.Data = data
+	p.Length = p.Len()
+	return p
+}
+
+func (p *PortDescPropExperimenter) Len() uint16 {
+	return p.PortDescPropHeader.Len() + 8 + uint16(len(p.Data))
+}
+
+func (p *PortDescPropExperimenter) MarshalBinary() (data []byte, err error) {
+	p.Length = p.Len()
+	data = make([]byte, p.Len())
+	b, err := p.PortDescPropHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	binary.BigEndian.PutUint32(data[n:], p.Experimenter)
+	n += 4
+	binary.BigEndian.PutUint32(data[n:], p.ExpType)
+	n += 4
+	copy(data[n:], p.Data)
+	return padPortDescProp(data), nil
+}
+
+func (p *PortDescPropExperimenter) UnmarshalBinary(data []byte) error {
+	if err := p.PortDescPropHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if p.Length < p.PortDescPropHeader.Len()+8 || int(p.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full PortDescPropExperimenter message")
+	}
+	n := int(p.PortDescPropHeader.Len())
+	p.Experimenter = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	p.ExpType = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	p.Data = make([]byte, int(p.Length)-n)
+	copy(p.Data, data[n:p.Length])
+	return nil
+}
+
+// PortDescPropUnknown keeps the raw data of the port description property which is not supported, e.g.,
+// pipeline input property.
+type PortDescPropUnknown struct {
+	PortDescPropHeader
+	Data []byte
+}
+
+func (p *PortDescPropUnknown) Len() uint16 {
+	return p.PortDescPropHeader.Len() + uint16(len(p.Data))
+}
+
+func (p *PortDescPropUnknown) MarshalBinary() (data []byte, err error) {
+	p.Length = p.Len()
+	b, err := p.PortDescPropHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return padPortDescProp(append(b, p.Data...)), nil
+}
+
+func (p *PortDescPropUnknown) UnmarshalBinary(data []byte) error {
+	if err := p.PortDescPropHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if p.Length < p.PortDescPropHeader.Len() || int(p.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full PortDescPropUnknown message")
+	}
+	p.Data = make([]byte, int(p.Length-p.PortDescPropHeader.Len()))
+	copy(p.Data, data[p.PortDescPropHeader.Len():p.Length])
+	return nil
+}
+
+// DecodePortDescProp decodes a port description property according to its type.
+func DecodePortDescProp(data []byte) (util.Message, error) {
+	header := new(PortDescPropHeader)
+	if err := header.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	var prop util.Message
+	switch header.Type {
+	case PDPT_ETHERNET:
+		prop = new(PortDescPropEthernet)
+	case PDPT_OPTICAL:
+		prop = new(PortDescPropOptical)
+	case PDPT_RECIRCULATE:
+		prop = new(PortDescPropRecirculate)
+	case PDPT_EXPERIMENTER:
+		prop = new(PortDescPropExperimenter)
+	default:
+		prop = new(PortDescPropUnknown)
+	}
+	if err := prop.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return prop, nil
+}
+
+// Port15 is the ofp_port 1.5, the features and the speeds of the port are in the properties.
+type Port15 struct {
+	PortNo     uint32
+	Length     uint16
+	pad        [2]byte
+	HWAddr     net.HardwareAddr
+	pad2       [2]byte
+	Name       []byte // Size 16
+	Config     uint32
+	State      uint32
+	Properties []util.Message
+}
+
+func NewPort15() *Port15 {
+	p := new(Port15)
+	p.HWAddr = make([]byte, ETH_ALEN)
+	p.Name = make([]byte, MAX_PORT_NAME_LEN)
+	return p
+}
+
+func (p *Port15) Len() (n uint16) {
+	n = 40
+	for _, prop := range p.Properties {
+		n += (prop.Len() + 7) / 8 * 8
+	}
+	return
+}
+
+func (p *Port15) MarshalBinary() (data []byte, err error) {
+	p.Length = p.Len()
+	data = make([]byte, 40)
+	n := 0
+	binary.BigEndian.PutUint32(data[n:], p.PortNo)
+	n += 4
+	binary.BigEndian.PutUint16(data[n:], p.Length)
+	n += 2
+	n += 2 // for pad
+	copy(data[n:n+ETH_ALEN], p.HWAddr)
+	n += ETH_ALEN
+	n += 2 // for pad2
+	copy(data[n:n+MAX_PORT_NAME_LEN], p.Name)
+	n += MAX_PORT_NAME_LEN
+	binary.BigEndian.PutUint32(data[n:], p.Config)
+	n += 4
+	binary.BigEndian.PutUint32(data[n:], p.State)
+	n += 4
+	for _, prop := range p.Properties {
+		b, err := prop.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return
+}
+
+func (p *Port15) UnmarshalBinary(data []byte) error {
+	if len(data) < 40 {
+		return errors.New("the []byte is too short to unmarshal a full Port15 message")
+	}
+	n := 0
+	p.PortNo = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	p.Length = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	if p.Length < 40 || int(p.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full Port15 message")
+	}
+	n += 2 // for pad
+	p.HWAddr = make([]byte, ETH_ALEN)
+	copy(p.HWAddr, data[n:n+ETH_ALEN])
+	n += ETH_ALEN
+	n += 2 // for pad2
+	p.Name = make([]byte, MAX_PORT_NAME_LEN)
+	copy(p.Name, data[n:n+MAX_PORT_NAME_LEN])
+	n += MAX_PORT_NAME_LEN
+	p.Config = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	p.State = binary.BigEndian.Uint32(data[n:])
+	n += 4
+
+	p.Properties = nil
+	for n < int(p.Length) {
+		prop, err := DecodePortDescProp(data[n:p.Length])
+		if err != nil {
+			return decodeErrorAt(err, "Port15", n, "property")
+		}
+		p.Properties = append(p.Properties, prop)
+		// The properties are padded to 8 bytes.
+		length := binary.BigEndian.Uint16(data[n+2:])
+		if n, err = safeAdvance(n, (length+7)/8*8, int(p.Length), "port desc property"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Port is a version agnostic view of a port, it has the flat fields of ofp_port 1.3 and the ethernet property
+// of ofp_port 1.5.
+type Port struct {
+	PortNo uint32
+	HWAddr net.HardwareAddr
+	Name   string
+	Config uint32 /* Bitmap of PC_*. */
+	State  uint32 /* Bitmap of PS_*. */
+
+	// Ethernet is false if an OpenFlow 1.5 port has no ethernet property, then the features and the speeds
+	// are 0.
+	Ethernet   bool
+	Curr       uint32 /* Bitmap of PF_*. */
+	Advertised uint32
+	Supported  uint32
+	Peer       uint32
+	CurrSpeed  uint32 /* Current port bitrate in kbps. */
+	MaxSpeed   uint32 /* Max port bitrate in kbps. */
+
+	// Properties has the OpenFlow 1.5 properties other than the ethernet property.
+	Properties []util.Message
+}
+
+// portFeatureSpeeds is the bitrate in kbps of the PF_* speed features, from the highest.
+var portFeatureSpeeds = []struct {
+	feature uint32
+	kbps    uint32
+}{
+	{PF_1TB_FD, 1000000000},
+	{PF_100GB_FD, 100000000},
+	{PF_40GB_FD, 40000000},
+	{PF_10GB_FD, 10000000},
+	{PF_1GB_FD | PF_1GB_HD, 1000000},
+	{PF_100MB_FD | PF_100MB_HD, 100000},
+	{PF_10MB_FD | PF_10MB_HD, 10000},
+}
+
+// PortFeatureSpeedKbps returns the highest bitrate in kbps of the PF_* features, or 0 if there is no speed
+// feature, e.g., PF_OTHER.
+func PortFeatureSpeedKbps(features uint32) uint32 {
+	for _, s := range portFeatureSpeeds {
+		if features&s.feature != 0 {
+			return s.kbps
+		}
+	}
+	return 0
+}
+
+const (
+	portFullDuplexFeatures = PF_10MB_FD | PF_100MB_FD | PF_1GB_FD | PF_10GB_FD | PF_40GB_FD | PF_100GB_FD | PF_1TB_FD
+	portHalfDuplexFeatures = PF_10MB_HD | PF_100MB_HD | PF_1GB_HD
+)
+
+// PortFeatureFullDuplex returns true if the PF_* features have a full duplex speed.
+func PortFeatureFullDuplex(features uint32) bool {
+	return features&portFullDuplexFeatures != 0
+}
+
+// PortFeatureHalfDuplex returns true if the PF_* features have only the half duplex speeds.
+func PortFeatureHalfDuplex(features uint32) bool {
+	return features&portHalfDuplexFeatures != 0 && !PortFeatureFullDuplex(features)
+}
+
+// IsUp returns true if the port is administratively up and the link is up.
+func (p *Port) IsUp() bool {
+	return p.Config&PC_PORT_DOWN == 0 && p.State&PS_LINK_DOWN == 0
+}
+
+// CurrSpeedKbps returns the current bitrate of the port in kbps. Some switches leave curr_speed 0, the speed of
+// the current features is used then.
+func (p *Port) CurrSpeedKbps() uint32 {
+	if p.CurrSpeed != 0 {
+		return p.CurrSpeed
+	}
+	return PortFeatureSpeedKbps(p.Curr)
+}
+
+// MaxSpeedKbps returns the max bitrate of the port in kbps, the speed of the supported features is used if
+// max_speed is 0.
+func (p *Port) MaxSpeedKbps() uint32 {
+	if p.MaxSpeed != 0 {
+		return p.MaxSpeed
+	}
+	return PortFeatureSpeedKbps(p.Supported)
+}
+
+// IsFullDuplex returns true if the port currently runs in full duplex.
+func (p *Port) IsFullDuplex() bool {
+	return PortFeatureFullDuplex(p.Curr)
+}
+
+// IsHalfDuplex returns true if the port currently runs in half duplex.
+func (p *Port) IsHalfDuplex() bool {
+	return PortFeatureHalfDuplex(p.Curr)
+}
+
+// Optical returns the optical property of an OpenFlow 1.5 port, or nil if the port has none.
+func (p *Port) Optical() *PortDescPropOptical {
+	for _, prop := range p.Properties {
+		if optical, ok := prop.(*PortDescPropOptical); ok {
+			return optical
+		}
+	}
+	return nil
+}
+
+// RecirculatePortNos returns the port numbers of the recirculate property of an OpenFlow 1.5 port, or nil if
+// the port has none.
+func (p *Port) RecirculatePortNos() []uint32 {
+	for _, prop := range p.Properties {
+		if recirculate, ok := prop.(*PortDescPropRecirculate); ok {
+			return recirculate.PortNos
+		}
+	}
+	return nil
+}
+
+// ExperimenterProperties returns the experimenter properties of an OpenFlow 1.5 port.
+func (p *Port) ExperimenterProperties() []*PortDescPropExperimenter {
+	var props []*PortDescPropExperimenter
+	for _, prop := range p.Properties {
+		if exp, ok := prop.(*PortDescPropExperimenter); ok {
+			props = append(props, exp)
+		}
+	}
+	return props
+}
+
+func portName(name []byte) string {
+	if i := bytes.IndexByte(name, 0); i >= 0 {
+		name = name[:i]
+	}
+	return string(name)
+}
+
+func portNameBytes(name string) []byte {
+	b := make([]byte, MAX_PORT_NAME_LEN)
+	// Keep the name NUL terminated.
+	copy(b[:MAX_PORT_NAME_LEN-1], name)
+	return b
+}
+
+func copyHWAddr(addr net.HardwareAddr) net.HardwareAddr {
+	b := make(net.HardwareAddr, ETH_ALEN)
+	copy(b, addr)
+	return b
+}
+
+// NewPortFromPhyPort returns the Port of an OpenFlow 1.3 port.
+func NewPortFromPhyPort(p *PhyPort) *Port {
+	return &Port{
+		PortNo:     p.PortNo,
+		HWAddr:     copyHWAddr(p.HWAddr),
+		Name:       portName(p.Name),
+		Config:     p.Config,
+		State:      p.State,
+		Ethernet:   true,
+		Curr:       p.Curr,
+		Advertised: p.Advertised,
+		Supported:  p.Supported,
+		Peer:       p.Peer,
+		CurrSpeed:  p.CurrSpeed,
+		MaxSpeed:   p.MaxSpeed,
+	}
+}
+
+// NewPortFromPort15 returns the Port of an OpenFlow 1.5 port.
+func NewPortFromPort15(p *Port15) *Port {
+	port := &Port{
+		PortNo: p.PortNo,
+		HWAddr: copyHWAddr(p.HWAddr),
+		Name:   portName(p.Name),
+		Config: p.Config,
+		State:  p.State,
+	}
+	for _, prop := range p.Properties {
+		eth, ok := prop.(*PortDescPropEthernet)
+		if !ok {
+			port.Properties = append(port.Properties, prop)
+			continue
+		}
+		port.Ethernet = true
+		port.Curr = eth.Curr
+		port.Advertised = eth.Advertised
+		port.Supported = eth.Supported
+		port.Peer = eth.Peer
+		port.CurrSpeed = eth.CurrSpeed
+		port.MaxSpeed = eth.MaxSpeed
+	}
+	return port
+}
+
+// ToPhyPort returns the OpenFlow 1.3 port, the properties are dropped.
+func (p *Port) ToPhyPort() *PhyPort {
+	phy := NewPhyPort()
+	phy.PortNo = p.PortNo
+	phy.HWAddr = copyHWAddr(p.HWAddr)
+	phy.Name = portNameBytes(p.Name)
+	phy.Config = p.Config
+	phy.State = p.State
+	phy.Curr = p.Curr
+	phy.Advertised = p.Advertised
+	phy.Supported = p.Supported
+	phy.Peer = p.Peer
+	phy.CurrSpeed = p.CurrSpeed
+	phy.MaxSpeed = p.MaxSpeed
+	return phy
+}
+
+// ToPort15 returns the OpenFlow 1.5 port, the ethernet property is added if Ethernet is true.
+func (p *Port) ToPort15() *Port15 {
+	port := NewPort15()
+	port.PortNo = p.PortNo
+	port.HWAddr = copyHWAddr(p.HWAddr)
+	port.Name = portNameBytes(p.Name)
+	port.Config = p.Config
+	port.State = p.State
+	if p.Ethernet {
+		eth := NewPortDescPropEthernet()
+		eth.Curr = p.Curr
+		eth.Advertised = p.Advertised
+		eth.Supported = p.Supported
+		eth.Peer = p.Peer
+		eth.CurrSpeed = p.CurrSpeed
+		eth.MaxSpeed = p.MaxSpeed
+		port.Properties = append(port.Properties, eth)
+	}
+	port.Properties = append(port.Properties, p.Properties...)
+	port.Length = port.Len()
+	return port
+}
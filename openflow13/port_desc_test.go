@@ -0,0 +1,106 @@
+package openflow13
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+func TestPortConversion(t *testing.T) {
+	phy := NewPhyPort()
+	phy.PortNo = 3
+	phy.HWAddr, _ = net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	copy(phy.Name, "eth0")
+	phy.State = PS_LIVE
+	phy.Curr = PF_10GB_FD | PF_FIBER
+	phy.Supported = PF_10GB_FD | PF_40GB_FD
+
+	port := NewPortFromPhyPort(phy)
+	if port.Name != "eth0" || !port.IsUp() || !port.Ethernet {
+		t.Errorf("Unexpected port: %+v", port)
+	}
+	// The speeds are normalized from the features as the switch leaves them 0.
+	if port.CurrSpeedKbps() != 10000000 || port.MaxSpeedKbps() != 40000000 {
+		t.Errorf("Unexpected speeds %d/%d", port.CurrSpeedKbps(), port.MaxSpeedKbps())
+	}
+
+	port.Properties = append(port.Properties, &PortDescPropUnknown{PortDescPropHeader: PortDescPropHeader{Type: PDPT_RECIRCULATE}, Data: []byte{0, 0, 0, 1}})
+	port15 := port.ToPort15()
+	data, err := port15.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal Port15: %v", err)
+	}
+	if len(data) != int(port15.Len()) || len(data) != 40+32+8 {
+		t.Errorf("Unexpected Port15 length %d, Len(): %d", len(data), port15.Len())
+	}
+	port15 = new(Port15)
+	if err = port15.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal Port15: %v", err)
+	}
+	port2 := NewPortFromPort15(port15)
+	if port2.Name != "eth0" || port2.Curr != phy.Curr || port2.Supported != phy.Supported || len(port2.Properties) != 1 {
+		t.Errorf("Unexpected port from Port15: %+v", port2)
+	}
+
+	phy2 := port2.ToPhyPort()
+	b1, _ := phy.MarshalBinary()
+	b2, _ := phy2.MarshalBinary()
+	if !bytes.Equal(b1, b2) {
+		t.Errorf("PhyPort changed after the conversions, expect %x, actual %x", b1, b2)
+	}
+
+	port2.Config = PC_PORT_DOWN
+	if port2.IsUp() {
+		t.Errorf("Expect the port administratively down")
+	}
+}
+
+func TestPortDescProperties(t *testing.T) {
+	optical := NewPortDescPropOptical()
+	optical.Supported = OPF_RX_TUNE | OPF_USE_FREQ
+	optical.TxMinFreqLmda = 191000
+	optical.TxPwrMax = 30
+
+	port := &Port{PortNo: 5, Name: "opt0", Curr: PF_100MB_HD, Properties: []util.Message{
+		optical,
+		NewPortDescPropRecirculate(7, 8, 9),
+		NewPortDescPropExperimenter(0x2320, 1, []byte{1, 2, 3}),
+	}}
+	if !port.IsHalfDuplex() || port.IsFullDuplex() || port.CurrSpeedKbps() != 100000 {
+		t.Errorf("Unexpected duplex or speed of features 0x%x", port.Curr)
+	}
+
+	status := NewPortStatus()
+	status.Header.Version = OFP15_VERSION
+	status.Reason = PR_MODIFY
+	status.Desc15 = *port.ToPort15()
+	data, err := status.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal PortStatus: %v", err)
+	}
+	// The recirculate property of 16 bytes and the experimenter property of 15 bytes are padded.
+	if len(data) != int(status.Len()) || len(data) != 16+40+40+16+16 {
+		t.Errorf("Unexpected PortStatus length %d, Len(): %d", len(data), status.Len())
+	}
+	status = new(PortStatus)
+	if err = status.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal PortStatus: %v", err)
+	}
+	port2 := status.Port()
+	if port2.PortNo != 5 || port2.Name != "opt0" || port2.Optical() == nil || *port2.Optical() != *optical {
+		t.Errorf("Unexpected port from PortStatus: %+v", port2)
+	}
+	if portNos := port2.RecirculatePortNos(); len(portNos) != 3 || portNos[2] != 9 {
+		t.Errorf("Unexpected recirculate ports %v", portNos)
+	}
+	if exps := port2.ExperimenterProperties(); len(exps) != 1 || exps[0].ExpType != 1 || !bytes.Equal(exps[0].Data, []byte{1, 2, 3}) {
+		t.Errorf("Unexpected experimenter properties %+v", exps)
+	}
+
+	if PortFeatureSpeedKbps(PF_1GB_HD|PF_10GB_FD) != 10000000 || !PortFeatureFullDuplex(PF_1GB_HD|PF_10GB_FD) ||
+		PortFeatureHalfDuplex(PF_1GB_HD|PF_10GB_FD) || PortFeatureSpeedKbps(PF_OTHER) != 0 {
+		t.Errorf("Unexpected speed or duplex of the features")
+	}
+}
@@ -0,0 +1,103 @@
+package openflow13
+
+import (
+	"fmt"
+	"net"
+)
+
+// portConfigBits is the PC_* bits which the controller could change with PortMod.
+const portConfigBits = PC_PORT_DOWN | PC_NO_RECV | PC_NO_FWD | PC_NO_PACKET_IN
+
+// PortModBuilder builds a PortMod which changes only the config bits set by it, e.g.,
+//
+//	NewPortModBuilder(port).PortDown(true).NoPacketIn(false).Build()
+//
+// sets PC_PORT_DOWN and clears PC_NO_PACKET_IN, the Mask of the PortMod has exactly these bits so that the switch
+// keeps the other bits of the port config. The port number and the hardware address are of the port, as the
+// switch rejects the PortMod with PMFC_BAD_HW_ADDR if the address doesn't match. The features are advertised only
+// if Advertise is called, with the ethernet property if the version is newer than OpenFlow 1.3.
+type PortModBuilder struct {
+	version   uint8
+	portNo    uint32
+	hwAddr    net.HardwareAddr
+	config    uint32
+	mask      uint32
+	advertise *uint32
+	err       error
+}
+
+func NewPortModBuilder(port *Port) *PortModBuilder {
+	return &PortModBuilder{version: VERSION, portNo: port.PortNo, hwAddr: copyHWAddr(port.HWAddr)}
+}
+
+// Version sets the OpenFlow version of the PortMod, it is OpenFlow 1.3 by default.
+func (b *PortModBuilder) Version(version uint8) *PortModBuilder {
+	b.version = version
+	return b
+}
+
+// SetConfig sets the PC_* bits if on is true, or clears them. The bits which are not PC_* bits fail Build.
+func (b *PortModBuilder) SetConfig(bits uint32, on bool) *PortModBuilder {
+	if invalid := bits &^ portConfigBits; invalid != 0 {
+		if b.err == nil {
+			b.err = fmt.Errorf("invalid port config bits 0x%x", invalid)
+		}
+		return b
+	}
+	b.mask |= bits
+	if on {
+		b.config |= bits
+	} else {
+		b.config &^= bits
+	}
+	return b
+}
+
+// PortDown sets the port administratively down, or up.
+func (b *PortModBuilder) PortDown(down bool) *PortModBuilder {
+	return b.SetConfig(PC_PORT_DOWN, down)
+}
+
+// NoRecv drops all the packets received by the port, or stops dropping them.
+func (b *PortModBuilder) NoRecv(noRecv bool) *PortModBuilder {
+	return b.SetConfig(PC_NO_RECV, noRecv)
+}
+
+// NoFwd drops the packets forwarded to the port, or stops dropping them.
+func (b *PortModBuilder) NoFwd(noFwd bool) *PortModBuilder {
+	return b.SetConfig(PC_NO_FWD, noFwd)
+}
+
+// NoPacketIn stops sending the PacketIn messages of the packets received by the port, or resumes sending them.
+func (b *PortModBuilder) NoPacketIn(noPacketIn bool) *PortModBuilder {
+	return b.SetConfig(PC_NO_PACKET_IN, noPacketIn)
+}
+
+// Advertise sets the PF_* features advertised by the port.
+func (b *PortModBuilder) Advertise(features uint32) *PortModBuilder {
+	b.advertise = &features
+	return b
+}
+
+// Build returns the PortMod, or the error of an invalid config bit.
+func (b *PortModBuilder) Build() (*PortMod, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	p := NewPortMod(0)
+	p.Header = NewOfp13Header()
+	p.Header.Version = b.version
+	p.Header.Type = Type_PortMod
+	p.PortNo = b.portNo
+	copy(p.HWAddr, b.hwAddr)
+	p.Config = b.config
+	p.Mask = b.mask
+	if b.advertise != nil {
+		if p.usePropertiesLayout() {
+			p.AddProperty(NewPortModPropEthernet(*b.advertise))
+		} else {
+			p.Advertise = *b.advertise
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,41 @@
+package openflow13
+
+import (
+	"net"
+	"testing"
+)
+
+func TestPortModBuilder(t *testing.T) {
+	hwAddr, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	port := &Port{PortNo: 3, HWAddr: hwAddr, Config: PC_NO_PACKET_IN}
+
+	portMod, err := NewPortModBuilder(port).PortDown(true).NoPacketIn(false).Build()
+	if err != nil {
+		t.Fatalf("Failed to build PortMod: %v", err)
+	}
+	if portMod.PortNo != 3 || portMod.Config != PC_PORT_DOWN || portMod.Mask != PC_PORT_DOWN|PC_NO_PACKET_IN ||
+		net.HardwareAddr(portMod.HWAddr).String() != hwAddr.String() {
+		t.Errorf("Unexpected PortMod: %+v", portMod)
+	}
+
+	portMod, err = NewPortModBuilder(port).Version(OFP15_VERSION).NoFwd(true).Advertise(PF_10GB_FD).Build()
+	if err != nil {
+		t.Fatalf("Failed to build PortMod: %v", err)
+	}
+	data, err := portMod.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal PortMod: %v", err)
+	}
+	portMod = NewPortMod(0)
+	if err = portMod.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal PortMod: %v", err)
+	}
+	if len(portMod.Properties) != 1 || portMod.Properties[0].(*PortModPropEthernet).Advertise != PF_10GB_FD ||
+		portMod.Config != PC_NO_FWD || portMod.Mask != PC_NO_FWD {
+		t.Errorf("Unexpected PortMod of OpenFlow 1.5: %+v", portMod)
+	}
+
+	if _, err = NewPortModBuilder(port).SetConfig(PS_LIVE<<8, true).Build(); err == nil {
+		t.Errorf("Expect an error of the invalid config bits")
+	}
+}
@@ -0,0 +1,84 @@
+package openflow13
+
+import (
+	"fmt"
+
+	"github.com/contiv/libOpenflow/common"
+)
+
+// PortMask is a L4 port with a bitmask, it matches all the ports p where p&Mask == Port.
+type PortMask struct {
+	Port uint16
+	Mask uint16
+}
+
+// PortRangeToMasks decomposes the port range [start, end] into the minimal set of port/mask pairs which match
+// exactly the ports in the range, e.g., 1000-1999 is decomposed into 7 pairs.
+func PortRangeToMasks(start, end uint16) []PortMask {
+	var masks []PortMask
+	for port := uint32(start); port <= uint32(end); {
+		// The largest block which is aligned at port, and doesn't exceed end.
+		size := uint32(1 << 16)
+		if port != 0 {
+			size = port & -port
+		}
+		for port+size-1 > uint32(end) {
+			size >>= 1
+		}
+		masks = append(masks, PortMask{Port: uint16(port), Mask: uint16(^(size - 1))})
+		port += size
+	}
+	return masks
+}
+
+// NewPortMaskField returns a MatchField of the L4 port field, i.e., OXM_FIELD_TCP_SRC, OXM_FIELD_TCP_DST,
+// OXM_FIELD_UDP_SRC, OXM_FIELD_UDP_DST, OXM_FIELD_SCTP_SRC or OXM_FIELD_SCTP_DST, with the port and mask.
+// The mask is omitted if it is all ones.
+func NewPortMaskField(field uint8, portMask PortMask) (*MatchField, error) {
+	switch field {
+	case OXM_FIELD_TCP_SRC, OXM_FIELD_TCP_DST, OXM_FIELD_UDP_SRC, OXM_FIELD_UDP_DST, OXM_FIELD_SCTP_SRC, OXM_FIELD_SCTP_DST:
+	default:
+		return nil, fmt.Errorf("field %d is not a L4 port field", field)
+	}
+	f := new(MatchField)
+	f.Class = OXM_CLASS_OPENFLOW_BASIC
+	f.Field = field
+	f.HasMask = false
+
+	portField := NewPortField(portMask.Port)
+	f.Value = portField
+	f.Length = uint8(portField.Len())
+
+	if portMask.Mask != 0xffff {
+		mask := NewPortField(portMask.Mask)
+		f.Mask = mask
+		f.HasMask = true
+		f.Length += uint8(mask.Len())
+	}
+	return f, nil
+}
+
+// NewPortRangeFlowMods returns a FlowMod for each of the port/mask pairs decomposed from the port range
+// [start, end]. Each FlowMod is a copy of template with a new xid, and its match is extended with the L4 port
+// field. The version of the header and the instructions are kept from template. An error is returned if the
+// range is empty, i.e., start is greater than end.
+func NewPortRangeFlowMods(template *FlowMod, field uint8, start, end uint16) ([]*FlowMod, error) {
+	if start > end {
+		return nil, fmt.Errorf("the port range %d-%d is empty", start, end)
+	}
+	var flows []*FlowMod
+	for _, portMask := range PortRangeToMasks(start, end) {
+		portField, err := NewPortMaskField(field, portMask)
+		if err != nil {
+			return nil, err
+		}
+		flow := new(FlowMod)
+		*flow = *template
+		flow.Header.Xid = common.NextXid()
+		flow.Match.Fields = make([]MatchField, len(template.Match.Fields), len(template.Match.Fields)+1)
+		copy(flow.Match.Fields, template.Match.Fields)
+		flow.Match.AddField(*portField)
+		flows = append(flows, flow)
+	}
+	return flows, nil
+}
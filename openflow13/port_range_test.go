@@ -0,0 +1,91 @@
+package openflow13
+
+import (
+	"testing"
+)
+
+func TestPortRangeToMasks(t *testing.T) {
+	for _, tc := range []struct {
+		start, end uint16
+		expected   []PortMask
+	}{
+		{start: 80, end: 80, expected: []PortMask{{80, 0xffff}}},
+		{start: 0, end: 0xffff, expected: []PortMask{{0, 0}}},
+		{start: 1000, end: 1999, expected: []PortMask{
+			{1000, 0xfff8}, {1008, 0xfff0}, {1024, 0xfe00}, {1536, 0xff00}, {1792, 0xff80}, {1920, 0xffc0}, {1984, 0xfff0},
+		}},
+		{start: 0xfffe, end: 0xffff, expected: []PortMask{{0xfffe, 0xfffe}}},
+	} {
+		masks := PortRangeToMasks(tc.start, tc.end)
+		if len(masks) != len(tc.expected) {
+			t.Errorf("Expect %d masks for range %d-%d, actual: %v", len(tc.expected), tc.start, tc.end, masks)
+			continue
+		}
+		for i := range masks {
+			if masks[i] != tc.expected[i] {
+				t.Errorf("Expect %v for range %d-%d, actual: %v", tc.expected, tc.start, tc.end, masks)
+				break
+			}
+		}
+		// Check every port is matched by exactly one mask if it is in the range.
+		for port := 0; port <= 0xffff; port++ {
+			matched := 0
+			for _, m := range masks {
+				if uint16(port)&m.Mask == m.Port {
+					matched++
+				}
+			}
+			inRange := port >= int(tc.start) && port <= int(tc.end)
+			if (inRange && matched != 1) || (!inRange && matched != 0) {
+				t.Fatalf("Port %d is matched %d times for range %d-%d", port, matched, tc.start, tc.end)
+			}
+		}
+	}
+}
+
+func TestPortRangeFlowMods(t *testing.T) {
+	template := NewFlowMod()
+	template.Match.AddField(*NewEthTypeField(0x0800))
+	template.Match.AddField(*NewIpProtoField(6))
+	flows, err := NewPortRangeFlowMods(template, OXM_FIELD_TCP_DST, 1000, 1999)
+	if err != nil {
+		t.Fatalf("Failed to create FlowMods: %v", err)
+	}
+	if len(flows) != 7 || len(template.Match.Fields) != 2 {
+		t.Fatalf("Unexpected FlowMods: %d", len(flows))
+	}
+	if flows[0].Header.Version != VERSION || flows[0].Header.Type != Type_FlowMod ||
+		flows[0].Header.Xid == template.Header.Xid || flows[0].Header.Xid == flows[1].Header.Xid {
+		t.Errorf("Unexpected header: %+v", flows[0].Header)
+	}
+	data, err := flows[0].MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal FlowMod: %v", err)
+	}
+	flow := NewFlowMod()
+	if err := flow.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal FlowMod: %v", err)
+	}
+	portField := flow.Match.Fields[2]
+	if portField.Field != OXM_FIELD_TCP_DST || !portField.HasMask ||
+		portField.Value.(*PortField).port != 1000 || portField.Mask.(*PortField).port != 0xfff8 {
+		t.Errorf("Unexpected port field: %+v", portField)
+	}
+
+	if _, err := NewPortRangeFlowMods(template, OXM_FIELD_IPV4_SRC, 1000, 1999); err == nil {
+		t.Errorf("Expect error with non-port field")
+	}
+	if _, err := NewPortRangeFlowMods(template, OXM_FIELD_TCP_DST, 2000, 1999); err == nil {
+		t.Errorf("Expect error with empty port range")
+	}
+
+	// The version of the template is kept, e.g., for the FlowMods sent on an OpenFlow 1.5 connection.
+	template.Header.Version = OFP15_VERSION
+	flows, err = NewPortRangeFlowMods(template, OXM_FIELD_TCP_DST, 1000, 1000)
+	if err != nil {
+		t.Fatalf("Failed to create FlowMods: %v", err)
+	}
+	if len(flows) != 1 || flows[0].Header.Version != OFP15_VERSION {
+		t.Errorf("Expect a FlowMod of version 0x%x, actual: %+v", OFP15_VERSION, flows)
+	}
+}
@@ -0,0 +1,110 @@
+package openflow13
+
+import (
+	"encoding/binary"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+func TestPortModRoundTrip(t *testing.T) {
+	hwAddr, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	portMod := NewPortMod(3)
+	portMod.HWAddr = hwAddr
+	portMod.Config = PC_PORT_DOWN
+	portMod.Mask = PC_PORT_DOWN
+	portMod.Advertise = PF_10GB_FD
+	data, err := portMod.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal PortMod: %v", err)
+	}
+	if len(data) != 40 {
+		t.Errorf("Expect 40 bytes of PortMod of OpenFlow 1.3, actual: %d", len(data))
+	}
+	// The Properties of the reused message must not affect the decoding.
+	decoded := NewPortMod(0)
+	decoded.Properties = []util.Message{NewPortModPropOptical(0, 0, 0, 0, 0)}
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal PortMod: %v", err)
+	}
+	if decoded.PortNo != 3 || net.HardwareAddr(decoded.HWAddr).String() != hwAddr.String() ||
+		decoded.Config != PC_PORT_DOWN || decoded.Advertise != PF_10GB_FD {
+		t.Errorf("Unexpected PortMod of OpenFlow 1.3: %+v", decoded)
+	}
+
+	portMod = NewPortMod(3)
+	portMod.Header.Version = OFP15_VERSION
+	portMod.HWAddr = hwAddr
+	portMod.AddProperty(NewPortModPropEthernet(PF_10GB_FD))
+	portMod.AddProperty(NewPortModPropOptical(OPF_TX_PWR, 1, -2, 3, 4))
+	portMod.AddProperty(&PortModPropUnknown{PortModPropHeader{Type: PMPT_EXPERIMENTER}, []byte{1, 2, 3, 4}})
+	data, err = portMod.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal PortMod: %v", err)
+	}
+	if len(data) != 32+8+24+8 {
+		t.Errorf("Expect %d bytes of PortMod of OpenFlow 1.5, actual: %d", 32+8+24+8, len(data))
+	}
+	decoded = new(PortMod)
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal PortMod: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Properties, portMod.Properties) {
+		t.Errorf("Expect properties %+v, actual: %+v", portMod.Properties, decoded.Properties)
+	}
+}
+
+func TestPortModPropertyLength(t *testing.T) {
+	portMod := NewPortMod(3)
+	portMod.Header.Version = OFP15_VERSION
+	portMod.AddProperty(NewPortModPropEthernet(PF_10GB_FD))
+	portMod.AddProperty(NewPortModPropEthernet(PF_1GB_FD))
+	data, err := portMod.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal PortMod: %v", err)
+	}
+
+	// The ethernet property with 4 more bytes than it needs, the next property is after its length.
+	longer := append([]byte{}, data[:32]...)
+	longer = append(longer, data[32:40]...)
+	longer = append(longer, 0, 0, 0, 0)
+	longer = append(longer, data[40:]...)
+	binary.BigEndian.PutUint16(longer[2:], uint16(len(longer)))
+	binary.BigEndian.PutUint16(longer[34:], 12)
+	decoded := new(PortMod)
+	if err = decoded.UnmarshalBinary(longer); err != nil {
+		t.Fatalf("Failed to unmarshal PortMod: %v", err)
+	}
+	if len(decoded.Properties) != 2 || decoded.Properties[1].(*PortModPropEthernet).Advertise != PF_1GB_FD {
+		t.Errorf("Unexpected properties: %+v", decoded.Properties)
+	}
+
+	for name, mutate := range map[string]func(b []byte) []byte{
+		"message shorter than the fixed part": func(b []byte) []byte {
+			binary.BigEndian.PutUint16(b[2:], 24)
+			return b
+		},
+		"data shorter than the message": func(b []byte) []byte {
+			return b[:len(b)-1]
+		},
+		"property over the end of the message": func(b []byte) []byte {
+			binary.BigEndian.PutUint16(b[42:], 12)
+			return b
+		},
+		"property of zero length": func(b []byte) []byte {
+			binary.BigEndian.PutUint16(b[34:], 0)
+			return b
+		},
+		"property shorter than its type": func(b []byte) []byte {
+			binary.BigEndian.PutUint16(b[34:], 4)
+			return b
+		},
+	} {
+		b := mutate(append([]byte{}, data...))
+		if err := new(PortMod).UnmarshalBinary(b); err == nil {
+			t.Errorf("Expect an error of the %s", name)
+		}
+	}
+}
@@ -0,0 +1,420 @@
+package openflow13
+
+// This file has the queue configuration messages of OpenFlow 1.3, OFPT_QUEUE_GET_CONFIG_REQUEST and
+// OFPT_QUEUE_GET_CONFIG_REPLY, which are replaced by OFPMP_QUEUE_DESC in OpenFlow 1.4.
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/contiv/libOpenflow/common"
+	"github.com/contiv/libOpenflow/util"
+)
+
+// ofp_queue_properties 1.3
+const (
+	OFPQT_MIN_RATE     = 1      /* Minimum datarate guaranteed. */
+	OFPQT_MAX_RATE     = 2      /* Maximum datarate. */
+	OFPQT_EXPERIMENTER = 0xffff /* Experimenter defined property. */
+)
+
+// OFPQ_RATE_DISABLED is the rate of a min rate or max rate property which is not configured, the configured
+// rates are in 1/10 of a percent, i.e., up to 1000.
+const OFPQ_RATE_DISABLED = 0xffff
+
+// QueueGetConfigRequest queries the queues of a port, or of all the ports with P_ANY.
+type QueueGetConfigRequest struct {
+	common.Header
+	Port uint32
+	pad  [4]byte
+}
+
+func NewQueueGetConfigRequest(port uint32) *QueueGetConfigRequest {
+	r := new(QueueGetConfigRequest)
+	r.Header = NewOfp13Header()
+	r.Header.Type = Type_QueueGetConfigRequest
+	r.Port = port
+	return r
+}
+
+func (r *QueueGetConfigRequest) Len() (n uint16) {
+	return r.Header.Len() + 8
+}
+
+func (r *QueueGetConfigRequest) MarshalBinary() (data []byte, err error) {
+	r.Header.Length = r.Len()
+	data = make([]byte, int(r.Len()))
+	b, err := r.Header.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	binary.BigEndian.PutUint32(data[n:], r.Port)
+	return
+}
+
+func (r *QueueGetConfigRequest) UnmarshalBinary(data []byte) error {
+	if len(data) < int(r.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full QueueGetConfigRequest message")
+	}
+	if err := r.Header.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	r.Port = binary.BigEndian.Uint32(data[r.Header.Len():])
+	return nil
+}
+
+// QueuePropHeader is the common header of all queue properties.
+type QueuePropHeader struct {
+	Property uint16
+	Length   uint16
+	pad      [4]byte
+}
+
+func (p *QueuePropHeader) Len() uint16 {
+	return 8
+}
+
+func (p *QueuePropHeader) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, p.Len())
+	binary.BigEndian.PutUint16(data[0:], p.Property)
+	binary.BigEndian.PutUint16(data[2:], p.Length)
+	return
+}
+
+func (p *QueuePropHeader) UnmarshalBinary(data []byte) error {
+	if len(data) < int(p.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full QueuePropHeader message")
+	}
+	p.Property = binary.BigEndian.Uint16(data[0:])
+	p.Length = binary.BigEndian.Uint16(data[2:])
+	return nil
+}
+
+// QueuePropRate is the min rate or the max rate property of a queue, Rate is in 1/10 of a percent, and it is
+// OFPQ_RATE_DISABLED if the rate is not configured.
+type QueuePropRate struct {
+	QueuePropHeader
+	Rate uint16
+	pad  [6]byte
+}
+
+// NewQueuePropMinRate returns the min rate property, the guaranteed rate in 1/10 of a percent.
+func NewQueuePropMinRate(rate uint16) *QueuePropRate {
+	return newQueuePropRate(OFPQT_MIN_RATE, rate)
+}
+
+// NewQueuePropMaxRate returns the max rate property, the limited rate in 1/10 of a percent.
+func NewQueuePropMaxRate(rate uint16) *QueuePropRate {
+	return newQueuePropRate(OFPQT_MAX_RATE, rate)
+}
+
+func newQueuePropRate(property uint16, rate uint16) *QueuePropRate {
+	p := new(QueuePropRate)
+	p.Property = property
+	p.Length = p.Len()
+	p.Rate = rate
+	return p
+}
+
+// IsDisabled returns true if the rate is not configured.
+func (p *QueuePropRate) IsDisabled() bool {
+	return p.Rate > 1000
+}
+
+func (p *QueuePropRate) Len() uint16 {
+	return 16
+}
+
+func (p *QueuePropRate) MarshalBinary() (data []byte, err error) {
+	p.Length = p.Len()
+	data = make([]byte, p.Len())
+	b, err := p.QueuePropHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	binary.BigEndian.PutUint16(data[n:], p.Rate)
+	return
+}
+
+func (p *QueuePropRate) UnmarshalBinary(data []byte) error {
+	if len(data) < int(p.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full QueuePropRate message")
+	}
+	if err := p.QueuePropHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	p.Rate = binary.BigEndian.Uint16(data[p.QueuePropHeader.Len():])
+	return nil
+}
+
+// QueuePropExperimenter is the queue property of an experimenter, Data is the experimenter defined data.
+type QueuePropExperimenter struct {
+	QueuePropHeader
+	Experimenter uint32
+	pad          [4]byte
+	Data         []byte
+}
+
+func NewQueuePropExperimenter(experimenter uint32, data []byte) *QueuePropExperimenter {
+	p := new(QueuePropExperimenter)
+	p.Property = OFPQT_EXPERIMENTER
+	p.Experimenter = experimenter
+	p.Data = data
+	p.Length = p.Len()
+	return p
+}
+
+func (p *QueuePropExperimenter) Len() uint16 {
+	return p.QueuePropHeader.Len() + 8 + uint16(len(p.Data))
+}
+
+func (p *QueuePropExperimenter) MarshalBinary() (data []byte, err error) {
+	p.Length = p.Len()
+	data = make([]byte, p.Len())
+	b, err := p.QueuePropHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	binary.BigEndian.PutUint32(data[n:], p.Experimenter)
+	n += 8 // with pad
+	copy(data[n:], p.Data)
+	return
+}
+
+func (p *QueuePropExperimenter) UnmarshalBinary(data []byte) error {
+	if err := p.QueuePropHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if p.Length < p.QueuePropHeader.Len()+8 || int(p.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full QueuePropExperimenter message")
+	}
+	n := int(p.QueuePropHeader.Len())
+	p.Experimenter = binary.BigEndian.Uint32(data[n:])
+	n += 8 // with pad
+	p.Data = make([]byte, int(p.Length)-n)
+	copy(p.Data, data[n:p.Length])
+	return nil
+}
+
+// QueuePropUnknown keeps the raw data of the queue property which is not supported.
+type QueuePropUnknown struct {
+	QueuePropHeader
+	Data []byte
+}
+
+func (p *QueuePropUnknown) Len() uint16 {
+	return p.QueuePropHeader.Len() + uint16(len(p.Data))
+}
+
+func (p *QueuePropUnknown) MarshalBinary() (data []byte, err error) {
+	p.Length = p.Len()
+	b, err := p.QueuePropHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append(b, p.Data...), nil
+}
+
+func (p *QueuePropUnknown) UnmarshalBinary(data []byte) error {
+	if err := p.QueuePropHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if p.Length < p.QueuePropHeader.Len() || int(p.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full QueuePropUnknown message")
+	}
+	p.Data = make([]byte, int(p.Length-p.QueuePropHeader.Len()))
+	copy(p.Data, data[p.QueuePropHeader.Len():p.Length])
+	return nil
+}
+
+// DecodeQueueProp decodes a queue property according to its type.
+func DecodeQueueProp(data []byte) (util.Message, error) {
+	header := new(QueuePropHeader)
+	if err := header.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	var prop util.Message
+	switch header.Property {
+	case OFPQT_MIN_RATE, OFPQT_MAX_RATE:
+		prop = new(QueuePropRate)
+	case OFPQT_EXPERIMENTER:
+		prop = new(QueuePropExperimenter)
+	default:
+		prop = new(QueuePropUnknown)
+	}
+	if err := prop.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return prop, nil
+}
+
+// PacketQueue is the ofp_packet_queue, the description of a queue of a port.
+type PacketQueue struct {
+	QueueId    uint32
+	Port       uint32
+	Length     uint16
+	pad        [6]byte
+	Properties []util.Message
+}
+
+func NewPacketQueue(queueID uint32, port uint32) *PacketQueue {
+	q := new(PacketQueue)
+	q.QueueId = queueID
+	q.Port = port
+	q.Length = q.Len()
+	return q
+}
+
+// AddProperty adds a queue property, e.g., the min rate property.
+func (q *PacketQueue) AddProperty(prop util.Message) {
+	q.Properties = append(q.Properties, prop)
+	q.Length = q.Len()
+}
+
+// MinRate returns the min rate property of the queue, or nil if there is none.
+func (q *PacketQueue) MinRate() *QueuePropRate {
+	return q.rateProperty(OFPQT_MIN_RATE)
+}
+
+// MaxRate returns the max rate property of the queue, or nil if there is none.
+func (q *PacketQueue) MaxRate() *QueuePropRate {
+	return q.rateProperty(OFPQT_MAX_RATE)
+}
+
+func (q *PacketQueue) rateProperty(property uint16) *QueuePropRate {
+	for _, prop := range q.Properties {
+		if rate, ok := prop.(*QueuePropRate); ok && rate.Property == property {
+			return rate
+		}
+	}
+	return nil
+}
+
+func (q *PacketQueue) Len() (n uint16) {
+	n = 16
+	for _, prop := range q.Properties {
+		n += prop.Len()
+	}
+	return
+}
+
+func (q *PacketQueue) MarshalBinary() (data []byte, err error) {
+	q.Length = q.Len()
+	data = make([]byte, 16)
+	n := 0
+	binary.BigEndian.PutUint32(data[n:], q.QueueId)
+	n += 4
+	binary.BigEndian.PutUint32(data[n:], q.Port)
+	n += 4
+	binary.BigEndian.PutUint16(data[n:], q.Length)
+	for _, prop := range q.Properties {
+		b, err := prop.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return
+}
+
+func (q *PacketQueue) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("the []byte is too short to unmarshal a full PacketQueue message")
+	}
+	n := 0
+	q.QueueId = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	q.Port = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	q.Length = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	if q.Length < 16 || int(q.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full PacketQueue message")
+	}
+	n += 6 // for pad
+
+	q.Properties = nil
+	for n < int(q.Length) {
+		prop, err := DecodeQueueProp(data[n:q.Length])
+		if err != nil {
+			return decodeErrorAt(err, "PacketQueue", n, "property")
+		}
+		q.Properties = append(q.Properties, prop)
+		if n, err = safeAdvance(n, prop.Len(), int(q.Length), "queue property"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QueueGetConfigReply has the queues of the port queried by QueueGetConfigRequest.
+type QueueGetConfigReply struct {
+	common.Header
+	Port   uint32
+	pad    [4]byte
+	Queues []*PacketQueue
+}
+
+func NewQueueGetConfigReply(port uint32) *QueueGetConfigReply {
+	r := new(QueueGetConfigReply)
+	r.Header = NewOfp13Header()
+	r.Header.Type = Type_QueueGetConfigReply
+	r.Port = port
+	return r
+}
+
+func (r *QueueGetConfigReply) Len() (n uint16) {
+	n = r.Header.Len() + 8
+	for _, q := range r.Queues {
+		n += q.Len()
+	}
+	return
+}
+
+func (r *QueueGetConfigReply) MarshalBinary() (data []byte, err error) {
+	r.Header.Length = r.Len()
+	data, err = r.Header.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint32(b, r.Port)
+	data = append(data, b...)
+	for _, q := range r.Queues {
+		b, err = q.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return
+}
+
+func (r *QueueGetConfigReply) UnmarshalBinary(data []byte) error {
+	if err := r.Header.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	n := int(r.Header.Len())
+	if len(data) < n+8 || int(r.Header.Length) < n+8 || int(r.Header.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full QueueGetConfigReply message")
+	}
+	r.Port = binary.BigEndian.Uint32(data[n:])
+	n += 8 // with pad
+
+	r.Queues = nil
+	for n < int(r.Header.Length) {
+		q := new(PacketQueue)
+		if err := q.UnmarshalBinary(data[n:r.Header.Length]); err != nil {
+			return decodeErrorAt(err, "QueueGetConfigReply", n, "queue")
+		}
+		r.Queues = append(r.Queues, q)
+		var err error
+		if n, err = safeAdvance(n, q.Length, int(r.Header.Length), "packet queue"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package openflow13
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+func TestQueueGetConfig(t *testing.T) {
+	req := NewQueueGetConfigRequest(P_ANY)
+	data, err := req.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal QueueGetConfigRequest: %v", err)
+	}
+	msg, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse QueueGetConfigRequest: %v", err)
+	}
+	if req2, ok := msg.(*QueueGetConfigRequest); !ok || req2.Port != P_ANY || len(data) != 16 {
+		t.Errorf("Unexpected QueueGetConfigRequest: %+v", msg)
+	}
+
+	reply := NewQueueGetConfigReply(P_ANY)
+	q1 := NewPacketQueue(1, 3)
+	q1.AddProperty(NewQueuePropMinRate(200))
+	q1.AddProperty(NewQueuePropMaxRate(OFPQ_RATE_DISABLED))
+	q2 := NewPacketQueue(2, 3)
+	q2.AddProperty(NewQueuePropExperimenter(0x2320, []byte{1, 2, 3, 4, 5, 6, 7, 8}))
+	reply.Queues = []*PacketQueue{q1, q2}
+	data, err = reply.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal QueueGetConfigReply: %v", err)
+	}
+	if len(data) != int(reply.Len()) || len(data) != 16+48+40 {
+		t.Errorf("Unexpected QueueGetConfigReply length %d, Len(): %d", len(data), reply.Len())
+	}
+	msg, err = Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse QueueGetConfigReply: %v", err)
+	}
+	reply2, ok := msg.(*QueueGetConfigReply)
+	if !ok || len(reply2.Queues) != 2 {
+		t.Fatalf("Unexpected QueueGetConfigReply: %+v", msg)
+	}
+	if minRate := reply2.Queues[0].MinRate(); minRate == nil || minRate.Rate != 200 || minRate.IsDisabled() {
+		t.Errorf("Unexpected min rate: %+v", minRate)
+	}
+	if maxRate := reply2.Queues[0].MaxRate(); maxRate == nil || !maxRate.IsDisabled() {
+		t.Errorf("Unexpected max rate: %+v", maxRate)
+	}
+	exp, ok := reply2.Queues[1].Properties[0].(*QueuePropExperimenter)
+	if !ok || exp.Experimenter != 0x2320 || !bytes.Equal(exp.Data, []byte{1, 2, 3, 4, 5, 6, 7, 8}) {
+		t.Errorf("Unexpected experimenter property: %+v", reply2.Queues[1].Properties[0])
+	}
+	if _, err = Parse(data[:len(data)-8]); err == nil {
+		t.Errorf("Expect an error of the truncated QueueGetConfigReply")
+	}
+}
+
+func TestRequestQueueConfig(t *testing.T) {
+	inbound := make(chan util.Message)
+	outbound := make(chan util.Message)
+	defer close(outbound)
+	conn := newConn(inbound, outbound, func(msg util.Message) {})
+	defer conn.Close()
+
+	go fakeSwitch(outbound, inbound, func(req util.Message) []util.Message {
+		queueReq, ok := req.(*QueueGetConfigRequest)
+		if !ok {
+			return nil
+		}
+		reply := NewQueueGetConfigReply(queueReq.Port)
+		reply.Header.Xid = queueReq.Header.Xid
+		reply.Queues = append(reply.Queues, NewPacketQueue(7, queueReq.Port))
+		return []util.Message{reply}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	queues, err := RequestQueueConfig(ctx, conn, 3)
+	if err != nil {
+		t.Fatalf("Failed to request queue config: %v", err)
+	}
+	if len(queues) != 1 || queues[0].QueueId != 7 || queues[0].Port != 3 {
+		t.Errorf("Unexpected queues: %+v", queues)
+	}
+}
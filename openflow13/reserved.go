@@ -0,0 +1,67 @@
+package openflow13
+
+// This file has the reserved values of the port, group, meter, queue, table and buffer IDs. Use them instead of
+// the literal values, e.g., 0xffffffff means OFPP_ANY as an out_port filter but OFPG_ALL is 0xfffffffc, and a
+// mistaken literal either matches nothing or deletes everything.
+
+// ofp_port_no 1.3
+const (
+	P_MAX = 0xffffff00 /* Maximum number of physical and logical switch ports. */
+
+	P_IN_PORT = 0xfffffff8 /* Send the packet out the input port. */
+	P_TABLE   = 0xfffffff9 /* Submit the packet to the first flow table. Only valid in the PacketOut actions. */
+
+	P_NORMAL = 0xfffffffa /* Forward using non-OpenFlow pipeline. */
+	P_FLOOD  = 0xfffffffb /* Flood using non-OpenFlow pipeline. */
+
+	P_ALL        = 0xfffffffc /* All standard ports except input port. */
+	P_CONTROLLER = 0xfffffffd /* Send to controller. */
+	P_LOCAL      = 0xfffffffe /* Local openflow "port". */
+	// P_ANY is the wildcard port. As the out_port of a flow delete or a flow stats request, it selects the flows
+	// regardless of their output ports; any other value selects only the flows outputting to that port.
+	P_ANY = 0xffffffff
+)
+
+// ofp_group 1.3
+const (
+	OFPG_MAX = 0xffffff00 /* Last usable group number. */
+	/* Fake groups. */
+	// OFPG_ALL represents all groups for the group delete commands. It is not a wildcard of the flow filters.
+	OFPG_ALL = 0xfffffffc
+	// OFPG_ANY is the wildcard group used only for the flow delete and flow stats requests. As the out_group, it
+	// selects all flows regardless of group, including the flows with no group.
+	OFPG_ANY = 0xffffffff
+)
+
+// Bucket IDs used by OpenFlow 1.5 buckets.
+const (
+	OFPG_BUCKET_MAX   = 0xffffff00 /* Last usable bucket ID. */
+	OFPG_BUCKET_FIRST = 0xfffffffd /* First bucket ID in the list of action buckets of a group. */
+	OFPG_BUCKET_LAST  = 0xfffffffe /* Last bucket ID in the list of action buckets of a group. */
+	OFPG_BUCKET_ALL   = 0xffffffff /* All action buckets in a group. */
+)
+
+// ofp_meter 1.3
+const (
+	/* Meter numbering. Flow meters can use any number up to OFPM_MAX. */
+	OFPM13_MAX        = 0xffff0000 /* Last usable meter. */
+	OFPM13_SLOWPATH   = 0xfffffffd /* Meter for slow datapath. */
+	OFPM13_CONTROLLER = 0xfffffffe /* Meter for controller connection. */
+	// OFPM13_ALL represents all meters for the meter stats and config requests, and the meter delete commands.
+	OFPM13_ALL = 0xffffffff
+)
+
+// ofp_queue 1.3
+// OFPQ_ALL represents all queues of the port in the queue stats requests.
+const OFPQ_ALL = 0xffffffff
+
+// ofp_table 1.3
+const (
+	OFPTT_MAX = 0xfe
+	/* Fake tables. */
+	OFPTT_ALL = 0xff /* Wildcard table used for table config, flow stats and flow deletes. */
+)
+
+// NO_BUFFER is the buffer ID of a PacketOut which carries the packet in its data, or of a FlowMod or a PacketIn
+// which refers to no buffered packet.
+const NO_BUFFER = 0xffffffff
@@ -0,0 +1,47 @@
+package openflow13
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestConstructorsUseReservedConstants checks that the constructors set the reserved IDs with the constants in
+// reserved.go rather than the literal values, which are easy to mix up, e.g., OFPG_ALL and OFPG_ANY.
+func TestConstructorsUseReservedConstants(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatalf("Failed to list source files: %v", err)
+	}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", file, err)
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil || !strings.HasPrefix(fn.Name.Name, "New") {
+				continue
+			}
+			ast.Inspect(fn.Body, func(node ast.Node) bool {
+				lit, ok := node.(*ast.BasicLit)
+				if !ok || lit.Kind != token.INT {
+					return true
+				}
+				val, err := strconv.ParseUint(lit.Value, 0, 64)
+				if err == nil && val >= P_MAX && val <= P_ANY {
+					t.Errorf("%s: %s uses literal %s, use the reserved constant instead", fset.Position(lit.Pos()), fn.Name.Name, lit.Value)
+				}
+				return true
+			})
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package openflow13
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/contiv/libOpenflow/common"
+)
+
+// ofp_controller_role
+const (
+	OFPCR_ROLE_NOCHANGE = 0 /* Don't change current role. */
+	OFPCR_ROLE_EQUAL    = 1 /* Default role, full access. */
+	OFPCR_ROLE_MASTER   = 2 /* Full access, at most one master. */
+	OFPCR_ROLE_SLAVE    = 3 /* Read-only access. */
+)
+
+// OFPCID_UNDEFINED is the short_id used by the controller which doesn't use a short ID. The short_id is
+// introduced in OpenFlow 1.5, and is carried in the padding of ofp_role_request in OpenFlow 1.3.
+const OFPCID_UNDEFINED = 0
+
+// ofp_role_request_failed_code
+const (
+	RRFC_STALE     = 0 /* Stale Message: old generation_id. */
+	RRFC_UNSUP     = 1 /* Controller role change unsupported. */
+	RRFC_BAD_ROLE  = 2 /* Invalid role. */
+	RRFC_ID_UNSUP  = 3 /* Switch doesn't support changing ID (from OpenFlow 1.5). */
+	RRFC_ID_IN_USE = 4 /* Requested ID is in use (from OpenFlow 1.5). */
+)
+
+var (
+	ErrRoleRequestStale   = errors.New("stale role request: old generation ID")
+	ErrRoleRequestUnsup   = errors.New("controller role change unsupported")
+	ErrRoleRequestBadRole = errors.New("invalid controller role")
+	ErrRoleRequestIDUnsup = errors.New("switch doesn't support controller short ID")
+	ErrRoleRequestIDInUse = errors.New("controller short ID is in use")
+)
+
+// RoleRequest is the message used by the controller to change its role, the switch replies with the same
+// structure in OFPT_ROLE_REPLY.
+// ShortID is only used in OpenFlow 1.5, and it should be unique among all the controllers connected to the
+// switch, otherwise the switch replies an error with RRFC_ID_IN_USE. Use OFPCID_UNDEFINED if the controller
+// doesn't need a short ID. ShortID is encoded only if the version in the header is OpenFlow 1.5 or later, use
+// NewRoleRequest15 to build such a message.
+type RoleRequest struct {
+	common.Header
+	Role         uint32
+	ShortID      uint16
+	pad          [2]byte // 2 bytes
+	GenerationID uint64
+}
+
+func NewRoleRequest(role uint32, generationID uint64) *RoleRequest {
+	r := new(RoleRequest)
+	r.Header = NewOfp13Header()
+	r.Header.Type = Type_RoleRequest
+	r.Role = role
+	r.ShortID = OFPCID_UNDEFINED
+	r.GenerationID = generationID
+	return r
+}
+
+// NewRoleRequest15 returns an OpenFlow 1.5 role request with the short ID of the controller.
+func NewRoleRequest15(role uint32, generationID uint64, shortID uint16) *RoleRequest {
+	r := NewRoleRequest(role, generationID)
+	r.Header.Version = OFP15_VERSION
+	r.ShortID = shortID
+	return r
+}
+
+func NewRoleReply() *RoleRequest {
+	r := NewRoleRequest(OFPCR_ROLE_NOCHANGE, 0)
+	r.Header.Type = Type_RoleReply
+	return r
+}
+
+// useShortID returns true if the short_id is a field of the message for the version in the header, it is
+// padding in the versions before OpenFlow 1.5.
+func (r *RoleRequest) useShortID() bool {
+	return r.Header.Version >= OFP15_VERSION
+}
+
+func (r *RoleRequest) Len() (n uint16) {
+	return r.Header.Len() + 16
+}
+
+func (r *RoleRequest) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(r.Len()))
+	n := 0
+	r.Header.Length = r.Len()
+	b, err := r.Header.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	copy(data[n:], b)
+	n += len(b)
+	binary.BigEndian.PutUint32(data[n:], r.Role)
+	n += 4
+	if r.useShortID() {
+		binary.BigEndian.PutUint16(data[n:], r.ShortID)
+	}
+	n += 2
+	n += 2 // for pad
+	binary.BigEndian.PutUint64(data[n:], r.GenerationID)
+	n += 8
+	return
+}
+
+func (r *RoleRequest) UnmarshalBinary(data []byte) error {
+	if len(data) < int(r.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full RoleRequest message")
+	}
+	n := 0
+	err := r.Header.UnmarshalBinary(data[n:])
+	if err != nil {
+		return err
+	}
+	n += int(r.Header.Len())
+	r.Role = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	r.ShortID = OFPCID_UNDEFINED
+	if r.useShortID() {
+		r.ShortID = binary.BigEndian.Uint16(data[n:])
+	}
+	n += 2
+	copy(r.pad[:], data[n:n+2])
+	n += 2
+	r.GenerationID = binary.BigEndian.Uint64(data[n:])
+	n += 8
+	return nil
+}
+
+// Validate checks the role and the usage of ShortID. A short ID is only allowed if the message is encoded for
+// OpenFlow 1.5 or later, otherwise it would be dropped silently by MarshalBinary.
+func (r *RoleRequest) Validate() error {
+	if r.Role > OFPCR_ROLE_SLAVE {
+		return fmt.Errorf("invalid controller role %d", r.Role)
+	}
+	if r.ShortID != OFPCID_UNDEFINED && !r.useShortID() {
+		return fmt.Errorf("controller short ID is not supported in OpenFlow version %d", r.Header.Version)
+	}
+	return nil
+}
+
+// ParseRoleRequestError returns error according to role request failed code, the returned error could be
+// compared with the ErrRoleRequestXXX variables.
+func ParseRoleRequestError(errCode uint16) error {
+	switch errCode {
+	case RRFC_STALE:
+		return ErrRoleRequestStale
+	case RRFC_UNSUP:
+		return ErrRoleRequestUnsup
+	case RRFC_BAD_ROLE:
+		return ErrRoleRequestBadRole
+	case RRFC_ID_UNSUP:
+		return ErrRoleRequestIDUnsup
+	case RRFC_ID_IN_USE:
+		return ErrRoleRequestIDInUse
+	}
+	return fmt.Errorf("unknown role request error code %d", errCode)
+}
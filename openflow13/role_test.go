@@ -0,0 +1,78 @@
+package openflow13
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestRoleRequest(t *testing.T) {
+	req := NewRoleRequest(OFPCR_ROLE_MASTER, 7)
+	if err := req.Validate(); err != nil {
+		t.Errorf("Failed to validate the role request of OpenFlow 1.3: %v", err)
+	}
+	data, err := req.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal RoleRequest: %v", err)
+	}
+	if len(data) != 24 || data[0] != VERSION || binary.BigEndian.Uint32(data[8:]) != OFPCR_ROLE_MASTER ||
+		binary.BigEndian.Uint64(data[16:]) != 7 {
+		t.Errorf("Unexpected RoleRequest of OpenFlow 1.3: %v", data)
+	}
+
+	// The short ID is padding in OpenFlow 1.3, it is rejected by Validate and not encoded.
+	req.ShortID = 3
+	if err = req.Validate(); err == nil {
+		t.Errorf("Expect an error of the short ID in OpenFlow 1.3")
+	}
+	if data, _ = req.MarshalBinary(); binary.BigEndian.Uint16(data[12:]) != 0 {
+		t.Errorf("Expect the short ID not encoded in OpenFlow 1.3: %v", data)
+	}
+	binary.BigEndian.PutUint16(data[12:], 3)
+	decoded := new(RoleRequest)
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal RoleRequest: %v", err)
+	}
+	if decoded.ShortID != OFPCID_UNDEFINED {
+		t.Errorf("Expect the padding of OpenFlow 1.3 not decoded as the short ID, actual: %d", decoded.ShortID)
+	}
+
+	req = NewRoleRequest15(OFPCR_ROLE_SLAVE, 8, 3)
+	if err = req.Validate(); err != nil {
+		t.Errorf("Failed to validate the role request of OpenFlow 1.5: %v", err)
+	}
+	if data, err = req.MarshalBinary(); err != nil {
+		t.Fatalf("Failed to marshal RoleRequest: %v", err)
+	}
+	if data[0] != OFP15_VERSION || binary.BigEndian.Uint16(data[12:]) != 3 {
+		t.Errorf("Unexpected RoleRequest of OpenFlow 1.5: %v", data)
+	}
+	decoded = new(RoleRequest)
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal RoleRequest: %v", err)
+	}
+	if decoded.Role != OFPCR_ROLE_SLAVE || decoded.ShortID != 3 || decoded.GenerationID != 8 {
+		t.Errorf("Unexpected RoleRequest of OpenFlow 1.5: %+v", decoded)
+	}
+
+	req = NewRoleRequest(OFPCR_ROLE_SLAVE+1, 0)
+	if err = req.Validate(); err == nil {
+		t.Errorf("Expect an error of the invalid role")
+	}
+}
+
+func TestParseRoleRequestError(t *testing.T) {
+	for code, expect := range map[uint16]error{
+		RRFC_STALE:     ErrRoleRequestStale,
+		RRFC_UNSUP:     ErrRoleRequestUnsup,
+		RRFC_BAD_ROLE:  ErrRoleRequestBadRole,
+		RRFC_ID_UNSUP:  ErrRoleRequestIDUnsup,
+		RRFC_ID_IN_USE: ErrRoleRequestIDInUse,
+	} {
+		if err := ParseRoleRequestError(code); err != expect {
+			t.Errorf("Expect %v of code %d, actual: %v", expect, code, err)
+		}
+	}
+	if err := ParseRoleRequestError(5); err == nil {
+		t.Errorf("Expect an error of the unknown code")
+	}
+}
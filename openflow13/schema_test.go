@@ -0,0 +1,36 @@
+package openflow13
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestSchemaUpToDate checks that schema.json is regenerated after the supported messages, actions, instructions
+// or match fields are changed.
+func TestSchemaUpToDate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skip running the schema generator in short mode")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("Skip running the schema generator without the go command")
+	}
+	output := filepath.Join(t.TempDir(), "schema.json")
+	if out, err := exec.Command(goBin, "run", "gen_schema.go", output).CombinedOutput(); err != nil {
+		t.Fatalf("Failed to run the schema generator: %v\n%s", err, out)
+	}
+	expect, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Failed to read the generated schema: %v", err)
+	}
+	actual, err := os.ReadFile("schema.json")
+	if err != nil {
+		t.Fatalf("Failed to read schema.json: %v", err)
+	}
+	if !bytes.Equal(expect, actual) {
+		t.Errorf("schema.json is out of date, run \"go generate\" in the openflow13 directory")
+	}
+}
@@ -0,0 +1,56 @@
+package openflow13
+
+import (
+	"sort"
+)
+
+// TableUsage is the estimated usage of a flow table.
+type TableUsage struct {
+	TableId     uint8
+	ActiveCount uint32
+	MaxEntries  uint32
+	// Utilization is ActiveCount/MaxEntries, it is 0 if MaxEntries is unknown.
+	Utilization float64
+	// OverThreshold is true if Utilization reaches the threshold passed to EstimateTableUsage.
+	OverThreshold bool
+}
+
+// EstimateTableUsage computes the usage of the tables in the bodies of the TableStats replies. The max entries
+// of a table is taken from maxEntries if it has the table, e.g., collected from the TableFeatures replies, and
+// from TableStats otherwise. The tables are sorted by table ID, and marked with OverThreshold if the
+// utilization is not lower than threshold, e.g., 0.8 for 80%.
+func EstimateTableUsage(replies []*MultipartReply, maxEntries map[uint8]uint32, threshold float64) []TableUsage {
+	var usages []TableUsage
+	for _, reply := range replies {
+		if reply.Type != MultipartType_Table {
+			continue
+		}
+		for _, body := range reply.Body {
+			stats, ok := body.(*TableStats)
+			if !ok {
+				continue
+			}
+			usages = append(usages, newTableUsage(stats, maxEntries, threshold))
+		}
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].TableId < usages[j].TableId
+	})
+	return usages
+}
+
+func newTableUsage(stats *TableStats, maxEntries map[uint8]uint32, threshold float64) TableUsage {
+	usage := TableUsage{
+		TableId:     stats.TableId,
+		ActiveCount: stats.ActiveCount,
+		MaxEntries:  stats.MaxEntries,
+	}
+	if max, ok := maxEntries[stats.TableId]; ok {
+		usage.MaxEntries = max
+	}
+	if usage.MaxEntries > 0 {
+		usage.Utilization = float64(usage.ActiveCount) / float64(usage.MaxEntries)
+		usage.OverThreshold = usage.Utilization >= threshold
+	}
+	return usage
+}
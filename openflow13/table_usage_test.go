@@ -0,0 +1,30 @@
+package openflow13
+
+import (
+	"testing"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+func TestEstimateTableUsage(t *testing.T) {
+	reply := new(MultipartReply)
+	reply.Type = MultipartType_Table
+	reply.Body = []util.Message{
+		&TableStats{TableId: 1, MaxEntries: 1000, ActiveCount: 900},
+		&TableStats{TableId: 0, MaxEntries: 1000, ActiveCount: 100},
+		&TableStats{TableId: 2, ActiveCount: 100},
+	}
+	usages := EstimateTableUsage([]*MultipartReply{reply}, map[uint8]uint32{0: 200}, 0.8)
+	if len(usages) != 3 {
+		t.Fatalf("Expect usages of 3 tables, actual: %d", len(usages))
+	}
+	if usages[0].TableId != 0 || usages[0].MaxEntries != 200 || usages[0].Utilization != 0.5 || usages[0].OverThreshold {
+		t.Errorf("Unexpected usage of table 0: %+v", usages[0])
+	}
+	if usages[1].TableId != 1 || usages[1].Utilization != 0.9 || !usages[1].OverThreshold {
+		t.Errorf("Unexpected usage of table 1: %+v", usages[1])
+	}
+	if usages[2].TableId != 2 || usages[2].Utilization != 0 || usages[2].OverThreshold {
+		t.Errorf("Unexpected usage of table 2: %+v", usages[2])
+	}
+}
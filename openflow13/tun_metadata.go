@@ -0,0 +1,188 @@
+package openflow13
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// NXM_NX_TUN_METADATA_NUM is the number of the tun_metadata fields, tun_metadata<N> is the NXM_1 field
+// NXM_NX_TUN_METADATA0 + N.
+const NXM_NX_TUN_METADATA_NUM = 64
+
+func isTunMetadataField(class uint16, field uint8) bool {
+	return class == OXM_CLASS_NXM_1 && field >= NXM_NX_TUN_METADATA0 && field < NXM_NX_TUN_METADATA0+NXM_NX_TUN_METADATA_NUM
+}
+
+// newTunMetadataValue returns the value or the mask of a tun_metadata field with the length in the OXM header.
+func newTunMetadataValue(length uint8, hasMask bool) *ByteArrayField {
+	msg := new(ByteArrayField)
+	if !hasMask {
+		msg.Length = length
+	} else {
+		msg.Length = length / 2
+	}
+	return msg
+}
+
+// FieldLengthContext has the lengths of the variable length fields of a connection, which are defined by the
+// TLV table of the switch rather than the static field table. The length of tun_metadata<N> is the OptLength of
+// the TLVTableMap with Index N. Keep one FieldLengthContext per connection, update it with the TLV table reply
+// and the TLV table mods sent on the connection, and pass it to Match.UnmarshalBinaryWithContext so that a
+// tun_metadata field with a wrong length fails the decoding instead of skewing the subsequent fields.
+type FieldLengthContext struct {
+	lock        sync.RWMutex
+	tunMetadata map[uint16]TLVTableMap
+}
+
+func NewFieldLengthContext() *FieldLengthContext {
+	return &FieldLengthContext{tunMetadata: make(map[uint16]TLVTableMap)}
+}
+
+// SetTLVTableMaps replaces the tun_metadata lengths with the maps, e.g., of a TLVTableReply.
+func (c *FieldLengthContext) SetTLVTableMaps(maps []*TLVTableMap) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.tunMetadata = make(map[uint16]TLVTableMap, len(maps))
+	for _, m := range maps {
+		c.tunMetadata[m.Index] = *m
+	}
+}
+
+// ApplyTLVTableMod updates the tun_metadata lengths with the TLVTableMod sent to the switch.
+func (c *FieldLengthContext) ApplyTLVTableMod(mod *TLVTableMod) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	switch mod.Command {
+	case NXTTMC_ADD:
+		for _, m := range mod.TlvMaps {
+			c.tunMetadata[m.Index] = *m
+		}
+	case NXTTMC_DELETE:
+		for _, m := range mod.TlvMaps {
+			delete(c.tunMetadata, m.Index)
+		}
+	case NXTTMC_CLEAR:
+		c.tunMetadata = make(map[uint16]TLVTableMap)
+	default:
+		return fmt.Errorf("unknown TLV table mod command %d", mod.Command)
+	}
+	return nil
+}
+
+// TunMetadataLength returns the length of tun_metadata<index>, and false if the index is not mapped.
+func (c *FieldLengthContext) TunMetadataLength(index uint16) (uint8, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	m, found := c.tunMetadata[index]
+	return m.OptLength, found
+}
+
+// checkTunMetadataField returns an error if the length in the header of the tun_metadata field is not the
+// length in the TLV table.
+func (c *FieldLengthContext) checkTunMetadataField(m *MatchField) error {
+	index := uint16(m.Field - NXM_NX_TUN_METADATA0)
+	length, found := c.TunMetadataLength(index)
+	if !found {
+		return fmt.Errorf("tun_metadata%d is not mapped in the TLV table", index)
+	}
+	expected := uint16(length)
+	if m.HasMask {
+		expected *= 2
+	}
+	if uint16(m.Length) != expected {
+		return fmt.Errorf("tun_metadata%d has length %d, expect %d by the TLV table", index, m.Length, expected)
+	}
+	return nil
+}
+
+// TunMetadataIndex returns the index of the tun_metadata field mapped to the Geneve option of the class and the
+// type, and false if the option is not mapped.
+func (c *FieldLengthContext) TunMetadataIndex(optClass uint16, optType uint8) (uint16, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	for index, m := range c.tunMetadata {
+		if m.OptClass == optClass && m.OptType == optType {
+			return index, true
+		}
+	}
+	return 0, false
+}
+
+// MapGeneveOption returns the index of the tun_metadata field mapped to the Geneve option, and the TLVTableMod
+// to send to the switch if the option is not mapped yet, which is nil otherwise. A new option is mapped to the
+// lowest free index, and the mapping is recorded in c at once, so the TLVTableMod must not be applied again.
+// The option length is in bytes, a multiple of 4 up to 124 as required by Geneve, and an error is returned if
+// the option is mapped with another length or all the indexes are used.
+func (c *FieldLengthContext) MapGeneveOption(optClass uint16, optType uint8, optLength uint8) (uint16, *TLVTableMod, error) {
+	if optLength == 0 || optLength%4 != 0 || optLength > 124 {
+		return 0, nil, fmt.Errorf("invalid Geneve option length %d", optLength)
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var index uint16
+	for i, m := range c.tunMetadata {
+		if m.OptClass == optClass && m.OptType == optType {
+			if m.OptLength != optLength {
+				return 0, nil, fmt.Errorf("Geneve option class 0x%x type %d is mapped to tun_metadata%d with length %d", optClass, optType, i, m.OptLength)
+			}
+			return i, nil, nil
+		}
+	}
+	for ; index < NXM_NX_TUN_METADATA_NUM; index++ {
+		if _, used := c.tunMetadata[index]; !used {
+			break
+		}
+	}
+	if index == NXM_NX_TUN_METADATA_NUM {
+		return 0, nil, errors.New("no free tun_metadata field to map the Geneve option")
+	}
+	m := TLVTableMap{OptClass: optClass, OptType: optType, OptLength: optLength, Index: index}
+	c.tunMetadata[index] = m
+	return index, NewTLVTableMod(NXTTMC_ADD, []*TLVTableMap{&m}), nil
+}
+
+// tunMetadataBytes returns the value right-aligned in the length of the mapped tun_metadata field as ovs-ofctl,
+// e.g., 0x1 is 00 00 00 01 in a 4-byte option.
+func (c *FieldLengthContext) tunMetadataBytes(index uint16, value []byte) ([]byte, error) {
+	length, found := c.TunMetadataLength(index)
+	if !found {
+		return nil, fmt.Errorf("tun_metadata%d is not mapped in the TLV table", index)
+	}
+	if len(value) > int(length) {
+		return nil, fmt.Errorf("the value of %d bytes exceeds the length %d of tun_metadata%d", len(value), length, index)
+	}
+	data := make([]byte, length)
+	copy(data[int(length)-len(value):], value)
+	return data, nil
+}
+
+// NewTunMetadataMatchField returns the match field of tun_metadata<index> with the length in the TLV table. The
+// value and the mask, which is optional, are right-aligned in the length. The field could also be the
+// destination of NXActionRegLoad2 to load the masked bits.
+func (c *FieldLengthContext) NewTunMetadataMatchField(index uint16, value []byte, mask []byte) (*MatchField, error) {
+	if index >= NXM_NX_TUN_METADATA_NUM {
+		return nil, fmt.Errorf("invalid tun_metadata index %d", index)
+	}
+	data, err := c.tunMetadataBytes(index, value)
+	if err != nil {
+		return nil, err
+	}
+	var maskData []byte
+	if mask != nil {
+		if maskData, err = c.tunMetadataBytes(index, mask); err != nil {
+			return nil, err
+		}
+	}
+	return NewTunMetadataField(int(index), data, maskData), nil
+}
+
+// NewTunMetadataSetField returns the set_field action which sets tun_metadata<index> to the value, e.g., to add
+// the Geneve option to the packets sent to the tunnel.
+func (c *FieldLengthContext) NewTunMetadataSetField(index uint16, value []byte) (*ActionSetField, error) {
+	field, err := c.NewTunMetadataMatchField(index, value, nil)
+	if err != nil {
+		return nil, err
+	}
+	return NewActionSetField(*field), nil
+}
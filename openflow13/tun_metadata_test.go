@@ -0,0 +1,101 @@
+package openflow13
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMatchTunMetadataWithContext(t *testing.T) {
+	match := NewMatch()
+	match.AddField(*NewTunMetadataField(2, []byte{1, 2, 3, 4}, nil))
+	match.AddField(*NewTunMetadataField(20, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, bytes.Repeat([]byte{0xff}, 12)))
+	match.AddField(*NewRegMatchField(1, 100, nil))
+	data, err := match.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal Match: %v", err)
+	}
+
+	ctx := NewFieldLengthContext()
+	ctx.SetTLVTableMaps([]*TLVTableMap{{OptClass: 0xffff, OptType: 1, OptLength: 4, Index: 2}})
+	if err = ctx.ApplyTLVTableMod(NewTLVTableMod(NXTTMC_ADD, []*TLVTableMap{{OptClass: 0xffff, OptType: 2, OptLength: 12, Index: 20}})); err != nil {
+		t.Fatalf("Failed to apply TLVTableMod: %v", err)
+	}
+	match2 := new(Match)
+	if err = match2.UnmarshalBinaryWithContext(data, ctx); err != nil {
+		t.Fatalf("Failed to unmarshal Match: %v", err)
+	}
+	if len(match2.Fields) != 3 {
+		t.Fatalf("Expect 3 fields, actual: %d", len(match2.Fields))
+	}
+	if v := match2.Fields[1].Value.(*ByteArrayField); v.Length != 12 || !bytes.Equal(v.Data, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}) {
+		t.Errorf("Unexpected tun_metadata20: %+v", v)
+	}
+	if v := match2.Fields[2].Value.(*Uint32Message); v.Data != 100 {
+		t.Errorf("Unexpected reg1 after tun_metadata: %+v", v)
+	}
+
+	// The length of tun_metadata20 doesn't match the TLV table.
+	ctx.ApplyTLVTableMod(NewTLVTableMod(NXTTMC_ADD, []*TLVTableMap{{OptClass: 0xffff, OptType: 2, OptLength: 8, Index: 20}}))
+	if err = new(Match).UnmarshalBinaryWithContext(data, ctx); err == nil {
+		t.Errorf("Expect error with mismatched tun_metadata length")
+	}
+	ctx.ApplyTLVTableMod(NewTLVTableMod(NXTTMC_CLEAR, nil))
+	if err = new(Match).UnmarshalBinaryWithContext(data, ctx); err == nil {
+		t.Errorf("Expect error with unmapped tun_metadata")
+	}
+	// Without the context, the lengths in the headers are used.
+	if err = new(Match).UnmarshalBinary(data); err != nil {
+		t.Errorf("Failed to unmarshal Match without context: %v", err)
+	}
+}
+
+func TestMapGeneveOption(t *testing.T) {
+	ctx := NewFieldLengthContext()
+	ctx.SetTLVTableMaps([]*TLVTableMap{{OptClass: 0x0102, OptType: 1, OptLength: 4, Index: 0}})
+	index, mod, err := ctx.MapGeneveOption(0x0102, 1, 4)
+	if err != nil || index != 0 || mod != nil {
+		t.Errorf("Expect the mapped index 0 without TLVTableMod, actual: %d, %v, %v", index, mod, err)
+	}
+	index, mod, err = ctx.MapGeneveOption(0x0102, 2, 8)
+	if err != nil || index != 1 || mod == nil || mod.Command != NXTTMC_ADD || len(mod.TlvMaps) != 1 || mod.TlvMaps[0].Index != 1 {
+		t.Fatalf("Expect the new index 1 with TLVTableMod, actual: %d, %+v, %v", index, mod, err)
+	}
+	if i, found := ctx.TunMetadataIndex(0x0102, 2); !found || i != 1 {
+		t.Errorf("Expect the option mapped to index 1, actual: %d, %v", i, found)
+	}
+	if _, _, err = ctx.MapGeneveOption(0x0102, 2, 12); err == nil {
+		t.Errorf("Expect error to map the option with another length")
+	}
+	if _, _, err = ctx.MapGeneveOption(0x0102, 3, 6); err == nil {
+		t.Errorf("Expect error for the invalid option length")
+	}
+
+	field, err := ctx.NewTunMetadataMatchField(1, []byte{0x12, 0x34}, []byte{0xff, 0x00})
+	if err != nil {
+		t.Fatalf("Failed to create tun_metadata1 field: %v", err)
+	}
+	if field.Field != NXM_NX_TUN_METADATA1 || field.Length != 16 ||
+		!bytes.Equal(field.Value.(*ByteArrayField).Data, []byte{0, 0, 0, 0, 0, 0, 0x12, 0x34}) ||
+		!bytes.Equal(field.Mask.(*ByteArrayField).Data, []byte{0, 0, 0, 0, 0, 0, 0xff, 0}) {
+		t.Errorf("Unexpected tun_metadata1 field: %+v", field)
+	}
+	action, err := ctx.NewTunMetadataSetField(0, []byte{1, 2, 3, 4})
+	if err != nil {
+		t.Fatalf("Failed to create set_field action: %v", err)
+	}
+	data, _ := action.MarshalBinary()
+	decoded, err := DecodeAction(data)
+	if err != nil {
+		t.Fatalf("Failed to decode set_field action: %v", err)
+	}
+	if f := decoded.(*ActionSetField).Field; f.Field != NXM_NX_TUN_METADATA0 || !bytes.Equal(f.Value.(*ByteArrayField).Data, []byte{1, 2, 3, 4}) {
+		t.Errorf("Unexpected set_field action: %+v", f)
+	}
+
+	if _, err = ctx.NewTunMetadataMatchField(2, []byte{1}, nil); err == nil {
+		t.Errorf("Expect error for the unmapped tun_metadata2")
+	}
+	if _, err = ctx.NewTunMetadataMatchField(0, []byte{1, 2, 3, 4, 5}, nil); err == nil {
+		t.Errorf("Expect error for the value longer than the option")
+	}
+}
@@ -0,0 +1,169 @@
+package openflow14
+
+// This file has the bundle messages of OpenFlow 1.4, which are the standard messages OFPT_BUNDLE_CONTROL and
+// OFPT_BUNDLE_ADD_MESSAGE in place of the ONF experimenter messages of openflow13.
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/contiv/libOpenflow/common"
+	"github.com/contiv/libOpenflow/util"
+)
+
+// The bundle message types of OpenFlow 1.4.
+const (
+	Type_BundleControl    = 33
+	Type_BundleAddMessage = 34
+)
+
+// ET_BUNDLE_FAILED is the OFPET_BUNDLE_FAILED error type of OpenFlow 1.4, the codes are OFPBFC_*.
+const ET_BUNDLE_FAILED = 17
+
+// ofp_bundle_failed_code 1.4
+const (
+	OFPBFC_UNKNOWN            = 0  /* Unspecified error. */
+	OFPBFC_EPERM              = 1  /* Permissions error. */
+	OFPBFC_BAD_ID             = 2  /* Bundle ID doesn't exist. */
+	OFPBFC_BUNDLE_EXIST       = 3  /* Bundle ID already exists. */
+	OFPBFC_BUNDLE_CLOSED      = 4  /* Bundle ID is closed. */
+	OFPBFC_OUT_OF_BUNDLES     = 5  /* Too many bundles IDs. */
+	OFPBFC_BAD_TYPE           = 6  /* Unsupported or unknown message control type. */
+	OFPBFC_BAD_FLAGS          = 7  /* Unsupported, unknown, or inconsistent flags. */
+	OFPBFC_MSG_BAD_LEN        = 8  /* Length problem in included message. */
+	OFPBFC_MSG_BAD_XID        = 9  /* Inconsistent or duplicate XID. */
+	OFPBFC_MSG_UNSUP          = 10 /* Unsupported message in this bundle. */
+	OFPBFC_MSG_CONFLICT       = 11 /* Unsupported message combination in this bundle. */
+	OFPBFC_MSG_TOO_MANY       = 12 /* Can't handle this many messages in bundle. */
+	OFPBFC_MSG_FAILED         = 13 /* One message in bundle failed. */
+	OFPBFC_TIMEOUT            = 14 /* Bundle is taking too long. */
+	OFPBFC_BUNDLE_IN_PROGRESS = 15 /* Bundle is locking the resource. */
+)
+
+// BundleControl is the ofp_bundle_ctrl_msg 1.4. The type is one of openflow13.OFPBCT_*_REQUEST or _REPLY, and the
+// flags are openflow13.OFPBCT_ATOMIC and OFPBCT_ORDERED, which are OFPBF_* of OpenFlow 1.4. The bundle
+// properties are not supported, they are not sent and are ignored when received.
+type BundleControl struct {
+	common.Header
+	BundleID uint32
+	Type     uint16 /* One of OFPBCT_*. */
+	Flags    uint16 /* Bitmap of OFPBF_* flags. */
+}
+
+func NewBundleControl(bundleID uint32, ctrlType uint16, flags uint16) *BundleControl {
+	b := new(BundleControl)
+	b.Header = NewOfp14Header()
+	b.Header.Type = Type_BundleControl
+	b.BundleID = bundleID
+	b.Type = ctrlType
+	b.Flags = flags
+	return b
+}
+
+func (b *BundleControl) Len() (n uint16) {
+	return b.Header.Len() + 8
+}
+
+func (b *BundleControl) MarshalBinary() (data []byte, err error) {
+	b.Header.Length = b.Len()
+	data, err = b.Header.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	body := make([]byte, 8)
+	binary.BigEndian.PutUint32(body[0:], b.BundleID)
+	binary.BigEndian.PutUint16(body[4:], b.Type)
+	binary.BigEndian.PutUint16(body[6:], b.Flags)
+	return append(data, body...), nil
+}
+
+func (b *BundleControl) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("the []byte is too short to unmarshal a full BundleControl message")
+	}
+	if err := b.Header.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if b.Header.Length < 16 || int(b.Header.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full BundleControl message")
+	}
+	n := int(b.Header.Len())
+	b.BundleID = binary.BigEndian.Uint32(data[n:])
+	b.Type = binary.BigEndian.Uint16(data[n+4:])
+	b.Flags = binary.BigEndian.Uint16(data[n+6:])
+	return nil
+}
+
+// BundleAdd is the ofp_bundle_add_msg 1.4, which adds the message to the opened bundle. The message is decoded
+// with Parse of this package. The bundle properties are not supported, they are not sent and are ignored when
+// received.
+type BundleAdd struct {
+	common.Header
+	BundleID uint32
+	pad      [2]uint8
+	Flags    uint16 /* Bitmap of OFPBF_* flags. */
+	Message  util.Message
+}
+
+func NewBundleAdd(bundleID uint32, flags uint16, msg util.Message) *BundleAdd {
+	b := new(BundleAdd)
+	b.Header = NewOfp14Header()
+	b.Header.Type = Type_BundleAddMessage
+	b.BundleID = bundleID
+	b.Flags = flags
+	b.Message = msg
+	return b
+}
+
+func (b *BundleAdd) Len() (n uint16) {
+	n = b.Header.Len() + 8
+	if b.Message != nil {
+		n += b.Message.Len()
+	}
+	return
+}
+
+func (b *BundleAdd) MarshalBinary() (data []byte, err error) {
+	if b.Message == nil {
+		return nil, errors.New("the BundleAdd message has no message to add")
+	}
+	b.Header.Length = b.Len()
+	data, err = b.Header.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	body := make([]byte, 8)
+	binary.BigEndian.PutUint32(body[0:], b.BundleID)
+	binary.BigEndian.PutUint16(body[6:], b.Flags)
+	data = append(data, body...)
+	msg, err := b.Message.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append(data, msg...), nil
+}
+
+func (b *BundleAdd) UnmarshalBinary(data []byte) error {
+	if len(data) < 24 {
+		return errors.New("the []byte is too short to unmarshal a full BundleAdd message")
+	}
+	if err := b.Header.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if b.Header.Length < 24 || int(b.Header.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full BundleAdd message")
+	}
+	n := int(b.Header.Len())
+	b.BundleID = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	n += 2 // for pad
+	b.Flags = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	msgLen := int(binary.BigEndian.Uint16(data[n+2:]))
+	if msgLen < 8 || n+msgLen > int(b.Header.Length) {
+		return errors.New("the []byte is too short to unmarshal the message of the BundleAdd message")
+	}
+	var err error
+	b.Message, err = Parse(data[n : n+msgLen])
+	return err
+}
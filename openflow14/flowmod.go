@@ -0,0 +1,92 @@
+package openflow14
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/contiv/libOpenflow/openflow13"
+)
+
+// importanceOffset is the offset of the importance in ofp_flow_mod, which is the pad of the OpenFlow 1.3 layout.
+const importanceOffset = 46
+
+// ofp_flow_mod 1.4, which is ofp_flow_mod 1.3 with the importance of the flow in the pad.
+type FlowMod struct {
+	openflow13.FlowMod
+	Importance uint16 /* Eviction precedence (optional). */
+}
+
+func NewFlowMod() *FlowMod {
+	f := new(FlowMod)
+	f.FlowMod = *openflow13.NewFlowMod()
+	f.Header.Version = VERSION
+	return f
+}
+
+func (f *FlowMod) MarshalBinary() (data []byte, err error) {
+	data, err = f.FlowMod.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint16(data[importanceOffset:], f.Importance)
+	return
+}
+
+func (f *FlowMod) UnmarshalBinary(data []byte) error {
+	if err := f.FlowMod.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if len(data) < importanceOffset+2 {
+		return errors.New("the []byte is too short to unmarshal a full FlowMod message")
+	}
+	f.Importance = binary.BigEndian.Uint16(data[importanceOffset:])
+	return nil
+}
+
+// MarshalJSON encodes the FlowMod as the OpenFlow 1.3 FlowMod with the Importance.
+func (f *FlowMod) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(&f.FlowMod)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if fields["Importance"], err = json.Marshal(f.Importance); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+func (f *FlowMod) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &f.FlowMod); err != nil {
+		return err
+	}
+	var importance struct {
+		Importance uint16
+	}
+	if err := json.Unmarshal(data, &importance); err != nil {
+		return err
+	}
+	f.Importance = importance.Importance
+	return nil
+}
+
+// NewGroupMod returns an OpenFlow 1.4 group mod, which has the same layout as OpenFlow 1.3.
+func NewGroupMod() *openflow13.GroupMod {
+	g := openflow13.NewGroupMod()
+	g.Header.Version = VERSION
+	return g
+}
+
+// String returns the flow mod in the syntax of ovs-ofctl add-flow with the importance, e.g.,
+// "importance=10,priority=100,ip actions=drop".
+func (f *FlowMod) String() string {
+	if f.Importance == 0 {
+		return f.FlowMod.String()
+	}
+	return fmt.Sprintf("importance=%d,%s", f.Importance, f.FlowMod.String())
+}
@@ -0,0 +1,196 @@
+package openflow14
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/contiv/libOpenflow/common"
+	"github.com/contiv/libOpenflow/openflow13"
+	"github.com/contiv/libOpenflow/util"
+)
+
+// NewMultipartRequest returns an OpenFlow 1.4 multipart request of mpType with the body, the request bodies
+// have the same layouts as OpenFlow 1.3.
+func NewMultipartRequest(mpType uint16, body util.Message) *openflow13.MultipartRequest {
+	req := &openflow13.MultipartRequest{Header: NewOfp14Header(), Type: mpType, Body: body}
+	req.Header.Type = openflow13.Type_MultiPartRequest
+	if req.Body == nil {
+		req.Body = new(util.Buffer)
+	}
+	return req
+}
+
+// ofp_multipart_reply 1.4. The bodies of OFPMP_PORT_DESC are openflow13.Port15, which is the ofp_port of both
+// OpenFlow 1.4 and 1.5, and the bodies of OFPMP_PORT_STATS and OFPMP_QUEUE_STATS are PortStats and QueueStats of
+// this package. The other bodies are decoded as OpenFlow 1.3.
+type MultipartReply struct {
+	openflow13.MultipartReply
+}
+
+// hasReplyBody14 returns true if the bodies of the multipart reply of mpType have the OpenFlow 1.4 layouts.
+func hasReplyBody14(mpType uint16) bool {
+	switch mpType {
+	case openflow13.MultipartType_PortDesc, openflow13.MultipartType_Port, openflow13.MultipartType_Queue:
+		return true
+	}
+	return false
+}
+
+// decodeReplyBody14 decodes a body of the multipart reply of mpType in the OpenFlow 1.4 layout, and returns the
+// body and its length.
+func decodeReplyBody14(mpType uint16, data []byte) (util.Message, int, error) {
+	switch mpType {
+	case openflow13.MultipartType_PortDesc:
+		port := openflow13.NewPort15()
+		if err := port.UnmarshalBinary(data); err != nil {
+			return nil, 0, err
+		}
+		return port, int(port.Length), nil
+	case openflow13.MultipartType_Port:
+		stats := new(PortStats)
+		if err := stats.UnmarshalBinary(data); err != nil {
+			return nil, 0, err
+		}
+		return stats, int(stats.Length), nil
+	case openflow13.MultipartType_Queue:
+		stats := new(QueueStats)
+		if err := stats.UnmarshalBinary(data); err != nil {
+			return nil, 0, err
+		}
+		return stats, int(stats.Length), nil
+	}
+	return nil, 0, fmt.Errorf("multipart type %d has no OpenFlow 1.4 body", mpType)
+}
+
+func (s *MultipartReply) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 || !hasReplyBody14(binary.BigEndian.Uint16(data[8:])) {
+		return s.MultipartReply.UnmarshalBinary(data)
+	}
+	if err := s.Header.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if int(s.Header.Length) > len(data) || s.Header.Length < 16 {
+		return errors.New("the []byte is too short to unmarshal a full MultipartReply message")
+	}
+	n := int(s.Header.Len())
+	s.Type = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	s.Flags = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	n += 4 // for padding
+
+	s.Body = nil
+	for n < int(s.Header.Length) {
+		body, length, err := decodeReplyBody14(s.Type, data[n:s.Header.Length])
+		if err != nil {
+			return err
+		}
+		s.Body = append(s.Body, body)
+		n += length
+	}
+	return nil
+}
+
+// replyBody14JSON is the multipart reply with the OpenFlow 1.4 bodies in JSON, the bodies are kept as the wire
+// bytes as their properties are polymorphic.
+type replyBody14JSON struct {
+	common.Header
+	Type  uint16
+	Flags uint16
+	Body  []struct {
+		Data []byte
+	}
+}
+
+// MarshalJSON encodes the MultipartReply as the OpenFlow 1.3 MultipartReply, the OpenFlow 1.4 bodies of
+// OFPMP_PORT_DESC, OFPMP_PORT_STATS and OFPMP_QUEUE_STATS are encoded as the raw bytes.
+func (s *MultipartReply) MarshalJSON() ([]byte, error) {
+	if !hasReplyBody14(s.Type) {
+		return json.Marshal(&s.MultipartReply)
+	}
+	reply := replyBody14JSON{Header: s.Header, Type: s.Type, Flags: s.Flags}
+	reply.Body = make([]struct{ Data []byte }, len(s.Body))
+	for i, body := range s.Body {
+		var err error
+		if reply.Body[i].Data, err = body.MarshalBinary(); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(&reply)
+}
+
+func (s *MultipartReply) UnmarshalJSON(data []byte) error {
+	var reply replyBody14JSON
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return err
+	}
+	if !hasReplyBody14(reply.Type) {
+		return json.Unmarshal(data, &s.MultipartReply)
+	}
+	s.Header, s.Type, s.Flags = reply.Header, reply.Type, reply.Flags
+	s.Header.Type = openflow13.Type_MultiPartReply
+	s.Body = nil
+	for _, b := range reply.Body {
+		body, _, err := decodeReplyBody14(s.Type, b.Data)
+		if err != nil {
+			return err
+		}
+		s.Body = append(s.Body, body)
+	}
+	return nil
+}
+
+// ofp_port_status 1.4, the port is in the layout of ofp_port 1.4.
+type PortStatus struct {
+	common.Header
+	Reason uint8 /* One of OFPPR_*. */
+	pad    [7]uint8
+	Desc   openflow13.Port15
+}
+
+func NewPortStatus() *PortStatus {
+	p := new(PortStatus)
+	p.Header = NewOfp14Header()
+	p.Header.Type = openflow13.Type_PortStatus
+	p.Desc = *openflow13.NewPort15()
+	return p
+}
+
+func (p *PortStatus) Len() (n uint16) {
+	return p.Header.Len() + 8 + p.Desc.Len()
+}
+
+func (p *PortStatus) MarshalBinary() (data []byte, err error) {
+	p.Header.Length = p.Len()
+	data, err = p.Header.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 8)
+	b[0] = p.Reason
+	data = append(data, b...)
+	b, err = p.Desc.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, b...)
+	return
+}
+
+func (p *PortStatus) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return errors.New("the []byte is too short to unmarshal a full PortStatus message")
+	}
+	if err := p.Header.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if int(p.Header.Length) > len(data) || p.Header.Length < 16 {
+		return errors.New("the []byte is too short to unmarshal a full PortStatus message")
+	}
+	n := int(p.Header.Len())
+	p.Reason = data[n]
+	n += 8
+	return p.Desc.UnmarshalBinary(data[n:p.Header.Length])
+}
@@ -0,0 +1,77 @@
+// Package openflow14 provides the OpenFlow 1.4 messages, wire protocol 0x05.
+//
+// OpenFlow 1.4 keeps most of the OpenFlow 1.3 layouts, so the messages are shared with package openflow13, e.g.,
+// the matches, actions, instructions, group mods and most multipart stats, and only the messages whose layout is
+// changed are defined here, e.g., the flow mod with importance, the ports and the port and queue stats with
+// properties, and the bundle messages. The ports have the same layout as OpenFlow 1.5 in package openflow13. The
+// messages created by this package have the header of version 0x05.
+//
+// Struct documentation is taken from the OpenFlow Switch Specification Version 1.4.1.
+package openflow14
+
+import (
+	"errors"
+
+	"github.com/contiv/libOpenflow/common"
+	"github.com/contiv/libOpenflow/openflow13"
+	"github.com/contiv/libOpenflow/util"
+)
+
+const (
+	VERSION = 5
+)
+
+// Returns a new OpenFlow header with version field set to v1.4.
+var NewOfp14Header func() common.Header = common.NewHeaderGenerator(VERSION)
+
+func NewEchoRequest() *common.Header {
+	h := NewOfp14Header()
+	h.Type = openflow13.Type_EchoRequest
+	return &h
+}
+
+func NewEchoReply() *common.Header {
+	h := NewOfp14Header()
+	h.Type = openflow13.Type_EchoReply
+	return &h
+}
+
+func NewBarrierRequest() *common.Header {
+	h := NewOfp14Header()
+	h.Type = openflow13.Type_BarrierRequest
+	return &h
+}
+
+// Parse parses an OpenFlow 1.4 message. The messages with the OpenFlow 1.4 layouts are decoded by this package,
+// and the others are decoded by openflow13.Parse.
+func Parse(b []byte) (message util.Message, err error) {
+	if len(b) < 8 {
+		return nil, common.ErrHeaderTooShort
+	}
+	if b[0] != VERSION && b[1] != openflow13.Type_Hello {
+		return nil, errors.New("the message is not an OpenFlow 1.4 message")
+	}
+	switch b[1] {
+	case openflow13.Type_FlowMod:
+		message = NewFlowMod()
+		err = message.UnmarshalBinary(b)
+	case openflow13.Type_GroupMod:
+		message = NewGroupMod()
+		err = message.UnmarshalBinary(b)
+	case openflow13.Type_PortStatus:
+		message = NewPortStatus()
+		err = message.UnmarshalBinary(b)
+	case openflow13.Type_MultiPartReply:
+		message = new(MultipartReply)
+		err = message.UnmarshalBinary(b)
+	case Type_BundleControl:
+		message = new(BundleControl)
+		err = message.UnmarshalBinary(b)
+	case Type_BundleAddMessage:
+		message = new(BundleAdd)
+		err = message.UnmarshalBinary(b)
+	default:
+		return openflow13.Parse(b)
+	}
+	return
+}
@@ -0,0 +1,259 @@
+package openflow14
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/contiv/libOpenflow/common"
+	"github.com/contiv/libOpenflow/openflow13"
+	"github.com/contiv/libOpenflow/util"
+)
+
+func marshalAndParse(t *testing.T, msg util.Message) util.Message {
+	t.Helper()
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal %T: %v", msg, err)
+	}
+	if data[0] != VERSION {
+		t.Fatalf("Expect version %d of %T, actual: %d", VERSION, msg, data[0])
+	}
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse %T: %v", msg, err)
+	}
+	return parsed
+}
+
+func TestFlowMod(t *testing.T) {
+	flow := NewFlowMod()
+	flow.TableId = 2
+	flow.Priority = 100
+	flow.OutGroup = 7
+	flow.Importance = 30
+	flow.Match.AddField(*openflow13.NewInPortField(1))
+	instr := openflow13.NewInstrApplyActions()
+	instr.AddAction(openflow13.NewActionOutput(openflow13.P_NORMAL), false)
+	flow.AddInstruction(instr)
+
+	parsed, ok := marshalAndParse(t, flow).(*FlowMod)
+	if !ok {
+		t.Fatalf("Expect *FlowMod")
+	}
+	if parsed.TableId != 2 || parsed.Priority != 100 || parsed.OutGroup != 7 || parsed.Importance != 30 ||
+		len(parsed.Match.Fields) != 1 || len(parsed.Instructions) != 1 {
+		t.Errorf("Unexpected FlowMod: %+v", parsed)
+	}
+	if s := parsed.String(); s != "importance=30,table=2,priority=100,in_port=1 actions=NORMAL" {
+		t.Errorf("Unexpected FlowMod string: %s", s)
+	}
+}
+
+func TestGroupMod(t *testing.T) {
+	group := NewGroupMod()
+	group.GroupId = 10
+	bucket := openflow13.NewBucket()
+	bucket.AddAction(openflow13.NewActionOutput(openflow13.P_LOCAL))
+	group.AddBucket(*bucket)
+
+	parsed, ok := marshalAndParse(t, group).(*openflow13.GroupMod)
+	if !ok || parsed.GroupId != 10 || len(parsed.Buckets) != 1 {
+		t.Errorf("Unexpected GroupMod: %+v", parsed)
+	}
+}
+
+func TestPortDesc(t *testing.T) {
+	port := openflow13.NewPort15()
+	port.PortNo = 3
+	port.Properties = append(port.Properties, openflow13.NewPortDescPropEthernet())
+	reply := &MultipartReply{MultipartReply: openflow13.MultipartReply{Header: NewOfp14Header(),
+		Type: openflow13.MultipartType_PortDesc, Body: []util.Message{port}}}
+	reply.Header.Type = openflow13.Type_MultiPartReply
+
+	parsed, ok := marshalAndParse(t, reply).(*MultipartReply)
+	if !ok || len(parsed.Body) != 1 {
+		t.Fatalf("Unexpected port desc reply: %+v", parsed)
+	}
+	if parsedPort, ok := parsed.Body[0].(*openflow13.Port15); !ok || parsedPort.PortNo != 3 || len(parsedPort.Properties) != 1 {
+		t.Errorf("Unexpected port: %+v", parsed.Body[0])
+	}
+
+	status := NewPortStatus()
+	status.Reason = openflow13.PR_MODIFY
+	status.Desc = *port
+	parsedStatus, ok := marshalAndParse(t, status).(*PortStatus)
+	if !ok || parsedStatus.Reason != openflow13.PR_MODIFY || parsedStatus.Desc.PortNo != 3 {
+		t.Errorf("Unexpected PortStatus: %+v", parsedStatus)
+	}
+}
+
+func TestParseOpenFlow13Layouts(t *testing.T) {
+	req := NewMultipartRequest(openflow13.MultipartType_Flow, openflow13.NewFlowStatsRequest())
+	if _, ok := marshalAndParse(t, req).(*openflow13.MultipartRequest); !ok {
+		t.Errorf("Expect *openflow13.MultipartRequest")
+	}
+	if _, ok := marshalAndParse(t, NewBarrierRequest()).(*common.Header); !ok {
+		t.Errorf("Expect *common.Header of barrier request")
+	}
+
+	data, _ := openflow13.NewFlowMod().MarshalBinary()
+	if _, err := Parse(data); err == nil {
+		t.Errorf("Expect error when parsing an OpenFlow 1.3 message")
+	}
+}
+
+func TestJSON(t *testing.T) {
+	flow := NewFlowMod()
+	flow.Priority = 100
+	flow.Importance = 30
+	flow.Match.AddField(*openflow13.NewInPortField(1))
+	data, err := json.Marshal(flow)
+	if err != nil {
+		t.Fatalf("Failed to encode FlowMod in JSON: %v", err)
+	}
+	decoded := new(FlowMod)
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("Failed to decode FlowMod from JSON %s: %v", data, err)
+	}
+	if s := decoded.String(); s != "importance=30,priority=100,in_port=1 actions=drop" {
+		t.Errorf("Unexpected FlowMod string: %s", s)
+	}
+
+	port := openflow13.NewPort15()
+	port.PortNo = 3
+	port.Properties = append(port.Properties, openflow13.NewPortDescPropEthernet())
+	reply := &MultipartReply{MultipartReply: openflow13.MultipartReply{Header: NewOfp14Header(),
+		Type: openflow13.MultipartType_PortDesc, Body: []util.Message{port}}}
+	reply.Header.Type = openflow13.Type_MultiPartReply
+	if data, err = json.Marshal(reply); err != nil {
+		t.Fatalf("Failed to encode MultipartReply in JSON: %v", err)
+	}
+	decodedReply := new(MultipartReply)
+	if err := json.Unmarshal(data, decodedReply); err != nil {
+		t.Fatalf("Failed to decode MultipartReply from JSON %s: %v", data, err)
+	}
+	expected, _ := reply.MarshalBinary()
+	if actual, _ := decodedReply.MarshalBinary(); !bytes.Equal(expected, actual) {
+		t.Errorf("Decoded MultipartReply from JSON %s is different:\n%x\n%x", data, expected, actual)
+	}
+}
+
+// FuzzParse checks Parse never panics or loops forever on arbitrary input, run it with
+// go test -fuzz FuzzParse ./openflow14.
+func FuzzParse(f *testing.F) {
+	flow := NewFlowMod()
+	flow.Match.AddField(*openflow13.NewInPortField(1))
+	instr := openflow13.NewInstrApplyActions()
+	instr.AddAction(openflow13.NewActionOutput(openflow13.P_NORMAL), false)
+	flow.AddInstruction(instr)
+	port := openflow13.NewPort15()
+	port.Properties = append(port.Properties, openflow13.NewPortDescPropEthernet())
+	reply := &MultipartReply{MultipartReply: openflow13.MultipartReply{Header: NewOfp14Header(),
+		Type: openflow13.MultipartType_PortDesc, Body: []util.Message{port}}}
+	reply.Header.Type = openflow13.Type_MultiPartReply
+	status := NewPortStatus()
+	status.Desc = *port
+	portStats := NewPortStats()
+	portStats.Properties = append(portStats.Properties, NewPortStatsPropEthernet())
+	statsReply := &MultipartReply{MultipartReply: openflow13.MultipartReply{Header: NewOfp14Header(),
+		Type: openflow13.MultipartType_Port, Body: []util.Message{portStats}}}
+	statsReply.Header.Type = openflow13.Type_MultiPartReply
+	for _, msg := range []util.Message{flow, NewGroupMod(), reply, status, NewEchoRequest(), statsReply,
+		NewBundleControl(1, openflow13.OFPBCT_OPEN_REQUEST, 0), NewBundleAdd(1, 0, flow)} {
+		if data, err := msg.MarshalBinary(); err == nil {
+			f.Add(data)
+		}
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Parse(data)
+	})
+}
+
+func TestPortAndQueueStats(t *testing.T) {
+	portStats := NewPortStats()
+	portStats.PortNo = 3
+	portStats.RxPackets = 10
+	portStats.TxErrors = 2
+	ethernet := NewPortStatsPropEthernet()
+	ethernet.RxCRCErr = 5
+	portStats.Properties = append(portStats.Properties, ethernet,
+		&openflow13.PortDescPropUnknown{PortDescPropHeader: openflow13.PortDescPropHeader{Type: PSPT_EXPERIMENTER}, Data: []byte{0, 0, 0x23, 0x20, 0, 0, 0, 1, 0xaa}})
+	reply := &MultipartReply{MultipartReply: openflow13.MultipartReply{Header: NewOfp14Header(),
+		Type: openflow13.MultipartType_Port, Body: []util.Message{portStats}}}
+	reply.Header.Type = openflow13.Type_MultiPartReply
+
+	parsed, ok := marshalAndParse(t, reply).(*MultipartReply)
+	if !ok || len(parsed.Body) != 1 {
+		t.Fatalf("Unexpected port stats reply: %+v", parsed)
+	}
+	parsedStats, ok := parsed.Body[0].(*PortStats)
+	if !ok || parsedStats.PortNo != 3 || parsedStats.RxPackets != 10 || parsedStats.TxErrors != 2 ||
+		parsedStats.Length != 80+40+16 || len(parsedStats.Properties) != 2 {
+		t.Fatalf("Unexpected port stats: %+v", parsed.Body[0])
+	}
+	if e := parsedStats.Ethernet(); e == nil || e.RxCRCErr != 5 {
+		t.Errorf("Unexpected ethernet property: %+v", e)
+	}
+	if p, ok := parsedStats.Properties[1].(*openflow13.PortDescPropUnknown); !ok || len(p.Data) != 9 || p.Data[8] != 0xaa {
+		t.Errorf("Unexpected experimenter property: %+v", parsedStats.Properties[1])
+	}
+
+	queueStats := []util.Message{NewQueueStats(), NewQueueStats()}
+	queueStats[0].(*QueueStats).QueueId = 1
+	queueStats[1].(*QueueStats).QueueId = 2
+	queueStats[1].(*QueueStats).DurationSec = 60
+	reply = &MultipartReply{MultipartReply: openflow13.MultipartReply{Header: NewOfp14Header(),
+		Type: openflow13.MultipartType_Queue, Body: queueStats}}
+	reply.Header.Type = openflow13.Type_MultiPartReply
+	if parsed, ok = marshalAndParse(t, reply).(*MultipartReply); !ok || len(parsed.Body) != 2 {
+		t.Fatalf("Unexpected queue stats reply: %+v", parsed)
+	}
+	if q, ok := parsed.Body[1].(*QueueStats); !ok || q.QueueId != 2 || q.DurationSec != 60 || q.Length != 48 {
+		t.Errorf("Unexpected queue stats: %+v", parsed.Body[1])
+	}
+
+	// The property overrunning the length of the stats.
+	data, _ := portStats.MarshalBinary()
+	binary.BigEndian.PutUint16(data[0:], 80+8)
+	if err := new(PortStats).UnmarshalBinary(data); err == nil {
+		t.Errorf("Expect error for the truncated port stats property")
+	}
+	if err := new(QueueStats).UnmarshalBinary(make([]byte, 47)); err == nil {
+		t.Errorf("Expect error for the truncated queue stats")
+	}
+}
+
+func TestBundle(t *testing.T) {
+	ctrl := NewBundleControl(7, openflow13.OFPBCT_OPEN_REQUEST, openflow13.OFPBCT_ATOMIC)
+	parsedCtrl, ok := marshalAndParse(t, ctrl).(*BundleControl)
+	if !ok || parsedCtrl.BundleID != 7 || parsedCtrl.Type != openflow13.OFPBCT_OPEN_REQUEST ||
+		parsedCtrl.Flags != openflow13.OFPBCT_ATOMIC || parsedCtrl.Header.Length != 16 {
+		t.Errorf("Unexpected BundleControl: %+v", parsedCtrl)
+	}
+
+	flow := NewFlowMod()
+	flow.Importance = 30
+	add := NewBundleAdd(7, openflow13.OFPBCT_ATOMIC, flow)
+	data, err := add.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal BundleAdd: %v", err)
+	}
+	if data[1] != Type_BundleAddMessage || int(binary.BigEndian.Uint16(data[2:])) != len(data) {
+		t.Errorf("Unexpected BundleAdd header %x", data[:8])
+	}
+	parsedAdd, ok := marshalAndParse(t, add).(*BundleAdd)
+	if !ok || parsedAdd.BundleID != 7 || parsedAdd.Flags != openflow13.OFPBCT_ATOMIC {
+		t.Fatalf("Unexpected BundleAdd: %+v", parsedAdd)
+	}
+	if parsedFlow, ok := parsedAdd.Message.(*FlowMod); !ok || parsedFlow.Importance != 30 {
+		t.Errorf("Unexpected message in BundleAdd: %+v", parsedAdd.Message)
+	}
+
+	// The message overrunning the bundle add message.
+	binary.BigEndian.PutUint16(data[2:], 24)
+	if _, err := Parse(data); err == nil {
+		t.Errorf("Expect error for the truncated BundleAdd")
+	}
+}
@@ -0,0 +1,290 @@
+package openflow14
+
+// This file has the port and queue stats of OpenFlow 1.4, which have the length and the properties in place of
+// the flat fields of OpenFlow 1.3.
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/contiv/libOpenflow/openflow13"
+	"github.com/contiv/libOpenflow/util"
+)
+
+// ofp_port_stats_prop_type 1.4
+const (
+	PSPT_ETHERNET     = 0      /* Ethernet property. */
+	PSPT_OPTICAL      = 1      /* Optical property. */
+	PSPT_EXPERIMENTER = 0xffff /* Experimenter property. */
+)
+
+// PortStatsPropEthernet has the ethernet specific counters of the port, which are the flat fields of
+// ofp_port_stats in OpenFlow 1.3.
+type PortStatsPropEthernet struct {
+	openflow13.PortDescPropHeader
+	pad        [4]byte
+	RxFrameErr uint64 /* Number of frame alignment errors. */
+	RxOverErr  uint64 /* Number of packets with RX overrun. */
+	RxCRCErr   uint64 /* Number of CRC errors. */
+	Collisions uint64 /* Number of collisions. */
+}
+
+func NewPortStatsPropEthernet() *PortStatsPropEthernet {
+	p := new(PortStatsPropEthernet)
+	p.Type = PSPT_ETHERNET
+	p.Length = p.Len()
+	return p
+}
+
+func (p *PortStatsPropEthernet) Len() uint16 {
+	return 40
+}
+
+func (p *PortStatsPropEthernet) MarshalBinary() (data []byte, err error) {
+	p.Length = p.Len()
+	data = make([]byte, p.Len())
+	b, err := p.PortDescPropHeader.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := copy(data, b)
+	n += 4 // for pad
+	for _, v := range []uint64{p.RxFrameErr, p.RxOverErr, p.RxCRCErr, p.Collisions} {
+		binary.BigEndian.PutUint64(data[n:], v)
+		n += 8
+	}
+	return
+}
+
+func (p *PortStatsPropEthernet) UnmarshalBinary(data []byte) error {
+	if len(data) < int(p.Len()) {
+		return errors.New("the []byte is too short to unmarshal a full PortStatsPropEthernet message")
+	}
+	if err := p.PortDescPropHeader.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	n := 8
+	for _, v := range []*uint64{&p.RxFrameErr, &p.RxOverErr, &p.RxCRCErr, &p.Collisions} {
+		*v = binary.BigEndian.Uint64(data[n:])
+		n += 8
+	}
+	return nil
+}
+
+// decodeStatsProps decodes the properties in data, which is bounded by the length of the stats. The ethernet
+// property of the port stats is decoded, and the other properties are kept as openflow13.PortDescPropUnknown,
+// which has the same TLV layout.
+func decodeStatsProps(data []byte, portStats bool) ([]util.Message, error) {
+	var props []util.Message
+	n := 0
+	for n < len(data) {
+		header := new(openflow13.PortDescPropHeader)
+		if err := header.UnmarshalBinary(data[n:]); err != nil {
+			return nil, err
+		}
+		if header.Length < header.Len() || n+int(header.Length) > len(data) {
+			return nil, errors.New("the []byte is too short to unmarshal a full stats property")
+		}
+		var prop util.Message
+		if portStats && header.Type == PSPT_ETHERNET {
+			prop = new(PortStatsPropEthernet)
+		} else {
+			prop = new(openflow13.PortDescPropUnknown)
+		}
+		if err := prop.UnmarshalBinary(data[n : n+int(header.Length)]); err != nil {
+			return nil, err
+		}
+		props = append(props, prop)
+		// The properties are padded to 8 bytes, the padding is not counted in the length.
+		n += (int(header.Length) + 7) / 8 * 8
+	}
+	return props, nil
+}
+
+// propsLen returns the length of the properties with the padding to 8 bytes.
+func propsLen(props []util.Message) (n uint16) {
+	for _, prop := range props {
+		n += (prop.Len() + 7) / 8 * 8
+	}
+	return
+}
+
+// marshalProps marshals the properties with the padding to 8 bytes.
+func marshalProps(data []byte, props []util.Message) ([]byte, error) {
+	for _, prop := range props {
+		b, err := prop.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+		if pad := len(b) % 8; pad != 0 {
+			data = append(data, make([]byte, 8-pad)...)
+		}
+	}
+	return data, nil
+}
+
+// PortStats is the ofp_port_stats 1.4, the body of the OFPMP_PORT_STATS reply.
+type PortStats struct {
+	Length       uint16
+	pad          [2]uint8
+	PortNo       uint32
+	DurationSec  uint32 /* Time port has been alive in seconds. */
+	DurationNSec uint32 /* Time port has been alive in nanoseconds beyond duration_sec. */
+	RxPackets    uint64
+	TxPackets    uint64
+	RxBytes      uint64
+	TxBytes      uint64
+	RxDropped    uint64
+	TxDropped    uint64
+	RxErrors     uint64
+	TxErrors     uint64
+	Properties   []util.Message
+}
+
+const portStatsLen = 80
+
+func NewPortStats() *PortStats {
+	s := new(PortStats)
+	s.Length = s.Len()
+	return s
+}
+
+func (s *PortStats) Len() (n uint16) {
+	return portStatsLen + propsLen(s.Properties)
+}
+
+func (s *PortStats) MarshalBinary() (data []byte, err error) {
+	s.Length = s.Len()
+	data = make([]byte, portStatsLen)
+	n := 0
+	binary.BigEndian.PutUint16(data[n:], s.Length)
+	n += 2
+	n += 2 // for pad
+	binary.BigEndian.PutUint32(data[n:], s.PortNo)
+	n += 4
+	binary.BigEndian.PutUint32(data[n:], s.DurationSec)
+	n += 4
+	binary.BigEndian.PutUint32(data[n:], s.DurationNSec)
+	n += 4
+	for _, v := range []uint64{s.RxPackets, s.TxPackets, s.RxBytes, s.TxBytes, s.RxDropped, s.TxDropped,
+		s.RxErrors, s.TxErrors} {
+		binary.BigEndian.PutUint64(data[n:], v)
+		n += 8
+	}
+	return marshalProps(data, s.Properties)
+}
+
+func (s *PortStats) UnmarshalBinary(data []byte) error {
+	if len(data) < portStatsLen {
+		return errors.New("the []byte is too short to unmarshal a full PortStats message")
+	}
+	n := 0
+	s.Length = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	if s.Length < portStatsLen || int(s.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full PortStats message")
+	}
+	n += 2 // for pad
+	s.PortNo = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	s.DurationSec = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	s.DurationNSec = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	for _, v := range []*uint64{&s.RxPackets, &s.TxPackets, &s.RxBytes, &s.TxBytes, &s.RxDropped, &s.TxDropped,
+		&s.RxErrors, &s.TxErrors} {
+		*v = binary.BigEndian.Uint64(data[n:])
+		n += 8
+	}
+	var err error
+	s.Properties, err = decodeStatsProps(data[n:s.Length], true)
+	return err
+}
+
+// Ethernet returns the ethernet property of the port stats, or nil if there is none.
+func (s *PortStats) Ethernet() *PortStatsPropEthernet {
+	for _, prop := range s.Properties {
+		if p, ok := prop.(*PortStatsPropEthernet); ok {
+			return p
+		}
+	}
+	return nil
+}
+
+// QueueStats is the ofp_queue_stats 1.4, the body of the OFPMP_QUEUE_STATS reply. OpenFlow 1.4 only defines the
+// experimenter property of the queue stats, the properties are kept as openflow13.PortDescPropUnknown.
+type QueueStats struct {
+	Length       uint16
+	pad          [6]uint8
+	PortNo       uint32
+	QueueId      uint32
+	TxBytes      uint64
+	TxPackets    uint64
+	TxErrors     uint64
+	DurationSec  uint32 /* Time queue has been alive in seconds. */
+	DurationNSec uint32 /* Time queue has been alive in nanoseconds beyond duration_sec. */
+	Properties   []util.Message
+}
+
+const queueStatsLen = 48
+
+func NewQueueStats() *QueueStats {
+	s := new(QueueStats)
+	s.Length = s.Len()
+	return s
+}
+
+func (s *QueueStats) Len() (n uint16) {
+	return queueStatsLen + propsLen(s.Properties)
+}
+
+func (s *QueueStats) MarshalBinary() (data []byte, err error) {
+	s.Length = s.Len()
+	data = make([]byte, queueStatsLen)
+	n := 0
+	binary.BigEndian.PutUint16(data[n:], s.Length)
+	n += 2
+	n += 6 // for pad
+	binary.BigEndian.PutUint32(data[n:], s.PortNo)
+	n += 4
+	binary.BigEndian.PutUint32(data[n:], s.QueueId)
+	n += 4
+	for _, v := range []uint64{s.TxBytes, s.TxPackets, s.TxErrors} {
+		binary.BigEndian.PutUint64(data[n:], v)
+		n += 8
+	}
+	binary.BigEndian.PutUint32(data[n:], s.DurationSec)
+	n += 4
+	binary.BigEndian.PutUint32(data[n:], s.DurationNSec)
+	n += 4
+	return marshalProps(data, s.Properties)
+}
+
+func (s *QueueStats) UnmarshalBinary(data []byte) error {
+	if len(data) < queueStatsLen {
+		return errors.New("the []byte is too short to unmarshal a full QueueStats message")
+	}
+	n := 0
+	s.Length = binary.BigEndian.Uint16(data[n:])
+	n += 2
+	if s.Length < queueStatsLen || int(s.Length) > len(data) {
+		return errors.New("the []byte is too short to unmarshal a full QueueStats message")
+	}
+	n += 6 // for pad
+	s.PortNo = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	s.QueueId = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	for _, v := range []*uint64{&s.TxBytes, &s.TxPackets, &s.TxErrors} {
+		*v = binary.BigEndian.Uint64(data[n:])
+		n += 8
+	}
+	s.DurationSec = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	s.DurationNSec = binary.BigEndian.Uint32(data[n:])
+	n += 4
+	var err error
+	s.Properties, err = decodeStatsProps(data[n:s.Length], false)
+	return err
+}
@@ -89,3 +89,46 @@ func (a *ARP) UnmarshalBinary(data []byte) error {
 	a.IPDst = data[n : n+int(a.ProtoLength)]
 	return nil
 }
+
+// broadcastMAC is the Ethernet broadcast address.
+var broadcastMAC = net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+// newARPEthernet returns the Ethernet frame of the ARP packet of the operation from the source to the destination,
+// the frame is sent to the Ethernet destination.
+func newARPEthernet(op int, ethDst, srcMAC net.HardwareAddr, srcIP net.IP, dstMAC net.HardwareAddr, dstIP net.IP) *Ethernet {
+	a, _ := NewARP(op)
+	copy(a.HWSrc, srcMAC)
+	copy(a.IPSrc, srcIP.To4())
+	copy(a.HWDst, dstMAC)
+	copy(a.IPDst, dstIP.To4())
+	eth := NewEthernet()
+	copy(eth.HWDst, ethDst)
+	copy(eth.HWSrc, srcMAC)
+	eth.Ethertype = ARP_MSG
+	eth.Data = a
+	return eth
+}
+
+// NewARPRequest returns the broadcast Ethernet frame of the ARP request for the IP address dstIP from the source,
+// e.g., the data of a packet-out.
+func NewARPRequest(srcMAC net.HardwareAddr, srcIP, dstIP net.IP) *Ethernet {
+	return newARPEthernet(Type_Request, broadcastMAC, srcMAC, srcIP, nil, dstIP)
+}
+
+// NewARPReply returns the Ethernet frame of the ARP reply from the source, which answers that srcIP is at srcMAC,
+// to the requester of dstMAC and dstIP.
+func NewARPReply(srcMAC net.HardwareAddr, srcIP net.IP, dstMAC net.HardwareAddr, dstIP net.IP) *Ethernet {
+	return newARPEthernet(Type_Reply, dstMAC, srcMAC, srcIP, dstMAC, dstIP)
+}
+
+// NewGratuitousARP returns the broadcast Ethernet frame of the gratuitous ARP request which announces that ip is
+// at mac, the sender and the target IP addresses are both ip.
+func NewGratuitousARP(mac net.HardwareAddr, ip net.IP) *Ethernet {
+	return newARPEthernet(Type_Request, broadcastMAC, mac, ip, nil, ip)
+}
+
+// NewARPProbe returns the broadcast Ethernet frame of the ARP probe for the IP address, RFC 5227, the sender IP
+// address is 0.0.0.0 so that the probe doesn't update the ARP caches of the receivers.
+func NewARPProbe(mac net.HardwareAddr, ip net.IP) *Ethernet {
+	return newARPEthernet(Type_Request, broadcastMAC, mac, net.IPv4zero, nil, ip)
+}
@@ -0,0 +1,49 @@
+package protocol
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestARPHelpers(t *testing.T) {
+	mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:01")
+	peer, _ := net.ParseMAC("aa:bb:cc:dd:ee:02")
+	ip := net.ParseIP("10.0.0.1")
+	peerIP := net.ParseIP("10.0.0.2")
+	zeroMAC := net.HardwareAddr(make([]byte, 6))
+	for _, tc := range []struct {
+		name   string
+		eth    *Ethernet
+		ethDst net.HardwareAddr
+		op     uint16
+		hwDst  net.HardwareAddr
+		ipSrc  net.IP
+		ipDst  net.IP
+	}{
+		{"request", NewARPRequest(mac, ip, peerIP), broadcastMAC, Type_Request, zeroMAC, ip, peerIP},
+		{"reply", NewARPReply(mac, ip, peer, peerIP), peer, Type_Reply, peer, ip, peerIP},
+		{"gratuitous", NewGratuitousARP(mac, ip), broadcastMAC, Type_Request, zeroMAC, ip, ip},
+		{"probe", NewARPProbe(mac, ip), broadcastMAC, Type_Request, zeroMAC, net.IPv4zero, ip},
+	} {
+		data, err := tc.eth.MarshalBinary()
+		if err != nil {
+			t.Fatalf("Failed to marshal the ARP %s: %v", tc.name, err)
+		}
+		eth := new(Ethernet)
+		if err := eth.UnmarshalBinary(data); err != nil {
+			t.Fatalf("Failed to unmarshal the ARP %s: %v", tc.name, err)
+		}
+		if !bytes.Equal(eth.HWDst, tc.ethDst) || !bytes.Equal(eth.HWSrc, mac) || eth.Ethertype != ARP_MSG {
+			t.Errorf("Unexpected Ethernet header of the ARP %s: %s %s 0x%x", tc.name, eth.HWDst, eth.HWSrc, eth.Ethertype)
+		}
+		a, ok := eth.Data.(*ARP)
+		if !ok {
+			t.Fatalf("Unexpected payload of the ARP %s: %T", tc.name, eth.Data)
+		}
+		if a.Operation != tc.op || !bytes.Equal(a.HWSrc, mac) || !bytes.Equal(a.HWDst, tc.hwDst) ||
+			!a.IPSrc.Equal(tc.ipSrc) || !a.IPDst.Equal(tc.ipDst) {
+			t.Errorf("Unexpected ARP %s %+v", tc.name, a)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// The UDP destination ports of BFD, RFC 5881 and RFC 5883.
+const (
+	BFD_PORT          = 3784
+	BFD_ECHO_PORT     = 3785
+	BFD_MULTIHOP_PORT = 4784
+)
+
+// BFD session states.
+const (
+	BFD_STATE_ADMIN_DOWN = 0
+	BFD_STATE_DOWN       = 1
+	BFD_STATE_INIT       = 2
+	BFD_STATE_UP         = 3
+)
+
+// BFD diagnostic codes.
+const (
+	BFD_DIAG_NONE                     = 0
+	BFD_DIAG_CONTROL_DETECT_EXPIRED   = 1
+	BFD_DIAG_ECHO_FAILED              = 2
+	BFD_DIAG_NEIGHBOR_SIGNALED_DOWN   = 3
+	BFD_DIAG_FORWARDING_PLANE_RESET   = 4
+	BFD_DIAG_PATH_DOWN                = 5
+	BFD_DIAG_CONCATENATED_PATH_DOWN   = 6
+	BFD_DIAG_ADMIN_DOWN               = 7
+	BFD_DIAG_REVERSE_CONCAT_PATH_DOWN = 8
+)
+
+// BFD control packet flags, the A flag is set if Auth is not nil.
+const (
+	BFD_FLAG_POLL          = 0x20
+	BFD_FLAG_FINAL         = 0x10
+	BFD_FLAG_CONTROL_PLANE = 0x08
+	BFD_FLAG_AUTH          = 0x04
+	BFD_FLAG_DEMAND        = 0x02
+	BFD_FLAG_MULTIPOINT    = 0x01
+)
+
+const bfdControlLen = 24
+
+// BFDAuth is the authentication section of the BFD control packet, the length on the wire is the length of Data
+// plus the type and the length.
+type BFDAuth struct {
+	Type uint8
+	Data []byte
+}
+
+// BFD is a BFD control packet, RFC 5880, e.g., the Data of the UDP datagram to BFD_PORT. The intervals are in
+// microseconds.
+type BFD struct {
+	Version               uint8 // 3-bits
+	Diag                  uint8 // 5-bits
+	State                 uint8 // 2-bits
+	Flags                 uint8 // 6-bits
+	DetectMult            uint8
+	MyDiscriminator       uint32
+	YourDiscriminator     uint32
+	DesiredMinTxInterval  uint32
+	RequiredMinRxInterval uint32
+	RequiredMinEchoRx     uint32
+	Auth                  *BFDAuth
+}
+
+// NewBFD returns the BFD control packet of version 1 in the state with the local discriminator.
+func NewBFD(state uint8, myDiscriminator uint32) *BFD {
+	return &BFD{Version: 1, State: state, DetectMult: 3, MyDiscriminator: myDiscriminator}
+}
+
+func (b *BFD) Len() (n uint16) {
+	n = bfdControlLen
+	if b.Auth != nil {
+		n += 2 + uint16(len(b.Auth.Data))
+	}
+	return
+}
+
+func (b *BFD) MarshalBinary() (data []byte, err error) {
+	if b.Auth != nil && len(b.Auth.Data) > 0xff-2 {
+		return nil, fmt.Errorf("the BFD authentication data of %d bytes is longer than 253 bytes", len(b.Auth.Data))
+	}
+	data = make([]byte, int(b.Len()))
+	data[0] = b.Version<<5 | b.Diag&0x1f
+	flags := b.Flags &^ BFD_FLAG_AUTH
+	if b.Auth != nil {
+		flags |= BFD_FLAG_AUTH
+	}
+	data[1] = b.State<<6 | flags&0x3f
+	data[2] = b.DetectMult
+	data[3] = uint8(len(data))
+	binary.BigEndian.PutUint32(data[4:], b.MyDiscriminator)
+	binary.BigEndian.PutUint32(data[8:], b.YourDiscriminator)
+	binary.BigEndian.PutUint32(data[12:], b.DesiredMinTxInterval)
+	binary.BigEndian.PutUint32(data[16:], b.RequiredMinRxInterval)
+	binary.BigEndian.PutUint32(data[20:], b.RequiredMinEchoRx)
+	if b.Auth != nil {
+		data[24] = b.Auth.Type
+		data[25] = uint8(2 + len(b.Auth.Data))
+		copy(data[26:], b.Auth.Data)
+	}
+	return data, nil
+}
+
+func (b *BFD) UnmarshalBinary(data []byte) error {
+	if len(data) < bfdControlLen {
+		return errors.New("The []byte is too short to unmarshal a full BFD message.")
+	}
+	length := int(data[3])
+	if length < bfdControlLen || length > len(data) {
+		return fmt.Errorf("invalid BFD length %d of %d bytes", length, len(data))
+	}
+	b.Version = data[0] >> 5
+	b.Diag = data[0] & 0x1f
+	b.State = data[1] >> 6
+	b.Flags = data[1] & 0x3f
+	b.DetectMult = data[2]
+	b.MyDiscriminator = binary.BigEndian.Uint32(data[4:])
+	b.YourDiscriminator = binary.BigEndian.Uint32(data[8:])
+	b.DesiredMinTxInterval = binary.BigEndian.Uint32(data[12:])
+	b.RequiredMinRxInterval = binary.BigEndian.Uint32(data[16:])
+	b.RequiredMinEchoRx = binary.BigEndian.Uint32(data[20:])
+	b.Auth = nil
+	if b.Flags&BFD_FLAG_AUTH == 0 {
+		return nil
+	}
+	if length < bfdControlLen+2 || int(data[25]) < 2 || bfdControlLen+int(data[25]) > length {
+		return errors.New("The []byte is too short to unmarshal a full BFD authentication section.")
+	}
+	authLen := int(data[25])
+	b.Auth = &BFDAuth{Type: data[24], Data: append([]byte(nil), data[26:bfdControlLen+authLen]...)}
+	return nil
+}
@@ -0,0 +1,53 @@
+package protocol
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBFD(t *testing.T) {
+	b := NewBFD(BFD_STATE_UP, 0x11223344)
+	b.YourDiscriminator = 0x55667788
+	b.Diag = BFD_DIAG_CONTROL_DETECT_EXPIRED
+	b.Flags = BFD_FLAG_POLL
+	b.DesiredMinTxInterval = 300000
+	b.RequiredMinRxInterval = 300000
+	data, err := b.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal BFD: %v", err)
+	}
+	if !bytes.Equal(data[:8], []byte{0x21, 0xe0, 3, 24, 0x11, 0x22, 0x33, 0x44}) || len(data) != 24 {
+		t.Fatalf("Unexpected BFD %x", data)
+	}
+
+	b2 := new(BFD)
+	if err := b2.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal BFD: %v", err)
+	}
+	if *b2 != *b {
+		t.Errorf("Unexpected BFD %+v, expected %+v", b2, b)
+	}
+
+	b.Auth = &BFDAuth{Type: 1, Data: []byte{1, 3, 'k', 'e', 'y'}}
+	data, err = b.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal BFD: %v", err)
+	}
+	if len(data) != 31 || data[3] != 31 || data[1]&BFD_FLAG_AUTH == 0 {
+		t.Fatalf("Unexpected BFD with authentication %x", data)
+	}
+	if err := b2.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal BFD: %v", err)
+	}
+	if b2.Auth == nil || b2.Auth.Type != 1 || !bytes.Equal(b2.Auth.Data, b.Auth.Data) {
+		t.Errorf("Unexpected BFD authentication %+v", b2.Auth)
+	}
+
+	if err := b2.UnmarshalBinary(data[:20]); err == nil {
+		t.Errorf("Expected an error for the truncated BFD")
+	}
+	data[3] = 25
+	if err := b2.UnmarshalBinary(data); err == nil {
+		t.Errorf("Expected an error for the truncated authentication section")
+	}
+}
@@ -229,6 +229,9 @@ func (d *DHCP) Write(b []byte) (n int, err error) {
 	if err = binary.Read(buf, binary.BigEndian, &clientHWAddr); err != nil {
 		return
 	}
+	if d.HardwareLen > 16 {
+		return n, errors.New("Bad DHCP hardware address length")
+	}
 	d.ClientHWAddr = net.HardwareAddr(clientHWAddr[:d.HardwareLen])
 	n += 16
 
@@ -489,17 +492,75 @@ func DHCPParseOptions(in []byte) (opts []DHCPOption, err error) {
 		case DHCP_OPT_END:
 			return
 		default:
-			if len(in)-pos >= 1 {
-				_len := in[pos]
-				pos++
-				opts = append(opts, DHCPNewOption(tag, in[pos:pos+int(_len)]))
-				pos += int(_len)
+			if len(in)-pos < 1 || len(in)-pos-1 < int(in[pos]) {
+				return opts, errors.New("The []byte is too short to unmarshal a full DHCP option.")
 			}
+			_len := in[pos]
+			pos++
+			opts = append(opts, DHCPNewOption(tag, in[pos:pos+int(_len)]))
+			pos += int(_len)
 		}
 	}
 	return
 }
 
+func (d *DHCP) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(d.Len()))
+	if _, err = d.Read(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (d *DHCP) UnmarshalBinary(data []byte) error {
+	_, err := d.Write(data)
+	return err
+}
+
+// Option returns the first option of the tag, or nil if there is none.
+func (d *DHCP) Option(tag byte) DHCPOption {
+	for _, opt := range d.Options {
+		if opt.OptionType() == tag {
+			return opt
+		}
+	}
+	return nil
+}
+
+// MessageType returns the DHCP message type in the option 53, e.g., DHCP_MSG_REQUEST, it is DHCP_MSG_UNSPEC if
+// there is no such option.
+func (d *DHCP) MessageType() DHCPOperation {
+	if opt := d.Option(DHCP_OPT_MESSAGE_TYPE); opt != nil && len(opt.Bytes()) == 1 {
+		return DHCPOperation(opt.Bytes()[0])
+	}
+	return DHCP_MSG_UNSPEC
+}
+
+// RequestedIP returns the address in the requested IP address option, or nil if there is none.
+func (d *DHCP) RequestedIP() net.IP {
+	return d.ip4Option(DHCP_OPT_REQUEST_IP)
+}
+
+// ServerID returns the address in the server identifier option, or nil if there is none.
+func (d *DHCP) ServerID() net.IP {
+	return d.ip4Option(DHCP_OPT_SERVER_ID)
+}
+
+// ClientID returns the client identifier option, whose first byte is the hardware type, or nil if there is none.
+func (d *DHCP) ClientID() []byte {
+	if opt := d.Option(DHCP_OPT_CLIENT_ID); opt != nil {
+		return opt.Bytes()
+	}
+	return nil
+}
+
+func (d *DHCP) ip4Option(tag byte) net.IP {
+	if opt := d.Option(tag); opt != nil && len(opt.Bytes()) == 4 {
+		return net.IP(append([]byte(nil), opt.Bytes()...))
+	}
+	return nil
+}
+
 func NewDHCPDiscover(xid uint32, hwAddr net.HardwareAddr) (d *DHCP, err error) {
 	if d, err = NewDHCP(xid, DHCP_MSG_DISCOVER, DHCP_HW_ETHERNET); err != nil {
 		return
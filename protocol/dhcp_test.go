@@ -0,0 +1,44 @@
+package protocol
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestDHCPOptions(t *testing.T) {
+	mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	d, err := NewDHCPRequest(0x1234, mac)
+	if err != nil {
+		t.Fatalf("Failed to build DHCP request: %v", err)
+	}
+	requestIP, _ := DHCPIP4Option(DHCP_OPT_REQUEST_IP, net.ParseIP("10.0.0.5"))
+	serverID, _ := DHCPIP4Option(DHCP_OPT_SERVER_ID, net.ParseIP("10.0.0.1"))
+	d.Options = append(d.Options, requestIP, serverID, DHCPNewOption(DHCP_OPT_CLIENT_ID, append([]byte{DHCP_HW_ETHERNET}, mac...)))
+	data, err := d.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal DHCP: %v", err)
+	}
+	if len(data) != int(d.Len()) {
+		t.Fatalf("Expect %d bytes, actual: %d", d.Len(), len(data))
+	}
+
+	decoded := new(DHCP)
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal DHCP: %v", err)
+	}
+	if decoded.Xid != 0x1234 || !bytes.Equal(decoded.ClientHWAddr, mac) || decoded.MessageType() != DHCP_MSG_REQUEST {
+		t.Errorf("Unexpected DHCP message %+v", decoded)
+	}
+	if !decoded.RequestedIP().Equal(net.ParseIP("10.0.0.5")) || !decoded.ServerID().Equal(net.ParseIP("10.0.0.1")) {
+		t.Errorf("Unexpected addresses %s and %s", decoded.RequestedIP(), decoded.ServerID())
+	}
+	if id := decoded.ClientID(); !bytes.Equal(id[1:], mac) {
+		t.Errorf("Unexpected client ID %x", id)
+	}
+
+	// The options are truncated in the middle of the last one.
+	if err := decoded.UnmarshalBinary(data[:len(data)-4]); err == nil {
+		t.Errorf("Expect error to unmarshal the truncated option")
+	}
+}
@@ -0,0 +1,267 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+// UDP ports of DHCPv6, RFC 8415.
+const (
+	DHCPv6_CLIENT_PORT = 546
+	DHCPv6_SERVER_PORT = 547
+)
+
+// DHCPv6 message types.
+const (
+	DHCPv6_MSG_SOLICIT             = 1
+	DHCPv6_MSG_ADVERTISE           = 2
+	DHCPv6_MSG_REQUEST             = 3
+	DHCPv6_MSG_CONFIRM             = 4
+	DHCPv6_MSG_RENEW               = 5
+	DHCPv6_MSG_REBIND              = 6
+	DHCPv6_MSG_REPLY               = 7
+	DHCPv6_MSG_RELEASE             = 8
+	DHCPv6_MSG_DECLINE             = 9
+	DHCPv6_MSG_RECONFIGURE         = 10
+	DHCPv6_MSG_INFORMATION_REQUEST = 11
+	DHCPv6_MSG_RELAY_FORW          = 12
+	DHCPv6_MSG_RELAY_REPL          = 13
+)
+
+// DHCPv6 option codes.
+const (
+	DHCPv6_OPT_CLIENTID     = 1
+	DHCPv6_OPT_SERVERID     = 2
+	DHCPv6_OPT_IA_NA        = 3
+	DHCPv6_OPT_IA_TA        = 4
+	DHCPv6_OPT_IAADDR       = 5
+	DHCPv6_OPT_ORO          = 6
+	DHCPv6_OPT_PREFERENCE   = 7
+	DHCPv6_OPT_ELAPSED_TIME = 8
+	DHCPv6_OPT_RELAY_MSG    = 9
+	DHCPv6_OPT_STATUS_CODE  = 13
+	DHCPv6_OPT_RAPID_COMMIT = 14
+	DHCPv6_OPT_INTERFACE_ID = 18
+	DHCPv6_OPT_IA_PD        = 25
+	DHCPv6_OPT_IAPREFIX     = 26
+)
+
+// DHCPv6Option is a DHCPv6 option, the length on the wire is the length of Data.
+type DHCPv6Option struct {
+	Code uint16
+	Data []byte
+}
+
+func NewDHCPv6Option(code uint16, data []byte) *DHCPv6Option {
+	return &DHCPv6Option{Code: code, Data: data}
+}
+
+func (o *DHCPv6Option) Len() uint16 {
+	return 4 + uint16(len(o.Data))
+}
+
+func (o *DHCPv6Option) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(o.Len()))
+	binary.BigEndian.PutUint16(data[0:], o.Code)
+	binary.BigEndian.PutUint16(data[2:], uint16(len(o.Data)))
+	copy(data[4:], o.Data)
+	return data, nil
+}
+
+func (o *DHCPv6Option) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full DHCPv6Option message.")
+	}
+	o.Code = binary.BigEndian.Uint16(data[0:])
+	length := int(binary.BigEndian.Uint16(data[2:]))
+	if len(data) < 4+length {
+		return errors.New("The []byte is too short to unmarshal a full DHCPv6Option message.")
+	}
+	o.Data = make([]byte, length)
+	copy(o.Data, data[4:4+length])
+	return nil
+}
+
+func dhcpv6OptionsLen(opts []*DHCPv6Option) uint16 {
+	var n uint16
+	for _, o := range opts {
+		n += o.Len()
+	}
+	return n
+}
+
+func marshalDHCPv6Options(data []byte, opts []*DHCPv6Option) {
+	n := 0
+	for _, o := range opts {
+		b, _ := o.MarshalBinary()
+		copy(data[n:], b)
+		n += len(b)
+	}
+}
+
+// ParseDHCPv6Options parses the options, e.g., the options of a DHCPv6 message or the options encapsulated in
+// an IA_NA option after its IAID, T1 and T2.
+func ParseDHCPv6Options(data []byte) ([]*DHCPv6Option, error) {
+	var opts []*DHCPv6Option
+	for n := 0; n < len(data); {
+		o := new(DHCPv6Option)
+		if err := o.UnmarshalBinary(data[n:]); err != nil {
+			return nil, err
+		}
+		opts = append(opts, o)
+		n += int(o.Len())
+	}
+	return opts, nil
+}
+
+func findDHCPv6Option(opts []*DHCPv6Option, code uint16) *DHCPv6Option {
+	for _, o := range opts {
+		if o.Code == code {
+			return o
+		}
+	}
+	return nil
+}
+
+// DHCPv6 is a DHCPv6 message between a client and a server, TransactionID is 24 bits.
+type DHCPv6 struct {
+	MessageType   uint8
+	TransactionID uint32
+	Options       []*DHCPv6Option
+}
+
+func NewDHCPv6(msgType uint8, transactionID uint32) *DHCPv6 {
+	return &DHCPv6{MessageType: msgType, TransactionID: transactionID & 0xffffff}
+}
+
+// AddOption adds the option to the message.
+func (d *DHCPv6) AddOption(o *DHCPv6Option) {
+	d.Options = append(d.Options, o)
+}
+
+// Option returns the first option of the code, or nil if there is none.
+func (d *DHCPv6) Option(code uint16) *DHCPv6Option {
+	return findDHCPv6Option(d.Options, code)
+}
+
+// ClientID returns the DUID in the client identifier option, or nil if there is none.
+func (d *DHCPv6) ClientID() []byte {
+	if o := d.Option(DHCPv6_OPT_CLIENTID); o != nil {
+		return o.Data
+	}
+	return nil
+}
+
+// ServerID returns the DUID in the server identifier option, or nil if there is none.
+func (d *DHCPv6) ServerID() []byte {
+	if o := d.Option(DHCPv6_OPT_SERVERID); o != nil {
+		return o.Data
+	}
+	return nil
+}
+
+func (d *DHCPv6) Len() uint16 {
+	return 4 + dhcpv6OptionsLen(d.Options)
+}
+
+func (d *DHCPv6) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(d.Len()))
+	binary.BigEndian.PutUint32(data, uint32(d.MessageType)<<24|d.TransactionID&0xffffff)
+	marshalDHCPv6Options(data[4:], d.Options)
+	return data, nil
+}
+
+func (d *DHCPv6) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full DHCPv6 message.")
+	}
+	d.MessageType = data[0]
+	d.TransactionID = binary.BigEndian.Uint32(data) & 0xffffff
+	var err error
+	d.Options, err = ParseDHCPv6Options(data[4:])
+	return err
+}
+
+// DHCPv6Relay is a DHCPv6 Relay-forward or Relay-reply message between relay agents and servers.
+type DHCPv6Relay struct {
+	MessageType uint8
+	HopCount    uint8
+	LinkAddress net.IP
+	PeerAddress net.IP
+	Options     []*DHCPv6Option
+}
+
+// NewDHCPv6RelayForward returns the Relay-forward message which relays the message received from the peer on the
+// link, msg is a *DHCPv6 from a client or a *DHCPv6Relay from another relay agent.
+func NewDHCPv6RelayForward(hopCount uint8, linkAddr, peerAddr net.IP, msg util.Message) (*DHCPv6Relay, error) {
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	r := &DHCPv6Relay{MessageType: DHCPv6_MSG_RELAY_FORW, HopCount: hopCount, LinkAddress: linkAddr, PeerAddress: peerAddr}
+	r.Options = append(r.Options, NewDHCPv6Option(DHCPv6_OPT_RELAY_MSG, data))
+	return r, nil
+}
+
+// Option returns the first option of the code, or nil if there is none.
+func (r *DHCPv6Relay) Option(code uint16) *DHCPv6Option {
+	return findDHCPv6Option(r.Options, code)
+}
+
+// RelayMessage decodes the message in the relay message option.
+func (r *DHCPv6Relay) RelayMessage() (util.Message, error) {
+	o := r.Option(DHCPv6_OPT_RELAY_MSG)
+	if o == nil {
+		return nil, errors.New("no relay message option in the DHCPv6 relay message")
+	}
+	return DecodeDHCPv6(o.Data)
+}
+
+func (r *DHCPv6Relay) Len() uint16 {
+	return 34 + dhcpv6OptionsLen(r.Options)
+}
+
+func (r *DHCPv6Relay) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(r.Len()))
+	data[0] = r.MessageType
+	data[1] = r.HopCount
+	copy(data[2:18], r.LinkAddress.To16())
+	copy(data[18:34], r.PeerAddress.To16())
+	marshalDHCPv6Options(data[34:], r.Options)
+	return data, nil
+}
+
+func (r *DHCPv6Relay) UnmarshalBinary(data []byte) error {
+	if len(data) < 34 {
+		return errors.New("The []byte is too short to unmarshal a full DHCPv6Relay message.")
+	}
+	r.MessageType = data[0]
+	r.HopCount = data[1]
+	r.LinkAddress = net.IP(append([]byte(nil), data[2:18]...))
+	r.PeerAddress = net.IP(append([]byte(nil), data[18:34]...))
+	var err error
+	r.Options, err = ParseDHCPv6Options(data[34:])
+	return err
+}
+
+// DecodeDHCPv6 decodes the DHCPv6 message, e.g., the Data of the UDP datagram to DHCPv6_SERVER_PORT. It is a
+// *DHCPv6Relay for the relay messages, and a *DHCPv6 otherwise.
+func DecodeDHCPv6(data []byte) (util.Message, error) {
+	if len(data) < 1 {
+		return nil, errors.New("The []byte is too short to unmarshal a full DHCPv6 message.")
+	}
+	var msg util.Message
+	switch data[0] {
+	case DHCPv6_MSG_RELAY_FORW, DHCPv6_MSG_RELAY_REPL:
+		msg = new(DHCPv6Relay)
+	default:
+		msg = new(DHCPv6)
+	}
+	if err := msg.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
@@ -0,0 +1,48 @@
+package protocol
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestDHCPv6Relay(t *testing.T) {
+	duid := []byte{0, 3, 0, 1, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	solicit := NewDHCPv6(DHCPv6_MSG_SOLICIT, 0xabcdef)
+	solicit.AddOption(NewDHCPv6Option(DHCPv6_OPT_CLIENTID, duid))
+	solicit.AddOption(NewDHCPv6Option(DHCPv6_OPT_ELAPSED_TIME, []byte{0, 0}))
+
+	relay, err := NewDHCPv6RelayForward(0, net.ParseIP("2001:db8::1"), net.ParseIP("fe80::2"), solicit)
+	if err != nil {
+		t.Fatalf("Failed to build relay message: %v", err)
+	}
+	data, err := relay.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal relay message: %v", err)
+	}
+	if len(data) != 34+4+4+14+6 {
+		t.Fatalf("Unexpected length %d", len(data))
+	}
+
+	msg, err := DecodeDHCPv6(data)
+	if err != nil {
+		t.Fatalf("Failed to decode relay message: %v", err)
+	}
+	decodedRelay, ok := msg.(*DHCPv6Relay)
+	if !ok || decodedRelay.MessageType != DHCPv6_MSG_RELAY_FORW || !decodedRelay.PeerAddress.Equal(net.ParseIP("fe80::2")) {
+		t.Fatalf("Unexpected relay message %+v", msg)
+	}
+	msg, err = decodedRelay.RelayMessage()
+	if err != nil {
+		t.Fatalf("Failed to decode relayed message: %v", err)
+	}
+	decoded, ok := msg.(*DHCPv6)
+	if !ok || decoded.MessageType != DHCPv6_MSG_SOLICIT || decoded.TransactionID != 0xabcdef ||
+		!bytes.Equal(decoded.ClientID(), duid) || decoded.ServerID() != nil {
+		t.Errorf("Unexpected relayed message %+v", msg)
+	}
+
+	if _, err := DecodeDHCPv6(data[:len(data)-1]); err == nil {
+		t.Errorf("Expect error to decode the truncated option")
+	}
+}
@@ -0,0 +1,391 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+const DNS_PORT = 53
+
+// DNS resource record types.
+const (
+	DNSType_A     = 1
+	DNSType_NS    = 2
+	DNSType_CNAME = 5
+	DNSType_PTR   = 12
+	DNSType_TXT   = 16
+	DNSType_AAAA  = 28
+)
+
+const DNSClass_IN = 1
+
+// DNS opcodes and response codes.
+const (
+	DNSOpcode_Query = 0
+
+	DNSRCode_NoError  = 0
+	DNSRCode_FormErr  = 1
+	DNSRCode_ServFail = 2
+	DNSRCode_NXDomain = 3
+	DNSRCode_NotImp   = 4
+	DNSRCode_Refused  = 5
+)
+
+const (
+	dnsHeaderLen     = 12
+	dnsMaxNameLen    = 255
+	dnsMaxLabelLen   = 63
+	dnsPointerMask   = 0xc0
+	dnsMaxPointerOfs = 0x3fff
+)
+
+// DNS is a DNS query or response, RFC 1035. The names are without the trailing dot, e.g., "example.com", and
+// they are compressed when the message is marshaled.
+type DNS struct {
+	ID                 uint16
+	Response           bool
+	Opcode             uint8 // 4-bits
+	Authoritative      bool
+	Truncated          bool
+	RecursionDesired   bool
+	RecursionAvailable bool
+	Z                  uint8 // 3-bits
+	RCode              uint8 // 4-bits
+
+	Questions   []DNSQuestion
+	Answers     []DNSResourceRecord
+	Authorities []DNSResourceRecord
+	Additionals []DNSResourceRecord
+}
+
+type DNSQuestion struct {
+	Name  string
+	Type  uint16
+	Class uint16
+}
+
+// DNSResourceRecord is a resource record. The data of the A, AAAA, NS, CNAME, PTR and TXT records are in IP,
+// Target and TXT, the data of the other types are kept as raw bytes in Data.
+type DNSResourceRecord struct {
+	Name  string
+	Type  uint16
+	Class uint16
+	TTL   uint32
+
+	IP     net.IP   // A and AAAA
+	Target string   // NS, CNAME and PTR
+	TXT    []string // TXT
+	Data   []byte   // the other types
+}
+
+// NewDNSQuery returns the recursive query of the name and the type, e.g., DNSType_A.
+func NewDNSQuery(id uint16, name string, qtype uint16) *DNS {
+	return &DNS{
+		ID:               id,
+		RecursionDesired: true,
+		Questions:        []DNSQuestion{{Name: name, Type: qtype, Class: DNSClass_IN}},
+	}
+}
+
+// NewDNSResponse returns the response of the query with its ID and questions, and the answers.
+func NewDNSResponse(query *DNS, answers ...DNSResourceRecord) *DNS {
+	return &DNS{
+		ID:                 query.ID,
+		Response:           true,
+		Opcode:             query.Opcode,
+		RecursionDesired:   query.RecursionDesired,
+		RecursionAvailable: true,
+		Questions:          append([]DNSQuestion(nil), query.Questions...),
+		Answers:            answers,
+	}
+}
+
+func (d *DNS) Len() (n uint16) {
+	data, err := d.MarshalBinary()
+	if err != nil {
+		return dnsHeaderLen
+	}
+	return uint16(len(data))
+}
+
+func (d *DNS) MarshalBinary() (data []byte, err error) {
+	e := &dnsEncoder{data: make([]byte, dnsHeaderLen, 512), names: make(map[string]int)}
+	binary.BigEndian.PutUint16(e.data[0:], d.ID)
+	var flags uint16
+	if d.Response {
+		flags |= 1 << 15
+	}
+	flags |= uint16(d.Opcode&0xf) << 11
+	if d.Authoritative {
+		flags |= 1 << 10
+	}
+	if d.Truncated {
+		flags |= 1 << 9
+	}
+	if d.RecursionDesired {
+		flags |= 1 << 8
+	}
+	if d.RecursionAvailable {
+		flags |= 1 << 7
+	}
+	flags |= uint16(d.Z&0x7) << 4
+	flags |= uint16(d.RCode & 0xf)
+	binary.BigEndian.PutUint16(e.data[2:], flags)
+	for i, count := range []int{len(d.Questions), len(d.Answers), len(d.Authorities), len(d.Additionals)} {
+		if count > 0xffff {
+			return nil, errors.New("too many DNS records")
+		}
+		binary.BigEndian.PutUint16(e.data[4+2*i:], uint16(count))
+	}
+
+	for _, q := range d.Questions {
+		if err := e.name(q.Name); err != nil {
+			return nil, err
+		}
+		e.uint16(q.Type)
+		e.uint16(q.Class)
+	}
+	for _, records := range [][]DNSResourceRecord{d.Answers, d.Authorities, d.Additionals} {
+		for i := range records {
+			if err := e.record(&records[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(e.data) > 0xffff {
+		return nil, errors.New("the DNS message is too long")
+	}
+	return e.data, nil
+}
+
+func (d *DNS) UnmarshalBinary(data []byte) error {
+	if len(data) < dnsHeaderLen {
+		return errors.New("The []byte is too short to unmarshal a full DNS message.")
+	}
+	d.ID = binary.BigEndian.Uint16(data[0:])
+	flags := binary.BigEndian.Uint16(data[2:])
+	d.Response = flags&(1<<15) != 0
+	d.Opcode = uint8(flags>>11) & 0xf
+	d.Authoritative = flags&(1<<10) != 0
+	d.Truncated = flags&(1<<9) != 0
+	d.RecursionDesired = flags&(1<<8) != 0
+	d.RecursionAvailable = flags&(1<<7) != 0
+	d.Z = uint8(flags>>4) & 0x7
+	d.RCode = uint8(flags) & 0xf
+	qdCount := int(binary.BigEndian.Uint16(data[4:]))
+	counts := []int{int(binary.BigEndian.Uint16(data[6:])), int(binary.BigEndian.Uint16(data[8:])),
+		int(binary.BigEndian.Uint16(data[10:]))}
+
+	n := dnsHeaderLen
+	d.Questions = nil
+	for i := 0; i < qdCount; i++ {
+		var q DNSQuestion
+		var err error
+		if q.Name, n, err = decodeDNSName(data, n); err != nil {
+			return err
+		}
+		if n+4 > len(data) {
+			return errors.New("The []byte is too short to unmarshal a full DNS question.")
+		}
+		q.Type = binary.BigEndian.Uint16(data[n:])
+		q.Class = binary.BigEndian.Uint16(data[n+2:])
+		n += 4
+		d.Questions = append(d.Questions, q)
+	}
+	sections := []*[]DNSResourceRecord{&d.Answers, &d.Authorities, &d.Additionals}
+	for i, section := range sections {
+		*section = nil
+		for j := 0; j < counts[i]; j++ {
+			var rr DNSResourceRecord
+			var err error
+			if n, err = rr.decode(data, n); err != nil {
+				return err
+			}
+			*section = append(*section, rr)
+		}
+	}
+	return nil
+}
+
+// dnsEncoder encodes a DNS message, names has the offsets of the names already in data for the compression.
+type dnsEncoder struct {
+	data  []byte
+	names map[string]int
+}
+
+func (e *dnsEncoder) uint16(v uint16) {
+	e.data = append(e.data, byte(v>>8), byte(v))
+}
+
+func (e *dnsEncoder) name(name string) error {
+	name = strings.TrimSuffix(name, ".")
+	if len(name)+2 > dnsMaxNameLen {
+		return fmt.Errorf("the DNS name %s is too long", name)
+	}
+	for name != "" {
+		key := strings.ToLower(name)
+		if ofs, ok := e.names[key]; ok {
+			e.uint16(uint16(dnsPointerMask)<<8 | uint16(ofs))
+			return nil
+		}
+		if len(e.data) <= dnsMaxPointerOfs {
+			e.names[key] = len(e.data)
+		}
+		label := name
+		if i := strings.IndexByte(name, '.'); i >= 0 {
+			label, name = name[:i], name[i+1:]
+		} else {
+			name = ""
+		}
+		if len(label) == 0 || len(label) > dnsMaxLabelLen {
+			return fmt.Errorf("invalid DNS label %q", label)
+		}
+		e.data = append(e.data, byte(len(label)))
+		e.data = append(e.data, label...)
+	}
+	e.data = append(e.data, 0)
+	return nil
+}
+
+func (e *dnsEncoder) record(rr *DNSResourceRecord) error {
+	if err := e.name(rr.Name); err != nil {
+		return err
+	}
+	e.uint16(rr.Type)
+	e.uint16(rr.Class)
+	e.data = append(e.data, byte(rr.TTL>>24), byte(rr.TTL>>16), byte(rr.TTL>>8), byte(rr.TTL))
+	lengthOfs := len(e.data)
+	e.uint16(0)
+	switch rr.Type {
+	case DNSType_A:
+		ip := rr.IP.To4()
+		if ip == nil {
+			return fmt.Errorf("invalid IPv4 address %s in A record", rr.IP)
+		}
+		e.data = append(e.data, ip...)
+	case DNSType_AAAA:
+		if len(rr.IP) != net.IPv6len {
+			return fmt.Errorf("invalid IPv6 address %s in AAAA record", rr.IP)
+		}
+		e.data = append(e.data, rr.IP...)
+	case DNSType_NS, DNSType_CNAME, DNSType_PTR:
+		if err := e.name(rr.Target); err != nil {
+			return err
+		}
+	case DNSType_TXT:
+		for _, s := range rr.TXT {
+			if len(s) > 0xff {
+				return errors.New("the TXT string is longer than 255 bytes")
+			}
+			e.data = append(e.data, byte(len(s)))
+			e.data = append(e.data, s...)
+		}
+	default:
+		e.data = append(e.data, rr.Data...)
+	}
+	rdLength := len(e.data) - lengthOfs - 2
+	if rdLength > 0xffff {
+		return errors.New("the DNS record data is too long")
+	}
+	binary.BigEndian.PutUint16(e.data[lengthOfs:], uint16(rdLength))
+	return nil
+}
+
+func (rr *DNSResourceRecord) decode(data []byte, n int) (int, error) {
+	var err error
+	if rr.Name, n, err = decodeDNSName(data, n); err != nil {
+		return n, err
+	}
+	if n+10 > len(data) {
+		return n, errors.New("The []byte is too short to unmarshal a full DNS resource record.")
+	}
+	rr.Type = binary.BigEndian.Uint16(data[n:])
+	rr.Class = binary.BigEndian.Uint16(data[n+2:])
+	rr.TTL = binary.BigEndian.Uint32(data[n+4:])
+	rdLength := int(binary.BigEndian.Uint16(data[n+8:]))
+	n += 10
+	end := n + rdLength
+	if end > len(data) {
+		return n, errors.New("The []byte is too short to unmarshal a full DNS resource record.")
+	}
+	rdata := data[n:end]
+	switch rr.Type {
+	case DNSType_A, DNSType_AAAA:
+		if (rr.Type == DNSType_A && rdLength != net.IPv4len) || (rr.Type == DNSType_AAAA && rdLength != net.IPv6len) {
+			return n, fmt.Errorf("invalid address length %d of DNS record type %d", rdLength, rr.Type)
+		}
+		rr.IP = net.IP(append([]byte(nil), rdata...))
+	case DNSType_NS, DNSType_CNAME, DNSType_PTR:
+		// The name in the data may point to the other names of the message.
+		target, next, err := decodeDNSName(data[:end], n)
+		if err != nil {
+			return n, err
+		}
+		if next != end {
+			return n, errors.New("invalid DNS record data length")
+		}
+		rr.Target = target
+	case DNSType_TXT:
+		rr.TXT = nil
+		for i := 0; i < len(rdata); {
+			l := int(rdata[i])
+			if i+1+l > len(rdata) {
+				return n, errors.New("The []byte is too short to unmarshal a full TXT string.")
+			}
+			rr.TXT = append(rr.TXT, string(rdata[i+1:i+1+l]))
+			i += 1 + l
+		}
+	default:
+		rr.Data = append([]byte(nil), rdata...)
+	}
+	return end, nil
+}
+
+// decodeDNSName decodes the possibly compressed name at n, and returns it with the offset after it.
+func decodeDNSName(data []byte, n int) (string, int, error) {
+	var labels []string
+	next := -1
+	nameLen := 0
+	// Every pointer must go backward, so that there is no loop.
+	limit := n
+	for {
+		if n >= len(data) {
+			return "", n, errors.New("The []byte is too short to unmarshal a full DNS name.")
+		}
+		l := int(data[n])
+		switch {
+		case l == 0:
+			if next < 0 {
+				next = n + 1
+			}
+			return strings.Join(labels, "."), next, nil
+		case l&dnsPointerMask == dnsPointerMask:
+			if n+1 >= len(data) {
+				return "", n, errors.New("The []byte is too short to unmarshal a full DNS name.")
+			}
+			ptr := int(binary.BigEndian.Uint16(data[n:]) & dnsMaxPointerOfs)
+			if ptr >= limit {
+				return "", n, fmt.Errorf("invalid DNS name pointer %d", ptr)
+			}
+			if next < 0 {
+				next = n + 2
+			}
+			n, limit = ptr, ptr
+		case l&dnsPointerMask != 0:
+			return "", n, fmt.Errorf("invalid DNS label length 0x%x", l)
+		default:
+			if n+1+l > len(data) {
+				return "", n, errors.New("The []byte is too short to unmarshal a full DNS name.")
+			}
+			nameLen += l + 1
+			if nameLen > dnsMaxNameLen {
+				return "", n, errors.New("the DNS name is too long")
+			}
+			labels = append(labels, string(data[n+1:n+1+l]))
+			n += 1 + l
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package protocol
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestDNS(t *testing.T) {
+	query := NewDNSQuery(0x1234, "www.example.com", DNSType_A)
+	data, err := query.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal DNS query: %v", err)
+	}
+	if len(data) != 12+17+4 {
+		t.Errorf("Unexpected length of DNS query %d", len(data))
+	}
+
+	response := NewDNSResponse(query,
+		DNSResourceRecord{Name: "www.example.com", Type: DNSType_CNAME, Class: DNSClass_IN, TTL: 60, Target: "web.example.com"},
+		DNSResourceRecord{Name: "web.example.com", Type: DNSType_A, Class: DNSClass_IN, TTL: 60, IP: net.ParseIP("10.0.0.1").To4()},
+		DNSResourceRecord{Name: "web.example.com", Type: DNSType_AAAA, Class: DNSClass_IN, TTL: 60, IP: net.ParseIP("2001:db8::1")},
+	)
+	response.Additionals = []DNSResourceRecord{
+		{Name: "1.0.0.10.in-addr.arpa", Type: DNSType_PTR, Class: DNSClass_IN, TTL: 30, Target: "web.example.com"},
+		{Name: "example.com", Type: DNSType_TXT, Class: DNSClass_IN, TTL: 30, TXT: []string{"v=spf1", "-all"}},
+		{Name: "example.com", Type: 99, Class: DNSClass_IN, TTL: 30, Data: []byte{1, 2, 3}},
+	}
+	data, err = response.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal DNS response: %v", err)
+	}
+	decoded := new(DNS)
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal DNS response: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, response) {
+		t.Errorf("Unexpected DNS response\n%+v\n%+v", decoded, response)
+	}
+	// The names after the first one are compressed with pointers.
+	if n := bytes.Count(data, []byte("example")); n != 1 {
+		t.Errorf("Expect the name example once in the compressed message, actual: %d", n)
+	}
+	if decoded.Len() != uint16(len(data)) {
+		t.Errorf("Unexpected length %d", decoded.Len())
+	}
+
+	// The pointer to itself is a loop.
+	loop := append(data[:12:12], 0xc0, 12, 0, 1, 0, 1)
+	loop[5] = 1
+	if err := new(DNS).UnmarshalBinary(loop); err == nil {
+		t.Errorf("Expect error to unmarshal the pointer loop")
+	}
+	if err := decoded.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Errorf("Expect error to unmarshal the truncated message")
+	}
+}
@@ -16,19 +16,25 @@ const (
 	WOL_MSG  = 0x0842
 	RARP_MSG = 0x8035
 	VLAN_MSG = 0x8100
+	QINQ_MSG = 0x88a8
+
+	SLOW_PROTOCOLS_MSG = 0x8809
 
 	IPv6_MSG     = 0x86DD
 	STP_MSG      = 0x4242
 	STP_BPDU_MSG = 0xAAAA
 )
 
+// Ethernet is an Ethernet frame with up to two VLAN tags. VLANID is the outer tag, e.g., the 802.1ad service tag
+// of TPID QINQ_MSG, and InnerVLANID is the inner tag of a double tagged frame. A tag is absent if its VID is 0.
 type Ethernet struct {
-	Delimiter uint8
-	HWDst     net.HardwareAddr
-	HWSrc     net.HardwareAddr
-	VLANID    VLAN
-	Ethertype uint16
-	Data      util.Message
+	Delimiter   uint8
+	HWDst       net.HardwareAddr
+	HWSrc       net.HardwareAddr
+	VLANID      VLAN
+	InnerVLANID VLAN
+	Ethertype   uint16
+	Data        util.Message
 }
 
 func NewEthernet() *Ethernet {
@@ -36,6 +42,7 @@ func NewEthernet() *Ethernet {
 	eth.HWDst = net.HardwareAddr(make([]byte, 6))
 	eth.HWSrc = net.HardwareAddr(make([]byte, 6))
 	eth.VLANID = *NewVLAN()
+	eth.InnerVLANID = *NewVLAN()
 	eth.Ethertype = 0x800
 	eth.Data = nil
 	return eth
@@ -44,9 +51,7 @@ func NewEthernet() *Ethernet {
 func (e *Ethernet) Len() (n uint16) {
 	n = 0
 	n += 12
-	if e.VLANID.VID != 0 {
-		n += 4
-	}
+	n += 4 * uint16(len(e.vlanTags()))
 	n += 2
 	if e.Data != nil {
 		n += e.Data.Len()
@@ -63,8 +68,8 @@ func (e *Ethernet) MarshalBinary() (data []byte, err error) {
 	copy(data[n:], e.HWSrc)
 	n += len(e.HWSrc)
 
-	if e.VLANID.VID != 0 {
-		bytes, err = e.VLANID.MarshalBinary()
+	for _, v := range e.vlanTags() {
+		bytes, err = v.MarshalBinary()
 		if err != nil {
 			return
 		}
@@ -97,19 +102,21 @@ func (e *Ethernet) UnmarshalBinary(data []byte) error {
 	copy(e.HWSrc, data[n:n+6])
 	n += 6
 
+	e.VLANID = *new(VLAN)
+	e.InnerVLANID = *new(VLAN)
 	e.Ethertype = binary.BigEndian.Uint16(data[n:])
-	if e.Ethertype == VLAN_MSG {
-		e.VLANID = *new(VLAN)
-		err := e.VLANID.UnmarshalBinary(data[n:])
-		if err != nil {
+	for _, v := range []*VLAN{&e.VLANID, &e.InnerVLANID} {
+		if e.Ethertype != VLAN_MSG && e.Ethertype != QINQ_MSG {
+			break
+		}
+		if len(data) < n+6 {
+			return errors.New("The []byte is too short to unmarshal a full Ethernet message.")
+		}
+		if err := v.UnmarshalBinary(data[n:]); err != nil {
 			return err
 		}
-		n += int(e.VLANID.Len())
-
+		n += int(v.Len())
 		e.Ethertype = binary.BigEndian.Uint16(data[n:])
-	} else {
-		e.VLANID = *new(VLAN)
-		e.VLANID.VID = 0
 	}
 	n += 2
 
@@ -120,12 +127,70 @@ func (e *Ethernet) UnmarshalBinary(data []byte) error {
 		e.Data = new(IPv6)
 	case ARP_MSG:
 		e.Data = new(ARP)
+	case LLDP_MSG:
+		e.Data = decodeOrRaw(new(LLDP), data[n:])
+		return nil
+	case SLOW_PROTOCOLS_MSG:
+		if len(data) > n && data[n] == LACP_SUBTYPE {
+			e.Data = decodeOrRaw(new(LACP), data[n:])
+			return nil
+		}
+		e.Data = new(util.Buffer)
 	default:
 		e.Data = new(util.Buffer)
 	}
 	return e.Data.UnmarshalBinary(data[n:])
 }
 
+// decodeOrRaw decodes data as msg, or returns data as a util.Buffer if it fails, e.g., for a frame truncated by the
+// max_len of a packet-in, so that the payload doesn't fail the enclosing message.
+func decodeOrRaw(msg util.Message, data []byte) util.Message {
+	if err := msg.UnmarshalBinary(data); err != nil {
+		buf := new(util.Buffer)
+		buf.UnmarshalBinary(data)
+		return buf
+	}
+	return msg
+}
+
+// vlanTags returns the VLAN tags in the frame from the outer one. The outer tag is present if the inner tag is.
+func (e *Ethernet) vlanTags() []*VLAN {
+	if e.InnerVLANID.VID != 0 {
+		return []*VLAN{&e.VLANID, &e.InnerVLANID}
+	}
+	if e.VLANID.VID != 0 {
+		return []*VLAN{&e.VLANID}
+	}
+	return nil
+}
+
+// PushVLAN pushes a VLAN tag of the TPID, VLAN_MSG or QINQ_MSG, as the outer tag of the frame. The current tag
+// becomes the inner tag, and it fails if the frame already has two tags.
+func (e *Ethernet) PushVLAN(tpid uint16, vid uint16, pcp uint8) error {
+	if vid&VID_MASK == 0 {
+		return errors.New("the VLAN ID to push is 0")
+	}
+	if e.InnerVLANID.VID != 0 {
+		return errors.New("the Ethernet frame already has two VLAN tags")
+	}
+	if e.VLANID.VID != 0 {
+		e.InnerVLANID = e.VLANID
+	}
+	e.VLANID = VLAN{TPID: tpid, PCP: pcp & 0x7, VID: vid & VID_MASK}
+	return nil
+}
+
+// PopVLAN pops the outer VLAN tag of the frame and returns it, the inner tag becomes the outer tag.
+func (e *Ethernet) PopVLAN() (*VLAN, error) {
+	if len(e.vlanTags()) == 0 {
+		return nil, errors.New("the Ethernet frame has no VLAN tag")
+	}
+	v := e.VLANID
+	e.VLANID = e.InnerVLANID
+	e.InnerVLANID = *NewVLAN()
+	return &v, nil
+}
+
 const (
 	PCP_MASK = 0xe000
 	DEI_MASK = 0x1000
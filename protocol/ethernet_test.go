@@ -0,0 +1,63 @@
+package protocol
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestEthernetQinQ(t *testing.T) {
+	eth := NewEthernet()
+	eth.HWSrc, _ = net.ParseMAC("aa:bb:cc:dd:ee:01")
+	eth.HWDst, _ = net.ParseMAC("aa:bb:cc:dd:ee:02")
+	eth.Ethertype = ARP_MSG
+	arp, _ := NewARP(Type_Request)
+	eth.Data = arp
+	if err := eth.PushVLAN(VLAN_MSG, 100, 0); err != nil {
+		t.Fatalf("Failed to push the VLAN tag: %v", err)
+	}
+	if err := eth.PushVLAN(QINQ_MSG, 200, 5); err != nil {
+		t.Fatalf("Failed to push the service VLAN tag: %v", err)
+	}
+	if err := eth.PushVLAN(QINQ_MSG, 300, 0); err == nil {
+		t.Errorf("Expected an error when pushing the third VLAN tag")
+	}
+	data, err := eth.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal Ethernet: %v", err)
+	}
+	if len(data) != 22+int(arp.Len()) || !bytes.Equal(data[12:20], []byte{0x88, 0xa8, 0xa0, 200, 0x81, 0x00, 0, 100}) {
+		t.Fatalf("Unexpected VLAN tags %x", data[12:22])
+	}
+
+	eth2 := new(Ethernet)
+	if err := eth2.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal Ethernet: %v", err)
+	}
+	if eth2.VLANID.TPID != QINQ_MSG || eth2.VLANID.VID != 200 || eth2.VLANID.PCP != 5 {
+		t.Errorf("Unexpected outer VLAN tag %+v", eth2.VLANID)
+	}
+	if eth2.InnerVLANID.TPID != VLAN_MSG || eth2.InnerVLANID.VID != 100 {
+		t.Errorf("Unexpected inner VLAN tag %+v", eth2.InnerVLANID)
+	}
+	if _, ok := eth2.Data.(*ARP); eth2.Ethertype != ARP_MSG || !ok {
+		t.Errorf("Unexpected payload of Ethertype 0x%x: %T", eth2.Ethertype, eth2.Data)
+	}
+
+	v, err := eth2.PopVLAN()
+	if err != nil || v.VID != 200 {
+		t.Fatalf("Unexpected popped VLAN tag %+v: %v", v, err)
+	}
+	if eth2.VLANID.VID != 100 || eth2.InnerVLANID.VID != 0 || eth2.Len() != 18+arp.Len() {
+		t.Errorf("Unexpected VLAN tags after pop %+v %+v", eth2.VLANID, eth2.InnerVLANID)
+	}
+	if _, err := eth2.PopVLAN(); err != nil {
+		t.Fatalf("Failed to pop the VLAN tag: %v", err)
+	}
+	if _, err := eth2.PopVLAN(); err == nil {
+		t.Errorf("Expected an error when popping from the untagged frame")
+	}
+	if err := eth2.UnmarshalBinary(data[:16]); err == nil {
+		t.Errorf("Expected an error for the truncated VLAN tag")
+	}
+}
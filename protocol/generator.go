@@ -0,0 +1,250 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"net"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+// TCP flags
+const (
+	TCP_FLAG_FIN = 1 << 0
+	TCP_FLAG_SYN = 1 << 1
+	TCP_FLAG_RST = 1 << 2
+	TCP_FLAG_PSH = 1 << 3
+	TCP_FLAG_ACK = 1 << 4
+	TCP_FLAG_URG = 1 << 5
+)
+
+// tcpFlagCombinations are the TCP flags of the generated TCP segments, a valid connection never sends the other
+// combinations.
+var tcpFlagCombinations = []uint8{
+	TCP_FLAG_SYN,
+	TCP_FLAG_SYN | TCP_FLAG_ACK,
+	TCP_FLAG_ACK,
+	TCP_FLAG_PSH | TCP_FLAG_ACK,
+	TCP_FLAG_FIN | TCP_FLAG_ACK,
+	TCP_FLAG_RST,
+}
+
+// defaultMaxPayloadLen is the max length of the random L4 payload if GeneratorOptions.MaxPayloadLen is not set.
+const defaultMaxPayloadLen = 256
+
+// GeneratorOptions fixes the header fields of the packets generated by Generator. The zero value of a field
+// means that the field is randomized for each packet.
+type GeneratorOptions struct {
+	HWSrc net.HardwareAddr
+	HWDst net.HardwareAddr
+	// VLANID adds an 802.1Q header with the VLAN ID if it is not 0, no VLAN header is added otherwise.
+	VLANID uint16
+	// Ethertype is IPv4_MSG or IPv6_MSG.
+	Ethertype uint16
+	// Protocol is Type_TCP or Type_UDP.
+	Protocol uint8
+	NWSrc    net.IP
+	NWDst    net.IP
+	// TTL is the TTL of IPv4 or the hop limit of IPv6.
+	TTL     uint8
+	PortSrc uint16
+	PortDst uint16
+	// TCPFlags is a combination of TCP_FLAG_*, a random valid combination is used if it is 0.
+	TCPFlags uint8
+	// PayloadLen is the length of the L4 payload, a random length up to MaxPayloadLen is used if it is 0.
+	PayloadLen    int
+	MaxPayloadLen int
+}
+
+// Generator generates randomized but valid Ethernet frames carrying TCP or UDP over IPv4 or IPv6, e.g., to
+// load test the PacketOut path. The length fields and the checksums of the frames are filled in. A Generator
+// is deterministic for a given seed, and it is not safe for concurrent use.
+type Generator struct {
+	rand *rand.Rand
+	opts GeneratorOptions
+}
+
+// NewGenerator returns a Generator with the seed and the options.
+func NewGenerator(seed int64, opts GeneratorOptions) *Generator {
+	if opts.MaxPayloadLen <= 0 {
+		opts.MaxPayloadLen = defaultMaxPayloadLen
+	}
+	return &Generator{rand: rand.New(rand.NewSource(seed)), opts: opts}
+}
+
+// Next returns a new packet.
+func (g *Generator) Next() *Ethernet {
+	eth := NewEthernet()
+	eth.HWSrc = g.hardwareAddr(g.opts.HWSrc)
+	eth.HWDst = g.hardwareAddr(g.opts.HWDst)
+	if g.opts.VLANID != 0 {
+		eth.VLANID.VID = g.opts.VLANID & VID_MASK
+	}
+	eth.Ethertype = g.opts.Ethertype
+	if eth.Ethertype == 0 {
+		eth.Ethertype = []uint16{IPv4_MSG, IPv6_MSG}[g.rand.Intn(2)]
+	}
+	protocol := g.opts.Protocol
+	if protocol == 0 {
+		protocol = []uint8{Type_TCP, Type_UDP}[g.rand.Intn(2)]
+	}
+	ttl := g.opts.TTL
+	if ttl == 0 {
+		ttl = uint8(1 + g.rand.Intn(255))
+	}
+
+	var nwSrc, nwDst net.IP
+	if eth.Ethertype == IPv6_MSG {
+		nwSrc, nwDst = g.ipv6Addr(g.opts.NWSrc), g.ipv6Addr(g.opts.NWDst)
+	} else {
+		nwSrc, nwDst = g.ipv4Addr(g.opts.NWSrc), g.ipv4Addr(g.opts.NWDst)
+	}
+	l4 := g.l4Message(protocol, nwSrc, nwDst)
+
+	if eth.Ethertype == IPv6_MSG {
+		ip := new(IPv6)
+		ip.Version = 6
+		ip.TrafficClass = uint8(g.rand.Intn(256)) &^ 0x3
+		ip.FlowLabel = uint32(g.rand.Intn(1 << 20))
+		ip.Length = l4.Len()
+		ip.NextHeader = protocol
+		ip.HopLimit = ttl
+		ip.NWSrc = nwSrc
+		ip.NWDst = nwDst
+		ip.Data = l4
+		eth.Data = ip
+		return eth
+	}
+	ip := NewIPv4()
+	ip.Version = 4
+	ip.IHL = 5
+	ip.Length = 20 + l4.Len()
+	ip.Id = uint16(g.rand.Intn(1 << 16))
+	// Don't fragment
+	ip.Flags = 0x2
+	ip.TTL = ttl
+	ip.Protocol = protocol
+	ip.NWSrc = nwSrc
+	ip.NWDst = nwDst
+	ip.Data = l4
+	header, _ := ip.MarshalBinary()
+	ip.Checksum = checksum(header[:20], 0)
+	eth.Data = ip
+	return eth
+}
+
+// NextBytes returns the bytes of a new packet.
+func (g *Generator) NextBytes() ([]byte, error) {
+	return g.Next().MarshalBinary()
+}
+
+func (g *Generator) hardwareAddr(fixed net.HardwareAddr) net.HardwareAddr {
+	if fixed != nil {
+		return fixed
+	}
+	addr := make(net.HardwareAddr, 6)
+	g.rand.Read(addr)
+	// A locally administered unicast address.
+	addr[0] = addr[0]&^0x1 | 0x2
+	return addr
+}
+
+func (g *Generator) ipv4Addr(fixed net.IP) net.IP {
+	if fixed != nil {
+		return fixed.To4()
+	}
+	addr := make(net.IP, 4)
+	g.rand.Read(addr)
+	// A unicast address out of 0.0.0.0/8 and 127.0.0.0/8.
+	addr[0] = uint8(1 + g.rand.Intn(223))
+	if addr[0] == 127 {
+		addr[0] = 10
+	}
+	return addr
+}
+
+func (g *Generator) ipv6Addr(fixed net.IP) net.IP {
+	if fixed != nil {
+		return fixed.To16()
+	}
+	addr := make(net.IP, 16)
+	g.rand.Read(addr)
+	// A unique local address in fd00::/8.
+	addr[0] = 0xfd
+	return addr
+}
+
+// l4Message returns the TCP or UDP segment with the checksum.
+func (g *Generator) l4Message(protocol uint8, nwSrc, nwDst net.IP) util.Message {
+	payloadLen := g.opts.PayloadLen
+	if payloadLen <= 0 {
+		payloadLen = g.rand.Intn(g.opts.MaxPayloadLen + 1)
+	}
+	payload := make([]byte, payloadLen)
+	g.rand.Read(payload)
+	portSrc, portDst := g.opts.PortSrc, g.opts.PortDst
+	if portSrc == 0 {
+		portSrc = uint16(1 + g.rand.Intn(0xffff))
+	}
+	if portDst == 0 {
+		portDst = uint16(1 + g.rand.Intn(0xffff))
+	}
+
+	if protocol == Type_TCP {
+		tcp := NewTCP()
+		tcp.PortSrc = portSrc
+		tcp.PortDst = portDst
+		tcp.SeqNum = g.rand.Uint32()
+		tcp.HdrLen = 5
+		tcp.Code = g.opts.TCPFlags
+		if tcp.Code == 0 {
+			tcp.Code = tcpFlagCombinations[g.rand.Intn(len(tcpFlagCombinations))]
+		}
+		if tcp.Code&TCP_FLAG_ACK != 0 {
+			tcp.AckNum = g.rand.Uint32()
+		}
+		tcp.WinSize = uint16(1 + g.rand.Intn(0xffff))
+		tcp.Data = payload
+		data, _ := tcp.MarshalBinary()
+		tcp.Checksum = checksum(data, pseudoHeaderSum(protocol, nwSrc, nwDst, len(data)))
+		return tcp
+	}
+	udp := NewUDP()
+	udp.PortSrc = portSrc
+	udp.PortDst = portDst
+	udp.Length = uint16(8 + len(payload))
+	udp.Data = payload
+	data, _ := udp.MarshalBinary()
+	udp.Checksum = checksum(data, pseudoHeaderSum(protocol, nwSrc, nwDst, len(data)))
+	// A computed UDP checksum of 0 is transmitted as all ones.
+	if udp.Checksum == 0 {
+		udp.Checksum = 0xffff
+	}
+	return udp
+}
+
+// pseudoHeaderSum returns the sum of the pseudo header of IPv4 or IPv6 for the L4 checksum.
+func pseudoHeaderSum(protocol uint8, nwSrc, nwDst net.IP, length int) uint32 {
+	var sum uint32
+	for _, addr := range []net.IP{nwSrc, nwDst} {
+		for i := 0; i+1 < len(addr); i += 2 {
+			sum += uint32(binary.BigEndian.Uint16(addr[i:]))
+		}
+	}
+	return sum + uint32(protocol) + uint32(length)
+}
+
+// checksum returns the internet checksum of data, with the initial sum, e.g., of the pseudo header.
+func checksum(data []byte, initial uint32) uint16 {
+	sum := initial
+	for i := 0; i+1 < len(data); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(data[i:]))
+	}
+	if len(data)%2 == 1 {
+		sum += uint32(data[len(data)-1]) << 8
+	}
+	for sum > 0xffff {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
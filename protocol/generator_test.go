@@ -0,0 +1,84 @@
+package protocol
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestGenerator(t *testing.T) {
+	gen1 := NewGenerator(1, GeneratorOptions{})
+	gen2 := NewGenerator(1, GeneratorOptions{})
+	for i := 0; i < 100; i++ {
+		data1, err := gen1.NextBytes()
+		if err != nil {
+			t.Fatalf("Failed to generate packet: %v", err)
+		}
+		data2, _ := gen2.NextBytes()
+		if !bytes.Equal(data1, data2) {
+			t.Fatalf("Packets generated with the same seed are different")
+		}
+
+		eth := new(Ethernet)
+		if err = eth.UnmarshalBinary(data1); err != nil {
+			t.Fatalf("Failed to parse generated packet: %v", err)
+		}
+		l3 := data1[14:]
+		switch eth.Ethertype {
+		case IPv4_MSG:
+			ip := eth.Data.(*IPv4)
+			if checksum(l3[:20], 0) != 0 {
+				t.Errorf("Invalid IPv4 checksum")
+			}
+			if int(ip.Length) != len(l3) {
+				t.Errorf("Invalid IPv4 length %d, expect %d", ip.Length, len(l3))
+			}
+			if checksum(l3[20:], pseudoHeaderSum(ip.Protocol, ip.NWSrc, ip.NWDst, len(l3)-20)) != 0 {
+				t.Errorf("Invalid L4 checksum of IPv4 packet")
+			}
+		case IPv6_MSG:
+			ip := eth.Data.(*IPv6)
+			if int(ip.Length) != len(l3)-40 {
+				t.Errorf("Invalid IPv6 payload length %d, expect %d", ip.Length, len(l3)-40)
+			}
+			if checksum(l3[40:], pseudoHeaderSum(ip.NextHeader, ip.NWSrc, ip.NWDst, len(l3)-40)) != 0 {
+				t.Errorf("Invalid L4 checksum of IPv6 packet")
+			}
+		default:
+			t.Fatalf("Unexpected ethertype 0x%x", eth.Ethertype)
+		}
+	}
+}
+
+func TestGeneratorFixedFields(t *testing.T) {
+	hwDst, _ := net.ParseMAC("00:00:00:00:00:02")
+	opts := GeneratorOptions{
+		HWDst:      hwDst,
+		VLANID:     100,
+		Ethertype:  IPv4_MSG,
+		Protocol:   Type_UDP,
+		NWDst:      net.ParseIP("10.0.0.2"),
+		TTL:        64,
+		PortDst:    53,
+		PayloadLen: 32,
+	}
+	gen := NewGenerator(2, opts)
+	for i := 0; i < 10; i++ {
+		data, _ := gen.NextBytes()
+		eth := new(Ethernet)
+		if err := eth.UnmarshalBinary(data); err != nil {
+			t.Fatalf("Failed to parse generated packet: %v", err)
+		}
+		if eth.HWDst.String() != hwDst.String() || eth.VLANID.VID != 100 {
+			t.Errorf("Unexpected Ethernet header: %+v", eth)
+		}
+		ip := eth.Data.(*IPv4)
+		if !ip.NWDst.Equal(opts.NWDst) || ip.TTL != 64 || ip.Protocol != Type_UDP {
+			t.Errorf("Unexpected IPv4 header: %+v", ip)
+		}
+		udp := ip.Data.(*UDP)
+		if udp.PortDst != 53 || len(udp.Data) != 32 || udp.Length != 40 {
+			t.Errorf("Unexpected UDP header: %+v", udp)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+// ICMPv6 types of the Neighbor Discovery messages, RFC 4861.
+const (
+	ICMPv6Type_RouterSolicitation    = 133
+	ICMPv6Type_RouterAdvertisement   = 134
+	ICMPv6Type_NeighborSolicitation  = 135
+	ICMPv6Type_NeighborAdvertisement = 136
+	ICMPv6Type_Redirect              = 137
+)
+
+// Neighbor Discovery option types.
+const (
+	NDOption_SourceLinkLayerAddr = 1
+	NDOption_TargetLinkLayerAddr = 2
+	NDOption_PrefixInformation   = 3
+	NDOption_RedirectedHeader    = 4
+	NDOption_MTU                 = 5
+)
+
+// NDOption is a Neighbor Discovery option, Length is in units of 8 bytes including the type and the length.
+type NDOption struct {
+	Type   uint8
+	Length uint8
+	Data   []byte
+}
+
+// NewNDOptionLinkLayerAddr returns the source or the target link-layer address option of the MAC address, the
+// type is NDOption_SourceLinkLayerAddr or NDOption_TargetLinkLayerAddr.
+func NewNDOptionLinkLayerAddr(optionType uint8, addr net.HardwareAddr) *NDOption {
+	o := &NDOption{Type: optionType}
+	o.Length = uint8((2 + len(addr) + 7) / 8)
+	o.Data = make([]byte, int(o.Length)*8-2)
+	copy(o.Data, addr)
+	return o
+}
+
+// LinkLayerAddr returns the Ethernet address in the source or the target link-layer address option.
+func (o *NDOption) LinkLayerAddr() net.HardwareAddr {
+	if len(o.Data) < 6 {
+		return nil
+	}
+	return net.HardwareAddr(o.Data[:6])
+}
+
+func (o *NDOption) Len() uint16 {
+	return 8 * uint16(o.Length)
+}
+
+func (o *NDOption) MarshalBinary() (data []byte, err error) {
+	if o.Length == 0 {
+		return nil, errors.New("the length of the ND option is 0")
+	}
+	data = make([]byte, int(o.Len()))
+	data[0] = o.Type
+	data[1] = o.Length
+	copy(data[2:], o.Data)
+	return data, nil
+}
+
+func (o *NDOption) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return errors.New("The []byte is too short to unmarshal a full NDOption message.")
+	}
+	o.Type = data[0]
+	o.Length = data[1]
+	if o.Length == 0 {
+		return errors.New("the length of the ND option is 0")
+	}
+	if len(data) < int(o.Len()) {
+		return errors.New("The []byte is too short to unmarshal a full NDOption message.")
+	}
+	o.Data = make([]byte, int(o.Len())-2)
+	copy(o.Data, data[2:o.Len()])
+	return nil
+}
+
+// ndOptions is the options at the end of the Neighbor Discovery messages.
+type ndOptions []*NDOption
+
+func (opts ndOptions) len() uint16 {
+	var n uint16
+	for _, o := range opts {
+		n += o.Len()
+	}
+	return n
+}
+
+func (opts ndOptions) marshal(data []byte) error {
+	n := 0
+	for _, o := range opts {
+		b, err := o.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		copy(data[n:], b)
+		n += len(b)
+	}
+	return nil
+}
+
+func unmarshalNDOptions(data []byte) ([]*NDOption, error) {
+	var opts []*NDOption
+	for n := 0; n < len(data); {
+		o := new(NDOption)
+		if err := o.UnmarshalBinary(data[n:]); err != nil {
+			return nil, err
+		}
+		opts = append(opts, o)
+		n += int(o.Len())
+	}
+	return opts, nil
+}
+
+// findNDOption returns the first option of the type, or nil if there is none.
+func findNDOption(opts []*NDOption, optionType uint8) *NDOption {
+	for _, o := range opts {
+		if o.Type == optionType {
+			return o
+		}
+	}
+	return nil
+}
+
+// NeighborSolicitation is the body of the ICMPv6 Neighbor Solicitation message after the ICMP header.
+type NeighborSolicitation struct {
+	Reserved      uint32
+	TargetAddress net.IP
+	Options       []*NDOption
+}
+
+// NewNeighborSolicitation returns the Neighbor Solicitation of the target with the source link-layer address
+// option of srcMAC, the option is omitted if srcMAC is nil, e.g., in the duplicate address detection.
+func NewNeighborSolicitation(target net.IP, srcMAC net.HardwareAddr) *NeighborSolicitation {
+	ns := &NeighborSolicitation{TargetAddress: target}
+	if srcMAC != nil {
+		ns.Options = append(ns.Options, NewNDOptionLinkLayerAddr(NDOption_SourceLinkLayerAddr, srcMAC))
+	}
+	return ns
+}
+
+// SourceLinkLayerAddr returns the address in the source link-layer address option, or nil if there is none.
+func (ns *NeighborSolicitation) SourceLinkLayerAddr() net.HardwareAddr {
+	if o := findNDOption(ns.Options, NDOption_SourceLinkLayerAddr); o != nil {
+		return o.LinkLayerAddr()
+	}
+	return nil
+}
+
+func (ns *NeighborSolicitation) Len() uint16 {
+	return 20 + ndOptions(ns.Options).len()
+}
+
+func (ns *NeighborSolicitation) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(ns.Len()))
+	binary.BigEndian.PutUint32(data, ns.Reserved)
+	copy(data[4:20], ns.TargetAddress.To16())
+	return data, ndOptions(ns.Options).marshal(data[20:])
+}
+
+func (ns *NeighborSolicitation) UnmarshalBinary(data []byte) error {
+	if len(data) < 20 {
+		return errors.New("The []byte is too short to unmarshal a full NeighborSolicitation message.")
+	}
+	ns.Reserved = binary.BigEndian.Uint32(data)
+	ns.TargetAddress = net.IP(append([]byte(nil), data[4:20]...))
+	var err error
+	ns.Options, err = unmarshalNDOptions(data[20:])
+	return err
+}
+
+// NeighborAdvertisement is the body of the ICMPv6 Neighbor Advertisement message after the ICMP header.
+type NeighborAdvertisement struct {
+	Router        bool
+	Solicited     bool
+	Override      bool
+	TargetAddress net.IP
+	Options       []*NDOption
+}
+
+// NewNeighborAdvertisement returns the solicited Neighbor Advertisement of the target with the target link-layer
+// address option of targetMAC, e.g., the reply of an ND proxy.
+func NewNeighborAdvertisement(target net.IP, targetMAC net.HardwareAddr) *NeighborAdvertisement {
+	na := &NeighborAdvertisement{Solicited: true, Override: true, TargetAddress: target}
+	if targetMAC != nil {
+		na.Options = append(na.Options, NewNDOptionLinkLayerAddr(NDOption_TargetLinkLayerAddr, targetMAC))
+	}
+	return na
+}
+
+// TargetLinkLayerAddr returns the address in the target link-layer address option, or nil if there is none.
+func (na *NeighborAdvertisement) TargetLinkLayerAddr() net.HardwareAddr {
+	if o := findNDOption(na.Options, NDOption_TargetLinkLayerAddr); o != nil {
+		return o.LinkLayerAddr()
+	}
+	return nil
+}
+
+func (na *NeighborAdvertisement) Len() uint16 {
+	return 20 + ndOptions(na.Options).len()
+}
+
+func (na *NeighborAdvertisement) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(na.Len()))
+	if na.Router {
+		data[0] |= 0x80
+	}
+	if na.Solicited {
+		data[0] |= 0x40
+	}
+	if na.Override {
+		data[0] |= 0x20
+	}
+	copy(data[4:20], na.TargetAddress.To16())
+	return data, ndOptions(na.Options).marshal(data[20:])
+}
+
+func (na *NeighborAdvertisement) UnmarshalBinary(data []byte) error {
+	if len(data) < 20 {
+		return errors.New("The []byte is too short to unmarshal a full NeighborAdvertisement message.")
+	}
+	na.Router = data[0]&0x80 != 0
+	na.Solicited = data[0]&0x40 != 0
+	na.Override = data[0]&0x20 != 0
+	na.TargetAddress = net.IP(append([]byte(nil), data[4:20]...))
+	var err error
+	na.Options, err = unmarshalNDOptions(data[20:])
+	return err
+}
+
+// RouterSolicitation is the body of the ICMPv6 Router Solicitation message after the ICMP header.
+type RouterSolicitation struct {
+	Reserved uint32
+	Options  []*NDOption
+}
+
+func (rs *RouterSolicitation) Len() uint16 {
+	return 4 + ndOptions(rs.Options).len()
+}
+
+func (rs *RouterSolicitation) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(rs.Len()))
+	binary.BigEndian.PutUint32(data, rs.Reserved)
+	return data, ndOptions(rs.Options).marshal(data[4:])
+}
+
+func (rs *RouterSolicitation) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full RouterSolicitation message.")
+	}
+	rs.Reserved = binary.BigEndian.Uint32(data)
+	var err error
+	rs.Options, err = unmarshalNDOptions(data[4:])
+	return err
+}
+
+// RouterAdvertisement is the body of the ICMPv6 Router Advertisement message after the ICMP header, the
+// lifetime is in seconds and the times are in milliseconds.
+type RouterAdvertisement struct {
+	CurHopLimit    uint8
+	Managed        bool
+	Other          bool
+	RouterLifetime uint16
+	ReachableTime  uint32
+	RetransTimer   uint32
+	Options        []*NDOption
+}
+
+func (ra *RouterAdvertisement) Len() uint16 {
+	return 12 + ndOptions(ra.Options).len()
+}
+
+func (ra *RouterAdvertisement) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(ra.Len()))
+	data[0] = ra.CurHopLimit
+	if ra.Managed {
+		data[1] |= 0x80
+	}
+	if ra.Other {
+		data[1] |= 0x40
+	}
+	binary.BigEndian.PutUint16(data[2:], ra.RouterLifetime)
+	binary.BigEndian.PutUint32(data[4:], ra.ReachableTime)
+	binary.BigEndian.PutUint32(data[8:], ra.RetransTimer)
+	return data, ndOptions(ra.Options).marshal(data[12:])
+}
+
+func (ra *RouterAdvertisement) UnmarshalBinary(data []byte) error {
+	if len(data) < 12 {
+		return errors.New("The []byte is too short to unmarshal a full RouterAdvertisement message.")
+	}
+	ra.CurHopLimit = data[0]
+	ra.Managed = data[1]&0x80 != 0
+	ra.Other = data[1]&0x40 != 0
+	ra.RouterLifetime = binary.BigEndian.Uint16(data[2:])
+	ra.ReachableTime = binary.BigEndian.Uint32(data[4:])
+	ra.RetransTimer = binary.BigEndian.Uint32(data[8:])
+	var err error
+	ra.Options, err = unmarshalNDOptions(data[12:])
+	return err
+}
+
+// DecodeNDMessage decodes the body of the ICMPv6 Neighbor Discovery message, i.e., *NeighborSolicitation,
+// *NeighborAdvertisement, *RouterSolicitation or *RouterAdvertisement according to the type of the ICMP message.
+func DecodeNDMessage(icmp *ICMP) (util.Message, error) {
+	var msg util.Message
+	switch icmp.Type {
+	case ICMPv6Type_RouterSolicitation:
+		msg = new(RouterSolicitation)
+	case ICMPv6Type_RouterAdvertisement:
+		msg = new(RouterAdvertisement)
+	case ICMPv6Type_NeighborSolicitation:
+		msg = new(NeighborSolicitation)
+	case ICMPv6Type_NeighborAdvertisement:
+		msg = new(NeighborAdvertisement)
+	default:
+		return nil, fmt.Errorf("ICMPv6 type %d is not a Neighbor Discovery message", icmp.Type)
+	}
+	if err := msg.UnmarshalBinary(icmp.Data); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// NewICMPv6 returns the ICMPv6 message of the type with the body, e.g., a NeighborAdvertisement, and its checksum
+// with the pseudo-header of the source and the destination IPv6 addresses.
+func NewICMPv6(icmpType uint8, body util.Message, src, dst net.IP) (*ICMP, error) {
+	data, err := body.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	icmp := NewICMP()
+	icmp.Type = icmpType
+	icmp.Data = data
+	icmp.Checksum = ICMPv6Checksum(icmp, src, dst)
+	return icmp, nil
+}
+
+// ICMPv6Checksum returns the checksum of the ICMPv6 message with the pseudo-header of the source and the
+// destination IPv6 addresses, the checksum in the message is taken as 0.
+func ICMPv6Checksum(icmp *ICMP, src, dst net.IP) uint16 {
+	data, _ := icmp.MarshalBinary()
+	data[2], data[3] = 0, 0
+	return checksum(data, pseudoHeaderSum(Type_IPv6ICMP, src.To16(), dst.To16(), len(data)))
+}
@@ -0,0 +1,71 @@
+package protocol
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestNeighborDiscovery(t *testing.T) {
+	target := net.ParseIP("2001:db8::2")
+	mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	src := net.ParseIP("fe80::1")
+	dst := net.ParseIP("ff02::1:ff00:2")
+
+	icmp, err := NewICMPv6(ICMPv6Type_NeighborSolicitation, NewNeighborSolicitation(target, mac), src, dst)
+	if err != nil {
+		t.Fatalf("Failed to build Neighbor Solicitation: %v", err)
+	}
+	if icmp.Len() != 4+20+8 {
+		t.Errorf("Unexpected length %d", icmp.Len())
+	}
+	// The checksum of the message with its checksum is 0.
+	data, _ := icmp.MarshalBinary()
+	if sum := checksum(data, pseudoHeaderSum(Type_IPv6ICMP, src, dst, len(data))); sum != 0 {
+		t.Errorf("Invalid checksum 0x%x", icmp.Checksum)
+	}
+
+	msg, err := DecodeNDMessage(icmp)
+	if err != nil {
+		t.Fatalf("Failed to decode Neighbor Solicitation: %v", err)
+	}
+	ns, ok := msg.(*NeighborSolicitation)
+	if !ok || !ns.TargetAddress.Equal(target) || !bytes.Equal(ns.SourceLinkLayerAddr(), mac) {
+		t.Fatalf("Unexpected Neighbor Solicitation %+v", msg)
+	}
+
+	icmp, err = NewICMPv6(ICMPv6Type_NeighborAdvertisement, NewNeighborAdvertisement(target, mac), target, src)
+	if err != nil {
+		t.Fatalf("Failed to build Neighbor Advertisement: %v", err)
+	}
+	msg, err = DecodeNDMessage(icmp)
+	if err != nil {
+		t.Fatalf("Failed to decode Neighbor Advertisement: %v", err)
+	}
+	na, ok := msg.(*NeighborAdvertisement)
+	if !ok || na.Router || !na.Solicited || !na.Override || !na.TargetAddress.Equal(target) ||
+		!bytes.Equal(na.TargetLinkLayerAddr(), mac) {
+		t.Fatalf("Unexpected Neighbor Advertisement %+v", msg)
+	}
+
+	ra := &RouterAdvertisement{CurHopLimit: 64, Managed: true, RouterLifetime: 1800,
+		Options: []*NDOption{NewNDOptionLinkLayerAddr(NDOption_SourceLinkLayerAddr, mac)}}
+	icmp, _ = NewICMPv6(ICMPv6Type_RouterAdvertisement, ra, src, dst)
+	msg, err = DecodeNDMessage(icmp)
+	if err != nil {
+		t.Fatalf("Failed to decode Router Advertisement: %v", err)
+	}
+	if decoded := msg.(*RouterAdvertisement); decoded.CurHopLimit != 64 || !decoded.Managed || decoded.Other ||
+		decoded.RouterLifetime != 1800 || len(decoded.Options) != 1 {
+		t.Errorf("Unexpected Router Advertisement %+v", decoded)
+	}
+
+	icmp.Data = append(icmp.Data, 1, 0)
+	if _, err := DecodeNDMessage(icmp); err == nil {
+		t.Errorf("Expect error to decode the option of length 0")
+	}
+	icmp.Type = 128
+	if _, err := DecodeNDMessage(icmp); err == nil {
+		t.Errorf("Expect error to decode the echo request")
+	}
+}
@@ -12,6 +12,7 @@ const (
 	Type_ICMP     = 0x01
 	Type_TCP      = 0x06
 	Type_UDP      = 0x11
+	Type_SCTP     = 0x84
 	Type_IPv6     = 0x29
 	Type_IPv6ICMP = 0x3a
 )
@@ -106,6 +107,9 @@ func (i *IPv4) UnmarshalBinary(data []byte) error {
 	i.Version = ihl >> 4
 	i.IHL = ihl & 0x0f
 	n += 1
+	if i.IHL < 5 || int(i.IHL*4) > len(data) {
+		return errors.New("The []byte is too short to unmarshal the IPv4 options.")
+	}
 
 	var ecn uint8
 	ecn = data[n]
@@ -146,6 +150,8 @@ func (i *IPv4) UnmarshalBinary(data []byte) error {
 		i.Data = NewICMP()
 	case Type_UDP:
 		i.Data = NewUDP()
+	case Type_SCTP:
+		i.Data = NewSCTP()
 	default:
 		i.Data = new(util.Buffer)
 	}
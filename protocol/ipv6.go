@@ -3,6 +3,7 @@ package protocol
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"net"
 
 	"github.com/contiv/libOpenflow/util"
@@ -12,6 +13,14 @@ const (
 	Type_HBH      = 0x0
 	Type_Routing  = 0x2b
 	Type_Fragment = 0x2c
+	Type_NoNext   = 0x3b
+	Type_DestOpts = 0x3c
+)
+
+// The IPv6 options of the hop-by-hop and the destination options headers.
+const (
+	Option_Pad1 = 0x0 /* A single octet of padding, without the length and the data. */
+	Option_PadN = 0x1
 )
 
 type IPv6 struct {
@@ -26,7 +35,10 @@ type IPv6 struct {
 	HbhHeader      *HopByHopHeader
 	RoutingHeader  *RoutingHeader
 	FragmentHeader *FragmentHeader
-	Data           util.Message
+	// DestOptsHeaders is the destination options headers in the order of the chain, there are two of them if the
+	// packet has the one before the routing header and the one before the upper-layer header.
+	DestOptsHeaders []*DestinationOptionsHeader
+	Data            util.Message
 }
 
 func (i *IPv6) Len() (n uint16) {
@@ -40,10 +52,88 @@ func (i *IPv6) Len() (n uint16) {
 	if i.FragmentHeader != nil {
 		length += i.FragmentHeader.Len()
 	}
-	length += i.Data.Len()
+	for _, h := range i.DestOptsHeaders {
+		length += h.Len()
+	}
+	if i.Data != nil {
+		length += i.Data.Len()
+	}
 	return length
 }
 
+// extensionHeaderCount returns the number of the extension headers in the packet.
+func (i *IPv6) extensionHeaderCount() int {
+	n := len(i.DestOptsHeaders)
+	if i.HbhHeader != nil {
+		n++
+	}
+	if i.RoutingHeader != nil {
+		n++
+	}
+	if i.FragmentHeader != nil {
+		n++
+	}
+	return n
+}
+
+// extensionHeaders returns the extension headers in the chain from NextHeader, and the next header after them. The
+// destination options headers are taken from DestOptsHeaders in order, and it fails if any other extension header
+// is in the chain more than once.
+func (i *IPv6) extensionHeaders() ([]util.Message, uint8, error) {
+	var headers []util.Message
+	nxtHeader := i.NextHeader
+	seen := make(map[uint8]bool)
+	destOpts := 0
+	for {
+		var header util.Message
+		var next uint8
+		switch nxtHeader {
+		case Type_HBH:
+			if i.HbhHeader != nil {
+				header, next = i.HbhHeader, i.HbhHeader.NextHeader
+			}
+		case Type_Routing:
+			if i.RoutingHeader != nil {
+				header, next = i.RoutingHeader, i.RoutingHeader.NextHeader
+			}
+		case Type_Fragment:
+			if i.FragmentHeader != nil {
+				header, next = i.FragmentHeader, i.FragmentHeader.NextHeader
+			}
+		case Type_DestOpts:
+			if destOpts < len(i.DestOptsHeaders) {
+				header, next = i.DestOptsHeaders[destOpts], i.DestOptsHeaders[destOpts].NextHeader
+				destOpts++
+			}
+		}
+		if header == nil {
+			return headers, nxtHeader, nil
+		}
+		if nxtHeader != Type_DestOpts && seen[nxtHeader] {
+			return headers, nxtHeader, fmt.Errorf("the IPv6 extension header %d is in the chain more than once", nxtHeader)
+		}
+		seen[nxtHeader] = true
+		headers = append(headers, header)
+		nxtHeader = next
+	}
+}
+
+// Protocol returns the upper-layer protocol of the packet, i.e., the next header after the extension headers,
+// e.g., Type_UDP.
+func (i *IPv6) Protocol() uint8 {
+	_, nxtHeader, _ := i.extensionHeaders()
+	return nxtHeader
+}
+
+// IsFragment returns true if the packet is a fragment, and firstFragment is true if it is the first one, which is
+// the only fragment having the upper-layer header.
+func (i *IPv6) IsFragment() (fragment bool, firstFragment bool) {
+	if i.FragmentHeader == nil {
+		return false, false
+	}
+	return true, i.FragmentHeader.FragmentOffset == 0
+}
+
 func (i *IPv6) MarshalBinary() (data []byte, err error) {
 	data = make([]byte, int(i.Len()))
 	b := make([]byte, 0)
@@ -67,28 +157,15 @@ func (i *IPv6) MarshalBinary() (data []byte, err error) {
 	copy(data[n:], i.NWDst)
 	n += 16
 
-	checkExtHeader := true
-	nxtHeader := i.NextHeader
-	for checkExtHeader {
-		var err error
-		var hBytes []byte
-		switch nxtHeader {
-		case Type_HBH:
-			checkExtHeader = true
-			nxtHeader = i.HbhHeader.NextHeader
-			hBytes, err = i.HbhHeader.MarshalBinary()
-		case Type_Routing:
-			checkExtHeader = true
-			nxtHeader = i.RoutingHeader.NextHeader
-			hBytes, err = i.RoutingHeader.MarshalBinary()
-		case Type_Fragment:
-			checkExtHeader = true
-			nxtHeader = i.FragmentHeader.NextHeader
-			hBytes, err = i.FragmentHeader.MarshalBinary()
-		default:
-			checkExtHeader = false
-			break
-		}
+	headers, _, err := i.extensionHeaders()
+	if err != nil {
+		return nil, err
+	}
+	if len(headers) != i.extensionHeaderCount() {
+		return nil, errors.New("an IPv6 extension header is not in the chain from the next header")
+	}
+	for _, header := range headers {
+		hBytes, err := header.MarshalBinary()
 		if err != nil {
 			return nil, err
 		}
@@ -134,48 +211,58 @@ func (i *IPv6) UnmarshalBinary(data []byte) error {
 	i.NWDst = data[n : n+16]
 	n += 16
 
-	checkExtHeader := true
+	i.HbhHeader, i.RoutingHeader, i.FragmentHeader, i.DestOptsHeaders = nil, nil, nil, nil
 	nxtHeader := i.NextHeader
-checkXHeader:
-	for checkExtHeader {
+	for {
+		var header util.Message
+		var next *uint8
 		switch nxtHeader {
 		case Type_HBH:
-			checkExtHeader = true
-			i.HbhHeader = NewHopByHopHeader()
-			err := i.HbhHeader.UnmarshalBinary(data[n:])
-			if err != nil {
-				return err
+			if i.HbhHeader != nil {
+				return fmt.Errorf("the IPv6 extension header %d is in the chain more than once", nxtHeader)
 			}
-			nxtHeader = i.HbhHeader.NextHeader
-			n += int(i.HbhHeader.Len())
+			i.HbhHeader = NewHopByHopHeader()
+			header, next = i.HbhHeader, &i.HbhHeader.NextHeader
 		case Type_Routing:
-			checkExtHeader = true
-			i.RoutingHeader = NewRoutingHeader()
-			err := i.RoutingHeader.UnmarshalBinary(data[n:])
-			if err != nil {
-				return err
+			if i.RoutingHeader != nil {
+				return fmt.Errorf("the IPv6 extension header %d is in the chain more than once", nxtHeader)
 			}
-			nxtHeader = i.RoutingHeader.NextHeader
-			n += int(i.RoutingHeader.Len())
+			i.RoutingHeader = NewRoutingHeader()
+			header, next = i.RoutingHeader, &i.RoutingHeader.NextHeader
 		case Type_Fragment:
-			checkExtHeader = true
-			i.FragmentHeader = NewFragmentHeader()
-			err := i.FragmentHeader.UnmarshalBinary(data[n:])
-			if err != nil {
-				return err
+			if i.FragmentHeader != nil {
+				return fmt.Errorf("the IPv6 extension header %d is in the chain more than once", nxtHeader)
 			}
-			nxtHeader = i.FragmentHeader.NextHeader
-			n += int(i.FragmentHeader.Len())
-		case Type_IPv6ICMP:
-			i.Data = NewICMP()
-			break checkXHeader
-		case Type_UDP:
-			i.Data = NewUDP()
-			break checkXHeader
-		default:
-			i.Data = new(util.Buffer)
-			break checkXHeader
+			i.FragmentHeader = NewFragmentHeader()
+			header, next = i.FragmentHeader, &i.FragmentHeader.NextHeader
+		case Type_DestOpts:
+			h := NewDestinationOptionsHeader()
+			i.DestOptsHeaders = append(i.DestOptsHeaders, h)
+			header, next = h, &h.NextHeader
 		}
+		if header == nil {
+			break
+		}
+		if err := header.UnmarshalBinary(data[n:]); err != nil {
+			return err
+		}
+		nxtHeader = *next
+		n += int(header.Len())
+	}
+
+	// The upper-layer header is only in the first fragment.
+	if fragment, first := i.IsFragment(); fragment && !first {
+		nxtHeader = Type_NoNext
+	}
+	switch nxtHeader {
+	case Type_IPv6ICMP:
+		i.Data = NewICMP()
+	case Type_UDP:
+		i.Data = NewUDP()
+	case Type_SCTP:
+		i.Data = NewSCTP()
+	default:
+		i.Data = new(util.Buffer)
 	}
 	return i.Data.UnmarshalBinary(data[n:])
 }
@@ -187,7 +274,10 @@ type Option struct {
 }
 
 func (o *Option) Len() uint16 {
-	return uint16(o.Length + 2)
+	if o.Type == Option_Pad1 {
+		return 1
+	}
+	return uint16(o.Length) + 2
 }
 
 func (o *Option) MarshalBinary() (data []byte, err error) {
@@ -195,6 +285,9 @@ func (o *Option) MarshalBinary() (data []byte, err error) {
 	n := 0
 	data[n] = o.Type
 	n += 1
+	if o.Type == Option_Pad1 {
+		return data, nil
+	}
 	data[n] = o.Length
 	n += 1
 	copy(data[n:], o.Data)
@@ -202,9 +295,19 @@ func (o *Option) MarshalBinary() (data []byte, err error) {
 }
 
 func (o *Option) UnmarshalBinary(data []byte) error {
+	if len(data) < 1 {
+		return errors.New("The []byte is too short to unmarshal a full Option message.")
+	}
 	n := 0
 	o.Type = data[n]
 	n += 1
+	if o.Type == Option_Pad1 {
+		o.Length, o.Data = 0, nil
+		return nil
+	}
+	if len(data) < 2 {
+		return errors.New("The []byte is too short to unmarshal a full Option message.")
+	}
 	o.Length = data[n]
 	n += 1
 	if (len(data) - 2) < int(o.Length) {
@@ -222,7 +325,7 @@ type HopByHopHeader struct {
 }
 
 func (h *HopByHopHeader) Len() uint16 {
-	return 8 * uint16(h.HEL+1)
+	return 8 * (uint16(h.HEL) + 1)
 }
 
 func (h *HopByHopHeader) MarshalBinary() (data []byte, err error) {
@@ -244,17 +347,21 @@ func (h *HopByHopHeader) MarshalBinary() (data []byte, err error) {
 }
 
 func (h *HopByHopHeader) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return errors.New("The []byte is too short to unmarshal a full HopByHopHeader message.")
+	}
 	n := 0
 	h.NextHeader = data[n]
 	n += 1
 	h.HEL = data[n]
-	if len(data) < 8*int(h.HEL+1) {
+	if len(data) < int(h.Len()) {
 		return errors.New("The []byte is too short to unmarshal a full HopByHopHeader message.")
 	}
 	n += 1
+	h.Options = nil
 	for n < int(h.Len()) {
 		o := new(Option)
-		err := o.UnmarshalBinary(data[n:])
+		err := o.UnmarshalBinary(data[n:h.Len()])
 		if err != nil {
 			return err
 		}
@@ -268,6 +375,16 @@ func NewHopByHopHeader() *HopByHopHeader {
 	return new(HopByHopHeader)
 }
 
+// DestinationOptionsHeader is the destination options header, which has the same format as the hop-by-hop
+// options header.
+type DestinationOptionsHeader struct {
+	HopByHopHeader
+}
+
+func NewDestinationOptionsHeader() *DestinationOptionsHeader {
+	return new(DestinationOptionsHeader)
+}
+
 type RoutingHeader struct {
 	NextHeader   uint8
 	HEL          uint8
@@ -277,7 +394,7 @@ type RoutingHeader struct {
 }
 
 func (h *RoutingHeader) Len() uint16 {
-	return 8 * uint16(h.HEL+1)
+	return 8 * (uint16(h.HEL) + 1)
 }
 
 func (h *RoutingHeader) MarshalBinary() (data []byte, err error) {
@@ -291,16 +408,21 @@ func (h *RoutingHeader) MarshalBinary() (data []byte, err error) {
 	n += 1
 	data[n] = h.SegmentsLeft
 	n += 1
-	copy(data[n:], h.Data.Bytes())
+	if h.Data != nil {
+		copy(data[n:], h.Data.Bytes())
+	}
 	return data, nil
 }
 
 func (h *RoutingHeader) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return errors.New("The []byte is too short to unmarshal a full RoutingHeader message.")
+	}
 	n := 0
 	h.NextHeader = data[n]
 	n += 1
 	h.HEL = data[n]
-	if len(data) < 8*int(h.HEL+1) {
+	if len(data) < int(h.Len()) {
 		return errors.New("The []byte is too short to unmarshal a full RoutingHeader message.")
 	}
 	n += 1
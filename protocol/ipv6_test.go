@@ -311,3 +311,111 @@ func testIPv6Equals(oriMessage, newMessage *IPv6) error {
 	}
 	return nil
 }
+
+func TestIPv6ExtensionHeaders(t *testing.T) {
+	msg := &IPv6{
+		Version:    6,
+		NextHeader: Type_HBH,
+		HopLimit:   64,
+		NWSrc:      net.ParseIP("2001:db8::1"),
+		NWDst:      net.ParseIP("2001:db8::2"),
+		HbhHeader: &HopByHopHeader{
+			NextHeader: Type_DestOpts,
+			Options:    []*Option{{Type: Option_Pad1}, {Type: 5, Length: 2, Data: []byte{0, 0}}, {Type: Option_Pad1}},
+		},
+		DestOptsHeaders: []*DestinationOptionsHeader{{HopByHopHeader{
+			NextHeader: Type_Fragment,
+			Options:    []*Option{{Type: Option_PadN, Length: 4, Data: make([]byte, 4)}},
+		}}},
+		FragmentHeader: &FragmentHeader{NextHeader: Type_UDP, MoreFragments: true, Identification: 1},
+		Data:           &UDP{PortSrc: 1000, PortDst: 53, Length: 12, Data: []byte{1, 2, 3, 4}},
+	}
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to Marshal message: %v", err)
+	}
+	if len(data) != 40+8+8+8+12 {
+		t.Fatalf("Unexpected length %d", len(data))
+	}
+	newMessage := new(IPv6)
+	if err := newMessage.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to UnMarshal message: %v", err)
+	}
+	if newMessage.Protocol() != Type_UDP || len(newMessage.DestOptsHeaders) != 1 || len(newMessage.HbhHeader.Options) != 3 {
+		t.Errorf("Unexpected extension headers of IPv6 message %+v", newMessage)
+	}
+	if udp, ok := newMessage.Data.(*UDP); !ok || udp.PortDst != 53 {
+		t.Errorf("Expect the UDP payload after the extension headers, actual: %+v", newMessage.Data)
+	}
+	if err := testIPv6Equals(msg, newMessage); err != nil {
+		t.Error(err.Error())
+	}
+
+	// The fragment other than the first one has no upper-layer header.
+	msg.FragmentHeader.FragmentOffset = 10
+	data, _ = msg.MarshalBinary()
+	if err := newMessage.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to UnMarshal message: %v", err)
+	}
+	if _, ok := newMessage.Data.(*util.Buffer); !ok {
+		t.Errorf("Expect the raw payload of the non-first fragment, actual: %T", newMessage.Data)
+	}
+
+	// The extension header in the chain must be in the message.
+	msg.DestOptsHeaders[0].NextHeader = Type_HBH
+	if _, err := msg.MarshalBinary(); err == nil {
+		t.Errorf("Expect error to marshal the extension header twice")
+	}
+	msg.DestOptsHeaders[0].NextHeader = Type_UDP
+	if _, err := msg.MarshalBinary(); err == nil {
+		t.Errorf("Expect error to marshal the extension header which is not in the chain")
+	}
+}
+
+func TestIPv6TwoDestinationOptionsHeaders(t *testing.T) {
+	routing := make([]byte, 20)
+	copy(routing[4:], net.ParseIP("2001:db8::3"))
+	msg := &IPv6{
+		Version:    6,
+		NextHeader: Type_DestOpts,
+		HopLimit:   64,
+		NWSrc:      net.ParseIP("2001:db8::1"),
+		NWDst:      net.ParseIP("2001:db8::2"),
+		DestOptsHeaders: []*DestinationOptionsHeader{
+			{HopByHopHeader{NextHeader: Type_Routing, Options: []*Option{{Type: Option_PadN, Length: 4, Data: make([]byte, 4)}}}},
+			{HopByHopHeader{NextHeader: Type_UDP, Options: []*Option{{Type: 7, Length: 4, Data: []byte{1, 2, 3, 4}}}}},
+		},
+		RoutingHeader: &RoutingHeader{NextHeader: Type_DestOpts, HEL: 2, SegmentsLeft: 1, Data: util.NewBuffer(routing)},
+		Data:          &UDP{PortSrc: 1000, PortDst: 53, Length: 12, Data: []byte{1, 2, 3, 4}},
+	}
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to Marshal message: %v", err)
+	}
+	if len(data) != int(msg.Len()) || len(data) != 40+8+24+8+12 {
+		t.Fatalf("Unexpected length %d of Len %d", len(data), msg.Len())
+	}
+	newMessage := new(IPv6)
+	if err := newMessage.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to UnMarshal message: %v", err)
+	}
+	if len(newMessage.DestOptsHeaders) != 2 || newMessage.RoutingHeader == nil || newMessage.Protocol() != Type_UDP {
+		t.Fatalf("Unexpected extension headers of IPv6 message %+v", newMessage)
+	}
+	if o := newMessage.DestOptsHeaders[1].Options[0]; o.Type != 7 || !bytes.Equal(o.Data, []byte{1, 2, 3, 4}) {
+		t.Errorf("Unexpected option of the second destination options header %+v", o)
+	}
+	newData, err := newMessage.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to Marshal message: %v", err)
+	}
+	if !bytes.Equal(data, newData) {
+		t.Errorf("Unexpected re-marshaled message %x, expected %x", newData, data)
+	}
+
+	// The extension headers other than the destination options header can't be repeated.
+	data[48] = Type_Routing
+	if err := newMessage.UnmarshalBinary(data); err == nil {
+		t.Errorf("Expect error to unmarshal the routing header twice")
+	}
+}
@@ -0,0 +1,147 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// LACP_SUBTYPE is the subtype of LACP in the slow protocols frames, IEEE 802.1AX.
+const LACP_SUBTYPE = 1
+
+// LACP_MULTICAST_ADDR is the slow protocols multicast address, the destination of the LACPDUs.
+var LACP_MULTICAST_ADDR = net.HardwareAddr{0x01, 0x80, 0xc2, 0x00, 0x00, 0x02}
+
+// LACP actor and partner state bits.
+const (
+	LACP_STATE_ACTIVITY        = 1 << 0
+	LACP_STATE_TIMEOUT         = 1 << 1
+	LACP_STATE_AGGREGATION     = 1 << 2
+	LACP_STATE_SYNCHRONIZATION = 1 << 3
+	LACP_STATE_COLLECTING      = 1 << 4
+	LACP_STATE_DISTRIBUTING    = 1 << 5
+	LACP_STATE_DEFAULTED       = 1 << 6
+	LACP_STATE_EXPIRED         = 1 << 7
+)
+
+// The TLV types and lengths of the LACPDU.
+const (
+	lacpTLVTerminator    = 0
+	lacpTLVActor         = 1
+	lacpTLVPartner       = 2
+	lacpTLVCollector     = 3
+	lacpActorInfoLen     = 20
+	lacpCollectorInfoLen = 16
+	lacpPDULen           = 110
+)
+
+// LACPInfo is the actor or the partner information of the LACPDU.
+type LACPInfo struct {
+	SystemPriority uint16
+	System         net.HardwareAddr
+	Key            uint16
+	PortPriority   uint16
+	Port           uint16
+	State          uint8
+}
+
+func (i *LACPInfo) marshal(data []byte, tlvType uint8) {
+	data[0] = tlvType
+	data[1] = lacpActorInfoLen
+	binary.BigEndian.PutUint16(data[2:], i.SystemPriority)
+	copy(data[4:10], i.System)
+	binary.BigEndian.PutUint16(data[10:], i.Key)
+	binary.BigEndian.PutUint16(data[12:], i.PortPriority)
+	binary.BigEndian.PutUint16(data[14:], i.Port)
+	data[16] = i.State
+}
+
+func (i *LACPInfo) unmarshal(data []byte, tlvType uint8) error {
+	if data[0] != tlvType || data[1] != lacpActorInfoLen {
+		return fmt.Errorf("unexpected LACP TLV type %d of length %d, expected type %d", data[0], data[1], tlvType)
+	}
+	i.SystemPriority = binary.BigEndian.Uint16(data[2:])
+	i.System = net.HardwareAddr(append([]byte(nil), data[4:10]...))
+	i.Key = binary.BigEndian.Uint16(data[10:])
+	i.PortPriority = binary.BigEndian.Uint16(data[12:])
+	i.Port = binary.BigEndian.Uint16(data[14:])
+	i.State = data[16]
+	return nil
+}
+
+// LACP is an LACPDU, the payload of the slow protocols frame of subtype LACP_SUBTYPE.
+type LACP struct {
+	Version           uint8
+	Actor             LACPInfo
+	Partner           LACPInfo
+	CollectorMaxDelay uint16
+}
+
+// NewLACP returns the LACPDU of version 1 with the actor information.
+func NewLACP(actor LACPInfo) *LACP {
+	l := new(LACP)
+	l.Version = 1
+	l.Actor = actor
+	l.Partner.System = net.HardwareAddr(make([]byte, 6))
+	return l
+}
+
+func (l *LACP) Len() uint16 {
+	return lacpPDULen
+}
+
+func (l *LACP) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(l.Len()))
+	data[0] = LACP_SUBTYPE
+	data[1] = l.Version
+	n := 2
+	l.Actor.marshal(data[n:], lacpTLVActor)
+	n += lacpActorInfoLen
+	l.Partner.marshal(data[n:], lacpTLVPartner)
+	n += lacpActorInfoLen
+	data[n] = lacpTLVCollector
+	data[n+1] = lacpCollectorInfoLen
+	binary.BigEndian.PutUint16(data[n+2:], l.CollectorMaxDelay)
+	// The terminator TLV and the reserved bytes are 0.
+	return data, nil
+}
+
+func (l *LACP) UnmarshalBinary(data []byte) error {
+	if len(data) < lacpPDULen {
+		return errors.New("The []byte is too short to unmarshal a full LACP message.")
+	}
+	if data[0] != LACP_SUBTYPE {
+		return fmt.Errorf("unexpected slow protocols subtype %d of LACP", data[0])
+	}
+	l.Version = data[1]
+	n := 2
+	if err := l.Actor.unmarshal(data[n:], lacpTLVActor); err != nil {
+		return err
+	}
+	n += lacpActorInfoLen
+	if err := l.Partner.unmarshal(data[n:], lacpTLVPartner); err != nil {
+		return err
+	}
+	n += lacpActorInfoLen
+	if data[n] != lacpTLVCollector || data[n+1] != lacpCollectorInfoLen {
+		return fmt.Errorf("unexpected LACP TLV type %d of length %d, expected type %d", data[n], data[n+1], lacpTLVCollector)
+	}
+	l.CollectorMaxDelay = binary.BigEndian.Uint16(data[n+2:])
+	n += lacpCollectorInfoLen
+	if data[n] != lacpTLVTerminator {
+		return fmt.Errorf("unexpected LACP TLV type %d, expected the terminator", data[n])
+	}
+	return nil
+}
+
+// NewLACPEthernet returns the slow protocols frame of the LACPDU from the source MAC address to
+// LACP_MULTICAST_ADDR.
+func NewLACPEthernet(src net.HardwareAddr, l *LACP) *Ethernet {
+	eth := NewEthernet()
+	copy(eth.HWDst, LACP_MULTICAST_ADDR)
+	copy(eth.HWSrc, src)
+	eth.Ethertype = SLOW_PROTOCOLS_MSG
+	eth.Data = l
+	return eth
+}
@@ -0,0 +1,65 @@
+package protocol
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+func TestLACP(t *testing.T) {
+	src, _ := net.ParseMAC("aa:bb:cc:dd:ee:01")
+	l := NewLACP(LACPInfo{SystemPriority: 0x8000, System: src, Key: 13, PortPriority: 0xff, Port: 2,
+		State: LACP_STATE_ACTIVITY | LACP_STATE_AGGREGATION | LACP_STATE_SYNCHRONIZATION})
+	l.CollectorMaxDelay = 10
+	data, err := NewLACPEthernet(src, l).MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal LACP: %v", err)
+	}
+	if len(data) != 14+110 || !bytes.Equal(data[12:18], []byte{0x88, 0x09, LACP_SUBTYPE, 1, 1, 20}) {
+		t.Fatalf("Unexpected LACP frame %x", data)
+	}
+
+	eth := new(Ethernet)
+	if err := eth.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal LACP: %v", err)
+	}
+	l2, ok := eth.Data.(*LACP)
+	if !ok {
+		t.Fatalf("Unexpected Ethernet payload %T", eth.Data)
+	}
+	if l2.Version != 1 || l2.Actor.Key != 13 || l2.Actor.Port != 2 || l2.Actor.State != l.Actor.State ||
+		!bytes.Equal(l2.Actor.System, src) || l2.CollectorMaxDelay != 10 {
+		t.Errorf("Unexpected LACP %+v", l2)
+	}
+	if !bytes.Equal(l2.Partner.System, make([]byte, 6)) || l2.Partner.State != 0 {
+		t.Errorf("Unexpected LACP partner %+v", l2.Partner)
+	}
+
+	if err := l2.UnmarshalBinary(data[14:100]); err == nil {
+		t.Errorf("Expected an error for the truncated LACPDU")
+	}
+	bad := append([]byte(nil), data[14:]...)
+	bad[22] = 3
+	if err := l2.UnmarshalBinary(bad); err == nil {
+		t.Errorf("Expected an error for the unexpected partner TLV type")
+	}
+
+	// The LACPDU cut off by the max_len of a packet-in is kept as the raw bytes.
+	if err := eth.UnmarshalBinary(data[:64]); err != nil {
+		t.Fatalf("Failed to unmarshal the truncated LACP frame: %v", err)
+	}
+	if buf, ok := eth.Data.(*util.Buffer); !ok || !bytes.Equal(buf.Bytes(), data[14:64]) {
+		t.Errorf("Unexpected payload of the truncated LACP frame %+v", eth.Data)
+	}
+
+	// The slow protocols frames of other subtypes are not decoded.
+	data[14] = 2
+	if err := eth.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal the slow protocols frame: %v", err)
+	}
+	if _, ok := eth.Data.(*LACP); ok {
+		t.Errorf("Unexpected LACP of the subtype 2")
+	}
+}
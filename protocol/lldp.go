@@ -3,52 +3,245 @@ package protocol
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
 )
 
+// LLDP TLV types, IEEE 802.1AB.
+const (
+	LLDP_TLV_END          = 0
+	LLDP_TLV_CHASSIS_ID   = 1
+	LLDP_TLV_PORT_ID      = 2
+	LLDP_TLV_TTL          = 3
+	LLDP_TLV_PORT_DESC    = 4
+	LLDP_TLV_SYSTEM_NAME  = 5
+	LLDP_TLV_SYSTEM_DESC  = 6
+	LLDP_TLV_SYSTEM_CAPS  = 7
+	LLDP_TLV_MGMT_ADDR    = 8
+	LLDP_TLV_ORG_SPECIFIC = 127
+)
+
+// lldpMaxTLVLen is the maximum length of a TLV value, the length is 9 bits.
+const lldpMaxTLVLen = 0x1ff
+
+// LLDP_MULTICAST_ADDR is the nearest bridge group address, the destination of the LLDP frames which are not
+// forwarded by the bridges.
+var LLDP_MULTICAST_ADDR = net.HardwareAddr{0x01, 0x80, 0xc2, 0x00, 0x00, 0x0e}
+
+// LLDP is an LLDP data unit, the mandatory chassis ID, port ID and TTL TLVs, and the optional TLVs in Optional
+// which doesn't include the End TLV.
 type LLDP struct {
-	Chassis ChassisTLV
-	Port    PortTLV
-	TTL     TTLTLV
+	Chassis  ChassisTLV
+	Port     PortTLV
+	TTL      TTLTLV
+	Optional []*LLDPTLV
+}
+
+// NewLLDP returns the LLDP of the chassis ID and the port ID with the subtypes, e.g., CH_MAC_ADDR and
+// PT_IFACE_NAME, and the TTL in seconds.
+func NewLLDP(chassisSubtype uint8, chassisID []byte, portSubtype uint8, portID []byte, ttl uint16) *LLDP {
+	d := new(LLDP)
+	d.Chassis = ChassisTLV{Type: LLDP_TLV_CHASSIS_ID, Length: uint16(1 + len(chassisID)), Subtype: chassisSubtype, Data: chassisID}
+	d.Port = PortTLV{Type: LLDP_TLV_PORT_ID, Length: uint16(1 + len(portID)), Subtype: portSubtype, Data: portID}
+	d.TTL = TTLTLV{Type: LLDP_TLV_TTL, Length: 2, Seconds: ttl}
+	return d
+}
+
+// NewLLDPEthernet returns the Ethernet frame of the LLDP from the source MAC address to LLDP_MULTICAST_ADDR, e.g.,
+// the packet of a packet-out for the topology discovery.
+func NewLLDPEthernet(src net.HardwareAddr, d *LLDP) *Ethernet {
+	eth := NewEthernet()
+	copy(eth.HWDst, LLDP_MULTICAST_ADDR)
+	copy(eth.HWSrc, src)
+	eth.Ethertype = LLDP_MSG
+	eth.Data = d
+	return eth
+}
+
+// AddTLV adds the optional TLV to the LLDP.
+func (d *LLDP) AddTLV(t *LLDPTLV) {
+	d.Optional = append(d.Optional, t)
+}
+
+// TLV returns the first optional TLV of the type, or nil if there is none.
+func (d *LLDP) TLV(tlvType uint8) *LLDPTLV {
+	for _, t := range d.Optional {
+		if t.Type == tlvType {
+			return t
+		}
+	}
+	return nil
+}
+
+func (d *LLDP) stringTLV(tlvType uint8) string {
+	if t := d.TLV(tlvType); t != nil {
+		return string(t.Value)
+	}
+	return ""
+}
+
+// PortDescription returns the port description, or "" if there is no such TLV.
+func (d *LLDP) PortDescription() string {
+	return d.stringTLV(LLDP_TLV_PORT_DESC)
+}
+
+// SystemName returns the system name, or "" if there is no such TLV.
+func (d *LLDP) SystemName() string {
+	return d.stringTLV(LLDP_TLV_SYSTEM_NAME)
+}
+
+// SystemDescription returns the system description, or "" if there is no such TLV.
+func (d *LLDP) SystemDescription() string {
+	return d.stringTLV(LLDP_TLV_SYSTEM_DESC)
+}
+
+// SystemCapabilities returns the system capabilities and the enabled capabilities, ok is false if there is no
+// such TLV.
+func (d *LLDP) SystemCapabilities() (caps, enabled uint16, ok bool) {
+	t := d.TLV(LLDP_TLV_SYSTEM_CAPS)
+	if t == nil || len(t.Value) != 4 {
+		return 0, 0, false
+	}
+	return binary.BigEndian.Uint16(t.Value[0:]), binary.BigEndian.Uint16(t.Value[2:]), true
 }
 
 func (d *LLDP) Len() (n uint16) {
-	return 15
+	n = 2 + d.Chassis.Length
+	n += 2 + d.Port.Length
+	n += 2 + d.TTL.Length
+	for _, t := range d.Optional {
+		n += t.Len()
+	}
+	// The End TLV.
+	n += 2
+	return
 }
 
 func (d *LLDP) Read(b []byte) (n int, err error) {
-	m, o, p := 0, 0, 0
-	if m, err = d.Chassis.Read(b); m == 0 {
+	m := 0
+	if m, err = d.Chassis.Read(b); err != nil {
 		return
 	}
 	n += m
-	if o, err = d.Port.Read(b); o == 0 {
+	if m, err = d.Port.Read(b[n:]); err != nil {
 		return
 	}
-	n += o
-	if p, err = d.Chassis.Read(b); p == 0 {
+	n += m
+	if m, err = d.TTL.Read(b[n:]); err != nil {
 		return
 	}
-	n += p
+	n += m
+	for _, t := range d.Optional {
+		data, err := t.MarshalBinary()
+		if err != nil {
+			return n, err
+		}
+		n += copy(b[n:], data)
+	}
+	n += copy(b[n:], []byte{0, 0})
 	return
 }
 
+// Write decodes the LLDP, the TLVs after the End TLV, e.g., the padding of the Ethernet frame, are ignored.
 func (d *LLDP) Write(b []byte) (n int, err error) {
-	m, o, p := 0, 0, 0
-	if m, err = d.Chassis.Write(b); m == 0 {
+	m := 0
+	if m, err = d.Chassis.Write(b); err != nil {
 		return
 	}
 	n += m
-	if o, err = d.Port.Write(b[n:]); o == 0 {
+	if m, err = d.Port.Write(b[n:]); err != nil {
 		return
 	}
-	n += o
-	if p, err = d.Chassis.Write(b[n:]); p == 0 {
+	n += m
+	if m, err = d.TTL.Write(b[n:]); err != nil {
 		return
 	}
-	n += p
+	n += m
+	if d.Chassis.Type != LLDP_TLV_CHASSIS_ID || d.Port.Type != LLDP_TLV_PORT_ID || d.TTL.Type != LLDP_TLV_TTL {
+		return n, fmt.Errorf("unexpected mandatory LLDP TLV types %d, %d and %d", d.Chassis.Type, d.Port.Type, d.TTL.Type)
+	}
+	d.Optional = nil
+	for n < len(b) {
+		t := new(LLDPTLV)
+		if err = t.UnmarshalBinary(b[n:]); err != nil {
+			return
+		}
+		n += int(t.Len())
+		if t.Type == LLDP_TLV_END {
+			break
+		}
+		d.Optional = append(d.Optional, t)
+	}
 	return
 }
 
+func (d *LLDP) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(d.Len()))
+	if _, err = d.Read(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (d *LLDP) UnmarshalBinary(data []byte) error {
+	_, err := d.Write(data)
+	return err
+}
+
+// LLDPTLV is an optional LLDP TLV, the length on the wire is the length of Value.
+type LLDPTLV struct {
+	Type  uint8 //7 bits
+	Value []byte
+}
+
+func NewLLDPTLV(tlvType uint8, value []byte) *LLDPTLV {
+	return &LLDPTLV{Type: tlvType, Value: value}
+}
+
+// NewLLDPSystemCapsTLV returns the system capabilities TLV of the capabilities and the enabled capabilities.
+func NewLLDPSystemCapsTLV(caps, enabled uint16) *LLDPTLV {
+	value := make([]byte, 4)
+	binary.BigEndian.PutUint16(value[0:], caps)
+	binary.BigEndian.PutUint16(value[2:], enabled)
+	return NewLLDPTLV(LLDP_TLV_SYSTEM_CAPS, value)
+}
+
+// NewLLDPOrgSpecificTLV returns the organizationally specific TLV of the OUI and the subtype.
+func NewLLDPOrgSpecificTLV(oui [3]byte, subtype uint8, info []byte) *LLDPTLV {
+	value := append(append(oui[:], subtype), info...)
+	return NewLLDPTLV(LLDP_TLV_ORG_SPECIFIC, value)
+}
+
+func (t *LLDPTLV) Len() uint16 {
+	return 2 + uint16(len(t.Value))
+}
+
+func (t *LLDPTLV) MarshalBinary() (data []byte, err error) {
+	if len(t.Value) > lldpMaxTLVLen {
+		return nil, fmt.Errorf("the LLDP TLV value of %d bytes is longer than %d bytes", len(t.Value), lldpMaxTLVLen)
+	}
+	data = make([]byte, int(t.Len()))
+	binary.BigEndian.PutUint16(data, uint16(t.Type)<<9|uint16(len(t.Value)))
+	copy(data[2:], t.Value)
+	return data, nil
+}
+
+func (t *LLDPTLV) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return errors.New("The []byte is too short to unmarshal a full LLDPTLV message.")
+	}
+	typeAndLen := binary.BigEndian.Uint16(data)
+	t.Type = uint8(typeAndLen >> 9)
+	length := int(typeAndLen & lldpMaxTLVLen)
+	if len(data) < 2+length {
+		return errors.New("The []byte is too short to unmarshal a full LLDPTLV message.")
+	}
+	t.Value = make([]byte, length)
+	copy(t.Value, data[2:2+length])
+	return nil
+}
+
 // Chassis ID subtypes
 const (
 	_ = iota
@@ -92,11 +285,14 @@ func (t *ChassisTLV) Write(b []byte) (n int, err error) {
 		return
 	}
 	n += 1
-	t.Data = make([]uint8, t.Length)
+	if t.Length < 1 {
+		return n, errors.New("The TLV length is shorter than the subtype.")
+	}
+	t.Data = make([]uint8, t.Length-1)
 	if err = binary.Read(buf, binary.BigEndian, &t.Data); err != nil {
 		return
 	}
-	n += int(t.Length)
+	n += int(t.Length) - 1
 	return
 }
 
@@ -143,11 +339,14 @@ func (t *PortTLV) Write(b []byte) (n int, err error) {
 		return
 	}
 	n += 1
-	t.Data = make([]uint8, t.Length)
+	if t.Length < 1 {
+		return n, errors.New("The TLV length is shorter than the subtype.")
+	}
+	t.Data = make([]uint8, t.Length-1)
 	if err = binary.Read(buf, binary.BigEndian, &t.Data); err != nil {
 		return
 	}
-	n += int(t.Length)
+	n += int(t.Length) - 1
 	return
 }
 
@@ -0,0 +1,93 @@
+package protocol
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+func TestLLDP(t *testing.T) {
+	src, _ := net.ParseMAC("aa:bb:cc:dd:ee:01")
+	d := NewLLDP(CH_MAC_ADDR, src, PT_IFACE_NAME, []byte("eth1"), 120)
+	d.AddTLV(NewLLDPTLV(LLDP_TLV_PORT_DESC, []byte("uplink")))
+	d.AddTLV(NewLLDPTLV(LLDP_TLV_SYSTEM_NAME, []byte("switch1")))
+	d.AddTLV(NewLLDPSystemCapsTLV(0x14, 0x04))
+	d.AddTLV(NewLLDPOrgSpecificTLV([3]byte{0x00, 0x26, 0xe1}, 1, []byte{0xde, 0xad}))
+	eth := NewLLDPEthernet(src, d)
+	data, err := eth.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal LLDP: %v", err)
+	}
+	// Chassis ID, port ID and TTL TLVs.
+	expected := []byte{0x02, 0x07, CH_MAC_ADDR, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0x01,
+		0x04, 0x05, PT_IFACE_NAME, 'e', 't', 'h', '1',
+		0x06, 0x02, 0x00, 120}
+	if !bytes.Equal(data[14:14+len(expected)], expected) || !bytes.Equal(data[len(data)-2:], []byte{0, 0}) {
+		t.Fatalf("Unexpected LLDP %x", data[14:])
+	}
+	if int(d.Len()) != len(data)-14 {
+		t.Errorf("Unexpected LLDP length %d of %d bytes", d.Len(), len(data)-14)
+	}
+
+	// The frame padded to the minimum Ethernet frame size.
+	data = append(data, make([]byte, 8)...)
+	eth2 := new(Ethernet)
+	if err := eth2.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal LLDP: %v", err)
+	}
+	if !bytes.Equal(eth2.HWDst, LLDP_MULTICAST_ADDR) {
+		t.Errorf("Unexpected destination %s", eth2.HWDst)
+	}
+	d2, ok := eth2.Data.(*LLDP)
+	if !ok {
+		t.Fatalf("Unexpected Ethernet payload %T", eth2.Data)
+	}
+	if d2.Chassis.Subtype != CH_MAC_ADDR || !bytes.Equal(d2.Chassis.Data, src) {
+		t.Errorf("Unexpected chassis ID %+v", d2.Chassis)
+	}
+	if d2.Port.Subtype != PT_IFACE_NAME || string(d2.Port.Data) != "eth1" || d2.TTL.Seconds != 120 {
+		t.Errorf("Unexpected port ID %+v or TTL %+v", d2.Port, d2.TTL)
+	}
+	if len(d2.Optional) != 4 || d2.PortDescription() != "uplink" || d2.SystemName() != "switch1" || d2.SystemDescription() != "" {
+		t.Errorf("Unexpected optional TLVs %+v", d2.Optional)
+	}
+	if caps, enabled, ok := d2.SystemCapabilities(); !ok || caps != 0x14 || enabled != 0x04 {
+		t.Errorf("Unexpected system capabilities 0x%x 0x%x", caps, enabled)
+	}
+	if o := d2.TLV(LLDP_TLV_ORG_SPECIFIC); o == nil || !bytes.Equal(o.Value, []byte{0x00, 0x26, 0xe1, 1, 0xde, 0xad}) {
+		t.Errorf("Unexpected organizationally specific TLV %+v", o)
+	}
+
+	if err := d2.UnmarshalBinary(data[14:20]); err == nil {
+		t.Errorf("Expected an error for the truncated chassis ID")
+	}
+	bad := append([]byte(nil), data[14:]...)
+	bad[9] = 0x08
+	if err := d2.UnmarshalBinary(bad); err == nil {
+		t.Errorf("Expected an error for the unexpected port ID TLV type")
+	}
+}
+
+func TestLLDPTruncatedFrame(t *testing.T) {
+	src, _ := net.ParseMAC("aa:bb:cc:dd:ee:01")
+	d := NewLLDP(CH_MAC_ADDR, src, PT_IFACE_NAME, []byte("eth1"), 120)
+	d.AddTLV(NewLLDPTLV(LLDP_TLV_SYSTEM_DESC, bytes.Repeat([]byte("x"), 200)))
+	data, err := NewLLDPEthernet(src, d).MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal LLDP: %v", err)
+	}
+	// The frame cut off by the max_len of a packet-in is kept as the raw bytes.
+	eth := new(Ethernet)
+	if err := eth.UnmarshalBinary(data[:128]); err != nil {
+		t.Fatalf("Failed to unmarshal the truncated LLDP frame: %v", err)
+	}
+	buf, ok := eth.Data.(*util.Buffer)
+	if !ok {
+		t.Fatalf("Unexpected payload of the truncated LLDP frame %T", eth.Data)
+	}
+	if !bytes.Equal(buf.Bytes(), data[14:128]) {
+		t.Errorf("Unexpected raw LLDP %x", buf.Bytes())
+	}
+}
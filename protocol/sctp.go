@@ -0,0 +1,168 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+// SCTP chunk types, RFC 9260.
+const (
+	SCTPChunk_DATA              = 0
+	SCTPChunk_INIT              = 1
+	SCTPChunk_INIT_ACK          = 2
+	SCTPChunk_SACK              = 3
+	SCTPChunk_HEARTBEAT         = 4
+	SCTPChunk_HEARTBEAT_ACK     = 5
+	SCTPChunk_ABORT             = 6
+	SCTPChunk_SHUTDOWN          = 7
+	SCTPChunk_SHUTDOWN_ACK      = 8
+	SCTPChunk_ERROR             = 9
+	SCTPChunk_COOKIE_ECHO       = 10
+	SCTPChunk_COOKIE_ACK        = 11
+	SCTPChunk_SHUTDOWN_COMPLETE = 14
+)
+
+var sctpCRC32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// SCTPChunk is a chunk of an SCTP packet. The length on the wire is the length of Data plus the 4-byte chunk
+// header, and the chunk is padded to a multiple of 4 bytes.
+type SCTPChunk struct {
+	Type  uint8
+	Flags uint8
+	Data  []byte
+}
+
+func NewSCTPChunk(chunkType, flags uint8, data []byte) *SCTPChunk {
+	return &SCTPChunk{Type: chunkType, Flags: flags, Data: data}
+}
+
+func (c *SCTPChunk) Len() uint16 {
+	return 4 + uint16((len(c.Data)+3)/4*4)
+}
+
+func (c *SCTPChunk) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, int(c.Len()))
+	data[0] = c.Type
+	data[1] = c.Flags
+	binary.BigEndian.PutUint16(data[2:], uint16(4+len(c.Data)))
+	copy(data[4:], c.Data)
+	return data, nil
+}
+
+// UnmarshalBinary decodes the chunk. If the chunk is truncated, e.g., in a packet-in truncated by miss_send_len,
+// Data is the available part of the chunk value.
+func (c *SCTPChunk) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full SCTPChunk message.")
+	}
+	c.Type = data[0]
+	c.Flags = data[1]
+	length := int(binary.BigEndian.Uint16(data[2:]))
+	if length < 4 {
+		return errors.New("The SCTPChunk length is shorter than the chunk header.")
+	}
+	if length > len(data) {
+		length = len(data)
+	}
+	c.Data = make([]byte, length-4)
+	copy(c.Data, data[4:length])
+	return nil
+}
+
+// SCTP is an SCTP packet, the common header and the chunks. Checksum is the CRC32c of the packet, which is
+// little-endian on the wire, see UpdateChecksum.
+type SCTP struct {
+	PortSrc         uint16
+	PortDst         uint16
+	VerificationTag uint32
+	Checksum        uint32
+	Chunks          []*SCTPChunk
+}
+
+func NewSCTP() *SCTP {
+	s := new(SCTP)
+	s.Chunks = make([]*SCTPChunk, 0)
+	return s
+}
+
+// AddChunk appends the chunk to the packet.
+func (s *SCTP) AddChunk(c *SCTPChunk) {
+	s.Chunks = append(s.Chunks, c)
+}
+
+// Chunk returns the first chunk of the type, or nil if there is none.
+func (s *SCTP) Chunk(chunkType uint8) *SCTPChunk {
+	for _, c := range s.Chunks {
+		if c.Type == chunkType {
+			return c
+		}
+	}
+	return nil
+}
+
+func (s *SCTP) Len() (n uint16) {
+	n = 12
+	for _, c := range s.Chunks {
+		n += c.Len()
+	}
+	return
+}
+
+func (s *SCTP) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, 12, int(s.Len()))
+	binary.BigEndian.PutUint16(data[0:], s.PortSrc)
+	binary.BigEndian.PutUint16(data[2:], s.PortDst)
+	binary.BigEndian.PutUint32(data[4:], s.VerificationTag)
+	binary.LittleEndian.PutUint32(data[8:], s.Checksum)
+	for _, c := range s.Chunks {
+		b, err := c.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return data, nil
+}
+
+func (s *SCTP) UnmarshalBinary(data []byte) error {
+	if len(data) < 12 {
+		return errors.New("The []byte is too short to unmarshal a full SCTP message.")
+	}
+	s.PortSrc = binary.BigEndian.Uint16(data[0:])
+	s.PortDst = binary.BigEndian.Uint16(data[2:])
+	s.VerificationTag = binary.BigEndian.Uint32(data[4:])
+	s.Checksum = binary.LittleEndian.Uint32(data[8:])
+	s.Chunks = make([]*SCTPChunk, 0)
+	for n := 12; n < len(data); {
+		c := new(SCTPChunk)
+		if err := c.UnmarshalBinary(data[n:]); err != nil {
+			return err
+		}
+		s.Chunks = append(s.Chunks, c)
+		n += int(c.Len())
+	}
+	return nil
+}
+
+// ComputeChecksum returns the CRC32c of the packet with the checksum field set to zero.
+func (s *SCTP) ComputeChecksum() (uint32, error) {
+	checksum := s.Checksum
+	s.Checksum = 0
+	data, err := s.MarshalBinary()
+	s.Checksum = checksum
+	if err != nil {
+		return 0, err
+	}
+	return crc32.Checksum(data, sctpCRC32cTable), nil
+}
+
+// UpdateChecksum sets Checksum to the CRC32c of the packet, it must be called after the chunks are final.
+func (s *SCTP) UpdateChecksum() error {
+	checksum, err := s.ComputeChecksum()
+	if err != nil {
+		return err
+	}
+	s.Checksum = checksum
+	return nil
+}
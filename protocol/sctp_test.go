@@ -0,0 +1,103 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"net"
+	"testing"
+)
+
+func TestSCTP(t *testing.T) {
+	s := NewSCTP()
+	s.PortSrc = 5000
+	s.PortDst = 80
+	s.VerificationTag = 0x01020304
+	s.AddChunk(NewSCTPChunk(SCTPChunk_DATA, 0x03, []byte{1, 2, 3, 4, 5}))
+	s.AddChunk(NewSCTPChunk(SCTPChunk_COOKIE_ACK, 0, nil))
+	if err := s.UpdateChecksum(); err != nil {
+		t.Fatalf("Failed to compute the SCTP checksum: %v", err)
+	}
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal SCTP: %v", err)
+	}
+	if len(data) != 12+12+4 || int(s.Len()) != len(data) {
+		t.Fatalf("Unexpected SCTP %x", data)
+	}
+	if binary.BigEndian.Uint16(data[14:]) != 9 {
+		t.Errorf("Unexpected chunk length %d", binary.BigEndian.Uint16(data[14:]))
+	}
+	zeroed := append([]byte(nil), data...)
+	copy(zeroed[8:12], []byte{0, 0, 0, 0})
+	if crc := crc32.Checksum(zeroed, crc32.MakeTable(crc32.Castagnoli)); binary.LittleEndian.Uint32(data[8:]) != crc {
+		t.Errorf("Unexpected checksum %x, expected %08x", data[8:12], crc)
+	}
+
+	s2 := new(SCTP)
+	if err := s2.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal SCTP: %v", err)
+	}
+	if s2.PortSrc != 5000 || s2.PortDst != 80 || s2.VerificationTag != 0x01020304 || s2.Checksum != s.Checksum {
+		t.Errorf("Unexpected SCTP header %+v", s2)
+	}
+	if len(s2.Chunks) != 2 {
+		t.Fatalf("Unexpected chunks %+v", s2.Chunks)
+	}
+	if c := s2.Chunk(SCTPChunk_DATA); c == nil || c.Flags != 0x03 || !bytes.Equal(c.Data, []byte{1, 2, 3, 4, 5}) {
+		t.Errorf("Unexpected DATA chunk %+v", c)
+	}
+	if c := s2.Chunk(SCTPChunk_INIT); c != nil {
+		t.Errorf("Unexpected INIT chunk %+v", c)
+	}
+	if checksum, _ := s2.ComputeChecksum(); checksum != s2.Checksum {
+		t.Errorf("Checksum %08x does not match %08x", s2.Checksum, checksum)
+	}
+
+	// A packet truncated in the middle of a chunk keeps the available part of the chunk.
+	if err := s2.UnmarshalBinary(data[:18]); err != nil {
+		t.Fatalf("Failed to unmarshal the truncated SCTP: %v", err)
+	}
+	if len(s2.Chunks) != 1 || !bytes.Equal(s2.Chunks[0].Data, []byte{1, 2}) {
+		t.Errorf("Unexpected chunks of the truncated SCTP %+v", s2.Chunks)
+	}
+	if err := s2.UnmarshalBinary(data[:8]); err == nil {
+		t.Errorf("Expected an error for the truncated common header")
+	}
+	bad := append([]byte(nil), data[:16]...)
+	binary.BigEndian.PutUint16(bad[14:], 2)
+	if err := s2.UnmarshalBinary(bad); err == nil {
+		t.Errorf("Expected an error for the chunk length shorter than the chunk header")
+	}
+}
+
+func TestIPv4SCTP(t *testing.T) {
+	s := NewSCTP()
+	s.PortSrc = 1
+	s.PortDst = 2
+	s.AddChunk(NewSCTPChunk(SCTPChunk_INIT, 0, make([]byte, 16)))
+	ip := NewIPv4()
+	ip.Version = 4
+	ip.IHL = 5
+	ip.TTL = 64
+	ip.Protocol = Type_SCTP
+	ip.NWSrc = net.ParseIP("10.0.0.1").To4()
+	ip.NWDst = net.ParseIP("10.0.0.2").To4()
+	ip.Data = s
+	ip.Length = ip.Len()
+	data, err := ip.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal IPv4: %v", err)
+	}
+	ip2 := new(IPv4)
+	if err := ip2.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal IPv4: %v", err)
+	}
+	s2, ok := ip2.Data.(*SCTP)
+	if !ok {
+		t.Fatalf("Unexpected IPv4 payload %T", ip2.Data)
+	}
+	if s2.PortDst != 2 || s2.Chunk(SCTPChunk_INIT) == nil {
+		t.Errorf("Unexpected SCTP %+v", s2)
+	}
+}
@@ -0,0 +1,247 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/contiv/libOpenflow/util"
+)
+
+// The IANA UDP ports of the tunnels.
+const (
+	VXLAN_PORT  = 4789
+	GENEVE_PORT = 6081
+)
+
+// ETH_BRIDGING_MSG is the protocol type of Geneve for the inner Ethernet frame, Transparent Ethernet Bridging.
+const ETH_BRIDGING_MSG = 0x6558
+
+// vxlanFlagVNI is the I flag of the VXLAN header, which is set if the VNI is valid.
+const vxlanFlagVNI = 0x08
+
+// VXLAN is the VXLAN header and the inner Ethernet frame in the UDP payload, RFC 7348.
+type VXLAN struct {
+	Flags uint8
+	VNI   uint32 // 24-bits
+	Data  util.Message
+}
+
+// NewVXLAN returns the VXLAN of the VNI with the valid VNI flag and the inner Ethernet frame.
+func NewVXLAN(vni uint32, inner *Ethernet) *VXLAN {
+	return &VXLAN{Flags: vxlanFlagVNI, VNI: vni & 0xffffff, Data: inner}
+}
+
+func (v *VXLAN) Len() (n uint16) {
+	n = 8
+	if v.Data != nil {
+		n += v.Data.Len()
+	}
+	return
+}
+
+func (v *VXLAN) MarshalBinary() (data []byte, err error) {
+	data = make([]byte, 8)
+	data[0] = v.Flags
+	binary.BigEndian.PutUint32(data[4:], v.VNI<<8)
+	if v.Data != nil {
+		b, err := v.Data.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return data, nil
+}
+
+func (v *VXLAN) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full VXLAN message.")
+	}
+	v.Flags = data[0]
+	v.VNI = binary.BigEndian.Uint32(data[4:]) >> 8
+	v.Data = new(Ethernet)
+	return v.Data.UnmarshalBinary(data[8:])
+}
+
+// InnerEthernet returns the inner Ethernet frame.
+func (v *VXLAN) InnerEthernet() (*Ethernet, error) {
+	if eth, ok := v.Data.(*Ethernet); ok {
+		return eth, nil
+	}
+	return nil, errors.New("no inner Ethernet frame in VXLAN")
+}
+
+// GeneveOption is a Geneve option TLV, the length on the wire is in units of 4 bytes and excludes the 4-byte
+// option header, so that Data is padded to a multiple of 4 bytes.
+type GeneveOption struct {
+	Class uint16
+	Type  uint8
+	Data  []byte
+}
+
+func (o *GeneveOption) Len() uint16 {
+	return 4 + uint16((len(o.Data)+3)/4*4)
+}
+
+func (o *GeneveOption) MarshalBinary() (data []byte, err error) {
+	if len(o.Data) > 31*4 {
+		return nil, fmt.Errorf("the Geneve option data of %d bytes is longer than 124 bytes", len(o.Data))
+	}
+	data = make([]byte, int(o.Len()))
+	binary.BigEndian.PutUint16(data[0:], o.Class)
+	data[2] = o.Type
+	data[3] = uint8(len(data)-4) / 4
+	copy(data[4:], o.Data)
+	return data, nil
+}
+
+func (o *GeneveOption) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return errors.New("The []byte is too short to unmarshal a full GeneveOption message.")
+	}
+	o.Class = binary.BigEndian.Uint16(data[0:])
+	o.Type = data[2]
+	length := 4 * int(data[3]&0x1f)
+	if len(data) < 4+length {
+		return errors.New("The []byte is too short to unmarshal a full GeneveOption message.")
+	}
+	o.Data = make([]byte, length)
+	copy(o.Data, data[4:4+length])
+	return nil
+}
+
+// Geneve is the Geneve header with the options and the payload in the UDP payload, RFC 8926. The payload is an
+// Ethernet frame if the ProtocolType is ETH_BRIDGING_MSG, and raw bytes otherwise.
+type Geneve struct {
+	Version      uint8 // 2-bits
+	OAM          bool
+	Critical     bool
+	ProtocolType uint16
+	VNI          uint32 // 24-bits
+	Options      []*GeneveOption
+	Data         util.Message
+}
+
+// NewGeneve returns the Geneve of the VNI with the inner Ethernet frame.
+func NewGeneve(vni uint32, inner *Ethernet) *Geneve {
+	return &Geneve{ProtocolType: ETH_BRIDGING_MSG, VNI: vni & 0xffffff, Data: inner}
+}
+
+func (g *Geneve) optionsLen() uint16 {
+	var n uint16
+	for _, o := range g.Options {
+		n += o.Len()
+	}
+	return n
+}
+
+func (g *Geneve) Len() (n uint16) {
+	n = 8 + g.optionsLen()
+	if g.Data != nil {
+		n += g.Data.Len()
+	}
+	return
+}
+
+func (g *Geneve) MarshalBinary() (data []byte, err error) {
+	optLen := g.optionsLen()
+	if optLen > 63*4 {
+		return nil, fmt.Errorf("the Geneve options of %d bytes are longer than 252 bytes", optLen)
+	}
+	data = make([]byte, 8)
+	data[0] = g.Version<<6 | uint8(optLen/4)
+	if g.OAM {
+		data[1] |= 0x80
+	}
+	if g.Critical {
+		data[1] |= 0x40
+	}
+	binary.BigEndian.PutUint16(data[2:], g.ProtocolType)
+	binary.BigEndian.PutUint32(data[4:], g.VNI<<8)
+	for _, o := range g.Options {
+		b, err := o.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	if g.Data != nil {
+		b, err := g.Data.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b...)
+	}
+	return data, nil
+}
+
+func (g *Geneve) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return errors.New("The []byte is too short to unmarshal a full Geneve message.")
+	}
+	g.Version = data[0] >> 6
+	optLen := 4 * int(data[0]&0x3f)
+	g.OAM = data[1]&0x80 != 0
+	g.Critical = data[1]&0x40 != 0
+	g.ProtocolType = binary.BigEndian.Uint16(data[2:])
+	g.VNI = binary.BigEndian.Uint32(data[4:]) >> 8
+	if len(data) < 8+optLen {
+		return errors.New("The []byte is too short to unmarshal a full Geneve message.")
+	}
+	g.Options = nil
+	for n := 8; n < 8+optLen; {
+		o := new(GeneveOption)
+		if err := o.UnmarshalBinary(data[n : 8+optLen]); err != nil {
+			return err
+		}
+		g.Options = append(g.Options, o)
+		n += int(o.Len())
+	}
+	if g.ProtocolType == ETH_BRIDGING_MSG {
+		g.Data = new(Ethernet)
+	} else {
+		g.Data = new(util.Buffer)
+	}
+	return g.Data.UnmarshalBinary(data[8+optLen:])
+}
+
+// Option returns the first option of the class and the type, or nil if there is none.
+func (g *Geneve) Option(class uint16, optionType uint8) *GeneveOption {
+	for _, o := range g.Options {
+		if o.Class == class && o.Type == optionType {
+			return o
+		}
+	}
+	return nil
+}
+
+// InnerEthernet returns the inner Ethernet frame.
+func (g *Geneve) InnerEthernet() (*Ethernet, error) {
+	if eth, ok := g.Data.(*Ethernet); ok {
+		return eth, nil
+	}
+	return nil, fmt.Errorf("no inner Ethernet frame in Geneve of protocol type 0x%04x", g.ProtocolType)
+}
+
+// UnwrapTunnel decodes the VXLAN or the Geneve in the UDP datagram according to its destination port, and returns
+// the inner Ethernet frame and the VNI.
+func UnwrapTunnel(udp *UDP) (*Ethernet, uint32, error) {
+	switch udp.PortDst {
+	case VXLAN_PORT:
+		v := new(VXLAN)
+		if err := v.UnmarshalBinary(udp.Data); err != nil {
+			return nil, 0, err
+		}
+		eth, err := v.InnerEthernet()
+		return eth, v.VNI, err
+	case GENEVE_PORT:
+		g := new(Geneve)
+		if err := g.UnmarshalBinary(udp.Data); err != nil {
+			return nil, 0, err
+		}
+		eth, err := g.InnerEthernet()
+		return eth, g.VNI, err
+	}
+	return nil, 0, fmt.Errorf("UDP port %d is not a tunnel port", udp.PortDst)
+}
@@ -0,0 +1,79 @@
+package protocol
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func newTunnelInnerFrame() *Ethernet {
+	eth := NewEthernet()
+	eth.HWSrc, _ = net.ParseMAC("aa:bb:cc:dd:ee:01")
+	eth.HWDst, _ = net.ParseMAC("ff:ff:ff:ff:ff:ff")
+	eth.Ethertype = ARP_MSG
+	arp, _ := NewARP(Type_Request)
+	arp.IPSrc = net.ParseIP("10.0.0.1").To4()
+	arp.IPDst = net.ParseIP("10.0.0.2").To4()
+	eth.Data = arp
+	return eth
+}
+
+func TestVXLAN(t *testing.T) {
+	inner := newTunnelInnerFrame()
+	data, err := NewVXLAN(0x123456, inner).MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal VXLAN: %v", err)
+	}
+	if len(data) != 8+int(inner.Len()) || data[0] != vxlanFlagVNI {
+		t.Fatalf("Unexpected VXLAN %x", data)
+	}
+	eth, vni, err := UnwrapTunnel(&UDP{PortDst: VXLAN_PORT, Data: data})
+	if err != nil {
+		t.Fatalf("Failed to unwrap VXLAN: %v", err)
+	}
+	if vni != 0x123456 || !bytes.Equal(eth.HWSrc, inner.HWSrc) {
+		t.Errorf("Unexpected inner frame of VNI 0x%x: %+v", vni, eth)
+	}
+	if arp, ok := eth.Data.(*ARP); !ok || !arp.IPDst.Equal(net.ParseIP("10.0.0.2")) {
+		t.Errorf("Unexpected inner payload %+v", eth.Data)
+	}
+}
+
+func TestGeneve(t *testing.T) {
+	inner := newTunnelInnerFrame()
+	g := NewGeneve(100, inner)
+	g.Critical = true
+	g.Options = []*GeneveOption{{Class: 0x0104, Type: 0x80, Data: []byte{1, 2, 3, 4, 5}}}
+	data, err := g.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to marshal Geneve: %v", err)
+	}
+	if len(data) != 8+12+int(inner.Len()) || data[0] != 3 || int(g.Len()) != len(data) {
+		t.Fatalf("Unexpected Geneve %x", data)
+	}
+
+	decoded := new(Geneve)
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Failed to unmarshal Geneve: %v", err)
+	}
+	if !decoded.Critical || decoded.OAM || decoded.VNI != 100 {
+		t.Errorf("Unexpected Geneve %+v", decoded)
+	}
+	opt := decoded.Option(0x0104, 0x80)
+	if opt == nil || !bytes.Equal(opt.Data, []byte{1, 2, 3, 4, 5, 0, 0, 0}) {
+		t.Errorf("Unexpected Geneve option %+v", opt)
+	}
+	if eth, err := decoded.InnerEthernet(); err != nil || !bytes.Equal(eth.HWDst, inner.HWDst) {
+		t.Errorf("Unexpected inner frame %+v: %v", eth, err)
+	}
+
+	decoded.ProtocolType = IPv4_MSG
+	data, _ = decoded.MarshalBinary()
+	if _, _, err := UnwrapTunnel(&UDP{PortDst: GENEVE_PORT, Data: data}); err == nil {
+		t.Errorf("Expect error to unwrap the Geneve without Ethernet frame")
+	}
+	data[0] = 10
+	if err := decoded.UnmarshalBinary(data[:20]); err == nil {
+		t.Errorf("Expect error to unmarshal the truncated options")
+	}
+}
@@ -0,0 +1,31 @@
+package util
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// ofpHeaderLen is the length of the OpenFlow header, which is the same in all the OpenFlow versions.
+const ofpHeaderLen = 8
+
+// SplitMessages splits data containing back-to-back OpenFlow messages into the messages according to the length
+// in their headers, e.g., the payload of a datagram or a packet capture. The returned messages share the memory
+// with data. An error is returned if a message has an invalid length, or data ends with an incomplete message.
+func SplitMessages(data []byte) ([][]byte, error) {
+	var msgs [][]byte
+	for n := 0; n < len(data); {
+		if len(data)-n < ofpHeaderLen {
+			return nil, fmt.Errorf("incomplete OpenFlow header at offset %d: %d bytes left", n, len(data)-n)
+		}
+		length := int(binary.BigEndian.Uint16(data[n+2:]))
+		if length < ofpHeaderLen {
+			return nil, fmt.Errorf("invalid OpenFlow message length %d at offset %d", length, n)
+		}
+		if len(data)-n < length {
+			return nil, fmt.Errorf("incomplete OpenFlow message at offset %d: expect %d bytes, %d bytes left", n, length, len(data)-n)
+		}
+		msgs = append(msgs, data[n:n+length:n+length])
+		n += length
+	}
+	return msgs, nil
+}
@@ -0,0 +1,30 @@
+package util
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSplitMessages(t *testing.T) {
+	hello := []byte{4, 0, 0, 8, 0, 0, 0, 1}
+	echo := []byte{4, 2, 0, 12, 0, 0, 0, 2, 1, 2, 3, 4}
+	data := append(append([]byte{}, hello...), echo...)
+
+	msgs, err := SplitMessages(data)
+	if err != nil {
+		t.Fatalf("Failed to split messages: %v", err)
+	}
+	if len(msgs) != 2 || !bytes.Equal(msgs[0], hello) || !bytes.Equal(msgs[1], echo) {
+		t.Errorf("Unexpected messages: %v", msgs)
+	}
+
+	for _, invalid := range [][]byte{
+		data[:len(data)-1],
+		data[:len(hello)+4],
+		{4, 0, 0, 4, 0, 0, 0, 1},
+	} {
+		if _, err := SplitMessages(invalid); err == nil {
+			t.Errorf("Expect error when splitting %v", invalid)
+		}
+	}
+}
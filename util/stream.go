@@ -3,8 +3,12 @@ package util
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
+	"io"
 	"net"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -49,21 +53,38 @@ type MessageStream struct {
 	Outbound chan Message
 	// Channel on which to receive a shutdown command
 	Shutdown chan bool
+	// Lock to write a message to conn as a whole, so that concurrent writers never interleave
+	writeLock sync.Mutex
+	// Number of messages written to conn
+	messagesSent uint64
+	// Number of short writes retried
+	partialWrites uint64
+}
+
+// MessageStreamStats are the metrics of a MessageStream. The queue lengths show the back-pressure, e.g., a full
+// outbound queue means the senders are blocked by the peer reading slowly.
+type MessageStreamStats struct {
+	OutboundQueued   int
+	OutboundCapacity int
+	InboundQueued    int
+	InboundCapacity  int
+	ParseQueued      int
+	MessagesSent     uint64
+	PartialWrites    uint64
 }
 
 // Returns a pointer to a new MessageStream. Used to parse
 // OpenFlow messages from conn.
 func NewMessageStream(conn net.Conn, parser Parser) *MessageStream {
 	m := &MessageStream{
-		conn,
-		NewBufferPool(),
-		parser,
-		make(chan bool, 1), // parserShutdown
-		0,
-		make(chan error, 1),   // Error
-		make(chan Message, 1), // Inbound
-		make(chan Message, 1), // Outbound
-		make(chan bool, 1),    // Shutdown
+		conn:           conn,
+		pool:           NewBufferPool(),
+		parser:         parser,
+		parserShutdown: make(chan bool, 1),
+		Error:          make(chan error, 1),
+		Inbound:        make(chan Message, 1),
+		Outbound:       make(chan Message, 1),
+		Shutdown:       make(chan bool, 1),
 	}
 
 	go m.outbound()
@@ -79,6 +100,48 @@ func (m *MessageStream) GetAddr() net.Addr {
 	return m.conn.RemoteAddr()
 }
 
+// Stats returns the metrics of the stream.
+func (m *MessageStream) Stats() MessageStreamStats {
+	return MessageStreamStats{
+		OutboundQueued:   len(m.Outbound),
+		OutboundCapacity: cap(m.Outbound),
+		InboundQueued:    len(m.Inbound),
+		InboundCapacity:  cap(m.Inbound),
+		ParseQueued:      len(m.pool.Full),
+		MessagesSent:     atomic.LoadUint64(&m.messagesSent),
+		PartialWrites:    atomic.LoadUint64(&m.partialWrites),
+	}
+}
+
+// writeMessage writes the marshaled message to conn as a whole. A short write is retried with the rest of the
+// message as long as the previous write made progress, and the lock makes sure the message isn't interleaved
+// with the ones of other writers.
+func (m *MessageStream) writeMessage(data []byte) error {
+	m.writeLock.Lock()
+	defer m.writeLock.Unlock()
+	for len(data) > 0 {
+		n, err := m.conn.Write(data)
+		data = data[n:]
+		if len(data) == 0 {
+			break
+		}
+		if n == 0 {
+			if err == nil {
+				err = io.ErrShortWrite
+			}
+			return err
+		}
+		if err != nil {
+			if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+				return err
+			}
+		}
+		atomic.AddUint64(&m.partialWrites, 1)
+	}
+	atomic.AddUint64(&m.messagesSent, 1)
+	return nil
+}
+
 // Listen for a Shutdown signal or Outbound messages.
 func (m *MessageStream) outbound() {
 	for {
@@ -93,13 +156,13 @@ func (m *MessageStream) outbound() {
 		case msg := <-m.Outbound:
 			// Forward outbound messages to conn
 			data, _ := msg.MarshalBinary()
-			if _, err := m.conn.Write(data); err != nil {
+			if err := m.writeMessage(data); err != nil {
 				log.Warnln("OutboundError:", err)
 				m.Error <- err
 				m.Shutdown <- true
 			}
 
-			log.Debugf("Sent(%d): %v", len(data), data)
+			TraceMessage(msg, data)
 		}
 	}
 }
@@ -132,6 +195,15 @@ func (m *MessageStream) inbound() {
 				hdr += 1
 				if hdr >= 4 {
 					msg = int(binary.BigEndian.Uint16(hdrBuf[2:])) - 4
+					// The message is reassembled across the reads until its length, which must cover at
+					// least the OpenFlow header.
+					if msg < 4 {
+						err = fmt.Errorf("invalid message length %d", msg+4)
+						log.Warnln("InboundError", err)
+						m.Error <- err
+						m.Shutdown <- true
+						return
+					}
 				}
 				continue
 			}
@@ -159,9 +231,14 @@ func (m *MessageStream) parse() {
 			// Log all message parsing errors.
 			if err != nil {
 				log.Errorf(errMessage, b.Bytes(), err)
+			} else if msg != nil {
+				TraceMessage(msg, b.Bytes())
 			}
 
-			m.Inbound <- msg
+			// The message consumed by a handler of the parser isn't published.
+			if _, handled := msg.(*HandledMessage); !handled {
+				m.Inbound <- msg
+			}
 			b.Reset()
 			m.pool.Empty <- b
 		case <-m.parserShutdown:
@@ -0,0 +1,151 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// bufferParser parses the messages as raw bytes.
+type bufferParser struct{}
+
+func (p bufferParser) Parse(b []byte) (Message, error) {
+	return NewBuffer(append([]byte{}, b...)), nil
+}
+
+// shortConn writes at most 3 bytes in each Write without an error.
+type shortConn struct {
+	net.Conn
+}
+
+func (c *shortConn) Write(b []byte) (int, error) {
+	if len(b) > 3 {
+		b = b[:3]
+	}
+	return c.Conn.Write(b)
+}
+
+func newTestMessage(xid byte, length int) []byte {
+	data := bytes.Repeat([]byte{xid}, length)
+	data[0], data[1], data[2], data[3] = 4, 2, 0, byte(length)
+	return data
+}
+
+func TestMessageStreamPartialWrites(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close()
+	stream := NewMessageStream(&shortConn{local}, bufferParser{})
+	defer func() { stream.Shutdown <- true }()
+
+	const senders = 8
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func(xid byte) {
+			defer wg.Done()
+			stream.Outbound <- NewBuffer(newTestMessage(xid, 8+int(xid)*4))
+		}(byte(i + 1))
+	}
+
+	length := 0
+	for i := 1; i <= senders; i++ {
+		length += 8 + i*4
+	}
+	data := make([]byte, length)
+	remote.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.ReadFull(remote, data); err != nil {
+		t.Fatalf("Failed to read messages: %v", err)
+	}
+	wg.Wait()
+	msgs, err := SplitMessages(data)
+	if err != nil {
+		t.Fatalf("Failed to split messages: %v", err)
+	}
+	if len(msgs) != senders {
+		t.Fatalf("Expect %d messages, actual: %d", senders, len(msgs))
+	}
+	for _, msg := range msgs {
+		xid := msg[4]
+		if !bytes.Equal(msg, newTestMessage(xid, 8+int(xid)*4)) {
+			t.Errorf("Message %d is interleaved: %v", xid, msg)
+		}
+	}
+	// The last message is counted after it is read.
+	stats := stream.Stats()
+	for deadline := time.Now().Add(5 * time.Second); stats.MessagesSent != senders && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		stats = stream.Stats()
+	}
+	if stats.MessagesSent != senders || stats.PartialWrites == 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if stats.OutboundCapacity != cap(stream.Outbound) || stats.InboundCapacity != cap(stream.Inbound) {
+		t.Errorf("Unexpected queue capacities: %+v", stats)
+	}
+}
+
+func TestMessageStreamSegmentedReads(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close()
+	stream := NewMessageStream(local, bufferParser{})
+	defer func() { stream.Shutdown <- true }()
+
+	msg := newTestMessage(1, 20)
+	go func() {
+		// Send the message one byte per segment.
+		for i := range msg {
+			remote.Write(msg[i : i+1])
+		}
+	}()
+	select {
+	case received := <-stream.Inbound:
+		data, _ := received.MarshalBinary()
+		if !bytes.Equal(data, msg) {
+			t.Errorf("Expect message %v, actual: %v", msg, data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("The message is not received")
+	}
+
+	// A message shorter than the OpenFlow header is an error rather than stalling the reader.
+	go remote.Write([]byte{4, 2, 0, 4})
+	select {
+	case err := <-stream.Error:
+		if err == nil {
+			t.Errorf("Expect error of invalid message length")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("The invalid message length is not reported")
+	}
+}
+
+// handlingParser consumes the messages of xid 1 and parses the others as raw bytes.
+type handlingParser struct{}
+
+func (p handlingParser) Parse(b []byte) (Message, error) {
+	if b[4] == 1 {
+		return &HandledMessage{Data: b}, nil
+	}
+	return bufferParser{}.Parse(b)
+}
+
+func TestMessageStreamDropsHandledMessages(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close()
+	stream := NewMessageStream(local, handlingParser{})
+	defer func() { stream.Shutdown <- true }()
+
+	go remote.Write(append(newTestMessage(1, 8), newTestMessage(2, 8)...))
+	select {
+	case received := <-stream.Inbound:
+		data, _ := received.MarshalBinary()
+		if !bytes.Equal(data, newTestMessage(2, 8)) {
+			t.Errorf("Expect the message after the handled one, actual: %v", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("The message is not received")
+	}
+}
@@ -0,0 +1,34 @@
+package util
+
+import (
+	"sync/atomic"
+)
+
+// MessageTracer receives the messages when they are sent or received by MessageStream, it could be
+// used to dump the messages for debugging. The message object is passed to the tracer instead of the formatted bytes, so that the cost
+// is only paid when a tracer is set.
+type MessageTracer interface {
+	// TraceMessage is called with the message and its binary data. The data must not be modified, nor retained
+	// after the call as the buffer of a received message is reused.
+	TraceMessage(msg Message, data []byte)
+}
+
+type tracerHolder struct {
+	tracer MessageTracer
+}
+
+var messageTracer atomic.Value
+
+// SetMessageTracer sets the global MessageTracer, a nil tracer disables the tracing.
+func SetMessageTracer(tracer MessageTracer) {
+	messageTracer.Store(tracerHolder{tracer: tracer})
+}
+
+// TraceMessage passes the message to the global MessageTracer if it is set.
+func TraceMessage(msg Message, data []byte) {
+	holder, ok := messageTracer.Load().(tracerHolder)
+	if !ok || holder.tracer == nil {
+		return
+	}
+	holder.tracer.TraceMessage(msg, data)
+}
@@ -0,0 +1,70 @@
+package util
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+// chanTracer sends a copy of the data of every traced message to the channel.
+type chanTracer chan []byte
+
+func (c chanTracer) TraceMessage(msg Message, data []byte) {
+	c <- append([]byte{}, data...)
+}
+
+func expectTraced(t *testing.T, tracer chanTracer, expect []byte) {
+	t.Helper()
+	select {
+	case data := <-tracer:
+		if !bytes.Equal(data, expect) {
+			t.Errorf("Expect traced message %v, actual: %v", expect, data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("The message %v is not traced", expect)
+	}
+}
+
+func TestMessageTracer(t *testing.T) {
+	tracer := make(chanTracer, 10)
+	SetMessageTracer(tracer)
+	defer SetMessageTracer(nil)
+
+	msg := newTestMessage(1, 12)
+	TraceMessage(NewBuffer(msg), msg)
+	expectTraced(t, tracer, msg)
+
+	local, remote := net.Pipe()
+	defer remote.Close()
+	stream := NewMessageStream(local, bufferParser{})
+	defer func() { stream.Shutdown <- true }()
+
+	// The sent message is traced after it is written.
+	sent := newTestMessage(2, 16)
+	stream.Outbound <- NewBuffer(sent)
+	buf := make([]byte, len(sent))
+	remote.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := remote.Read(buf); err != nil {
+		t.Fatalf("Failed to read the sent message: %v", err)
+	}
+	expectTraced(t, tracer, sent)
+
+	// The received message is traced before it is published.
+	received := newTestMessage(3, 20)
+	go remote.Write(received)
+	select {
+	case <-stream.Inbound:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("The message is not received")
+	}
+	expectTraced(t, tracer, received)
+
+	SetMessageTracer(nil)
+	TraceMessage(NewBuffer(msg), msg)
+	select {
+	case data := <-tracer:
+		t.Errorf("Expect no message traced after the tracer is unset, actual: %v", data)
+	default:
+	}
+}
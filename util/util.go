@@ -34,3 +34,24 @@ func (b *Buffer) UnmarshalBinary(data []byte) error {
 	_, err := b.Buffer.Write(data)
 	return err
 }
+
+// HandledMessage is returned by a Parser for a message which is consumed by a handler while parsing, e.g., an
+// experimenter message passed to a handler registered with openflow13.Parser, so that the callers of a Parser
+// always get either a message or an error. The callers drop it, e.g., MessageStream doesn't publish it to
+// Inbound. Data is the raw message, which shares the memory with the parsed []byte.
+type HandledMessage struct {
+	Data []byte
+}
+
+func (m *HandledMessage) Len() uint16 {
+	return uint16(len(m.Data))
+}
+
+func (m *HandledMessage) MarshalBinary() (data []byte, err error) {
+	return m.Data, nil
+}
+
+func (m *HandledMessage) UnmarshalBinary(data []byte) error {
+	m.Data = data
+	return nil
+}